@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // HorizontalRunnerAutoscalerSpec defines the desired state of HorizontalRunnerAutoscaler
@@ -25,7 +26,29 @@ type HorizontalRunnerAutoscalerSpec struct {
 	// ScaleTargetRef sis the reference to scaled resource like RunnerDeployment
 	ScaleTargetRef ScaleTargetRef `json:"scaleTargetRef,omitempty"`
 
-	// MinReplicas is the minimum number of replicas the deployment is allowed to scale
+	// Policy is either Auto, which scales the scale target to the desired replicas, or RecommendOnly,
+	// which computes the desired replicas in the same way but only records them in status.recommendedReplicas,
+	// the metrics, and the events, without ever updating the scale target.
+	// RecommendOnly is useful to compare the replicas recommended by a new configuration with the actual ones before letting it take control.
+	// Defaults to Auto.
+	// +optional
+	// +kubebuilder:validation:Enum=Auto;RecommendOnly
+	Policy string `json:"policy,omitempty"`
+
+	// GitHubAPICredentialsFrom makes the autoscaler query the GitHub API with the credentials stored in the referenced Secret.
+	// Defaults to the githubAPICredentialsFrom of the scale target, or the controller-wide credentials.
+	// +optional
+	GitHubAPICredentialsFrom *GitHubAPICredentialsFrom `json:"githubAPICredentialsFrom,omitempty"`
+
+	// SyncPeriod is the interval at which the autoscaler is synced, overriding the controller's --sync-period,
+	// like 15s for a critical runner pool or 10m for a low-priority one. Each sync is delayed by a random jitter of up to 10%,
+	// so that the autoscalers don't query the GitHub API at the same instant.
+	// The syncs triggered by the controller-wide resyncs are skipped until the period passes, unless the spec is updated.
+	// +optional
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// MinReplicas is the minimum number of replicas the deployment is allowed to scale.
+	// Defaults to 1 by the admission webhook.
 	// +optional
 	MinReplicas *int `json:"minReplicas,omitempty"`
 
@@ -35,13 +58,93 @@ type HorizontalRunnerAutoscalerSpec struct {
 
 	// ScaleDownDelaySecondsAfterScaleUp is the approximate delay for a scale down followed by a scale up
 	// Used to prevent flapping (down->up->down->... loop)
+	// It overrides the default scale down delay of the controller, and can be overridden per metric
+	// by MetricSpec.ScaleDownDelaySecondsAfterScaleOut.
 	// +optional
 	ScaleDownDelaySecondsAfterScaleUp *int `json:"scaleDownDelaySecondsAfterScaleOut,omitempty"`
 
+	// ScaleDownStabilizationWindowSeconds is the duration for which the past desired replicas are considered on scale down,
+	// like the stabilization window of HorizontalPodAutoscaler.
+	// The desired replicas are never decreased below the highest replicas recommended by the metrics within the window,
+	// so that a brief drop of the demand doesn't scale the runners down.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ScaleDownStabilizationWindowSeconds *int `json:"scaleDownStabilizationWindowSeconds,omitempty"`
+
+	// ScaleDownMaxStep is the maximum number of replicas removed on each scale down, so that
+	// the replicas decrease gradually instead of dropping from maxReplicas to minReplicas at once.
+	// It is either an absolute number like 2, or a percentage of the current desired replicas like "25%", which is rounded up.
+	// At least one replica is removed on each scale down.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	ScaleDownMaxStep *intstr.IntOrString `json:"scaleDownMaxStep,omitempty"`
+
+	// ScaleUpMaxStep is the maximum number of replicas added on each scale up, so that
+	// a burst of queued jobs or webhook events doesn't create all the runner pods at once and exhaust the node capacity.
+	// It is either an absolute number like 5, or a percentage of the current desired replicas like "50%", which is rounded up.
+	// At least one replica is added on each scale up. It limits the replicas reserved by ScaleUpTriggers too.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	ScaleUpMaxStep *intstr.IntOrString `json:"scaleUpMaxStep,omitempty"`
+
+	// ScaleUpPeriodSeconds is the minimum interval between two scale ups limited by ScaleUpMaxStep.
+	// Defaults to 30.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ScaleUpPeriodSeconds *int `json:"scaleUpPeriodSeconds,omitempty"`
+
+	// PickupConfirmationTimeoutSeconds enables delaying scale down until job pickup is confirmed.
+	// When set, the replicas added on a scale up driven by the TotalNumberOfQueuedAndInProgressWorkflowRuns metric
+	// are not scaled down until a job is observed running on each of them, or this timeout passes.
+	// It prevents a poll racing with the runners, e.g. one that no longer sees the jobs as queued, from removing
+	// the added runners right before they pick up the jobs.
+	// +optional
+	PickupConfirmationTimeoutSeconds *int `json:"pickupConfirmationTimeoutSeconds,omitempty"`
+
+	// ScalingHistoryLimit is the number of the latest scaling decisions kept in status.scalingHistory.
+	// Older decisions are pruned. Defaults to 10. Set it to 0 to disable the history.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ScalingHistoryLimit *int `json:"scalingHistoryLimit,omitempty"`
+
+	// MaxUnschedulableReplicas enables pausing scale up while the cluster is short of nodes.
+	// When set, the desired replicas are not increased while the number of runner pods that are pending
+	// because they are unschedulable is greater than this, so that the autoscaler doesn't keep adding pods
+	// the cluster autoscaler hasn't provisioned nodes for yet.
+	// Set it to 0 to pause scale up whenever there's any unschedulable runner pod.
+	// +optional
+	MaxUnschedulableReplicas *int `json:"maxUnschedulableReplicas,omitempty"`
+
+	// Priority is the weight of the HorizontalRunnerAutoscaler in the fair-share allocation of the RunnerBudgets
+	// that apply to it. A HorizontalRunnerAutoscaler with the priority of 2 is granted twice as many replicas as
+	// one with the priority of 1 when their combined desired replicas exceed a budget.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Priority *int `json:"priority,omitempty"`
+
+	// Overflow configures what the controller does while the demand for runners exceeds MaxReplicas,
+	// in addition to setting the CapacitySaturated condition and status.shortfallReplicas.
+	// +optional
+	Overflow *HorizontalRunnerAutoscalerOverflow `json:"overflow,omitempty"`
+
+	// DependencyHealthChecks caps the desired replicas while any of the services the jobs depend on,
+	// like an artifact store or a license server, is degraded, so that the runners don't stampede a struggling service.
+	// The cap is lifted automatically once the dependency recovers.
+	// +optional
+	DependencyHealthChecks []DependencyHealthCheck `json:"dependencyHealthChecks,omitempty"`
+
 	// Metrics is the collection of various metric targets to calculate desired number of runners
 	// +optional
 	Metrics []MetricSpec `json:"metrics,omitempty"`
 
+	// MetricsCombinationPolicy makes the autoscaler evaluate all the Metrics and combine the desired replicas suggested by them,
+	// by taking the Max, Min, Sum, or Average of them. Average is rounded up.
+	// When omitted, Metrics can have up to 2 entries, and the second one is used only when the first one suggests no replicas.
+	// +optional
+	// +kubebuilder:validation:Enum=Max;Min;Sum;Average
+	MetricsCombinationPolicy string `json:"metricsCombinationPolicy,omitempty"`
+
 	// ScaleUpTriggers is an experimental feature to increase the desired replicas by 1
 	// on each webhook requested received by the webhookBasedAutoscaler.
 	//
@@ -53,6 +156,10 @@ type HorizontalRunnerAutoscalerSpec struct {
 	// receive a webhook from GitHub, so that you can loosely expect MinReplicas runners to be always available.
 	ScaleUpTriggers []ScaleUpTrigger `json:"scaleUpTriggers,omitempty"`
 
+	// CapacityReservations is the list of the replicas temporarily added on top of the desired replicas computed by the metrics.
+	// The webhookBasedAutoscaler adds them on ScaleUpTriggers, and you can add them yourself, for example before a release train,
+	// to bump the capacity without editing MinReplicas. Expired reservations no longer add replicas.
+	// +optional
 	CapacityReservations []CapacityReservation `json:"capacityReservations,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
 
 	// ScheduledOverrides is the list of ScheduledOverride.
@@ -62,10 +169,154 @@ type HorizontalRunnerAutoscalerSpec struct {
 	ScheduledOverrides []ScheduledOverride `json:"scheduledOverrides,omitempty"`
 }
 
+// HorizontalRunnerAutoscalerOverflow configures the actions taken while the demand for runners,
+// which is the replicas suggested by the metrics plus the capacity reservations, exceeds MaxReplicas.
+type HorizontalRunnerAutoscalerOverflow struct {
+	// WebhookURL is the URL the controller POSTs a JSON notification to when the demand starts exceeding
+	// MaxReplicas, and when it stops exceeding it.
+	// +optional
+	WebhookURL string `json:"webhookURL,omitempty"`
+
+	// BorrowFrom lets the HorizontalRunnerAutoscaler temporarily borrow the unused replicas of another
+	// HorizontalRunnerAutoscaler with a lower priority while the demand exceeds MaxReplicas.
+	// +optional
+	BorrowFrom *OverflowBorrow `json:"borrowFrom,omitempty"`
+
+	// Federation delegates the shortfall replicas, which are demanded beyond MaxReplicas and the borrowable replicas,
+	// to RunnerDeployments in other clusters, like in another region, so that a burst exceeding the capacity of this cluster spills into them.
+	// The shortfall is delegated to the members in order, each up to its MaxReplicas.
+	// This is experimental and requires the controller to run with --enable-federation.
+	// +optional
+	Federation []FederationMember `json:"federation,omitempty"`
+
+	// Sinks is the list of the destinations the shortfall replicas are published to while the demand exceeds MaxReplicas,
+	// so that a secondary cluster or a cloud burst pool can absorb the spillover.
+	// Zero replicas are published once the demand drops, so that the spillover is withdrawn.
+	// +optional
+	Sinks []OverflowSink `json:"sinks,omitempty"`
+
+	// PublishAfterSeconds is how long the CapacitySaturated condition must have been True
+	// before the shortfall replicas are published to the Sinks, so that a short burst doesn't spill over.
+	// Defaults to 0, which publishes the shortfall replicas right away.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	PublishAfterSeconds *int `json:"publishAfterSeconds,omitempty"`
+}
+
+// OverflowSink is a destination of the shortfall replicas of a HorizontalRunnerAutoscaler.
+// Exactly one of ConfigMap, WebhookURL and HorizontalRunnerAutoscaler must be set.
+type OverflowSink struct {
+	// Name identifies the sink in the events of the HorizontalRunnerAutoscaler.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ConfigMap is the name of the ConfigMap in the namespace of the HorizontalRunnerAutoscaler whose key named after
+	// the HorizontalRunnerAutoscaler is set to the JSON of the OverflowNotification. The ConfigMap is created when missing.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// WebhookURL is the URL the controller POSTs the JSON of the OverflowNotification to whenever the published shortfall replicas change.
+	// +optional
+	WebhookURL string `json:"webhookURL,omitempty"`
+
+	// HorizontalRunnerAutoscaler is the HorizontalRunnerAutoscaler in another cluster whose capacity reservation is set to the shortfall replicas.
+	// +optional
+	HorizontalRunnerAutoscaler *OverflowHorizontalRunnerAutoscalerSink `json:"horizontalRunnerAutoscaler,omitempty"`
+}
+
+// OverflowHorizontalRunnerAutoscalerSink is a HorizontalRunnerAutoscaler in another cluster that absorbs the shortfall replicas
+// via one of its CapacityReservations, which is renewed while the shortfall lasts and expires in case the publisher is gone.
+// This is experimental and requires the controller to run with --enable-federation.
+type OverflowHorizontalRunnerAutoscalerSink struct {
+	// KubeconfigSecretRef references the Secret in the namespace of the HorizontalRunnerAutoscaler
+	// that contains the kubeconfig of the other cluster under the `kubeconfig` key.
+	KubeconfigSecretRef SecretReference `json:"kubeconfigSecretRef"`
+
+	// Namespace is the namespace of the HorizontalRunnerAutoscaler in the other cluster.
+	// Defaults to the namespace of the publishing HorizontalRunnerAutoscaler.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the HorizontalRunnerAutoscaler in the other cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ReservationName is the name of the capacity reservation.
+	// Defaults to `overflow-NAMESPACE-NAME` after the publishing HorizontalRunnerAutoscaler.
+	// Set it when the HorizontalRunnerAutoscalers of the same namespace and name in multiple clusters publish to the same one.
+	// +optional
+	ReservationName string `json:"reservationName,omitempty"`
+}
+
+// FederationMember is a RunnerDeployment in another cluster whose replicas are set to the replicas delegated to it.
+// The RunnerDeployment must not be scaled by anything else, like a HorizontalRunnerAutoscaler in its cluster.
+type FederationMember struct {
+	// Name identifies the member in HorizontalRunnerAutoscalerStatus.DelegatedReplicas.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// KubeconfigSecretRef references the Secret in the namespace of the HorizontalRunnerAutoscaler
+	// that contains the kubeconfig of the member cluster under the `kubeconfig` key.
+	KubeconfigSecretRef SecretReference `json:"kubeconfigSecretRef"`
+
+	// Namespace is the namespace of the RunnerDeployment in the member cluster.
+	// Defaults to the namespace of the HorizontalRunnerAutoscaler.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// RunnerDeployment is the name of the RunnerDeployment in the member cluster.
+	// +kubebuilder:validation:MinLength=1
+	RunnerDeployment string `json:"runnerDeployment"`
+
+	// MaxReplicas is the maximum number of replicas delegated to the member at a time.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int `json:"maxReplicas"`
+}
+
+// OverflowBorrow names the HorizontalRunnerAutoscaler to borrow replicas from.
+// The lender's MaxReplicas is lowered by the borrowed replicas until they are returned,
+// which happens as soon as the borrower's demand drops.
+type OverflowBorrow struct {
+	// Name is the name of the lender HorizontalRunnerAutoscaler in the same namespace.
+	// Its priority must be lower than the borrower's.
+	Name string `json:"name"`
+
+	// MaxReplicas is the maximum number of replicas borrowed at a time.
+	// Defaults to all the replicas the lender isn't using.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas *int `json:"maxReplicas,omitempty"`
+}
+
+// DelegatedReplicas is the replicas delegated to a federation member.
+type DelegatedReplicas struct {
+	// Member is the name of the federation member.
+	Member string `json:"member"`
+
+	// Replicas is the replicas of the RunnerDeployment of the member set by the controller.
+	Replicas int `json:"replicas"`
+
+	// LastScaleUpTime is the last time the replicas were increased.
+	// The replicas are decreased only after the scale down delay of the HorizontalRunnerAutoscaler since then.
+	// +optional
+	// +nullable
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty"`
+
+	// Message tells why the replicas couldn't be delegated to the member on the last reconciliation, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 type ScaleUpTrigger struct {
 	GitHubEvent *GitHubEventScaleUpTriggerSpec `json:"githubEvent,omitempty"`
-	Amount      int                            `json:"amount,omitempty"`
-	Duration    metav1.Duration                `json:"duration,omitempty"`
+
+	// Amount is the number of replicas reserved on each matching checkRun, pullRequest or push event. Defaults to 1.
+	// A workflowJob event always reserves one replica per job.
+	// HorizontalRunnerAutoscalerSpec.ScaleUpMaxStep limits how fast the reserved replicas are added.
+	Amount int `json:"amount,omitempty"`
+
+	// Duration is how long the replicas are reserved for. Defaults to 10m.
+	Duration metav1.Duration `json:"duration,omitempty"`
 }
 
 type GitHubEventScaleUpTriggerSpec struct {
@@ -94,6 +345,11 @@ type CheckRunSpec struct {
 
 // https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads#workflow_job
 type WorkflowJobSpec struct {
+	// Workflows is a list of GitHub Actions glob patterns.
+	// Only the workflow_job events of the workflows whose names or paths, like `.github/workflows/deploy-*.yml`,
+	// match one of the patterns can trigger autoscaling. The jobs of the reusable workflows are matched by their caller workflows.
+	// +optional
+	Workflows []string `json:"workflows,omitempty"`
 }
 
 // https://docs.github.com/en/actions/reference/events-that-trigger-workflows#pull_request
@@ -107,6 +363,60 @@ type PullRequestSpec struct {
 type PushSpec struct {
 }
 
+// DependencyHealthCheck checks the health of a service the jobs depend on with either HTTPGet or Condition.
+type DependencyHealthCheck struct {
+	// Name identifies the dependency in the status and the events.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// MaxReplicas is the maximum number of replicas while the dependency is degraded.
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas int `json:"maxReplicas"`
+
+	// HTTPGet makes the dependency degraded while a GET request to the URL fails or responds with a status other than 2xx or 3xx.
+	// +optional
+	HTTPGet *DependencyHTTPGetCheck `json:"httpGet,omitempty"`
+
+	// Condition makes the dependency degraded while the condition of an object in the namespace of the
+	// HorizontalRunnerAutoscaler isn't True, e.g. the Available condition of the Deployment of the dependency.
+	// +optional
+	Condition *DependencyConditionCheck `json:"condition,omitempty"`
+}
+
+// DependencyHTTPGetCheck probes the health endpoint of a dependency.
+type DependencyHTTPGetCheck struct {
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// TimeoutSeconds is the timeout of the request. Defaults to 5.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int `json:"timeoutSeconds,omitempty"`
+}
+
+// DependencyConditionCheck references a condition in status.conditions of an object.
+// The controller needs the permission to get the object.
+type DependencyConditionCheck struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+
+	// Type is the type of the condition, like Available or Ready.
+	Type string `json:"type"`
+}
+
+// DegradedDependency is a dependency found degraded by the DependencyHealthCheck of the same name.
+type DegradedDependency struct {
+	Name string `json:"name"`
+
+	// Message describes why the dependency is considered degraded.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Since is when the dependency was found degraded.
+	Since metav1.Time `json:"since"`
+}
+
 // CapacityReservation specifies the number of replicas temporarily added
 // to the scale target until ExpirationTime.
 type CapacityReservation struct {
@@ -116,6 +426,20 @@ type CapacityReservation struct {
 
 	// +optional
 	EffectiveTime metav1.Time `json:"effectiveTime,omitempty"`
+
+	// Size is the size label requested by the workflow job the capacity is reserved for,
+	// when the scale target advertises multiple sizes.
+	// +optional
+	Size string `json:"size,omitempty"`
+}
+
+// CapacityReservationRunner is a runner linked to a CapacityReservation.
+type CapacityReservationRunner struct {
+	// Name is the name of the CapacityReservation.
+	Name string `json:"name"`
+
+	// Runner is the name of the runner, which is also the name of its pod.
+	Runner string `json:"runner"`
 }
 
 type ScaleTargetRef struct {
@@ -130,14 +454,85 @@ type ScaleTargetRef struct {
 
 type MetricSpec struct {
 	// Type is the type of metric to be used for autoscaling.
-	// The only supported Type is TotalNumberOfQueuedAndInProgressWorkflowRuns
+	// It can be TotalNumberOfQueuedAndInProgressWorkflowRuns, PercentageRunnersBusy, QueuedJobsPlusBusyRunners, QueuedJobWaitTime,
+	// QueuedJobsPerRunner, or External.
 	Type string `json:"type,omitempty"`
 
+	// Suspended excludes the metric from the autoscaling without removing it, e.g. to compare metrics while tuning the HRA.
+	// The remaining metrics are used as if the suspended one weren't there, and minReplicas is desired when all the metrics are suspended.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
 	// RepositoryNames is the list of repository names to be used for calculating the metric.
 	// For example, a repository name is the REPO part of `github.com/USER/REPO`.
+	// For enterprise runners, it must be the USER/REPO part instead, as the repositories can belong to any organization in the enterprise.
 	// +optional
 	RepositoryNames []string `json:"repositoryNames,omitempty"`
 
+	// RepositorySelector discovers the repositories of the organization whose workflow jobs are counted in addition to RepositoryNames,
+	// so that the repositories added to the organization are counted without updating the HorizontalRunnerAutoscaler.
+	// The repositories are listed via the GitHub API and cached for a few minutes.
+	// It's supported only for organizational runners.
+	// +optional
+	RepositorySelector *RepositorySelector `json:"repositorySelector,omitempty"`
+
+	// RepositoryWeights is the number of runners demanded per workflow job of the repository, keyed by the entries of RepositoryNames
+	// or the names of the repositories selected by RepositorySelector, so that e.g. the jobs of a heavyweight build repository count double.
+	// Each weight is a float64 formatted as a string, and defaults to 1.
+	// +optional
+	RepositoryWeights map[string]string `json:"repositoryWeights,omitempty"`
+
+	// Workflows is a list of GitHub Actions glob patterns.
+	// The TotalNumberOfQueuedAndInProgressWorkflowRuns, QueuedJobsPlusBusyRunners, QueuedJobWaitTime and QueuedJobsPerRunner metrics count only the workflow runs
+	// whose workflow names or paths, like `.github/workflows/deploy-*.yml`, match one of the patterns.
+	// The jobs of the reusable workflows are matched by their caller workflows.
+	// +optional
+	Workflows []string `json:"workflows,omitempty"`
+
+	// Branches is a list of GitHub Actions glob patterns, like `main` and `release/*`.
+	// The same metrics as Workflows count only the workflow runs whose head branches match one of the patterns.
+	// When both are set, the workflow runs need to match both.
+	// +optional
+	Branches []string `json:"branches,omitempty"`
+
+	// ConcurrencyAware makes the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count only the jobs
+	// GitHub can actually run in parallel.
+	// The `strategy.max-parallel` of matrix jobs and the job-level `concurrency` groups are read from
+	// the workflow definitions at the commits of the workflow runs, and the queued jobs exceeding them aren't counted.
+	// It costs a few extra GitHub API calls per workflow definition, which are cached.
+	// +optional
+	ConcurrencyAware bool `json:"concurrencyAware,omitempty"`
+
+	// IncludeWaitingWorkflowRuns makes the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count also the workflow runs and jobs
+	// waiting for the approvals of their deployment environments or pending on their concurrency groups, as if they were queued,
+	// so that the runners are ready by the time the deployment gates clear.
+	// It costs two extra GitHub API calls per repository, which are cached.
+	// +optional
+	IncludeWaitingWorkflowRuns bool `json:"includeWaitingWorkflowRuns,omitempty"`
+
+	// WaitingJobsWeight is the number of runners the TotalNumberOfQueuedAndInProgressWorkflowRuns metric demands
+	// per workflow job or run waiting for its deployment gates, which are counted only when includeWaitingWorkflowRuns is true.
+	// It is a float64 formatted as a string, and defaults to 1.
+	// Set it to e.g. 0.25 to have a runner ready for every four jobs pending approvals.
+	// +optional
+	WaitingJobsWeight string `json:"waitingJobsWeight,omitempty"`
+
+	// QueuedJobsWeight is the number of runners the QueuedJobsPlusBusyRunners metric demands per queued workflow job.
+	// It is a float64 formatted as a string, and defaults to 1.
+	// +optional
+	QueuedJobsWeight string `json:"queuedJobsWeight,omitempty"`
+
+	// BusyRunnersWeight is the number of runners the QueuedJobsPlusBusyRunners metric demands per busy runner.
+	// It is a float64 formatted as a string, and defaults to 1.
+	// +optional
+	BusyRunnersWeight string `json:"busyRunnersWeight,omitempty"`
+
+	// TargetQueuedJobsPerRunner is the number of the queued workflow jobs the QueuedJobsPerRunner metric leaves to each runner
+	// that isn't running a job, so that a pool of many short jobs can run hotter than one runner per job.
+	// It is a float64 formatted as a string greater than 0, and defaults to 1.
+	// +optional
+	TargetQueuedJobsPerRunner string `json:"targetQueuedJobsPerRunner,omitempty"`
+
 	// ScaleUpThreshold is the percentage of busy runners greater than which will
 	// trigger the hpa to scale runners up.
 	// +optional
@@ -145,6 +540,7 @@ type MetricSpec struct {
 
 	// ScaleDownThreshold is the percentage of busy runners less than which will
 	// trigger the hpa to scale the runners down.
+	// The QueuedJobsPlusBusyRunners metric uses it along with ScaleDownFactor and ScaleDownAdjustment to scale down.
 	// +optional
 	ScaleDownThreshold string `json:"scaleDownThreshold,omitempty"`
 
@@ -167,16 +563,68 @@ type MetricSpec struct {
 	// You can only specify either ScaleDownFactor or ScaleDownAdjustment.
 	// +optional
 	ScaleDownAdjustment int `json:"scaleDownAdjustment,omitempty"`
+
+	// ScaleUpWaitTime is the time the oldest queued workflow job has been waiting for a runner
+	// beyond which the QueuedJobWaitTime metric scales out by ScaleUpFactor or ScaleUpAdjustment. Defaults to 1m.
+	// +optional
+	ScaleUpWaitTime *metav1.Duration `json:"scaleUpWaitTime,omitempty"`
+
+	// ScaleDownWaitTime is the time the oldest queued workflow job has been waiting for a runner
+	// at or below which the QueuedJobWaitTime metric scales in by ScaleDownFactor or ScaleDownAdjustment. Defaults to 10s.
+	// +optional
+	ScaleDownWaitTime *metav1.Duration `json:"scaleDownWaitTime,omitempty"`
+
+	// ScaleDownDelaySecondsAfterScaleOut overrides HorizontalRunnerAutoscalerSpec.ScaleDownDelaySecondsAfterScaleUp
+	// while this metric determines the desired replicas.
+	// It's useful when e.g. the fallback metric should scale down sooner or later than the primary metric.
+	// +optional
+	ScaleDownDelaySecondsAfterScaleOut *int `json:"scaleDownDelaySecondsAfterScaleOut,omitempty"`
+
+	// External is the configuration of the External metric type.
+	// Required when Type is External.
+	// +optional
+	External *ExternalMetricSpec `json:"external,omitempty"`
+}
+
+// ExternalMetricSpec delegates the computation of the desired replicas to an external metric provider.
+type ExternalMetricSpec struct {
+	// Provider is the name of the metric provider registered to the controller
+	// via the `--metric-provider NAME=COMMAND_OR_URL` flag.
+	Provider string `json:"provider"`
+
+	// Params is the arbitrary key-value pairs passed as-is to the metric provider.
+	// +optional
+	Params map[string]string `json:"params,omitempty"`
 }
 
 // ScheduledOverride can be used to override a few fields of HorizontalRunnerAutoscalerSpec on schedule.
 // A schedule can optionally be recurring, so that the correspoding override happens every day, week, month, or year.
+// Alternatively, a schedule can be written as a cron expression along with the duration of each override.
 type ScheduledOverride struct {
 	// StartTime is the time at which the first override starts.
-	StartTime metav1.Time `json:"startTime"`
+	// Required unless Schedule is set.
+	// +optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
 
 	// EndTime is the time at which the first override ends.
-	EndTime metav1.Time `json:"endTime"`
+	// Required unless Schedule is set.
+	// +optional
+	EndTime metav1.Time `json:"endTime,omitempty"`
+
+	// Schedule is the standard 5-field cron expression, like "0 9 * * 1-5", denoting the times at which overrides start.
+	// Each override lasts for Duration.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Duration is the duration of each override started by Schedule.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+
+	// TimeZone is the name of the IANA time zone, like "America/New_York", in which Schedule is evaluated and
+	// StartTime and EndTime recur, so that overrides keep happening at the same local time across DST transitions.
+	// Defaults to UTC for Schedule, and to the offsets of StartTime and EndTime otherwise.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 
 	// MinReplicas is the number of runners while overriding.
 	// If omitted, it doesn't override minReplicas.
@@ -185,6 +633,13 @@ type ScheduledOverride struct {
 	// +kubebuilder:validation:Minimum=0
 	MinReplicas *int `json:"minReplicas,omitempty"`
 
+	// MaxReplicas is the maximum number of runners while overriding.
+	// If omitted, it doesn't override maxReplicas.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas *int `json:"maxReplicas,omitempty"`
+
 	// +optional
 	RecurrenceRule RecurrenceRule `json:"recurrenceRule,omitempty"`
 }
@@ -211,9 +666,14 @@ type HorizontalRunnerAutoscalerStatus struct {
 
 	// DesiredReplicas is the total number of desired, non-terminated and latest pods to be set for the primary RunnerSet
 	// This doesn't include outdated pods while upgrading the deployment and replacing the runnerset.
+	// It follows the replicas of the scale target while Policy is RecommendOnly.
 	// +optional
 	DesiredReplicas *int `json:"desiredReplicas,omitempty"`
 
+	// RecommendedReplicas is the desired replicas computed while Policy is RecommendOnly, which isn't applied to the scale target.
+	// +optional
+	RecommendedReplicas *int `json:"recommendedReplicas,omitempty"`
+
 	// +optional
 	// +nullable
 	LastSuccessfulScaleOutTime *metav1.Time `json:"lastSuccessfulScaleOutTime,omitempty"`
@@ -225,6 +685,252 @@ type HorizontalRunnerAutoscalerStatus struct {
 	// for observability.
 	// +optional
 	ScheduledOverridesSummary *string `json:"scheduledOverridesSummary,omitempty"`
+
+	// ScheduledOverrides is the list of the active and next periods of the ScheduledOverrides computed by the controller,
+	// along with the problems found by simulating them over the next two weeks, like shifts due to DST transitions.
+	// +optional
+	ScheduledOverrides []ScheduledOverrideStatus `json:"scheduledOverrides,omitempty"`
+
+	// Metrics is the status of each of HorizontalRunnerAutoscalerSpec.Metrics, which tells the metrics used for the autoscaling
+	// apart from the suspended ones.
+	// +optional
+	Metrics []MetricStatus `json:"metrics,omitempty"`
+
+	// UnschedulableReplicas is the number of runner pods of the scale target that are pending
+	// because they are unschedulable, e.g. while the cluster autoscaler is provisioning nodes for them.
+	// +optional
+	UnschedulableReplicas *int `json:"unschedulableReplicas,omitempty"`
+
+	// RequestedReplicas is the desired replicas computed before the RunnerBudgets are applied.
+	// It's set only when one or more RunnerBudgets apply to the HorizontalRunnerAutoscaler,
+	// and used to allocate the budgets among the HorizontalRunnerAutoscalers.
+	// +optional
+	RequestedReplicas *int `json:"requestedReplicas,omitempty"`
+
+	// GrantedReplicas is the share of the RunnerBudgets granted to the HorizontalRunnerAutoscaler.
+	// The desired replicas never exceeds it.
+	// It's set only when one or more RunnerBudgets apply to the HorizontalRunnerAutoscaler.
+	// +optional
+	GrantedReplicas *int `json:"grantedReplicas,omitempty"`
+
+	// ShortfallReplicas is the number of replicas demanded by the metrics and the capacity reservations
+	// beyond MaxReplicas and the replicas that can be borrowed. See the CapacitySaturated condition.
+	// +optional
+	ShortfallReplicas *int `json:"shortfallReplicas,omitempty"`
+
+	// BorrowedReplicas is the number of replicas borrowed from the HorizontalRunnerAutoscaler named by
+	// HorizontalRunnerAutoscalerSpec.Overflow.BorrowFrom, whose MaxReplicas is lowered by them.
+	// +optional
+	BorrowedReplicas *int `json:"borrowedReplicas,omitempty"`
+
+	// DelegatedReplicas is the replicas delegated to the members of HorizontalRunnerAutoscalerOverflow.Federation.
+	// +optional
+	DelegatedReplicas []DelegatedReplicas `json:"delegatedReplicas,omitempty"`
+
+	// PublishedShortfallReplicas is the shortfall replicas last published to all the HorizontalRunnerAutoscalerOverflow.Sinks.
+	// +optional
+	PublishedShortfallReplicas *int `json:"publishedShortfallReplicas,omitempty"`
+
+	// PickupReservations is the list of the replicas added on scale ups whose job pickup is not confirmed yet.
+	// See HorizontalRunnerAutoscalerSpec.PickupConfirmationTimeoutSeconds.
+	// +optional
+	PickupReservations []PickupReservation `json:"pickupReservations,omitempty"`
+
+	// ReservedReplicas is the number of replicas added by the unexpired CapacityReservations.
+	// It's unset when there's no unexpired CapacityReservation.
+	// +optional
+	ReservedReplicas *int `json:"reservedReplicas,omitempty"`
+
+	// CapacityReservationsExpirationTime is when the earliest of the unexpired CapacityReservations expires.
+	// +optional
+	// +nullable
+	CapacityReservationsExpirationTime *metav1.Time `json:"capacityReservationsExpirationTime,omitempty"`
+
+	// CapacityReservationRunners is the runners created for the unexpired CapacityReservations, or running their jobs,
+	// as linked by the capacity reservation annotation of the runners.
+	// +optional
+	CapacityReservationRunners []CapacityReservationRunner `json:"capacityReservationRunners,omitempty"`
+
+	// ScaleDownRecommendations is the list of the past replicas recommended by the metrics within
+	// HorizontalRunnerAutoscalerSpec.ScaleDownStabilizationWindowSeconds, oldest first.
+	// Only the recommendations that may still be the highest in the window are kept.
+	// +optional
+	ScaleDownRecommendations []ReplicaRecommendation `json:"scaleDownRecommendations,omitempty"`
+
+	// DegradedDependencies is the list of the dependencies found degraded by the DependencyHealthChecks.
+	// The desired replicas are capped while it's not empty.
+	// +optional
+	DegradedDependencies []DegradedDependency `json:"degradedDependencies,omitempty"`
+
+	// LastAPIError is the last transient error of the GitHub API calls made to compute the desired replicas,
+	// while the desired replicas are kept as is. It's cleared once the desired replicas are computed again.
+	// +optional
+	LastAPIError *GitHubAPIError `json:"lastAPIError,omitempty"`
+
+	// Conditions is the list of the latest observations of the HorizontalRunnerAutoscaler,
+	// which are ScalingActive, AbleToScale, LimitedByMaxReplicas and CapacitySaturated.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ScalingHistory is the list of the latest changes of the desired replicas, oldest first.
+	// See HorizontalRunnerAutoscalerSpec.ScalingHistoryLimit.
+	// +optional
+	ScalingHistory []ScalingDecision `json:"scalingHistory,omitempty"`
+}
+
+// GitHubAPIError is a transient error of the GitHub API calls made by a HorizontalRunnerAutoscaler, like a 5xx response.
+type GitHubAPIError struct {
+	// Message is the error message.
+	Message string `json:"message"`
+
+	// Time is when the error occurred.
+	Time metav1.Time `json:"time"`
+
+	// ConsecutiveFailures is the number of the consecutive reconciliations that failed with transient errors
+	// after retrying the GitHub API calls.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+
+	// CircuitOpenUntil is when the HorizontalRunnerAutoscaler resumes calling the GitHub API,
+	// set once the consecutive failures reach the threshold of the circuit breaker.
+	// +optional
+	// +nullable
+	CircuitOpenUntil *metav1.Time `json:"circuitOpenUntil,omitempty"`
+}
+
+// ScheduledOverrideStatus is the status of the ScheduledOverride at Index in HorizontalRunnerAutoscalerSpec.ScheduledOverrides.
+// The times are formatted in RFC3339 in the time zone of the ScheduledOverride, so that the local times can be checked at a glance.
+type ScheduledOverrideStatus struct {
+	Index int `json:"index"`
+
+	// ActiveUntil is the end time of the active override, if any.
+	// +optional
+	ActiveUntil string `json:"activeUntil,omitempty"`
+
+	// NextStartTime and NextEndTime are the times at which the next override starts and ends, if any.
+	// +optional
+	NextStartTime string `json:"nextStartTime,omitempty"`
+	// +optional
+	NextEndTime string `json:"nextEndTime,omitempty"`
+
+	// Warnings are the problems found by simulating the ScheduledOverride, like an override starting at
+	// a different local time due to a DST transition, or overlapping with a higher priority one.
+	// +optional
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RepositorySelector selects the repositories of the organization that match all of the given filters.
+// The archived and disabled repositories are never selected.
+type RepositorySelector struct {
+	// Topics selects the repositories that have any of the topics.
+	// +optional
+	Topics []string `json:"topics,omitempty"`
+
+	// NamePattern is the regular expression the names of the repositories must match, like `^service-`.
+	// +optional
+	NamePattern string `json:"namePattern,omitempty"`
+
+	// Visibility selects the repositories of the visibility.
+	// +optional
+	// +kubebuilder:validation:Enum=public;private;internal
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// MetricStatus is the status of the metric at Index in HorizontalRunnerAutoscalerSpec.Metrics.
+type MetricStatus struct {
+	Index int `json:"index"`
+
+	Type string `json:"type"`
+
+	// Active is true when the metric is used for the autoscaling, and false while it's suspended.
+	Active bool `json:"active"`
+
+	// Repositories is the number of the workflow jobs of each of MetricSpec.RepositoryNames and the repositories selected by
+	// MetricSpec.RepositorySelector counted in the last reconciliation, before MetricSpec.RepositoryWeights are applied.
+	// The repositories the runner group has no access to aren't listed.
+	// +optional
+	Repositories []RepositoryWorkflowJobs `json:"repositories,omitempty"`
+
+	// QueuedJobsPerRunner is the number of the queued workflow jobs per desired runner that isn't running a job,
+	// after the desired replicas are clamped to the min and max replicas, for the QueuedJobsPerRunner metric.
+	// It is a float64 formatted as a string, and is omitted when there's no queued job or no runner left for them.
+	// +optional
+	QueuedJobsPerRunner string `json:"queuedJobsPerRunner,omitempty"`
+}
+
+// RepositoryWorkflowJobs is the number of the queued and in-progress workflow jobs of a repository counted by a metric.
+type RepositoryWorkflowJobs struct {
+	// Name is the entry of MetricSpec.RepositoryNames, or the name of the repository selected by MetricSpec.RepositorySelector.
+	Name string `json:"name"`
+
+	// Weight is the weight of the repository, if any. See MetricSpec.RepositoryWeights.
+	// +optional
+	Weight string `json:"weight,omitempty"`
+
+	Queued int `json:"queued"`
+
+	InProgress int `json:"inProgress"`
+}
+
+// ScalingDecision is a change of the desired replicas made by the HorizontalRunnerAutoscaler.
+type ScalingDecision struct {
+	Time metav1.Time `json:"time"`
+
+	From int `json:"from"`
+
+	To int `json:"to"`
+
+	// Reason is what determined the desired replicas, like the metric type, MinReplicas, or MaxReplicas.
+	Reason string `json:"reason"`
+
+	// Observed is the numbers observed in the reconciliation that made the decision.
+	// +optional
+	Observed *ScalingObservation `json:"observed,omitempty"`
+}
+
+// ScalingObservation is the numbers that fed a scaling decision.
+// The numbers not needed by the metrics of the HorizontalRunnerAutoscaler are omitted.
+type ScalingObservation struct {
+	// QueuedWorkflowJobs is the number of the queued workflow jobs counted by the metrics.
+	// +optional
+	QueuedWorkflowJobs *int `json:"queuedWorkflowJobs,omitempty"`
+
+	// InProgressWorkflowJobs is the number of the in-progress workflow jobs counted by the metrics.
+	// +optional
+	InProgressWorkflowJobs *int `json:"inProgressWorkflowJobs,omitempty"`
+
+	// BusyRunners is the number of the runners running jobs.
+	// +optional
+	BusyRunners *int `json:"busyRunners,omitempty"`
+
+	// DemandedReplicas is the number of the replicas demanded by the metrics before they're clamped to maxReplicas.
+	// +optional
+	DemandedReplicas *int `json:"demandedReplicas,omitempty"`
+}
+
+// ReplicaRecommendation is the desired replicas recommended by the metrics at a time.
+type ReplicaRecommendation struct {
+	Time metav1.Time `json:"time"`
+
+	Replicas int `json:"replicas"`
+}
+
+// PickupReservation holds the replicas added on a scale up until a job is observed running on each of them,
+// or the ExpirationTime passes.
+type PickupReservation struct {
+	// Replicas is the number of the replicas added on the scale up.
+	Replicas int `json:"replicas,omitempty"`
+
+	// CreationTime is the time of the scale up.
+	// Runners created after it are considered to be added on the scale up.
+	CreationTime metav1.Time `json:"creationTime,omitempty"`
+
+	ExpirationTime metav1.Time `json:"expirationTime,omitempty"`
+
+	// ConfirmedRunners is the names of the runners added on the scale up that were observed busy.
+	// +optional
+	ConfirmedRunners []string `json:"confirmedRunners,omitempty"`
 }
 
 const CacheEntryKeyDesiredReplicas = "desiredReplicas"
@@ -241,6 +947,11 @@ type CacheEntry struct {
 // +kubebuilder:printcolumn:JSONPath=".spec.minReplicas",name=Min,type=number
 // +kubebuilder:printcolumn:JSONPath=".spec.maxReplicas",name=Max,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.desiredReplicas",name=Desired,type=number
+// +kubebuilder:printcolumn:JSONPath=".status.recommendedReplicas",name=Recommended,type=number,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.unschedulableReplicas",name=Unschedulable,type=number,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.reservedReplicas",name=Reserved,type=number,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.requestedReplicas",name=Requested,type=number,priority=1
+// +kubebuilder:printcolumn:JSONPath=".status.grantedReplicas",name=Granted,type=number,priority=1
 // +kubebuilder:printcolumn:JSONPath=".status.scheduledOverridesSummary",name=Schedule,type=string
 
 // HorizontalRunnerAutoscaler is the Schema for the horizontalrunnerautoscaler API
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// DefaultHorizontalRunnerAutoscalerMinReplicas is the minReplicas of the HorizontalRunnerAutoscaler without one,
+// which is the same as the one of HorizontalPodAutoscaler.
+const DefaultHorizontalRunnerAutoscalerMinReplicas = 1
+
+// The validating webhook of HorizontalRunnerAutoscaler needs to look up the scale target,
+// so it's implemented by the controller and registered along with this mutating webhook.
+
+// +kubebuilder:webhook:path=/mutate-actions-summerwind-dev-v1alpha1-horizontalrunnerautoscaler,verbs=create;update,mutating=true,failurePolicy=fail,groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,versions=v1alpha1,name=mutate.horizontalrunnerautoscaler.actions.summerwind.dev,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &HorizontalRunnerAutoscaler{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *HorizontalRunnerAutoscaler) Default() {
+	if r.Spec.ScaleTargetRef.Kind == "" {
+		r.Spec.ScaleTargetRef.Kind = "RunnerDeployment"
+	}
+
+	if r.Spec.MinReplicas == nil {
+		min := DefaultHorizontalRunnerAutoscalerMinReplicas
+		r.Spec.MinReplicas = &min
+	}
+}
+
+// Validate validates the resource spec on its own, without looking up the scale target.
+func (r *HorizontalRunnerAutoscaler) Validate() field.ErrorList {
+	var errList field.ErrorList
+
+	spec := field.NewPath("spec")
+
+	if r.Spec.ScaleTargetRef.Name == "" {
+		errList = append(errList, field.Required(spec.Child("scaleTargetRef", "name"), "the name of the scale target is required"))
+	}
+
+	if r.Spec.MinReplicas == nil {
+		errList = append(errList, field.Required(spec.Child("minReplicas"), ""))
+	} else if *r.Spec.MinReplicas < 0 {
+		errList = append(errList, field.Invalid(spec.Child("minReplicas"), *r.Spec.MinReplicas, "must be greater than or equal to 0"))
+	}
+
+	if r.Spec.MaxReplicas == nil {
+		errList = append(errList, field.Required(spec.Child("maxReplicas"), ""))
+	} else if min := r.Spec.MinReplicas; min != nil && *min > *r.Spec.MaxReplicas {
+		errList = append(errList, field.Invalid(spec.Child("maxReplicas"), *r.Spec.MaxReplicas, "must be greater than or equal to minReplicas"))
+	}
+
+	if p := r.Spec.SyncPeriod; p != nil && p.Duration < time.Second {
+		errList = append(errList, field.Invalid(spec.Child("syncPeriod"), p.Duration.String(), "must be 1s or longer"))
+	}
+
+	for i, m := range r.Spec.Metrics {
+		path := spec.Child("metrics").Index(i)
+
+		if m.Type == AutoscalingMetricTypeExternal && (m.External == nil || m.External.Provider == "") {
+			errList = append(errList, field.Required(path.Child("external", "provider"), "the provider is required for the External metric type"))
+		}
+	}
+
+	if o := r.Spec.Overflow; o != nil {
+		for i, s := range o.Sinks {
+			path := spec.Child("overflow", "sinks").Index(i)
+
+			var n int
+			if s.ConfigMap != "" {
+				n++
+			}
+			if s.WebhookURL != "" {
+				n++
+			}
+			if s.HorizontalRunnerAutoscaler != nil {
+				n++
+			}
+
+			if n != 1 {
+				errList = append(errList, field.Invalid(path, s.Name, "exactly one of configMap, webhookURL and horizontalRunnerAutoscaler must be set"))
+			}
+		}
+	}
+
+	return errList
+}
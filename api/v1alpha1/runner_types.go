@@ -17,9 +17,17 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,8 +37,23 @@ import (
 type RunnerSpec struct {
 	RunnerConfig  `json:",inline"`
 	RunnerPodSpec `json:",inline"`
+
+	// Provisioner is the name of the runner provisioner, registered to the controller via the --runner-provisioner flag,
+	// that provisions the machine running the runner instead of a pod.
+	// The pod spec fields are ignored when this is set.
+	// +optional
+	Provisioner string `json:"provisioner,omitempty"`
 }
 
+// The operating systems and the CPU architectures of runners, in the form of the labels the runners register.
+const (
+	RunnerOSLinux   = "linux"
+	RunnerOSWindows = "windows"
+
+	RunnerArchX64   = "x64"
+	RunnerArchARM64 = "arm64"
+)
+
 type RunnerConfig struct {
 	// +optional
 	// +kubebuilder:validation:Pattern=`^[^/]+$`
@@ -47,6 +70,20 @@ type RunnerConfig struct {
 	// +optional
 	Labels []string `json:"labels,omitempty"`
 
+	// OS is the operating system of the runner, which the runner registers as a label along with the architecture.
+	// Defaults to the kubernetes.io/os node selector of the runner, or windows when the runner image looks like a Windows image, or linux otherwise.
+	// A Windows runner has no docker sidecar and works in C:\runner\_work by default.
+	// +optional
+	// +kubebuilder:validation:Enum=linux;windows
+	OS string `json:"os,omitempty"`
+
+	// Arch is the CPU architecture of the runner, which the runner registers as a label along with the operating system.
+	// Defaults to the kubernetes.io/arch node selector of the runner, or arm64 when the runner image looks like an arm64 image.
+	// The runner is unaware of its architecture otherwise, and is matched against the workflow jobs requesting any architecture.
+	// +optional
+	// +kubebuilder:validation:Enum=x64;arm64
+	Arch string `json:"arch,omitempty"`
+
 	// +optional
 	Group string `json:"group,omitempty"`
 
@@ -63,14 +100,194 @@ type RunnerConfig struct {
 	DockerdWithinRunnerContainer *bool `json:"dockerdWithinRunnerContainer,omitempty"`
 	// +optional
 	DockerEnabled *bool `json:"dockerEnabled,omitempty"`
+
+	// DockerRootless runs the docker sidecar with rootless dockerd as a non-root user, instead of a privileged container.
+	// The runner talks to it over the unix socket shared between the containers.
+	// It can't be used along with dockerdWithinRunnerContainer.
+	// +optional
+	DockerRootless *bool `json:"dockerRootless,omitempty"`
 	// +optional
 	DockerMTU *int64 `json:"dockerMTU,omitempty"`
 	// +optional
 	DockerRegistryMirror *string `json:"dockerRegistryMirror,omitempty"`
+
+	// DockerRestartPolicy is what happens when dockerd crashes, like on OOM, while the runner is running.
+	// Restart restarts dockerd without stopping the runner, so that the in-flight job can continue once dockerd is back.
+	// FailFast stops the runner along with dockerd, so that the in-flight job fails right away and the runner pod is recreated.
+	// It applies to both the docker sidecar and dockerd within the runner container. Defaults to Restart.
+	// +optional
+	// +kubebuilder:validation:Enum=Restart;FailFast
+	DockerRestartPolicy string `json:"dockerRestartPolicy,omitempty"`
 	// +optional
 	VolumeSizeLimit *resource.Quantity `json:"volumeSizeLimit,omitempty"`
 	// +optional
 	VolumeStorageMedium *string `json:"volumeStorageMedium,omitempty"`
+
+	// RegistrationSecretRef makes the runner register itself with the registration token or the JIT config
+	// stored in the referenced Secret, instead of the registration token the controller fetches from GitHub.
+	// This is for environments where GitHub credentials are brokered by a separate system that writes the Secret.
+	// +optional
+	RegistrationSecretRef *RunnerRegistrationSecretRef `json:"registrationSecretRef,omitempty"`
+
+	// GitHubAPICredentialsFrom makes the controller register and unregister the runner with the GitHub API credentials
+	// stored in the referenced Secret, instead of the controller-wide credentials.
+	// +optional
+	GitHubAPICredentialsFrom *GitHubAPICredentialsFrom `json:"githubAPICredentialsFrom,omitempty"`
+
+	// RerunInterruptedJobs makes the controller re-run the failed jobs of a workflow run
+	// when all of them failed because their runners of this runner pool were lost, like on spot instance interruptions.
+	// This is supported only for repository runners of RunnerDeployments and RunnerSets.
+	// +optional
+	RerunInterruptedJobs bool `json:"rerunInterruptedJobs,omitempty"`
+
+	// JobTimeouts bounds ephemeral runner pods by the timeouts of the jobs they run, by setting activeDeadlineSeconds
+	// of the pods once they pick up jobs, so that runaway jobs are stopped by Kubernetes even if the runners hang.
+	// This is supported for Runners and RunnerDeployments, as it relies on the jobs and the busy states recorded to the runner statuses.
+	// +optional
+	JobTimeouts *JobTimeouts `json:"jobTimeouts,omitempty"`
+
+	// PreRunScript is run by the runner container before the runner is registered, e.g. to log in to internal registries.
+	// The runner container exits without registering the runner when the script fails.
+	// +optional
+	PreRunScript *RunnerScript `json:"preRunScript,omitempty"`
+
+	// PreJobScript is run by the runner before each job, via the ACTIONS_RUNNER_HOOK_JOB_STARTED runner hook.
+	// +optional
+	PreJobScript *RunnerScript `json:"preJobScript,omitempty"`
+
+	// PostJobScript is run by the runner after each job, via the ACTIONS_RUNNER_HOOK_JOB_COMPLETED runner hook,
+	// e.g. to scrub the secrets left in the workspace.
+	// +optional
+	PostJobScript *RunnerScript `json:"postJobScript,omitempty"`
+
+	// GPU allocates GPUs to the runner container, and applies the GPU profile of the controller to the runner pod,
+	// so that the runner pod lands on the GPU nodes without repeating the tolerations and the runtime class in every runner spec.
+	// +optional
+	GPU *RunnerGPU `json:"gpu,omitempty"`
+
+	// ExtendedResources is the extended resources, like `hugepages-2Mi` or `example.com/fpga`, allocated to the runner container.
+	// Each of them is set to both the requests and the limits of the runner container, as they can't be overcommitted.
+	// +optional
+	ExtendedResources corev1.ResourceList `json:"extendedResources,omitempty"`
+
+	// CertificateAuthorities is the PEM-encoded CA certificates trusted by the runner and the docker daemon in addition to the public ones,
+	// e.g. for the internal TLS-intercepting proxies, without building custom runner images just for the trust.
+	// +optional
+	CertificateAuthorities []CertificateAuthority `json:"certificateAuthorities,omitempty"`
+}
+
+// CertificateAuthority is a PEM-encoded CA certificate, or a bundle of them, in a key of a ConfigMap or a Secret in the namespace of the runner.
+// Exactly one of ConfigMapKeyRef and SecretKeyRef must be set.
+type CertificateAuthority struct {
+	// ConfigMapKeyRef references the key of the ConfigMap that contains the certificates.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// SecretKeyRef references the key of the Secret that contains the certificates.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// DefaultGPUResourceName is the resource name of the GPUs allocated by RunnerGPU by default.
+const DefaultGPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+// RunnerGPU is the GPUs allocated to the runner container.
+type RunnerGPU struct {
+	// Count is the number of the GPUs allocated to the runner container.
+	// +kubebuilder:validation:Minimum=1
+	Count int64 `json:"count"`
+
+	// ResourceName is the extended resource name of the GPUs advertised by the device plugin, like `amd.com/gpu`.
+	// Defaults to `nvidia.com/gpu`.
+	// +optional
+	ResourceName corev1.ResourceName `json:"resourceName,omitempty"`
+
+	// Profile is the name of the GPU profile of the controller whose node selector, tolerations and runtime class are applied to the runner pod.
+	// Defaults to the profile named after ResourceName, if any.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+}
+
+// GetResourceName returns the resource name of the GPUs, defaulting to DefaultGPUResourceName.
+func (g *RunnerGPU) GetResourceName() corev1.ResourceName {
+	if g.ResourceName == "" {
+		return DefaultGPUResourceName
+	}
+
+	return g.ResourceName
+}
+
+// RunnerScript is a bash script run by the runner container, given either inline or as a key of a ConfigMap.
+// Exactly one of Inline and ConfigMapKeyRef must be set.
+type RunnerScript struct {
+	// Inline is the content of the script.
+	// +optional
+	Inline string `json:"inline,omitempty"`
+
+	// ConfigMapKeyRef references the key of the ConfigMap in the namespace of the runner that contains the script.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// JobTimeouts configures how the timeouts of the jobs are derived from the runner labels and the job names.
+type JobTimeouts struct {
+	// LabelPrefix is the prefix of the runner label advertising the timeout of the jobs the runner accepts,
+	// followed by a duration like `timeout-90m`, so that jobs pick the timeout with `runs-on`.
+	// Defaults to `timeout-`.
+	// +optional
+	LabelPrefix string `json:"labelPrefix,omitempty"`
+
+	// Jobs are the timeouts of the jobs whose names match the patterns, which take precedence over the runner label.
+	// The first matching one wins. Job names are known only when the runner pickups are recorded via workflow_job webhook events.
+	// +optional
+	Jobs []JobTimeout `json:"jobs,omitempty"`
+
+	// Grace is added to the timeout for the runner to report the result of the job and clean up.
+	// Defaults to 5m.
+	// +optional
+	Grace *metav1.Duration `json:"grace,omitempty"`
+}
+
+// JobTimeout is the timeout of the jobs whose names match the pattern.
+type JobTimeout struct {
+	// NamePattern is the regular expression matched against the names of the jobs.
+	// +kubebuilder:validation:MinLength=1
+	NamePattern string `json:"namePattern"`
+
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// GitHubAPICredentialsFrom references the GitHub API credentials of a GitHub organization, enterprise, or repository.
+type GitHubAPICredentialsFrom struct {
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// SecretReference references a Secret in the namespace of the referencing resource.
+// The Secret contains either github_token, or github_app_id, github_app_installation_id and github_app_private_key,
+// like the Secret of the controller.
+type SecretReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// RunnerRegistrationSecretRef references a user-provided Secret containing either a runner registration token
+// or a just-in-time runner configuration.
+type RunnerRegistrationSecretRef struct {
+	// Name is the name of the Secret in the namespace of the runner.
+	// Defaults to the name of the runner for a Runner, and the name of the RunnerSet for a RunnerSet.
+	// As a JIT config can be used only once, you usually want a Secret per Runner for JIT configs.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// TokenKey is the key of the registration token in the Secret.
+	// Defaults to "token" unless JITConfigKey is set.
+	// +optional
+	TokenKey string `json:"tokenKey,omitempty"`
+
+	// JITConfigKey is the key of the encoded JIT config in the Secret.
+	// The runner runs with the JIT config without running config.sh when this is set.
+	// +optional
+	JITConfigKey string `json:"jitConfigKey,omitempty"`
 }
 
 // RunnerPodSpec defines the desired pod spec fields of the runner pod
@@ -144,9 +361,31 @@ type RunnerPodSpec struct {
 	// +optional
 	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
 
+	// HostNetwork runs the runner pod in the network namespace of its node, for the jobs that need to receive callbacks on the node address.
+	// The DNS policy defaults to ClusterFirstWithHostNet along with it, so that the services of the cluster are still resolved.
+	// It is rejected unless the controller is started with --allow-runner-network-exposure.
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// HostPorts are the ports of the runner container exposed on the node of the runner pod.
+	// HostPort defaults to ContainerPort. The runner pods exposing the same host ports aren't scheduled onto the same node.
+	// It is rejected unless the controller is started with --allow-runner-network-exposure.
+	// +optional
+	HostPorts []corev1.ContainerPort `json:"hostPorts,omitempty"`
+
+	// Services are created for each runner, pointing at its pod, and deleted along with the runner,
+	// so that the services under test in the jobs can receive callbacks from outside the runner pod.
+	// It is rejected unless the controller is started with --allow-runner-network-exposure.
+	// +optional
+	Services []RunnerService `json:"services,omitempty"`
+
 	// +optional
 	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 
+	// PriorityClassName is the priorityClassName of the runner pod.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
 	// RuntimeClassName is the container runtime configuration that containers should run under.
 	// More info: https://kubernetes.io/docs/concepts/containers/runtime-class
 	// +optional
@@ -154,6 +393,376 @@ type RunnerPodSpec struct {
 
 	// +optional
 	DnsConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// DnsPolicy is the DNS policy of the runner pod, which applies to both the runner and docker containers.
+	// Set it to None along with DnsConfig to replace the resolv.conf of the cluster, e.g. for split-horizon DNS.
+	// Defaults to ClusterFirst.
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterFirstWithHostNet;ClusterFirst;Default;None
+	DnsPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// Queueing submits the runner pod through the batch queueing system of the cluster,
+	// so that the capacity for runners is arbitrated against other batch workloads.
+	// +optional
+	Queueing *RunnerQueueing `json:"queueing,omitempty"`
+
+	// PodRetention keeps the pod of the runner after the runner completes or fails and is deleted,
+	// so that failures can be debugged after the fact.
+	// +optional
+	PodRetention *RunnerPodRetention `json:"podRetention,omitempty"`
+
+	// PodTemplate is a pod template merged into the runner pod generated from the other fields,
+	// as a strategic merge patch like `kubectl patch --type strategic`.
+	// Containers, init containers and volumes are merged by their names,
+	// so that any field of the pod, including the ones not exposed by the runner spec, can be set or overridden.
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplate *runtime.RawExtension `json:"podTemplate,omitempty"`
+
+	// VariablesFrom references the ConfigMap in the namespace of the runner whose keys and values are the substitution variables,
+	// like ENVIRONMENT and ARTIFACTORY_URL, so that the same template can be promoted across environments.
+	// The ${NAME} references to the variables in the string fields of the runner pod are replaced with their values
+	// when the pod is created. The references to the names not defined in the ConfigMap are left as is.
+	// +optional
+	VariablesFrom *corev1.LocalObjectReference `json:"variablesFrom,omitempty"`
+}
+
+// RunnerService is a Service created for each runner, pointing at the runner pod.
+type RunnerService struct {
+	// Name is the suffix of the name of the Service, which is named like RUNNER_NAME-NAME
+	// so that the jobs can find it from the RUNNER_NAME environment variable.
+	Name string `json:"name"`
+
+	// Type is the type of the Service, either ClusterIP, NodePort or LoadBalancer. Defaults to ClusterIP.
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Ports are the ports of the Service, whose targetPorts are the ports the jobs listen on in the runner pod.
+	Ports []corev1.ServicePort `json:"ports"`
+
+	// Annotations are added to the Service, e.g. for the load balancer controllers and external-dns.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RunnerPodRetention configures how long the pods of the completed and failed runners are retained.
+type RunnerPodRetention struct {
+	// TTL is how long the pod of a completed or failed runner is retained after the runner is deleted.
+	// Defaults to 0, which deletes the pods of the completed runners right away.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// FailedPodsLimit is the number of the latest pods of the failed runners retained beyond TTL, per runner deployment.
+	// Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	FailedPodsLimit *int `json:"failedPodsLimit,omitempty"`
+}
+
+// RunnerQueueing is the queue the runner pod is submitted to.
+type RunnerQueueing struct {
+	// Provider is the batch queueing system, either kueue or volcano.
+	// +kubebuilder:validation:Enum=kueue;volcano
+	Provider string `json:"provider"`
+
+	// QueueName is the name of the Kueue LocalQueue in the namespace of the runner,
+	// or the name of the Volcano Queue.
+	// +kubebuilder:validation:MinLength=1
+	QueueName string `json:"queueName"`
+}
+
+// ValidateRegistrationSecretRef validates registrationSecretRef field.
+func (rs *RunnerConfig) ValidateRegistrationSecretRef() error {
+	ref := rs.RegistrationSecretRef
+	if ref == nil {
+		return nil
+	}
+
+	if ref.TokenKey != "" && ref.JITConfigKey != "" {
+		return errors.New("tokenKey and jitConfigKey are mutually exclusive")
+	}
+
+	return nil
+}
+
+// ValidateJobTimeouts validates jobTimeouts field.
+func (rs *RunnerConfig) ValidateJobTimeouts() error {
+	if rs.JobTimeouts == nil {
+		return nil
+	}
+
+	for i, j := range rs.JobTimeouts.Jobs {
+		if _, err := regexp.Compile(j.NamePattern); err != nil {
+			return fmt.Errorf("jobs[%d].namePattern must be a valid regular expression: %w", i, err)
+		}
+
+		if j.Timeout.Duration <= 0 {
+			return fmt.Errorf("jobs[%d].timeout must be positive", i)
+		}
+	}
+
+	return nil
+}
+
+// ValidateDnsPolicy validates dnsPolicy field.
+func (rs *RunnerPodSpec) ValidateDnsPolicy() error {
+	if rs.DnsPolicy == corev1.DNSNone && rs.DnsConfig == nil {
+		return errors.New("dnsConfig is required when dnsPolicy is None")
+	}
+
+	return nil
+}
+
+// ValidateScripts validates preRunScript, preJobScript and postJobScript fields under path.
+func (rs *RunnerConfig) ValidateScripts(path *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	scripts := []struct {
+		name   string
+		script *RunnerScript
+	}{
+		{"preRunScript", rs.PreRunScript},
+		{"preJobScript", rs.PreJobScript},
+		{"postJobScript", rs.PostJobScript},
+	}
+
+	for _, s := range scripts {
+		if s.script == nil {
+			continue
+		}
+
+		if (s.script.Inline == "") == (s.script.ConfigMapKeyRef == nil) {
+			errList = append(errList, field.Invalid(path.Child(s.name), s.script, "exactly one of inline and configMapKeyRef must be set"))
+		}
+	}
+
+	return errList
+}
+
+// ValidateCertificateAuthorities validates certificateAuthorities field under path.
+func (rs *RunnerConfig) ValidateCertificateAuthorities(path *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	for i, ca := range rs.CertificateAuthorities {
+		if (ca.ConfigMapKeyRef == nil) == (ca.SecretKeyRef == nil) {
+			errList = append(errList, field.Invalid(path.Child("certificateAuthorities").Index(i), ca, "exactly one of configMapKeyRef and secretKeyRef must be set"))
+		}
+	}
+
+	return errList
+}
+
+// AllowRunnerNetworkExposure permits the hostNetwork, hostPorts and services of runners, which expose the runner pods beyond the pod network.
+// The admission webhooks reject them unless it's enabled by the --allow-runner-network-exposure flag of the controller.
+var AllowRunnerNetworkExposure bool
+
+// ValidateNetworkExposure validates hostNetwork, hostPorts and services fields under path,
+// along with the host network and the host ports set via podTemplate, which are forbidden unless AllowRunnerNetworkExposure is true.
+func (rs *RunnerPodSpec) ValidateNetworkExposure(path *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	forbidden := func(p *field.Path) {
+		errList = append(errList, field.Forbidden(p, "exposing runner pods is forbidden unless the controller is started with --allow-runner-network-exposure"))
+	}
+
+	if !AllowRunnerNetworkExposure {
+		if rs.HostNetwork {
+			forbidden(path.Child("hostNetwork"))
+		}
+
+		if len(rs.HostPorts) > 0 {
+			forbidden(path.Child("hostPorts"))
+		}
+
+		if len(rs.Services) > 0 {
+			forbidden(path.Child("services"))
+		}
+
+		if pod, ok := rs.podTemplate(); ok && podExposesHost(pod) {
+			forbidden(path.Child("podTemplate"))
+		}
+	}
+
+	for i, p := range rs.HostPorts {
+		portPath := path.Child("hostPorts").Index(i)
+
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			errList = append(errList, field.Invalid(portPath.Child("containerPort"), p.ContainerPort, "must be between 1 and 65535"))
+		}
+
+		if p.HostPort < 0 || p.HostPort > 65535 {
+			errList = append(errList, field.Invalid(portPath.Child("hostPort"), p.HostPort, "must be between 1 and 65535, or 0 to default to containerPort"))
+		} else if rs.HostNetwork && p.HostPort != 0 && p.HostPort != p.ContainerPort {
+			errList = append(errList, field.Invalid(portPath.Child("hostPort"), p.HostPort, "must equal containerPort along with hostNetwork"))
+		}
+	}
+
+	names := map[string]bool{}
+
+	for i, svc := range rs.Services {
+		svcPath := path.Child("services").Index(i)
+
+		for _, msg := range validation.IsDNS1035Label(svc.Name) {
+			errList = append(errList, field.Invalid(svcPath.Child("name"), svc.Name, msg))
+		}
+
+		if names[svc.Name] {
+			errList = append(errList, field.Duplicate(svcPath.Child("name"), svc.Name))
+		}
+
+		names[svc.Name] = true
+
+		if len(svc.Ports) == 0 {
+			errList = append(errList, field.Required(svcPath.Child("ports"), "one or more ports are required"))
+		}
+
+		for j, p := range svc.Ports {
+			if p.Port < 1 || p.Port > 65535 {
+				errList = append(errList, field.Invalid(svcPath.Child("ports").Index(j).Child("port"), p.Port, "must be between 1 and 65535"))
+			}
+		}
+	}
+
+	return errList
+}
+
+// podTemplate returns the pod decoded from podTemplate, if any.
+func (rs *RunnerPodSpec) podTemplate() (corev1.Pod, bool) {
+	var pod corev1.Pod
+
+	if rs.PodTemplate == nil || len(rs.PodTemplate.Raw) == 0 {
+		return pod, false
+	}
+
+	patched, err := strategicpatch.StrategicMergePatch([]byte("{}"), rs.PodTemplate.Raw, corev1.Pod{})
+	if err != nil {
+		return pod, false
+	}
+
+	if err := json.Unmarshal(patched, &pod); err != nil {
+		return pod, false
+	}
+
+	return pod, true
+}
+
+// podExposesHost returns true if the pod runs in the host network or exposes any host port.
+func podExposesHost(pod corev1.Pod) bool {
+	if pod.Spec.HostNetwork {
+		return true
+	}
+
+	for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ValidatePodTemplate validates podTemplate field.
+func (rs *RunnerPodSpec) ValidatePodTemplate() error {
+	if rs.PodTemplate == nil || len(rs.PodTemplate.Raw) == 0 {
+		return nil
+	}
+
+	patched, err := strategicpatch.StrategicMergePatch([]byte("{}"), rs.PodTemplate.Raw, corev1.Pod{})
+	if err != nil {
+		return fmt.Errorf("podTemplate must be a valid strategic merge patch: %w", err)
+	}
+
+	var pod corev1.Pod
+	if err := json.Unmarshal(patched, &pod); err != nil {
+		return fmt.Errorf("podTemplate must be a pod template: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateExtendedResources validates gpu and extendedResources fields under path,
+// along with the extended resources and hugepages of the resources of the runner container,
+// whose requests must equal their limits as they can't be overcommitted.
+func (rs *RunnerConfig) ValidateExtendedResources(resources corev1.ResourceRequirements, path *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	if g := rs.GPU; g != nil {
+		gpuPath := path.Child("gpu")
+
+		if g.Count < 1 {
+			errList = append(errList, field.Invalid(gpuPath.Child("count"), g.Count, "must be greater than 0"))
+		}
+
+		if name := g.GetResourceName(); !isExtendedResourceName(name) {
+			errList = append(errList, field.Invalid(gpuPath.Child("resourceName"), name, "must be an extended resource name like nvidia.com/gpu"))
+		} else if _, ok := rs.ExtendedResources[name]; ok {
+			errList = append(errList, field.Duplicate(path.Child("extendedResources").Key(string(name)), name))
+		}
+	}
+
+	var hugePages bool
+
+	for name, q := range rs.ExtendedResources {
+		p := path.Child("extendedResources").Key(string(name))
+
+		if !isExtendedResourceName(name) && !isHugePageResourceName(name) {
+			errList = append(errList, field.Invalid(p, name, "must be an extended resource name like example.com/fpga, or a hugepages resource name like hugepages-2Mi"))
+		} else if q.Sign() <= 0 {
+			errList = append(errList, field.Invalid(p, q.String(), "must be greater than 0"))
+		}
+
+		hugePages = hugePages || isHugePageResourceName(name)
+	}
+
+	for name, req := range resources.Requests {
+		if !isExtendedResourceName(name) && !isHugePageResourceName(name) {
+			continue
+		}
+
+		hugePages = hugePages || isHugePageResourceName(name)
+
+		if limit, ok := resources.Limits[name]; !ok || limit.Cmp(req) != 0 {
+			errList = append(errList, field.Invalid(path.Child("resources", "requests").Key(string(name)), req.String(), "must equal the limit, as it can't be overcommitted"))
+		}
+	}
+
+	for name := range resources.Limits {
+		if isHugePageResourceName(name) {
+			hugePages = true
+		}
+	}
+
+	if hugePages {
+		_, cpu := resources.Requests[corev1.ResourceCPU]
+		_, memory := resources.Requests[corev1.ResourceMemory]
+		_, cpuLimit := resources.Limits[corev1.ResourceCPU]
+		_, memoryLimit := resources.Limits[corev1.ResourceMemory]
+
+		if !cpu && !memory && !cpuLimit && !memoryLimit {
+			errList = append(errList, field.Required(path.Child("resources"), "cpu or memory is required along with hugepages"))
+		}
+	}
+
+	return errList
+}
+
+// isExtendedResourceName returns true if the name is an extended resource name, which is fully-qualified outside the kubernetes.io domain.
+func isExtendedResourceName(name corev1.ResourceName) bool {
+	parts := strings.SplitN(string(name), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+
+	return !strings.HasSuffix(parts[0], "kubernetes.io")
+}
+
+// isHugePageResourceName returns true if the name is a hugepages resource name like hugepages-2Mi.
+func isHugePageResourceName(name corev1.ResourceName) bool {
+	return strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix)
 }
 
 // ValidateRepository validates repository field.
@@ -195,6 +804,90 @@ type RunnerStatus struct {
 	// +optional
 	// +nullable
 	LastRegistrationCheckTime *metav1.Time `json:"lastRegistrationCheckTime,omitempty"`
+	// InstanceID is the ID of the machine provisioned by the runner provisioner.
+	// +optional
+	InstanceID string `json:"instanceID,omitempty"`
+	// Busy is whether the runner was busy running a job on the latest utilization sample.
+	// +optional
+	Busy bool `json:"busy,omitempty"`
+	// Utilization is the busy time and the number of jobs of the runner,
+	// sampled by the controller when the runner utilization sampling is enabled.
+	// +optional
+	Utilization *RunnerUtilization `json:"utilization,omitempty"`
+	// RecentJobs is the workflow jobs the runner picked up most recently, newest first,
+	// recorded by the webhook-based autoscaler on workflow_job events.
+	// +optional
+	RecentJobs []RunnerJob `json:"recentJobs,omitempty"`
+	// CapacityReservation is the name of the capacity reservation of the HorizontalRunnerAutoscaler
+	// the runner was created for, or whose job the runner picked up.
+	// The reservation is released once the runner pod completes a job.
+	// +optional
+	CapacityReservation string `json:"capacityReservation,omitempty"`
+	// GitHub is the state of the runner on GitHub, synced periodically by the controller
+	// when the runner status sync is enabled.
+	// +optional
+	GitHub *RunnerGitHubStatus `json:"github,omitempty"`
+}
+
+const (
+	RunnerGitHubStateBusy         RunnerGitHubState = "Busy"
+	RunnerGitHubStateIdle         RunnerGitHubState = "Idle"
+	RunnerGitHubStateOffline      RunnerGitHubState = "Offline"
+	RunnerGitHubStateUnregistered RunnerGitHubState = "Unregistered"
+)
+
+// RunnerGitHubState is the state of a runner on GitHub.
+// +kubebuilder:validation:Enum=Busy;Idle;Offline;Unregistered
+type RunnerGitHubState string
+
+// RunnerGitHubStatus is the state of a runner on GitHub.
+type RunnerGitHubStatus struct {
+	// State is Busy, Idle or Offline while the runner is registered to GitHub, and Unregistered otherwise.
+	State RunnerGitHubState `json:"state"`
+	// ID is the ID of the runner on GitHub.
+	// +optional
+	ID int64 `json:"id,omitempty"`
+	// Labels is the labels of the runner on GitHub, including the default ones like `self-hosted`.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+	// LastBusyTime is the latest time the runner was seen busy running a job.
+	// The job itself is recorded in recentJobs by the webhook-based autoscaler.
+	// +optional
+	// +nullable
+	LastBusyTime *metav1.Time `json:"lastBusyTime,omitempty"`
+	// LastSyncTime is the time the state was synced from GitHub.
+	LastSyncTime metav1.Time `json:"lastSyncTime"`
+}
+
+// RunnerJob is a workflow job picked up by a runner.
+type RunnerJob struct {
+	// Repository is the repository of the job in the form of `owner/name`.
+	Repository string `json:"repository"`
+	// Workflow is the name of the workflow of the job.
+	// +optional
+	Workflow string `json:"workflow,omitempty"`
+	// Name is the name of the job.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// StartedAt is the time the job was picked up by the runner.
+	StartedAt metav1.Time `json:"startedAt"`
+}
+
+// RunnerUtilization is the busy time and the number of jobs of a runner, or the sum of them for a runner pool,
+// accumulated over the utilization samples.
+type RunnerUtilization struct {
+	// RegisteredSeconds is the cumulative seconds the runners have been registered to GitHub.
+	RegisteredSeconds int64 `json:"registeredSeconds"`
+	// BusySeconds is the cumulative seconds the runners have been busy running jobs.
+	// BusySeconds divided by RegisteredSeconds is the utilization of the runners.
+	BusySeconds int64 `json:"busySeconds"`
+	// JobsExecuted is the number of jobs the runners have run, counted on each transition from idle to busy.
+	// Jobs run back to back without being observed idle in between are counted as one.
+	JobsExecuted int64 `json:"jobsExecuted"`
+	// LastSampleTime is the time of the latest sample.
+	// +optional
+	// +nullable
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
 }
 
 // RunnerStatusRegistration contains runner registration status
@@ -214,6 +907,7 @@ type RunnerStatusRegistration struct {
 // +kubebuilder:printcolumn:JSONPath=".spec.repository",name=Repository,type=string
 // +kubebuilder:printcolumn:JSONPath=".spec.labels",name=Labels,type=string
 // +kubebuilder:printcolumn:JSONPath=".status.phase",name=Status,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.github.state",name=State,type=string
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Runner is the Schema for the runners API
@@ -76,6 +76,32 @@ func (r *Runner) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "repository"), r.Spec.Repository, err.Error()))
 	}
 
+	err = r.Spec.ValidateRegistrationSecretRef()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "registrationSecretRef"), r.Spec.RegistrationSecretRef, err.Error()))
+	}
+
+	err = r.Spec.ValidateJobTimeouts()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "jobTimeouts"), r.Spec.JobTimeouts, err.Error()))
+	}
+
+	err = r.Spec.ValidateDnsPolicy()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "dnsPolicy"), r.Spec.DnsPolicy, err.Error()))
+	}
+
+	errList = append(errList, r.Spec.ValidateScripts(field.NewPath("spec"))...)
+	errList = append(errList, r.Spec.ValidateCertificateAuthorities(field.NewPath("spec"))...)
+	errList = append(errList, r.Spec.ValidateNetworkExposure(field.NewPath("spec"))...)
+
+	errList = append(errList, r.Spec.ValidateExtendedResources(r.Spec.Resources, field.NewPath("spec"))...)
+
+	err = r.Spec.ValidatePodTemplate()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "podTemplate"), string(r.Spec.PodTemplate.Raw), err.Error()))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunnerBudgetSpec defines the desired state of RunnerBudget
+type RunnerBudgetSpec struct {
+	// MaxReplicas is the total number of runners the HorizontalRunnerAutoscalers the budget applies to are allowed to desire.
+	// When their combined desired replicas exceed it, each of them is granted a share of it weighted by its priority.
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas int `json:"maxReplicas"`
+
+	// Organization limits the budget to the HorizontalRunnerAutoscalers whose scale targets are
+	// organizational runners of the GitHub organization or repository runners of its repositories.
+	// The budget applies to every HorizontalRunnerAutoscaler in the cluster when this is empty.
+	// +optional
+	Organization string `json:"organization,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:JSONPath=".spec.organization",name=Organization,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.maxReplicas",name=Max,type=number
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RunnerBudget is the Schema for the runnerbudgets API
+type RunnerBudget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RunnerBudgetSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerBudgetList contains a list of RunnerBudget
+type RunnerBudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerBudget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerBudget{}, &RunnerBudgetList{})
+}
@@ -17,12 +17,30 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns = "TotalNumberOfQueuedAndInProgressWorkflowRuns"
 	AutoscalingMetricTypePercentageRunnersBusy                        = "PercentageRunnersBusy"
+	AutoscalingMetricTypeExternal                                     = "External"
+	AutoscalingMetricTypeQueuedJobsPlusBusyRunners                    = "QueuedJobsPlusBusyRunners"
+	AutoscalingMetricTypeQueuedJobWaitTime                            = "QueuedJobWaitTime"
+	AutoscalingMetricTypeQueuedJobsPerRunner                          = "QueuedJobsPerRunner"
+)
+
+const (
+	MetricsCombinationPolicyMax     = "Max"
+	MetricsCombinationPolicyMin     = "Min"
+	MetricsCombinationPolicySum     = "Sum"
+	MetricsCombinationPolicyAverage = "Average"
+)
+
+const (
+	HorizontalRunnerAutoscalerPolicyAuto          = "Auto"
+	HorizontalRunnerAutoscalerPolicyRecommendOnly = "RecommendOnly"
 )
 
 // RunnerDeploymentSpec defines the desired state of RunnerDeployment
@@ -31,6 +49,13 @@ type RunnerDeploymentSpec struct {
 	// +nullable
 	Replicas *int `json:"replicas,omitempty"`
 
+	// Schedule overrides Replicas with the replicas of the first entry whose window is active, e.g. to run 20 runners
+	// during the working hours and 2 at night. Replicas is used outside the windows.
+	// It's meant for the RunnerDeployments without HorizontalRunnerAutoscalers, which should use their scheduledOverrides instead.
+	//
+	// +optional
+	Schedule []ScheduledReplicas `json:"schedule,omitempty"`
+
 	// EffectiveTime is the time the upstream controller requested to sync Replicas.
 	// It is usually populated by the webhook-based autoscaler via HRA.
 	// The value is inherited to RunnerRepicaSet(s) and used to prevent ephemeral runners from unnecessarily recreated.
@@ -39,12 +64,454 @@ type RunnerDeploymentSpec struct {
 	// +nullable
 	EffectiveTime *metav1.Time `json:"effectiveTime"`
 
+	// WarmReplicas is the number of runners that are created and registered ahead of demand in addition to Replicas,
+	// but kept suspended without accepting any job until they are activated on scale out.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	// +nullable
+	WarmReplicas *int `json:"warmReplicas,omitempty"`
+
+	// BurstPriorityClassName is the priorityClassName given to the runner pods created for BurstReplicas,
+	// so that they can preempt lower-priority workloads while the other runner pods keep
+	// the priorityClassName of the template.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	BurstPriorityClassName string `json:"burstPriorityClassName,omitempty"`
+
+	// BurstReplicas is the number of Replicas backed by the capacity reservations of the webhook-driven scale out.
+	// It is usually populated by the HRA, and the value is inherited to RunnerReplicaSet(s).
+	// It has no effect unless BurstPriorityClassName is set.
+	//
+	// +optional
+	// +nullable
+	BurstReplicas *int `json:"burstReplicas,omitempty"`
+
+	// Sizes is the list of the size labels, like `4-core` and `16-core`, advertised by the runners.
+	// Each runner is registered with exactly one of the size labels in addition to the labels of the template,
+	// and its pod gets the resources of the size tier configured for the controller.
+	// Runners not reserved for any specific size are given the first size.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	Sizes []string `json:"sizes,omitempty"`
+
+	// SizeReplicas is the number of Replicas reserved for each size label, backed by the capacity reservations
+	// for the workflow jobs that requested the size.
+	// It is usually populated by the HRA, and the value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	SizeReplicas map[string]int `json:"sizeReplicas,omitempty"`
+
+	// ZoneRebalance enables replacing idle runners to restore the zone spread of the runner pods
+	// that got skewed by burst scale ups.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	ZoneRebalance *ZoneRebalanceSpec `json:"zoneRebalance,omitempty"`
+
+	// WarmRunnerAffinity makes the scale in retain the idle runners that recently ran jobs of the repositories
+	// most active in the runner pool, so that their warm caches are reused by the next jobs.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	WarmRunnerAffinity *WarmRunnerAffinity `json:"warmRunnerAffinity,omitempty"`
+
+	// ScaleDownPolicy is the order in which the runners are chosen for removal on scale down.
+	// Defaults to OldestFirst.
+	// The value is inherited to RunnerReplicaSet(s).
+	//
+	// +optional
+	ScaleDownPolicy ScaleDownPolicy `json:"scaleDownPolicy,omitempty"`
+
+	// TeardownPolicy configures what the controller does on deletion of the RunnerDeployment.
+	//
+	// +optional
+	TeardownPolicy *RunnerDeploymentTeardownPolicy `json:"teardownPolicy,omitempty"`
+
+	// Rightsizing configures how the resource requests recommended by the runner right-sizing are applied.
+	// The recommendations are written to status.resourceRecommendation regardless of this,
+	// when the runner right-sizing is enabled for the controller.
+	//
+	// +optional
+	Rightsizing *RunnerRightsizing `json:"rightsizing,omitempty"`
+
+	// EvictionProtection keeps the busy runners from being evicted by the cluster autoscaler scaling down nodes, which kills their jobs.
+	// The busy runner pods are annotated with `cluster-autoscaler.kubernetes.io/safe-to-evict: "false"` until they become idle,
+	// which requires the controller to be started with --runner-status-sync to know which runners are busy.
+	//
+	// +optional
+	EvictionProtection *RunnerEvictionProtection `json:"evictionProtection,omitempty"`
+
+	// RunnerGroupRef references the RunnerGroup in the namespace of the RunnerDeployment whose runner group the runners are registered to,
+	// instead of spec.template.spec.group. The runners are scaled to zero until the runner group is synced to GitHub.
+	//
+	// +optional
+	RunnerGroupRef *RunnerGroupReference `json:"runnerGroupRef,omitempty"`
+
+	// BlueGreen makes the controller roll out template changes by switching to a new "green" pool of runners
+	// after verifying it on a share of the replicas, instead of replacing all the runners as soon as the new ones are available.
+	//
+	// +optional
+	BlueGreen *RunnerDeploymentBlueGreen `json:"blueGreen,omitempty"`
+
+	// Strategy is how the runners of the old template are replaced by the ones of the new template on a template change,
+	// like the strategy of Deployment. It can't be used along with BlueGreen.
+	// When omitted, all the new runners are created at once, and the old runners are removed after all the new ones become available.
+	//
+	// +optional
+	Strategy *RunnerDeploymentStrategy `json:"strategy,omitempty"`
+
 	// +optional
 	// +nullable
 	Selector *metav1.LabelSelector `json:"selector"`
 	Template RunnerTemplate        `json:"template"`
 }
 
+// RunnerGroupReference references a RunnerGroup in the namespace of the referencing resource.
+type RunnerGroupReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+type RunnerDeploymentTeardownPolicy struct {
+	// CancelPendingJobs makes the controller cancel queued workflow runs that no runner other than
+	// the ones of the RunnerDeployment can run, on deletion of the RunnerDeployment.
+	// Otherwise such workflow runs wait for a runner until GitHub times them out.
+	// The cancellation is best-effort and never blocks the deletion.
+	//
+	// +optional
+	CancelPendingJobs bool `json:"cancelPendingJobs,omitempty"`
+
+	// RepositoryNames is the list of the repositories whose queued workflow runs are checked on deletion,
+	// in case the RunnerDeployment is for organizational runners.
+	// The repository of the runners is always checked in case it's for repository runners.
+	//
+	// +optional
+	RepositoryNames []string `json:"repositoryNames,omitempty"`
+}
+
+// RunnerDeploymentBlueGreen configures the blue/green rollout of template changes.
+//
+// On a template change, the controller creates the green RunnerReplicaSet for the new template next to the current blue one,
+// and gives it GreenPercent of the replicas while the blue one keeps the rest.
+// Once all the green runners have been available for VerificationPeriod, all the replicas are shifted to the green RunnerReplicaSet
+// and the blue one is drained. The rollout is aborted, keeping the blue RunnerReplicaSet, unless it's promoted within ProgressDeadline.
+type RunnerDeploymentBlueGreen struct {
+	// GreenPercent is the percentage of the replicas given to the green runners while they are verified.
+	// The green runners get at least one replica.
+	// Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	GreenPercent *int `json:"greenPercent,omitempty"`
+
+	// Labels is the runner labels added to the green runners while they are verified,
+	// so that the workflows can opt into the new template with `runs-on`.
+	// The green runners still run the jobs for the labels of the template.
+	//
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// Group is the runner group the green runners are registered to while they are verified, instead of the group of the template,
+	// so that only the repositories granted access to the group run their jobs on the new template.
+	//
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// VerificationPeriod is how long all the green runners must stay available before all the replicas are shifted to them.
+	// Defaults to 10m.
+	//
+	// +optional
+	VerificationPeriod *metav1.Duration `json:"verificationPeriod,omitempty"`
+
+	// ProgressDeadline is how long the controller waits for the green runners to be verified before aborting the rollout.
+	// Defaults to 30m.
+	//
+	// +optional
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// RunnerDeploymentStrategyType is the type of the RunnerDeploymentStrategy.
+//
+// +kubebuilder:validation:Enum=RollingUpdate;Recreate
+type RunnerDeploymentStrategyType string
+
+const (
+	// RunnerDeploymentStrategyRollingUpdate replaces the runners gradually within MaxSurge and MaxUnavailable.
+	RunnerDeploymentStrategyRollingUpdate RunnerDeploymentStrategyType = "RollingUpdate"
+
+	// RunnerDeploymentStrategyRecreate removes all the old runners before creating the new ones.
+	RunnerDeploymentStrategyRecreate RunnerDeploymentStrategyType = "Recreate"
+)
+
+// RunnerDeploymentStrategy configures how the runners are replaced on a template change.
+//
+// The old runners are removed by scaling down their RunnerReplicaSets, which removes idle runners first
+// and lets the busy runners complete their jobs before they are unregistered and deleted.
+type RunnerDeploymentStrategy struct {
+	// Type is either RollingUpdate or Recreate.
+	// Defaults to RollingUpdate.
+	//
+	// +optional
+	Type RunnerDeploymentStrategyType `json:"type,omitempty"`
+
+	// RollingUpdate configures the RollingUpdate strategy.
+	//
+	// +optional
+	RollingUpdate *RollingUpdateRunnerDeployment `json:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateRunnerDeployment configures the rolling update of the runners.
+type RollingUpdateRunnerDeployment struct {
+	// MaxUnavailable is the maximum number of the desired runners that can be unavailable during the update,
+	// either an absolute number or a percentage of the desired replicas rounded down.
+	// Old runners are removed only while the available runners are no less than the desired replicas minus MaxUnavailable.
+	// Defaults to 25%.
+	//
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// MaxSurge is the maximum number of the runners that can be created above the desired replicas during the update,
+	// either an absolute number or a percentage of the desired replicas rounded up.
+	// Busy old runners awaiting removal count towards it.
+	// Defaults to 25%. It can't be 0 when MaxUnavailable is 0.
+	//
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+}
+
+const (
+	// BlueGreenPhaseVerifying is the phase in which the green runners are given a share of the replicas to be verified.
+	BlueGreenPhaseVerifying = "Verifying"
+	// BlueGreenPhasePromoted is the phase after all the replicas are shifted to the green runners.
+	BlueGreenPhasePromoted = "Promoted"
+	// BlueGreenPhaseAborted is the phase after the green runners failed to be verified within the progress deadline.
+	// The template of the green runners is never rolled out again, until the template is changed.
+	BlueGreenPhaseAborted = "Aborted"
+)
+
+// RunnerDeploymentBlueGreenStatus is the state of the latest blue/green rollout.
+type RunnerDeploymentBlueGreenStatus struct {
+	// Phase is one of Verifying, Promoted and Aborted.
+	Phase string `json:"phase"`
+
+	// BlueTemplateHash and GreenTemplateHash are the template hashes of the blue and the green RunnerReplicaSets.
+	BlueTemplateHash  string `json:"blueTemplateHash"`
+	GreenTemplateHash string `json:"greenTemplateHash"`
+
+	// StartTime is when the green RunnerReplicaSet was created.
+	StartTime metav1.Time `json:"startTime"`
+
+	// HealthySince is when all the green runners became available, which is reset when any of them becomes unavailable.
+	//
+	// +optional
+	// +nullable
+	HealthySince *metav1.Time `json:"healthySince,omitempty"`
+
+	// CompletionTime is when the rollout was promoted or aborted.
+	//
+	// +optional
+	// +nullable
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message is the human-readable details of the phase.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RunnerEvictionProtection configures how the busy runners are protected from evictions.
+type RunnerEvictionProtection struct {
+	// PodDisruptionBudget makes the controller maintain a PodDisruptionBudget that disallows evicting the busy runner pods,
+	// so that node drains, which don't read the annotation of the cluster autoscaler, wait for their jobs to complete too.
+	// The PodDisruptionBudget is named after the RunnerDeployment, and deleted when this is disabled.
+	//
+	// +optional
+	PodDisruptionBudget bool `json:"podDisruptionBudget,omitempty"`
+}
+
+// RunnerRightsizing configures the resource requests recommended for the runner pods from their actual usage.
+type RunnerRightsizing struct {
+	// AutoApply makes the controller update the resource requests of the runner and docker containers of the template
+	// to the recommended ones, within MinAllowed and MaxAllowed.
+	// Updating the template replaces the runners like any other change to the template.
+	//
+	// +optional
+	AutoApply bool `json:"autoApply,omitempty"`
+
+	// HeadroomPercent is the percentage added to the peak usage to get the recommended requests.
+	// Defaults to 20.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	HeadroomPercent *int `json:"headroomPercent,omitempty"`
+
+	// MinChangePercent is how much the recommended requests must differ from the current ones to be applied,
+	// so that the runners aren't replaced for small changes.
+	// Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinChangePercent *int `json:"minChangePercent,omitempty"`
+
+	// MinApplyInterval is the minimum interval between two applications of the recommendations.
+	// Defaults to 24h.
+	//
+	// +optional
+	MinApplyInterval *metav1.Duration `json:"minApplyInterval,omitempty"`
+
+	// MinAllowed is the lower bound of the applied requests, like `cpu: 500m`.
+	//
+	// +optional
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+
+	// MaxAllowed is the upper bound of the applied requests, like `memory: 8Gi`.
+	// The requests are also capped at the limits of the containers.
+	//
+	// +optional
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// RunnerResourceRecommendation is the resource requests recommended for the containers of the runner pods.
+type RunnerResourceRecommendation struct {
+	// Containers is the recommendation for each container of the runner pods, like runner and docker.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Containers []ContainerResourceRecommendation `json:"containers,omitempty"`
+
+	// Samples is the number of the samples the recommendation is based on.
+	// The recommendation is applied only after enough samples are taken.
+	Samples int `json:"samples"`
+
+	// LastSampleTime is the time of the latest sample.
+	//
+	// +optional
+	// +nullable
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
+
+	// LastAppliedTime is when the recommendation was applied to the template for the last time.
+	//
+	// +optional
+	// +nullable
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+}
+
+// ContainerResourceRecommendation is the resource requests recommended for a container of the runner pods.
+type ContainerResourceRecommendation struct {
+	// Name is the name of the container.
+	Name string `json:"name"`
+
+	// Peak is the peak usage of the container across the runner pods.
+	// It decays over time so that the recommendation follows a decrease of the usage.
+	Peak corev1.ResourceList `json:"peak,omitempty"`
+
+	// Requests is the recommended requests, which is the peak usage plus the headroom.
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+}
+
+// ZoneRebalanceSpec configures the replacement of idle runners in the zones crowded beyond the maxSkew of
+// the topologySpreadConstraint on `topology.kubernetes.io/zone` of the runner template.
+// The replacement runners are spread by the scheduler according to the constraint.
+type ZoneRebalanceSpec struct {
+	// IdleSeconds is the duration for which no runner must have been created before the runners are rebalanced,
+	// so that runners are never replaced while the RunnerReplicaSet is scaling.
+	// Defaults to 600.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	IdleSeconds *int `json:"idleSeconds,omitempty"`
+
+	// MaxReplacements is the churn budget, which is the maximum number of runners replaced within Period.
+	// Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxReplacements *int `json:"maxReplacements,omitempty"`
+
+	// Period is the period of the churn budget.
+	// Defaults to 1h.
+	//
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
+}
+
+// WarmRunnerAffinity configures how the recent jobs of the runners, recorded in status.recentJobs of each runner
+// by the webhook-based autoscaler, bias the choice of the runners removed on scale in.
+// A runner is retained before another when the most active repository among the ones it recently ran jobs of
+// ran more jobs in the runner pool within Window. Busy runners are still retained before idle ones.
+type WarmRunnerAffinity struct {
+	// Window is the duration for which the jobs run by the runners are considered recent.
+	// Defaults to 1h.
+	//
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+}
+
+// ScheduledReplicas is the number of runners of a RunnerDeployment during the recurring windows.
+// The windows are defined the same way as the ScheduledOverride of HorizontalRunnerAutoscaler.
+type ScheduledReplicas struct {
+	// StartTime is the time at which the first window starts.
+	// Required unless Schedule is set.
+	// +optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is the time at which the first window ends.
+	// Required unless Schedule is set.
+	// +optional
+	EndTime metav1.Time `json:"endTime,omitempty"`
+
+	// Schedule is the standard 5-field cron expression, like "0 9 * * 1-5", denoting the times at which windows start.
+	// Each window lasts for Duration.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Duration is the duration of each window started by Schedule.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+
+	// TimeZone is the name of the IANA time zone, like "America/New_York", in which Schedule is evaluated and
+	// StartTime and EndTime recur.
+	// Defaults to UTC for Schedule, and to the offsets of StartTime and EndTime otherwise.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// +optional
+	RecurrenceRule RecurrenceRule `json:"recurrenceRule,omitempty"`
+
+	// Replicas is the number of runners during the windows.
+	// +kubebuilder:validation:Minimum=0
+	Replicas int `json:"replicas"`
+}
+
+// ScaleDownPolicy is the order in which the runners are chosen for removal on scale down.
+// Busy runners are retained before idle ones regardless of the policy.
+//
+// +kubebuilder:validation:Enum=OldestFirst;NewestFirst;LeastRecentlyBusy;IdleOnly
+type ScaleDownPolicy string
+
+const (
+	// ScaleDownPolicyOldestFirst removes the oldest runners first.
+	ScaleDownPolicyOldestFirst ScaleDownPolicy = "OldestFirst"
+
+	// ScaleDownPolicyNewestFirst removes the newest runners first, retaining the long-lived runners.
+	ScaleDownPolicyNewestFirst ScaleDownPolicy = "NewestFirst"
+
+	// ScaleDownPolicyLeastRecentlyBusy removes the runners that ran a job least recently first,
+	// so that the runners with freshly warmed caches are retained. The runners that have never run a job are removed first.
+	// The time a runner last ran a job is read from status.recentJobs and status.utilization of the runner.
+	ScaleDownPolicyLeastRecentlyBusy ScaleDownPolicy = "LeastRecentlyBusy"
+
+	// ScaleDownPolicyIdleOnly removes idle runners only, oldest first. Busy runners are never removed,
+	// even when that keeps more runners than desired until they complete their jobs,
+	// and no runner is removed while the busy runners are unknown.
+	ScaleDownPolicyIdleOnly ScaleDownPolicy = "IdleOnly"
+)
+
 type RunnerDeploymentStatus struct {
 	// See K8s deployment controller code for reference
 	// https://github.com/kubernetes/kubernetes/blob/ea0764452222146c47ec826977f49d7001b0ea8c/pkg/controller/deployment/sync.go#L487-L505
@@ -59,11 +526,16 @@ type RunnerDeploymentStatus struct {
 	// +optional
 	ReadyReplicas *int `json:"readyReplicas"`
 
-	// ReadyReplicas is the total number of available runners which have been successfully registered to GitHub and still running.
+	// UpdatedReplicas is the total number of runners of the desired template.
 	// This corresponds to status.replicas of the runner replica set that has the desired template hash.
 	// +optional
 	UpdatedReplicas *int `json:"updatedReplicas"`
 
+	// UpdatedReadyReplicas is the total number of available runners of the desired template.
+	// This corresponds to status.availableReplicas of the runner replica set that has the desired template hash.
+	// +optional
+	UpdatedReadyReplicas *int `json:"updatedReadyReplicas,omitempty"`
+
 	// DesiredReplicas is the total number of desired, non-terminated and latest pods to be set for the primary RunnerSet
 	// This doesn't include outdated pods while upgrading the deployment and replacing the runnerset.
 	// +optional
@@ -72,11 +544,91 @@ type RunnerDeploymentStatus struct {
 	// Replicas is the total number of replicas
 	// +optional
 	Replicas *int `json:"replicas"`
+
+	// Selector is the label selector of the runners of the runner deployment in the string form,
+	// which is exposed via the scale subresource for the HorizontalPodAutoscaler.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Conditions is the list of the latest observations of the runner pool.
+	// It currently contains the results of the latest canary job probe and runner version drift check, if enabled.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Utilization is the sum of the busy time and the number of jobs of all the runners of the runner deployment,
+	// including the ones already deleted, sampled by the controller when the runner utilization sampling is enabled.
+	// +optional
+	Utilization *RunnerUtilization `json:"utilization,omitempty"`
+
+	// UtilizationReport is the utilization of the runners of the runner deployment over the rolling window,
+	// sampled by the controller when the runner utilization report is enabled.
+	// +optional
+	UtilizationReport *RunnerUtilizationReport `json:"utilizationReport,omitempty"`
+
+	// ResourceRecommendation is the resource requests recommended for the runner pods from their actual usage,
+	// sampled by the controller when the runner right-sizing is enabled.
+	// +optional
+	ResourceRecommendation *RunnerResourceRecommendation `json:"resourceRecommendation,omitempty"`
+
+	// BlueGreen is the state of the latest blue/green rollout, when spec.blueGreen is set.
+	// +optional
+	BlueGreen *RunnerDeploymentBlueGreenStatus `json:"blueGreen,omitempty"`
+
+	// ActiveSchedule is the entry of spec.schedule whose window is active, if any.
+	// +optional
+	ActiveSchedule *ActiveScheduledReplicas `json:"activeSchedule,omitempty"`
+
+	// RunnerGroup is the name of the runner group resolved from spec.runnerGroupRef, which the runners are registered to.
+	// +optional
+	RunnerGroup string `json:"runnerGroup,omitempty"`
+}
+
+// RunnerUtilizationReport is the utilization of a runner pool over a rolling window,
+// for reviewing whether the pool is over-provisioned.
+type RunnerUtilizationReport struct {
+	// Window is the length of the rolling window, which is rounded up to hours.
+	Window metav1.Duration `json:"window"`
+	// RegisteredSeconds is the seconds the runners have been registered to GitHub within the window, summed up across the runners.
+	RegisteredSeconds int64 `json:"registeredSeconds"`
+	// BusySeconds is the seconds the runners have been busy running jobs within the window, summed up across the runners.
+	BusySeconds int64 `json:"busySeconds"`
+	// UtilizationPercent is BusySeconds divided by RegisteredSeconds, in percent.
+	UtilizationPercent int `json:"utilizationPercent"`
+	// PeakRunners is the largest number of runners registered at the same time within the window.
+	PeakRunners int `json:"peakRunners"`
+	// PeakBusyRunners is the largest number of runners busy at the same time within the window.
+	PeakBusyRunners int `json:"peakBusyRunners"`
+	// Buckets are the hourly utilizations within the window, oldest first, which the numbers above are summed up from.
+	// +optional
+	Buckets []RunnerUtilizationBucket `json:"buckets,omitempty"`
+}
+
+// RunnerUtilizationBucket is the utilization of a runner pool within the hour starting at StartTime.
+type RunnerUtilizationBucket struct {
+	StartTime         metav1.Time `json:"startTime"`
+	RegisteredSeconds int64       `json:"registeredSeconds"`
+	BusySeconds       int64       `json:"busySeconds"`
+	PeakRunners       int         `json:"peakRunners"`
+	PeakBusyRunners   int         `json:"peakBusyRunners"`
+}
+
+// ActiveScheduledReplicas is the entry at Index in RunnerDeploymentSpec.Schedule whose window is active.
+type ActiveScheduledReplicas struct {
+	Index int `json:"index"`
+
+	// Replicas is the number of runners desired during the window.
+	Replicas int `json:"replicas"`
+
+	// EndTime is the time at which the window ends.
+	EndTime metav1.Time `json:"endTime"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=rdeploy
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 // +kubebuilder:printcolumn:JSONPath=".spec.replicas",name=Desired,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.replicas",name=Current,type=number
 // +kubebuilder:printcolumn:JSONPath=".status.updatedReplicas",name=Up-To-Date,type=number
@@ -92,6 +644,16 @@ type RunnerDeployment struct {
 	Status RunnerDeploymentStatus `json:"status,omitempty"`
 }
 
+// RunnerGroupName returns the runner group the runners of the RunnerDeployment are registered to,
+// which is resolved from spec.runnerGroupRef when it's set.
+func (rd RunnerDeployment) RunnerGroupName() string {
+	if rd.Spec.RunnerGroupRef != nil {
+		return rd.Status.RunnerGroup
+	}
+
+	return rd.Spec.Template.Spec.Group
+}
+
 // +kubebuilder:object:root=true
 
 // RunnerList contains a list of Runner
@@ -17,8 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -76,9 +79,104 @@ func (r *RunnerDeployment) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "repository"), r.Spec.Template.Spec.Repository, err.Error()))
 	}
 
+	err = r.Spec.Template.Spec.ValidateRegistrationSecretRef()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "registrationSecretRef"), r.Spec.Template.Spec.RegistrationSecretRef, err.Error()))
+	}
+
+	err = r.Spec.Template.Spec.ValidateJobTimeouts()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "jobTimeouts"), r.Spec.Template.Spec.JobTimeouts, err.Error()))
+	}
+
+	err = r.Spec.Template.Spec.ValidateDnsPolicy()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "dnsPolicy"), r.Spec.Template.Spec.DnsPolicy, err.Error()))
+	}
+
+	errList = append(errList, r.Spec.Template.Spec.ValidateScripts(field.NewPath("spec", "template", "spec"))...)
+	errList = append(errList, r.Spec.Template.Spec.ValidateCertificateAuthorities(field.NewPath("spec", "template", "spec"))...)
+	errList = append(errList, r.Spec.Template.Spec.ValidateNetworkExposure(field.NewPath("spec", "template", "spec"))...)
+
+	errList = append(errList, r.Spec.Template.Spec.ValidateExtendedResources(r.Spec.Template.Spec.Resources, field.NewPath("spec", "template", "spec"))...)
+
+	err = r.Spec.Template.Spec.ValidatePodTemplate()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "podTemplate"), string(r.Spec.Template.Spec.PodTemplate.Raw), err.Error()))
+	}
+
+	if ref := r.Spec.RunnerGroupRef; ref != nil {
+		if r.Spec.Template.Spec.Group != "" {
+			errList = append(errList, field.Invalid(field.NewPath("spec", "runnerGroupRef"), ref.Name, "runnerGroupRef can't be used along with spec.template.spec.group"))
+		}
+
+		if r.Spec.Template.Spec.Repository != "" {
+			errList = append(errList, field.Invalid(field.NewPath("spec", "runnerGroupRef"), ref.Name, "runnerGroupRef can be used only for organizational and enterprise runners"))
+		}
+	}
+
+	if s := r.Spec.Strategy; s != nil {
+		if r.Spec.BlueGreen != nil {
+			errList = append(errList, field.Invalid(field.NewPath("spec", "strategy"), s.Type, "strategy can't be used along with spec.blueGreen"))
+		}
+
+		if s.RollingUpdate != nil {
+			if s.Type == RunnerDeploymentStrategyRecreate {
+				errList = append(errList, field.Forbidden(field.NewPath("spec", "strategy", "rollingUpdate"), "rollingUpdate can't be used along with the Recreate strategy"))
+			} else {
+				path := field.NewPath("spec", "strategy", "rollingUpdate")
+
+				maxSurge, err := validateIntOrPercent(s.RollingUpdate.MaxSurge)
+				if err != nil {
+					errList = append(errList, field.Invalid(path.Child("maxSurge"), s.RollingUpdate.MaxSurge, err.Error()))
+				}
+
+				maxUnavailable, err := validateIntOrPercent(s.RollingUpdate.MaxUnavailable)
+				if err != nil {
+					errList = append(errList, field.Invalid(path.Child("maxUnavailable"), s.RollingUpdate.MaxUnavailable, err.Error()))
+				}
+
+				if maxSurge == 0 && maxUnavailable == 0 {
+					errList = append(errList, field.Invalid(path.Child("maxSurge"), s.RollingUpdate.MaxSurge, "maxSurge and maxUnavailable can't both be 0"))
+				}
+			}
+		}
+	}
+
+	sizes := map[string]bool{}
+	for _, l := range r.Spec.Template.Spec.Labels {
+		sizes[l] = true
+	}
+
+	for i, size := range r.Spec.Sizes {
+		if size == "" || sizes[size] {
+			errList = append(errList, field.Invalid(field.NewPath("spec", "sizes").Index(i), size, "sizes must be non-empty, unique and not included in the labels of the template"))
+		}
+
+		sizes[size] = true
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
 
 	return nil
 }
+
+// validateIntOrPercent returns the value scaled to the percentage of 100, which is 1 when v is nil as it's defaulted to a non-zero value.
+func validateIntOrPercent(v *intstr.IntOrString) (int, error) {
+	if v == nil {
+		return 1, nil
+	}
+
+	n, err := intstr.GetScaledValueFromIntOrPercent(v, 100, true)
+	if err != nil {
+		return -1, err
+	}
+
+	if n < 0 {
+		return -1, fmt.Errorf("must be 0 or greater")
+	}
+
+	return n, nil
+}
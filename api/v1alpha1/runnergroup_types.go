@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RunnerGroupVisibilityAll      = "all"
+	RunnerGroupVisibilitySelected = "selected"
+	RunnerGroupVisibilityPrivate  = "private"
+
+	RunnerGroupDeletionPolicyRetain = "Retain"
+	RunnerGroupDeletionPolicyDelete = "Delete"
+)
+
+// RunnerGroupSpec defines the desired state of RunnerGroup
+type RunnerGroupSpec struct {
+	// Enterprise is the GitHub enterprise the runner group is created in.
+	// Exactly one of Enterprise and Organization must be set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[^/]+$`
+	Enterprise string `json:"enterprise,omitempty"`
+
+	// Organization is the GitHub organization the runner group is created in.
+	// Exactly one of Enterprise and Organization must be set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[^/]+$`
+	Organization string `json:"organization,omitempty"`
+
+	// Name is the name of the runner group on GitHub. Defaults to the name of the RunnerGroup.
+	// An existing runner group of the name is adopted instead of creating a new one.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Visibility is which repositories, or organizations for an enterprise runner group, can use the runner group.
+	// "all" allows all of them, and "selected" allows only Repositories or Organizations.
+	// "private" allows only the private repositories of the organization, and isn't available to enterprise runner groups.
+	// Defaults to "all".
+	// +optional
+	// +kubebuilder:validation:Enum=all;selected;private
+	Visibility string `json:"visibility,omitempty"`
+
+	// Repositories is the names of the repositories of the organization that can use the runner group when Visibility is "selected".
+	// +optional
+	Repositories []string `json:"repositories,omitempty"`
+
+	// Organizations is the names of the organizations of the enterprise that can use the runner group when Visibility is "selected".
+	// +optional
+	Organizations []string `json:"organizations,omitempty"`
+
+	// AllowsPublicRepositories allows the public repositories to use the runner group.
+	// +optional
+	AllowsPublicRepositories bool `json:"allowsPublicRepositories,omitempty"`
+
+	// DeletionPolicy is what the controller does with the runner group on GitHub on deletion of the RunnerGroup.
+	// "Retain" leaves it as is, and "Delete" deletes it. Defaults to "Retain".
+	// +optional
+	// +kubebuilder:validation:Enum=Retain;Delete
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// GitHubAPICredentialsFrom makes the controller manage the runner group with the GitHub API credentials stored in the referenced Secret,
+	// instead of the credentials of the controller.
+	// +optional
+	GitHubAPICredentialsFrom *GitHubAPICredentialsFrom `json:"githubAPICredentialsFrom,omitempty"`
+}
+
+// RunnerGroupStatus defines the observed state of RunnerGroup
+type RunnerGroupStatus struct {
+	// ID is the ID of the runner group on GitHub.
+	// +optional
+	ID int64 `json:"id,omitempty"`
+
+	// Name is the name of the runner group on GitHub, which the runners of the RunnerDeployments referencing the RunnerGroup are registered to.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// ObservedGeneration is the generation of the RunnerGroup last synced to GitHub.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the time the runner group was last synced to GitHub.
+	// +optional
+	// +nullable
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions is the list of the latest observations of the runner group.
+	// It contains the Synced condition telling whether the runner group on GitHub matches the spec.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=".spec.enterprise",name=Enterprise,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.organization",name=Organization,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.name",name=Group,type=string
+// +kubebuilder:printcolumn:JSONPath=".spec.visibility",name=Visibility,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.conditions[?(@.type=='Synced')].status",name=Synced,type=string
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RunnerGroup is the Schema for the runnergroups API
+type RunnerGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RunnerGroupSpec   `json:"spec,omitempty"`
+	Status RunnerGroupStatus `json:"status,omitempty"`
+}
+
+// GroupName returns the name of the runner group on GitHub.
+func (g RunnerGroup) GroupName() string {
+	if g.Spec.Name != "" {
+		return g.Spec.Name
+	}
+
+	return g.Name
+}
+
+// +kubebuilder:object:root=true
+
+// RunnerGroupList contains a list of RunnerGroup
+type RunnerGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunnerGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RunnerGroup{}, &RunnerGroupList{})
+}
@@ -35,6 +35,54 @@ type RunnerReplicaSetSpec struct {
 	// +nullable
 	EffectiveTime *metav1.Time `json:"effectiveTime"`
 
+	// WarmReplicas is the number of runners that are created and registered ahead of demand in addition to Replicas,
+	// but kept suspended without accepting any job until they are activated on scale out.
+	//
+	// +optional
+	// +nullable
+	WarmReplicas *int `json:"warmReplicas,omitempty"`
+
+	// BurstPriorityClassName is the priorityClassName given to the runners created for BurstReplicas.
+	//
+	// +optional
+	BurstPriorityClassName string `json:"burstPriorityClassName,omitempty"`
+
+	// BurstReplicas is the number of Replicas backed by the capacity reservations of the webhook-driven scale out.
+	// Runners created while the other runners already satisfy the rest of Replicas are given BurstPriorityClassName.
+	//
+	// +optional
+	// +nullable
+	BurstReplicas *int `json:"burstReplicas,omitempty"`
+
+	// Sizes is the list of the size labels advertised by the runners.
+	// Each runner is registered with exactly one of them, which is the first size unless SizeReplicas requires another.
+	//
+	// +optional
+	Sizes []string `json:"sizes,omitempty"`
+
+	// SizeReplicas is the number of Replicas reserved for each size label.
+	//
+	// +optional
+	SizeReplicas map[string]int `json:"sizeReplicas,omitempty"`
+
+	// ZoneRebalance enables replacing idle runners to restore the zone spread of the runner pods
+	// that got skewed by burst scale ups.
+	//
+	// +optional
+	ZoneRebalance *ZoneRebalanceSpec `json:"zoneRebalance,omitempty"`
+
+	// WarmRunnerAffinity makes the scale in retain the idle runners that recently ran jobs of the repositories
+	// most active in the runner pool.
+	//
+	// +optional
+	WarmRunnerAffinity *WarmRunnerAffinity `json:"warmRunnerAffinity,omitempty"`
+
+	// ScaleDownPolicy is the order in which the runners are chosen for removal on scale down.
+	// Defaults to OldestFirst.
+	//
+	// +optional
+	ScaleDownPolicy ScaleDownPolicy `json:"scaleDownPolicy,omitempty"`
+
 	// +optional
 	// +nullable
 	Selector *metav1.LabelSelector `json:"selector"`
@@ -55,6 +103,11 @@ type RunnerReplicaSetStatus struct {
 	// AvailableReplicas is the number of runners that are created and Runnning.
 	// This is currently same as ReadyReplicas but perserved for future use.
 	AvailableReplicas *int `json:"availableReplicas"`
+
+	// ZoneRebalanceTimes is the times the runners were replaced to rebalance the zones within the period of
+	// the churn budget. See RunnerReplicaSetSpec.ZoneRebalance.
+	// +optional
+	ZoneRebalanceTimes []metav1.Time `json:"zoneRebalanceTimes,omitempty"`
 }
 
 type RunnerTemplate struct {
@@ -76,6 +76,32 @@ func (r *RunnerReplicaSet) Validate() error {
 		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "repository"), r.Spec.Template.Spec.Repository, err.Error()))
 	}
 
+	err = r.Spec.Template.Spec.ValidateRegistrationSecretRef()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "registrationSecretRef"), r.Spec.Template.Spec.RegistrationSecretRef, err.Error()))
+	}
+
+	err = r.Spec.Template.Spec.ValidateJobTimeouts()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "jobTimeouts"), r.Spec.Template.Spec.JobTimeouts, err.Error()))
+	}
+
+	err = r.Spec.Template.Spec.ValidateDnsPolicy()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "dnsPolicy"), r.Spec.Template.Spec.DnsPolicy, err.Error()))
+	}
+
+	errList = append(errList, r.Spec.Template.Spec.ValidateScripts(field.NewPath("spec", "template", "spec"))...)
+	errList = append(errList, r.Spec.Template.Spec.ValidateCertificateAuthorities(field.NewPath("spec", "template", "spec"))...)
+	errList = append(errList, r.Spec.Template.Spec.ValidateNetworkExposure(field.NewPath("spec", "template", "spec"))...)
+
+	errList = append(errList, r.Spec.Template.Spec.ValidateExtendedResources(r.Spec.Template.Spec.Resources, field.NewPath("spec", "template", "spec"))...)
+
+	err = r.Spec.Template.Spec.ValidatePodTemplate()
+	if err != nil {
+		errList = append(errList, field.Invalid(field.NewPath("spec", "template", "spec", "podTemplate"), string(r.Spec.Template.Spec.PodTemplate.Raw), err.Error()))
+	}
+
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
 	}
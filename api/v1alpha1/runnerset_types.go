@@ -63,6 +63,13 @@ type RunnerSetStatus struct {
 	// Replicas is the total number of replicas
 	// +optional
 	Replicas *int `json:"replicas"`
+
+	// Conditions is the list of the latest observations of the runner pool.
+	// It currently contains the results of the latest canary job probe and runner version drift check, if enabled.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -22,11 +22,28 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveScheduledReplicas) DeepCopyInto(out *ActiveScheduledReplicas) {
+	*out = *in
+	in.EndTime.DeepCopyInto(&out.EndTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveScheduledReplicas.
+func (in *ActiveScheduledReplicas) DeepCopy() *ActiveScheduledReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveScheduledReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CacheEntry) DeepCopyInto(out *CacheEntry) {
 	*out = *in
@@ -60,6 +77,46 @@ func (in *CapacityReservation) DeepCopy() *CapacityReservation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityReservationRunner) DeepCopyInto(out *CapacityReservationRunner) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityReservationRunner.
+func (in *CapacityReservationRunner) DeepCopy() *CapacityReservationRunner {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityReservationRunner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAuthority) DeepCopyInto(out *CertificateAuthority) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAuthority.
+func (in *CertificateAuthority) DeepCopy() *CertificateAuthority {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAuthority)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CheckRunSpec) DeepCopyInto(out *CheckRunSpec) {
 	*out = *in
@@ -90,6 +147,204 @@ func (in *CheckRunSpec) DeepCopy() *CheckRunSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerResourceRecommendation) DeepCopyInto(out *ContainerResourceRecommendation) {
+	*out = *in
+	if in.Peak != nil {
+		in, out := &in.Peak, &out.Peak
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResourceRecommendation.
+func (in *ContainerResourceRecommendation) DeepCopy() *ContainerResourceRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DegradedDependency) DeepCopyInto(out *DegradedDependency) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DegradedDependency.
+func (in *DegradedDependency) DeepCopy() *DegradedDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(DegradedDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DelegatedReplicas) DeepCopyInto(out *DelegatedReplicas) {
+	*out = *in
+	if in.LastScaleUpTime != nil {
+		in, out := &in.LastScaleUpTime, &out.LastScaleUpTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DelegatedReplicas.
+func (in *DelegatedReplicas) DeepCopy() *DelegatedReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(DelegatedReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencyConditionCheck) DeepCopyInto(out *DependencyConditionCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencyConditionCheck.
+func (in *DependencyConditionCheck) DeepCopy() *DependencyConditionCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencyConditionCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencyHTTPGetCheck) DeepCopyInto(out *DependencyHTTPGetCheck) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencyHTTPGetCheck.
+func (in *DependencyHTTPGetCheck) DeepCopy() *DependencyHTTPGetCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencyHTTPGetCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencyHealthCheck) DeepCopyInto(out *DependencyHealthCheck) {
+	*out = *in
+	if in.HTTPGet != nil {
+		in, out := &in.HTTPGet, &out.HTTPGet
+		*out = new(DependencyHTTPGetCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(DependencyConditionCheck)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencyHealthCheck.
+func (in *DependencyHealthCheck) DeepCopy() *DependencyHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencyHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricSpec) DeepCopyInto(out *ExternalMetricSpec) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricSpec.
+func (in *ExternalMetricSpec) DeepCopy() *ExternalMetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationMember) DeepCopyInto(out *FederationMember) {
+	*out = *in
+	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationMember.
+func (in *FederationMember) DeepCopy() *FederationMember {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHubAPICredentialsFrom) DeepCopyInto(out *GitHubAPICredentialsFrom) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHubAPICredentialsFrom.
+func (in *GitHubAPICredentialsFrom) DeepCopy() *GitHubAPICredentialsFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHubAPICredentialsFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitHubAPIError) DeepCopyInto(out *GitHubAPIError) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.CircuitOpenUntil != nil {
+		in, out := &in.CircuitOpenUntil, &out.CircuitOpenUntil
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitHubAPIError.
+func (in *GitHubAPIError) DeepCopy() *GitHubAPIError {
+	if in == nil {
+		return nil
+	}
+	out := new(GitHubAPIError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubEventScaleUpTriggerSpec) DeepCopyInto(out *GitHubEventScaleUpTriggerSpec) {
 	*out = *in
@@ -111,7 +366,7 @@ func (in *GitHubEventScaleUpTriggerSpec) DeepCopyInto(out *GitHubEventScaleUpTri
 	if in.WorkflowJob != nil {
 		in, out := &in.WorkflowJob, &out.WorkflowJob
 		*out = new(WorkflowJobSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -184,10 +439,57 @@ func (in *HorizontalRunnerAutoscalerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HorizontalRunnerAutoscalerOverflow) DeepCopyInto(out *HorizontalRunnerAutoscalerOverflow) {
+	*out = *in
+	if in.BorrowFrom != nil {
+		in, out := &in.BorrowFrom, &out.BorrowFrom
+		*out = new(OverflowBorrow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = make([]FederationMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]OverflowSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PublishAfterSeconds != nil {
+		in, out := &in.PublishAfterSeconds, &out.PublishAfterSeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRunnerAutoscalerOverflow.
+func (in *HorizontalRunnerAutoscalerOverflow) DeepCopy() *HorizontalRunnerAutoscalerOverflow {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalRunnerAutoscalerOverflow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HorizontalRunnerAutoscalerSpec) DeepCopyInto(out *HorizontalRunnerAutoscalerSpec) {
 	*out = *in
 	out.ScaleTargetRef = in.ScaleTargetRef
+	if in.GitHubAPICredentialsFrom != nil {
+		in, out := &in.GitHubAPICredentialsFrom, &out.GitHubAPICredentialsFrom
+		*out = new(GitHubAPICredentialsFrom)
+		**out = **in
+	}
+	if in.SyncPeriod != nil {
+		in, out := &in.SyncPeriod, &out.SyncPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.MinReplicas != nil {
 		in, out := &in.MinReplicas, &out.MinReplicas
 		*out = new(int)
@@ -203,6 +505,58 @@ func (in *HorizontalRunnerAutoscalerSpec) DeepCopyInto(out *HorizontalRunnerAuto
 		*out = new(int)
 		**out = **in
 	}
+	if in.ScaleDownStabilizationWindowSeconds != nil {
+		in, out := &in.ScaleDownStabilizationWindowSeconds, &out.ScaleDownStabilizationWindowSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.ScaleDownMaxStep != nil {
+		in, out := &in.ScaleDownMaxStep, &out.ScaleDownMaxStep
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.ScaleUpMaxStep != nil {
+		in, out := &in.ScaleUpMaxStep, &out.ScaleUpMaxStep
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.ScaleUpPeriodSeconds != nil {
+		in, out := &in.ScaleUpPeriodSeconds, &out.ScaleUpPeriodSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.PickupConfirmationTimeoutSeconds != nil {
+		in, out := &in.PickupConfirmationTimeoutSeconds, &out.PickupConfirmationTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.ScalingHistoryLimit != nil {
+		in, out := &in.ScalingHistoryLimit, &out.ScalingHistoryLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxUnschedulableReplicas != nil {
+		in, out := &in.MaxUnschedulableReplicas, &out.MaxUnschedulableReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int)
+		**out = **in
+	}
+	if in.Overflow != nil {
+		in, out := &in.Overflow, &out.Overflow
+		*out = new(HorizontalRunnerAutoscalerOverflow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependencyHealthChecks != nil {
+		in, out := &in.DependencyHealthChecks, &out.DependencyHealthChecks
+		*out = make([]DependencyHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Metrics != nil {
 		in, out := &in.Metrics, &out.Metrics
 		*out = make([]MetricSpec, len(*in))
@@ -251,6 +605,11 @@ func (in *HorizontalRunnerAutoscalerStatus) DeepCopyInto(out *HorizontalRunnerAu
 		*out = new(int)
 		**out = **in
 	}
+	if in.RecommendedReplicas != nil {
+		in, out := &in.RecommendedReplicas, &out.RecommendedReplicas
+		*out = new(int)
+		**out = **in
+	}
 	if in.LastSuccessfulScaleOutTime != nil {
 		in, out := &in.LastSuccessfulScaleOutTime, &out.LastSuccessfulScaleOutTime
 		*out = (*in).DeepCopy()
@@ -267,178 +626,1056 @@ func (in *HorizontalRunnerAutoscalerStatus) DeepCopyInto(out *HorizontalRunnerAu
 		*out = new(string)
 		**out = **in
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRunnerAutoscalerStatus.
-func (in *HorizontalRunnerAutoscalerStatus) DeepCopy() *HorizontalRunnerAutoscalerStatus {
-	if in == nil {
-		return nil
+	if in.ScheduledOverrides != nil {
+		in, out := &in.ScheduledOverrides, &out.ScheduledOverrides
+		*out = make([]ScheduledOverrideStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out := new(HorizontalRunnerAutoscalerStatus)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
-	*out = *in
-	if in.RepositoryNames != nil {
-		in, out := &in.RepositoryNames, &out.RepositoryNames
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]MetricStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnschedulableReplicas != nil {
+		in, out := &in.UnschedulableReplicas, &out.UnschedulableReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.RequestedReplicas != nil {
+		in, out := &in.RequestedReplicas, &out.RequestedReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.GrantedReplicas != nil {
+		in, out := &in.GrantedReplicas, &out.GrantedReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.ShortfallReplicas != nil {
+		in, out := &in.ShortfallReplicas, &out.ShortfallReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.BorrowedReplicas != nil {
+		in, out := &in.BorrowedReplicas, &out.BorrowedReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.DelegatedReplicas != nil {
+		in, out := &in.DelegatedReplicas, &out.DelegatedReplicas
+		*out = make([]DelegatedReplicas, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PublishedShortfallReplicas != nil {
+		in, out := &in.PublishedShortfallReplicas, &out.PublishedShortfallReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.PickupReservations != nil {
+		in, out := &in.PickupReservations, &out.PickupReservations
+		*out = make([]PickupReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReservedReplicas != nil {
+		in, out := &in.ReservedReplicas, &out.ReservedReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.CapacityReservationsExpirationTime != nil {
+		in, out := &in.CapacityReservationsExpirationTime, &out.CapacityReservationsExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CapacityReservationRunners != nil {
+		in, out := &in.CapacityReservationRunners, &out.CapacityReservationRunners
+		*out = make([]CapacityReservationRunner, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleDownRecommendations != nil {
+		in, out := &in.ScaleDownRecommendations, &out.ScaleDownRecommendations
+		*out = make([]ReplicaRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DegradedDependencies != nil {
+		in, out := &in.DegradedDependencies, &out.DegradedDependencies
+		*out = make([]DegradedDependency, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastAPIError != nil {
+		in, out := &in.LastAPIError, &out.LastAPIError
+		*out = new(GitHubAPIError)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScalingHistory != nil {
+		in, out := &in.ScalingHistory, &out.ScalingHistory
+		*out = make([]ScalingDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRunnerAutoscalerStatus.
+func (in *HorizontalRunnerAutoscalerStatus) DeepCopy() *HorizontalRunnerAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalRunnerAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobTimeout) DeepCopyInto(out *JobTimeout) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTimeout.
+func (in *JobTimeout) DeepCopy() *JobTimeout {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTimeout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobTimeouts) DeepCopyInto(out *JobTimeouts) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]JobTimeout, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grace != nil {
+		in, out := &in.Grace, &out.Grace
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobTimeouts.
+func (in *JobTimeouts) DeepCopy() *JobTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(JobTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
+	*out = *in
+	if in.RepositoryNames != nil {
+		in, out := &in.RepositoryNames, &out.RepositoryNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RepositorySelector != nil {
+		in, out := &in.RepositorySelector, &out.RepositorySelector
+		*out = new(RepositorySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RepositoryWeights != nil {
+		in, out := &in.RepositoryWeights, &out.RepositoryWeights
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Workflows != nil {
+		in, out := &in.Workflows, &out.Workflows
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleUpWaitTime != nil {
+		in, out := &in.ScaleUpWaitTime, &out.ScaleUpWaitTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScaleDownWaitTime != nil {
+		in, out := &in.ScaleDownWaitTime, &out.ScaleDownWaitTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ScaleDownDelaySecondsAfterScaleOut != nil {
+		in, out := &in.ScaleDownDelaySecondsAfterScaleOut, &out.ScaleDownDelaySecondsAfterScaleOut
+		*out = new(int)
+		**out = **in
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalMetricSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
+func (in *MetricSpec) DeepCopy() *MetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricStatus) DeepCopyInto(out *MetricStatus) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]RepositoryWorkflowJobs, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricStatus.
+func (in *MetricStatus) DeepCopy() *MetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverflowBorrow) DeepCopyInto(out *OverflowBorrow) {
+	*out = *in
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverflowBorrow.
+func (in *OverflowBorrow) DeepCopy() *OverflowBorrow {
+	if in == nil {
+		return nil
+	}
+	out := new(OverflowBorrow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverflowHorizontalRunnerAutoscalerSink) DeepCopyInto(out *OverflowHorizontalRunnerAutoscalerSink) {
+	*out = *in
+	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverflowHorizontalRunnerAutoscalerSink.
+func (in *OverflowHorizontalRunnerAutoscalerSink) DeepCopy() *OverflowHorizontalRunnerAutoscalerSink {
+	if in == nil {
+		return nil
+	}
+	out := new(OverflowHorizontalRunnerAutoscalerSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverflowSink) DeepCopyInto(out *OverflowSink) {
+	*out = *in
+	if in.HorizontalRunnerAutoscaler != nil {
+		in, out := &in.HorizontalRunnerAutoscaler, &out.HorizontalRunnerAutoscaler
+		*out = new(OverflowHorizontalRunnerAutoscalerSink)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverflowSink.
+func (in *OverflowSink) DeepCopy() *OverflowSink {
+	if in == nil {
+		return nil
+	}
+	out := new(OverflowSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PickupReservation) DeepCopyInto(out *PickupReservation) {
+	*out = *in
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
+	in.ExpirationTime.DeepCopyInto(&out.ExpirationTime)
+	if in.ConfirmedRunners != nil {
+		in, out := &in.ConfirmedRunners, &out.ConfirmedRunners
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PickupReservation.
+func (in *PickupReservation) DeepCopy() *PickupReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(PickupReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullRequestSpec) DeepCopyInto(out *PullRequestSpec) {
+	*out = *in
+	if in.Types != nil {
+		in, out := &in.Types, &out.Types
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullRequestSpec.
+func (in *PullRequestSpec) DeepCopy() *PullRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PullRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PushSpec) DeepCopyInto(out *PushSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushSpec.
+func (in *PushSpec) DeepCopy() *PushSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PushSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecurrenceRule) DeepCopyInto(out *RecurrenceRule) {
+	*out = *in
+	in.UntilTime.DeepCopyInto(&out.UntilTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurrenceRule.
+func (in *RecurrenceRule) DeepCopy() *RecurrenceRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RecurrenceRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaRecommendation) DeepCopyInto(out *ReplicaRecommendation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaRecommendation.
+func (in *ReplicaRecommendation) DeepCopy() *ReplicaRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositorySelector) DeepCopyInto(out *RepositorySelector) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositorySelector.
+func (in *RepositorySelector) DeepCopy() *RepositorySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositorySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryWorkflowJobs) DeepCopyInto(out *RepositoryWorkflowJobs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryWorkflowJobs.
+func (in *RepositoryWorkflowJobs) DeepCopy() *RepositoryWorkflowJobs {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryWorkflowJobs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateRunnerDeployment) DeepCopyInto(out *RollingUpdateRunnerDeployment) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateRunnerDeployment.
+func (in *RollingUpdateRunnerDeployment) DeepCopy() *RollingUpdateRunnerDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateRunnerDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Runner) DeepCopyInto(out *Runner) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Runner.
+func (in *Runner) DeepCopy() *Runner {
+	if in == nil {
+		return nil
+	}
+	out := new(Runner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Runner) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerBudget) DeepCopyInto(out *RunnerBudget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerBudget.
+func (in *RunnerBudget) DeepCopy() *RunnerBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunnerBudget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerBudgetList) DeepCopyInto(out *RunnerBudgetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RunnerBudget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerBudgetList.
+func (in *RunnerBudgetList) DeepCopy() *RunnerBudgetList {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerBudgetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunnerBudgetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerBudgetSpec) DeepCopyInto(out *RunnerBudgetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerBudgetSpec.
+func (in *RunnerBudgetSpec) DeepCopy() *RunnerBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerConfig) DeepCopyInto(out *RunnerConfig) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ephemeral != nil {
+		in, out := &in.Ephemeral, &out.Ephemeral
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DockerdWithinRunnerContainer != nil {
+		in, out := &in.DockerdWithinRunnerContainer, &out.DockerdWithinRunnerContainer
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DockerEnabled != nil {
+		in, out := &in.DockerEnabled, &out.DockerEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DockerRootless != nil {
+		in, out := &in.DockerRootless, &out.DockerRootless
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DockerMTU != nil {
+		in, out := &in.DockerMTU, &out.DockerMTU
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DockerRegistryMirror != nil {
+		in, out := &in.DockerRegistryMirror, &out.DockerRegistryMirror
+		*out = new(string)
+		**out = **in
+	}
+	if in.VolumeSizeLimit != nil {
+		in, out := &in.VolumeSizeLimit, &out.VolumeSizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.VolumeStorageMedium != nil {
+		in, out := &in.VolumeStorageMedium, &out.VolumeStorageMedium
+		*out = new(string)
+		**out = **in
+	}
+	if in.RegistrationSecretRef != nil {
+		in, out := &in.RegistrationSecretRef, &out.RegistrationSecretRef
+		*out = new(RunnerRegistrationSecretRef)
+		**out = **in
+	}
+	if in.GitHubAPICredentialsFrom != nil {
+		in, out := &in.GitHubAPICredentialsFrom, &out.GitHubAPICredentialsFrom
+		*out = new(GitHubAPICredentialsFrom)
+		**out = **in
+	}
+	if in.JobTimeouts != nil {
+		in, out := &in.JobTimeouts, &out.JobTimeouts
+		*out = new(JobTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreRunScript != nil {
+		in, out := &in.PreRunScript, &out.PreRunScript
+		*out = new(RunnerScript)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreJobScript != nil {
+		in, out := &in.PreJobScript, &out.PreJobScript
+		*out = new(RunnerScript)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostJobScript != nil {
+		in, out := &in.PostJobScript, &out.PostJobScript
+		*out = new(RunnerScript)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		*out = new(RunnerGPU)
+		**out = **in
+	}
+	if in.ExtendedResources != nil {
+		in, out := &in.ExtendedResources, &out.ExtendedResources
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.CertificateAuthorities != nil {
+		in, out := &in.CertificateAuthorities, &out.CertificateAuthorities
+		*out = make([]CertificateAuthority, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerConfig.
+func (in *RunnerConfig) DeepCopy() *RunnerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeployment) DeepCopyInto(out *RunnerDeployment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeployment.
+func (in *RunnerDeployment) DeepCopy() *RunnerDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunnerDeployment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeploymentBlueGreen) DeepCopyInto(out *RunnerDeploymentBlueGreen) {
+	*out = *in
+	if in.GreenPercent != nil {
+		in, out := &in.GreenPercent, &out.GreenPercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VerificationPeriod != nil {
+		in, out := &in.VerificationPeriod, &out.VerificationPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentBlueGreen.
+func (in *RunnerDeploymentBlueGreen) DeepCopy() *RunnerDeploymentBlueGreen {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerDeploymentBlueGreen)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeploymentBlueGreenStatus) DeepCopyInto(out *RunnerDeploymentBlueGreenStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.HealthySince != nil {
+		in, out := &in.HealthySince, &out.HealthySince
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentBlueGreenStatus.
+func (in *RunnerDeploymentBlueGreenStatus) DeepCopy() *RunnerDeploymentBlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerDeploymentBlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeploymentList) DeepCopyInto(out *RunnerDeploymentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RunnerDeployment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentList.
+func (in *RunnerDeploymentList) DeepCopy() *RunnerDeploymentList {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerDeploymentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunnerDeploymentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeploymentSpec) DeepCopyInto(out *RunnerDeploymentSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = make([]ScheduledReplicas, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EffectiveTime != nil {
+		in, out := &in.EffectiveTime, &out.EffectiveTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WarmReplicas != nil {
+		in, out := &in.WarmReplicas, &out.WarmReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.BurstReplicas != nil {
+		in, out := &in.BurstReplicas, &out.BurstReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Sizes != nil {
+		in, out := &in.Sizes, &out.Sizes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SizeReplicas != nil {
+		in, out := &in.SizeReplicas, &out.SizeReplicas
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ZoneRebalance != nil {
+		in, out := &in.ZoneRebalance, &out.ZoneRebalance
+		*out = new(ZoneRebalanceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WarmRunnerAffinity != nil {
+		in, out := &in.WarmRunnerAffinity, &out.WarmRunnerAffinity
+		*out = new(WarmRunnerAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TeardownPolicy != nil {
+		in, out := &in.TeardownPolicy, &out.TeardownPolicy
+		*out = new(RunnerDeploymentTeardownPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rightsizing != nil {
+		in, out := &in.Rightsizing, &out.Rightsizing
+		*out = new(RunnerRightsizing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EvictionProtection != nil {
+		in, out := &in.EvictionProtection, &out.EvictionProtection
+		*out = new(RunnerEvictionProtection)
+		**out = **in
+	}
+	if in.RunnerGroupRef != nil {
+		in, out := &in.RunnerGroupRef, &out.RunnerGroupRef
+		*out = new(RunnerGroupReference)
+		**out = **in
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(RunnerDeploymentBlueGreen)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(RunnerDeploymentStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentSpec.
+func (in *RunnerDeploymentSpec) DeepCopy() *RunnerDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerDeploymentStatus) DeepCopyInto(out *RunnerDeploymentStatus) {
+	*out = *in
+	if in.AvailableReplicas != nil {
+		in, out := &in.AvailableReplicas, &out.AvailableReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.ReadyReplicas != nil {
+		in, out := &in.ReadyReplicas, &out.ReadyReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.UpdatedReplicas != nil {
+		in, out := &in.UpdatedReplicas, &out.UpdatedReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.UpdatedReadyReplicas != nil {
+		in, out := &in.UpdatedReadyReplicas, &out.UpdatedReadyReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.DesiredReplicas != nil {
+		in, out := &in.DesiredReplicas, &out.DesiredReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Utilization != nil {
+		in, out := &in.Utilization, &out.Utilization
+		*out = new(RunnerUtilization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UtilizationReport != nil {
+		in, out := &in.UtilizationReport, &out.UtilizationReport
+		*out = new(RunnerUtilizationReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceRecommendation != nil {
+		in, out := &in.ResourceRecommendation, &out.ResourceRecommendation
+		*out = new(RunnerResourceRecommendation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(RunnerDeploymentBlueGreenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveSchedule != nil {
+		in, out := &in.ActiveSchedule, &out.ActiveSchedule
+		*out = new(ActiveScheduledReplicas)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
-func (in *MetricSpec) DeepCopy() *MetricSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentStatus.
+func (in *RunnerDeploymentStatus) DeepCopy() *RunnerDeploymentStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricSpec)
+	out := new(RunnerDeploymentStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PullRequestSpec) DeepCopyInto(out *PullRequestSpec) {
+func (in *RunnerDeploymentStrategy) DeepCopyInto(out *RunnerDeploymentStrategy) {
 	*out = *in
-	if in.Types != nil {
-		in, out := &in.Types, &out.Types
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Branches != nil {
-		in, out := &in.Branches, &out.Branches
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdateRunnerDeployment)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullRequestSpec.
-func (in *PullRequestSpec) DeepCopy() *PullRequestSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentStrategy.
+func (in *RunnerDeploymentStrategy) DeepCopy() *RunnerDeploymentStrategy {
 	if in == nil {
 		return nil
 	}
-	out := new(PullRequestSpec)
+	out := new(RunnerDeploymentStrategy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PushSpec) DeepCopyInto(out *PushSpec) {
+func (in *RunnerDeploymentTeardownPolicy) DeepCopyInto(out *RunnerDeploymentTeardownPolicy) {
 	*out = *in
+	if in.RepositoryNames != nil {
+		in, out := &in.RepositoryNames, &out.RepositoryNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PushSpec.
-func (in *PushSpec) DeepCopy() *PushSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentTeardownPolicy.
+func (in *RunnerDeploymentTeardownPolicy) DeepCopy() *RunnerDeploymentTeardownPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(PushSpec)
+	out := new(RunnerDeploymentTeardownPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RecurrenceRule) DeepCopyInto(out *RecurrenceRule) {
+func (in *RunnerEvictionProtection) DeepCopyInto(out *RunnerEvictionProtection) {
 	*out = *in
-	in.UntilTime.DeepCopyInto(&out.UntilTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecurrenceRule.
-func (in *RecurrenceRule) DeepCopy() *RecurrenceRule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerEvictionProtection.
+func (in *RunnerEvictionProtection) DeepCopy() *RunnerEvictionProtection {
 	if in == nil {
 		return nil
 	}
-	out := new(RecurrenceRule)
+	out := new(RunnerEvictionProtection)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Runner) DeepCopyInto(out *Runner) {
+func (in *RunnerGPU) DeepCopyInto(out *RunnerGPU) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Runner.
-func (in *Runner) DeepCopy() *Runner {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGPU.
+func (in *RunnerGPU) DeepCopy() *RunnerGPU {
 	if in == nil {
 		return nil
 	}
-	out := new(Runner)
+	out := new(RunnerGPU)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Runner) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunnerConfig) DeepCopyInto(out *RunnerConfig) {
+func (in *RunnerGitHubStatus) DeepCopyInto(out *RunnerGitHubStatus) {
 	*out = *in
 	if in.Labels != nil {
 		in, out := &in.Labels, &out.Labels
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Ephemeral != nil {
-		in, out := &in.Ephemeral, &out.Ephemeral
-		*out = new(bool)
-		**out = **in
-	}
-	if in.DockerdWithinRunnerContainer != nil {
-		in, out := &in.DockerdWithinRunnerContainer, &out.DockerdWithinRunnerContainer
-		*out = new(bool)
-		**out = **in
-	}
-	if in.DockerEnabled != nil {
-		in, out := &in.DockerEnabled, &out.DockerEnabled
-		*out = new(bool)
-		**out = **in
-	}
-	if in.DockerMTU != nil {
-		in, out := &in.DockerMTU, &out.DockerMTU
-		*out = new(int64)
-		**out = **in
-	}
-	if in.DockerRegistryMirror != nil {
-		in, out := &in.DockerRegistryMirror, &out.DockerRegistryMirror
-		*out = new(string)
-		**out = **in
-	}
-	if in.VolumeSizeLimit != nil {
-		in, out := &in.VolumeSizeLimit, &out.VolumeSizeLimit
-		x := (*in).DeepCopy()
-		*out = &x
-	}
-	if in.VolumeStorageMedium != nil {
-		in, out := &in.VolumeStorageMedium, &out.VolumeStorageMedium
-		*out = new(string)
-		**out = **in
+	if in.LastBusyTime != nil {
+		in, out := &in.LastBusyTime, &out.LastBusyTime
+		*out = (*in).DeepCopy()
 	}
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerConfig.
-func (in *RunnerConfig) DeepCopy() *RunnerConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGitHubStatus.
+func (in *RunnerGitHubStatus) DeepCopy() *RunnerGitHubStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RunnerConfig)
+	out := new(RunnerGitHubStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunnerDeployment) DeepCopyInto(out *RunnerDeployment) {
+func (in *RunnerGroup) DeepCopyInto(out *RunnerGroup) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -446,18 +1683,18 @@ func (in *RunnerDeployment) DeepCopyInto(out *RunnerDeployment) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeployment.
-func (in *RunnerDeployment) DeepCopy() *RunnerDeployment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGroup.
+func (in *RunnerGroup) DeepCopy() *RunnerGroup {
 	if in == nil {
 		return nil
 	}
-	out := new(RunnerDeployment)
+	out := new(RunnerGroup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RunnerDeployment) DeepCopyObject() runtime.Object {
+func (in *RunnerGroup) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -465,31 +1702,31 @@ func (in *RunnerDeployment) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunnerDeploymentList) DeepCopyInto(out *RunnerDeploymentList) {
+func (in *RunnerGroupList) DeepCopyInto(out *RunnerGroupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]RunnerDeployment, len(*in))
+		*out = make([]RunnerGroup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentList.
-func (in *RunnerDeploymentList) DeepCopy() *RunnerDeploymentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGroupList.
+func (in *RunnerGroupList) DeepCopy() *RunnerGroupList {
 	if in == nil {
 		return nil
 	}
-	out := new(RunnerDeploymentList)
+	out := new(RunnerGroupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RunnerDeploymentList) DeepCopyObject() runtime.Object {
+func (in *RunnerGroupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -497,71 +1734,88 @@ func (in *RunnerDeploymentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunnerDeploymentSpec) DeepCopyInto(out *RunnerDeploymentSpec) {
+func (in *RunnerGroupReference) DeepCopyInto(out *RunnerGroupReference) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int)
-		**out = **in
-	}
-	if in.EffectiveTime != nil {
-		in, out := &in.EffectiveTime, &out.EffectiveTime
-		*out = (*in).DeepCopy()
-	}
-	if in.Selector != nil {
-		in, out := &in.Selector, &out.Selector
-		*out = new(metav1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentSpec.
-func (in *RunnerDeploymentSpec) DeepCopy() *RunnerDeploymentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGroupReference.
+func (in *RunnerGroupReference) DeepCopy() *RunnerGroupReference {
 	if in == nil {
 		return nil
 	}
-	out := new(RunnerDeploymentSpec)
+	out := new(RunnerGroupReference)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunnerDeploymentStatus) DeepCopyInto(out *RunnerDeploymentStatus) {
+func (in *RunnerGroupSpec) DeepCopyInto(out *RunnerGroupSpec) {
 	*out = *in
-	if in.AvailableReplicas != nil {
-		in, out := &in.AvailableReplicas, &out.AvailableReplicas
-		*out = new(int)
-		**out = **in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.ReadyReplicas != nil {
-		in, out := &in.ReadyReplicas, &out.ReadyReplicas
-		*out = new(int)
-		**out = **in
+	if in.Organizations != nil {
+		in, out := &in.Organizations, &out.Organizations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.UpdatedReplicas != nil {
-		in, out := &in.UpdatedReplicas, &out.UpdatedReplicas
-		*out = new(int)
+	if in.GitHubAPICredentialsFrom != nil {
+		in, out := &in.GitHubAPICredentialsFrom, &out.GitHubAPICredentialsFrom
+		*out = new(GitHubAPICredentialsFrom)
 		**out = **in
 	}
-	if in.DesiredReplicas != nil {
-		in, out := &in.DesiredReplicas, &out.DesiredReplicas
-		*out = new(int)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGroupSpec.
+func (in *RunnerGroupSpec) DeepCopy() *RunnerGroupSpec {
+	if in == nil {
+		return nil
 	}
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int)
-		**out = **in
+	out := new(RunnerGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerGroupStatus) DeepCopyInto(out *RunnerGroupStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerDeploymentStatus.
-func (in *RunnerDeploymentStatus) DeepCopy() *RunnerDeploymentStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerGroupStatus.
+func (in *RunnerGroupStatus) DeepCopy() *RunnerGroupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RunnerDeploymentStatus)
+	out := new(RunnerGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerJob) DeepCopyInto(out *RunnerJob) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerJob.
+func (in *RunnerJob) DeepCopy() *RunnerJob {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerJob)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -598,41 +1852,66 @@ func (in *RunnerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerPodRetention) DeepCopyInto(out *RunnerPodRetention) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FailedPodsLimit != nil {
+		in, out := &in.FailedPodsLimit, &out.FailedPodsLimit
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerPodRetention.
+func (in *RunnerPodRetention) DeepCopy() *RunnerPodRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerPodRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	*out = *in
 	in.DockerdContainerResources.DeepCopyInto(&out.DockerdContainerResources)
 	if in.DockerVolumeMounts != nil {
 		in, out := &in.DockerVolumeMounts, &out.DockerVolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]corev1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.DockerEnv != nil {
 		in, out := &in.DockerEnv, &out.DockerEnv
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.Containers != nil {
 		in, out := &in.Containers, &out.Containers
-		*out = make([]v1.Container, len(*in))
+		*out = make([]corev1.Container, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
-		*out = make([]v1.EnvFromSource, len(*in))
+		*out = make([]corev1.EnvFromSource, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -640,14 +1919,14 @@ func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]corev1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -659,7 +1938,7 @@ func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	}
 	if in.InitContainers != nil {
 		in, out := &in.InitContainers, &out.InitContainers
-		*out = make([]v1.Container, len(*in))
+		*out = make([]corev1.Container, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -678,29 +1957,29 @@ func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	}
 	if in.SidecarContainers != nil {
 		in, out := &in.SidecarContainers, &out.SidecarContainers
-		*out = make([]v1.Container, len(*in))
+		*out = make([]corev1.Container, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.SecurityContext != nil {
 		in, out := &in.SecurityContext, &out.SecurityContext
-		*out = new(v1.PodSecurityContext)
+		*out = new(corev1.PodSecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]corev1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
+		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -712,21 +1991,33 @@ func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	}
 	if in.EphemeralContainers != nil {
 		in, out := &in.EphemeralContainers, &out.EphemeralContainers
-		*out = make([]v1.EphemeralContainer, len(*in))
+		*out = make([]corev1.EphemeralContainer, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.HostAliases != nil {
 		in, out := &in.HostAliases, &out.HostAliases
-		*out = make([]v1.HostAlias, len(*in))
+		*out = make([]corev1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostPorts != nil {
+		in, out := &in.HostPorts, &out.HostPorts
+		*out = make([]corev1.ContainerPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]RunnerService, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.TopologySpreadConstraints != nil {
 		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -738,9 +2029,29 @@ func (in *RunnerPodSpec) DeepCopyInto(out *RunnerPodSpec) {
 	}
 	if in.DnsConfig != nil {
 		in, out := &in.DnsConfig, &out.DnsConfig
-		*out = new(v1.PodDNSConfig)
+		*out = new(corev1.PodDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Queueing != nil {
+		in, out := &in.Queueing, &out.Queueing
+		*out = new(RunnerQueueing)
+		**out = **in
+	}
+	if in.PodRetention != nil {
+		in, out := &in.PodRetention, &out.PodRetention
+		*out = new(RunnerPodRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.VariablesFrom != nil {
+		in, out := &in.VariablesFrom, &out.VariablesFrom
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerPodSpec.
@@ -753,6 +2064,36 @@ func (in *RunnerPodSpec) DeepCopy() *RunnerPodSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerQueueing) DeepCopyInto(out *RunnerQueueing) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerQueueing.
+func (in *RunnerQueueing) DeepCopy() *RunnerQueueing {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerQueueing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerRegistrationSecretRef) DeepCopyInto(out *RunnerRegistrationSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerRegistrationSecretRef.
+func (in *RunnerRegistrationSecretRef) DeepCopy() *RunnerRegistrationSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerRegistrationSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunnerReplicaSet) DeepCopyInto(out *RunnerReplicaSet) {
 	*out = *in
@@ -820,13 +2161,45 @@ func (in *RunnerReplicaSetSpec) DeepCopyInto(out *RunnerReplicaSetSpec) {
 		*out = new(int)
 		**out = **in
 	}
-	if in.EffectiveTime != nil {
-		in, out := &in.EffectiveTime, &out.EffectiveTime
-		*out = (*in).DeepCopy()
+	if in.EffectiveTime != nil {
+		in, out := &in.EffectiveTime, &out.EffectiveTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WarmReplicas != nil {
+		in, out := &in.WarmReplicas, &out.WarmReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.BurstReplicas != nil {
+		in, out := &in.BurstReplicas, &out.BurstReplicas
+		*out = new(int)
+		**out = **in
+	}
+	if in.Sizes != nil {
+		in, out := &in.Sizes, &out.Sizes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SizeReplicas != nil {
+		in, out := &in.SizeReplicas, &out.SizeReplicas
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ZoneRebalance != nil {
+		in, out := &in.ZoneRebalance, &out.ZoneRebalance
+		*out = new(ZoneRebalanceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WarmRunnerAffinity != nil {
+		in, out := &in.WarmRunnerAffinity, &out.WarmRunnerAffinity
+		*out = new(WarmRunnerAffinity)
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(metav1.LabelSelector)
+		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	in.Template.DeepCopyInto(&out.Template)
@@ -860,6 +2233,13 @@ func (in *RunnerReplicaSetStatus) DeepCopyInto(out *RunnerReplicaSetStatus) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.ZoneRebalanceTimes != nil {
+		in, out := &in.ZoneRebalanceTimes, &out.ZoneRebalanceTimes
+		*out = make([]v1.Time, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerReplicaSetStatus.
@@ -872,6 +2252,129 @@ func (in *RunnerReplicaSetStatus) DeepCopy() *RunnerReplicaSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerResourceRecommendation) DeepCopyInto(out *RunnerResourceRecommendation) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ContainerResourceRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSampleTime != nil {
+		in, out := &in.LastSampleTime, &out.LastSampleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAppliedTime != nil {
+		in, out := &in.LastAppliedTime, &out.LastAppliedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerResourceRecommendation.
+func (in *RunnerResourceRecommendation) DeepCopy() *RunnerResourceRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerRightsizing) DeepCopyInto(out *RunnerRightsizing) {
+	*out = *in
+	if in.HeadroomPercent != nil {
+		in, out := &in.HeadroomPercent, &out.HeadroomPercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinChangePercent != nil {
+		in, out := &in.MinChangePercent, &out.MinChangePercent
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinApplyInterval != nil {
+		in, out := &in.MinApplyInterval, &out.MinApplyInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinAllowed != nil {
+		in, out := &in.MinAllowed, &out.MinAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxAllowed != nil {
+		in, out := &in.MaxAllowed, &out.MaxAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerRightsizing.
+func (in *RunnerRightsizing) DeepCopy() *RunnerRightsizing {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerRightsizing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerScript) DeepCopyInto(out *RunnerScript) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerScript.
+func (in *RunnerScript) DeepCopy() *RunnerScript {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerScript)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerService) DeepCopyInto(out *RunnerService) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]corev1.ServicePort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerService.
+func (in *RunnerService) DeepCopy() *RunnerService {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerService)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunnerSet) DeepCopyInto(out *RunnerSet) {
 	*out = *in
@@ -980,6 +2483,13 @@ func (in *RunnerSetStatus) DeepCopyInto(out *RunnerSetStatus) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerSetStatus.
@@ -1017,6 +2527,23 @@ func (in *RunnerStatus) DeepCopyInto(out *RunnerStatus) {
 		in, out := &in.LastRegistrationCheckTime, &out.LastRegistrationCheckTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Utilization != nil {
+		in, out := &in.Utilization, &out.Utilization
+		*out = new(RunnerUtilization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecentJobs != nil {
+		in, out := &in.RecentJobs, &out.RecentJobs
+		*out = make([]RunnerJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GitHub != nil {
+		in, out := &in.GitHub, &out.GitHub
+		*out = new(RunnerGitHubStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerStatus.
@@ -1067,6 +2594,64 @@ func (in *RunnerTemplate) DeepCopy() *RunnerTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerUtilization) DeepCopyInto(out *RunnerUtilization) {
+	*out = *in
+	if in.LastSampleTime != nil {
+		in, out := &in.LastSampleTime, &out.LastSampleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerUtilization.
+func (in *RunnerUtilization) DeepCopy() *RunnerUtilization {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerUtilization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerUtilizationBucket) DeepCopyInto(out *RunnerUtilizationBucket) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerUtilizationBucket.
+func (in *RunnerUtilizationBucket) DeepCopy() *RunnerUtilizationBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerUtilizationBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunnerUtilizationReport) DeepCopyInto(out *RunnerUtilizationReport) {
+	*out = *in
+	out.Window = in.Window
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]RunnerUtilizationBucket, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunnerUtilizationReport.
+func (in *RunnerUtilizationReport) DeepCopy() *RunnerUtilizationReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RunnerUtilizationReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScaleTargetRef) DeepCopyInto(out *ScaleTargetRef) {
 	*out = *in
@@ -1103,16 +2688,78 @@ func (in *ScaleUpTrigger) DeepCopy() *ScaleUpTrigger {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingDecision) DeepCopyInto(out *ScalingDecision) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Observed != nil {
+		in, out := &in.Observed, &out.Observed
+		*out = new(ScalingObservation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingDecision.
+func (in *ScalingDecision) DeepCopy() *ScalingDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingObservation) DeepCopyInto(out *ScalingObservation) {
+	*out = *in
+	if in.QueuedWorkflowJobs != nil {
+		in, out := &in.QueuedWorkflowJobs, &out.QueuedWorkflowJobs
+		*out = new(int)
+		**out = **in
+	}
+	if in.InProgressWorkflowJobs != nil {
+		in, out := &in.InProgressWorkflowJobs, &out.InProgressWorkflowJobs
+		*out = new(int)
+		**out = **in
+	}
+	if in.BusyRunners != nil {
+		in, out := &in.BusyRunners, &out.BusyRunners
+		*out = new(int)
+		**out = **in
+	}
+	if in.DemandedReplicas != nil {
+		in, out := &in.DemandedReplicas, &out.DemandedReplicas
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingObservation.
+func (in *ScalingObservation) DeepCopy() *ScalingObservation {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingObservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScheduledOverride) DeepCopyInto(out *ScheduledOverride) {
 	*out = *in
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
+	out.Duration = in.Duration
 	if in.MinReplicas != nil {
 		in, out := &in.MinReplicas, &out.MinReplicas
 		*out = new(int)
 		**out = **in
 	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int)
+		**out = **in
+	}
 	in.RecurrenceRule.DeepCopyInto(&out.RecurrenceRule)
 }
 
@@ -1126,9 +2773,88 @@ func (in *ScheduledOverride) DeepCopy() *ScheduledOverride {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledOverrideStatus) DeepCopyInto(out *ScheduledOverrideStatus) {
+	*out = *in
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledOverrideStatus.
+func (in *ScheduledOverrideStatus) DeepCopy() *ScheduledOverrideStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledOverrideStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledReplicas) DeepCopyInto(out *ScheduledReplicas) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.EndTime.DeepCopyInto(&out.EndTime)
+	out.Duration = in.Duration
+	in.RecurrenceRule.DeepCopyInto(&out.RecurrenceRule)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledReplicas.
+func (in *ScheduledReplicas) DeepCopy() *ScheduledReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmRunnerAffinity) DeepCopyInto(out *WarmRunnerAffinity) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmRunnerAffinity.
+func (in *WarmRunnerAffinity) DeepCopy() *WarmRunnerAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmRunnerAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowJobSpec) DeepCopyInto(out *WorkflowJobSpec) {
 	*out = *in
+	if in.Workflows != nil {
+		in, out := &in.Workflows, &out.Workflows
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowJobSpec.
@@ -1140,3 +2866,33 @@ func (in *WorkflowJobSpec) DeepCopy() *WorkflowJobSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneRebalanceSpec) DeepCopyInto(out *ZoneRebalanceSpec) {
+	*out = *in
+	if in.IdleSeconds != nil {
+		in, out := &in.IdleSeconds, &out.IdleSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxReplacements != nil {
+		in, out := &in.MaxReplacements, &out.MaxReplacements
+		*out = new(int)
+		**out = **in
+	}
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneRebalanceSpec.
+func (in *ZoneRebalanceSpec) DeepCopy() *ZoneRebalanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneRebalanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/backtest"
+	"github.com/kelseyhightower/envconfig"
+	"sigs.k8s.io/yaml"
+)
+
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+
+	var (
+		file         string
+		repositories string
+		labels       string
+		days         int
+		jobsFile     string
+		saveJobs     string
+		timeline     string
+		output       string
+
+		c backtest.Config
+	)
+
+	fs.StringVar(&file, "f", "", "The path to the YAML file containing the proposed HorizontalRunnerAutoscaler.")
+	fs.StringVar(&repositories, "repositories", "", "Comma-separated list of repositories in the OWNER/REPO format whose workflow jobs are used for the backtest.")
+	fs.StringVar(&labels, "labels", "", "Comma-separated list of the custom labels of the runner pool. Only jobs that can run on the runner pool are taken into account.")
+	fs.IntVar(&days, "days", 7, "The number of days to backtest, counting back from now.")
+	fs.StringVar(&jobsFile, "jobs", "", "The path to the JSON file of workflow jobs previously saved with -save-jobs. When specified, jobs are not fetched from GitHub and the period is derived from the jobs instead of -days.")
+	fs.StringVar(&saveJobs, "save-jobs", "", "The path to save the fetched workflow jobs as JSON, so that you can rerun backtests with -jobs without calling GitHub API again.")
+	fs.StringVar(&timeline, "timeline", "", "The path to write the simulated replicas over time as CSV.")
+	fs.StringVar(&output, "o", "text", "The output format of the report. Either text or json.")
+	fs.DurationVar(&c.SyncPeriod, "sync-period", backtest.DefaultSyncPeriod, "The sync period of the controller.")
+	fs.DurationVar(&c.RunnerStartupTime, "runner-startup-time", backtest.DefaultRunnerStartupTime, "The time it takes for a new runner to become ready.")
+	fs.DurationVar(&c.DefaultScaleDownDelay, "default-scale-down-delay", backtest.DefaultScaleDownDelay, "The scale down delay used when the HRA doesn't specify scaleDownDelaySecondsAfterScaleOut.")
+	fs.DurationVar(&c.DelayThreshold, "delay-threshold", backtest.DefaultDelayThreshold, "The queue wait above which a job is counted as delayed.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: arc backtest -f HRA_FILE -repositories OWNER/REPO[,...] [-labels LABEL[,...]] [-days N]\n\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if file == "" {
+		return errors.New("-f is required")
+	}
+
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q: it must be either text or json", output)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := yaml.UnmarshalStrict(data, &hra); err != nil {
+		return fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	c.HorizontalRunnerAutoscaler = hra.Spec
+	c.RunnerLabels = splitCommaSeparated(labels)
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	var jobs []backtest.Job
+
+	if jobsFile != "" {
+		data, err := os.ReadFile(jobsFile)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return fmt.Errorf("parsing %s: %w", jobsFile, err)
+		}
+
+		from, to = jobsPeriod(jobs, from, to)
+	} else {
+		repos := splitCommaSeparated(repositories)
+		if len(repos) == 0 {
+			return errors.New("either -repositories or -jobs is required")
+		}
+
+		var ghConfig github.Config
+		if err := envconfig.Process("github", &ghConfig); err != nil {
+			return fmt.Errorf("processing environment variables: %w", err)
+		}
+
+		ghClient, err := ghConfig.NewClient()
+		if err != nil {
+			return fmt.Errorf("creating GitHub client: %w", err)
+		}
+
+		jobs, err = backtest.FetchJobs(context.Background(), ghClient, repos, from)
+		if err != nil {
+			return err
+		}
+
+		if saveJobs != "" {
+			data, err := json.MarshalIndent(jobs, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(saveJobs, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	report, err := backtest.Run(c, jobs, from, to)
+	if err != nil {
+		return err
+	}
+
+	if timeline != "" {
+		f, err := os.Create(timeline)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := report.WriteTimelineCSV(f); err != nil {
+			return err
+		}
+	}
+
+	if output == "json" {
+		report.Timeline = nil
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	return report.WriteText(os.Stdout)
+}
+
+// jobsPeriod returns the period covering all the saved jobs, so that the backtest of saved jobs is reproducible
+// regardless of when it is run.
+func jobsPeriod(jobs []backtest.Job, defaultFrom, defaultTo time.Time) (time.Time, time.Time) {
+	if len(jobs) == 0 {
+		return defaultFrom, defaultTo
+	}
+
+	from, to := jobs[0].CreatedAt, jobs[0].CompletedAt
+
+	for _, j := range jobs {
+		if j.CreatedAt.Before(from) {
+			from = j.CreatedAt
+		}
+
+		if j.CompletedAt.After(to) {
+			to = j.CompletedAt
+		}
+	}
+
+	// Give enough time for the jobs to complete in the simulation.
+	return from.Truncate(time.Minute), to.Add(time.Hour).Truncate(time.Minute)
+}
+
+func splitCommaSeparated(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
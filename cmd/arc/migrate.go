@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/migrate"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+
+	var (
+		file          string
+		output        string
+		flagsOnly     bool
+		allowWarnings bool
+	)
+
+	fs.StringVar(&file, "f", "-", "The path to the multi-document YAML file containing upstream custom resources. Specify - to read from stdin.")
+	fs.StringVar(&output, "o", "-", "The path to write the migrated manifests to. Specify - to write to stdout.")
+	fs.BoolVar(&flagsOnly, "flags", false, "Migrate the upstream controller flags given after -- instead of manifests, like `arc migrate -flags -- --sync-period=1m`")
+	fs.BoolVar(&allowWarnings, "allow-warnings", true, "Exit with zero even when there are warnings. Errors always result in a non-zero exit code.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: arc migrate [-f FILE] [-o FILE]\n       arc migrate -flags -- UPSTREAM_FLAGS...\n\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		migrated string
+		issues   []migrate.Issue
+	)
+
+	if flagsOnly {
+		var flags []string
+		flags, issues = migrate.Flags(fs.Args())
+		migrated = strings.Join(flags, " ") + "\n"
+	} else {
+		var in io.Reader = os.Stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		res, err := migrate.Manifests(in)
+		if err != nil {
+			return err
+		}
+
+		migrated = string(res.YAML())
+		issues = res.Issues
+	}
+
+	var numErrors, numWarnings int
+
+	for _, i := range issues {
+		switch i.Severity {
+		case migrate.SeverityError:
+			numErrors++
+		case migrate.SeverityWarning:
+			numWarnings++
+		}
+
+		fmt.Fprintln(os.Stderr, i.String())
+	}
+
+	if output == "-" {
+		fmt.Fprint(os.Stdout, migrated)
+	} else if err := os.WriteFile(output, []byte(migrated), 0644); err != nil {
+		return err
+	}
+
+	if numErrors > 0 {
+		return fmt.Errorf("found %d incompatibilities that need to be fixed manually", numErrors)
+	}
+
+	if numWarnings > 0 && !allowWarnings {
+		return errors.New("found warnings while -allow-warnings=false")
+	}
+
+	return nil
+}
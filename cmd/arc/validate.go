@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/validate"
+)
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	var (
+		path          string
+		namespace     string
+		output        string
+		allowWarnings bool
+	)
+
+	fs.StringVar(&path, "f", "-", "The path to a YAML file, or a directory whose YAML files are validated recursively. Specify - to read from stdin.")
+	fs.StringVar(&namespace, "namespace", "default", "The namespace of the custom resources that don't specify one, like kubectl apply -n.")
+	fs.StringVar(&output, "o", "json", "The output format. Either json or text.")
+	fs.BoolVar(&allowWarnings, "allow-warnings", true, "Exit with zero even when there are warnings. Errors always result in a non-zero exit code.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: arc validate [-f FILE_OR_DIR] [-o json|text]\n\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		res *validate.Result
+		err error
+	)
+
+	if path == "-" {
+		res, err = validate.Manifests(os.Stdin, namespace)
+	} else {
+		res, err = validate.Path(path, namespace)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case "json":
+		if res.Issues == nil {
+			res.Issues = []validate.Issue{}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	case "text":
+		for _, i := range res.Issues {
+			fmt.Fprintln(os.Stdout, i.String())
+		}
+		fmt.Fprintf(os.Stdout, "Validated %d custom resources in %d files with %d issues\n", res.Objects, res.Files, len(res.Issues))
+	default:
+		return fmt.Errorf("unsupported output format %q", output)
+	}
+
+	if res.HasErrors() {
+		return errors.New("found invalid custom resources")
+	}
+
+	if res.HasWarnings() && !allowWarnings {
+		return errors.New("found warnings while -allow-warnings=false")
+	}
+
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/actions-runner-controller/actions-runner-controller/controllers"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
 	"github.com/actions-runner-controller/actions-runner-controller/logging"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/credentialsprovider"
 	"github.com/kelseyhightower/envconfig"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -74,6 +76,18 @@ func main() {
 		logLevel             string
 
 		ghClient *github.Client
+
+		previewPoolNamespace    string
+		previewPoolRepositories string
+		previewPoolTeams        string
+		previewPoolDefaultTTL   time.Duration
+		previewPoolMaxTTL       time.Duration
+		previewPoolMaxReplicas  int
+
+		runnerLabelAliases string
+
+		githubCredentialsProvider       string
+		githubCredentialsReloadInterval time.Duration
 	)
 
 	var c github.Config
@@ -102,6 +116,16 @@ func main() {
 	flag.StringVar(&c.BasicauthUsername, "github-basicauth-username", c.BasicauthUsername, "Username for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.BasicauthPassword, "github-basicauth-password", c.BasicauthPassword, "Password for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.RunnerGitHubURL, "runner-github-url", c.RunnerGitHubURL, "GitHub URL to be used by runners during registration")
+	flag.StringVar(&c.RecordFixtures, "github-record-fixtures", c.RecordFixtures, "For testing only. The path to the file to which every GitHub API interaction is recorded as a sanitized fixture that can be replayed by the fake GitHub server")
+	flag.StringVar(&previewPoolNamespace, "preview-pool-namespace", "", "The namespace of the template RunnerDeployments and the preview pools created from /arc pool commands commented on pull requests. Setting this enables preview pools, which requires GitHub authentication for replying to the commands.")
+	flag.StringVar(&previewPoolRepositories, "preview-pool-repositories", "", "Comma-separated list of repositories in the OWNER/REPO or OWNER/* format whose pull requests can request preview pools.")
+	flag.StringVar(&previewPoolTeams, "preview-pool-teams", "", "Comma-separated list of teams in the ORG/TEAM_SLUG format whose members can request preview pools. When empty, any owner, member, or collaborator of the repository can request preview pools.")
+	flag.DurationVar(&previewPoolDefaultTTL, "preview-pool-default-ttl", controllers.DefaultPreviewPoolTTL, "The duration after which a preview pool is deleted when the command doesn't specify the ttl.")
+	flag.DurationVar(&previewPoolMaxTTL, "preview-pool-max-ttl", controllers.DefaultPreviewPoolMaxTTL, "The maximum ttl of a preview pool that can be requested.")
+	flag.IntVar(&previewPoolMaxReplicas, "preview-pool-max-replicas", controllers.DefaultPreviewPoolMaxReplicas, "The maximum number of runners of a preview pool that can be requested.")
+	flag.StringVar(&runnerLabelAliases, "runner-label-aliases", "", "Comma-separated list of runner label renames in the OLD=NEW format. The old and the new labels are considered the same on matching workflow jobs to the runners.")
+	flag.StringVar(&githubCredentialsProvider, "github-credentials-provider", "", "The command or the URL that returns the GitHub API credentials as JSON, like a script reading them from AWS Secrets Manager or Vault. The credentials are re-read every github-credentials-reload-interval.")
+	flag.DurationVar(&githubCredentialsReloadInterval, "github-credentials-reload-interval", controllers.DefaultGitHubCredentialsReloadInterval, "The interval at which the GitHub API credentials are re-read from the github-credentials-provider.")
 
 	flag.Parse()
 
@@ -124,6 +148,23 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
+	var credentialsProvider credentialsprovider.Provider
+	if githubCredentialsProvider != "" {
+		credentialsProvider, err = credentialsprovider.Parse(githubCredentialsProvider, 0)
+		if err != nil {
+			setupLog.Error(err, "invalid -github-credentials-provider")
+			os.Exit(1)
+		}
+
+		auth, err := credentialsProvider.Credentials(context.Background())
+		if err != nil {
+			setupLog.Error(err, "unable to read GitHub credentials from the credentials provider")
+			os.Exit(1)
+		}
+
+		c.SetAuth(*auth)
+	}
+
 	// In order to support runner groups with custom visibility (selected repositories), we need to perform some GitHub API calls.
 	// Let the user define if they want to opt-in supporting this option by providing the proper GitHub authentication parameters
 	// Without an opt-in, runner groups with custom visibility won't be supported to save API calls
@@ -155,6 +196,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	labelAliases, err := controllers.ParseRunnerLabelAliases(splitCommaSeparated(runnerLabelAliases))
+	if err != nil {
+		setupLog.Error(err, "invalid -runner-label-aliases")
+		os.Exit(1)
+	}
+
+	if credentialsProvider != nil && ghClient != nil {
+		credentialsReloader := &controllers.GitHubCredentialsReloader{
+			Log:          ctrl.Log.WithName("githubcredentialsreloader"),
+			GitHubClient: ghClient,
+			Provider:     credentialsProvider,
+			Interval:     githubCredentialsReloadInterval,
+		}
+
+		if err := mgr.Add(credentialsReloader); err != nil {
+			setupLog.Error(err, "unable to add github credentials reloader")
+			os.Exit(1)
+		}
+	}
+
 	hraGitHubWebhook := &controllers.HorizontalRunnerAutoscalerGitHubWebhook{
 		Name:           "webhookbasedautoscaler",
 		Client:         mgr.GetClient(),
@@ -164,6 +225,34 @@ func main() {
 		SecretKeyBytes: []byte(webhookSecretToken),
 		Namespace:      watchNamespace,
 		GitHubClient:   ghClient,
+
+		RunnerLabelAliases: labelAliases,
+	}
+
+	if previewPoolNamespace != "" {
+		if ghClient == nil {
+			setupLog.Info("-preview-pool-namespace requires GitHub authentication for replying to the commands")
+			os.Exit(1)
+		}
+
+		previewPools := &controllers.PreviewPools{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("previewpools"),
+			GitHubClient: ghClient,
+			Namespace:    previewPoolNamespace,
+			Repositories: splitCommaSeparated(previewPoolRepositories),
+			Teams:        splitCommaSeparated(previewPoolTeams),
+			DefaultTTL:   previewPoolDefaultTTL,
+			MaxTTL:       previewPoolMaxTTL,
+			MaxReplicas:  previewPoolMaxReplicas,
+		}
+
+		if err := mgr.Add(previewPools); err != nil {
+			setupLog.Error(err, "unable to add preview pools")
+			os.Exit(1)
+		}
+
+		hraGitHubWebhook.PreviewPools = previewPools
 	}
 
 	if err = hraGitHubWebhook.SetupWithManager(mgr); err != nil {
@@ -220,3 +309,15 @@ func main() {
 
 	wg.Wait()
 }
+
+func splitCommaSeparated(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
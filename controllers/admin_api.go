@@ -0,0 +1,433 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKeyDrain is the annotation on a RunnerDeployment or a RunnerSet that drains the runner pool when set to "true".
+	// A drained runner pool is scaled to zero regardless of its replicas, so that no runner is created
+	// while the busy runners are still given time to complete their jobs, until the annotation is removed.
+	AnnotationKeyDrain = annotationKeyPrefix + "drain"
+
+	// AnnotationKeySyncRequestedAt is the annotation on a HorizontalRunnerAutoscaler that is updated to trigger
+	// its reconciliation immediately, instead of waiting for the sync period.
+	AnnotationKeySyncRequestedAt = annotationKeyPrefix + "sync-requested-at"
+
+	RunnerStatePending      = "pending"
+	RunnerStateIdle         = "idle"
+	RunnerStateBusy         = "busy"
+	RunnerStateUnregistered = "unregistered"
+	RunnerStateCompleted    = "completed"
+	// RunnerStateUnknown is the state of a running runner whose registration couldn't be fetched from GitHub.
+	RunnerStateUnknown = "unknown"
+
+	adminAPIPathPrefix = "/admin/v1/"
+)
+
+// AdminAPI serves the operations to control the runner fleet programmatically, for platforms that orchestrate
+// the controller without kubectl:
+//
+//	GET    /admin/v1/runners?state=STATE,...&namespace=NS&pool=KIND/NAME
+//	POST   /admin/v1/namespaces/NS/horizontalrunnerautoscalers/NAME/sync
+//	GET    /admin/v1/namespaces/NS/horizontalrunnerautoscalers/NAME/explanation
+//	POST   /admin/v1/namespaces/NS/runnerdeployments/NAME/drain
+//	DELETE /admin/v1/namespaces/NS/runnerdeployments/NAME/drain
+//	POST   /admin/v1/namespaces/NS/runnersets/NAME/drain
+//	DELETE /admin/v1/namespaces/NS/runnersets/NAME/drain
+//
+// Every operation is done by reading or annotating the custom resources, so that the controllers act on them
+// in the same way as on changes made with kubectl.
+//
+// Requests must have the `Authorization: Bearer TOKEN` header whose TOKEN matches Token.
+type AdminAPI struct {
+	client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+
+	// Token is the bearer token required to access the admin API.
+	// The admin API is never served when this is empty.
+	Token string
+
+	Namespace string
+
+	now func() time.Time
+}
+
+// AdminRunner is a runner listed by the admin API.
+type AdminRunner struct {
+	RunnerInventoryEntry `json:",inline"`
+
+	// State is one of pending, idle, busy, unregistered, completed, and unknown.
+	State string `json:"state"`
+}
+
+// AdminPoolDrain is the result of draining or undraining a runner pool.
+type AdminPoolDrain struct {
+	Namespace string `json:"namespace"`
+	// Pool is the runner pool in the KIND/NAME format like `RunnerDeployment/example`.
+	Pool    string `json:"pool"`
+	Drained bool   `json:"drained"`
+}
+
+// AdminSyncRequest is the result of triggering the reconciliation of a HorizontalRunnerAutoscaler.
+type AdminSyncRequest struct {
+	Namespace                  string    `json:"namespace"`
+	HorizontalRunnerAutoscaler string    `json:"horizontalRunnerAutoscaler"`
+	RequestedAt                time.Time `json:"requestedAt"`
+}
+
+// ScaleExplanation explains why a HorizontalRunnerAutoscaler wants the desired replicas it has.
+type ScaleExplanation struct {
+	Namespace                  string `json:"namespace"`
+	HorizontalRunnerAutoscaler string `json:"horizontalRunnerAutoscaler"`
+	// ScaleTarget is the scale target in the KIND/NAME format like `RunnerDeployment/example`.
+	ScaleTarget string `json:"scaleTarget"`
+
+	DesiredReplicas *int `json:"desiredReplicas"`
+	MinReplicas     *int `json:"minReplicas,omitempty"`
+	MaxReplicas     *int `json:"maxReplicas,omitempty"`
+
+	// RecommendedReplicas is the desired replicas not applied to the scale target, as the policy of the HorizontalRunnerAutoscaler is RecommendOnly.
+	RecommendedReplicas *int `json:"recommendedReplicas,omitempty"`
+
+	// Reason and Message are those of the ScalingActive condition, which tell what decided the desired replicas last.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Drained is true when the scale target is drained, so that it's kept scaled to zero.
+	Drained bool `json:"drained"`
+
+	Conditions     []metav1.Condition         `json:"conditions,omitempty"`
+	ScalingHistory []v1alpha1.ScalingDecision `json:"scalingHistory,omitempty"`
+}
+
+func (a *AdminAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !hasBearerToken(r, a.Token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, adminAPIPathPrefix), "/"), "/")
+
+	switch {
+	case len(segments) == 1 && segments[0] == "runners":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+
+		a.listRunners(w, r)
+	case len(segments) == 5 && segments[0] == "namespaces":
+		ns, kind, name, op := segments[1], segments[2], segments[3], segments[4]
+
+		if a.Namespace != "" && ns != a.Namespace {
+			http.Error(w, "namespace "+strconv.Quote(ns)+" is not watched by the controller", http.StatusNotFound)
+			return
+		}
+
+		key := types.NamespacedName{Namespace: ns, Name: name}
+
+		switch {
+		case kind == "horizontalrunnerautoscalers" && op == "sync":
+			if !allowMethods(w, r, http.MethodPost) {
+				return
+			}
+
+			a.syncHorizontalRunnerAutoscaler(w, r, key)
+		case kind == "horizontalrunnerautoscalers" && op == "explanation":
+			if !allowMethods(w, r, http.MethodGet) {
+				return
+			}
+
+			a.explainScale(w, r, key)
+		case (kind == "runnerdeployments" || kind == "runnersets") && op == "drain":
+			if !allowMethods(w, r, http.MethodPost, http.MethodDelete) {
+				return
+			}
+
+			a.drainPool(w, r, kind, key, r.Method == http.MethodPost)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.WriteHeader(http.StatusMethodNotAllowed)
+
+	return false
+}
+
+func (a *AdminAPI) listRunners(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	states := map[string]bool{}
+	for _, s := range strings.Split(query.Get("state"), ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+
+		switch s {
+		case RunnerStatePending, RunnerStateIdle, RunnerStateBusy, RunnerStateUnregistered, RunnerStateCompleted, RunnerStateUnknown:
+			states[s] = true
+		default:
+			http.Error(w, "unsupported state "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	namespace := a.Namespace
+	if ns := query.Get("namespace"); ns != "" {
+		if namespace != "" && ns != namespace {
+			http.Error(w, "namespace "+strconv.Quote(ns)+" is not watched by the controller", http.StatusNotFound)
+			return
+		}
+
+		namespace = ns
+	}
+
+	inventory := &RunnerInventory{
+		Client:       a.Client,
+		GitHubClient: a.GitHubClient,
+		Log:          a.Log,
+		Namespace:    namespace,
+		now:          a.now,
+	}
+
+	entries, err := inventory.List(r.Context())
+	if err != nil {
+		a.Log.Error(err, "Failed to list runners")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pool := query.Get("pool")
+
+	runners := []AdminRunner{}
+
+	for _, e := range entries {
+		if pool != "" && e.Pool != pool {
+			continue
+		}
+
+		state := runnerState(e)
+		if len(states) > 0 && !states[state] {
+			continue
+		}
+
+		runners = append(runners, AdminRunner{RunnerInventoryEntry: e, State: state})
+	}
+
+	a.writeJSON(w, http.StatusOK, runners)
+}
+
+// runnerState returns the state of the runner in the inventory.
+func runnerState(e RunnerInventoryEntry) string {
+	switch corev1.PodPhase(e.Phase) {
+	case corev1.PodSucceeded, corev1.PodFailed:
+		return RunnerStateCompleted
+	case corev1.PodPending:
+		return RunnerStatePending
+	}
+
+	switch {
+	case e.Registered == nil:
+		return RunnerStateUnknown
+	case !*e.Registered:
+		return RunnerStateUnregistered
+	case e.Busy != nil && *e.Busy:
+		return RunnerStateBusy
+	}
+
+	return RunnerStateIdle
+}
+
+func (a *AdminAPI) syncHorizontalRunnerAutoscaler(w http.ResponseWriter, r *http.Request, key types.NamespacedName) {
+	ctx := r.Context()
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := a.Get(ctx, key, &hra); err != nil {
+		a.writeError(w, "getting horizontalrunnerautoscaler", err)
+		return
+	}
+
+	now := a.currentTime()
+
+	updated := hra.DeepCopy()
+	setAnnotation(&updated.ObjectMeta, AnnotationKeySyncRequestedAt, now.Format(time.RFC3339Nano))
+
+	if err := a.Patch(ctx, updated, client.MergeFrom(&hra)); err != nil {
+		a.writeError(w, "patching horizontalrunnerautoscaler", err)
+		return
+	}
+
+	a.Log.Info("Requested horizontalrunnerautoscaler sync", "namespace", key.Namespace, "horizontalrunnerautoscaler", key.Name)
+
+	a.writeJSON(w, http.StatusAccepted, AdminSyncRequest{
+		Namespace:                  key.Namespace,
+		HorizontalRunnerAutoscaler: key.Name,
+		RequestedAt:                now,
+	})
+}
+
+func (a *AdminAPI) explainScale(w http.ResponseWriter, r *http.Request, key types.NamespacedName) {
+	ctx := r.Context()
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := a.Get(ctx, key, &hra); err != nil {
+		a.writeError(w, "getting horizontalrunnerautoscaler", err)
+		return
+	}
+
+	kind := hra.Spec.ScaleTargetRef.Kind
+	if kind == "" {
+		kind = "RunnerDeployment"
+	}
+
+	explanation := ScaleExplanation{
+		Namespace:                  hra.Namespace,
+		HorizontalRunnerAutoscaler: hra.Name,
+		ScaleTarget:                kind + "/" + hra.Spec.ScaleTargetRef.Name,
+		DesiredReplicas:            hra.Status.DesiredReplicas,
+		MinReplicas:                hra.Spec.MinReplicas,
+		MaxReplicas:                hra.Spec.MaxReplicas,
+		RecommendedReplicas:        hra.Status.RecommendedReplicas,
+		Conditions:                 hra.Status.Conditions,
+		ScalingHistory:             hra.Status.ScalingHistory,
+	}
+
+	if active := meta.FindStatusCondition(hra.Status.Conditions, ScalingActiveConditionType); active != nil {
+		explanation.Reason = active.Reason
+		explanation.Message = active.Message
+	}
+
+	target, err := a.pool(ctx, kind, types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name})
+	if err != nil && !kerrors.IsNotFound(err) {
+		a.writeError(w, "getting scale target", err)
+		return
+	}
+
+	if target != nil {
+		explanation.Drained = poolDrained(target)
+	}
+
+	a.writeJSON(w, http.StatusOK, explanation)
+}
+
+func (a *AdminAPI) drainPool(w http.ResponseWriter, r *http.Request, resource string, key types.NamespacedName, drain bool) {
+	ctx := r.Context()
+
+	kind := "RunnerDeployment"
+	if resource == "runnersets" {
+		kind = "RunnerSet"
+	}
+
+	pool, err := a.pool(ctx, kind, key)
+	if err != nil {
+		a.writeError(w, "getting "+strings.ToLower(kind), err)
+		return
+	}
+
+	updated := pool.DeepCopyObject().(client.Object)
+
+	annotations := updated.GetAnnotations()
+	if drain {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[AnnotationKeyDrain] = "true"
+	} else {
+		delete(annotations, AnnotationKeyDrain)
+	}
+	updated.SetAnnotations(annotations)
+
+	if err := a.Patch(ctx, updated, client.MergeFrom(pool)); err != nil {
+		a.writeError(w, "patching "+strings.ToLower(kind), err)
+		return
+	}
+
+	a.Log.Info("Updated runner pool drain", "namespace", key.Namespace, "pool", kind+"/"+key.Name, "drained", drain)
+
+	a.writeJSON(w, http.StatusOK, AdminPoolDrain{
+		Namespace: key.Namespace,
+		Pool:      kind + "/" + key.Name,
+		Drained:   drain,
+	})
+}
+
+// pool gets the RunnerDeployment or the RunnerSet of the runner pool.
+func (a *AdminAPI) pool(ctx context.Context, kind string, key types.NamespacedName) (client.Object, error) {
+	var obj client.Object
+
+	switch kind {
+	case "RunnerDeployment":
+		obj = &v1alpha1.RunnerDeployment{}
+	case "RunnerSet":
+		obj = &v1alpha1.RunnerSet{}
+	default:
+		return nil, nil
+	}
+
+	if err := a.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// poolDrained returns true when the RunnerDeployment or the RunnerSet is drained via AnnotationKeyDrain.
+func poolDrained(obj metav1.Object) bool {
+	return obj.GetAnnotations()[AnnotationKeyDrain] == "true"
+}
+
+func (a *AdminAPI) currentTime() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+
+	return time.Now()
+}
+
+func (a *AdminAPI) writeError(w http.ResponseWriter, action string, err error) {
+	code := http.StatusInternalServerError
+	if kerrors.IsNotFound(err) {
+		code = http.StatusNotFound
+	} else {
+		a.Log.Error(err, "Failed to serve admin API request")
+	}
+
+	http.Error(w, action+": "+err.Error(), code)
+}
+
+func (a *AdminAPI) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		a.Log.Error(err, "Failed writing admin API response")
+	}
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	ghfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAdminAPI(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	runnerPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            name,
+				Labels:          map[string]string{LabelKeyRunnerSetName: name},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Runner", Name: name}},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "runner", Env: []corev1.EnvVar{{Name: EnvVarRepo, Value: "test/valid"}}},
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	runner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{LabelKeyRunnerDeploymentName: "example"}},
+		}
+	}
+
+	newObjects := func() []client.Object {
+		return []client.Object{
+			&v1alpha1.RunnerDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"}},
+			&v1alpha1.RunnerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-set", Annotations: map[string]string{AnnotationKeyDrain: "true"}}},
+			&v1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-hra"},
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: v1alpha1.ScaleTargetRef{Kind: "RunnerSet", Name: "example-set"},
+					MinReplicas:    intPtr(1),
+					MaxReplicas:    intPtr(10),
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					DesiredReplicas: intPtr(3),
+					Conditions: []metav1.Condition{
+						{Type: ScalingActiveConditionType, Status: metav1.ConditionTrue, Reason: "TotalNumberOfQueuedAndInProgressWorkflowRuns", Message: "3 queued workflow jobs"},
+					},
+				},
+			},
+			runner("busy"), runnerPod("busy", corev1.PodRunning),
+			runner("idle"), runnerPod("idle", corev1.PodRunning),
+			runner("unregistered"), runnerPod("unregistered", corev1.PodRunning),
+			runner("pending"), runnerPod("pending", corev1.PodPending),
+			runner("completed"), runnerPod("completed", corev1.PodSucceeded),
+		}
+	}
+
+	runners := ghfake.NewRunnersList()
+	runners.Add(&github.Runner{ID: github.Int64(1), Name: github.String("busy"), Busy: github.Bool(true)})
+	runners.Add(&github.Runner{ID: github.Int64(2), Name: github.String("idle"), Busy: github.Bool(false)})
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	newAdminAPI := func() *AdminAPI {
+		return &AdminAPI{
+			Client:       clientfake.NewClientBuilder().WithScheme(sc).WithObjects(newObjects()...).Build(),
+			GitHubClient: newGithubClient(server),
+			Log:          logr.Discard(),
+			Token:        "secret",
+			now:          func() time.Time { return now },
+		}
+	}
+
+	serve := func(a *AdminAPI, method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		if rec := serve(newAdminAPI(), http.MethodGet, "/admin/v1/runners", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status: %d", rec.Code)
+		}
+	})
+
+	t.Run("list runners by state", func(t *testing.T) {
+		testcases := []struct {
+			query string
+			want  map[string]string
+		}{
+			{
+				query: "",
+				want: map[string]string{
+					"busy":         RunnerStateBusy,
+					"idle":         RunnerStateIdle,
+					"unregistered": RunnerStateUnregistered,
+					"pending":      RunnerStatePending,
+					"completed":    RunnerStateCompleted,
+				},
+			},
+			{
+				query: "?state=busy,pending",
+				want:  map[string]string{"busy": RunnerStateBusy, "pending": RunnerStatePending},
+			},
+			{
+				query: "?pool=RunnerSet/example-set",
+				want:  map[string]string{},
+			},
+		}
+
+		for _, tc := range testcases {
+			rec := serve(newAdminAPI(), http.MethodGet, "/admin/v1/runners"+tc.query, "secret")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: unexpected status: %d: %s", tc.query, rec.Code, rec.Body.String())
+			}
+
+			var list []AdminRunner
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.query, err)
+			}
+
+			got := map[string]string{}
+			for _, r := range list {
+				got[r.Name] = r.State
+			}
+
+			if len(got) != len(tc.want) {
+				t.Errorf("%s: unexpected runners: want %v, got %v", tc.query, tc.want, got)
+				continue
+			}
+
+			for name, state := range tc.want {
+				if got[name] != state {
+					t.Errorf("%s: unexpected state of %s: want %s, got %s", tc.query, name, state, got[name])
+				}
+			}
+		}
+
+		if rec := serve(newAdminAPI(), http.MethodGet, "/admin/v1/runners?state=sleeping", "secret"); rec.Code != http.StatusBadRequest {
+			t.Errorf("unexpected status for an unsupported state: %d", rec.Code)
+		}
+	})
+
+	t.Run("sync horizontalrunnerautoscaler", func(t *testing.T) {
+		a := newAdminAPI()
+
+		rec := serve(a, http.MethodPost, "/admin/v1/namespaces/default/horizontalrunnerautoscalers/example-hra/sync", "secret")
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var hra v1alpha1.HorizontalRunnerAutoscaler
+		if err := a.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example-hra"}, &hra); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := hra.Annotations[AnnotationKeySyncRequestedAt]; got != now.Format(time.RFC3339Nano) {
+			t.Errorf("unexpected sync request annotation: %q", got)
+		}
+
+		if rec := serve(a, http.MethodGet, "/admin/v1/namespaces/default/horizontalrunnerautoscalers/example-hra/sync", "secret"); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("unexpected status for GET: %d", rec.Code)
+		}
+
+		if rec := serve(a, http.MethodPost, "/admin/v1/namespaces/default/horizontalrunnerautoscalers/missing/sync", "secret"); rec.Code != http.StatusNotFound {
+			t.Errorf("unexpected status for a missing horizontalrunnerautoscaler: %d", rec.Code)
+		}
+	})
+
+	t.Run("explain scale", func(t *testing.T) {
+		rec := serve(newAdminAPI(), http.MethodGet, "/admin/v1/namespaces/default/horizontalrunnerautoscalers/example-hra/explanation", "secret")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var got ScaleExplanation
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got.ScaleTarget != "RunnerSet/example-set" || got.DesiredReplicas == nil || *got.DesiredReplicas != 3 {
+			t.Errorf("unexpected explanation: %+v", got)
+		}
+
+		if got.Reason != "TotalNumberOfQueuedAndInProgressWorkflowRuns" || got.Message != "3 queued workflow jobs" {
+			t.Errorf("unexpected reason: %s: %s", got.Reason, got.Message)
+		}
+
+		if !got.Drained {
+			t.Errorf("expected the drained scale target to be explained as drained")
+		}
+	})
+
+	t.Run("drain and undrain pool", func(t *testing.T) {
+		a := newAdminAPI()
+		ctx := context.Background()
+		key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+		if rec := serve(a, http.MethodPost, "/admin/v1/namespaces/default/runnerdeployments/example/drain", "secret"); rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var rd v1alpha1.RunnerDeployment
+		if err := a.Get(ctx, key, &rd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !poolDrained(&rd) {
+			t.Errorf("expected the runnerdeployment to be drained: %v", rd.Annotations)
+		}
+
+		if rec := serve(a, http.MethodDelete, "/admin/v1/namespaces/default/runnerdeployments/example/drain", "secret"); rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if err := a.Get(ctx, key, &rd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if poolDrained(&rd) {
+			t.Errorf("expected the runnerdeployment to be undrained: %v", rd.Annotations)
+		}
+	})
+
+	t.Run("unwatched namespace", func(t *testing.T) {
+		a := newAdminAPI()
+		a.Namespace = "runners"
+
+		if rec := serve(a, http.MethodPost, "/admin/v1/namespaces/default/runnerdeployments/example/drain", "secret"); rec.Code != http.StatusNotFound {
+			t.Errorf("unexpected status: %d", rec.Code)
+		}
+	})
+}
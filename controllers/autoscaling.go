@@ -7,88 +7,251 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/metricprovider"
 	"github.com/google/go-github/v39/github"
 )
 
-const (
-	defaultScaleUpThreshold   = 0.8
-	defaultScaleDownThreshold = 0.3
-	defaultScaleUpFactor      = 1.3
-	defaultScaleDownFactor    = 0.7
-)
-
-func (r *HorizontalRunnerAutoscalerReconciler) suggestDesiredReplicas(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler) (*int, error) {
+// suggestDesiredReplicas returns the desired replicas suggested by the metrics of the HRA,
+// along with the metric that determined it and the reason given by its scale algorithm, if any.
+func (r *HorizontalRunnerAutoscalerReconciler) suggestDesiredReplicas(ctx context.Context, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler) (*int, *v1alpha1.MetricSpec, string, error) {
 	if hra.Spec.MinReplicas == nil {
-		return nil, fmt.Errorf("horizontalrunnerautoscaler %s/%s is missing minReplicas", hra.Namespace, hra.Name)
+		return nil, nil, "", fmt.Errorf("horizontalrunnerautoscaler %s/%s is missing minReplicas", hra.Namespace, hra.Name)
 	} else if hra.Spec.MaxReplicas == nil {
-		return nil, fmt.Errorf("horizontalrunnerautoscaler %s/%s is missing maxReplicas", hra.Namespace, hra.Name)
+		return nil, nil, "", fmt.Errorf("horizontalrunnerautoscaler %s/%s is missing maxReplicas", hra.Namespace, hra.Name)
 	}
 
-	metrics := hra.Spec.Metrics
-	numMetrics := len(metrics)
-	if numMetrics == 0 {
-		// We don't default to anything since ARC 0.23.0
-		// See https://github.com/actions-runner-controller/actions-runner-controller/issues/728
-		return nil, nil
-	} else if numMetrics > 2 {
-		return nil, fmt.Errorf("too many autoscaling metrics configured: It must be 0 to 2, but got %d", numMetrics)
+	if r.DisableRunLevelAutoscaling {
+		if err := validateJobLevelAutoscaling(hra); err != nil {
+			return nil, nil, "", err
+		}
 	}
 
-	primaryMetric := metrics[0]
-	primaryMetricType := primaryMetric.Type
+	reasons := map[string]string{}
 
-	var (
-		suggested *int
-		err       error
-	)
+	suggested, metric, err := autoscaling.Suggest(hra.Spec, func(metric v1alpha1.MetricSpec) (*int, error) {
+		s, err := r.suggestReplicasByMetric(ctx, st, hra, metric)
+		if err != nil || s == nil {
+			return nil, err
+		}
 
-	switch primaryMetricType {
-	case v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns:
-		suggested, err = r.suggestReplicasByQueuedAndInProgressWorkflowRuns(st, hra, &primaryMetric)
-	case v1alpha1.AutoscalingMetricTypePercentageRunnersBusy:
-		suggested, err = r.suggestReplicasByPercentageRunnersBusy(st, hra, primaryMetric)
-	default:
-		return nil, fmt.Errorf("validating autoscaling metrics: unsupported metric type %q", primaryMetric)
+		reasons[metric.Type] = s.Reason
+
+		return &s.Replicas, nil
+	})
+	if err != nil || suggested == nil {
+		return nil, metric, "", err
 	}
 
-	if err != nil {
-		return nil, err
+	if policy := hra.Spec.MetricsCombinationPolicy; policy != "" {
+		r.Log.V(1).Info(
+			fmt.Sprintf("Suggested desired replicas of %d by the %s of the metrics", *suggested, policy),
+			"namespace", hra.Namespace,
+			"kind", st.kind,
+			"name", st.st,
+			"horizontal_runner_autoscaler", hra.Name,
+		)
 	}
 
-	if suggested != nil && *suggested > 0 {
-		return suggested, nil
+	var reason string
+	if metric != nil {
+		reason = reasons[metric.Type]
 	}
 
-	if len(metrics) == 1 {
-		// This is never supposed to happen but anyway-
-		// Fall-back to `minReplicas + capacityReservedThroughWebhook`.
-		return nil, nil
+	return suggested, metric, reason, nil
+}
+
+// metricStatuses returns the status of each of the metrics, or nil when there's no metric.
+// repos is the workflow jobs counted by the metrics by repository, and jobs is their weighted numbers, both keyed by the metric types.
+// desiredReplicas is the desired replicas of the reconciliation, against which the ratio of the QueuedJobsPerRunner metric is reported.
+func metricStatuses(metrics []v1alpha1.MetricSpec, repos map[string][]v1alpha1.RepositoryWorkflowJobs, jobs map[string]workflowJobCounts, desiredReplicas int) []v1alpha1.MetricStatus {
+	var statuses []v1alpha1.MetricStatus
+
+	for i, m := range metrics {
+		s := v1alpha1.MetricStatus{Index: i, Type: m.Type, Active: !m.Suspended}
+		if s.Active {
+			s.Repositories = repos[m.Type]
+		}
+
+		if c, ok := jobs[m.Type]; ok && s.Active && m.Type == v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner {
+			if ratio, ok := autoscaling.QueuedJobsPerRunnerRatio(c.queued, c.inProgress, desiredReplicas); ok {
+				s.QueuedJobsPerRunner = strconv.FormatFloat(ratio, 'f', 2, 64)
+			}
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	return statuses
+}
+
+// suggestReplicasByMetric returns the desired replicas suggested by the metric.
+// External metrics are suggested by the metric providers, and the other metrics by the scale algorithms registered for their types.
+func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByMetric(ctx context.Context, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metric v1alpha1.MetricSpec) (*autoscaling.ScaleSuggestion, error) {
+	if metric.Type == v1alpha1.AutoscalingMetricTypeExternal {
+		replicas, err := r.suggestReplicasByExternal(ctx, st, hra, metric)
+		if err != nil {
+			return nil, err
+		}
+
+		return &autoscaling.ScaleSuggestion{Replicas: *replicas}, nil
+	}
+
+	algorithms := r.ScaleAlgorithms
+	if algorithms == nil {
+		algorithms = autoscaling.ScaleAlgorithms()
+	}
+
+	algorithm, ok := algorithms[metric.Type]
+	if !ok {
+		return nil, fmt.Errorf("validating autoscaling metrics: unsupported metric type %q", metric.Type)
+	}
+
+	st.demandLimit = r.workflowJobDemandLimit(hra, metric)
+
+	observer := &scaleTargetObserver{r: r, st: st, hra: hra}
+
+	s, err := algorithm.SuggestReplicas(st.githubContext(), autoscaling.ScaleRequest{
+		HorizontalRunnerAutoscaler: hra,
+		Metric:                     metric,
+		ScaleTarget: autoscaling.ScaleTarget{
+			Kind:         st.kind,
+			Name:         st.st,
+			Enterprise:   st.enterprise,
+			Organization: st.org,
+			Repository:   st.repo,
+			Labels:       st.labels,
+			Replicas:     st.replicas,
+		},
+		Observer: observer,
+		Now:      r.now(),
+	})
+	if err != nil || s == nil {
+		return nil, err
 	}
 
-	// At this point, we are sure that there are exactly 2 Metrics entries.
+	desiredReplicasBefore := 1
+	if v := st.replicas; v != nil {
+		desiredReplicasBefore = *v
+	}
 
-	fallbackMetric := metrics[1]
-	fallbackMetricType := fallbackMetric.Type
+	// NOTES for operators:
+	//
+	// - num_runners can be as twice as large as replicas_desired_before while
+	//   the runnerdeployment controller is replacing RunnerReplicaSet for runner update.
 
-	if primaryMetricType != v1alpha1.AutoscalingMetricTypePercentageRunnersBusy ||
-		fallbackMetricType != v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns {
+	keysAndValues := []interface{}{
+		"replicas_desired_before", desiredReplicasBefore,
+		"replicas_desired", s.Replicas,
+	}
+
+	if c := observer.jobs; c != nil {
+		keysAndValues = append(keysAndValues,
+			"workflow_runs_completed", c.completed,
+			"workflow_jobs_in_progress", c.inProgress,
+			"workflow_jobs_queued", c.queued,
+			"workflow_jobs_unknown", c.unknown,
+		)
+	}
 
-		return nil, fmt.Errorf(
-			"invalid HRA Spec: Metrics[0] of %s cannot be combined with Metrics[1] of %s: The only allowed combination is 0=PercentageRunnersBusy and 1=TotalNumberOfQueuedAndInProgressWorkflowRuns",
-			primaryMetricType, fallbackMetricType,
+	if c := observer.runners; c != nil {
+		keysAndValues = append(keysAndValues,
+			"num_runners", c.runners,
+			"num_runners_registered", c.registered,
+			"num_runners_busy", c.busy,
+			"num_runners_completed", c.completed,
 		)
 	}
 
-	return r.suggestReplicasByQueuedAndInProgressWorkflowRuns(st, hra, &fallbackMetric)
+	if s.Reason != "" {
+		keysAndValues = append(keysAndValues, "reason", s.Reason)
+	}
+
+	keysAndValues = append(keysAndValues,
+		"namespace", hra.Namespace,
+		"kind", st.kind,
+		"name", st.st,
+		"horizontal_runner_autoscaler", hra.Name,
+		"enterprise", st.enterprise,
+		"organization", st.org,
+		"repository", st.repo,
+	)
+
+	r.Log.V(1).Info(fmt.Sprintf("Suggested desired replicas of %d by %s", s.Replicas, metric.Type), keysAndValues...)
+
+	return s, nil
+}
+
+// workflowJobDemandLimit returns the number of the runners demanded by the workflow jobs of the metric
+// beyond which the rest of the jobs are left uncounted, or zero when all the jobs need to be counted.
+//
+// The jobs are counted up to one more than maxReplicas, so that the HRA still tells it's short of replicas.
+// It's enabled along with the page budget, and only for the metrics whose suggestion is clamped to maxReplicas
+// regardless of the uncounted jobs. The concurrency-aware metrics count all the jobs to apply the concurrency limits,
+// the average of the metrics depends on the exact counts, and the overflow needs the exact shortfall.
+func (r *HorizontalRunnerAutoscalerReconciler) workflowJobDemandLimit(hra v1alpha1.HorizontalRunnerAutoscaler, metric v1alpha1.MetricSpec) int {
+	if r.GitHubAPIMaxPagesPerReconcile <= 0 || hra.Spec.MaxReplicas == nil || hra.Spec.Overflow != nil {
+		return 0
+	}
+
+	if metric.Type != v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns || metric.ConcurrencyAware {
+		return 0
+	}
+
+	if hra.Spec.MetricsCombinationPolicy == v1alpha1.MetricsCombinationPolicyAverage {
+		return 0
+	}
+
+	return *hra.Spec.MaxReplicas + 1
+}
+
+// workflowJobCounts is the numbers of the workflow runs and jobs by status.
+// queued and inProgress count the jobs that can run on the scale target, or the runs whose jobs are unavailable.
+type workflowJobCounts struct {
+	total, inProgress, queued, completed, unknown int
+
+	// jobs is the queued and in-progress jobs that can run on the scale target, before the concurrency limits are applied.
+	jobs []activeWorkflowJob
 }
 
-func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgressWorkflowRuns(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics *v1alpha1.MetricSpec) (*int, error) {
+// countWorkflowJobs counts the queued and in-progress workflow jobs for the scale target.
+// It returns nil when there's nothing to count, i.e. for organizational and enterprise runners without any metrics.
+// The repositories of the metric are counted in parallel, and their jobs are weighted by the repositoryWeights of the metric.
+func (r *HorizontalRunnerAutoscalerReconciler) countWorkflowJobs(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics *v1alpha1.MetricSpec) (*workflowJobCounts, error) {
+	weights, err := parseRepositoryWeights(metrics)
+	if err != nil {
+		return nil, err
+	}
 
-	var repos [][]string
+	var repos []metricRepository
 	repoID := st.repo
-	if repoID == "" {
+	if repoID == "" && st.org == "" && st.enterprise != "" {
+		if metrics == nil {
+			return nil, nil
+		}
+
+		if len(metrics.RepositoryNames) == 0 {
+			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].repositoryNames is required and must have one more more entries for enterprise runner deployment")
+		}
+
+		if metrics.RepositorySelector != nil {
+			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].repositorySelector is supported only for organizational runner deployment")
+		}
+
+		// Enterprise runners pick up jobs of the repositories of any organization in the enterprise,
+		// so each repository needs to be specified along with its owner.
+		for _, repoName := range metrics.RepositoryNames {
+			repo := strings.Split(repoName, "/")
+			if len(repo) != 2 || repo[0] == "" || repo[1] == "" {
+				return nil, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].repositoryNames must be in the OWNER/REPO format for enterprise runner deployment, but got %q", repoName)
+			}
+
+			repos = append(repos, metricRepository{owner: repo[0], repo: repo[1], name: repoName, weight: repositoryWeight(weights, repoName)})
+		}
+	} else if repoID == "" {
 		orgName := st.org
 		if orgName == "" {
 			return nil, fmt.Errorf("asserting runner deployment spec to detect bug: spec.template.organization should not be empty on this code path")
@@ -101,60 +264,157 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 			return nil, nil
 		}
 
-		if len(metrics.RepositoryNames) == 0 {
+		if len(metrics.RepositoryNames) == 0 && metrics.RepositorySelector == nil {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].repositoryNames is required and must have one more more entries for organizational runner deployment")
 		}
 
-		for _, repoName := range metrics.RepositoryNames {
-			repos = append(repos, []string{orgName, repoName})
+		repoNames := metrics.RepositoryNames
+
+		// The discovered repositories are counted along with the listed ones, each only once.
+		if sel := metrics.RepositorySelector; sel != nil {
+			discovered, err := r.discoverRepositories(st, *sel, r.now())
+			if err != nil {
+				return nil, err
+			}
+
+			listed := map[string]bool{}
+			for _, name := range repoNames {
+				listed[name] = true
+			}
+
+			repoNames = append([]string{}, repoNames...)
+			for _, name := range discovered {
+				if !listed[name] {
+					repoNames = append(repoNames, name)
+				}
+			}
+		}
+
+		for _, repoName := range repoNames {
+			repos = append(repos, metricRepository{owner: orgName, repo: repoName, name: repoName, weight: repositoryWeight(weights, repoName)})
 		}
 	} else {
 		repo := strings.Split(repoID, "/")
 
-		repos = append(repos, repo)
+		repos = append(repos, metricRepository{owner: repo[0], repo: repo[1], weight: 1})
+	}
+
+	now := r.now()
+
+	demand := newWorkflowJobDemand(st.demandLimit)
+
+	repoCounts := make([]*workflowJobCounts, len(repos))
+	repoTargets := make([]scaleTarget, len(repos))
+
+	concurrency := r.RepositoryFetchConcurrency
+	if concurrency == 0 {
+		concurrency = DefaultRepositoryFetchConcurrency
+	}
+
+	err = forEachRepository(len(repos), concurrency, func(i int) error {
+		repoTargets[i] = st.forRepository()
+
+		if demand.established() {
+			r.Log.V(1).Info(
+				"Skipped the repository as the demand beyond maxReplicas is already established",
+				"repository", repos[i].owner+"/"+repos[i].repo,
+				"namespace", hra.Namespace,
+				"horizontal_runner_autoscaler", hra.Name,
+			)
+			return nil
+		}
+
+		counts, err := r.countRepositoryWorkflowJobs(repoTargets[i], hra, metrics, repos[i].owner, repos[i].repo, now, demand.forRepository(repos[i].weight))
+		if err != nil {
+			return err
+		}
+
+		repoCounts[i] = counts
+
+		return nil
+	})
+
+	for _, t := range repoTargets {
+		mergeRepositoryObservation(st.observation, t.observation)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var counts workflowJobCounts
+
+	for _, c := range repoCounts {
+		if c == nil {
+			continue
+		}
+
+		counts.total += c.total
+		counts.completed += c.completed
+		counts.unknown += c.unknown
+		counts.jobs = append(counts.jobs, c.jobs...)
+	}
+
+	counts.queued = weightedJobs(repos, func(i int) int {
+		if c := repoCounts[i]; c != nil {
+			return c.queued
+		}
+		return 0
+	})
+	counts.inProgress = weightedJobs(repos, func(i int) int {
+		if c := repoCounts[i]; c != nil {
+			return c.inProgress
+		}
+		return 0
+	})
+
+	if metrics != nil {
+		st.observeRepositoryWorkflowJobs(metrics.Type, repositoryWorkflowJobs(metrics, repos, repoCounts))
+		st.observeMetricWorkflowJobs(metrics.Type, counts)
+	}
+
+	st.observeWorkflowJobs(r.now(), counts)
+
+	return &counts, nil
+}
+
+// countRepositoryWorkflowJobs counts the queued and in-progress workflow jobs of the repository for the scale target.
+// It returns nil when the runner group of the scale target has no access to the repository.
+// The rest of the workflow runs are left uncounted once the demand is established, if any.
+func (r *HorizontalRunnerAutoscalerReconciler) countRepositoryWorkflowJobs(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics *v1alpha1.MetricSpec, user, repoName string, now time.Time, demand repositoryDemand) (*workflowJobCounts, error) {
+	concurrencyAware := metrics != nil && metrics.ConcurrencyAware
+	includeWaiting := metrics != nil && metrics.IncludeWaitingWorkflowRuns
+
+	waitingWeight, err := waitingJobsWeight(metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows, branches []string
+	if metrics != nil {
+		workflows = metrics.Workflows
+		branches = metrics.Branches
 	}
 
-	var total, inProgress, queued, completed, unknown int
+	var total, inProgress, queued, completed, unknown, waiting int
+	var activeJobs []activeWorkflowJob
 	type callback func()
-	listWorkflowJobs := func(user string, repoName string, runID int64, fallback_cb callback) {
-		if runID == 0 {
+	listWorkflowJobs := func(user string, repoName string, run *github.WorkflowRun, fallback_cb callback) {
+		runID := run.GetID()
+		if runID == 0 || r.DisableJobLevelAutoscaling {
 			fallback_cb()
 			return
 		}
-		opt := github.ListWorkflowJobsOptions{ListOptions: github.ListOptions{PerPage: 50}}
-		var allJobs []*github.WorkflowJob
-		for {
-			jobs, resp, err := r.GitHubClient.Actions.ListWorkflowJobs(context.TODO(), user, repoName, runID, &opt)
-			if err != nil {
-				r.Log.Error(err, "Error listing workflow jobs")
-				return //err
-			}
-			allJobs = append(allJobs, jobs.Jobs...)
-			if resp.NextPage == 0 {
-				break
-			}
-			opt.Page = resp.NextPage
+		jobs, numJobs, err := r.listMatchingWorkflowJobs(st, user, repoName, run, now)
+		if err != nil {
+			r.Log.Error(err, "Error listing workflow jobs")
+			return //err
 		}
-		if len(allJobs) == 0 {
+		if numJobs == 0 {
 			fallback_cb()
 		} else {
 		JOB:
-			for _, job := range allJobs {
-				labels := make(map[string]struct{}, len(job.Labels))
-				for _, l := range job.Labels {
-					labels[l] = struct{}{}
-				}
-
-				if _, ok := labels["self-hosted"]; !ok {
-					continue JOB
-				}
-
-				for _, l := range st.labels {
-					if _, ok := labels[l]; !ok {
-						continue JOB
-					}
-				}
-
+			for _, job := range jobs {
 				switch job.GetStatus() {
 				case "completed":
 					// We add a case for `completed` so it is not counted in `unknown`.
@@ -167,198 +427,359 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 					inProgress++
 				case "queued":
 					queued++
+				case "waiting", "pending":
+					// The jobs waiting for the approvals of their environments or pending on their concurrency groups
+					// are counted as queued by their weight only when asked to, as they don't run until the gates clear.
+					if !includeWaiting {
+						unknown++
+						continue JOB
+					}
+					waiting++
 				default:
 					unknown++
+					continue JOB
 				}
+
+				activeJobs = append(activeJobs, activeWorkflowJob{owner: user, repo: repoName, run: run, job: job})
 			}
 		}
 	}
 
-	for _, repo := range repos {
-		user, repoName := repo[0], repo[1]
-		workflowRuns, err := r.GitHubClient.ListRepositoryWorkflowRuns(context.TODO(), user, repoName)
+	// countRun returns the fallback that counts the run itself when its jobs are unavailable.
+	// The run is counted as unknown instead when run-level autoscaling is disabled, so that only jobs are counted.
+	countRun := func(n *int) callback {
+		if r.DisableRunLevelAutoscaling {
+			return func() { unknown++ }
+		}
+
+		return func() { *n++ }
+	}
+
+	// The jobs of the repositories the runner group has no access to are never routed to the runners of the scale target.
+	visible, err := r.isRunnerGroupVisibleToRepository(st, user, repoName, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !visible {
+		r.Log.V(1).Info(
+			"Skipped the repository the runner group has no access to",
+			"repository", user+"/"+repoName,
+			"runner_group", st.group,
+			"namespace", hra.Namespace,
+			"horizontal_runner_autoscaler", hra.Name,
+		)
+		return nil, nil
+	}
+
+	workflowRuns, err := r.githubClient(st).ListRepositoryWorkflowRuns(st.githubContext(), user, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	if includeWaiting {
+		waitingRuns, err := r.githubClient(st).ListRepositoryWaitingWorkflowRuns(st.githubContext(), user, repoName)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, run := range workflowRuns {
-			total++
+		workflowRuns = append(workflowRuns, waitingRuns...)
+	}
 
-			// In May 2020, there are only 3 statuses.
-			// Follow the below links for more details:
-			// - https://developer.github.com/v3/actions/workflow-runs/#list-repository-workflow-runs
-			// - https://developer.github.com/v3/checks/runs/#create-a-check-run
-			switch run.GetStatus() {
-			case "completed":
-				completed++
-			case "in_progress":
-				listWorkflowJobs(user, repoName, run.GetID(), func() { inProgress++ })
-			case "queued":
-				listWorkflowJobs(user, repoName, run.GetID(), func() { queued++ })
-			default:
+	for i, run := range workflowRuns {
+		if demand.established() {
+			r.Log.V(1).Info(
+				"Skipped the rest of the workflow runs as the demand beyond maxReplicas is already established",
+				"workflow_runs_skipped", len(workflowRuns)-i,
+				"repository", user+"/"+repoName,
+				"namespace", hra.Namespace,
+				"horizontal_runner_autoscaler", hra.Name,
+			)
+			break
+		}
+
+		if len(branches) > 0 && !matchBranch(branches, run.GetHeadBranch()) {
+			continue
+		}
+
+		if len(workflows) > 0 {
+			ok, err := r.workflowPaths.matchWorkflowRun(st.githubContext(), r.githubClient(st), user, repoName, workflows, run.GetName(), run.GetWorkflowID())
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				continue
+			}
+		}
+
+		total++
+
+		// In May 2020, there are only 3 statuses.
+		// Follow the below links for more details:
+		// - https://developer.github.com/v3/actions/workflow-runs/#list-repository-workflow-runs
+		// - https://developer.github.com/v3/checks/runs/#create-a-check-run
+		active := queued + inProgress
+
+		switch run.GetStatus() {
+		case "completed":
+			completed++
+		case "in_progress":
+			listWorkflowJobs(user, repoName, run, countRun(&inProgress))
+		case "queued":
+			listWorkflowJobs(user, repoName, run, countRun(&queued))
+		case "waiting", "pending":
+			if includeWaiting {
+				listWorkflowJobs(user, repoName, run, countRun(&waiting))
+			} else {
 				unknown++
 			}
+		default:
+			unknown++
 		}
+
+		demand.add(queued + inProgress - active)
 	}
 
-	necessaryReplicas := queued + inProgress
+	if includeWaiting {
+		// The waiting jobs are left out of the demand above, which stays a lower bound with a weight less than 1.
+		weighted := int(math.Ceil(float64(waiting) * waitingWeight))
+		queued += weighted
+
+		r.Log.V(1).Info(
+			"Counted the workflow jobs waiting for their deployment gates as queued",
+			"workflow_jobs_waiting", waiting,
+			"workflow_jobs_waiting_weighted", weighted,
+			"repository", user+"/"+repoName,
+			"namespace", hra.Namespace,
+			"horizontal_runner_autoscaler", hra.Name,
+		)
+	}
 
-	r.Log.V(1).Info(
-		fmt.Sprintf("Suggested desired replicas of %d by TotalNumberOfQueuedAndInProgressWorkflowRuns", necessaryReplicas),
-		"workflow_runs_completed", completed,
-		"workflow_runs_in_progress", inProgress,
-		"workflow_runs_queued", queued,
-		"workflow_runs_unknown", unknown,
-		"namespace", hra.Namespace,
-		"kind", st.kind,
-		"name", st.st,
-		"horizontal_runner_autoscaler", hra.Name,
-	)
+	// Concurrency groups are scoped to the repository, so the concurrency limits are applied per repository.
+	if concurrencyAware && len(activeJobs) > 0 {
+		// Jobs of the runs whose jobs couldn't be listed are counted by the fallback callbacks and stay as-is.
+		var jobsQueued, jobsInProgress int
+		for _, j := range activeJobs {
+			switch j.job.GetStatus() {
+			case "queued":
+				jobsQueued++
+			case "in_progress":
+				jobsInProgress++
+			}
+		}
+
+		concurrentQueued, concurrentInProgress := countConcurrentJobs(activeJobs, func(j activeWorkflowJob) *workflowDefinition {
+			def, err := r.getWorkflowDefinition(st.githubContext(), r.githubClient(st), j.owner, j.repo, j.run)
+			if err != nil {
+				r.Log.Error(err, "Error getting workflow definition. The job is counted without its concurrency limits", "run_id", j.run.GetID())
+				return nil
+			}
+			return def
+		})
+
+		r.Log.V(1).Info(
+			"Excluded the queued jobs throttled by max-parallel and concurrency groups",
+			"workflow_jobs_throttled", jobsQueued-concurrentQueued,
+			"repository", user+"/"+repoName,
+			"namespace", hra.Namespace,
+			"horizontal_runner_autoscaler", hra.Name,
+		)
+
+		queued += concurrentQueued - jobsQueued
+		inProgress += concurrentInProgress - jobsInProgress
+	}
+
+	return &workflowJobCounts{
+		total:      total,
+		inProgress: inProgress,
+		queued:     queued,
+		completed:  completed,
+		unknown:    unknown,
+		jobs:       activeJobs,
+	}, nil
+}
+
+// waitingJobsWeight returns the waitingJobsWeight of the metric, which defaults to 1.
+func waitingJobsWeight(metrics *v1alpha1.MetricSpec) (float64, error) {
+	if metrics == nil || metrics.WaitingJobsWeight == "" {
+		return 1, nil
+	}
+
+	w, err := strconv.ParseFloat(metrics.WaitingJobsWeight, 64)
+	if err != nil || w < 0 {
+		return 0, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].waitingJobsWeight must be a non-negative float64, but got %q", metrics.WaitingJobsWeight)
+	}
 
-	return &necessaryReplicas, nil
+	return w, nil
 }
 
-func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunnersBusy(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
-	ctx := context.Background()
-	scaleUpThreshold := defaultScaleUpThreshold
-	scaleDownThreshold := defaultScaleDownThreshold
-	scaleUpFactor := defaultScaleUpFactor
-	scaleDownFactor := defaultScaleDownFactor
+// validateJobLevelAutoscaling returns an error if the HRA depends on run-level autoscaling,
+// which scales by the workflow runs rather than the jobs.
+func validateJobLevelAutoscaling(hra v1alpha1.HorizontalRunnerAutoscaler) error {
+	for i, t := range hra.Spec.ScaleUpTriggers {
+		e := t.GitHubEvent
+		if e == nil {
+			continue
+		}
 
-	if metrics.ScaleUpThreshold != "" {
-		sut, err := strconv.ParseFloat(metrics.ScaleUpThreshold, 64)
-		if err != nil {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpThreshold cannot be parsed into a float64")
+		var event string
+
+		switch {
+		case e.CheckRun != nil:
+			event = "checkRun"
+		case e.PullRequest != nil:
+			event = "pullRequest"
+		case e.Push != nil:
+			event = "push"
+		default:
+			continue
 		}
-		scaleUpThreshold = sut
+
+		return fmt.Errorf("validating scale up triggers: spec.scaleUpTriggers[%d].githubEvent.%s scales by workflow runs, which is disabled by --disable-run-level-autoscaling. Use workflowJob instead", i, event)
 	}
-	if metrics.ScaleDownThreshold != "" {
-		sdt, err := strconv.ParseFloat(metrics.ScaleDownThreshold, 64)
-		if err != nil {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownThreshold cannot be parsed into a float64")
-		}
 
-		scaleDownThreshold = sdt
+	return nil
+}
+
+// runnerCounts is the numbers of the runners of a scale target.
+type runnerCounts struct {
+	runners, registered, busy int
+
+	// completed is the number of the runners whose pods have completed but are not replaced yet.
+	// They are usually ephemeral runners that have run a job and unregistered themselves,
+	// which are neither registered nor busy but are about to be replaced to run the next job.
+	completed int
+}
+
+// countRunners counts the runners of the scale target, and the ones among them that are registered to and busy on GitHub,
+// or have completed.
+func (r *HorizontalRunnerAutoscalerReconciler) countRunners(ctx context.Context, st scaleTarget) (*runnerCounts, error) {
+	runnerMap, err := st.getRunnerMap()
+	if err != nil {
+		return nil, err
 	}
 
-	scaleUpAdjustment := metrics.ScaleUpAdjustment
-	if scaleUpAdjustment != 0 {
-		if metrics.ScaleUpAdjustment < 0 {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpAdjustment cannot be lower than 0")
-		}
+	counts := runnerCounts{runners: len(runnerMap)}
+
+	registered := map[string]bool{}
+
+	if states, ok := r.syncedRunnerStates(st); ok {
+		for name, state := range states {
+			if _, ok := runnerMap[name]; !ok || state == v1alpha1.RunnerGitHubStateUnregistered {
+				continue
+			}
+
+			counts.registered++
+			registered[name] = true
 
-		if metrics.ScaleUpFactor != "" {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[]: scaleUpAdjustment and scaleUpFactor cannot be specified together")
+			if state == v1alpha1.RunnerGitHubStateBusy {
+				counts.busy++
+			}
 		}
-	} else if metrics.ScaleUpFactor != "" {
-		suf, err := strconv.ParseFloat(metrics.ScaleUpFactor, 64)
+	} else {
+		// ListRunners will return all runners managed by GitHub - not restricted to ns
+		runners, err := r.githubClient(st).ListRunners(
+			ctx,
+			st.enterprise,
+			st.org,
+			st.repo)
 		if err != nil {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpFactor cannot be parsed into a float64")
+			return nil, err
 		}
-		scaleUpFactor = suf
-	}
 
-	scaleDownAdjustment := metrics.ScaleDownAdjustment
-	if scaleDownAdjustment != 0 {
-		if metrics.ScaleDownAdjustment < 0 {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownAdjustment cannot be lower than 0")
-		}
+		for _, runner := range runners {
+			if _, ok := runnerMap[*runner.Name]; ok {
+				counts.registered++
+				registered[*runner.Name] = true
 
-		if metrics.ScaleDownFactor != "" {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[]: scaleDownAdjustment and scaleDownFactor cannot be specified together")
+				if runner.GetBusy() {
+					counts.busy++
+				}
+			}
 		}
-	} else if metrics.ScaleDownFactor != "" {
-		sdf, err := strconv.ParseFloat(metrics.ScaleDownFactor, 64)
+	}
+
+	if st.listRunnerPods != nil {
+		pods, err := st.listRunnerPods()
 		if err != nil {
-			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownFactor cannot be parsed into a float64")
+			return nil, err
 		}
-		scaleDownFactor = sdf
-	}
 
-	runnerMap, err := st.getRunnerMap()
-	if err != nil {
-		return nil, err
+		for i := range pods {
+			pod := &pods[i]
+
+			if _, ok := runnerMap[pod.Name]; ok && !registered[pod.Name] && runnerPodOrContainerIsStopped(pod) {
+				counts.completed++
+			}
+		}
 	}
 
-	var (
-		enterprise   = st.enterprise
-		organization = st.org
-		repository   = st.repo
-	)
+	st.observeRunners(counts)
 
-	// ListRunners will return all runners managed by GitHub - not restricted to ns
-	runners, err := r.GitHubClient.ListRunners(
-		ctx,
-		enterprise,
-		organization,
-		repository)
+	return &counts, nil
+}
+
+// syncedRunnerStates returns the states of the runners of the scale target on GitHub synced into the runner statuses,
+// or false when the runners are to be listed via the GitHub API, as the scale target has no Runners or the statuses are stale.
+func (r *HorizontalRunnerAutoscalerReconciler) syncedRunnerStates(st scaleTarget) (map[string]v1alpha1.RunnerGitHubState, bool) {
+	if st.listRunners == nil || r.RunnerStatusMaxAge <= 0 {
+		return nil, false
+	}
+
+	runners, err := st.listRunners()
 	if err != nil {
-		return nil, err
+		return nil, false
 	}
 
-	var desiredReplicasBefore int
+	return syncedRunnerGitHubStates(r.now(), r.RunnerStatusMaxAge, runners)
+}
 
-	if v := st.replicas; v == nil {
-		desiredReplicasBefore = 1
-	} else {
-		desiredReplicasBefore = *v
+func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByExternal(ctx context.Context, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
+	if metrics.External == nil || metrics.External.Provider == "" {
+		return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].external.provider is required for the External metric type")
 	}
 
-	var (
-		numRunners           int
-		numRunnersRegistered int
-		numRunnersBusy       int
-	)
+	name := metrics.External.Provider
 
-	numRunners = len(runnerMap)
-
-	for _, runner := range runners {
-		if _, ok := runnerMap[*runner.Name]; ok {
-			numRunnersRegistered++
+	provider, ok := r.MetricProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("validating autoscaling metrics: metric provider %q is not registered to the controller. Register it with --metric-provider", name)
+	}
 
-			if runner.GetBusy() {
-				numRunnersBusy++
-			}
-		}
+	req := metricprovider.Request{
+		HorizontalRunnerAutoscaler: metricprovider.ObjectRef{
+			Namespace: hra.Namespace,
+			Name:      hra.Name,
+		},
+		ScaleTarget: metricprovider.ScaleTarget{
+			Kind:         st.kind,
+			Name:         st.st,
+			Enterprise:   st.enterprise,
+			Organization: st.org,
+			Repository:   st.repo,
+			Labels:       st.labels,
+			Replicas:     st.replicas,
+		},
+		MinReplicas: *hra.Spec.MinReplicas,
+		MaxReplicas: *hra.Spec.MaxReplicas,
+		Params:      metrics.External.Params,
 	}
 
-	var desiredReplicas int
-	fractionBusy := float64(numRunnersBusy) / float64(desiredReplicasBefore)
-	if fractionBusy >= scaleUpThreshold {
-		if scaleUpAdjustment > 0 {
-			desiredReplicas = desiredReplicasBefore + scaleUpAdjustment
-		} else {
-			desiredReplicas = int(math.Ceil(float64(desiredReplicasBefore) * scaleUpFactor))
-		}
-	} else if fractionBusy < scaleDownThreshold {
-		if scaleDownAdjustment > 0 {
-			desiredReplicas = desiredReplicasBefore - scaleDownAdjustment
-		} else {
-			desiredReplicas = int(float64(desiredReplicasBefore) * scaleDownFactor)
-		}
-	} else {
-		desiredReplicas = *st.replicas
+	res, err := provider.SuggestReplicas(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// NOTES for operators:
-	//
-	// - num_runners can be as twice as large as replicas_desired_before while
-	//   the runnerdeployment controller is replacing RunnerReplicaSet for runner update.
+	desiredReplicas := res.DesiredReplicas
 
 	r.Log.V(1).Info(
-		fmt.Sprintf("Suggested desired replicas of %d by PercentageRunnersBusy", desiredReplicas),
-		"replicas_desired_before", desiredReplicasBefore,
-		"replicas_desired", desiredReplicas,
-		"num_runners", numRunners,
-		"num_runners_registered", numRunnersRegistered,
-		"num_runners_busy", numRunnersBusy,
+		fmt.Sprintf("Suggested desired replicas of %d by External", desiredReplicas),
+		"provider", name,
 		"namespace", hra.Namespace,
 		"kind", st.kind,
 		"name", st.st,
 		"horizontal_runner_autoscaler", hra.Name,
-		"enterprise", enterprise,
-		"organization", organization,
-		"repository", repository,
 	)
 
 	return &desiredReplicas, nil
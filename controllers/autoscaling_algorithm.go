@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+)
+
+// scaleTargetObserver observes the runners and the workflow jobs of the scale target for the scale algorithms.
+// It remembers what it observed, so that the suggestion can be logged along with the numbers it's made from.
+type scaleTargetObserver struct {
+	r   *HorizontalRunnerAutoscalerReconciler
+	st  scaleTarget
+	hra v1alpha1.HorizontalRunnerAutoscaler
+
+	runners *runnerCounts
+	jobs    *workflowJobCounts
+}
+
+var _ autoscaling.Observer = &scaleTargetObserver{}
+
+func (o *scaleTargetObserver) Runners(ctx context.Context) (*autoscaling.RunnerCounts, error) {
+	counts, err := o.r.countRunners(ctx, o.st)
+	if err != nil {
+		return nil, err
+	}
+
+	o.runners = counts
+
+	return &autoscaling.RunnerCounts{
+		Runners:    counts.runners,
+		Registered: counts.registered,
+		Busy:       counts.busy,
+		Completed:  counts.completed,
+	}, nil
+}
+
+func (o *scaleTargetObserver) WorkflowJobs(ctx context.Context, metric v1alpha1.MetricSpec) (*autoscaling.WorkflowJobCounts, error) {
+	counts, err := o.r.countWorkflowJobs(o.st, o.hra, &metric)
+	if err != nil || counts == nil {
+		return nil, err
+	}
+
+	o.jobs = counts
+
+	jobs := make([]autoscaling.WorkflowJob, 0, len(counts.jobs))
+	for _, j := range counts.jobs {
+		jobs = append(jobs, autoscaling.WorkflowJob{Owner: j.owner, Repository: j.repo, Run: j.run, Job: j.job})
+	}
+
+	return &autoscaling.WorkflowJobCounts{
+		Queued:     counts.queued,
+		InProgress: counts.inProgress,
+		Jobs:       jobs,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/go-logr/logr"
+)
+
+func TestSuggestDesiredReplicas_ScaleAlgorithm(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	workflowRuns := `{"total_count": 2, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	// queuedJobs suggests twice the queued jobs it sees, to tell its suggestion from the built-in ones.
+	queuedJobs := autoscaling.ScaleAlgorithmFunc(func(ctx context.Context, req autoscaling.ScaleRequest) (*autoscaling.ScaleSuggestion, error) {
+		jobs, err := req.Observer.WorkflowJobs(ctx, req.Metric)
+		if err != nil || jobs == nil {
+			return nil, err
+		}
+
+		var queued int
+		for _, j := range jobs.Jobs {
+			if j.Job.GetStatus() == "queued" {
+				queued++
+			}
+		}
+
+		return &autoscaling.ScaleSuggestion{Replicas: 2 * queued, Reason: "QueuedJobsDoubled"}, nil
+	})
+
+	testcases := []struct {
+		description string
+		algorithms  map[string]autoscaling.ScaleAlgorithm
+		metricType  string
+
+		want       int
+		wantReason string
+		err        string
+	}{
+		{
+			description: "built-in algorithm",
+			metricType:  v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+			want:        4,
+		},
+		{
+			description: "custom algorithm",
+			algorithms:  map[string]autoscaling.ScaleAlgorithm{"QueuedJobs": queuedJobs},
+			metricType:  "QueuedJobs",
+			want:        6,
+			wantReason:  "QueuedJobsDoubled",
+		},
+		{
+			description: "built-in algorithm overridden",
+			algorithms:  map[string]autoscaling.ScaleAlgorithm{v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns: queuedJobs},
+			metricType:  v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+			want:        6,
+			wantReason:  "QueuedJobsDoubled",
+		},
+		{
+			description: "unregistered algorithm",
+			metricType:  "QueuedJobs",
+			err:         `validating autoscaling metrics: unsupported metric type "QueuedJobs"`,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:             logr.Discard(),
+				GitHubClient:    newGithubClient(server),
+				ScaleAlgorithms: tc.algorithms,
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: intPtr(1),
+					MaxReplicas: intPtr(10),
+					Metrics:     []v1alpha1.MetricSpec{{Type: tc.metricType}},
+				},
+			}
+
+			got, _, reason, err := h.suggestDesiredReplicas(context.Background(), scaleTarget{repo: "test/valid", replicas: intPtr(1)}, hra)
+			if err != nil {
+				if tc.err == "" {
+					t.Fatalf("unexpected error: expected none, got %v", err)
+				} else if err.Error() != tc.err {
+					t.Fatalf("unexpected error: expected %v, got %v", tc.err, err)
+				}
+				return
+			} else if tc.err != "" {
+				t.Fatalf("expected error %q, got none", tc.err)
+			}
+
+			if got == nil || *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", tc.want, got)
+			}
+
+			if reason != tc.wantReason {
+				t.Errorf("incorrect reason: want %q, got %q", tc.wantReason, reason)
+			}
+		})
+	}
+}
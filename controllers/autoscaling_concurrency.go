@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v39/github"
+	"sigs.k8s.io/yaml"
+)
+
+// maxCachedWorkflowDefinitions bounds the number of the workflow definitions cached for concurrency-aware scaling.
+// The cache is cleared once it's full, which is fine as the definitions are cheap to refetch.
+const maxCachedWorkflowDefinitions = 500
+
+// activeWorkflowJob is a queued or in-progress workflow job that can run on the runners of the scale target.
+type activeWorkflowJob struct {
+	owner, repo string
+	run         *gogithub.WorkflowRun
+	job         *gogithub.WorkflowJob
+}
+
+// workflowDefinition is the parallelism limits of the jobs of a workflow, read from the workflow file.
+type workflowDefinition struct {
+	jobs []workflowJobDefinition
+}
+
+type workflowJobDefinition struct {
+	id string
+	// name is the name of the job, which may contain expressions.
+	name string
+	// maxParallel is the strategy.max-parallel of the matrix job, or 0 when it's not limited.
+	maxParallel int
+	// concurrencyGroup is the job-level concurrency group, which may contain expressions.
+	concurrencyGroup string
+}
+
+type workflowFile struct {
+	Jobs map[string]struct {
+		Name     string `json:"name"`
+		Strategy struct {
+			MaxParallel interface{} `json:"max-parallel"`
+		} `json:"strategy"`
+		Concurrency json.RawMessage `json:"concurrency"`
+	} `json:"jobs"`
+}
+
+// parseWorkflowDefinition reads the parallelism limits of the jobs from the workflow file.
+// Limits given as expressions are ignored as they can't be evaluated outside of GitHub Actions.
+func parseWorkflowDefinition(data []byte) (*workflowDefinition, error) {
+	var f workflowFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var def workflowDefinition
+
+	for id, j := range f.Jobs {
+		jd := workflowJobDefinition{id: id, name: j.Name}
+
+		switch v := j.Strategy.MaxParallel.(type) {
+		case float64:
+			jd.maxParallel = int(v)
+		case string:
+			if n, err := strconv.Atoi(v); err == nil {
+				jd.maxParallel = n
+			}
+		}
+
+		if len(j.Concurrency) > 0 {
+			// The concurrency is either a group name or an object that has the group name.
+			var group string
+			if err := json.Unmarshal(j.Concurrency, &group); err != nil {
+				var c struct {
+					Group string `json:"group"`
+				}
+				if err := json.Unmarshal(j.Concurrency, &c); err != nil {
+					return nil, fmt.Errorf("parsing concurrency of job %s: %w", id, err)
+				}
+				group = c.Group
+			}
+
+			jd.concurrencyGroup = group
+		}
+
+		def.jobs = append(def.jobs, jd)
+	}
+
+	return &def, nil
+}
+
+// find returns the definition of the workflow job.
+// The name of a matrix job is the name of the job followed by the matrix values in parentheses, like `build (ubuntu-latest, 16)`.
+func (d *workflowDefinition) find(job *gogithub.WorkflowJob) *workflowJobDefinition {
+	name := job.GetName()
+
+	for i := range d.jobs {
+		jd := &d.jobs[i]
+
+		base := jd.name
+		if base == "" {
+			base = jd.id
+		} else if strings.Contains(base, "${{") {
+			continue
+		}
+
+		if name == base || strings.HasPrefix(name, base+" (") {
+			return jd
+		}
+	}
+
+	return nil
+}
+
+var workflowExpression = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+// resolveConcurrencyGroup evaluates the expressions in the concurrency group against the workflow run.
+// Only the commonly used `github` context properties and `||` are supported.
+// It returns false when the group can't be resolved.
+func resolveConcurrencyGroup(group string, j activeWorkflowJob, jobID string) (string, bool) {
+	run := j.run
+
+	ref := "refs/heads/" + run.GetHeadBranch()
+
+	var headRef string
+	if strings.HasPrefix(run.GetEvent(), "pull_request") {
+		headRef = run.GetHeadBranch()
+		ref = ""
+		if len(run.PullRequests) > 0 {
+			ref = fmt.Sprintf("refs/pull/%d/merge", run.PullRequests[0].GetNumber())
+		}
+	}
+
+	values := map[string]string{
+		"github.workflow":   run.GetName(),
+		"github.ref":        ref,
+		"github.ref_name":   run.GetHeadBranch(),
+		"github.head_ref":   headRef,
+		"github.event_name": run.GetEvent(),
+		"github.repository": j.owner + "/" + j.repo,
+		"github.run_id":     strconv.FormatInt(run.GetID(), 10),
+		"github.sha":        run.GetHeadSHA(),
+		"github.job":        jobID,
+	}
+
+	resolved := true
+
+	group = workflowExpression.ReplaceAllStringFunc(group, func(expr string) string {
+		for _, operand := range strings.Split(workflowExpression.FindStringSubmatch(expr)[1], "||") {
+			operand = strings.TrimSpace(operand)
+
+			if v, ok := values[operand]; ok {
+				if v != "" {
+					return v
+				}
+			} else if unquoted, err := strconv.Unquote(strings.ReplaceAll(operand, "'", `"`)); err == nil {
+				return unquoted
+			} else {
+				resolved = false
+			}
+		}
+
+		return ""
+	})
+
+	return group, resolved
+}
+
+// countConcurrentJobs counts the queued and in-progress jobs that GitHub can actually run in parallel.
+// A queued job is not counted when it would exceed the max-parallel of its matrix,
+// or its concurrency group already has a counted job, as GitHub doesn't run it until another job completes.
+// definitionOf returns nil when the definition of the workflow of the job is unavailable, in which case the job is counted as usual.
+func countConcurrentJobs(jobs []activeWorkflowJob, definitionOf func(activeWorkflowJob) *workflowDefinition) (queued, inProgress int) {
+	used := map[string]int{}
+
+	type limit struct {
+		key string
+		max int
+	}
+
+	limitsOf := func(j activeWorkflowJob) []limit {
+		def := definitionOf(j)
+		if def == nil {
+			return nil
+		}
+
+		jd := def.find(j.job)
+		if jd == nil {
+			return nil
+		}
+
+		var limits []limit
+
+		if jd.maxParallel > 0 {
+			limits = append(limits, limit{key: fmt.Sprintf("matrix:%s/%s#%d/%s", j.owner, j.repo, j.run.GetID(), jd.id), max: jd.maxParallel})
+		}
+
+		if jd.concurrencyGroup != "" {
+			// Concurrency groups are scoped to the repository.
+			if group, ok := resolveConcurrencyGroup(jd.concurrencyGroup, j, jd.id); ok {
+				limits = append(limits, limit{key: fmt.Sprintf("concurrency:%s/%s/%s", j.owner, j.repo, group), max: 1})
+			}
+		}
+
+		return limits
+	}
+
+	// In-progress jobs always occupy their slots, so they are counted first.
+	for _, j := range jobs {
+		if j.job.GetStatus() != "in_progress" {
+			continue
+		}
+
+		for _, l := range limitsOf(j) {
+			used[l.key]++
+		}
+
+		inProgress++
+	}
+
+JOB:
+	for _, j := range jobs {
+		if j.job.GetStatus() != "queued" {
+			continue
+		}
+
+		limits := limitsOf(j)
+
+		for _, l := range limits {
+			if used[l.key] >= l.max {
+				continue JOB
+			}
+		}
+
+		for _, l := range limits {
+			used[l.key]++
+		}
+
+		queued++
+	}
+
+	return queued, inProgress
+}
+
+type workflowDefinitionCache struct {
+	mu          sync.Mutex
+	definitions map[string]*workflowDefinition
+}
+
+// getWorkflowDefinition returns the definition of the workflow of the run at the commit of the run.
+func (r *HorizontalRunnerAutoscalerReconciler) getWorkflowDefinition(ctx context.Context, ghc *github.Client, owner, repo string, run *gogithub.WorkflowRun) (*workflowDefinition, error) {
+	key := fmt.Sprintf("%s/%s/%d/%s", owner, repo, run.GetWorkflowID(), run.GetHeadSHA())
+
+	c := &r.workflowDefinitions
+
+	c.mu.Lock()
+	def, ok := c.definitions[key]
+	c.mu.Unlock()
+
+	if ok {
+		return def, nil
+	}
+
+	workflow, _, err := ghc.Actions.GetWorkflowByID(ctx, owner, repo, run.GetWorkflowID())
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow %d of %s/%s: %w", run.GetWorkflowID(), owner, repo, err)
+	}
+
+	file, _, _, err := ghc.Repositories.GetContents(ctx, owner, repo, workflow.GetPath(), &gogithub.RepositoryContentGetOptions{Ref: run.GetHeadSHA()})
+	if err != nil {
+		return nil, fmt.Errorf("getting workflow file %s of %s/%s at %s: %w", workflow.GetPath(), owner, repo, run.GetHeadSHA(), err)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+
+	def, err = parseWorkflowDefinition([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing workflow file %s of %s/%s at %s: %w", workflow.GetPath(), owner, repo, run.GetHeadSHA(), err)
+	}
+
+	c.mu.Lock()
+	if c.definitions == nil || len(c.definitions) >= maxCachedWorkflowDefinitions {
+		c.definitions = map[string]*workflowDefinition{}
+	}
+	c.definitions[key] = def
+	c.mu.Unlock()
+
+	return def, nil
+}
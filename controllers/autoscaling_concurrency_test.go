@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func TestParseWorkflowDefinition(t *testing.T) {
+	def, err := parseWorkflowDefinition([]byte(`
+on: push
+jobs:
+  build:
+    runs-on: [self-hosted]
+    strategy:
+      max-parallel: 2
+      matrix:
+        os: [a, b, c, d]
+  deploy:
+    name: Deploy to ${{ matrix.env }}
+    concurrency: deploy-${{ github.ref }}
+  release:
+    name: Release
+    concurrency:
+      group: release
+      cancel-in-progress: false
+    strategy:
+      max-parallel: ${{ inputs.parallelism }}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		job              string
+		wantID           string
+		wantMaxParallel  int
+		wantConcurrency  string
+		wantNotFoundJobs bool
+	}{
+		{job: "build (a)", wantID: "build", wantMaxParallel: 2},
+		{job: "build", wantID: "build", wantMaxParallel: 2},
+		{job: "Release", wantID: "release", wantConcurrency: "release"},
+		// Names with expressions can't be matched
+		{job: "Deploy to prod", wantNotFoundJobs: true},
+		{job: "builder", wantNotFoundJobs: true},
+	}
+
+	for _, tc := range testcases {
+		jd := def.find(&github.WorkflowJob{Name: github.String(tc.job)})
+
+		if tc.wantNotFoundJobs {
+			if jd != nil {
+				t.Errorf("%s: unexpected match: %+v", tc.job, *jd)
+			}
+			continue
+		}
+
+		if jd == nil {
+			t.Errorf("%s: no match", tc.job)
+			continue
+		}
+
+		if jd.id != tc.wantID || jd.maxParallel != tc.wantMaxParallel || jd.concurrencyGroup != tc.wantConcurrency {
+			t.Errorf("%s: unexpected definition: %+v", tc.job, *jd)
+		}
+	}
+}
+
+func TestResolveConcurrencyGroup(t *testing.T) {
+	push := activeWorkflowJob{
+		owner: "test",
+		repo:  "valid",
+		run: &github.WorkflowRun{
+			ID:         github.Int64(1),
+			Name:       github.String("CI"),
+			Event:      github.String("push"),
+			HeadBranch: github.String("main"),
+		},
+	}
+
+	pr := activeWorkflowJob{
+		owner: "test",
+		repo:  "valid",
+		run: &github.WorkflowRun{
+			ID:           github.Int64(2),
+			Name:         github.String("CI"),
+			Event:        github.String("pull_request"),
+			HeadBranch:   github.String("feature"),
+			PullRequests: []*github.PullRequest{{Number: github.Int(3)}},
+		},
+	}
+
+	testcases := []struct {
+		group        string
+		job          activeWorkflowJob
+		want         string
+		wantResolved bool
+	}{
+		{group: "static", job: push, want: "static", wantResolved: true},
+		{group: "${{ github.workflow }}-${{ github.ref }}", job: push, want: "CI-refs/heads/main", wantResolved: true},
+		{group: "${{ github.workflow }}-${{ github.ref }}", job: pr, want: "CI-refs/pull/3/merge", wantResolved: true},
+		{group: "${{ github.head_ref || github.run_id }}", job: push, want: "1", wantResolved: true},
+		{group: "${{ github.head_ref || github.run_id }}", job: pr, want: "feature", wantResolved: true},
+		{group: "${{ github.job }}-${{ github.head_ref || 'default' }}", job: push, want: "build-default", wantResolved: true},
+		{group: "${{ matrix.env }}", job: push, wantResolved: false},
+		{group: "${{ inputs.env || 'default' }}", job: push, wantResolved: false},
+	}
+
+	for _, tc := range testcases {
+		got, resolved := resolveConcurrencyGroup(tc.group, tc.job, "build")
+
+		if resolved != tc.wantResolved {
+			t.Errorf("%s: unexpected resolved: want %v, got %v", tc.group, tc.wantResolved, resolved)
+		} else if resolved && got != tc.want {
+			t.Errorf("%s: unexpected group: want %q, got %q", tc.group, tc.want, got)
+		}
+	}
+}
+
+func TestCountConcurrentJobs(t *testing.T) {
+	def := &workflowDefinition{
+		jobs: []workflowJobDefinition{
+			{id: "build", maxParallel: 2},
+			{id: "deploy", concurrencyGroup: "deploy-${{ github.ref }}"},
+			{id: "test"},
+		},
+	}
+
+	newJob := func(runID int64, name, status string) activeWorkflowJob {
+		return activeWorkflowJob{
+			owner: "test",
+			repo:  "valid",
+			run:   &github.WorkflowRun{ID: github.Int64(runID), HeadBranch: github.String("main"), Event: github.String("push")},
+			job:   &github.WorkflowJob{Name: github.String(name), Status: github.String(status)},
+		}
+	}
+
+	testcases := []struct {
+		name           string
+		jobs           []activeWorkflowJob
+		definitionOf   func(activeWorkflowJob) *workflowDefinition
+		wantQueued     int
+		wantInProgress int
+	}{
+		{
+			name: "max-parallel",
+			jobs: []activeWorkflowJob{
+				newJob(1, "build (a)", "in_progress"),
+				newJob(1, "build (b)", "queued"),
+				newJob(1, "build (c)", "queued"),
+				newJob(1, "build (d)", "queued"),
+				// Another run of the workflow has its own max-parallel
+				newJob(2, "build (a)", "queued"),
+				newJob(2, "build (b)", "queued"),
+				newJob(2, "build (c)", "queued"),
+				newJob(1, "test", "queued"),
+			},
+			wantQueued:     4,
+			wantInProgress: 1,
+		},
+		{
+			name: "concurrency group",
+			jobs: []activeWorkflowJob{
+				newJob(1, "deploy", "queued"),
+				newJob(2, "deploy", "queued"),
+				newJob(3, "deploy", "queued"),
+			},
+			wantQueued: 1,
+		},
+		{
+			name: "concurrency group occupied by an in-progress job",
+			jobs: []activeWorkflowJob{
+				newJob(1, "deploy", "queued"),
+				newJob(2, "deploy", "in_progress"),
+			},
+			wantInProgress: 1,
+		},
+		{
+			name: "unavailable definitions",
+			jobs: []activeWorkflowJob{
+				newJob(1, "build (a)", "in_progress"),
+				newJob(1, "build (b)", "in_progress"),
+				newJob(1, "build (c)", "queued"),
+			},
+			definitionOf:   func(activeWorkflowJob) *workflowDefinition { return nil },
+			wantQueued:     1,
+			wantInProgress: 2,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			definitionOf := tc.definitionOf
+			if definitionOf == nil {
+				definitionOf = func(activeWorkflowJob) *workflowDefinition { return def }
+			}
+
+			queued, inProgress := countConcurrentJobs(tc.jobs, definitionOf)
+
+			if queued != tc.wantQueued || inProgress != tc.wantInProgress {
+				t.Errorf("unexpected counts: want queued=%d in_progress=%d, got queued=%d in_progress=%d", tc.wantQueued, tc.wantInProgress, queued, inProgress)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// DefaultWorkflowJobCacheTTL is the default max age of the jobs of a workflow run cached for job-level autoscaling.
+const DefaultWorkflowJobCacheTTL = 1 * time.Minute
+
+// maxCachedWorkflowRuns bounds the number of the workflow runs whose jobs are cached for job-level autoscaling.
+const maxCachedWorkflowRuns = 5000
+
+// workflowJobCache caches the jobs of the queued and in-progress workflow runs by the run IDs, along with the jobs
+// that match the labels and the runner group of each scale target, so that the HRAs don't list the jobs of every run on every reconciliation.
+//
+// The jobs of a run are refetched only when the status or the update time of the run changed since they were fetched,
+// or the TTL elapsed, as GitHub doesn't always update a run when the status of its jobs changes.
+type workflowJobCache struct {
+	mu   sync.Mutex
+	runs map[string]*cachedWorkflowRunJobs
+}
+
+type cachedWorkflowRunJobs struct {
+	status    string
+	updatedAt time.Time
+	fetchedAt time.Time
+
+	// total is the number of all the jobs of the run, including the ones that don't match any labels.
+	total int
+
+	jobs []*github.WorkflowJob
+
+	// matched is the jobs that can run on the self-hosted runners with the labels in the runner group,
+	// keyed by the sorted labels and the runner group.
+	matched map[string][]*gogithub.WorkflowJob
+}
+
+func (e *cachedWorkflowRunJobs) isFresh(run *gogithub.WorkflowRun, now time.Time, ttl time.Duration) bool {
+	return e.status == run.GetStatus() && e.updatedAt.Equal(run.GetUpdatedAt().Time) && now.Sub(e.fetchedAt) < ttl
+}
+
+// listMatchingWorkflowJobs returns the jobs of the run that can run on the self-hosted runners of the scale target,
+// along with the number of all the jobs of the run, which is zero when the jobs are unavailable.
+func (r *HorizontalRunnerAutoscalerReconciler) listMatchingWorkflowJobs(st scaleTarget, owner, repo string, run *gogithub.WorkflowRun, now time.Time) ([]*gogithub.WorkflowJob, int, error) {
+	key := fmt.Sprintf("%s/%s/%d", owner, repo, run.GetID())
+
+	labels := append([]string{}, r.RunnerLabelAliases.canonicalize(st.labels)...)
+	sort.Strings(labels)
+	group := runnerGroupNameOfJobs(st)
+	matchKey := strings.Join(labels, ",") + "@" + group + "/" + strings.Join(st.platformLabels, ",")
+
+	c := &r.workflowJobs
+	ttl := r.WorkflowJobCacheTTL
+
+	if ttl > 0 {
+		c.mu.Lock()
+		e, ok := c.runs[key]
+		if ok && e.isFresh(run, now, ttl) {
+			st.observeWorkflowJobCache(true)
+
+			matched, ok := e.matched[matchKey]
+			if !ok {
+				matched = matchWorkflowJobs(e.jobs, labels, st.platformLabels, group, r.RunnerLabelAliases)
+				e.matched[matchKey] = matched
+			}
+			c.mu.Unlock()
+
+			return matched, e.total, nil
+		}
+		c.mu.Unlock()
+
+		st.observeWorkflowJobCache(false)
+	}
+
+	jobs, err := r.githubClient(st).ListWorkflowJobs(st.githubContext(), owner, repo, run.GetID())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matched := matchWorkflowJobs(jobs, labels, st.platformLabels, group, r.RunnerLabelAliases)
+
+	// The jobs may have been cut short by the page budget, which are left uncached to be listed in full later.
+	if ttl > 0 && !st.pageBudget.Truncated() {
+		c.mu.Lock()
+		if c.runs == nil {
+			c.runs = map[string]*cachedWorkflowRunJobs{}
+		}
+		if len(c.runs) >= maxCachedWorkflowRuns {
+			for k, e := range c.runs {
+				if now.Sub(e.fetchedAt) >= ttl {
+					delete(c.runs, k)
+				}
+			}
+		}
+		if len(c.runs) >= maxCachedWorkflowRuns {
+			c.runs = map[string]*cachedWorkflowRunJobs{}
+		}
+		c.runs[key] = &cachedWorkflowRunJobs{
+			status:    run.GetStatus(),
+			updatedAt: run.GetUpdatedAt().Time,
+			fetchedAt: now,
+			total:     len(jobs),
+			jobs:      jobs,
+			matched:   map[string][]*gogithub.WorkflowJob{matchKey: matched},
+		}
+		c.mu.Unlock()
+	}
+
+	return matched, len(jobs), nil
+}
+
+// matchWorkflowJobs returns the jobs that can run on the self-hosted runners with the labels.
+// The jobs requesting an operating system or a CPU architecture other than the ones of the platform labels of the runners are excluded.
+// When the runner group is given, the jobs already picked up by the runners of the other groups are excluded,
+// as GitHub reports the runner group of a job only once it's assigned to a runner.
+// The labels of the jobs are renamed by the aliases, to be matched against the runner labels renamed by the same aliases.
+func matchWorkflowJobs(jobs []*github.WorkflowJob, runnerLabels, platformLabels []string, runnerGroup string, aliases RunnerLabelAliases) []*gogithub.WorkflowJob {
+	var matched []*gogithub.WorkflowJob
+
+JOB:
+	for _, job := range jobs {
+		labels := make(map[string]struct{}, len(job.Labels))
+		for _, l := range aliases.canonicalize(job.Labels) {
+			labels[l] = struct{}{}
+		}
+
+		if _, ok := labels["self-hosted"]; !ok {
+			continue JOB
+		}
+
+		for _, l := range runnerLabels {
+			if _, ok := labels[l]; !ok {
+				continue JOB
+			}
+		}
+
+		if !jobPlatformMatches(job.Labels, platformLabels) {
+			continue JOB
+		}
+
+		if g := job.GetRunnerGroupName(); runnerGroup != "" && g != "" && g != runnerGroup {
+			continue JOB
+		}
+
+		matched = append(matched, &job.WorkflowJob)
+	}
+
+	return matched
+}
+
+// runnerGroupNameOfJobs returns the runner group name GitHub reports for the jobs picked up by the runners of the scale target,
+// or an empty string for the repository runners, which don't belong to any runner group.
+func runnerGroupNameOfJobs(st scaleTarget) string {
+	if st.repo != "" {
+		return ""
+	}
+
+	if st.group == "" {
+		return defaultRunnerGroupName
+	}
+
+	return st.group
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+func TestListMatchingWorkflowJobs(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		fmt.Fprint(w, `{"total_count": 3, "jobs": [
+			{"id": 1, "status": "queued", "labels": ["self-hosted", "linux"]},
+			{"id": 2, "status": "in_progress", "labels": ["self-hosted", "linux", "gpu"]},
+			{"id": 3, "status": "queued", "labels": ["ubuntu-latest"]}
+		]}`)
+	}))
+	defer server.Close()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Log:                 logr.Discard(),
+		GitHubClient:        newGithubClient(server),
+		WorkflowJobCacheTTL: time.Minute,
+	}
+
+	newRun := func(status string, updatedAt time.Time) *gogithub.WorkflowRun {
+		return &gogithub.WorkflowRun{
+			ID:        gogithub.Int64(100),
+			Status:    gogithub.String(status),
+			UpdatedAt: &gogithub.Timestamp{Time: updatedAt},
+		}
+	}
+
+	testcases := []struct {
+		description  string
+		run          *gogithub.WorkflowRun
+		labels       []string
+		after        time.Duration
+		wantMatched  []int64
+		wantRequests int
+		wantHit      bool
+	}{
+		{
+			description:  "first lookup",
+			run:          newRun("queued", now),
+			labels:       []string{"linux"},
+			wantMatched:  []int64{1, 2},
+			wantRequests: 1,
+		},
+		{
+			description:  "unchanged run",
+			run:          newRun("queued", now),
+			labels:       []string{"linux"},
+			after:        30 * time.Second,
+			wantMatched:  []int64{1, 2},
+			wantRequests: 1,
+			wantHit:      true,
+		},
+		{
+			description:  "other labels are matched against the cached jobs",
+			run:          newRun("queued", now),
+			labels:       []string{"gpu", "linux"},
+			after:        30 * time.Second,
+			wantMatched:  []int64{2},
+			wantRequests: 1,
+			wantHit:      true,
+		},
+		{
+			description:  "status changed",
+			run:          newRun("in_progress", now),
+			labels:       []string{"linux"},
+			after:        30 * time.Second,
+			wantMatched:  []int64{1, 2},
+			wantRequests: 2,
+		},
+		{
+			description:  "updated",
+			run:          newRun("in_progress", now.Add(time.Minute)),
+			labels:       []string{"linux"},
+			after:        time.Minute,
+			wantMatched:  []int64{1, 2},
+			wantRequests: 3,
+		},
+		{
+			description:  "expired",
+			run:          newRun("in_progress", now.Add(time.Minute)),
+			labels:       []string{"linux"},
+			after:        2 * time.Minute,
+			wantMatched:  []int64{1, 2},
+			wantRequests: 4,
+		},
+	}
+
+	for _, tc := range testcases {
+		obs := &metrics.HorizontalRunnerAutoscalerObservation{}
+		st := scaleTarget{labels: tc.labels, observation: obs}
+
+		jobs, total, err := r.listMatchingWorkflowJobs(st, "test", "valid", tc.run, now.Add(tc.after))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.description, err)
+		}
+
+		var matched []int64
+		for _, j := range jobs {
+			matched = append(matched, j.GetID())
+		}
+
+		if fmt.Sprint(matched) != fmt.Sprint(tc.wantMatched) || total != 3 {
+			t.Errorf("%s: unexpected jobs: want %v of 3, got %v of %d", tc.description, tc.wantMatched, matched, total)
+		}
+
+		if requests != tc.wantRequests {
+			t.Errorf("%s: unexpected number of requests: want %d, got %d", tc.description, tc.wantRequests, requests)
+		}
+
+		if hit := obs.WorkflowJobCacheHits == 1 && obs.WorkflowJobCacheMisses == 0; hit != tc.wantHit {
+			t.Errorf("%s: unexpected cache result: want hit=%v, got %+v", tc.description, tc.wantHit, obs)
+		}
+	}
+}
+
+func TestListMatchingWorkflowJobs_Disabled(t *testing.T) {
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		fmt.Fprint(w, `{"total_count": 1, "jobs": [{"id": 1, "status": "queued", "labels": ["self-hosted"]}]}`)
+	}))
+	defer server.Close()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	run := &gogithub.WorkflowRun{ID: gogithub.Int64(100), Status: gogithub.String("queued")}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := r.listMatchingWorkflowJobs(scaleTarget{}, "test", "valid", run, time.Now()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("unexpected number of requests: want 2, got %d", requests)
+	}
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+)
+
+// DefaultRepositoryFetchConcurrency is the default number of the repositories whose workflow runs are fetched in parallel
+// to count the workflow jobs of a metric.
+const DefaultRepositoryFetchConcurrency = 4
+
+// metricRepository is a repository whose workflow jobs are counted by a metric.
+type metricRepository struct {
+	owner, repo string
+
+	// name is the entry of the repositoryNames of the metric or the name of the repository selected by its repositorySelector,
+	// which is empty for the repository runners.
+	name string
+
+	// weight is the number of runners demanded per workflow job of the repository.
+	weight float64
+}
+
+// parseRepositoryWeights returns the weights of the repositories of the metric keyed by the repository names.
+// The weights must be given to the entries of repositoryNames, unless the repositories are discovered by repositorySelector.
+func parseRepositoryWeights(metric *v1alpha1.MetricSpec) (map[string]float64, error) {
+	if metric == nil || len(metric.RepositoryWeights) == 0 {
+		return nil, nil
+	}
+
+	names := map[string]bool{}
+	for _, name := range metric.RepositoryNames {
+		names[name] = true
+	}
+
+	weights := map[string]float64{}
+
+	for name, s := range metric.RepositoryWeights {
+		if !names[name] && metric.RepositorySelector == nil {
+			return nil, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].repositoryWeights has the weight of %q, which isn't one of repositoryNames", name)
+		}
+
+		w, err := strconv.ParseFloat(s, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].repositoryWeights[%q] must be a non-negative float64, but got %q", name, s)
+		}
+
+		weights[name] = w
+	}
+
+	return weights, nil
+}
+
+// repositoryWeight returns the weight of the repository, which defaults to 1.
+func repositoryWeight(weights map[string]float64, name string) float64 {
+	if w, ok := weights[name]; ok {
+		return w
+	}
+
+	return 1
+}
+
+// weightedJobs returns the number of runners demanded by the jobs of the repositories, rounded up.
+func weightedJobs(repos []metricRepository, jobs func(i int) int) int {
+	var sum float64
+
+	for i, r := range repos {
+		sum += r.weight * float64(jobs(i))
+	}
+
+	return int(math.Ceil(sum))
+}
+
+// workflowJobDemand tracks the number of runners demanded by the workflow jobs counted so far across the repositories of a metric,
+// so that the rest of the jobs can be left uncounted once the demand reaches the limit. See workflowJobDemandLimit.
+// A nil workflowJobDemand has no limit.
+type workflowJobDemand struct {
+	limit int
+
+	mu  sync.Mutex
+	sum float64
+}
+
+func newWorkflowJobDemand(limit int) *workflowJobDemand {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &workflowJobDemand{limit: limit}
+}
+
+// established returns true when the demand has reached the limit.
+func (d *workflowJobDemand) established() bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return int(math.Ceil(d.sum)) >= d.limit
+}
+
+// forRepository returns the demand to which the jobs of the repository of the weight are added.
+func (d *workflowJobDemand) forRepository(weight float64) repositoryDemand {
+	return repositoryDemand{demand: d, weight: weight}
+}
+
+// repositoryDemand adds the workflow jobs of a repository to the demand by the weight of the repository.
+type repositoryDemand struct {
+	demand *workflowJobDemand
+	weight float64
+}
+
+func (d repositoryDemand) add(jobs int) {
+	if d.demand == nil {
+		return
+	}
+
+	d.demand.mu.Lock()
+	d.demand.sum += d.weight * float64(jobs)
+	d.demand.mu.Unlock()
+}
+
+func (d repositoryDemand) established() bool {
+	return d.demand.established()
+}
+
+// forEachRepository calls fn for each of the n repositories on up to concurrency goroutines,
+// and returns the first error returned by fn, if any. A concurrency less than 2 calls fn serially.
+func forEachRepository(n, concurrency int, fn func(i int) error) error {
+	if concurrency < 2 || n < 2 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	sem := make(chan struct{}, concurrency)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// forRepository returns the copy of the scale target used to count the workflow jobs of one of the repositories.
+// It observes the GitHub API requests on its own, so that the repositories can be counted in parallel
+// without updating the same observation concurrently. See mergeRepositoryObservation.
+func (st scaleTarget) forRepository() scaleTarget {
+	if st.observation != nil {
+		st.observation = &metrics.HorizontalRunnerAutoscalerObservation{}
+	}
+
+	return st
+}
+
+// mergeRepositoryObservation adds the GitHub API requests observed while counting the workflow jobs of a repository
+// to the observation of the reconciliation.
+func mergeRepositoryObservation(dst, src *metrics.HorizontalRunnerAutoscalerObservation) {
+	if dst == nil || src == nil {
+		return
+	}
+
+	dst.GitHubAPICacheHits += src.GitHubAPICacheHits
+	dst.GitHubAPICacheMisses += src.GitHubAPICacheMisses
+	dst.WorkflowJobCacheHits += src.WorkflowJobCacheHits
+	dst.WorkflowJobCacheMisses += src.WorkflowJobCacheMisses
+
+	if v := src.GitHubRateLimitRemaining; v != nil && (dst.GitHubRateLimitRemaining == nil || *v < *dst.GitHubRateLimitRemaining) {
+		dst.GitHubRateLimitRemaining = v
+	}
+}
+
+// repositoryWorkflowJobs returns the breakdown of the workflow jobs of the repositories listed or selected by the metric,
+// which is reported in the status of the metric.
+func repositoryWorkflowJobs(metric *v1alpha1.MetricSpec, repos []metricRepository, counts []*workflowJobCounts) []v1alpha1.RepositoryWorkflowJobs {
+	var breakdown []v1alpha1.RepositoryWorkflowJobs
+
+	for i, r := range repos {
+		c := counts[i]
+		if r.name == "" || c == nil {
+			continue
+		}
+
+		breakdown = append(breakdown, v1alpha1.RepositoryWorkflowJobs{
+			Name:       r.name,
+			Weight:     metric.RepositoryWeights[r.name],
+			Queued:     c.queued,
+			InProgress: c.inProgress,
+		})
+	}
+
+	return breakdown
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+func TestCountWorkflowJobs_RepositoryWeights(t *testing.T) {
+	// The number of the queued jobs of each repository of the organization.
+	queued := map[string]int{"build": 2, "docs": 3, "web": 1}
+
+	var inFlight, maxInFlight int32
+
+	mux := http.NewServeMux()
+	for repo, n := range queued {
+		repo, n := repo, n
+
+		mux.HandleFunc(fmt.Sprintf("/repos/test/%s/actions/runs", repo), func(w http.ResponseWriter, req *http.Request) {
+			cur := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if cur <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, cur) {
+					break
+				}
+			}
+
+			// Give the other repositories the chance to be fetched at the same time.
+			time.Sleep(10 * time.Millisecond)
+
+			switch req.URL.Query().Get("status") {
+			case "queued":
+				fmt.Fprint(w, `{"total_count": 1, "workflow_runs": [{"id": 1, "name": "CI", "status": "queued"}]}`)
+			default:
+				fmt.Fprint(w, `{"total_count": 0, "workflow_runs": []}`)
+			}
+		})
+		mux.HandleFunc(fmt.Sprintf("/repos/test/%s/actions/runs/1/jobs", repo), func(w http.ResponseWriter, req *http.Request) {
+			jobs := `{"status": "queued", "labels": ["self-hosted"]}`
+			for i := 1; i < n; i++ {
+				jobs += `, {"status": "queued", "labels": ["self-hosted"]}`
+			}
+			fmt.Fprintf(w, `{"total_count": %d, "jobs": [%s]}`, n, jobs)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testcases := []struct {
+		description     string
+		weights         map[string]string
+		concurrency     int
+		wantQueued      int
+		wantRepos       []v1alpha1.RepositoryWorkflowJobs
+		wantMaxInFlight int32
+		wantErr         bool
+	}{
+		{
+			description:     "no weights",
+			concurrency:     1,
+			wantQueued:      6,
+			wantMaxInFlight: 1,
+			wantRepos: []v1alpha1.RepositoryWorkflowJobs{
+				{Name: "build", Queued: 2},
+				{Name: "docs", Queued: 3},
+				{Name: "web", Queued: 1},
+			},
+		},
+		{
+			description:     "weighted and fetched in parallel",
+			weights:         map[string]string{"build": "2", "docs": "0.5"},
+			concurrency:     2,
+			wantQueued:      7,
+			wantMaxInFlight: 2,
+			wantRepos: []v1alpha1.RepositoryWorkflowJobs{
+				{Name: "build", Weight: "2", Queued: 2},
+				{Name: "docs", Weight: "0.5", Queued: 3},
+				{Name: "web", Queued: 1},
+			},
+		},
+		{
+			description: "weight of an unknown repository",
+			weights:     map[string]string{"api": "2"},
+			wantErr:     true,
+		},
+		{
+			description: "invalid weight",
+			weights:     map[string]string{"build": "-1"},
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			atomic.StoreInt32(&maxInFlight, 0)
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:                        logr.Discard(),
+				GitHubClient:               newGithubClient(server),
+				RepositoryFetchConcurrency: tc.concurrency,
+			}
+
+			metric := &v1alpha1.MetricSpec{
+				Type:              v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+				RepositoryNames:   []string{"build", "docs", "web"},
+				RepositoryWeights: tc.weights,
+			}
+
+			st := scaleTarget{
+				org:                    "test",
+				observation:            &metrics.HorizontalRunnerAutoscalerObservation{},
+				repositoryWorkflowJobs: map[string][]v1alpha1.RepositoryWorkflowJobs{},
+			}
+
+			counts, err := h.countWorkflowJobs(st, v1alpha1.HorizontalRunnerAutoscaler{}, metric)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if counts.queued != tc.wantQueued {
+				t.Errorf("incorrect queued workflow jobs: want %d, got %d", tc.wantQueued, counts.queued)
+			}
+
+			if got := st.repositoryWorkflowJobs[metric.Type]; !reflect.DeepEqual(got, tc.wantRepos) {
+				t.Errorf("unexpected breakdown by repository: want %+v, got %+v", tc.wantRepos, got)
+			}
+
+			if got := atomic.LoadInt32(&maxInFlight); got != tc.wantMaxInFlight {
+				t.Errorf("unexpected number of repositories fetched at the same time: want %d, got %d", tc.wantMaxInFlight, got)
+			}
+
+			if got := st.observation.GitHubAPICacheMisses; got == 0 {
+				t.Error("GitHub API requests made for the repositories aren't observed")
+			}
+		})
+	}
+}
+
+func TestCountWorkflowJobs_DemandLimit(t *testing.T) {
+	// Three queued runs of a job each per repository, whose IDs are unique across the repositories.
+	s := fake.NewScenario()
+	for i, repo := range []string{"build", "docs"} {
+		for id := int64(i*10 + 1); id <= int64(i*10+3); id++ {
+			s.AddWorkflowRun("test", repo,
+				&gogithub.WorkflowRun{ID: gogithub.Int64(id), Status: gogithub.String("queued")},
+				&gogithub.WorkflowJob{ID: gogithub.Int64(id), Status: gogithub.String("queued"), Labels: []string{"self-hosted"}},
+			)
+		}
+	}
+
+	server := s.GetServer()
+	defer server.Close()
+
+	testcases := []struct {
+		description string
+		demandLimit int
+		wantQueued  int
+		wantDocs    int
+	}{
+		{
+			description: "no limit",
+			wantQueued:  6,
+			wantDocs:    1,
+		},
+		{
+			description: "limit reached within the first repository",
+			demandLimit: 2,
+			wantQueued:  2,
+		},
+		{
+			description: "limit reached within the second repository",
+			demandLimit: 4,
+			wantQueued:  4,
+			wantDocs:    1,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			s.ResetRequests()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:                        logr.Discard(),
+				GitHubClient:               newGithubClient(server),
+				RepositoryFetchConcurrency: 1,
+			}
+
+			metric := &v1alpha1.MetricSpec{
+				Type:            v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+				RepositoryNames: []string{"build", "docs"},
+			}
+
+			st := scaleTarget{org: "test", demandLimit: tc.demandLimit}
+
+			counts, err := h.countWorkflowJobs(st, v1alpha1.HorizontalRunnerAutoscaler{}, metric)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if counts.queued != tc.wantQueued {
+				t.Errorf("incorrect queued workflow jobs: want %d, got %d", tc.wantQueued, counts.queued)
+			}
+
+			s.AssertRequested(t, "GET", "/repos/test/docs/actions/runs?status=queued", tc.wantDocs)
+		})
+	}
+}
+
+func TestWorkflowJobDemandLimit(t *testing.T) {
+	metric := v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns}
+
+	testcases := []struct {
+		description string
+		maxPages    int
+		spec        v1alpha1.HorizontalRunnerAutoscalerSpec
+		metric      v1alpha1.MetricSpec
+		want        int
+	}{
+		{
+			description: "page budget disabled",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+			metric:      metric,
+		},
+		{
+			description: "page budget enabled",
+			maxPages:    10,
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+			metric:      metric,
+			want:        6,
+		},
+		{
+			description: "concurrency-aware metric",
+			maxPages:    10,
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+			metric:      v1alpha1.MetricSpec{Type: metric.Type, ConcurrencyAware: true},
+		},
+		{
+			description: "average of the metrics",
+			maxPages:    10,
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5), MetricsCombinationPolicy: v1alpha1.MetricsCombinationPolicyAverage},
+			metric:      metric,
+		},
+		{
+			description: "overflow",
+			maxPages:    10,
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5), Overflow: &v1alpha1.HorizontalRunnerAutoscalerOverflow{}},
+			metric:      metric,
+		},
+		{
+			description: "other metric type",
+			maxPages:    10,
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			r := &HorizontalRunnerAutoscalerReconciler{GitHubAPIMaxPagesPerReconcile: tc.maxPages}
+
+			if got := r.workflowJobDemandLimit(v1alpha1.HorizontalRunnerAutoscaler{Spec: tc.spec}, tc.metric); got != tc.want {
+				t.Errorf("unexpected demand limit: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
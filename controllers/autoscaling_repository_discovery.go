@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// organizationRepositoriesTTL is how long the repositories of an organization listed for the repository selectors are cached.
+// Listing them costs an API call per 100 repositories, so they're listed at most once per the TTL however many HRAs select them,
+// while the repositories added to the organization are still picked up within minutes.
+const organizationRepositoriesTTL = 5 * time.Minute
+
+// organizationRepositoryCache caches the repositories of the organizations, keyed by the organizations.
+type organizationRepositoryCache struct {
+	mu      sync.Mutex
+	entries map[string]organizationRepositories
+}
+
+type organizationRepositories struct {
+	repos     []*gogithub.Repository
+	fetchedAt time.Time
+}
+
+// discoverRepositories returns the names of the repositories of the organization of the scale target selected by the selector,
+// sorted by the names.
+func (r *HorizontalRunnerAutoscalerReconciler) discoverRepositories(st scaleTarget, selector v1alpha1.RepositorySelector, now time.Time) ([]string, error) {
+	var pattern *regexp.Regexp
+	if selector.NamePattern != "" {
+		p, err := regexp.Compile(selector.NamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].repositorySelector.namePattern: %w", err)
+		}
+		pattern = p
+	}
+
+	repos, err := r.listOrganizationRepositories(st, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, repo := range repos {
+		if matchRepositorySelector(selector, pattern, repo) {
+			names = append(names, repo.GetName())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) listOrganizationRepositories(st scaleTarget, now time.Time) ([]*gogithub.Repository, error) {
+	c := &r.organizationRepositories
+
+	c.mu.Lock()
+	e, ok := c.entries[st.org]
+	c.mu.Unlock()
+
+	if ok && now.Sub(e.fetchedAt) < organizationRepositoriesTTL {
+		return e.repos, nil
+	}
+
+	repos, err := r.githubClient(st).ListOrganizationRepositories(st.githubContext(), st.org)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]organizationRepositories{}
+	}
+	c.entries[st.org] = organizationRepositories{repos: repos, fetchedAt: now}
+	c.mu.Unlock()
+
+	return repos, nil
+}
+
+// matchRepositorySelector returns true when the repository matches all the filters of the selector.
+// pattern is the compiled NamePattern of the selector, if any.
+func matchRepositorySelector(selector v1alpha1.RepositorySelector, pattern *regexp.Regexp, repo *gogithub.Repository) bool {
+	if repo.GetArchived() || repo.GetDisabled() {
+		return false
+	}
+
+	if pattern != nil && !pattern.MatchString(repo.GetName()) {
+		return false
+	}
+
+	if selector.Visibility != "" && repositoryVisibility(repo) != selector.Visibility {
+		return false
+	}
+
+	if len(selector.Topics) == 0 {
+		return true
+	}
+
+	for _, want := range selector.Topics {
+		for _, topic := range repo.Topics {
+			if topic == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// repositoryVisibility returns the visibility of the repository, which is either public, private or internal.
+// It falls back to the private flag for GHES versions that don't return the visibility.
+func repositoryVisibility(repo *gogithub.Repository) string {
+	if v := repo.GetVisibility(); v != "" {
+		return v
+	}
+
+	if repo.GetPrivate() {
+		return "private"
+	}
+
+	return "public"
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+func TestMatchRepositorySelector(t *testing.T) {
+	newRepo := func(name, visibility string, topics ...string) *gogithub.Repository {
+		return &gogithub.Repository{Name: gogithub.String(name), Visibility: gogithub.String(visibility), Topics: topics}
+	}
+
+	testcases := []struct {
+		description string
+		selector    v1alpha1.RepositorySelector
+		repo        *gogithub.Repository
+		want        bool
+	}{
+		{
+			description: "empty selector",
+			repo:        newRepo("service-a", "private"),
+			want:        true,
+		},
+		{
+			description: "archived",
+			repo:        &gogithub.Repository{Name: gogithub.String("service-a"), Archived: gogithub.Bool(true)},
+		},
+		{
+			description: "any of the topics",
+			selector:    v1alpha1.RepositorySelector{Topics: []string{"ci", "build"}},
+			repo:        newRepo("service-a", "private", "go", "build"),
+			want:        true,
+		},
+		{
+			description: "none of the topics",
+			selector:    v1alpha1.RepositorySelector{Topics: []string{"ci"}},
+			repo:        newRepo("service-a", "private", "go"),
+		},
+		{
+			description: "name pattern",
+			selector:    v1alpha1.RepositorySelector{NamePattern: "^service-"},
+			repo:        newRepo("service-a", "private"),
+			want:        true,
+		},
+		{
+			description: "name pattern not matching",
+			selector:    v1alpha1.RepositorySelector{NamePattern: "^service-"},
+			repo:        newRepo("docs", "private"),
+		},
+		{
+			description: "visibility",
+			selector:    v1alpha1.RepositorySelector{Visibility: "internal"},
+			repo:        newRepo("service-a", "internal"),
+			want:        true,
+		},
+		{
+			description: "visibility from the private flag",
+			selector:    v1alpha1.RepositorySelector{Visibility: "public"},
+			repo:        &gogithub.Repository{Name: gogithub.String("service-a"), Private: gogithub.Bool(true)},
+		},
+		{
+			description: "all of the filters",
+			selector:    v1alpha1.RepositorySelector{Topics: []string{"ci"}, NamePattern: "^service-", Visibility: "private"},
+			repo:        newRepo("service-a", "public", "ci"),
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var pattern *regexp.Regexp
+			if tc.selector.NamePattern != "" {
+				pattern = regexp.MustCompile(tc.selector.NamePattern)
+			}
+
+			if got := matchRepositorySelector(tc.selector, pattern, tc.repo); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverRepositories(t *testing.T) {
+	var requests int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/test/repos", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		fmt.Fprint(w, `[
+			{"name": "service-b", "visibility": "private", "topics": ["ci"]},
+			{"name": "service-a", "visibility": "private", "topics": ["ci"]},
+			{"name": "service-old", "visibility": "private", "topics": ["ci"], "archived": true},
+			{"name": "docs", "visibility": "public", "topics": ["ci"]}
+		]`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	h := &HorizontalRunnerAutoscalerReconciler{
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	st := scaleTarget{org: "test"}
+	selector := v1alpha1.RepositorySelector{Topics: []string{"ci"}, NamePattern: "^service-"}
+	now := time.Now()
+
+	for _, at := range []time.Time{now, now.Add(time.Minute)} {
+		got, err := h.discoverRepositories(st, selector, at)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []string{"service-a", "service-b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected repositories: want %v, got %v", want, got)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("the repositories must be listed once within the TTL: got %d requests", requests)
+	}
+
+	if _, err := h.discoverRepositories(st, selector, now.Add(organizationRepositoriesTTL)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("the repositories must be listed again after the TTL: got %d requests", requests)
+	}
+
+	if _, err := h.discoverRepositories(st, v1alpha1.RepositorySelector{NamePattern: "("}, now); err == nil {
+		t.Error("expected an error for the invalid name pattern")
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/simulator"
+)
+
+// defaultRunnerGroupName is the name GitHub reports for the default runner group of an organization or an enterprise.
+const defaultRunnerGroupName = "Default"
+
+// runnerGroupVisibilityTTL is how long the visibility of a runner group to a repository is cached.
+// Visibilities rarely change, so caching them saves an API call per repository on every reconciliation.
+const runnerGroupVisibilityTTL = 10 * time.Minute
+
+// runnerGroupVisibilityCache caches whether the runner groups are visible to the repositories,
+// keyed by the organization, the repository and the runner group.
+type runnerGroupVisibilityCache struct {
+	mu      sync.Mutex
+	entries map[string]runnerGroupVisibility
+}
+
+type runnerGroupVisibility struct {
+	visible   bool
+	fetchedAt time.Time
+}
+
+// isRunnerGroupVisibleToRepository returns whether the jobs of the repository can be routed to the runner group of the scale target,
+// honoring the repository access of the group.
+// The default runner group is assumed to be visible, like the webhook-based autoscaling does for the scale targets without a group.
+func (r *HorizontalRunnerAutoscalerReconciler) isRunnerGroupVisibleToRepository(st scaleTarget, owner, repo string, now time.Time) (bool, error) {
+	if st.repo != "" || st.group == "" {
+		return true, nil
+	}
+
+	key := owner + "/" + repo + "@" + st.group
+
+	c := &r.runnerGroupVisibilities
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && now.Sub(e.fetchedAt) < runnerGroupVisibilityTTL {
+		return e.visible, nil
+	}
+
+	group := simulator.NewRunnerGroupFromProperties(st.enterprise, st.org, st.group)
+
+	managed := simulator.NewVisibleRunnerGroups()
+	managed.Add(group)
+
+	simu := &simulator.Simulator{
+		Client: r.githubClient(st),
+	}
+
+	visibleGroups, err := simu.GetRunnerGroupsVisibleToRepository(st.githubContext(), owner, owner+"/"+repo, managed)
+	if err != nil {
+		return false, err
+	}
+
+	visible := visibleGroups.Includes(group)
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]runnerGroupVisibility{}
+	}
+	c.entries[key] = runnerGroupVisibility{visible: visible, fetchedAt: now}
+	c.mu.Unlock()
+
+	return visible, nil
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+func TestIsRunnerGroupVisibleToRepository(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		if req.URL.Path != "/orgs/test/actions/runner-groups" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch req.URL.Query().Get("visible_to_repository") {
+		case "valid":
+			fmt.Fprint(w, `{"total_count": 2, "runner_groups": [
+				{"id": 1, "name": "Default", "default": true},
+				{"id": 2, "name": "gpu"}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"total_count": 1, "runner_groups": [{"id": 1, "name": "Default", "default": true}]}`)
+		}
+	}))
+	defer server.Close()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	testcases := []struct {
+		description  string
+		st           scaleTarget
+		repo         string
+		after        time.Duration
+		want         bool
+		wantRequests int
+	}{
+		{
+			description: "repository runners",
+			st:          scaleTarget{repo: "test/valid", group: "gpu"},
+			repo:        "valid",
+			want:        true,
+		},
+		{
+			description: "default runner group",
+			st:          scaleTarget{org: "test"},
+			repo:        "other",
+			want:        true,
+		},
+		{
+			description:  "visible",
+			st:           scaleTarget{org: "test", group: "gpu"},
+			repo:         "valid",
+			want:         true,
+			wantRequests: 1,
+		},
+		{
+			description:  "not visible",
+			st:           scaleTarget{org: "test", group: "gpu"},
+			repo:         "other",
+			want:         false,
+			wantRequests: 2,
+		},
+		{
+			description:  "cached",
+			st:           scaleTarget{org: "test", group: "gpu"},
+			repo:         "other",
+			after:        time.Minute,
+			want:         false,
+			wantRequests: 2,
+		},
+		{
+			description:  "expired",
+			st:           scaleTarget{org: "test", group: "gpu"},
+			repo:         "other",
+			after:        runnerGroupVisibilityTTL,
+			want:         false,
+			wantRequests: 3,
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := r.isRunnerGroupVisibleToRepository(tc.st, "test", tc.repo, now.Add(tc.after))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.description, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: unexpected visibility: want %v, got %v", tc.description, tc.want, got)
+		}
+
+		if requests != tc.wantRequests {
+			t.Errorf("%s: unexpected number of requests: want %d, got %d", tc.description, tc.wantRequests, requests)
+		}
+	}
+}
+
+func TestListMatchingWorkflowJobs_RunnerGroup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"total_count": 3, "jobs": [
+			{"id": 1, "status": "queued", "labels": ["self-hosted", "linux"]},
+			{"id": 2, "status": "in_progress", "labels": ["self-hosted", "linux"], "runner_group_name": "Default"},
+			{"id": 3, "status": "in_progress", "labels": ["self-hosted", "linux"], "runner_group_name": "gpu"}
+		]}`)
+	}))
+	defer server.Close()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Log:                 logr.Discard(),
+		GitHubClient:        newGithubClient(server),
+		WorkflowJobCacheTTL: time.Minute,
+	}
+
+	run := &gogithub.WorkflowRun{ID: gogithub.Int64(100), Status: gogithub.String("in_progress")}
+
+	testcases := []struct {
+		description string
+		st          scaleTarget
+		want        []int64
+	}{
+		{
+			description: "repository runners",
+			st:          scaleTarget{repo: "test/valid"},
+			want:        []int64{1, 2, 3},
+		},
+		{
+			description: "default runner group",
+			st:          scaleTarget{org: "test"},
+			want:        []int64{1, 2},
+		},
+		{
+			description: "custom runner group",
+			st:          scaleTarget{org: "test", group: "gpu"},
+			want:        []int64{1, 3},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc.st.labels = []string{"linux"}
+		tc.st.observation = &metrics.HorizontalRunnerAutoscalerObservation{}
+
+		jobs, _, err := r.listMatchingWorkflowJobs(tc.st, "test", "valid", run, time.Now())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.description, err)
+		}
+
+		var got []int64
+		for _, j := range jobs {
+			got = append(got, j.GetID())
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: unexpected jobs: want %v, got %v", tc.description, tc.want, got)
+		}
+	}
+}
@@ -2,16 +2,23 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
 	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/metricprovider"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -363,7 +370,7 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 
 			st := h.scaleTargetFromRD(context.Background(), rd)
 
-			got, err := h.computeReplicasWithCache(log, metav1Now.Time, st, hra, minReplicas)
+			got, _, _, err := h.computeReplicasWithCache(context.Background(), log, metav1Now.Time, st, hra, minReplicas)
 			if err != nil {
 				if tc.err == "" {
 					t.Fatalf("unexpected error: expected none, got %v", err)
@@ -389,9 +396,10 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 	testcases := []struct {
 		description string
 
-		repos  []string
-		org    string
-		labels []string
+		repos      []string
+		org        string
+		enterprise string
+		labels     []string
 
 		fixed     *int
 		max       *int
@@ -418,6 +426,38 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 			workflowRuns_in_progress: `{"total_count": 2, "workflow_runs":[{"status":"in_progress"}, {"status":"in_progress"}]}"`,
 			want:                     3,
 		},
+		// enterprise runner, 3 demanded, max at 3
+		{
+			enterprise:               "test",
+			repos:                    []string{"test/valid"},
+			min:                      intPtr(2),
+			max:                      intPtr(3),
+			workflowRuns:             `{"total_count": 4, "workflow_runs":[{"status":"queued"}, {"status":"in_progress"}, {"status":"in_progress"}, {"status":"completed"}]}"`,
+			workflowRuns_queued:      `{"total_count": 1, "workflow_runs":[{"status":"queued"}]}"`,
+			workflowRuns_in_progress: `{"total_count": 2, "workflow_runs":[{"status":"in_progress"}, {"status":"in_progress"}]}"`,
+			want:                     3,
+		},
+		// enterprise runner, repository without owner
+		{
+			enterprise:               "test",
+			repos:                    []string{"valid"},
+			min:                      intPtr(2),
+			max:                      intPtr(3),
+			workflowRuns:             `{"total_count": 0, "workflow_runs":[]}"`,
+			workflowRuns_queued:      `{"total_count": 0, "workflow_runs":[]}"`,
+			workflowRuns_in_progress: `{"total_count": 0, "workflow_runs":[]}"`,
+			err:                      `validating autoscaling metrics: spec.autoscaling.metrics[].repositoryNames must be in the OWNER/REPO format for enterprise runner deployment, but got "valid"`,
+		},
+		// enterprise runner, no repos
+		{
+			enterprise:               "test",
+			min:                      intPtr(2),
+			max:                      intPtr(3),
+			workflowRuns:             `{"total_count": 0, "workflow_runs":[]}"`,
+			workflowRuns_queued:      `{"total_count": 0, "workflow_runs":[]}"`,
+			workflowRuns_in_progress: `{"total_count": 0, "workflow_runs":[]}"`,
+			err:                      "validating autoscaling metrics: spec.autoscaling.metrics[].repositoryNames is required and must have one more more entries for enterprise runner deployment",
+		},
 		// 2 demanded, max at 3, currently 3, delay scaling down due to grace period
 		{
 			org:                      "test",
@@ -673,6 +713,7 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 						},
 						Spec: v1alpha1.RunnerSpec{
 							RunnerConfig: v1alpha1.RunnerConfig{
+								Enterprise:   tc.enterprise,
 								Organization: tc.org,
 								Labels:       tc.labels,
 							},
@@ -712,7 +753,7 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 
 			st := h.scaleTargetFromRD(context.Background(), rd)
 
-			got, err := h.computeReplicasWithCache(log, metav1Now.Time, st, hra, minReplicas)
+			got, _, _, err := h.computeReplicasWithCache(context.Background(), log, metav1Now.Time, st, hra, minReplicas)
 			if err != nil {
 				if tc.err == "" {
 					t.Fatalf("unexpected error: expected none, got %v", err)
@@ -728,3 +769,770 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 		})
 	}
 }
+
+type stubMetricProvider int
+
+func (p stubMetricProvider) SuggestReplicas(ctx context.Context, req metricprovider.Request) (*metricprovider.Response, error) {
+	return &metricprovider.Response{DesiredReplicas: int(p)}, nil
+}
+
+// ctxMetricProvider fails with the error of the context it's called with, like a slow provider whose call is cancelled.
+type ctxMetricProvider struct{}
+
+func (ctxMetricProvider) SuggestReplicas(ctx context.Context, req metricprovider.Request) (*metricprovider.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &metricprovider.Response{DesiredReplicas: 2}, nil
+}
+
+func TestSuggestDesiredReplicas_ExternalProviderGetsReconcileContext(t *testing.T) {
+	h := &HorizontalRunnerAutoscalerReconciler{
+		Log:             logr.Discard(),
+		MetricProviders: map[string]metricprovider.Provider{"slow": ctxMetricProvider{}},
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			MinReplicas: intPtr(1),
+			MaxReplicas: intPtr(10),
+			Metrics: []v1alpha1.MetricSpec{
+				{Type: v1alpha1.AutoscalingMetricTypeExternal, External: &v1alpha1.ExternalMetricSpec{Provider: "slow"}},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, _, err := h.suggestDesiredReplicas(ctx, scaleTarget{replicas: intPtr(1)}, hra); !errors.Is(err, context.Canceled) {
+		t.Errorf("the metric provider must be called with the cancelled reconcile context: got error %v", err)
+	}
+}
+
+func TestComputeReplicasWithCache_ScaleDownDelay(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now := time.Now()
+	lastScaleOut := metav1.NewTime(now.Add(-5 * time.Minute))
+	maxStep := intstr.FromInt(1)
+
+	testcases := []struct {
+		description string
+
+		hraDelay    *int
+		metricDelay *int
+
+		window          *int
+		recommendations []v1alpha1.ReplicaRecommendation
+		maxStep         *intstr.IntOrString
+
+		want       int
+		wantReason string
+	}{
+		{
+			description: "controller default delays the scale down",
+			want:        5,
+			wantReason:  ScalingReasonScaleDownDelay,
+		},
+		{
+			description: "hra delay allows the scale down",
+			hraDelay:    intPtr(120),
+			want:        2,
+			wantReason:  v1alpha1.AutoscalingMetricTypeExternal,
+		},
+		{
+			description: "metric delay overrides the hra delay",
+			hraDelay:    intPtr(120),
+			metricDelay: intPtr(1800),
+			want:        5,
+			wantReason:  ScalingReasonScaleDownDelay,
+		},
+		{
+			description: "metric delay overrides the controller default",
+			metricDelay: intPtr(60),
+			want:        2,
+			wantReason:  v1alpha1.AutoscalingMetricTypeExternal,
+		},
+		{
+			description:     "stabilization window holds the highest recommendation",
+			hraDelay:        intPtr(120),
+			window:          intPtr(300),
+			recommendations: []v1alpha1.ReplicaRecommendation{{Time: metav1.NewTime(now.Add(-2 * time.Minute)), Replicas: 4}},
+			want:            4,
+			wantReason:      ScalingReasonScaleDownStabilization,
+		},
+		{
+			description: "max step limits the scale down",
+			hraDelay:    intPtr(120),
+			maxStep:     &maxStep,
+			want:        4,
+			wantReason:  ScalingReasonScaleDownMaxStep,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:                   logr.Discard(),
+				DefaultScaleDownDelay: DefaultScaleDownDelay,
+				MetricProviders:       map[string]metricprovider.Provider{"stub": stubMetricProvider(2)},
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas:                         intPtr(1),
+					MaxReplicas:                         intPtr(10),
+					ScaleDownDelaySecondsAfterScaleUp:   tc.hraDelay,
+					ScaleDownStabilizationWindowSeconds: tc.window,
+					ScaleDownMaxStep:                    tc.maxStep,
+					Metrics: []v1alpha1.MetricSpec{
+						{
+							Type:                               v1alpha1.AutoscalingMetricTypeExternal,
+							External:                           &v1alpha1.ExternalMetricSpec{Provider: "stub"},
+							ScaleDownDelaySecondsAfterScaleOut: tc.metricDelay,
+						},
+					},
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					DesiredReplicas:            intPtr(5),
+					LastSuccessfulScaleOutTime: &lastScaleOut,
+					ScaleDownRecommendations:   tc.recommendations,
+				},
+			}
+
+			got, reason, _, err := h.computeReplicasWithCache(context.Background(), logr.Discard(), now, scaleTarget{replicas: intPtr(5)}, hra, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %d", tc.want, got)
+			}
+
+			if reason != tc.wantReason {
+				t.Errorf("incorrect reason: want %s, got %s", tc.wantReason, reason)
+			}
+		})
+	}
+}
+
+func TestDetermineDesiredReplicas_QueuedJobsPlusBusyRunners(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	workflowRuns := `{"total_count": 3, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}, {"status":"completed"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	// 2 of the 3 runners of the scale target are busy. test4 belongs to another scale target.
+	runners := `
+{
+  "total_count": 4,
+  "runners": [
+    {"id": 1, "name": "test1", "os": "linux", "status": "online", "busy": true},
+    {"id": 2, "name": "test2", "os": "linux", "status": "online", "busy": true},
+    {"id": 3, "name": "test3", "os": "linux", "status": "online", "busy": false},
+    {"id": 4, "name": "test4", "os": "linux", "status": "online", "busy": true}
+  ]
+}
+`
+
+	testcases := []struct {
+		description string
+		metric      v1alpha1.MetricSpec
+		replicas    int
+		want        int
+		err         string
+	}{
+		{
+			description: "scale out to 3 queued jobs plus 2 busy runners",
+			replicas:    3,
+			want:        5,
+		},
+		{
+			description: "weighted queued jobs",
+			metric:      v1alpha1.MetricSpec{QueuedJobsWeight: "0.5"},
+			replicas:    3,
+			want:        4,
+		},
+		{
+			description: "weighted busy runners",
+			metric:      v1alpha1.MetricSpec{BusyRunnersWeight: "2"},
+			replicas:    3,
+			want:        7,
+		},
+		{
+			description: "scale in by utilization",
+			replicas:    10,
+			want:        7,
+		},
+		{
+			description: "no scale in while utilization is above the threshold",
+			metric:      v1alpha1.MetricSpec{ScaleDownThreshold: "0.1"},
+			replicas:    10,
+			want:        10,
+		},
+		{
+			description: "scale in never goes below the demand",
+			metric:      v1alpha1.MetricSpec{ScaleDownAdjustment: 8},
+			replicas:    10,
+			want:        5,
+		},
+		{
+			description: "invalid weight",
+			metric:      v1alpha1.MetricSpec{QueuedJobsWeight: "a"},
+			replicas:    3,
+			err:         "validating autoscaling metrics: spec.autoscaling.metrics[].queuedJobsWeight cannot be parsed into a float64",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, runners),
+			)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			metric := tc.metric
+			metric.Type = v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: intPtr(1),
+					MaxReplicas: intPtr(20),
+					Metrics:     []v1alpha1.MetricSpec{metric},
+				},
+			}
+
+			st := scaleTarget{
+				repo:     "test/valid",
+				replicas: intPtr(tc.replicas),
+				getRunnerMap: func() (map[string]time.Time, error) {
+					return map[string]time.Time{"test1": {}, "test2": {}, "test3": {}}, nil
+				},
+			}
+
+			got, _, _, err := h.suggestDesiredReplicas(context.Background(), st, hra)
+			if err != nil {
+				if tc.err == "" {
+					t.Fatalf("unexpected error: expected none, got %v", err)
+				} else if err.Error() != tc.err {
+					t.Fatalf("unexpected error: expected %v, got %v", tc.err, err)
+				}
+				return
+			} else if tc.err != "" {
+				t.Fatalf("expected error %q, got none", tc.err)
+			}
+
+			if got == nil || *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDetermineDesiredReplicas_ScaleFromZero(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	workflowRuns := `{"total_count": 3, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}, {"status":"completed"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	idleWorkflowRuns := `{"total_count": 1, "workflow_runs":[{"status":"completed"}]}"`
+	noWorkflowRuns := `{"total_count": 0, "workflow_runs":[]}"`
+
+	testcases := []struct {
+		description string
+		metric      v1alpha1.MetricSpec
+		idle        bool
+		want        *int
+	}{
+		{
+			description: "PercentageRunnersBusy wakes up to the queued jobs",
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+			want:        intPtr(3),
+		},
+		{
+			description: "QueuedJobsPlusBusyRunners wakes up to the weighted queued jobs",
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners, QueuedJobsWeight: "0.5"},
+			want:        intPtr(2),
+		},
+		{
+			description: "PercentageRunnersBusy stays at zero without queued jobs",
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+			idle:        true,
+		},
+		{
+			description: "QueuedJobsPlusBusyRunners stays at zero without queued jobs",
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners},
+			idle:        true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			opts := []fake.Option{
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			}
+			if tc.idle {
+				opts = append(opts, fake.WithListRepositoryWorkflowRunsResponse(200, idleWorkflowRuns, noWorkflowRuns, noWorkflowRuns))
+			} else {
+				opts = append(opts, fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress))
+			}
+
+			server := fake.NewServer(opts...)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: intPtr(0),
+					MaxReplicas: intPtr(20),
+					Metrics:     []v1alpha1.MetricSpec{tc.metric},
+				},
+			}
+
+			st := scaleTarget{
+				repo:     "test/valid",
+				replicas: intPtr(0),
+				getRunnerMap: func() (map[string]time.Time, error) {
+					t.Error("busy runners must not be checked while the scale target is scaled to zero")
+					return nil, nil
+				},
+			}
+
+			got, _, _, err := h.suggestDesiredReplicas(context.Background(), st, hra)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.want == nil {
+				if got != nil {
+					t.Errorf("incorrect desired replicas: want nil, got %d", *got)
+				}
+			} else if got == nil || *got != *tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", *tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDetermineDesiredReplicas_DisableRunLevelAutoscaling(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	// Run 1 has 2 queued jobs, while the jobs of the other runs are unavailable.
+	workflowRuns := `{"total_count": 4, "workflow_runs":[{"id": 1, "status":"queued"}, {"status":"queued"}, {"status":"in_progress"}, {"status":"completed"}]}"`
+	workflowRunsQueued := `{"total_count": 2, "workflow_runs":[{"id": 1, "status":"queued"}, {"status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	checkRunTrigger := v1alpha1.ScaleUpTrigger{GitHubEvent: &v1alpha1.GitHubEventScaleUpTriggerSpec{CheckRun: &v1alpha1.CheckRunSpec{}}}
+	workflowJobTrigger := v1alpha1.ScaleUpTrigger{GitHubEvent: &v1alpha1.GitHubEventScaleUpTriggerSpec{WorkflowJob: &v1alpha1.WorkflowJobSpec{}}}
+
+	testcases := []struct {
+		description string
+		disabled    bool
+		jobLevelOff bool
+		triggers    []v1alpha1.ScaleUpTrigger
+		want        int
+		err         string
+	}{
+		{
+			description: "runs without jobs are counted by default",
+			triggers:    []v1alpha1.ScaleUpTrigger{checkRunTrigger},
+			want:        4,
+		},
+		{
+			description: "only jobs are counted",
+			disabled:    true,
+			triggers:    []v1alpha1.ScaleUpTrigger{workflowJobTrigger},
+			want:        2,
+		},
+		{
+			description: "runs are counted without listing their jobs",
+			jobLevelOff: true,
+			triggers:    []v1alpha1.ScaleUpTrigger{checkRunTrigger},
+			want:        3,
+		},
+		{
+			description: "run-level scale up triggers are rejected",
+			disabled:    true,
+			triggers:    []v1alpha1.ScaleUpTrigger{workflowJobTrigger, checkRunTrigger},
+			err:         "validating scale up triggers: spec.scaleUpTriggers[1].githubEvent.checkRun scales by workflow runs, which is disabled by --disable-run-level-autoscaling. Use workflowJob instead",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:                        logr.Discard(),
+				GitHubClient:               newGithubClient(server),
+				DisableRunLevelAutoscaling: tc.disabled,
+				DisableJobLevelAutoscaling: tc.jobLevelOff,
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas:     intPtr(1),
+					MaxReplicas:     intPtr(10),
+					ScaleUpTriggers: tc.triggers,
+					Metrics: []v1alpha1.MetricSpec{
+						{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+					},
+				},
+			}
+
+			got, _, _, err := h.suggestDesiredReplicas(context.Background(), scaleTarget{repo: "test/valid"}, hra)
+			if err != nil {
+				if tc.err == "" {
+					t.Fatalf("unexpected error: expected none, got %v", err)
+				} else if err.Error() != tc.err {
+					t.Fatalf("unexpected error: expected %v, got %v", tc.err, err)
+				}
+				return
+			} else if tc.err != "" {
+				t.Fatalf("expected error %q, got none", tc.err)
+			}
+
+			if got == nil || *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDetermineDesiredReplicas_IncludeWaitingWorkflowRuns(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	// Run 1 has a job waiting for the approval of its environment besides 2 queued jobs,
+	// run 2 is waiting for the approval of its environment, and the jobs of run 3 pending on its concurrency group are unavailable.
+	workflowRuns := `{"total_count": 3, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"waiting"}, {"status":"pending"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 0, "workflow_runs":[]}"`
+	workflowRunsWaiting := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"waiting"}]}"`
+	workflowRunsPending := `{"total_count": 1, "workflow_runs":[{"status":"pending"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}, {"status":"waiting", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status":"completed", "labels":["self-hosted"]}, {"status":"waiting", "labels":["self-hosted"]}]}`,
+	}
+
+	testcases := []struct {
+		description    string
+		includeWaiting bool
+		waitingWeight  string
+		want           int
+		wantErr        bool
+	}{
+		{
+			description: "waiting runs and jobs are not counted by default",
+			want:        2,
+		},
+		{
+			description:    "waiting runs and jobs are counted as queued",
+			includeWaiting: true,
+			want:           5,
+		},
+		{
+			description:    "waiting runs and jobs are discounted by the weight",
+			includeWaiting: true,
+			waitingWeight:  "0.5",
+			want:           4,
+		},
+		{
+			description:    "waiting runs and jobs are not counted with the zero weight",
+			includeWaiting: true,
+			waitingWeight:  "0",
+			want:           2,
+		},
+		{
+			description:    "invalid weight",
+			includeWaiting: true,
+			waitingWeight:  "-1",
+			wantErr:        true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListRepositoryWaitingWorkflowRunsResponse(workflowRunsWaiting, workflowRunsPending),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: intPtr(1),
+					MaxReplicas: intPtr(10),
+					Metrics: []v1alpha1.MetricSpec{
+						{
+							Type:                       v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+							IncludeWaitingWorkflowRuns: tc.includeWaiting,
+							WaitingJobsWeight:          tc.waitingWeight,
+						},
+					},
+				},
+			}
+
+			got, _, _, err := h.suggestDesiredReplicas(context.Background(), scaleTarget{repo: "test/valid"}, hra)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got == nil || *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDetermineDesiredReplicas_Observation(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	workflowRuns := `{"total_count": 3, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}, {"status":"completed"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	server := fake.NewServer(
+		fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+		fake.WithListWorkflowJobsResponse(200, workflowJobs),
+		fake.WithListRunnersResponse(200, fake.RunnersListBody),
+	)
+	defer server.Close()
+
+	h := &HorizontalRunnerAutoscalerReconciler{
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			MinReplicas: intPtr(1),
+			MaxReplicas: intPtr(20),
+			Metrics: []v1alpha1.MetricSpec{
+				{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+			},
+		},
+	}
+
+	obs := &metrics.HorizontalRunnerAutoscalerObservation{}
+
+	st := scaleTarget{
+		repo:        "test/valid",
+		replicas:    intPtr(1),
+		observation: obs,
+	}
+
+	if _, _, _, err := h.suggestDesiredReplicas(context.Background(), st, hra); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if obs.QueuedWorkflowJobs == nil || *obs.QueuedWorkflowJobs != 3 {
+		t.Errorf("incorrect queued workflow jobs: want 3, got %v", obs.QueuedWorkflowJobs)
+	}
+
+	if obs.InProgressWorkflowJobs == nil || *obs.InProgressWorkflowJobs != 1 {
+		t.Errorf("incorrect in-progress workflow jobs: want 1, got %v", obs.InProgressWorkflowJobs)
+	}
+
+	if obs.BusyRunners != nil {
+		t.Errorf("busy runners must not be observed by TotalNumberOfQueuedAndInProgressWorkflowRuns, got %d", *obs.BusyRunners)
+	}
+
+	// The queued and in-progress workflow runs, and the jobs of the 2 workflow runs
+	if obs.GitHubAPICacheMisses != 4 || obs.GitHubAPICacheHits != 0 {
+		t.Errorf("incorrect github api cache results: want 4 misses and 0 hits, got %d misses and %d hits", obs.GitHubAPICacheMisses, obs.GitHubAPICacheHits)
+	}
+}
+
+func TestDetermineDesiredReplicas_CompletedRunners(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	runnersList := `{"total_count": 2, "runners": [{"name": "runner-1", "status": "online", "busy": true}, {"name": "runner-2", "status": "online", "busy": false}]}`
+
+	newPod := func(name string, phase corev1.PodPhase, runnerTerminated bool) corev1.Pod {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+
+		if runnerTerminated {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{Name: containerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+			}
+		}
+
+		return pod
+	}
+
+	testcases := []struct {
+		description string
+		pods        []corev1.Pod
+		want        int
+	}{
+		{
+			description: "no completed runners",
+			pods:        []corev1.Pod{newPod("runner-3", corev1.PodPending, false), newPod("runner-4", corev1.PodPending, false)},
+			// 1 busy out of 4 is below the scale down threshold
+			want: 2,
+		},
+		{
+			description: "completed runner pod is counted as busy",
+			pods:        []corev1.Pod{newPod("runner-3", corev1.PodSucceeded, false), newPod("runner-4", corev1.PodPending, false)},
+			want:        4,
+		},
+		{
+			description: "completed runner container is counted as busy",
+			pods:        []corev1.Pod{newPod("runner-3", corev1.PodSucceeded, false), newPod("runner-4", corev1.PodRunning, true)},
+			// 3 busy out of 4 is below the scale up threshold
+			want: 4,
+		},
+		{
+			description: "completed pods of registered runners are not counted",
+			pods:        []corev1.Pod{newPod("runner-2", corev1.PodSucceeded, false), newPod("runner-3", corev1.PodPending, false)},
+			want:        2,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(fake.WithListRunnersResponse(200, runnersList))
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas: intPtr(1),
+					MaxReplicas: intPtr(10),
+					Metrics: []v1alpha1.MetricSpec{
+						{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+					},
+				},
+			}
+
+			st := scaleTarget{
+				repo:     "test/valid",
+				replicas: intPtr(4),
+				getRunnerMap: func() (map[string]time.Time, error) {
+					return map[string]time.Time{"runner-1": {}, "runner-2": {}, "runner-3": {}, "runner-4": {}}, nil
+				},
+				listRunnerPods: func() ([]corev1.Pod, error) {
+					return tc.pods, nil
+				},
+			}
+
+			got, _, _, err := h.suggestDesiredReplicas(context.Background(), st, hra)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got == nil || *got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMetricStatuses_QueuedJobsPerRunner(t *testing.T) {
+	metrics := []v1alpha1.MetricSpec{
+		{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner, TargetQueuedJobsPerRunner: "2"},
+		{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+	}
+
+	jobs := map[string]workflowJobCounts{
+		v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner:                          {queued: 10, inProgress: 3},
+		v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns: {queued: 10, inProgress: 3},
+	}
+
+	// maxReplicas limited the desired replicas to 5, which leaves 2 runners to the 10 queued jobs.
+	statuses := metricStatuses(metrics, nil, jobs, 5)
+
+	if got := statuses[0].QueuedJobsPerRunner; got != "5.00" {
+		t.Errorf("unexpected queued jobs per runner: want %q, got %q", "5.00", got)
+	}
+
+	if got := statuses[1].QueuedJobsPerRunner; got != "" {
+		t.Errorf("unexpected queued jobs per runner of the other metric: want none, got %q", got)
+	}
+
+	if got := metricStatuses(metrics, nil, jobs, 3)[0].QueuedJobsPerRunner; got != "" {
+		t.Errorf("unexpected queued jobs per runner without any runner left for the queued jobs: want none, got %q", got)
+	}
+}
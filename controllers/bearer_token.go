@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// hasBearerToken returns true when the request has the `Authorization: Bearer TOKEN` header whose TOKEN matches token.
+// It's shared by the HTTP endpoints served on the metrics endpoint, which are disabled by the empty token.
+// The tokens are compared in constant time so that they can't be guessed from the response times.
+func hasBearerToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	got := r.Header.Get("Authorization")
+	if !strings.HasPrefix(got, "Bearer ") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(got, "Bearer ")), []byte(token)) == 1
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHasBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          bool
+	}{
+		{name: "matching token", token: "secret", authorization: "Bearer secret", want: true},
+		{name: "mismatching token", token: "secret", authorization: "Bearer wrong", want: false},
+		{name: "token without the bearer scheme", token: "secret", authorization: "secret", want: false},
+		{name: "missing header", token: "secret", want: false},
+		{name: "endpoint disabled by the empty token", token: "", authorization: "Bearer ", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			if got := hasBearerToken(r, tt.token); got != tt.want {
+				t.Errorf("hasBearerToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
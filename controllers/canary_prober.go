@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CanaryConditionType is the type of the condition set to RunnerDeployment and RunnerSet
+	// on each canary probe.
+	CanaryConditionType = "CanaryJobSucceeded"
+
+	// AnnotationKeyCanaryDisabled can be set to "true" on a RunnerDeployment or a RunnerSet
+	// to exclude it from canary probes.
+	AnnotationKeyCanaryDisabled = annotationKeyPrefix + "canary-disabled"
+
+	canaryResultSuccess = "success"
+	canaryResultFailure = "failure"
+	canaryResultTimeout = "timeout"
+	canaryResultError   = "error"
+
+	DefaultCanaryInterval     = 10 * time.Minute
+	DefaultCanaryTimeout      = 10 * time.Minute
+	defaultCanaryPollInterval = 10 * time.Second
+
+	// canaryCancelTimeout is the timeout of cancelling the canary workflow run that timed out.
+	canaryCancelTimeout = 30 * time.Second
+)
+
+// CanaryProber periodically dispatches a trivial workflow targeting each runner pool's labels,
+// and measures how long it takes for the job to be picked up and completed.
+//
+// The canary workflow is expected to accept the `labels` input, which is a JSON array of runner labels,
+// and to have a single job that `runs-on: ${{ fromJSON(github.event.inputs.labels) }}`.
+//
+// Runner pools are probed one by one so that each dispatched workflow run can be correlated to the pool.
+type CanaryProber struct {
+	client.Client
+	Log          logr.Logger
+	GitHubClient *github.Client
+
+	// Repository is the repository that contains the canary workflow, in the OWNER/REPO format.
+	Repository string
+	// Workflow is the file name of the canary workflow, like `canary.yml`.
+	Workflow string
+	// Ref is the git ref on which the canary workflow is dispatched.
+	Ref string
+
+	Interval     time.Duration
+	Timeout      time.Duration
+	PollInterval time.Duration
+
+	Namespace          string
+	CommonRunnerLabels []string
+}
+
+type canaryTarget struct {
+	kind string
+	key  types.NamespacedName
+
+	labels []string
+}
+
+type canaryResult struct {
+	result     string
+	message    string
+	pickup     time.Duration
+	completion time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader dispatches canary workflows.
+func (p *CanaryProber) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (p *CanaryProber) Start(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultCanaryInterval
+	}
+
+	p.Log.Info("Starting canary prober", "repository", p.Repository, "workflow", p.Workflow, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.probeAll(ctx); err != nil {
+			p.Log.Error(err, "Failed to probe runner pools")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *CanaryProber) probeAll(ctx context.Context) error {
+	targets, err := p.listTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		log := p.Log.WithValues("kind", t.kind, "name", t.key)
+
+		res := p.probe(ctx, t.labels)
+
+		log.Info("Canary probe finished", "result", res.result, "message", res.message, "pickup", res.pickup, "completion", res.completion)
+
+		metrics.SetCanaryResult(t.kind, t.key.Namespace, t.key.Name, res.result, res.pickup, res.completion, time.Now())
+
+		if err := p.setCondition(ctx, t, res); err != nil {
+			log.Error(err, "Failed to update canary condition")
+		}
+	}
+
+	return nil
+}
+
+// listTargets returns the runner pools that are able to run the canary workflow.
+// Organizational and enterprise runner pools are probed only when the canary repository is within the organization
+// or the enterprise, which is assumed for enterprise runners.
+func (p *CanaryProber) listTargets(ctx context.Context) ([]canaryTarget, error) {
+	var opts []client.ListOption
+	if p.Namespace != "" {
+		opts = append(opts, client.InNamespace(p.Namespace))
+	}
+
+	var targets []canaryTarget
+
+	var rdList v1alpha1.RunnerDeploymentList
+	if err := p.List(ctx, &rdList, opts...); err != nil {
+		return nil, err
+	}
+
+	for _, rd := range rdList.Items {
+		if t := p.newTarget("RunnerDeployment", rd.ObjectMeta, rd.Spec.Template.Spec.RunnerConfig); t != nil {
+			targets = append(targets, *t)
+		}
+	}
+
+	var rsList v1alpha1.RunnerSetList
+	if err := p.List(ctx, &rsList, opts...); err != nil {
+		return nil, err
+	}
+
+	for _, rs := range rsList.Items {
+		if t := p.newTarget("RunnerSet", rs.ObjectMeta, rs.Spec.RunnerConfig); t != nil {
+			targets = append(targets, *t)
+		}
+	}
+
+	return targets, nil
+}
+
+func (p *CanaryProber) newTarget(kind string, m metav1.ObjectMeta, rc v1alpha1.RunnerConfig) *canaryTarget {
+	if m.Annotations[AnnotationKeyCanaryDisabled] == "true" || !m.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	owner := strings.Split(p.Repository, "/")[0]
+
+	switch {
+	case rc.Repository != "":
+		if rc.Repository != p.Repository {
+			return nil
+		}
+	case rc.Organization != "":
+		if rc.Organization != owner {
+			return nil
+		}
+	case rc.Enterprise == "":
+		return nil
+	}
+
+	labels := []string{"self-hosted"}
+	labels = append(labels, p.CommonRunnerLabels...)
+	labels = append(labels, rc.Labels...)
+
+	return &canaryTarget{
+		kind:   kind,
+		key:    types.NamespacedName{Namespace: m.Namespace, Name: m.Name},
+		labels: labels,
+	}
+}
+
+// probe dispatches the canary workflow for the labels and waits for the resulting job to complete.
+// Durations are measured against the creation time of the workflow run so that
+// the clock skew between GitHub and the controller doesn't affect them.
+func (p *CanaryProber) probe(ctx context.Context, labels []string) canaryResult {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCanaryTimeout
+	}
+
+	pollInterval := p.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultCanaryPollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	owner, repo, err := splitOwnerAndRepo(p.Repository)
+	if err != nil {
+		return canaryResult{result: canaryResultError, message: err.Error()}
+	}
+
+	// Remember the latest run before dispatching so that we can find the run created by our dispatch
+	// without relying on timestamps.
+	lastRunID, err := p.latestRunID(ctx, owner, repo)
+	if err != nil {
+		return canaryResult{result: canaryResultError, message: fmt.Sprintf("listing canary workflow runs: %v", err)}
+	}
+
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return canaryResult{result: canaryResultError, message: err.Error()}
+	}
+
+	_, err = p.GitHubClient.Actions.CreateWorkflowDispatchEventByFileName(ctx, owner, repo, p.Workflow, gogithub.CreateWorkflowDispatchEventRequest{
+		Ref: p.Ref,
+		Inputs: map[string]interface{}{
+			"labels": string(labelsJSON),
+		},
+	})
+	if err != nil {
+		return canaryResult{result: canaryResultError, message: fmt.Sprintf("dispatching canary workflow: %v", err)}
+	}
+
+	var run *gogithub.WorkflowRun
+
+	for {
+		if run == nil {
+			run, err = p.findRunAfter(ctx, owner, repo, lastRunID)
+			if err != nil {
+				if ctx.Err() == context.DeadlineExceeded {
+					return p.timedOut(owner, repo, lastRunID, run)
+				}
+				return canaryResult{result: canaryResultError, message: fmt.Sprintf("listing canary workflow runs: %v", err)}
+			}
+		}
+
+		if run != nil {
+			jobs, _, err := p.GitHubClient.Actions.ListWorkflowJobs(ctx, owner, repo, run.GetID(), &gogithub.ListWorkflowJobsOptions{})
+			if err != nil {
+				if ctx.Err() == context.DeadlineExceeded {
+					return p.timedOut(owner, repo, lastRunID, run)
+				}
+				return canaryResult{result: canaryResultError, message: fmt.Sprintf("listing canary workflow jobs: %v", err)}
+			}
+
+			if len(jobs.Jobs) > 0 && jobs.Jobs[0].GetStatus() == "completed" {
+				job := jobs.Jobs[0]
+				created := run.GetCreatedAt().Time
+
+				res := canaryResult{
+					pickup:     job.GetStartedAt().Sub(created),
+					completion: job.GetCompletedAt().Sub(created),
+				}
+
+				if job.GetConclusion() == "success" {
+					res.result = canaryResultSuccess
+					res.message = fmt.Sprintf("Canary job %s succeeded", job.GetHTMLURL())
+				} else {
+					res.result = canaryResultFailure
+					res.message = fmt.Sprintf("Canary job %s completed with conclusion %q", job.GetHTMLURL(), job.GetConclusion())
+				}
+
+				return res
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return p.timedOut(owner, repo, lastRunID, run)
+			}
+			return canaryResult{result: canaryResultError, message: fmt.Sprintf("waiting for the canary workflow run: %v", ctx.Err())}
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// timedOut cancels the canary workflow run that didn't complete within the timeout, and returns the timeout result.
+// Otherwise the runs of the canaries stuck in the queue would pile up and inflate the queued workflow runs HRAs scale on.
+// run is nil when the run created by the dispatch wasn't found before the timeout, in which case it's looked up once more.
+// The API calls are made with a fresh context, as the one of the probe has already expired.
+func (p *CanaryProber) timedOut(owner, repo string, lastRunID int64, run *gogithub.WorkflowRun) canaryResult {
+	ctx, cancel := context.WithTimeout(context.Background(), canaryCancelTimeout)
+	defer cancel()
+
+	if run == nil {
+		var err error
+		run, err = p.findRunAfter(ctx, owner, repo, lastRunID)
+		if err != nil {
+			p.Log.Error(err, "Failed to find the timed out canary workflow run to cancel")
+		}
+	}
+
+	if run == nil {
+		return canaryResult{result: canaryResultTimeout, message: "Timed out waiting for the canary workflow run to be created"}
+	}
+
+	if _, err := p.GitHubClient.Actions.CancelWorkflowRunByID(ctx, owner, repo, run.GetID()); err != nil {
+		p.Log.Error(err, "Failed to cancel the timed out canary workflow run", "workflow_run", run.GetHTMLURL())
+	}
+
+	return canaryResult{result: canaryResultTimeout, message: fmt.Sprintf("Timed out waiting for the canary workflow run %s to complete", run.GetHTMLURL())}
+}
+
+func (p *CanaryProber) latestRunID(ctx context.Context, owner, repo string) (int64, error) {
+	runs, _, err := p.GitHubClient.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, p.Workflow, &gogithub.ListWorkflowRunsOptions{
+		Event:       "workflow_dispatch",
+		ListOptions: gogithub.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(runs.WorkflowRuns) == 0 {
+		return 0, nil
+	}
+
+	return runs.WorkflowRuns[0].GetID(), nil
+}
+
+// findRunAfter returns the oldest workflow_dispatch run of the canary workflow whose ID is greater than lastRunID,
+// or nil if it's not created yet.
+func (p *CanaryProber) findRunAfter(ctx context.Context, owner, repo string, lastRunID int64) (*gogithub.WorkflowRun, error) {
+	runs, _, err := p.GitHubClient.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, p.Workflow, &gogithub.ListWorkflowRunsOptions{
+		Event:       "workflow_dispatch",
+		ListOptions: gogithub.ListOptions{PerPage: 10},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var found *gogithub.WorkflowRun
+
+	for _, r := range runs.WorkflowRuns {
+		if r.GetID() > lastRunID && (found == nil || r.GetID() < found.GetID()) {
+			found = r
+		}
+	}
+
+	return found, nil
+}
+
+func (p *CanaryProber) setCondition(ctx context.Context, t canaryTarget, res canaryResult) error {
+	cond := metav1.Condition{
+		Type:    CanaryConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  strings.ToUpper(res.result[:1]) + res.result[1:],
+		Message: res.message,
+	}
+
+	if res.result == canaryResultSuccess {
+		cond.Status = metav1.ConditionTrue
+	}
+
+	switch t.kind {
+	case "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := p.Get(ctx, t.key, &rd); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		updated := rd.DeepCopy()
+		cond.ObservedGeneration = rd.Generation
+		meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+		return p.Status().Patch(ctx, updated, client.MergeFrom(&rd))
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := p.Get(ctx, t.key, &rs); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		updated := rs.DeepCopy()
+		cond.ObservedGeneration = rs.Generation
+		meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+		return p.Status().Patch(ctx, updated, client.MergeFrom(&rs))
+	}
+
+	return fmt.Errorf("unsupported kind of runner pool: %s", t.kind)
+}
+
+func splitOwnerAndRepo(repo string) (string, string, error) {
+	chunk := strings.Split(repo, "/")
+	if len(chunk) != 2 || chunk[0] == "" || chunk[1] == "" {
+		return "", "", fmt.Errorf("invalid repository %q: it must be in the OWNER/REPO format", repo)
+	}
+
+	return chunk[0], chunk[1], nil
+}
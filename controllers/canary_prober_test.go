@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCanaryProberNewTarget(t *testing.T) {
+	p := &CanaryProber{
+		Repository:         "myorg/canary",
+		CommonRunnerLabels: []string{"common"},
+	}
+
+	testcases := []struct {
+		name        string
+		annotations map[string]string
+		rc          v1alpha1.RunnerConfig
+		want        []string
+	}{
+		{
+			name: "repository runner for the canary repository",
+			rc:   v1alpha1.RunnerConfig{Repository: "myorg/canary", Labels: []string{"linux"}},
+			want: []string{"self-hosted", "common", "linux"},
+		},
+		{
+			name: "repository runner for another repository",
+			rc:   v1alpha1.RunnerConfig{Repository: "myorg/other"},
+		},
+		{
+			name: "organizational runner for the canary organization",
+			rc:   v1alpha1.RunnerConfig{Organization: "myorg", Labels: []string{"gpu"}},
+			want: []string{"self-hosted", "common", "gpu"},
+		},
+		{
+			name: "organizational runner for another organization",
+			rc:   v1alpha1.RunnerConfig{Organization: "otherorg"},
+		},
+		{
+			name: "enterprise runner",
+			rc:   v1alpha1.RunnerConfig{Enterprise: "myent"},
+			want: []string{"self-hosted", "common"},
+		},
+		{
+			name:        "disabled",
+			annotations: map[string]string{AnnotationKeyCanaryDisabled: "true"},
+			rc:          v1alpha1.RunnerConfig{Organization: "myorg"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := metav1.ObjectMeta{Namespace: "default", Name: "pool", Annotations: tc.annotations}
+
+			target := p.newTarget("RunnerDeployment", m, tc.rc)
+
+			if tc.want == nil {
+				if target != nil {
+					t.Fatalf("unexpected target: %+v", target)
+				}
+				return
+			}
+
+			if target == nil {
+				t.Fatal("expected a target, but got nil")
+			}
+
+			if !reflect.DeepEqual(target.labels, tc.want) {
+				t.Errorf("unexpected labels: want %v, got %v", tc.want, target.labels)
+			}
+		})
+	}
+}
+
+func TestCanaryProberProbe(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		dispatched bool
+		inputs     map[string]interface{}
+	)
+
+	created := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/myorg/canary/actions/workflows/canary.yml/runs", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !dispatched {
+			fmt.Fprintf(w, `{"total_count": 1, "workflow_runs": [{"id": 1, "created_at": "2022-02-01T10:00:00Z"}]}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"total_count": 2, "workflow_runs": [{"id": 2, "created_at": %q}, {"id": 1, "created_at": "2022-02-01T10:00:00Z"}]}`, created.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/repos/myorg/canary/actions/workflows/canary.yml/dispatches", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			Ref    string                 `json:"ref"`
+			Inputs map[string]interface{} `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dispatched = true
+		inputs = body.Inputs
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/repos/myorg/canary/actions/runs/2/jobs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"total_count": 1, "jobs": [{"id": 10, "status": "completed", "conclusion": "success", "started_at": %q, "completed_at": %q}]}`,
+			created.Add(30*time.Second).Format(time.RFC3339),
+			created.Add(45*time.Second).Format(time.RFC3339),
+		)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := &CanaryProber{
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+		Repository:   "myorg/canary",
+		Workflow:     "canary.yml",
+		Ref:          "main",
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	res := p.probe(context.Background(), []string{"self-hosted", "linux"})
+
+	if res.result != canaryResultSuccess {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	if res.pickup != 30*time.Second {
+		t.Errorf("unexpected pickup duration: %v", res.pickup)
+	}
+
+	if res.completion != 45*time.Second {
+		t.Errorf("unexpected completion duration: %v", res.completion)
+	}
+
+	if got := inputs["labels"]; got != `["self-hosted","linux"]` {
+		t.Errorf("unexpected labels input: %v", got)
+	}
+}
+
+func TestCanaryProberProbe_Timeout(t *testing.T) {
+	testcases := []struct {
+		name string
+		// slowJobs makes listing the jobs outlast the timeout of the probe
+		slowJobs bool
+	}{
+		{name: "run stays queued"},
+		{name: "deadline exceeded while listing jobs", slowJobs: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu         sync.Mutex
+				dispatched bool
+				cancelled  []string
+			)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/myorg/canary/actions/workflows/canary.yml/runs", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if !dispatched {
+					fmt.Fprint(w, `{"total_count": 0, "workflow_runs": []}`)
+					return
+				}
+
+				fmt.Fprint(w, `{"total_count": 1, "workflow_runs": [{"id": 2, "created_at": "2022-03-01T10:00:00Z"}]}`)
+			})
+			mux.HandleFunc("/repos/myorg/canary/actions/workflows/canary.yml/dispatches", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				dispatched = true
+
+				w.WriteHeader(http.StatusNoContent)
+			})
+			mux.HandleFunc("/repos/myorg/canary/actions/runs/2/jobs", func(w http.ResponseWriter, r *http.Request) {
+				if tc.slowJobs {
+					select {
+					case <-r.Context().Done():
+					case <-time.After(time.Second):
+					}
+				}
+
+				fmt.Fprint(w, `{"total_count": 1, "jobs": [{"id": 10, "status": "queued"}]}`)
+			})
+			mux.HandleFunc("/repos/myorg/canary/actions/runs/2/cancel", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				cancelled = append(cancelled, r.Method)
+
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			p := &CanaryProber{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+				Repository:   "myorg/canary",
+				Workflow:     "canary.yml",
+				Ref:          "main",
+				Timeout:      200 * time.Millisecond,
+				PollInterval: 10 * time.Millisecond,
+			}
+
+			res := p.probe(context.Background(), []string{"self-hosted", "linux"})
+
+			if res.result != canaryResultTimeout {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(cancelled) != 1 || cancelled[0] != http.MethodPost {
+				t.Errorf("the timed out canary workflow run must be cancelled once, got %v", cancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncRunnerPodsOwners_Drain(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	newRunner := func(name string, offset time.Duration) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created.Add(offset)),
+				Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+			},
+			Status: v1alpha1.RunnerStatus{Phase: "Running"},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		replicas    int
+		wantCreated int
+		wantDeleted []string
+	}{
+		{
+			description: "missing replicas are not created",
+			replicas:    5,
+		},
+		{
+			description: "redundant replicas are still deleted",
+			replicas:    1,
+			wantDeleted: []string{"runner-1", "runner-2"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			names := []string{"runner-1", "runner-2", "runner-3"}
+
+			for i, name := range names {
+				r := newRunner(name, time.Duration(i)*time.Minute)
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				}
+				objs = append(objs, r, pod)
+				owners = append(owners, r)
+			}
+
+			c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := newRunner("desired", 0)
+
+			var created int
+
+			create := func() client.Object {
+				created++
+				return desired.DeepCopy()
+			}
+
+			if _, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, tc.replicas, desired, create, false, true, "", 0, nil, nil, owners); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if created != 0 {
+				t.Errorf("unexpected number of created replicas: want 0, got %d", created)
+			}
+
+			wantDeleted := map[string]bool{}
+			for _, name := range tc.wantDeleted {
+				wantDeleted[name] = true
+			}
+
+			for _, name := range names {
+				var runner v1alpha1.Runner
+				if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted[name] {
+					t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted[name], deleted)
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerReconciler_Drain(t *testing.T) {
+	ctx := context.Background()
+
+	testcases := []struct {
+		description string
+		provisioner string
+	}{
+		{
+			description: "runner pod",
+		},
+		{
+			description: "runner instance",
+			provisioner: "mac",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			runner := &v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "example",
+					Namespace:  "default",
+					Finalizers: []string{finalizerName},
+				},
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+					Provisioner:  tc.provisioner,
+				},
+			}
+
+			p := &fakeProvisioner{phase: provisioner.PhaseRunning}
+
+			c := clientfake.NewFakeClientWithScheme(sc, runner)
+
+			r := &RunnerReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     record.NewFakeRecorder(10),
+				Provisioners: map[string]provisioner.Provisioner{"mac": p},
+				DrainMode:    true,
+			}
+
+			key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var pods corev1.PodList
+			if err := c.List(ctx, &pods); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(pods.Items) != 0 {
+				t.Errorf("unexpected runner pods created in drain mode: %d", len(pods.Items))
+			}
+
+			if len(p.actions) != 0 {
+				t.Errorf("unexpected provisioner actions in drain mode: %v", p.actions)
+			}
+
+			var got v1alpha1.Runner
+			if err := c.Get(ctx, key, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Status.Registration.Token != "" {
+				t.Errorf("unexpected registration token obtained in drain mode")
+			}
+		})
+	}
+}
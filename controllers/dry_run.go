@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// dryRunClient is the client of the controllers in the dry-run mode.
+// It logs the mutations of the objects instead of persisting them.
+// The mutations are still sent to the API server as dry-run requests, so that they're validated, defaulted
+// and authorized as usual, and the controllers see the objects they would've created or updated.
+type dryRunClient struct {
+	client.Client
+
+	log logr.Logger
+}
+
+var _ client.Client = &dryRunClient{}
+
+// NewDryRunClient returns the client that logs the mutations made via c instead of persisting them.
+// The objects are read from c as usual.
+func NewDryRunClient(c client.Client, log logr.Logger) client.Client {
+	return &dryRunClient{Client: client.NewDryRunClient(c), log: log}
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.logMutation("create object", obj, nil)
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.logMutation("update object", obj, nil)
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.logMutation("patch object", obj, patch)
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.logMutation("delete object", obj, nil)
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.logMutation("delete all objects", obj, nil)
+
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) Status() client.StatusWriter {
+	return &dryRunStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+// logMutation logs the mutation of the object that isn't persisted, along with the patch if any,
+// which tells e.g. the replicas a scale target would've been scaled to.
+// The updates of the statuses are logged at the debug level, as they're made on almost every reconciliation.
+func (c *dryRunClient) logMutation(verb string, obj client.Object, patch client.Patch) {
+	c.logMutationAt(0, verb, obj, patch)
+}
+
+func (c *dryRunClient) logMutationAt(level int, verb string, obj client.Object, patch client.Patch) {
+	log := c.log.V(level)
+	if !log.Enabled() {
+		return
+	}
+
+	kind := fmt.Sprintf("%T", obj)
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+
+	keysAndValues := []interface{}{"kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName()}
+
+	if patch != nil {
+		if data, err := patch.Data(obj); err == nil {
+			keysAndValues = append(keysAndValues, "patch", string(data))
+		}
+	}
+
+	log.Info(fmt.Sprintf("Dry run: would %s", verb), keysAndValues...)
+}
+
+type dryRunStatusWriter struct {
+	client.StatusWriter
+
+	c *dryRunClient
+}
+
+func (w *dryRunStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.c.logMutationAt(1, "update status of object", obj, nil)
+
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *dryRunStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.c.logMutationAt(1, "patch status of object", obj, patch)
+
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// dryRunProvisioner is the runner provisioner in the dry-run mode, which logs the instances it would've provisioned
+// and deprovisioned instead of calling the provisioner. The statuses of the instances are still fetched from the provisioner.
+type dryRunProvisioner struct {
+	provisioner.Provisioner
+
+	name string
+	log  logr.Logger
+}
+
+// NewDryRunProvisioner returns the runner provisioner that never provisions nor deprovisions instances via p.
+func NewDryRunProvisioner(name string, p provisioner.Provisioner, log logr.Logger) provisioner.Provisioner {
+	return &dryRunProvisioner{Provisioner: p, name: name, log: log}
+}
+
+func (p *dryRunProvisioner) Provision(ctx context.Context, runner provisioner.Runner) (*provisioner.Instance, error) {
+	p.log.Info("Dry run: would provision runner instance", "provisioner", p.name, "namespace", runner.Namespace, "name", runner.Name)
+
+	return &provisioner.Instance{Phase: provisioner.PhasePending, Message: "Not provisioned in the dry-run mode"}, nil
+}
+
+func (p *dryRunProvisioner) Deprovision(ctx context.Context, runner provisioner.Runner, instanceID string) (*provisioner.Instance, error) {
+	p.log.Info("Dry run: would deprovision runner instance", "provisioner", p.name, "namespace", runner.Namespace, "name", runner.Name, "instanceID", instanceID)
+
+	return &provisioner.Instance{ID: instanceID, Phase: provisioner.PhaseDeleted}, nil
+}
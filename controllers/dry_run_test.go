@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDryRunClient(t *testing.T) {
+	ctx := context.Background()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec:       v1alpha1.RunnerDeploymentSpec{Replicas: intPtr(1)},
+	}
+
+	base := fake.NewClientBuilder().WithScheme(sc).WithObjects(rd.DeepCopy()).Build()
+
+	c := NewDryRunClient(base, logr.Discard())
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-runner"}}
+	if err := c.Create(ctx, pod); err != nil {
+		t.Fatalf("unexpected error creating pod: %v", err)
+	}
+
+	if err := base.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-runner"}, &corev1.Pod{}); !kerrors.IsNotFound(err) {
+		t.Errorf("pod must not be created in the dry-run mode: %v", err)
+	}
+
+	var current v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, client.ObjectKeyFromObject(rd), &current); err != nil {
+		t.Fatalf("unexpected error getting runner deployment: %v", err)
+	}
+
+	scaled := current.DeepCopy()
+	scaled.Spec.Replicas = intPtr(3)
+	if err := c.Patch(ctx, scaled, client.MergeFrom(&current)); err != nil {
+		t.Fatalf("unexpected error patching runner deployment: %v", err)
+	}
+
+	var got v1alpha1.RunnerDeployment
+	if err := base.Get(ctx, client.ObjectKeyFromObject(rd), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Spec.Replicas == nil || *got.Spec.Replicas != 1 {
+		t.Errorf("runner deployment must not be scaled in the dry-run mode: got %v replicas", got.Spec.Replicas)
+	}
+}
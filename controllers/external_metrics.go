@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ExternalMetricQueuedJobs is the number of the queued workflow jobs that can run on the runner deployment.
+	ExternalMetricQueuedJobs = "github_queued_jobs"
+
+	// ExternalMetricBusyRunnersPercentage is the percentage of the runners of the runner deployment that are busy running jobs.
+	ExternalMetricBusyRunnersPercentage = "github_busy_runners_percentage"
+
+	// ExternalMetricLabelRunnerDeployment is the label every external metric value has,
+	// so that HorizontalPodAutoscalers can select the values of their own runner deployments.
+	ExternalMetricLabelRunnerDeployment = "runnerdeployment"
+
+	// AnnotationKeyExternalMetricsRepositoryNames is the annotation on an organizational or enterprise runner deployment
+	// that lists the repositories whose queued jobs are counted for the github_queued_jobs metric, separated by commas.
+	// It plays the same role as spec.metrics[].repositoryNames of HorizontalRunnerAutoscaler.
+	AnnotationKeyExternalMetricsRepositoryNames = annotationKeyPrefix + "external-metrics-repository-names"
+
+	externalMetricsGroupVersion = "external.metrics.k8s.io/v1beta1"
+	externalMetricsPathPrefix   = "/apis/external.metrics.k8s.io/"
+)
+
+// ExternalMetricsAdapter serves the External Metrics API for the runner deployments,
+// so that the standard HorizontalPodAutoscaler, or KEDA, can scale runner deployments via their scale subresource
+// without HorizontalRunnerAutoscaler.
+//
+// It is registered to the APIService of external.metrics.k8s.io/v1beta1 and served by the webhook server,
+// sharing its serving certificate.
+// The values are computed on each request with the same GitHub API calls and the same cache as HorizontalRunnerAutoscaler.
+type ExternalMetricsAdapter struct {
+	client.Client
+	Log logr.Logger
+
+	// Autoscaler counts the queued jobs and the busy runners of the runner deployments.
+	Autoscaler *HorizontalRunnerAutoscalerReconciler
+
+	now func() time.Time
+}
+
+// externalMetricValue is the external.metrics.k8s.io/v1beta1 ExternalMetricValue.
+// It is defined here to avoid depending on k8s.io/metrics just for the types.
+type externalMetricValue struct {
+	MetricName   string            `json:"metricName"`
+	MetricLabels map[string]string `json:"metricLabels"`
+	Timestamp    metav1.Time       `json:"timestamp"`
+	Value        resource.Quantity `json:"value"`
+}
+
+// externalMetricValueList is the external.metrics.k8s.io/v1beta1 ExternalMetricValueList.
+type externalMetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []externalMetricValue `json:"items"`
+}
+
+func (a *ExternalMetricsAdapter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, externalMetricsPathPrefix), "/")
+	segments := strings.Split(path, "/")
+
+	switch {
+	case path == "":
+		a.writeJSON(w, http.StatusOK, externalMetricsAPIGroup())
+	case path == "v1beta1":
+		a.writeJSON(w, http.StatusOK, externalMetricsAPIResourceList())
+	case len(segments) == 4 && segments[0] == "v1beta1" && segments[1] == "namespaces":
+		a.serveMetric(w, req, segments[2], segments[3])
+	default:
+		a.writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("the server could not find the requested resource %s", req.URL.Path))
+	}
+}
+
+func (a *ExternalMetricsAdapter) serveMetric(w http.ResponseWriter, req *http.Request, namespace, metricName string) {
+	if metricName != ExternalMetricQueuedJobs && metricName != ExternalMetricBusyRunnersPercentage {
+		a.writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("external metric %q is not found", metricName))
+		return
+	}
+
+	selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+	if err != nil {
+		a.writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid labelSelector: %v", err))
+		return
+	}
+
+	ctx := req.Context()
+
+	var rdList v1alpha1.RunnerDeploymentList
+	if err := a.List(ctx, &rdList, client.InNamespace(namespace)); err != nil {
+		a.Log.Error(err, "Failed to list runnerdeployments", "namespace", namespace)
+		a.writeStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+
+	list := externalMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: externalMetricsGroupVersion},
+		Items:    []externalMetricValue{},
+	}
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+
+	for i := range rdList.Items {
+		rd := rdList.Items[i]
+
+		metricLabels := externalMetricLabels(rd)
+		if !selector.Matches(labels.Set(metricLabels)) {
+			continue
+		}
+
+		var value *resource.Quantity
+
+		switch metricName {
+		case ExternalMetricQueuedJobs:
+			value, err = a.queuedJobs(ctx, rd)
+		case ExternalMetricBusyRunnersPercentage:
+			value, err = a.busyRunnersPercentage(ctx, rd)
+		}
+
+		if err != nil {
+			a.Log.Error(err, "Failed to compute external metric", "metric", metricName, "namespace", rd.Namespace, "runnerdeployment", rd.Name)
+			a.writeStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, fmt.Sprintf("computing %s for runnerdeployment %s/%s: %v", metricName, rd.Namespace, rd.Name, err))
+			return
+		}
+
+		list.Items = append(list.Items, externalMetricValue{
+			MetricName:   metricName,
+			MetricLabels: metricLabels,
+			Timestamp:    metav1.NewTime(now()),
+			Value:        *value,
+		})
+	}
+
+	a.writeJSON(w, http.StatusOK, list)
+}
+
+// queuedJobs returns the number of the queued workflow jobs that can run on the runner deployment.
+func (a *ExternalMetricsAdapter) queuedJobs(ctx context.Context, rd v1alpha1.RunnerDeployment) (*resource.Quantity, error) {
+	st, err := a.scaleTarget(ctx, rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var metric *v1alpha1.MetricSpec
+
+	if st.repo == "" {
+		names := rd.Annotations[AnnotationKeyExternalMetricsRepositoryNames]
+		if names == "" {
+			return nil, fmt.Errorf("the %s annotation is required to count the queued jobs of organizational and enterprise runners", AnnotationKeyExternalMetricsRepositoryNames)
+		}
+
+		metric = &v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns}
+
+		for _, n := range strings.Split(names, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				metric.RepositoryNames = append(metric.RepositoryNames, n)
+			}
+		}
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{ObjectMeta: metav1.ObjectMeta{Namespace: rd.Namespace}}
+
+	counts, err := a.Autoscaler.countWorkflowJobs(st, hra, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	var queued int
+	if counts != nil {
+		queued = counts.queued
+	}
+
+	return resource.NewQuantity(int64(queued), resource.DecimalSI), nil
+}
+
+// busyRunnersPercentage returns the percentage of the runners of the runner deployment that are busy,
+// counting the completed runners as busy the same as the PercentageRunnersBusy metric of HorizontalRunnerAutoscaler.
+func (a *ExternalMetricsAdapter) busyRunnersPercentage(ctx context.Context, rd v1alpha1.RunnerDeployment) (*resource.Quantity, error) {
+	st, err := a.scaleTarget(ctx, rd)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := a.Autoscaler.countRunners(st.githubContext(), st)
+	if err != nil {
+		return nil, err
+	}
+
+	if counts.runners == 0 {
+		return resource.NewQuantity(0, resource.DecimalSI), nil
+	}
+
+	milliPercent := int64(counts.busy+counts.completed) * 100 * 1000 / int64(counts.runners)
+
+	return resource.NewMilliQuantity(milliPercent, resource.DecimalSI), nil
+}
+
+// scaleTarget returns the scale target for the runner deployment, along with the GitHub client for its credentials.
+func (a *ExternalMetricsAdapter) scaleTarget(ctx context.Context, rd v1alpha1.RunnerDeployment) (scaleTarget, error) {
+	st := a.Autoscaler.scaleTargetFromRD(ctx, rd)
+
+	ghc, err := a.Autoscaler.GitHubClients.ClientFor(ctx, a.Autoscaler.GitHubClient, rd.Namespace, st.githubAPICredentialsFrom)
+	if err != nil {
+		return st, err
+	}
+
+	st.githubClient = ghc
+
+	return st, nil
+}
+
+// externalMetricLabels returns the labels of the metric values of the runner deployment,
+// which are the labels of the runner deployment plus the runnerdeployment label for its name.
+func externalMetricLabels(rd v1alpha1.RunnerDeployment) map[string]string {
+	l := map[string]string{}
+
+	for k, v := range rd.Labels {
+		l[k] = v
+	}
+
+	l[ExternalMetricLabelRunnerDeployment] = rd.Name
+
+	return l
+}
+
+func externalMetricsAPIGroup() metav1.APIGroup {
+	version := metav1.GroupVersionForDiscovery{GroupVersion: externalMetricsGroupVersion, Version: "v1beta1"}
+
+	return metav1.APIGroup{
+		TypeMeta:         metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"},
+		Name:             "external.metrics.k8s.io",
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	}
+}
+
+func externalMetricsAPIResourceList() metav1.APIResourceList {
+	list := metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: externalMetricsGroupVersion,
+	}
+
+	for _, name := range []string{ExternalMetricQueuedJobs, ExternalMetricBusyRunnersPercentage} {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      metav1.Verbs{"get"},
+		})
+	}
+
+	return list
+}
+
+func (a *ExternalMetricsAdapter) writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, message string) {
+	a.writeJSON(w, code, metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
+
+func (a *ExternalMetricsAdapter) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		a.Log.Error(err, "Failed to write response")
+	}
+}
+
+// SetupWithManager registers the adapter to the webhook server of the manager.
+func (a *ExternalMetricsAdapter) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(externalMetricsPathPrefix, a)
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExternalMetricsAdapter(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	workflowRuns := `{"total_count": 2, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+	runners := `{"total_count": 2, "runners": [{"id": 1, "name": "example-1", "os": "linux", "status": "online", "busy": true}, {"id": 2, "name": "example-2", "os": "linux", "status": "online", "busy": false}]}`
+
+	newRunnerDeployment := func(name string, labels map[string]string, config v1alpha1.RunnerConfig) *v1alpha1.RunnerDeployment {
+		return &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{RunnerConfig: config},
+				},
+			},
+		}
+	}
+
+	newRunner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{LabelKeyRunnerDeploymentName: "example"}},
+		}
+	}
+
+	objects := []client.Object{
+		newRunnerDeployment("example", map[string]string{"team": "a"}, v1alpha1.RunnerConfig{Repository: "test/valid"}),
+		newRunnerDeployment("org", map[string]string{"team": "b"}, v1alpha1.RunnerConfig{Organization: "test"}),
+		newRunner("example-1"),
+		newRunner("example-2"),
+	}
+
+	testcases := []struct {
+		description string
+		path        string
+		selector    string
+
+		wantCode   int
+		wantValues map[string]string
+	}{
+		{
+			description: "queued jobs of the runnerdeployment",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_queued_jobs",
+			selector:    "runnerdeployment=example",
+			wantCode:    http.StatusOK,
+			wantValues:  map[string]string{"example": "3"},
+		},
+		{
+			description: "busy runners percentage of the runnerdeployment",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_busy_runners_percentage",
+			selector:    "team=a",
+			wantCode:    http.StatusOK,
+			wantValues:  map[string]string{"example": "50"},
+		},
+		{
+			description: "no runnerdeployment matches the selector",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_queued_jobs",
+			selector:    "team=c",
+			wantCode:    http.StatusOK,
+			wantValues:  map[string]string{},
+		},
+		{
+			description: "organizational runnerdeployment without the repository names annotation",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_queued_jobs",
+			selector:    "runnerdeployment=org",
+			wantCode:    http.StatusInternalServerError,
+		},
+		{
+			description: "unknown metric",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_unknown",
+			wantCode:    http.StatusNotFound,
+		},
+		{
+			description: "invalid selector",
+			path:        "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/github_queued_jobs",
+			selector:    "team in",
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, runners),
+			)
+			defer server.Close()
+
+			c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(objects...).Build()
+
+			a := &ExternalMetricsAdapter{
+				Client: c,
+				Log:    logr.Discard(),
+				Autoscaler: &HorizontalRunnerAutoscalerReconciler{
+					Client:       c,
+					Log:          logr.Discard(),
+					GitHubClient: newGithubClient(server),
+				},
+				now: func() time.Time { return now },
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.path+"?labelSelector="+url.QueryEscape(tc.selector), nil)
+			rec := httptest.NewRecorder()
+
+			a.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("unexpected status code: want %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+
+			if tc.wantValues == nil {
+				return
+			}
+
+			var list externalMetricValueList
+			if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if list.Kind != "ExternalMetricValueList" || list.APIVersion != "external.metrics.k8s.io/v1beta1" {
+				t.Errorf("unexpected type: %+v", list.TypeMeta)
+			}
+
+			got := map[string]string{}
+			for _, item := range list.Items {
+				got[item.MetricLabels[ExternalMetricLabelRunnerDeployment]] = item.Value.String()
+
+				if !item.Timestamp.Time.Equal(now) {
+					t.Errorf("unexpected timestamp: want %v, got %v", now, item.Timestamp)
+				}
+			}
+
+			if len(got) != len(tc.wantValues) {
+				t.Fatalf("unexpected values: want %v, got %v", tc.wantValues, got)
+			}
+
+			for name, want := range tc.wantValues {
+				if got[name] != want {
+					t.Errorf("unexpected value for %s: want %s, got %s", name, want, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestExternalMetricsAdapter_Discovery(t *testing.T) {
+	a := &ExternalMetricsAdapter{Log: logr.Discard()}
+
+	req := httptest.NewRequest(http.MethodGet, "/apis/external.metrics.k8s.io/v1beta1", nil)
+	rec := httptest.NewRecorder()
+
+	a.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: want %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var list metav1.APIResourceList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, r := range list.APIResources {
+		names = append(names, r.Name)
+	}
+
+	if len(names) != 2 || names[0] != ExternalMetricQueuedJobs || names[1] != ExternalMetricBusyRunnersPercentage {
+		t.Errorf("unexpected metrics: %v", names)
+	}
+}
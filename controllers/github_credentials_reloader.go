@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/credentialsprovider"
+	"github.com/go-logr/logr"
+)
+
+const DefaultGitHubCredentialsReloadInterval = 5 * time.Minute
+
+// GitHubCredentialsReloader periodically re-reads the GitHub API credentials of the controller from the credentials provider,
+// and replaces the credentials of the GitHub client when they changed, so that the credentials rotated in an external secret store
+// are picked up without restarting the controller.
+// The current credentials are kept when the provider fails, until it succeeds again.
+type GitHubCredentialsReloader struct {
+	Log          logr.Logger
+	GitHubClient *github.Client
+	Provider     credentialsprovider.Provider
+
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Every controller process has its own GitHub client, so every process reloads its own credentials.
+func (r *GitHubCredentialsReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (r *GitHubCredentialsReloader) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultGitHubCredentialsReloadInterval
+	}
+
+	r.Log.Info("Starting GitHub credentials reload", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := r.reload(ctx); err != nil {
+			r.Log.Error(err, "Failed to reload GitHub credentials. Keeping the current ones")
+		}
+	}
+}
+
+func (r *GitHubCredentialsReloader) reload(ctx context.Context) error {
+	a, err := r.Provider.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	reloaded, err := r.GitHubClient.ReloadAuth(*a)
+	if err != nil {
+		return err
+	}
+
+	if reloaded {
+		r.Log.Info("Reloaded GitHub credentials")
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+)
+
+type credentialsProviderFunc func(ctx context.Context) (*github.AuthConfig, error)
+
+func (f credentialsProviderFunc) Credentials(ctx context.Context) (*github.AuthConfig, error) {
+	return f(ctx)
+}
+
+func TestGitHubCredentialsReloader(t *testing.T) {
+	c := github.Config{Token: "old"}
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var next *github.AuthConfig
+
+	r := &GitHubCredentialsReloader{
+		Log:          logr.Discard(),
+		GitHubClient: client,
+		Provider: credentialsProviderFunc(func(ctx context.Context) (*github.AuthConfig, error) {
+			if next == nil {
+				return nil, fmt.Errorf("secret store unavailable")
+			}
+
+			return next, nil
+		}),
+	}
+
+	if err := r.reload(context.Background()); err == nil {
+		t.Errorf("expected the provider error")
+	}
+
+	next = &github.AuthConfig{Token: "new"}
+
+	if err := r.reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reloaded, err := client.ReloadAuth(github.AuthConfig{Token: "new"}); err != nil || reloaded {
+		t.Errorf("the credentials weren't reloaded by the reloader: reloaded=%v, err=%v", reloaded, err)
+	}
+}
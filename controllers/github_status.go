@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// DefaultGitHubStatusURL is the components API of the GitHub status page.
+	DefaultGitHubStatusURL = "https://www.githubstatus.com/api/v2/components.json"
+
+	// DefaultGitHubStatusComponent is the component of the GitHub status page whose incidents freeze scale down.
+	DefaultGitHubStatusComponent = "Actions"
+
+	DefaultGitHubStatusInterval = 1 * time.Minute
+
+	defaultGitHubStatusTimeout = 10 * time.Second
+
+	githubStatusOperational = "operational"
+)
+
+// GitHubStatusPoller periodically polls the GitHub status page, or any health URL, and tells the HRAs
+// whether GitHub Actions is degraded, so that they don't scale down on the transient errors and
+// the missing workflow jobs observed during incidents.
+//
+// The URL is expected to serve the components API of a Statuspage like https://www.githubstatus.com/api/v2/components.json,
+// in which case GitHub is degraded while Component isn't operational.
+// Any other URL is a health check, which reports GitHub degraded while it responds with a non-2xx status.
+// A failure to reach the URL keeps the last status, so that an unreachable status page doesn't freeze scale down.
+type GitHubStatusPoller struct {
+	Log        logr.Logger
+	HTTPClient *http.Client
+
+	URL       string
+	Component string
+	Interval  time.Duration
+
+	mu     sync.RWMutex
+	status githubStatus
+}
+
+// githubStatus is the last status of GitHub observed by the poller.
+type githubStatus struct {
+	degraded bool
+	message  string
+	since    time.Time
+}
+
+type statuspageComponents struct {
+	Components []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"components"`
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader, which runs the HRA controller, polls the status page.
+func (p *GitHubStatusPoller) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (p *GitHubStatusPoller) Start(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultGitHubStatusInterval
+	}
+
+	p.Log.Info("Starting GitHub status poller", "url", p.url(), "component", p.component(), "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *GitHubStatusPoller) poll(ctx context.Context, now time.Time) {
+	degraded, message, err := p.check(ctx)
+	if err != nil {
+		p.Log.Error(err, "Failed to poll GitHub status. The last status is kept", "url", p.url())
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if degraded != p.status.degraded {
+		if degraded {
+			p.Log.Info("GitHub is degraded. Freezing scale down", "message", message)
+		} else {
+			p.Log.Info("GitHub has recovered. Resuming scale down", "message", message)
+		}
+
+		p.status.since = now
+	}
+
+	p.status.degraded = degraded
+	p.status.message = message
+}
+
+// check returns whether GitHub is degraded along with the message describing the status.
+func (p *GitHubStatusPoller) check(ctx context.Context) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultGitHubStatusTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(), nil)
+	if err != nil {
+		return false, "", err
+	}
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return true, fmt.Sprintf("GET %s responded with status %d", p.url(), res.StatusCode), nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, "", err
+	}
+
+	var page statuspageComponents
+	if err := json.Unmarshal(body, &page); err != nil || page.Components == nil {
+		// Not a status page, but a health check that responded successfully.
+		return false, fmt.Sprintf("GET %s responded with status %d", p.url(), res.StatusCode), nil
+	}
+
+	for _, c := range page.Components {
+		if c.Name != p.component() {
+			continue
+		}
+
+		return c.Status != githubStatusOperational, fmt.Sprintf("%s is %s", c.Name, c.Status), nil
+	}
+
+	return false, "", fmt.Errorf("component %q is not found in the status page", p.component())
+}
+
+// getStatus returns the last status of GitHub. ok is false when the poller isn't configured.
+func (p *GitHubStatusPoller) getStatus() (status githubStatus, ok bool) {
+	if p == nil {
+		return githubStatus{}, false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.status, true
+}
+
+func (p *GitHubStatusPoller) url() string {
+	if p.URL == "" {
+		return DefaultGitHubStatusURL
+	}
+	return p.URL
+}
+
+func (p *GitHubStatusPoller) component() string {
+	if p.Component == "" {
+		return DefaultGitHubStatusComponent
+	}
+	return p.Component
+}
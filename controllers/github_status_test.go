@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGitHubStatusPoller(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		description  string
+		status       int
+		body         string
+		wantDegraded bool
+		wantMessage  string
+		wantKept     bool
+	}{
+		{
+			description: "operational",
+			status:      200,
+			body:        `{"components": [{"name": "Git Operations", "status": "major_outage"}, {"name": "Actions", "status": "operational"}]}`,
+			wantMessage: "Actions is operational",
+		},
+		{
+			description:  "incident",
+			status:       200,
+			body:         `{"components": [{"name": "Actions", "status": "partial_outage"}]}`,
+			wantDegraded: true,
+			wantMessage:  "Actions is partial_outage",
+		},
+		{
+			description:  "unhealthy health check",
+			status:       503,
+			wantDegraded: true,
+			wantMessage:  "responded with status 503",
+		},
+		{
+			description: "healthy health check",
+			status:      200,
+			body:        `ok`,
+			wantMessage: "responded with status 200",
+		},
+		{
+			description: "component not found",
+			status:      200,
+			body:        `{"components": []}`,
+			wantKept:    true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			p := &GitHubStatusPoller{Log: logr.Discard(), URL: server.URL}
+
+			// The last status is kept when the status can't be read
+			p.status = githubStatus{degraded: true, message: "previous", since: now.Add(-time.Hour)}
+
+			p.poll(ctx, now)
+
+			got, ok := p.getStatus()
+			if !ok {
+				t.Fatal("expected the status of the configured poller")
+			}
+
+			if tc.wantKept {
+				if !got.degraded || got.message != "previous" {
+					t.Errorf("unexpected status: want the last status kept, got %+v", got)
+				}
+				return
+			}
+
+			if got.degraded != tc.wantDegraded {
+				t.Errorf("unexpected degraded: want %v, got %+v", tc.wantDegraded, got)
+			}
+
+			if !strings.Contains(got.message, tc.wantMessage) {
+				t.Errorf("unexpected message: want %q, got %q", tc.wantMessage, got.message)
+			}
+
+			wantSince := now
+			if tc.wantDegraded {
+				wantSince = now.Add(-time.Hour)
+			}
+
+			if !got.since.Equal(wantSince) {
+				t.Errorf("unexpected since: want %s, got %s", wantSince, got.since)
+			}
+		})
+	}
+
+	var nilPoller *GitHubStatusPoller
+	if _, ok := nilPoller.getStatus(); ok {
+		t.Errorf("expected no status without the poller")
+	}
+}
+
+func TestHorizontalRunnerAutoscalerReconciler_GitHubDegraded(t *testing.T) {
+	ctx := context.Background()
+
+	testcases := []struct {
+		description   string
+		degraded      bool
+		wantReplicas  int
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			description:   "operational",
+			wantReplicas:  1,
+			wantCondition: metav1.ConditionFalse,
+		},
+		{
+			description:   "degraded",
+			degraded:      true,
+			wantReplicas:  3,
+			wantCondition: metav1.ConditionTrue,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			noRuns := `{"total_count": 0, "workflow_runs": []}`
+
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, noRuns, noRuns, noRuns),
+				fake.WithListWorkflowJobsResponse(200, nil),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			)
+			defer server.Close()
+
+			rd := &v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Replicas: intPtr(3),
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{
+							RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+						},
+					},
+				},
+			}
+
+			hra := &v1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+					MinReplicas:    intPtr(1),
+					MaxReplicas:    intPtr(10),
+					Metrics: []v1alpha1.MetricSpec{
+						{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+					},
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(3)},
+			}
+
+			c := clientfake.NewFakeClientWithScheme(sc, rd, hra)
+
+			poller := &GitHubStatusPoller{}
+			poller.status = githubStatus{degraded: tc.degraded, message: "Actions is major_outage"}
+
+			r := &HorizontalRunnerAutoscalerReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     record.NewFakeRecorder(10),
+				GitHubClient: newGithubClient(server),
+				GitHubStatus: poller,
+			}
+
+			key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated v1alpha1.RunnerDeployment
+			if err := c.Get(ctx, key, &updated); err != nil {
+				t.Fatal(err)
+			}
+
+			if *updated.Spec.Replicas != tc.wantReplicas {
+				t.Errorf("unexpected replicas: want %d, got %d", tc.wantReplicas, *updated.Spec.Replicas)
+			}
+
+			var got v1alpha1.HorizontalRunnerAutoscaler
+			if err := c.Get(ctx, key, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, GitHubDegradedConditionType)
+			if cond == nil || cond.Status != tc.wantCondition {
+				t.Errorf("unexpected GitHubDegraded condition: %+v", cond)
+			}
+		})
+	}
+}
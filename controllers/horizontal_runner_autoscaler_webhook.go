@@ -68,6 +68,16 @@ type HorizontalRunnerAutoscalerGitHubWebhook struct {
 	// Set to empty for letting it watch for all namespaces.
 	Namespace string
 	Name      string
+
+	// PreviewPools handles `/arc pool` commands commented on pull requests.
+	// Set to nil to ignore issue_comment events.
+	PreviewPools *PreviewPools
+
+	// RunnerLabelAliases maps the old runner labels to the new ones, which are considered the same on matching the workflow jobs to the runners.
+	RunnerLabelAliases RunnerLabelAliases
+
+	// workflowPaths caches the workflow paths read for filtering workflow_job events by workflows.
+	workflowPaths workflowPathCache
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
@@ -231,6 +241,20 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 		labels := e.WorkflowJob.Labels
 
+		var p workflowJobPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			log.V(1).Info("Unable to read the workflow name from the workflow_job event", "error", err.Error())
+		}
+
+		workflow := &jobWorkflow{
+			owner: e.Repo.Owner.GetLogin(),
+			repo:  e.Repo.GetName(),
+			runID: e.GetWorkflowJob().GetRunID(),
+			name:  p.WorkflowJob.WorkflowName,
+			ghc:   autoscaler.GitHubClient,
+			paths: &autoscaler.workflowPaths,
+		}
+
 		switch action := e.GetAction(); action {
 		case "queued", "completed":
 			target, err = autoscaler.getJobScaleUpTargetForRepoOrOrg(
@@ -241,11 +265,14 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 				e.Repo.Owner.GetType(),
 				enterpriseSlug,
 				labels,
+				workflow,
 			)
 			if target == nil {
 				break
 			}
 
+			target.ReservationName = workflowJobReservationName(e.GetWorkflowJob().GetID())
+
 			if e.GetAction() == "queued" {
 				target.Amount = 1
 				break
@@ -258,6 +285,30 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 				break
 			}
 			// If the conclusion is "skipped", we will ignore it and fallthrough to the default case.
+			fallthrough
+		case "in_progress":
+			// The runner that picked up the job is recorded so that the warm runners are retained on scale in.
+			// See RunnerDeploymentSpec.WarmRunnerAffinity.
+			// It's also linked to the capacity reservation of the job, which is released once the runner pod completes it.
+			if name := p.WorkflowJob.RunnerName; action == "in_progress" && name != "" {
+				job := v1alpha1.RunnerJob{
+					Repository: e.Repo.GetFullName(),
+					Workflow:   p.WorkflowJob.WorkflowName,
+					Name:       e.GetWorkflowJob().GetName(),
+					StartedAt:  metav1.Now(),
+				}
+
+				if err := recordRunnerJob(context.TODO(), autoscaler.Client, autoscaler.Namespace, name, job); err != nil {
+					log.Error(err, "Failed to record the workflow job to the runner status", "runner", name)
+				}
+
+				reservation := workflowJobReservationName(e.GetWorkflowJob().GetID())
+
+				if err := linkRunnerCapacityReservation(context.TODO(), autoscaler.Client, autoscaler.Namespace, name, reservation); err != nil {
+					log.Error(err, "Failed to link the runner to the capacity reservation of the workflow job", "runner", name, "reservation", reservation)
+				}
+			}
+
 			fallthrough
 		default:
 			ok = true
@@ -268,6 +319,74 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 			return
 		}
+	case *gogithub.WorkflowRunEvent:
+		var p workflowRunPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			log.V(1).Info("Unable to read the run attempt from the workflow_run event", "error", err.Error())
+		}
+
+		log = log.WithValues(
+			"workflowRun.id", e.GetWorkflowRun().GetID(),
+			"workflowRun.runAttempt", p.WorkflowRun.RunAttempt,
+			"repository.name", e.Repo.GetName(),
+			"repository.owner.login", e.Repo.Owner.GetLogin(),
+			"repository.owner.type", e.Repo.Owner.GetType(),
+			"enterprise.slug", enterpriseSlug,
+			"action", e.GetAction(),
+		)
+
+		// A fresh workflow run is scaled for by the workflow_job events of its jobs.
+		// Only a re-run is scaled for here, so that the re-run jobs don't wait for the next sync period
+		// while the capacity reservations made for the original run have long expired.
+		if e.GetAction() != "requested" || p.WorkflowRun.RunAttempt < 2 {
+			ok = true
+
+			w.WriteHeader(http.StatusOK)
+
+			log.V(2).Info("Received and ignored a workflow_run event as it isn't a re-run")
+
+			return
+		}
+
+		var scaled []string
+
+		scaled, err = autoscaler.scaleForWorkflowRunRerun(context.TODO(), log, e, enterpriseSlug)
+		if err != nil {
+			log.Error(err, "handling workflow_run event")
+
+			return
+		}
+
+		ok = true
+
+		w.WriteHeader(http.StatusOK)
+
+		msg := fmt.Sprintf("scaled %d jobs of the re-run", len(scaled))
+		if len(scaled) > 0 {
+			msg += ": " + strings.Join(scaled, ", ")
+		}
+
+		autoscaler.Log.Info(msg)
+
+		if written, err := w.Write([]byte(msg)); err != nil {
+			log.Error(err, "failed writing http response", "msg", msg, "written", written)
+		}
+
+		return
+	case *gogithub.IssueCommentEvent:
+		if autoscaler.PreviewPools != nil {
+			if err = autoscaler.PreviewPools.HandleIssueComment(context.TODO(), e); err != nil {
+				log.Error(err, "handling issue_comment event")
+
+				return
+			}
+		}
+
+		ok = true
+
+		w.WriteHeader(http.StatusOK)
+
+		return
 	case *gogithub.PingEvent:
 		ok = true
 
@@ -383,6 +502,14 @@ func matchTriggerConditionAgainstEvent(types []string, eventAction *string) bool
 type ScaleTarget struct {
 	v1alpha1.HorizontalRunnerAutoscaler
 	v1alpha1.ScaleUpTrigger
+
+	// Size is the size label requested by the workflow job, when the scale target advertises multiple sizes.
+	Size string
+
+	// ReservationName is the name of the capacity reservation added or removed by the scale target, e.g. after the workflow job.
+	// The reservation isn't added twice while one with the same name is valid, so that a job reported more than once,
+	// like by the redelivery of the webhook event or by both the workflow_run and the workflow_job events of a re-run, counts only once.
+	ReservationName string
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) searchScaleTargets(hras []v1alpha1.HorizontalRunnerAutoscaler, f func(v1alpha1.ScaleUpTrigger) bool) []ScaleTarget {
@@ -452,11 +579,11 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTarget(ctx
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleUpTargetForRepoOrOrg(
-	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, labels []string,
+	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, labels []string, workflow *jobWorkflow,
 ) (*ScaleTarget, error) {
 
 	scaleTarget := func(value string) (*ScaleTarget, error) {
-		return autoscaler.getJobScaleTarget(ctx, value, labels)
+		return autoscaler.getJobScaleTarget(ctx, value, labels, workflow)
 	}
 	return autoscaler.getScaleUpTargetWithFunction(ctx, log, repo, owner, ownerType, enterprise, scaleTarget)
 }
@@ -612,7 +739,7 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getManagedRunnerGroup
 			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
 				return groups, err
 			}
-			o, e, g = rd.Spec.Template.Spec.Organization, rd.Spec.Template.Spec.Enterprise, rd.Spec.Template.Spec.Group
+			o, e, g = rd.Spec.Template.Spec.Organization, rd.Spec.Template.Spec.Enterprise, rd.RunnerGroupName()
 		default:
 			return nil, fmt.Errorf("unsupported scale target kind: %v", kind)
 		}
@@ -652,7 +779,11 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getManagedRunnerGroup
 	return groups, nil
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleTarget(ctx context.Context, name string, labels []string) (*ScaleTarget, error) {
+// getJobScaleTarget returns the scale target for the workflow job among the HRAs found by the key.
+// When the runners of two or more HRAs have all the labels requested by the job, the one with the longest match,
+// that is the runners with the fewest labels not requested by the job, is chosen so that the job is routed to the most specific runner pool.
+// Ties are broken by the namespaces and the names of the HRAs to keep the routing deterministic.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleTarget(ctx context.Context, name string, labels []string, workflow *jobWorkflow) (*ScaleTarget, error) {
 	hras, err := autoscaler.findHRAsByKey(ctx, name)
 	if err != nil {
 		return nil, err
@@ -660,7 +791,12 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleTarget(ctx
 
 	autoscaler.Log.V(1).Info(fmt.Sprintf("Found %d HRAs by key", len(hras)), "key", name)
 
-HRA:
+	var (
+		best          *ScaleTarget
+		bestExtra     int
+		bestDedicated bool
+	)
+
 	for _, hra := range hras {
 		if !hra.ObjectMeta.DeletionTimestamp.IsZero() {
 			continue
@@ -690,6 +826,24 @@ HRA:
 			continue
 		}
 
+		// dedicated is true when the HRA is dedicated to the workflow of the job, which wins over the generic ones sharing the labels.
+		var dedicated bool
+
+		if patterns := scaleUpTrigger.GitHubEvent.WorkflowJob.Workflows; len(patterns) > 0 && workflow != nil {
+			ok, err := workflow.match(ctx, patterns)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				autoscaler.Log.V(1).Info("Skipping this HRA as the workflow of the job doesn't match `githubEvent.workflowJob.workflows`", "hra", hra.Name, "workflow", workflow.name)
+
+				continue
+			}
+
+			dedicated = true
+		}
+
 		duration := scaleUpTrigger.Duration
 		if duration.Duration <= 0 {
 			// Try to release the reserved capacity after at least 10 minutes by default,
@@ -699,6 +853,8 @@ HRA:
 			duration.Duration = 10 * time.Minute
 		}
 
+		var runnerLabels, platformLabels, sizes []string
+
 		switch hra.Spec.ScaleTargetRef.Kind {
 		case "RunnerSet":
 			var rs v1alpha1.RunnerSet
@@ -707,67 +863,116 @@ HRA:
 				return nil, err
 			}
 
-			// Ensure that the RunnerSet-managed runners have all the labels requested by the workflow_job.
-			for _, l := range labels {
-				var matched bool
+			runnerLabels = rs.Spec.Labels
+			platformLabels = runnerPlatformLabels(rs.Spec.RunnerConfig, rs.Spec.Template.Spec.NodeSelector)
+		case "RunnerDeployment", "":
+			var rd v1alpha1.RunnerDeployment
 
-				// ignore "self-hosted" label as all instance here are self-hosted
-				if l == "self-hosted" {
-					continue
-				}
+			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
+				return nil, err
+			}
 
-				// TODO labels related to OS and architecture needs to be explicitly declared or the current implementation will not be able to find them.
+			runnerLabels = rd.Spec.Template.Spec.Labels
+			platformLabels = runnerPlatformLabels(rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.NodeSelector)
+			sizes = rd.Spec.Sizes
+		default:
+			return nil, fmt.Errorf("unsupported scaleTargetRef.kind: %v", hra.Spec.ScaleTargetRef.Kind)
+		}
 
-				for _, l2 := range rs.Spec.Labels {
-					if l == l2 {
-						matched = true
-						break
-					}
-				}
+		size := jobSize(labels, sizes)
 
-				if !matched {
-					continue HRA
-				}
+		if len(sizes) > 0 {
+			// Each runner advertises one of the sizes, which is the first size unless the job requested another.
+			s := size
+			if s == "" {
+				s = sizes[0]
 			}
 
-			return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
-		case "RunnerDeployment", "":
-			var rd v1alpha1.RunnerDeployment
+			runnerLabels = append(append([]string{}, runnerLabels...), s)
+		}
 
-			if err := autoscaler.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}, &rd); err != nil {
-				return nil, err
+		// Ensure that the runners have all the labels requested by the workflow_job.
+		extra, ok := matchJobLabels(autoscaler.RunnerLabelAliases.canonicalize(labels), autoscaler.RunnerLabelAliases.canonicalize(runnerLabels), platformLabels)
+		if !ok {
+			continue
+		}
+
+		if best != nil {
+			if extra > bestExtra {
+				continue
 			}
 
-			// Ensure that the RunnerDeployment-managed runners have all the labels requested by the workflow_job.
-			for _, l := range labels {
-				var matched bool
+			if extra == bestExtra {
+				if bestDedicated && !dedicated {
+					continue
+				}
 
-				// ignore "self-hosted" label as all instance here are self-hosted
-				if l == "self-hosted" {
+				if bestDedicated == dedicated && hraKey(best.HorizontalRunnerAutoscaler) < hraKey(hra) {
 					continue
 				}
+			}
+		}
 
-				// TODO labels related to OS and architecture needs to be explicitly declared or the current implementation will not be able to find them.
+		best = &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}, Size: size}
+		bestExtra = extra
+		bestDedicated = dedicated
+	}
 
-				for _, l2 := range rd.Spec.Template.Spec.Labels {
-					if l == l2 {
-						matched = true
-						break
-					}
-				}
+	if best != nil {
+		autoscaler.Log.V(1).Info("Routing the workflow job to the runner pool with the longest label match",
+			"hra", hraKey(best.HorizontalRunnerAutoscaler),
+			"labels", labels,
+			"unrequested_labels", bestExtra,
+			"dedicated", bestDedicated,
+			"size", best.Size,
+		)
+	}
 
-				if !matched {
-					continue HRA
-				}
+	return best, nil
+}
+
+// matchJobLabels returns true when the runners have all the labels requested by the workflow job,
+// along with the number of the labels of the runners that aren't requested by the job.
+// The operating system and the CPU architecture labels the runners register on their own are matched case-insensitively like GitHub does,
+// and aren't counted as the unrequested labels so that they don't affect the choice among the runner pools.
+func matchJobLabels(jobLabels, runnerLabels, platformLabels []string) (int, bool) {
+	requested := make(map[string]struct{}, len(jobLabels))
+
+	for _, l := range jobLabels {
+		// ignore "self-hosted" label as all instance here are self-hosted
+		if l == "self-hosted" {
+			continue
+		}
+
+		matched := containsFold(platformLabels, l)
+
+		for _, l2 := range runnerLabels {
+			if l == l2 {
+				matched = true
+				break
 			}
+		}
 
-			return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: v1alpha1.ScaleUpTrigger{Duration: duration}}, nil
-		default:
-			return nil, fmt.Errorf("unsupported scaleTargetRef.kind: %v", hra.Spec.ScaleTargetRef.Kind)
+		if !matched {
+			return 0, false
+		}
+
+		requested[l] = struct{}{}
+	}
+
+	var extra int
+
+	for _, l := range runnerLabels {
+		if _, ok := requested[l]; !ok && l != "self-hosted" {
+			extra++
 		}
 	}
 
-	return nil, nil
+	return extra, true
+}
+
+func hraKey(hra v1alpha1.HorizontalRunnerAutoscaler) string {
+	return hra.Namespace + "/" + hra.Name
 }
 
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) tryScale(ctx context.Context, target *ScaleTarget) error {
@@ -785,24 +990,51 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) tryScale(ctx context.
 
 	capacityReservations := getValidCapacityReservations(copy)
 
+	named := -1
+	if target.ReservationName != "" {
+		for i, r := range capacityReservations {
+			if r.Name == target.ReservationName {
+				named = i
+				break
+			}
+		}
+	}
+
 	if amount > 0 {
+		if named >= 0 {
+			autoscaler.Log.V(1).Info(
+				fmt.Sprintf("Skipped patching hra %s as the capacity reservation already exists", target.HorizontalRunnerAutoscaler.Name),
+				"reservation", target.ReservationName,
+			)
+
+			return nil
+		}
+
 		now := time.Now()
 		copy.Spec.CapacityReservations = append(capacityReservations, v1alpha1.CapacityReservation{
+			Name:           target.ReservationName,
 			EffectiveTime:  metav1.Time{Time: now},
 			ExpirationTime: metav1.Time{Time: now.Add(target.ScaleUpTrigger.Duration.Duration)},
 			Replicas:       amount,
+			Size:           target.Size,
 		})
 	} else if amount < 0 {
 		var reservations []v1alpha1.CapacityReservation
 
 		var found bool
 
-		for _, r := range capacityReservations {
-			if !found && r.Replicas+amount == 0 {
+		for i, r := range capacityReservations {
+			if named >= 0 {
+				// The reservation after the same job is released regardless of its amount and size
+				if i == named {
+					continue
+				}
+			} else if !found && r.Replicas+amount == 0 && r.Size == target.Size {
 				found = true
-			} else {
-				reservations = append(reservations, r)
+				continue
 			}
+
+			reservations = append(reservations, r)
 		}
 
 		copy.Spec.CapacityReservations = reservations
@@ -870,15 +1102,15 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) SetupWithManager(mgr
 				keys = append(keys, rd.Spec.Template.Spec.Repository) // Repository runners
 			}
 			if rd.Spec.Template.Spec.Organization != "" {
-				if group := rd.Spec.Template.Spec.Group; group != "" {
-					keys = append(keys, organizationalRunnerGroupKey(rd.Spec.Template.Spec.Organization, rd.Spec.Template.Spec.Group)) // Organization runner groups
+				if group := rd.RunnerGroupName(); group != "" {
+					keys = append(keys, organizationalRunnerGroupKey(rd.Spec.Template.Spec.Organization, group)) // Organization runner groups
 				} else {
 					keys = append(keys, rd.Spec.Template.Spec.Organization) // Organization runners
 				}
 			}
 			if enterprise := rd.Spec.Template.Spec.Enterprise; enterprise != "" {
-				if group := rd.Spec.Template.Spec.Group; group != "" {
-					keys = append(keys, enterpriseRunnerGroupKey(enterprise, rd.Spec.Template.Spec.Group)) // Enterprise runner groups
+				if group := rd.RunnerGroupName(); group != "" {
+					keys = append(keys, enterpriseRunnerGroupKey(enterprise, group)) // Enterprise runner groups
 				} else {
 					keys = append(keys, enterpriseKey(enterprise)) // Enterprise runners
 				}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// workflowRunPayload is the part of the workflow_run event payload that isn't parsed by go-github.
+type workflowRunPayload struct {
+	WorkflowRun struct {
+		RunAttempt int `json:"run_attempt"`
+	} `json:"workflow_run"`
+}
+
+// workflowJobReservationName returns the name of the capacity reservation made for the workflow job.
+func workflowJobReservationName(jobID int64) string {
+	return fmt.Sprintf("job-%d", jobID)
+}
+
+// scaleForWorkflowRunRerun adds a capacity reservation for each job of the re-run workflow run that hasn't completed,
+// to the scale target chosen by the labels of the job just like for a queued workflow_job event.
+// It returns the names of the scaled HorizontalRunnerAutoscalers, one per job.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) scaleForWorkflowRunRerun(ctx context.Context, log logr.Logger, e *gogithub.WorkflowRunEvent, enterprise string) ([]string, error) {
+	if autoscaler.GitHubClient == nil {
+		log.V(1).Info("Ignored the re-run as no GitHub client is configured to list its jobs")
+
+		return nil, nil
+	}
+
+	run := e.GetWorkflowRun()
+	owner, repo := e.Repo.Owner.GetLogin(), e.Repo.GetName()
+
+	// The jobs of the previous attempt may still be cached, hence the uncached listing.
+	jobs, err := autoscaler.GitHubClient.ListLatestWorkflowJobs(ctx, owner, repo, run.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("listing jobs of the re-run workflow run: %w", err)
+	}
+
+	workflow := &jobWorkflow{
+		owner: owner,
+		repo:  repo,
+		runID: run.GetID(),
+		name:  run.GetName(),
+		ghc:   autoscaler.GitHubClient,
+		paths: &autoscaler.workflowPaths,
+	}
+
+	var scaled []string
+
+	for _, j := range jobs {
+		// The jobs that succeeded in the previous attempt are carried over to the re-run as completed
+		if j.GetStatus() == "completed" {
+			continue
+		}
+
+		target, err := autoscaler.getJobScaleUpTargetForRepoOrOrg(ctx, log, repo, owner, e.Repo.Owner.GetType(), enterprise, j.Labels, workflow)
+		if err != nil {
+			// A job that can't be routed doesn't prevent the other jobs of the re-run from being scaled for
+			log.Error(err, "Could not find the scale target for the re-run job", "workflowJob.name", j.GetName(), "workflowJob.labels", j.Labels)
+
+			continue
+		}
+
+		if target == nil {
+			log.V(1).Info("Scale target not found for the re-run job", "workflowJob.name", j.GetName(), "workflowJob.labels", j.Labels)
+
+			continue
+		}
+
+		target.Amount = 1
+		target.ReservationName = workflowJobReservationName(j.GetID())
+
+		if err := autoscaler.tryScale(ctx, target); err != nil {
+			return scaled, err
+		}
+
+		scaled = append(scaled, target.Name)
+	}
+
+	return scaled, nil
+}
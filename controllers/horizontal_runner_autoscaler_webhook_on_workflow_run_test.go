@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTryScale_ReservationName(t *testing.T) {
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example",
+		},
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(hra).Build()
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: c, Log: logr.Discard()}
+
+	scale := func(amount int, name string) []v1alpha1.CapacityReservation {
+		t.Helper()
+
+		var latest v1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &latest); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: latest,
+			ScaleUpTrigger:             v1alpha1.ScaleUpTrigger{Amount: amount, Duration: metav1.Duration{Duration: time.Hour}},
+			ReservationName:            name,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &latest); err != nil {
+			t.Fatal(err)
+		}
+
+		return latest.Spec.CapacityReservations
+	}
+
+	scale(1, "")
+	scale(1, "job-1")
+
+	// The same job reported twice is reserved for only once
+	if got := scale(1, "job-1"); len(got) != 2 || got[1].Name != "job-1" {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	// The completed job releases its own reservation rather than the oldest one
+	if got := scale(-1, "job-1"); len(got) != 1 || got[0].Name != "" {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	// The job without a reservation of its own releases the oldest one, like before
+	if got := scale(-1, "job-2"); len(got) != 0 {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+}
+
+func TestWebhookWorkflowRunRerun(t *testing.T) {
+	s := fake.NewScenario().AddWorkflowRun("MYORG", "MYREPO",
+		&gogithub.WorkflowRun{ID: gogithub.Int64(100), Status: gogithub.String("queued")},
+		&gogithub.WorkflowJob{ID: gogithub.Int64(1), Status: gogithub.String("completed"), Labels: []string{"label1"}},
+		&gogithub.WorkflowJob{ID: gogithub.Int64(2), Status: gogithub.String("queued"), Labels: []string{"label1"}},
+		&gogithub.WorkflowJob{ID: gogithub.Int64(3), Status: gogithub.String("queued"), Labels: []string{"label1"}},
+		&gogithub.WorkflowJob{ID: gogithub.Int64(4), Status: gogithub.String("queued"), Labels: []string{"gpu"}},
+	)
+
+	server := s.GetServer()
+	defer server.Close()
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-name",
+		},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{
+				Name: "test-name",
+			},
+			ScaleUpTriggers: []v1alpha1.ScaleUpTrigger{
+				{
+					GitHubEvent: &v1alpha1.GitHubEventScaleUpTriggerSpec{
+						WorkflowJob: &v1alpha1.WorkflowJobSpec{},
+					},
+					Duration: metav1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+	}
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-name",
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Organization: "MYORG",
+						Labels:       []string{"label1"},
+					},
+				},
+			},
+		},
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithRuntimeObjects(hra, rd).Build()
+
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Client:       c,
+		GitHubClient: newGithubClient(server),
+	}
+
+	logs := installTestLogger(hraWebhook)
+
+	defer func() {
+		if t.Failed() {
+			t.Logf("diagnostics: %s", logs.String())
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", hraWebhook.Handle)
+
+	webhookServer := httptest.NewServer(mux)
+	defer webhookServer.Close()
+
+	send := func(action string, runAttempt int) string {
+		t.Helper()
+
+		// go-github doesn't know run_attempt, hence the event as a map
+		event := map[string]interface{}{
+			"action": action,
+			"workflow_run": map[string]interface{}{
+				"id":          100,
+				"name":        "nightly",
+				"run_attempt": runAttempt,
+			},
+			"repository": map[string]interface{}{
+				"name":      "MYREPO",
+				"full_name": "MYORG/MYREPO",
+				"owner": map[string]interface{}{
+					"login": "MYORG",
+					"type":  "Organization",
+				},
+			},
+		}
+
+		resp, err := sendWebhook(webhookServer, "workflow_run", event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal("status:", resp.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(body)
+	}
+
+	reservations := func() []v1alpha1.CapacityReservation {
+		t.Helper()
+
+		var latest v1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(context.Background(), types.NamespacedName{Name: "test-name"}, &latest); err != nil {
+			t.Fatal(err)
+		}
+
+		return latest.Spec.CapacityReservations
+	}
+
+	if body := send("requested", 1); body != "" {
+		t.Errorf("the first attempt must be left to the workflow_job events, got %q", body)
+	}
+
+	if got := reservations(); len(got) != 0 {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	if body := send("requested", 2); body != "scaled 2 jobs of the re-run: test-name, test-name" {
+		t.Errorf("unexpected body: %q", body)
+	}
+
+	got := reservations()
+	if len(got) != 2 || got[0].Name != "job-2" || got[1].Name != "job-3" {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	// The redelivery of the event doesn't reserve the capacity twice
+	send("requested", 2)
+
+	if got := reservations(); len(got) != 2 {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+}
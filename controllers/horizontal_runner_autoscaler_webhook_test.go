@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 	"github.com/google/go-github/v39/github"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -272,6 +274,288 @@ func TestWebhookWorkflowJob(t *testing.T) {
 			initObjs,
 		)
 	})
+	t.Run("LongestMatch", func(t *testing.T) {
+		e := setupTest()
+
+		newScaleTarget := func(name string, labels []string) []runtime.Object {
+			hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{
+						Name: name,
+					},
+					ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+						{
+							GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+								WorkflowJob: &actionsv1alpha1.WorkflowJobSpec{},
+							},
+						},
+					},
+				},
+			}
+
+			rd := &actionsv1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.RunnerDeploymentSpec{
+					Template: actionsv1alpha1.RunnerTemplate{
+						Spec: actionsv1alpha1.RunnerSpec{
+							RunnerConfig: actionsv1alpha1.RunnerConfig{
+								Organization: "MYORG",
+								Labels:       labels,
+							},
+						},
+					},
+				},
+			}
+
+			return []runtime.Object{hra, rd}
+		}
+
+		var initObjs []runtime.Object
+		initObjs = append(initObjs, newScaleTarget("a-gpu", []string{"label1", "gpu"})...)
+		initObjs = append(initObjs, newScaleTarget("b-generic", []string{"label1"})...)
+		initObjs = append(initObjs, newScaleTarget("c-other", []string{"other"})...)
+		initObjs = append(initObjs, newScaleTarget("d-generic", []string{"label1", "self-hosted"})...)
+
+		testServerWithInitObjs(t,
+			"workflow_job",
+			&e,
+			200,
+			"scaled b-generic by 1",
+			initObjs,
+		)
+	})
+	t.Run("Workflows", func(t *testing.T) {
+		// go-github doesn't parse workflow_name of the workflow_job event yet, hence the raw payload.
+		newEvent := func(workflowName string) map[string]interface{} {
+			f, err := os.Open("testdata/org_webhook_workflow_job_payload.json")
+			if err != nil {
+				t.Fatalf("could not open the fixture: %s", err)
+			}
+			defer f.Close()
+			var e map[string]interface{}
+			if err := json.NewDecoder(f).Decode(&e); err != nil {
+				t.Fatalf("invalid json: %s", err)
+			}
+
+			e["workflow_job"].(map[string]interface{})["workflow_name"] = workflowName
+
+			return e
+		}
+
+		newScaleTarget := func(name string, workflows []string) []runtime.Object {
+			hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{
+						Name: name,
+					},
+					ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+						{
+							GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+								WorkflowJob: &actionsv1alpha1.WorkflowJobSpec{
+									Workflows: workflows,
+								},
+							},
+						},
+					},
+				},
+			}
+
+			rd := &actionsv1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.RunnerDeploymentSpec{
+					Template: actionsv1alpha1.RunnerTemplate{
+						Spec: actionsv1alpha1.RunnerSpec{
+							RunnerConfig: actionsv1alpha1.RunnerConfig{
+								Organization: "MYORG",
+								Labels:       []string{"label1"},
+							},
+						},
+					},
+				},
+			}
+
+			return []runtime.Object{hra, rd}
+		}
+
+		t.Run("Matched", func(t *testing.T) {
+			e := newEvent("Deploy to production")
+
+			testServerWithInitObjs(t,
+				"workflow_job",
+				e,
+				200,
+				"scaled test-name by 1",
+				newScaleTarget("test-name", []string{"Deploy*"}),
+			)
+		})
+
+		t.Run("Unmatched", func(t *testing.T) {
+			e := newEvent("CI")
+
+			testServerWithInitObjs(t,
+				"workflow_job",
+				e,
+				200,
+				"no horizontalrunnerautoscaler to scale for this github event",
+				newScaleTarget("test-name", []string{"Deploy*"}),
+			)
+		})
+
+		t.Run("Dedicated", func(t *testing.T) {
+			e := newEvent("Deploy to production")
+
+			var initObjs []runtime.Object
+			initObjs = append(initObjs, newScaleTarget("a-generic", nil)...)
+			initObjs = append(initObjs, newScaleTarget("b-deploy", []string{"Deploy*"})...)
+
+			testServerWithInitObjs(t,
+				"workflow_job",
+				e,
+				200,
+				"scaled b-deploy by 1",
+				initObjs,
+			)
+		})
+	})
+	t.Run("Sizes", func(t *testing.T) {
+		e := setupTest()
+		e.WorkflowJob.Labels = []string{"self-hosted", "label1", "16-core"}
+
+		newScaleTarget := func(name string, sizes []string) []runtime.Object {
+			hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{
+						Name: name,
+					},
+					ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+						{
+							GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+								WorkflowJob: &actionsv1alpha1.WorkflowJobSpec{},
+							},
+						},
+					},
+				},
+			}
+
+			rd := &actionsv1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: actionsv1alpha1.RunnerDeploymentSpec{
+					Sizes: sizes,
+					Template: actionsv1alpha1.RunnerTemplate{
+						Spec: actionsv1alpha1.RunnerSpec{
+							RunnerConfig: actionsv1alpha1.RunnerConfig{
+								Organization: "MYORG",
+								Labels:       []string{"label1"},
+							},
+						},
+					},
+				},
+			}
+
+			return []runtime.Object{hra, rd}
+		}
+
+		var initObjs []runtime.Object
+		initObjs = append(initObjs, newScaleTarget("a-unsized", nil)...)
+		initObjs = append(initObjs, newScaleTarget("b-small", []string{"2-core", "4-core"})...)
+		initObjs = append(initObjs, newScaleTarget("c-sized", []string{"4-core", "16-core"})...)
+
+		testServerWithInitObjs(t,
+			"workflow_job",
+			&e,
+			200,
+			"scaled c-sized by 1",
+			initObjs,
+		)
+	})
+}
+
+func TestTryScale_Size(t *testing.T) {
+	hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example",
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, hra)
+
+	webhook := &HorizontalRunnerAutoscalerGitHubWebhook{Client: c, Log: logr.Discard()}
+
+	scale := func(amount int, size string) []actionsv1alpha1.CapacityReservation {
+		t.Helper()
+
+		var latest actionsv1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &latest); err != nil {
+			t.Fatal(err)
+		}
+
+		target := &ScaleTarget{
+			HorizontalRunnerAutoscaler: latest,
+			ScaleUpTrigger:             actionsv1alpha1.ScaleUpTrigger{Amount: amount, Duration: metav1.Duration{Duration: time.Hour}},
+			Size:                       size,
+		}
+
+		if err := webhook.tryScale(context.Background(), target); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &latest); err != nil {
+			t.Fatal(err)
+		}
+
+		return latest.Spec.CapacityReservations
+	}
+
+	scale(1, "")
+	if got := scale(1, "16-core"); len(got) != 2 || got[1].Size != "16-core" {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	// A completed job that requested the size releases the reservation for the size
+	if got := scale(-1, "16-core"); len(got) != 1 || got[0].Size != "" {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+
+	if got := scale(-1, "16-core"); len(got) != 1 {
+		t.Fatalf("unexpected capacity reservations: %+v", got)
+	}
+}
+
+func TestMatchJobLabels(t *testing.T) {
+	testcases := []struct {
+		job, runner []string
+		wantExtra   int
+		wantOK      bool
+	}{
+		{job: []string{"self-hosted", "linux"}, runner: []string{"linux"}, wantExtra: 0, wantOK: true},
+		{job: []string{"self-hosted", "linux"}, runner: []string{"linux", "gpu", "self-hosted"}, wantExtra: 1, wantOK: true},
+		{job: []string{"self-hosted"}, runner: []string{"linux", "gpu"}, wantExtra: 2, wantOK: true},
+		{job: []string{"self-hosted", "linux", "gpu"}, runner: []string{"linux"}, wantOK: false},
+	}
+
+	for i, tc := range testcases {
+		extra, ok := matchJobLabels(tc.job, tc.runner, nil)
+
+		if ok != tc.wantOK || (ok && extra != tc.wantExtra) {
+			t.Errorf("[%d] unexpected match: want (%d, %v), got (%d, %v)", i, tc.wantExtra, tc.wantOK, extra, ok)
+		}
+	}
 }
 
 func TestWebhookWorkflowJobWithSelfHostedLabel(t *testing.T) {
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/go-logr/logr"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-actions-summerwind-dev-v1alpha1-horizontalrunnerautoscaler,verbs=create;update,mutating=false,failurePolicy=fail,groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,versions=v1alpha1,name=validate.horizontalrunnerautoscaler.actions.summerwind.dev,sideEffects=None,admissionReviewVersions=v1beta1
+
+// HorizontalRunnerAutoscalerValidator rejects the HorizontalRunnerAutoscalers that would only fail on reconciliation,
+// like the ones with unknown metric types or the ones lacking the repositories to count the workflow jobs of organizational runners.
+// The scale target is looked up to validate the metrics against the kind of its runners,
+// and the metrics are validated only against the spec when the scale target doesn't exist yet.
+type HorizontalRunnerAutoscalerValidator struct {
+	client.Client
+
+	Log logr.Logger
+
+	// ScaleAlgorithms is the set of scale algorithms keyed by the metric types they are used for.
+	// Defaults to the algorithms registered to the autoscaling package.
+	ScaleAlgorithms map[string]autoscaling.ScaleAlgorithm
+}
+
+var _ admission.CustomValidator = &HorizontalRunnerAutoscalerValidator{}
+
+// ValidateCreate implements admission.CustomValidator
+func (v *HorizontalRunnerAutoscalerValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (v *HorizontalRunnerAutoscalerValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (v *HorizontalRunnerAutoscalerValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *HorizontalRunnerAutoscalerValidator) validate(ctx context.Context, obj runtime.Object) error {
+	hra, ok := obj.(*v1alpha1.HorizontalRunnerAutoscaler)
+	if !ok {
+		return fmt.Errorf("expected a HorizontalRunnerAutoscaler but got %T", obj)
+	}
+
+	v.Log.V(1).Info("validate resource", "namespace", hra.Namespace, "name", hra.Name)
+
+	errList := hra.Validate()
+
+	errList = append(errList, v.validateMetricTypes(*hra)...)
+
+	rc, err := v.getScaleTargetRunnerConfig(ctx, *hra)
+	if err != nil {
+		return err
+	}
+
+	if rc != nil {
+		errList = append(errList, validateMetricRepositories(*hra, *rc)...)
+	}
+
+	if len(errList) > 0 {
+		return kerrors.NewInvalid(v1alpha1.GroupVersion.WithKind("HorizontalRunnerAutoscaler").GroupKind(), hra.Name, errList)
+	}
+
+	return nil
+}
+
+func (v *HorizontalRunnerAutoscalerValidator) validateMetricTypes(hra v1alpha1.HorizontalRunnerAutoscaler) field.ErrorList {
+	algorithms := v.ScaleAlgorithms
+	if algorithms == nil {
+		algorithms = autoscaling.ScaleAlgorithms()
+	}
+
+	var errList field.ErrorList
+
+	for i, m := range hra.Spec.Metrics {
+		if _, ok := algorithms[m.Type]; ok || m.Type == v1alpha1.AutoscalingMetricTypeExternal {
+			continue
+		}
+
+		supported := []string{v1alpha1.AutoscalingMetricTypeExternal}
+		for t := range algorithms {
+			supported = append(supported, t)
+		}
+
+		sort.Strings(supported)
+
+		errList = append(errList, field.NotSupported(field.NewPath("spec", "metrics").Index(i).Child("type"), m.Type, supported))
+	}
+
+	return errList
+}
+
+// getScaleTargetRunnerConfig returns the runner config of the scale target, or nil when the scale target doesn't exist yet.
+func (v *HorizontalRunnerAutoscalerValidator) getScaleTargetRunnerConfig(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (*v1alpha1.RunnerConfig, error) {
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+
+	if key.Name == "" {
+		return nil, nil
+	}
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := v.Get(ctx, key, &rd); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+
+		return &rd.Spec.Template.Spec.RunnerConfig, nil
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := v.Get(ctx, key, &rs); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+
+		return &rs.Spec.RunnerConfig, nil
+	}
+
+	return nil, nil
+}
+
+// validateMetricRepositories validates the repositories of the metrics counting workflow jobs
+// against the organization or enterprise of the runners, in the same way as the autoscaler does on reconciliation.
+func validateMetricRepositories(hra v1alpha1.HorizontalRunnerAutoscaler, rc v1alpha1.RunnerConfig) field.ErrorList {
+	if rc.Repository != "" {
+		return nil
+	}
+
+	var errList field.ErrorList
+
+	for i, m := range hra.Spec.Metrics {
+		if m.Suspended {
+			continue
+		}
+
+		switch m.Type {
+		case v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+			v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners,
+			v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime,
+			v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner:
+		default:
+			continue
+		}
+
+		path := field.NewPath("spec", "metrics").Index(i)
+
+		if rc.Organization == "" && rc.Enterprise != "" {
+			if len(m.RepositoryNames) == 0 {
+				errList = append(errList, field.Required(path.Child("repositoryNames"), fmt.Sprintf("repositoryNames is required and must have one or more entries for enterprise runners of %s", hra.Spec.ScaleTargetRef.Name)))
+			}
+
+			if m.RepositorySelector != nil {
+				errList = append(errList, field.Forbidden(path.Child("repositorySelector"), "repositorySelector is supported only for organizational runners"))
+			}
+
+			for j, name := range m.RepositoryNames {
+				repo := strings.Split(name, "/")
+				if len(repo) != 2 || repo[0] == "" || repo[1] == "" {
+					errList = append(errList, field.Invalid(path.Child("repositoryNames").Index(j), name, "must be in the OWNER/REPO format for enterprise runners"))
+				}
+			}
+		} else if rc.Organization != "" {
+			if len(m.RepositoryNames) == 0 && m.RepositorySelector == nil {
+				errList = append(errList, field.Required(path.Child("repositoryNames"), fmt.Sprintf("repositoryNames or repositorySelector is required for organizational runners of %s", hra.Spec.ScaleTargetRef.Name)))
+			}
+		}
+	}
+
+	return errList
+}
+
+func (v *HorizontalRunnerAutoscalerValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		WithValidator(v).
+		Complete()
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHorizontalRunnerAutoscalerValidator(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	newRD := func(name string, rc v1alpha1.RunnerConfig) *v1alpha1.RunnerDeployment {
+		rd := &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		}
+		rd.Spec.Template.Spec.RunnerConfig = rc
+
+		return rd
+	}
+
+	c := fake.NewFakeClientWithScheme(sc,
+		newRD("org", v1alpha1.RunnerConfig{Organization: "test"}),
+		newRD("enterprise", v1alpha1.RunnerConfig{Enterprise: "test"}),
+		newRD("repo", v1alpha1.RunnerConfig{Repository: "test/repo"}),
+	)
+
+	v := &HorizontalRunnerAutoscalerValidator{Client: c, Log: logr.Discard()}
+
+	testcases := []struct {
+		name    string
+		target  string
+		min     *int
+		max     *int
+		noMax   bool
+		metrics []v1alpha1.MetricSpec
+		ovf     *v1alpha1.HorizontalRunnerAutoscalerOverflow
+		want    []string
+	}{
+		{
+			name:    "valid",
+			target:  "org",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns", RepositoryNames: []string{"repo"}}},
+		},
+		{
+			name:    "min greater than max",
+			target:  "org",
+			min:     intPtr(3),
+			max:     intPtr(2),
+			metrics: []v1alpha1.MetricSpec{{Type: "PercentageRunnersBusy"}},
+			want:    []string{"spec.maxReplicas: Invalid value: 2: must be greater than or equal to minReplicas"},
+		},
+		{
+			name:    "missing max",
+			target:  "org",
+			noMax:   true,
+			metrics: []v1alpha1.MetricSpec{{Type: "PercentageRunnersBusy"}},
+			want:    []string{"spec.maxReplicas: Required value"},
+		},
+		{
+			name:    "unknown metric type",
+			target:  "repo",
+			metrics: []v1alpha1.MetricSpec{{Type: "PercentageRunnerBusy"}},
+			want:    []string{`spec.metrics[0].type: Unsupported value: "PercentageRunnerBusy"`},
+		},
+		{
+			name:    "external without provider",
+			target:  "repo",
+			metrics: []v1alpha1.MetricSpec{{Type: "External"}},
+			want:    []string{"spec.metrics[0].external.provider: Required value"},
+		},
+		{
+			name:    "org runners without repositoryNames",
+			target:  "org",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns"}},
+			want:    []string{"spec.metrics[0].repositoryNames: Required value: repositoryNames or repositorySelector is required for organizational runners of org"},
+		},
+		{
+			name:    "org runners with repositorySelector",
+			target:  "org",
+			metrics: []v1alpha1.MetricSpec{{Type: "QueuedJobsPlusBusyRunners", RepositorySelector: &v1alpha1.RepositorySelector{}}},
+		},
+		{
+			name:    "suspended metric of org runners without repositoryNames",
+			target:  "org",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns", Suspended: true}},
+		},
+		{
+			name:    "enterprise runners with repository names of the wrong format",
+			target:  "enterprise",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns", RepositoryNames: []string{"test/repo1", "repo2"}}},
+			want:    []string{`spec.metrics[0].repositoryNames[1]: Invalid value: "repo2": must be in the OWNER/REPO format for enterprise runners`},
+		},
+		{
+			name:    "repository runners without repositoryNames",
+			target:  "repo",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns"}},
+		},
+		{
+			name:    "overflow sink without destination",
+			target:  "repo",
+			metrics: []v1alpha1.MetricSpec{{Type: "PercentageRunnersBusy"}},
+			ovf: &v1alpha1.HorizontalRunnerAutoscalerOverflow{Sinks: []v1alpha1.OverflowSink{
+				{Name: "configmap", ConfigMap: "overflow"},
+				{Name: "none"},
+			}},
+			want: []string{`spec.overflow.sinks[1]: Invalid value: "none": exactly one of configMap, webhookURL and horizontalRunnerAutoscaler must be set`},
+		},
+		{
+			name:    "missing scale target",
+			target:  "missing",
+			metrics: []v1alpha1.MetricSpec{{Type: "TotalNumberOfQueuedAndInProgressWorkflowRuns"}},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			hra := &v1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "hra"},
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: tc.target},
+					MinReplicas:    tc.min,
+					MaxReplicas:    intPtr(10),
+					Metrics:        tc.metrics,
+					Overflow:       tc.ovf,
+				},
+			}
+
+			if tc.max != nil {
+				hra.Spec.MaxReplicas = tc.max
+			} else if tc.noMax {
+				hra.Spec.MaxReplicas = nil
+			}
+
+			hra.Default()
+
+			err := v.ValidateCreate(context.Background(), hra)
+
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error containing %v, got none", tc.want)
+			}
+
+			for _, w := range tc.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("expected error containing %q, got %q", w, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestHorizontalRunnerAutoscalerDefault(t *testing.T) {
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{}
+
+	hra.Default()
+
+	if hra.Spec.ScaleTargetRef.Kind != "RunnerDeployment" {
+		t.Errorf("unexpected scaleTargetRef.kind: %q", hra.Spec.ScaleTargetRef.Kind)
+	}
+
+	if hra.Spec.MinReplicas == nil || *hra.Spec.MinReplicas != 1 {
+		t.Errorf("unexpected minReplicas: %v", hra.Spec.MinReplicas)
+	}
+}
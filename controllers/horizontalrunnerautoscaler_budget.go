@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const defaultHRAPriority = 1
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerbudgets,verbs=get;list;watch
+
+// budgetDemand is the number of replicas a HorizontalRunnerAutoscaler requests from a RunnerBudget.
+type budgetDemand struct {
+	key       types.NamespacedName
+	requested int
+	priority  int
+}
+
+// grantReplicas returns the number of replicas the RunnerBudgets that apply to the HorizontalRunnerAutoscaler grant to it,
+// or nil when no budget applies.
+// requested is the desired replicas of the HorizontalRunnerAutoscaler computed in this reconciliation.
+// The requested replicas of the other HorizontalRunnerAutoscalers are read from their status, so that every
+// HorizontalRunnerAutoscaler computes the same allocation from the latest demands and the sum of the grants never exceeds a budget.
+func (r *HorizontalRunnerAutoscalerReconciler) grantReplicas(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, requested int) (*int, error) {
+	var budgetList v1alpha1.RunnerBudgetList
+	if err := r.List(ctx, &budgetList); err != nil {
+		// The RunnerBudget CRD may not be installed yet, as e.g. Helm doesn't install new CRDs on upgrade.
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	org := runnerOrganization(st.org, st.repo)
+
+	var budgets []v1alpha1.RunnerBudget
+	for _, b := range budgetList.Items {
+		if budgetAppliesTo(b, org) {
+			budgets = append(budgets, b)
+		}
+	}
+
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	self := budgetDemand{
+		key:       types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name},
+		requested: requested,
+		priority:  hraPriority(hra),
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+	if err := r.List(ctx, &hraList); err != nil {
+		return nil, err
+	}
+
+	type otherDemand struct {
+		budgetDemand
+		org string
+	}
+
+	var others []otherDemand
+
+	for _, other := range hraList.Items {
+		if (other.Namespace == hra.Namespace && other.Name == hra.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		otherOrg, ok, err := r.scaleTargetOrganization(ctx, other)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		otherRequested := other.Status.RequestedReplicas
+		if otherRequested == nil {
+			otherRequested = other.Status.DesiredReplicas
+		}
+
+		others = append(others, otherDemand{
+			budgetDemand: budgetDemand{
+				key:       types.NamespacedName{Namespace: other.Namespace, Name: other.Name},
+				requested: getIntOrDefault(otherRequested, 0),
+				priority:  hraPriority(other),
+			},
+			org: otherOrg,
+		})
+	}
+
+	granted := requested
+
+	for _, b := range budgets {
+		demands := []budgetDemand{self}
+		for _, o := range others {
+			if budgetAppliesTo(b, o.org) {
+				demands = append(demands, o.budgetDemand)
+			}
+		}
+
+		if g := allocateBudget(b.Spec.MaxReplicas, demands)[self.key]; g < granted {
+			granted = g
+		}
+	}
+
+	return &granted, nil
+}
+
+// scaleTargetOrganization returns the GitHub organization the runners of the scale target of the HorizontalRunnerAutoscaler belong to.
+// The second return value is false when the scale target doesn't exist.
+func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetOrganization(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (string, bool, error) {
+	var rc v1alpha1.RunnerConfig
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Spec.ScaleTargetRef.Name}
+
+	switch hra.Spec.ScaleTargetRef.Kind {
+	case "", "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := r.Get(ctx, key, &rd); err != nil {
+			if kerrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+
+		rc = rd.Spec.Template.Spec.RunnerConfig
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := r.Get(ctx, key, &rs); err != nil {
+			if kerrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+
+		rc = rs.Spec.RunnerConfig
+	default:
+		return "", false, nil
+	}
+
+	return runnerOrganization(rc.Organization, rc.Repository), true, nil
+}
+
+func hraPriority(hra v1alpha1.HorizontalRunnerAutoscaler) int {
+	if p := hra.Spec.Priority; p != nil && *p > 0 {
+		return *p
+	}
+
+	return defaultHRAPriority
+}
+
+// runnerOrganization returns the organization of organizational runners, or the owner of the repository of repository runners.
+// It returns an empty string for enterprise runners.
+func runnerOrganization(org, repo string) string {
+	if org != "" {
+		return org
+	}
+
+	if owner, _, err := splitOwnerAndRepo(repo); err == nil {
+		return owner
+	}
+
+	return ""
+}
+
+func budgetAppliesTo(b v1alpha1.RunnerBudget, org string) bool {
+	return b.Spec.Organization == "" || strings.EqualFold(b.Spec.Organization, org)
+}
+
+// allocateBudget allocates the budget to the demands with weighted max-min fairness.
+// Every demand is granted its requested replicas when the combined demand fits in the budget.
+// Otherwise, the budget is shared in proportion to the priorities, and the part of a share exceeding
+// the requested replicas is reallocated to the demands that are still not satisfied.
+// The remainders due to rounding go to the demands with higher priorities first, and then in the order of the keys.
+func allocateBudget(budget int, demands []budgetDemand) map[types.NamespacedName]int {
+	granted := make(map[types.NamespacedName]int, len(demands))
+
+	var unsatisfied []budgetDemand
+	for _, d := range demands {
+		granted[d.key] = 0
+
+		if d.requested > 0 {
+			unsatisfied = append(unsatisfied, d)
+		}
+	}
+
+	sort.SliceStable(unsatisfied, func(i, j int) bool {
+		if unsatisfied[i].priority != unsatisfied[j].priority {
+			return unsatisfied[i].priority > unsatisfied[j].priority
+		}
+
+		return unsatisfied[i].key.String() < unsatisfied[j].key.String()
+	})
+
+	remaining := budget
+
+	for remaining > 0 && len(unsatisfied) > 0 {
+		var totalPriority int
+		for _, d := range unsatisfied {
+			totalPriority += d.priority
+		}
+
+		var allocated int
+
+		for _, d := range unsatisfied {
+			share := remaining * d.priority / totalPriority
+			if lack := d.requested - granted[d.key]; share > lack {
+				share = lack
+			}
+
+			granted[d.key] += share
+			allocated += share
+		}
+
+		if allocated == 0 {
+			// Every share is rounded down to zero. Hand out the remainders one by one.
+			for _, d := range unsatisfied {
+				if allocated == remaining {
+					break
+				}
+
+				granted[d.key]++
+				allocated++
+			}
+		}
+
+		remaining -= allocated
+
+		var next []budgetDemand
+		for _, d := range unsatisfied {
+			if granted[d.key] < d.requested {
+				next = append(next, d)
+			}
+		}
+
+		unsatisfied = next
+	}
+
+	return granted
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAllocateBudget(t *testing.T) {
+	a := types.NamespacedName{Namespace: "default", Name: "a"}
+	b := types.NamespacedName{Namespace: "default", Name: "b"}
+	c := types.NamespacedName{Namespace: "other", Name: "c"}
+
+	testcases := []struct {
+		name    string
+		budget  int
+		demands []budgetDemand
+		want    map[types.NamespacedName]int
+	}{
+		{
+			name:   "within the budget",
+			budget: 10,
+			demands: []budgetDemand{
+				{key: a, requested: 3, priority: 1},
+				{key: b, requested: 4, priority: 1},
+			},
+			want: map[types.NamespacedName]int{a: 3, b: 4},
+		},
+		{
+			name:   "equal shares",
+			budget: 10,
+			demands: []budgetDemand{
+				{key: a, requested: 8, priority: 1},
+				{key: b, requested: 8, priority: 1},
+			},
+			want: map[types.NamespacedName]int{a: 5, b: 5},
+		},
+		{
+			name:   "weighted by priority",
+			budget: 9,
+			demands: []budgetDemand{
+				{key: a, requested: 10, priority: 2},
+				{key: b, requested: 10, priority: 1},
+			},
+			want: map[types.NamespacedName]int{a: 6, b: 3},
+		},
+		{
+			name:   "unused share is reallocated",
+			budget: 10,
+			demands: []budgetDemand{
+				{key: a, requested: 2, priority: 1},
+				{key: b, requested: 10, priority: 1},
+				{key: c, requested: 10, priority: 1},
+			},
+			want: map[types.NamespacedName]int{a: 2, b: 4, c: 4},
+		},
+		{
+			name:   "remainders go to higher priorities first",
+			budget: 2,
+			demands: []budgetDemand{
+				{key: a, requested: 5, priority: 1},
+				{key: b, requested: 5, priority: 1},
+				{key: c, requested: 5, priority: 3},
+			},
+			want: map[types.NamespacedName]int{a: 0, b: 0, c: 2},
+		},
+		{
+			name:   "zero budget",
+			budget: 0,
+			demands: []budgetDemand{
+				{key: a, requested: 5, priority: 1},
+			},
+			want: map[types.NamespacedName]int{a: 0},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := allocateBudget(tc.budget, tc.demands)
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected allocation: (-want +got)\n%s", d)
+			}
+		})
+	}
+}
+
+func TestGrantReplicas(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	newRD := func(ns, name string, rc v1alpha1.RunnerConfig) *v1alpha1.RunnerDeployment {
+		return &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{Spec: v1alpha1.RunnerSpec{RunnerConfig: rc}},
+			},
+		}
+	}
+
+	newHRA := func(ns, name string, requested int) *v1alpha1.HorizontalRunnerAutoscaler {
+		return &v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: name},
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{RequestedReplicas: intPtr(requested)},
+		}
+	}
+
+	self := newHRA("default", "self", 0)
+	self.Spec.Priority = intPtr(2)
+
+	c := fake.NewFakeClientWithScheme(sc,
+		&v1alpha1.RunnerBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "myorg"},
+			Spec:       v1alpha1.RunnerBudgetSpec{Organization: "MyOrg", MaxReplicas: 12},
+		},
+		&v1alpha1.RunnerBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec:       v1alpha1.RunnerBudgetSpec{MaxReplicas: 100},
+		},
+		self,
+		newRD("default", "self", v1alpha1.RunnerConfig{Repository: "myorg/app"}),
+		newHRA("default", "sameorg", 10),
+		newRD("default", "sameorg", v1alpha1.RunnerConfig{Organization: "myorg"}),
+		newHRA("another", "otherorg", 50),
+		newRD("another", "otherorg", v1alpha1.RunnerConfig{Organization: "otherorg"}),
+		// The scale target doesn't exist
+		newHRA("default", "orphan", 50),
+	)
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client: c,
+		Log:    logr.Discard(),
+	}
+
+	st := scaleTarget{repo: "myorg/app"}
+
+	got, err := r.grantReplicas(context.Background(), *self, st, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The myorg budget of 12 is shared with sameorg in proportion to the priorities 2:1
+	if got == nil || *got != 8 {
+		t.Errorf("unexpected granted replicas: want 8, got %v", got)
+	}
+
+	r.Client = fake.NewFakeClientWithScheme(sc, self)
+
+	got, err = r.grantReplicas(context.Background(), *self, st, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("unexpected granted replicas without budgets: want nil, got %d", *got)
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AnnotationKeyForceScaleDown can be set to "true" on a HorizontalRunnerAutoscaler to let it scale down
+// below the number of the busy runners, which removes the runners in the middle of jobs.
+const AnnotationKeyForceScaleDown = annotationKeyPrefix + "force-scale-down"
+
+// countBusyRunners returns the number of the runners of the scale target that are busy running jobs.
+// It reuses the number observed while computing the metrics if any, and otherwise lists the runners on GitHub.
+// When GitHub can't tell, every running runner pod is counted as busy, so that no working runner is removed by guess.
+func (r *HorizontalRunnerAutoscalerReconciler) countBusyRunners(ctx context.Context, st scaleTarget) (int, error) {
+	if st.observation != nil && st.observation.BusyRunners != nil {
+		return *st.observation.BusyRunners, nil
+	}
+
+	runnerMap, err := st.getRunnerMap()
+	if err != nil {
+		return 0, err
+	}
+
+	if states, ok := r.syncedRunnerStates(st); ok {
+		var busy int
+
+		for name, state := range states {
+			if _, ok := runnerMap[name]; ok && state == v1alpha1.RunnerGitHubStateBusy {
+				busy++
+			}
+		}
+
+		return busy, nil
+	}
+
+	// ListRunners will return all runners managed by GitHub - not restricted to ns
+	runners, err := r.githubClient(st).ListRunners(ctx, st.enterprise, st.org, st.repo)
+	if err == nil {
+		var busy int
+
+		for _, runner := range runners {
+			if _, ok := runnerMap[runner.GetName()]; ok && runner.GetBusy() {
+				busy++
+			}
+		}
+
+		return busy, nil
+	}
+
+	if st.listRunnerPods == nil {
+		return 0, err
+	}
+
+	pods, podErr := st.listRunnerPods()
+	if podErr != nil {
+		return 0, err
+	}
+
+	return countRunningRunnerPods(pods), nil
+}
+
+// countRunningRunnerPods returns the number of the runner pods that are running and not being deleted.
+func countRunningRunnerPods(pods []corev1.Pod) int {
+	var n int
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp.IsZero() && !runnerPodOrContainerIsStopped(pod) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// busyRunnersFloor returns the desired replicas raised to the number of the busy runners when it would otherwise
+// scale down below it, whatever minReplicas and the metrics say.
+// The floor never goes beyond the current desired replicas, as it only prevents busy runners from being removed.
+// The second return value is true when the desired replicas is raised.
+func busyRunnersFloor(current, desired, busy int) (int, bool) {
+	floor := busy
+	if floor > current {
+		floor = current
+	}
+
+	if desired >= floor {
+		return desired, false
+	}
+
+	return floor, true
+}
+
+// isForceScaleDown returns true when the HorizontalRunnerAutoscaler is allowed to scale down below the number of the busy runners.
+func isForceScaleDown(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	return hra.Annotations[AnnotationKeyForceScaleDown] == "true"
+}
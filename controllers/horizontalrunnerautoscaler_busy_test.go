@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBusyRunnersFloor(t *testing.T) {
+	testcases := []struct {
+		description            string
+		current, desired, busy int
+		want                   int
+		wantRaised             bool
+	}{
+		{
+			description: "scale down to the busy runners",
+			current:     5,
+			desired:     3,
+			busy:        3,
+			want:        3,
+		},
+		{
+			description: "scale down below the busy runners",
+			current:     5,
+			desired:     0,
+			busy:        2,
+			want:        2,
+			wantRaised:  true,
+		},
+		{
+			description: "maxReplicas lowered below the busy runners",
+			current:     20,
+			desired:     5,
+			busy:        10,
+			want:        10,
+			wantRaised:  true,
+		},
+		{
+			description: "never scale up to the busy runners",
+			current:     2,
+			desired:     1,
+			busy:        4,
+			want:        2,
+			wantRaised:  true,
+		},
+		{
+			description: "scale up",
+			current:     2,
+			desired:     4,
+			busy:        2,
+			want:        4,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, raised := busyRunnersFloor(tc.current, tc.desired, tc.busy)
+			if got != tc.want || raised != tc.wantRaised {
+				t.Errorf("unexpected desired replicas: want %d (raised %v), got %d (raised %v)", tc.want, tc.wantRaised, got, raised)
+			}
+		})
+	}
+}
+
+func TestIsForceScaleDown(t *testing.T) {
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+
+	if isForceScaleDown(hra) {
+		t.Errorf("scale down is forced without the annotation")
+	}
+
+	hra.Annotations = map[string]string{AnnotationKeyForceScaleDown: "true"}
+
+	if !isForceScaleDown(hra) {
+		t.Errorf("scale down isn't forced with the annotation")
+	}
+}
+
+func TestCountRunningRunnerPods(t *testing.T) {
+	now := metav1.Now()
+
+	pods := []corev1.Pod{
+		{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+		{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+		{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: containerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}}},
+		}},
+	}
+
+	if got := countRunningRunnerPods(pods); got != 1 {
+		t.Errorf("unexpected number of running runner pods: want 1, got %d", got)
+	}
+}
+
+func TestCountRunners_SyncedRunnerStatuses(t *testing.T) {
+	ctx := context.Background()
+
+	now := metav1.Now()
+
+	runners := []v1alpha1.Runner{
+		{ObjectMeta: metav1.ObjectMeta{Name: "runner-1"}, Status: v1alpha1.RunnerStatus{GitHub: &v1alpha1.RunnerGitHubStatus{State: v1alpha1.RunnerGitHubStateBusy, LastSyncTime: now}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "runner-2"}, Status: v1alpha1.RunnerStatus{GitHub: &v1alpha1.RunnerGitHubStatus{State: v1alpha1.RunnerGitHubStateIdle, LastSyncTime: now}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "runner-3"}, Status: v1alpha1.RunnerStatus{GitHub: &v1alpha1.RunnerGitHubStatus{State: v1alpha1.RunnerGitHubStateUnregistered, LastSyncTime: now}}},
+	}
+
+	// The runners are never listed via the GitHub API while the synced statuses are fresh.
+	server := fake.NewServer(fake.WithListRunnersResponse(500, ""))
+	defer server.Close()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Log:                logr.Discard(),
+		GitHubClient:       newGithubClient(server),
+		RunnerStatusMaxAge: 2 * time.Minute,
+	}
+
+	st := scaleTarget{
+		repo: "test/valid",
+		getRunnerMap: func() (map[string]time.Time, error) {
+			m := map[string]time.Time{}
+			for _, runner := range runners {
+				m[runner.Name] = runner.CreationTimestamp.Time
+			}
+			return m, nil
+		},
+		listRunners: func() ([]v1alpha1.Runner, error) {
+			return runners, nil
+		},
+	}
+
+	counts, err := r.countRunners(ctx, st)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if counts.runners != 3 || counts.registered != 2 || counts.busy != 1 {
+		t.Errorf("unexpected counts: %+v", *counts)
+	}
+
+	busy, err := r.countBusyRunners(ctx, st)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if busy != 1 {
+		t.Errorf("unexpected number of busy runners: want 1, got %d", busy)
+	}
+
+	r.RunnerStatusMaxAge = 0
+
+	if _, err := r.countRunners(ctx, st); err == nil {
+		t.Errorf("expected the runners to be listed via the GitHub API when the runner status sync is disabled")
+	}
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultGitHubAPIRetries is the default number of times the GitHub API calls made to compute the desired replicas
+	// are retried on transient errors.
+	DefaultGitHubAPIRetries = 2
+
+	// DefaultGitHubAPIRetryBaseDelay is the default delay before the first retry of the GitHub API calls, doubled on each retry.
+	DefaultGitHubAPIRetryBaseDelay = time.Second
+
+	// DefaultGitHubAPICircuitBreakerThreshold is the default number of consecutive reconciliations failing with transient
+	// GitHub API errors after which the HRA stops calling the GitHub API for the cooldown.
+	DefaultGitHubAPICircuitBreakerThreshold = 3
+
+	// DefaultGitHubAPICircuitBreakerCooldown is the default duration for which the HRA stops calling the GitHub API
+	// once the circuit breaker opens.
+	DefaultGitHubAPICircuitBreakerCooldown = 5 * time.Minute
+
+	conditionReasonGitHubAPIUnavailable = "GitHubAPIUnavailable"
+)
+
+// isTransientGitHubAPIError returns true if err is likely to go away by retrying, like a 5xx response or a network error.
+// The rate limit errors aren't transient in this sense, as they are waited for until the rate limit resets at now.
+func isTransientGitHubAPIError(now time.Time, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if _, ok := rateLimitErrorBackoff(now, err); ok {
+		return false
+	}
+
+	var errResp *gogithub.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.Response != nil && errResp.Response.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// suggestDesiredReplicasWithRetries calls suggestDesiredReplicas, retrying up to GitHubAPIRetries times with the exponential backoff
+// while it fails with transient GitHub API errors.
+// The observations and the workflow jobs collected by a failed attempt are discarded before the next attempt.
+func (r *HorizontalRunnerAutoscalerReconciler) suggestDesiredReplicasWithRetries(ctx context.Context, log logr.Logger, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler) (*int, *v1alpha1.MetricSpec, string, error) {
+	delay := r.GitHubAPIRetryBaseDelay
+
+	for attempt := 0; ; attempt++ {
+		suggested, metric, reason, err := r.suggestDesiredReplicas(ctx, st, hra)
+		if err == nil || attempt >= r.GitHubAPIRetries || !isTransientGitHubAPIError(r.now(), err) {
+			return suggested, metric, reason, err
+		}
+
+		log.Info("Retrying the GitHub API calls failed with a transient error", "attempt", attempt+1, "delay", delay, "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, "", ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+
+		if st.observation != nil {
+			*st.observation = metrics.HorizontalRunnerAutoscalerObservation{}
+		}
+
+		for k := range st.repositoryWorkflowJobs {
+			delete(st.repositoryWorkflowJobs, k)
+		}
+
+		for k := range st.metricWorkflowJobs {
+			delete(st.metricWorkflowJobs, k)
+		}
+	}
+}
+
+// githubAPICircuitOpenUntil returns when the circuit breaker of the HRA closes, or nil if it's closed at now.
+func githubAPICircuitOpenUntil(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) *time.Time {
+	e := hra.Status.LastAPIError
+	if e == nil || e.CircuitOpenUntil == nil || !now.Before(e.CircuitOpenUntil.Time) {
+		return nil
+	}
+
+	return &e.CircuitOpenUntil.Time
+}
+
+// nextGitHubAPIError returns the LastAPIError of the HRA updated for another reconciliation failed with err,
+// opening the circuit breaker for the cooldown once the consecutive failures reach the threshold,
+// along with how long the HRA should wait before calling the GitHub API again.
+// The wait is doubled on each consecutive failure until it reaches the cooldown.
+func (r *HorizontalRunnerAutoscalerReconciler) nextGitHubAPIError(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, err error) (*v1alpha1.GitHubAPIError, time.Duration) {
+	failures := 1
+	if last := hra.Status.LastAPIError; last != nil {
+		failures = last.ConsecutiveFailures + 1
+	}
+
+	e := &v1alpha1.GitHubAPIError{
+		Message:             err.Error(),
+		Time:                metav1.Time{Time: now},
+		ConsecutiveFailures: failures,
+	}
+
+	cooldown := r.GitHubAPICircuitBreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultGitHubAPICircuitBreakerCooldown
+	}
+
+	if threshold := r.GitHubAPICircuitBreakerThreshold; threshold > 0 && failures >= threshold {
+		e.CircuitOpenUntil = &metav1.Time{Time: now.Add(cooldown)}
+
+		return e, cooldown
+	}
+
+	backoff := r.GitHubAPIRetryBaseDelay
+	if backoff <= 0 {
+		backoff = DefaultGitHubAPIRetryBaseDelay
+	}
+
+	for i := 1; i < failures && backoff < cooldown; i++ {
+		backoff *= 2
+	}
+
+	if backoff > cooldown {
+		backoff = cooldown
+	}
+
+	return e, backoff
+}
+
+// deferForGitHubAPIError keeps the desired replicas of the scale target as is, which is the last known desired replicas,
+// and requeues the HRA after the backoff, recording the error in status.lastAPIError, the ScalingActive condition and the event,
+// instead of failing the reconciliation.
+func (r *HorizontalRunnerAutoscalerReconciler) deferForGitHubAPIError(ctx context.Context, log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, cause error) (ctrl.Result, error) {
+	apiErr, backoff := r.nextGitHubAPIError(now, hra, cause)
+
+	msg := fmt.Sprintf("Keeping the desired replicas as the GitHub API failed %d times in a row. Retrying in %s: %v", apiErr.ConsecutiveFailures, backoff.Round(time.Second), cause)
+	if apiErr.CircuitOpenUntil != nil {
+		msg = fmt.Sprintf("Keeping the desired replicas and stopped calling the GitHub API until %s as it failed %d times in a row: %v", apiErr.CircuitOpenUntil.Format(time.RFC3339), apiErr.ConsecutiveFailures, cause)
+	}
+
+	log.Info(msg)
+
+	r.Recorder.Event(&hra, corev1.EventTypeWarning, conditionReasonGitHubAPIUnavailable, msg)
+
+	updated := hra.DeepCopy()
+	updated.Status.LastAPIError = apiErr
+
+	setConditions(hra, &updated.Status, metav1.Condition{
+		Type:    ScalingActiveConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonGitHubAPIUnavailable,
+		Message: msg,
+	})
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&hra)); err != nil {
+		log.Error(err, "Could not record the GitHub API error to the status")
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
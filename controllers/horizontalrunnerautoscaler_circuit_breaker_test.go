@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsTransientGitHubAPIError(t *testing.T) {
+	errorResponse := func(code int) error {
+		return fmt.Errorf("listing workflow runs: %w", &gogithub.ErrorResponse{Response: &http.Response{StatusCode: code}})
+	}
+
+	testcases := []struct {
+		description string
+		err         error
+		want        bool
+	}{
+		{description: "nil"},
+		{description: "5xx", err: errorResponse(http.StatusBadGateway), want: true},
+		{description: "4xx", err: errorResponse(http.StatusNotFound)},
+		{description: "network", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: io.EOF}, want: true},
+		{description: "unexpected eof", err: fmt.Errorf("reading response: %w", io.ErrUnexpectedEOF), want: true},
+		{description: "rate limit", err: &gogithub.RateLimitError{}},
+		{description: "other", err: errors.New("horizontalrunnerautoscaler default/example is missing minReplicas")},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			if got := isTransientGitHubAPIError(time.Now(), tc.err); got != tc.want {
+				t.Errorf("unexpected result: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNextGitHubAPIError(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		GitHubAPIRetryBaseDelay:          10 * time.Second,
+		GitHubAPICircuitBreakerThreshold: 4,
+		GitHubAPICircuitBreakerCooldown:  time.Minute,
+	}
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+
+	var backoffs []time.Duration
+
+	for i := 0; i < 4; i++ {
+		e, backoff := r.nextGitHubAPIError(now, hra, errors.New("502 Bad Gateway"))
+
+		if e.ConsecutiveFailures != i+1 {
+			t.Errorf("unexpected consecutive failures: want %d, got %d", i+1, e.ConsecutiveFailures)
+		}
+
+		if open := e.CircuitOpenUntil != nil; open != (i == 3) {
+			t.Errorf("unexpected circuit breaker state after %d failures: %v", i+1, e.CircuitOpenUntil)
+		}
+
+		backoffs = append(backoffs, backoff)
+
+		hra.Status.LastAPIError = e
+	}
+
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute}
+
+	for i := range want {
+		if backoffs[i] != want[i] {
+			t.Errorf("unexpected backoffs: want %v, got %v", want, backoffs)
+			break
+		}
+	}
+
+	if got := githubAPICircuitOpenUntil(now, hra); got == nil || !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("unexpected circuit open until: %v", got)
+	}
+
+	if got := githubAPICircuitOpenUntil(now.Add(time.Minute), hra); got != nil {
+		t.Errorf("the circuit breaker isn't closed after the cooldown: %v", got)
+	}
+}
+
+func TestHorizontalRunnerAutoscalerReconciler_GitHubAPIErrors(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		requests int32
+		failing  int32 = 1
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"message": "Server Error"}`)
+			return
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/runners") {
+			fmt.Fprint(w, `{"total_count": 0, "runners": []}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"total_count": 0, "workflow_runs": []}`)
+	}))
+	defer server.Close()
+
+	ghc, err := (&github.Config{Token: "token"}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ghc.Client.BaseURL = baseURL
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Replicas: intPtr(2),
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+			MinReplicas:    intPtr(0),
+			MaxReplicas:    intPtr(10),
+			Metrics: []v1alpha1.MetricSpec{
+				{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns, RepositoryNames: []string{"valid"}},
+			},
+		},
+	}
+
+	c := clientfake.NewFakeClientWithScheme(sc, rd, hra)
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client:                           c,
+		Log:                              logr.Discard(),
+		Recorder:                         record.NewFakeRecorder(10),
+		GitHubClient:                     ghc,
+		GitHubAPIRetries:                 1,
+		GitHubAPIRetryBaseDelay:          time.Millisecond,
+		GitHubAPICircuitBreakerThreshold: 2,
+		GitHubAPICircuitBreakerCooldown:  10 * time.Minute,
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func() (ctrl.Result, v1alpha1.HorizontalRunnerAutoscaler) {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var got v1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return res, got
+	}
+
+	// The first failure is retried, and then deferred with the backoff
+	res, got := reconcile()
+
+	if n := atomic.LoadInt32(&requests); n < 2 {
+		t.Errorf("the failed request isn't retried: %d requests", n)
+	}
+
+	if e := got.Status.LastAPIError; e == nil || e.ConsecutiveFailures != 1 || e.CircuitOpenUntil != nil {
+		t.Fatalf("unexpected last api error: %+v", e)
+	}
+
+	if res.RequeueAfter <= 0 || res.RequeueAfter >= time.Minute {
+		t.Errorf("unexpected requeue after: %s", res.RequeueAfter)
+	}
+
+	if active := meta.FindStatusCondition(got.Status.Conditions, ScalingActiveConditionType); active == nil || active.Reason != conditionReasonGitHubAPIUnavailable {
+		t.Errorf("unexpected ScalingActive condition: %+v", active)
+	}
+
+	// The second failure opens the circuit breaker
+	res, got = reconcile()
+
+	if e := got.Status.LastAPIError; e == nil || e.ConsecutiveFailures != 2 || e.CircuitOpenUntil == nil {
+		t.Fatalf("unexpected last api error: %+v", e)
+	}
+
+	if res.RequeueAfter != 10*time.Minute {
+		t.Errorf("unexpected requeue after: %s", res.RequeueAfter)
+	}
+
+	// No GitHub API call is made while the circuit breaker is open
+	before := atomic.LoadInt32(&requests)
+
+	if res, _ = reconcile(); res.RequeueAfter <= 0 || res.RequeueAfter > 10*time.Minute {
+		t.Errorf("unexpected requeue after: %s", res.RequeueAfter)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != before {
+		t.Errorf("unexpected requests made while the circuit breaker is open: %d", n-before)
+	}
+
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if *updated.Spec.Replicas != 2 {
+		t.Errorf("the desired replicas isn't kept while the GitHub API is failing: want 2, got %d", *updated.Spec.Replicas)
+	}
+
+	// The GitHub API recovers after the cooldown
+	atomic.StoreInt32(&failing, 0)
+
+	closed := got.DeepCopy()
+	closed.Status.LastAPIError.CircuitOpenUntil = &metav1.Time{Time: time.Now().Add(-time.Second)}
+
+	if err := c.Status().Patch(ctx, closed, client.MergeFrom(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, got = reconcile(); got.Status.LastAPIError != nil {
+		t.Errorf("the last api error isn't cleared after the recovery: %+v", got.Status.LastAPIError)
+	}
+
+	if err := c.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if *updated.Spec.Replicas != 0 {
+		t.Errorf("unexpected replicas after the recovery: want 0, got %d", *updated.Spec.Replicas)
+	}
+}
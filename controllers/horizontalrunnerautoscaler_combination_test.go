@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+)
+
+func TestComputeReplicasWithCache_MetricsCombinationPolicy(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	workflowRuns := `{"total_count": 3, "workflow_runs":[{"id": 1, "status":"queued"}, {"id": 2, "status":"in_progress"}, {"status":"completed"}]}"`
+	workflowRunsQueued := `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}"`
+	workflowRunsInProgress := `{"total_count": 1, "workflow_runs":[{"id": 2, "status":"in_progress"}]}"`
+	workflowJobs := map[int]string{
+		1: `{"jobs": [{"status":"queued", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+		2: `{"jobs": [{"status": "in_progress", "labels":["self-hosted"]}, {"status":"queued", "labels":["self-hosted"]}]}`,
+	}
+
+	// 2 of the 3 runners are busy, which is between the default thresholds of PercentageRunnersBusy.
+	runners := `
+{
+  "total_count": 3,
+  "runners": [
+    {"id": 1, "name": "test1", "os": "linux", "status": "online", "busy": true},
+    {"id": 2, "name": "test2", "os": "linux", "status": "online", "busy": true},
+    {"id": 3, "name": "test3", "os": "linux", "status": "online", "busy": false}
+  ]
+}
+`
+
+	// TotalNumberOfQueuedAndInProgressWorkflowRuns suggests 4 for 3 queued and 1 in-progress jobs,
+	// and PercentageRunnersBusy suggests 3 to keep the current replicas.
+	metrics := []v1alpha1.MetricSpec{
+		{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns, RepositoryNames: []string{"valid"}},
+		{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+	}
+
+	testcases := []struct {
+		policy     string
+		want       int
+		wantReason string
+		err        string
+	}{
+		{policy: v1alpha1.MetricsCombinationPolicyMax, want: 4, wantReason: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+		{policy: v1alpha1.MetricsCombinationPolicyMin, want: 3, wantReason: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+		{policy: v1alpha1.MetricsCombinationPolicySum, want: 7, wantReason: ScalingReasonMetricsSum},
+		{policy: v1alpha1.MetricsCombinationPolicyAverage, want: 4, wantReason: ScalingReasonMetricsAverage},
+		{policy: "Median", err: `validating autoscaling metrics: unsupported metricsCombinationPolicy "Median": It must be one of Max, Min, Sum, and Average`},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.policy, func(t *testing.T) {
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, workflowRuns, workflowRunsQueued, workflowRunsInProgress),
+				fake.WithListWorkflowJobsResponse(200, workflowJobs),
+				fake.WithListRunnersResponse(200, runners),
+			)
+			defer server.Close()
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas:              intPtr(1),
+					MaxReplicas:              intPtr(20),
+					Metrics:                  metrics,
+					MetricsCombinationPolicy: tc.policy,
+				},
+			}
+
+			st := scaleTarget{
+				repo:     "test/valid",
+				replicas: intPtr(3),
+				getRunnerMap: func() (map[string]time.Time, error) {
+					return map[string]time.Time{"test1": {}, "test2": {}, "test3": {}}, nil
+				},
+			}
+
+			got, reason, _, err := h.computeReplicasWithCache(context.Background(), logr.Discard(), now, st, hra, 1)
+			if err != nil {
+				if tc.err == "" {
+					t.Fatalf("unexpected error: expected none, got %v", err)
+				} else if err.Error() != tc.err {
+					t.Fatalf("unexpected error: expected %v, got %v", tc.err, err)
+				}
+				return
+			} else if tc.err != "" {
+				t.Fatalf("expected error %q, got none", tc.err)
+			}
+
+			if got != tc.want || reason != tc.wantReason {
+				t.Errorf("unexpected desired replicas: want %d by %s, got %d by %s", tc.want, tc.wantReason, got, reason)
+			}
+		})
+	}
+}
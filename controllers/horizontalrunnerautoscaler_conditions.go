@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ScalingActiveConditionType is the type of the condition set to HorizontalRunnerAutoscaler
+	// on each computation of the desired replicas. It's False while the desired replicas can't be computed.
+	ScalingActiveConditionType = "ScalingActive"
+
+	// AbleToScaleConditionType is the type of the condition set to HorizontalRunnerAutoscaler
+	// on each update of the scale target. It's False while the scale target can't be updated.
+	AbleToScaleConditionType = "AbleToScale"
+
+	// LimitedByMaxReplicasConditionType is the type of the condition set to HorizontalRunnerAutoscaler
+	// that is True while the desired replicas is pinned at maxReplicas.
+	LimitedByMaxReplicasConditionType = "LimitedByMaxReplicas"
+
+	// GitHubDegradedConditionType is the type of the condition set to HorizontalRunnerAutoscaler
+	// when the GitHub status poller is enabled. It's True while GitHub Actions is degraded and scale down is frozen.
+	GitHubDegradedConditionType = "GitHubDegraded"
+
+	conditionReasonFailedComputeReplicas      = "FailedComputeReplicas"
+	conditionReasonCredentialsUnavailable     = "GitHubAPICredentialsUnavailable"
+	conditionReasonRateLimited                = "GitHubAPIRateLimited"
+	conditionReasonSucceededUpdateScaleTarget = "SucceededUpdateScaleTarget"
+	conditionReasonFailedUpdateScaleTarget    = "FailedUpdateScaleTarget"
+	conditionReasonBelowMaxReplicas           = "BelowMaxReplicas"
+	conditionReasonGitHubIncident             = "GitHubIncident"
+	conditionReasonGitHubOperational          = "GitHubOperational"
+)
+
+// scalingConditions returns the conditions of a successful reconciliation of the HRA
+// that set the desired replicas of the scale target to desiredReplicas for the reason.
+func scalingConditions(hra v1alpha1.HorizontalRunnerAutoscaler, desiredReplicas int, reason string) []metav1.Condition {
+	conds := []metav1.Condition{
+		{
+			Type:    ScalingActiveConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: fmt.Sprintf("The desired replicas is determined by %s", reason),
+		},
+		{
+			Type:    AbleToScaleConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  conditionReasonSucceededUpdateScaleTarget,
+			Message: fmt.Sprintf("The scale target is updated to %d replicas", desiredReplicas),
+		},
+	}
+
+	if isAtMaxReplicas(hra.Spec.MaxReplicas, desiredReplicas) {
+		conds = append(conds, metav1.Condition{
+			Type:    LimitedByMaxReplicasConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  ScalingReasonMaxReplicas,
+			Message: fmt.Sprintf("The desired replicas is pinned at maxReplicas %d", *hra.Spec.MaxReplicas),
+		})
+	} else {
+		conds = append(conds, metav1.Condition{
+			Type:    LimitedByMaxReplicasConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonBelowMaxReplicas,
+			Message: "The desired replicas is below maxReplicas",
+		})
+	}
+
+	return conds
+}
+
+// githubDegradedCondition returns the GitHubDegraded condition for the last status of GitHub.
+func githubDegradedCondition(status githubStatus) metav1.Condition {
+	if status.degraded {
+		return metav1.Condition{
+			Type:    GitHubDegradedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  conditionReasonGitHubIncident,
+			Message: fmt.Sprintf("Scale down is frozen while GitHub is degraded: %s", status.message),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    GitHubDegradedConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonGitHubOperational,
+		Message: "GitHub is operational",
+	}
+}
+
+// setConditions sets the conditions to the status, keeping the last transition times of the conditions whose status is unchanged.
+func setConditions(hra v1alpha1.HorizontalRunnerAutoscaler, status *v1alpha1.HorizontalRunnerAutoscalerStatus, conds ...metav1.Condition) {
+	for _, c := range conds {
+		c.ObservedGeneration = hra.Generation
+		meta.SetStatusCondition(&status.Conditions, c)
+	}
+}
+
+// patchFailureCondition records why the reconciliation failed in the status of the HRA,
+// so that it's seen in `kubectl describe hra` along with the event.
+func (r *HorizontalRunnerAutoscalerReconciler) patchFailureCondition(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, condType, reason string, err error) error {
+	updated := hra.DeepCopy()
+
+	setConditions(hra, &updated.Status, metav1.Condition{
+		Type:    condType,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	})
+
+	if reflect.DeepEqual(hra.Status, updated.Status) {
+		return nil
+	}
+
+	return r.Status().Patch(ctx, updated, client.MergeFrom(&hra))
+}
+
+// recordScalingDecision emits an event explaining the change of the desired replicas, if any.
+func (r *HorizontalRunnerAutoscalerReconciler) recordScalingDecision(hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, from, to int, reason string) {
+	if from == to {
+		return
+	}
+
+	eventReason := "ScaledUp"
+	if to < from {
+		eventReason = "ScaledDown"
+	}
+
+	r.Recorder.Event(&hra, corev1.EventTypeNormal, eventReason, describeScalingDecision(st, from, to, reason))
+}
+
+// describeScalingDecision returns the human-readable explanation of the change of the desired replicas,
+// including the observations of the metrics made on the reconciliation, like
+// "Scaled from 3 to 5 replicas by TotalNumberOfQueuedAndInProgressWorkflowRuns: 4 queued and 1 in-progress workflow jobs matching labels [custom]".
+func describeScalingDecision(st scaleTarget, from, to int, reason string) string {
+	msg := fmt.Sprintf("Scaled from %d to %d replicas by %s", from, to, reason)
+
+	var details []string
+
+	if obs := st.observation; obs != nil {
+		if obs.QueuedWorkflowJobs != nil && obs.InProgressWorkflowJobs != nil {
+			details = append(details, fmt.Sprintf("%d queued and %d in-progress workflow jobs", *obs.QueuedWorkflowJobs, *obs.InProgressWorkflowJobs))
+		}
+
+		if obs.BusyRunners != nil {
+			details = append(details, fmt.Sprintf("%d busy runners", *obs.BusyRunners))
+		}
+	}
+
+	if len(details) == 0 {
+		return msg
+	}
+
+	msg += ": " + strings.Join(details, ", ")
+
+	if len(st.labels) > 0 {
+		msg += fmt.Sprintf(" matching labels %v", st.labels)
+	}
+
+	return msg
+}
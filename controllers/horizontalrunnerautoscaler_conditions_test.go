@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScalingConditions(t *testing.T) {
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+	}
+
+	testcases := []struct {
+		description string
+		desired     int
+		reason      string
+		wantLimited metav1.ConditionStatus
+	}{
+		{description: "below max", desired: 3, reason: "PercentageRunnersBusy", wantLimited: metav1.ConditionFalse},
+		{description: "at max", desired: 5, reason: ScalingReasonMaxReplicas, wantLimited: metav1.ConditionTrue},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var status v1alpha1.HorizontalRunnerAutoscalerStatus
+
+			setConditions(hra, &status, scalingConditions(hra, tc.desired, tc.reason)...)
+
+			active := meta.FindStatusCondition(status.Conditions, ScalingActiveConditionType)
+			if active == nil || active.Status != metav1.ConditionTrue || active.Reason != tc.reason || active.ObservedGeneration != 2 {
+				t.Errorf("unexpected ScalingActive condition: %+v", active)
+			}
+
+			able := meta.FindStatusCondition(status.Conditions, AbleToScaleConditionType)
+			if able == nil || able.Status != metav1.ConditionTrue {
+				t.Errorf("unexpected AbleToScale condition: %+v", able)
+			}
+
+			limited := meta.FindStatusCondition(status.Conditions, LimitedByMaxReplicasConditionType)
+			if limited == nil || limited.Status != tc.wantLimited {
+				t.Errorf("unexpected LimitedByMaxReplicas condition: %+v", limited)
+			}
+		})
+	}
+}
+
+func TestPatchFailureCondition(t *testing.T) {
+	ctx := context.Background()
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+
+	setConditions(*hra, &hra.Status, scalingConditions(*hra, 1, ScalingReasonMinReplicas)...)
+
+	c := clientfake.NewFakeClientWithScheme(sc, hra)
+
+	r := &HorizontalRunnerAutoscalerReconciler{Client: c}
+
+	if err := r.patchFailureCondition(ctx, *hra, AbleToScaleConditionType, conditionReasonFailedUpdateScaleTarget, errors.New("conflict")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got v1alpha1.HorizontalRunnerAutoscaler
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	able := meta.FindStatusCondition(got.Status.Conditions, AbleToScaleConditionType)
+	if able == nil || able.Status != metav1.ConditionFalse || able.Reason != conditionReasonFailedUpdateScaleTarget || able.Message != "conflict" {
+		t.Errorf("unexpected AbleToScale condition: %+v", able)
+	}
+
+	if active := meta.FindStatusCondition(got.Status.Conditions, ScalingActiveConditionType); active == nil || active.Status != metav1.ConditionTrue {
+		t.Errorf("unexpected ScalingActive condition: %+v", active)
+	}
+}
+
+func TestRecordScalingDecision(t *testing.T) {
+	queued, inProgress, busy := 4, 1, 2
+
+	testcases := []struct {
+		description string
+		st          scaleTarget
+		from, to    int
+		reason      string
+		want        string
+	}{
+		{
+			description: "unchanged",
+			from:        3,
+			to:          3,
+		},
+		{
+			description: "scaled up by workflow jobs",
+			st: scaleTarget{
+				labels:      []string{"custom"},
+				observation: &metrics.HorizontalRunnerAutoscalerObservation{QueuedWorkflowJobs: &queued, InProgressWorkflowJobs: &inProgress},
+			},
+			from:   3,
+			to:     5,
+			reason: "TotalNumberOfQueuedAndInProgressWorkflowRuns",
+			want:   "Normal ScaledUp Scaled from 3 to 5 replicas by TotalNumberOfQueuedAndInProgressWorkflowRuns: 4 queued and 1 in-progress workflow jobs matching labels [custom]",
+		},
+		{
+			description: "scaled down by busy runners",
+			st: scaleTarget{
+				observation: &metrics.HorizontalRunnerAutoscalerObservation{BusyRunners: &busy},
+			},
+			from:   5,
+			to:     3,
+			reason: "PercentageRunnersBusy",
+			want:   "Normal ScaledDown Scaled from 5 to 3 replicas by PercentageRunnersBusy: 2 busy runners",
+		},
+		{
+			description: "scaled without observations",
+			from:        0,
+			to:          1,
+			reason:      ScalingReasonMinReplicas,
+			want:        "Normal ScaledUp Scaled from 0 to 1 replicas by MinReplicas",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+
+			r := &HorizontalRunnerAutoscalerReconciler{Recorder: recorder}
+
+			r.recordScalingDecision(v1alpha1.HorizontalRunnerAutoscaler{}, tc.st, tc.from, tc.to, tc.reason)
+
+			close(recorder.Events)
+
+			var got string
+			for e := range recorder.Events {
+				got = e
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected event: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -19,7 +19,9 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"io"
 	"reflect"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -31,13 +33,17 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/metricprovider"
 )
 
 const (
@@ -54,6 +60,125 @@ type HorizontalRunnerAutoscalerReconciler struct {
 	CacheDuration         time.Duration
 	DefaultScaleDownDelay time.Duration
 	Name                  string
+
+	// ScaleFromZeroPollInterval is the interval at which the HRA whose scale target is scaled to zero is reconciled
+	// to detect newly queued workflow jobs, when it's shorter than the sync period. Zero disables it.
+	ScaleFromZeroPollInterval time.Duration
+
+	// GitHubAPIMaxPagesPerReconcile is the number of the additional pages of the workflow runs, jobs and runners
+	// listed from the GitHub API per reconciliation of an HRA, beyond the first page of each list. Zero means unlimited.
+	GitHubAPIMaxPagesPerReconcile int
+
+	// GitHubAPIRateLimitThreshold is the number of remaining GitHub API requests below which the HRA stops polling
+	// the GitHub API until the rate limit window resets. Zero disables it.
+	GitHubAPIRateLimitThreshold int
+
+	// GitHubAPIRetries is the number of times the GitHub API calls made to compute the desired replicas are retried
+	// on transient errors like 5xx responses, waiting GitHubAPIRetryBaseDelay before the first retry and doubling it on each retry.
+	// Zero disables the retries.
+	GitHubAPIRetries        int
+	GitHubAPIRetryBaseDelay time.Duration
+
+	// GitHubAPICircuitBreakerThreshold is the number of consecutive reconciliations failing with transient GitHub API errors
+	// after which the HRA stops calling the GitHub API for GitHubAPICircuitBreakerCooldown.
+	// The desired replicas are kept as is while the GitHub API is failing regardless of it. Zero disables the circuit breaker.
+	GitHubAPICircuitBreakerThreshold int
+	GitHubAPICircuitBreakerCooldown  time.Duration
+
+	// DisableRunLevelAutoscaling makes the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count workflow jobs only,
+	// never counting a workflow run whose jobs are unavailable as a single job,
+	// and rejects the HRAs that scale up on the run-level checkRun, pullRequest and push webhook events.
+	DisableRunLevelAutoscaling bool
+
+	// DisableJobLevelAutoscaling makes the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count each workflow run as a single job
+	// without listing its jobs, which saves the GitHub API calls at the cost of accuracy.
+	// The workflowJob webhook events are still handled per job.
+	DisableJobLevelAutoscaling bool
+
+	// WorkflowJobCacheTTL is the max age of the jobs of the queued and in-progress workflow runs cached for job-level autoscaling.
+	// The jobs of a run are refetched earlier when the status or the update time of the run changes. Zero disables the cache.
+	WorkflowJobCacheTTL time.Duration
+
+	// RunnerLabelAliases maps the old runner labels to the new ones, which are considered the same on matching the workflow jobs to the runners.
+	RunnerLabelAliases RunnerLabelAliases
+
+	// RepositoryFetchConcurrency is the number of the repositories whose workflow runs are fetched in parallel
+	// to count the workflow jobs of a metric. Zero uses DefaultRepositoryFetchConcurrency, and one fetches them serially.
+	RepositoryFetchConcurrency int
+
+	// GitHubStatus tells whether GitHub Actions is degraded, during which the desired replicas are kept from decreasing.
+	// Nil disables it.
+	GitHubStatus *GitHubStatusPoller
+
+	// DrainMode keeps the desired replicas from increasing, while they can still decrease.
+	DrainMode bool
+
+	// RunnerStatusMaxAge is the age within which the runner statuses synced by RunnerStatusSyncer are used
+	// to count the registered and busy runners of RunnerDeployments instead of listing the runners via the GitHub API.
+	// Zero always lists the runners via the GitHub API.
+	RunnerStatusMaxAge time.Duration
+
+	// GitHubClients holds the GitHub clients for the HRAs and scale targets that set githubAPICredentialsFrom.
+	// The HRAs that don't set it use GitHubClient.
+	GitHubClients *MultiGitHubClient
+
+	// MetricProviders is the set of metric providers that can be referenced
+	// by name from the External metric type.
+	MetricProviders map[string]metricprovider.Provider
+
+	// ScaleAlgorithms is the set of scale algorithms that suggest the desired replicas, keyed by the metric types they are used for.
+	// Nil uses the algorithms registered by autoscaling.RegisterScaleAlgorithm.
+	ScaleAlgorithms map[string]autoscaling.ScaleAlgorithm
+
+	// Federation enables delegating the shortfall replicas to the federation members of the HRAs. This is experimental.
+	Federation bool
+
+	// NewFederationClient creates the clients of the federation member clusters from their kubeconfigs.
+	// Nil creates them with Scheme.
+	NewFederationClient FederationClientFunc
+
+	// federationClients caches the clients of the federation member clusters.
+	federationClients federationClientCache
+
+	// workflowDefinitions caches the workflow definitions read for concurrency-aware scaling.
+	workflowDefinitions workflowDefinitionCache
+
+	// workflowPaths caches the workflow paths read for filtering workflow runs by workflows.
+	workflowPaths workflowPathCache
+
+	// workflowJobs caches the jobs of the workflow runs read for job-level autoscaling.
+	workflowJobs workflowJobCache
+
+	// runnerGroupVisibilities caches whether the runner groups of the scale targets are visible to the repositories.
+	runnerGroupVisibilities runnerGroupVisibilityCache
+
+	// organizationRepositories caches the repositories of the organizations listed for the repository selectors of the metrics.
+	organizationRepositories organizationRepositoryCache
+
+	// ErrorBudget stops retrying the horizontalrunnerautoscalers whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
+
+	// Shard is the share of the HRAs reconciled by the controller. Nil reconciles all the HRAs.
+	Shard *Shard
+
+	// ScalingDecisionLog receives each change of the desired replicas as a line of JSON, if not nil. See ScalingDecisionLogEntry.
+	ScalingDecisionLog io.Writer
+
+	scalingDecisionLogMu sync.Mutex
+
+	// Clock tells the current time the HRAs are reconciled at, which the scale down delay, the scheduled overrides
+	// and the capacity reservations are evaluated against. Nil uses the real clock.
+	Clock clock.PassiveClock
+
+	syncSchedule hraSyncSchedule
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock.Now()
+	}
+
+	return time.Now()
 }
 
 const defaultReplicas = 1
@@ -72,10 +197,23 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// The HRA requeued before its labels changed can have been reassigned to another shard.
+	if !r.Shard.Owns(&hra) {
+		log.V(2).Info("Skipped reconciling the HRA assigned to another shard")
+
+		return ctrl.Result{}, nil
+	}
+
 	if !hra.ObjectMeta.DeletionTimestamp.IsZero() {
 		return ctrl.Result{}, nil
 	}
 
+	if wait, ok := r.syncSchedule.wait(hra, r.now()); ok {
+		log.V(2).Info("Skipped reconciling the HRA until the next sync of its syncPeriod", "after", wait)
+
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	metrics.SetHorizontalRunnerAutoscalerSpec(hra.ObjectMeta, hra.Spec)
 
 	kind := hra.Spec.ScaleTargetRef.Kind
@@ -103,11 +241,19 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 
 			var effectiveTime *time.Time
 
+			now := r.now()
+
+			var reserved int
+
 			for _, r := range hra.Spec.CapacityReservations {
 				t := r.EffectiveTime
 				if effectiveTime == nil || effectiveTime.Before(t.Time) {
 					effectiveTime = &t.Time
 				}
+
+				if r.ExpirationTime.Time.After(now) {
+					reserved += r.Replicas
+				}
 			}
 
 			// Please add more conditions that we can in-place update the newest runnerreplicaset without disruption
@@ -119,6 +265,9 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 					copy.Spec.EffectiveTime = &metav1.Time{Time: *effectiveTime}
 				}
 
+				setBurstReplicas(copy, reserved, newDesiredReplicas)
+				setSizeReplicas(copy, hra.Spec.CapacityReservations, now, newDesiredReplicas)
+
 				if err := r.Client.Patch(ctx, copy, client.MergeFrom(&rd)); err != nil {
 					return fmt.Errorf("patching runnerdeployment to have %d replicas: %w", newDesiredReplicas, err)
 				}
@@ -126,9 +275,23 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 				copy := rd.DeepCopy()
 				copy.Spec.EffectiveTime = &metav1.Time{Time: *effectiveTime}
 
+				setBurstReplicas(copy, reserved, newDesiredReplicas)
+				setSizeReplicas(copy, hra.Spec.CapacityReservations, now, newDesiredReplicas)
+
 				if err := r.Client.Patch(ctx, copy, client.MergeFrom(&rd)); err != nil {
 					return fmt.Errorf("patching runnerdeployment to have %d replicas: %w", newDesiredReplicas, err)
 				}
+			} else {
+				copy := rd.DeepCopy()
+
+				burstChanged := setBurstReplicas(copy, reserved, newDesiredReplicas)
+				sizesChanged := setSizeReplicas(copy, hra.Spec.CapacityReservations, now, newDesiredReplicas)
+
+				if burstChanged || sizesChanged {
+					if err := r.Client.Patch(ctx, copy, client.MergeFrom(&rd)); err != nil {
+						return fmt.Errorf("patching runnerdeployment to have %d burst replicas and %v size replicas: %w", getIntOrDefault(copy.Spec.BurstReplicas, 0), copy.Spec.SizeReplicas, err)
+					}
+				}
 			}
 			return nil
 		})
@@ -152,47 +315,62 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 			replicas = &v
 		}
 
-		st := scaleTarget{
-			st:         rs.Name,
-			kind:       "runnerset",
-			enterprise: rs.Spec.Enterprise,
-			org:        rs.Spec.Organization,
-			repo:       rs.Spec.Repository,
-			replicas:   replicas,
-			labels:     rs.Spec.RunnerConfig.Labels,
-			getRunnerMap: func() (map[string]struct{}, error) {
-				// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
-				var runnerPodList corev1.PodList
-
-				var opts []client.ListOption
-
-				opts = append(opts, client.InNamespace(rs.Namespace))
-
-				selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
-				if err != nil {
+		listRunnerPods := func() ([]corev1.Pod, error) {
+			// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
+			var runnerPodList corev1.PodList
+
+			var opts []client.ListOption
+
+			opts = append(opts, client.InNamespace(rs.Namespace))
+
+			selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+
+			r.Log.V(2).Info("Finding runnerset's runner pods with selector", "ns", rs.Namespace)
+
+			if err := r.List(
+				ctx,
+				&runnerPodList,
+				opts...,
+			); err != nil {
+				if !kerrors.IsNotFound(err) {
 					return nil, err
 				}
+			}
 
-				opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
-
-				r.Log.V(2).Info("Finding runnerset's runner pods with selector", "ns", rs.Namespace)
+			return runnerPodList.Items, nil
+		}
 
-				if err := r.List(
-					ctx,
-					&runnerPodList,
-					opts...,
-				); err != nil {
-					if !kerrors.IsNotFound(err) {
-						return nil, err
-					}
+		st := scaleTarget{
+			st:                       rs.Name,
+			kind:                     "runnerset",
+			enterprise:               rs.Spec.Enterprise,
+			org:                      rs.Spec.Organization,
+			repo:                     rs.Spec.Repository,
+			replicas:                 replicas,
+			labels:                   rs.Spec.RunnerConfig.Labels,
+			platformLabels:           runnerPlatformLabels(rs.Spec.RunnerConfig, rs.Spec.Template.Spec.NodeSelector),
+			group:                    rs.Spec.RunnerConfig.Group,
+			githubAPICredentialsFrom: rs.Spec.RunnerConfig.GitHubAPICredentialsFrom,
+			drained:                  poolDrained(&rs),
+			getRunnerMap: func() (map[string]time.Time, error) {
+				pods, err := listRunnerPods()
+				if err != nil {
+					return nil, err
 				}
-				runnerMap := make(map[string]struct{})
-				for _, items := range runnerPodList.Items {
-					runnerMap[items.Name] = struct{}{}
+
+				runnerMap := make(map[string]time.Time)
+				for _, items := range pods {
+					runnerMap[items.Name] = items.CreationTimestamp.Time
 				}
 
 				return runnerMap, nil
 			},
+			listRunnerPods: listRunnerPods,
 		}
 
 		return r.reconcile(ctx, req, log, hra, st, func(newDesiredReplicas int) error {
@@ -245,47 +423,75 @@ func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, re
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) scaleTargetFromRD(ctx context.Context, rd v1alpha1.RunnerDeployment) scaleTarget {
-	st := scaleTarget{
-		st:         rd.Name,
-		kind:       "runnerdeployment",
-		enterprise: rd.Spec.Template.Spec.Enterprise,
-		org:        rd.Spec.Template.Spec.Organization,
-		repo:       rd.Spec.Template.Spec.Repository,
-		replicas:   rd.Spec.Replicas,
-		labels:     rd.Spec.Template.Spec.RunnerConfig.Labels,
-		getRunnerMap: func() (map[string]struct{}, error) {
-			// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
-			var runnerList v1alpha1.RunnerList
+	listRunners := func() ([]v1alpha1.Runner, error) {
+		// return the list of runners in namespace. Horizontal Runner Autoscaler should only be responsible for scaling resources in its own ns.
+		var runnerList v1alpha1.RunnerList
 
-			var opts []client.ListOption
+		var opts []client.ListOption
 
-			opts = append(opts, client.InNamespace(rd.Namespace))
+		opts = append(opts, client.InNamespace(rd.Namespace))
 
-			selector, err := metav1.LabelSelectorAsSelector(getSelector(&rd))
-			if err != nil {
+		selector, err := metav1.LabelSelectorAsSelector(getSelector(&rd))
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+
+		r.Log.V(2).Info("Finding runners with selector", "ns", rd.Namespace)
+
+		if err := r.List(
+			ctx,
+			&runnerList,
+			opts...,
+		); err != nil {
+			if !kerrors.IsNotFound(err) {
 				return nil, err
 			}
+		}
 
-			opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+		return runnerList.Items, nil
+	}
 
-			r.Log.V(2).Info("Finding runners with selector", "ns", rd.Namespace)
+	st := scaleTarget{
+		st:                       rd.Name,
+		kind:                     "runnerdeployment",
+		enterprise:               rd.Spec.Template.Spec.Enterprise,
+		org:                      rd.Spec.Template.Spec.Organization,
+		repo:                     rd.Spec.Template.Spec.Repository,
+		replicas:                 rd.Spec.Replicas,
+		labels:                   rd.Spec.Template.Spec.RunnerConfig.Labels,
+		platformLabels:           runnerPlatformLabels(rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.NodeSelector),
+		group:                    rd.RunnerGroupName(),
+		githubAPICredentialsFrom: rd.Spec.Template.Spec.RunnerConfig.GitHubAPICredentialsFrom,
+		drained:                  poolDrained(&rd),
+		getRunnerMap: func() (map[string]time.Time, error) {
+			runners, err := listRunners()
+			if err != nil {
+				return nil, err
+			}
 
-			if err := r.List(
-				ctx,
-				&runnerList,
-				opts...,
-			); err != nil {
-				if !kerrors.IsNotFound(err) {
-					return nil, err
+			runnerMap := make(map[string]time.Time)
+			for _, items := range runners {
+				// Suspended runners of the warm pool never run jobs hence they're not counted as runners to be busy.
+				if isRunnerSuspended(&items) {
+					continue
 				}
-			}
-			runnerMap := make(map[string]struct{})
-			for _, items := range runnerList.Items {
-				runnerMap[items.Name] = struct{}{}
+				runnerMap[items.Name] = items.CreationTimestamp.Time
 			}
 
 			return runnerMap, nil
 		},
+		listRunners: listRunners,
+		listRunnerPods: func() ([]corev1.Pod, error) {
+			var podList corev1.PodList
+
+			if err := r.List(ctx, &podList, client.InNamespace(rd.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: rd.Name}); err != nil {
+				return nil, err
+			}
+
+			return podList.Items, nil
+		},
 	}
 
 	return st
@@ -297,11 +503,95 @@ type scaleTarget struct {
 	replicas              *int
 	labels                []string
 
-	getRunnerMap func() (map[string]struct{}, error)
+	// platformLabels are the operating system and the CPU architecture labels the runners register on their own.
+	// The jobs requesting the other operating systems or architectures aren't counted.
+	platformLabels []string
+
+	// group is the runner group the runners of the scale target are registered to. Empty means the default group.
+	group string
+
+	// getRunnerMap returns the creation times of the runners of the scale target, keyed by the runner names.
+	getRunnerMap func() (map[string]time.Time, error)
+
+	// listRunners returns the Runners of the scale target. It is nil for RunnerSets, which have no Runners.
+	listRunners func() ([]v1alpha1.Runner, error)
+
+	listRunnerPods func() ([]corev1.Pod, error)
+
+	// githubAPICredentialsFrom is the githubAPICredentialsFrom of the runners of the scale target.
+	githubAPICredentialsFrom *v1alpha1.GitHubAPICredentialsFrom
+
+	// drained is true when the scale target is drained via the admin API. See AnnotationKeyDrain.
+	drained bool
+
+	// githubClient is the GitHub client for the credentials of the HRA, set at the beginning of the reconciliation.
+	githubClient *github.Client
+
+	// pageBudget caps the additional pages of the workflow runs, jobs and runners listed in the reconciliation. Nil means unlimited.
+	pageBudget *github.PageBudget
+
+	// demandLimit is the number of the runners demanded by the workflow jobs beyond which counting more jobs can't change the desired replicas,
+	// so that the rest of the repositories and workflow runs are skipped. Zero means no limit.
+	demandLimit int
+
+	// observation collects the numbers observed in the reconciliation to be exported as metrics.
+	observation *metrics.HorizontalRunnerAutoscalerObservation
+
+	// repositoryWorkflowJobs collects the workflow jobs counted by the metrics by repository, keyed by the metric types,
+	// to be reported in the status of the metrics.
+	repositoryWorkflowJobs map[string][]v1alpha1.RepositoryWorkflowJobs
+
+	// metricWorkflowJobs collects the weighted numbers of the workflow jobs counted by the metrics, keyed by the metric types,
+	// to be reported in the status of the metrics.
+	metricWorkflowJobs map[string]workflowJobCounts
+}
+
+// githubClient returns the GitHub client the HRA queries the GitHub API with.
+func (r *HorizontalRunnerAutoscalerReconciler) githubClient(st scaleTarget) *github.Client {
+	if st.githubClient != nil {
+		return st.githubClient
+	}
+
+	return r.GitHubClient
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, req ctrl.Request, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, updatedDesiredReplicas func(int) error) (ctrl.Result, error) {
-	now := time.Now()
+	now := r.now()
+
+	credentialsFrom := hra.Spec.GitHubAPICredentialsFrom
+	if credentialsFrom == nil {
+		credentialsFrom = st.githubAPICredentialsFrom
+	}
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, hra.Namespace, credentialsFrom)
+	if err != nil {
+		r.Recorder.Event(&hra, corev1.EventTypeWarning, "GitHubAPICredentialsUnavailable", err.Error())
+		log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+
+		if err := r.patchFailureCondition(ctx, hra, ScalingActiveConditionType, conditionReasonCredentialsUnavailable, err); err != nil {
+			log.Error(err, "Could not update the ScalingActive condition")
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	st.githubClient = ghc
+	st.pageBudget = github.NewPageBudget(r.GitHubAPIMaxPagesPerReconcile)
+	st.observation = &metrics.HorizontalRunnerAutoscalerObservation{}
+	st.repositoryWorkflowJobs = map[string][]v1alpha1.RepositoryWorkflowJobs{}
+	st.metricWorkflowJobs = map[string]workflowJobCounts{}
+
+	if rl, ok := ghc.RateLimit(); ok {
+		if backoff := rateLimitBackoff(now, rl, r.GitHubAPIRateLimitThreshold); backoff > 0 {
+			return r.deferForRateLimit(ctx, log, hra, backoff, fmt.Errorf("%d GitHub API requests remaining, below the threshold of %d", rl.Remaining, r.GitHubAPIRateLimitThreshold))
+		}
+	}
+
+	if until := githubAPICircuitOpenUntil(now, hra); until != nil {
+		log.V(1).Info("Skipped computing the desired replicas while the circuit breaker for the GitHub API errors is open", "until", until)
+
+		return ctrl.Result{RequeueAfter: until.Sub(now)}, nil
+	}
 
 	minReplicas, active, upcoming, err := r.getMinReplicas(log, now, hra)
 	if err != nil {
@@ -310,31 +600,348 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		return ctrl.Result{}, err
 	}
 
-	newDesiredReplicas, err := r.computeReplicasWithCache(log, now, st, hra, minReplicas)
+	scheduledOverrides := simulateScheduledOverrides(now, hra)
+
+	r.recordScheduledOverrideWarnings(hra, scheduledOverrides)
+
+	// The rest of the reconciliation reads maxReplicas from the spec,
+	// so the overridden value is set to this copy of the HRA, which is never written back.
+	hra.Spec.MaxReplicas = getMaxReplicas(hra, active)
+
+	capacity, err := r.getOverflowCapacity(ctx, hra)
+	if err != nil {
+		log.Error(err, "Could not compute the capacity lent and borrowed on overflow")
+
+		return ctrl.Result{}, err
+	}
+
+	if capacity != nil {
+		// The replicas lent to the other HRAs are taken from maxReplicas, and the borrowable replicas are added to it.
+		maxReplicas := capacity.maxReplicas + capacity.borrowable
+		hra.Spec.MaxReplicas = &maxReplicas
+	}
+
+	newDesiredReplicas, reason, recommendations, err := r.computeReplicasWithCache(ctx, log, now, st, hra, minReplicas)
 	if err != nil {
+		if backoff, ok := rateLimitErrorBackoff(now, err); ok {
+			return r.deferForRateLimit(ctx, log, hra, backoff, err)
+		}
+
+		if isTransientGitHubAPIError(now, err) {
+			return r.deferForGitHubAPIError(ctx, log, now, hra, err)
+		}
+
 		r.Recorder.Event(&hra, corev1.EventTypeNormal, "RunnerAutoscalingFailure", err.Error())
 
 		log.Error(err, "Could not compute replicas")
 
+		if err := r.patchFailureCondition(ctx, hra, ScalingActiveConditionType, conditionReasonFailedComputeReplicas, err); err != nil {
+			log.Error(err, "Could not update the ScalingActive condition")
+		}
+
 		return ctrl.Result{}, err
 	}
 
-	if err := updatedDesiredReplicas(newDesiredReplicas); err != nil {
+	if st.pageBudget.Truncated() {
+		msg := fmt.Sprintf("Listed only the first pages of the workflow runs, jobs or runners within the budget of %d pages per reconciliation, so the demand may be undercounted", r.GitHubAPIMaxPagesPerReconcile)
+
+		log.Info(msg)
+
+		r.Recorder.Event(&hra, corev1.EventTypeWarning, "GitHubAPIPageBudgetExhausted", msg)
+	}
+
+	// The rest of the replicas are added on the reconciliation after ScaleUpPeriodSeconds.
+	scaleUpLimited := reason == ScalingReasonScaleUpMaxStep
+
+	unschedulable, err := countUnschedulableRunnerPods(st)
+	if err != nil {
+		log.Error(err, "Could not count unschedulable runner pods")
+
 		return ctrl.Result{}, err
 	}
 
+	currentDesiredReplicas := getIntOrDefault(st.replicas, defaultReplicas)
+
+	if clamped, ok := clampScaleUpOnUnschedulable(hra, currentDesiredReplicas, newDesiredReplicas, minReplicas, unschedulable); ok {
+		log.Info(
+			"Pausing scale up while there are too many unschedulable runner pods",
+			"desired", newDesiredReplicas,
+			"clamped", clamped,
+			"unschedulable", unschedulable,
+			"max_unschedulable", *hra.Spec.MaxUnschedulableReplicas,
+		)
+
+		newDesiredReplicas = clamped
+		reason = ScalingReasonMaxUnschedulableReplicas
+	}
+
+	if (r.DrainMode || st.drained) && newDesiredReplicas > currentDesiredReplicas {
+		log.V(1).Info("Skipped scaling up in drain mode", "desired", newDesiredReplicas, "current", currentDesiredReplicas)
+
+		newDesiredReplicas = currentDesiredReplicas
+		reason = ScalingReasonDrainMode
+	}
+
+	githubStatus, githubStatusPolled := r.GitHubStatus.getStatus()
+
+	if githubStatus.degraded && newDesiredReplicas < currentDesiredReplicas {
+		log.Info("Freezing scale down while GitHub is degraded", "desired", newDesiredReplicas, "current", currentDesiredReplicas, "status", githubStatus.message)
+
+		newDesiredReplicas = currentDesiredReplicas
+		reason = ScalingReasonGitHubDegraded
+	}
+
+	degradedDependencies := r.checkDependencies(ctx, now, hra)
+
+	if maxReplicas := getDependencyMaxReplicas(hra, degradedDependencies); maxReplicas != nil && newDesiredReplicas > *maxReplicas {
+		log.Info("Capping desired replicas while dependencies are degraded", "desired", newDesiredReplicas, "capped", *maxReplicas, "degraded", degradedDependencies)
+
+		newDesiredReplicas = *maxReplicas
+		reason = ScalingReasonDependencyDegraded
+	}
+
+	r.recordDependencyTransitions(hra, degradedDependencies)
+
+	var pickupReservations []v1alpha1.PickupReservation
+
+	if timeout := hra.Spec.PickupConfirmationTimeoutSeconds; timeout != nil {
+		reservations, held, err := r.confirmJobPickups(ctx, now, st, hra)
+		if err != nil {
+			log.Error(err, "Could not confirm job pickups")
+
+			return ctrl.Result{}, err
+		}
+
+		pickupReservations = reservations
+
+		if current := hra.Status.DesiredReplicas; current != nil && newDesiredReplicas < *current && newDesiredReplicas < held {
+			kept := held
+			if kept > *current {
+				kept = *current
+			}
+
+			if maxReplicas := hra.Spec.MaxReplicas; maxReplicas != nil && kept > *maxReplicas {
+				kept = *maxReplicas
+			}
+
+			if kept > newDesiredReplicas {
+				log.V(1).Info("Delaying scale down until job pickup is confirmed", "desired", newDesiredReplicas, "kept", kept)
+
+				newDesiredReplicas = kept
+				reason = ScalingReasonPickupConfirmation
+			}
+		}
+
+		if added := newDesiredReplicas - currentDesiredReplicas; added > 0 && hasQueuedAndInProgressWorkflowRunsMetric(hra) && !isRecommendOnly(hra) {
+			pickupReservations = append(pickupReservations, newPickupReservation(now, added, time.Duration(*timeout)*time.Second))
+		}
+	}
+
+	requestedReplicas := newDesiredReplicas
+
+	grantedReplicas, err := r.grantReplicas(ctx, hra, st, requestedReplicas)
+	if err != nil {
+		log.Error(err, "Could not allocate runner budgets")
+
+		return ctrl.Result{}, err
+	}
+
+	if grantedReplicas != nil && newDesiredReplicas > *grantedReplicas {
+		log.V(1).Info("Limiting desired replicas to the share of runner budgets", "requested", requestedReplicas, "granted", *grantedReplicas)
+
+		newDesiredReplicas = *grantedReplicas
+		reason = ScalingReasonRunnerBudget
+	}
+
+	// No matter how minReplicas, maxReplicas and the metrics are configured, scaling down must not remove the runners running jobs,
+	// unless it's explicitly forced.
+	if newDesiredReplicas < currentDesiredReplicas && isForceScaleDown(hra) {
+		log.Info("Scaling down regardless of busy runners, as it's forced", "desired", newDesiredReplicas, "current", currentDesiredReplicas)
+	} else if newDesiredReplicas < currentDesiredReplicas {
+		busy, err := r.countBusyRunners(ctx, st)
+		if err != nil {
+			log.Error(err, "Could not count busy runners")
+
+			return ctrl.Result{}, err
+		}
+
+		if floor, ok := busyRunnersFloor(currentDesiredReplicas, newDesiredReplicas, busy); ok {
+			log.Info("Keeping desired replicas at or above the number of busy runners", "desired", newDesiredReplicas, "kept", floor, "busy", busy)
+
+			newDesiredReplicas = floor
+			reason = ScalingReasonBusyRunners
+		}
+	}
+
+	recommendedReplicas := newDesiredReplicas
+
+	if isRecommendOnly(hra) {
+		log.V(1).Info("Skipped updating the scale target as the policy is RecommendOnly", "recommended", recommendedReplicas, "current", currentDesiredReplicas)
+
+		// The rest of the reconciliation records the replicas of the scale target as the desired replicas,
+		// so that e.g. the runner budgets and the overflow are computed from the runners that actually exist.
+		newDesiredReplicas = currentDesiredReplicas
+	} else if err := updatedDesiredReplicas(newDesiredReplicas); err != nil {
+		if err := r.patchFailureCondition(ctx, hra, AbleToScaleConditionType, conditionReasonFailedUpdateScaleTarget, err); err != nil {
+			log.Error(err, "Could not update the AbleToScale condition")
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	var ovf *overflow
+	if capacity != nil && st.observation.DemandedReplicas != nil {
+		o := computeOverflow(*capacity, *st.observation.DemandedReplicas, newDesiredReplicas)
+		ovf = &o
+
+		st.observation.ShortfallReplicas = o.shortfall
+		st.observation.BorrowedReplicas = o.borrowed
+	}
+
+	var delegated []v1alpha1.DelegatedReplicas
+	if ovf != nil && hra.Spec.Overflow != nil && len(hra.Spec.Overflow.Federation) > 0 {
+		if isRecommendOnly(hra) {
+			log.V(1).Info("Skipped delegating the shortfall replicas to the federation members, as the policy is RecommendOnly")
+		} else if r.Federation {
+			delegated = r.delegateShortfall(ctx, log, now, hra, ovf.shortfall)
+		} else {
+			log.V(1).Info("Skipped delegating the shortfall replicas to the federation members, as federation is disabled")
+		}
+	}
+
+	st.observation.AtMaxReplicas = isAtMaxReplicas(hra.Spec.MaxReplicas, newDesiredReplicas)
+	metrics.SetHorizontalRunnerAutoscalerObservation(hra.ObjectMeta, *st.observation)
+
 	updated := hra.DeepCopy()
 
+	updated.Status.PickupReservations = pickupReservations
+	updated.Status.ScaleDownRecommendations = recommendations
+	updated.Status.UnschedulableReplicas = &unschedulable
+	updated.Status.DegradedDependencies = degradedDependencies
+
+	reserved, nextExpiration := autoscaling.ReservedReplicas(now, hra.Spec.CapacityReservations)
+
+	if nextExpiration != nil {
+		updated.Status.ReservedReplicas = &reserved
+		updated.Status.CapacityReservationsExpirationTime = &metav1.Time{Time: *nextExpiration}
+	} else {
+		updated.Status.ReservedReplicas = nil
+		updated.Status.CapacityReservationsExpirationTime = nil
+	}
+
+	updated.Status.CapacityReservationRunners = r.capacityReservationRunners(ctx, log, now, hra)
+
+	if hra.Status.LastAPIError != nil {
+		log.Info("The GitHub API recovered from the transient errors", "consecutiveFailures", hra.Status.LastAPIError.ConsecutiveFailures)
+
+		updated.Status.LastAPIError = nil
+	}
+
+	if grantedReplicas != nil {
+		updated.Status.RequestedReplicas = &requestedReplicas
+		updated.Status.GrantedReplicas = grantedReplicas
+	} else {
+		updated.Status.RequestedReplicas = nil
+		updated.Status.GrantedReplicas = nil
+	}
+
+	if ovf != nil {
+		updated.Status.ShortfallReplicas = &ovf.shortfall
+	} else {
+		updated.Status.ShortfallReplicas = nil
+	}
+
+	if ovf != nil && ovf.borrowed > 0 {
+		updated.Status.BorrowedReplicas = &ovf.borrowed
+	} else {
+		updated.Status.BorrowedReplicas = nil
+	}
+
+	updated.Status.DelegatedReplicas = delegated
+
+	if isRecommendOnly(hra) {
+		updated.Status.RecommendedReplicas = &recommendedReplicas
+	} else {
+		updated.Status.RecommendedReplicas = nil
+	}
+
 	if hra.Status.DesiredReplicas == nil || *hra.Status.DesiredReplicas != newDesiredReplicas {
 		if (hra.Status.DesiredReplicas == nil && newDesiredReplicas > 1) ||
 			(hra.Status.DesiredReplicas != nil && newDesiredReplicas > *hra.Status.DesiredReplicas) {
 
-			updated.Status.LastSuccessfulScaleOutTime = &metav1.Time{Time: time.Now()}
+			updated.Status.LastSuccessfulScaleOutTime = &metav1.Time{Time: now}
 		}
 
 		updated.Status.DesiredReplicas = &newDesiredReplicas
 	}
 
+	previousDesiredReplicas := currentDesiredReplicas
+	if hra.Status.DesiredReplicas != nil {
+		previousDesiredReplicas = *hra.Status.DesiredReplicas
+	}
+
+	observed := scalingObservation(st.observation)
+
+	if isRecommendOnly(hra) {
+		// The changes of the replicas of the scale target aren't decided by this HRA, so they aren't recorded as its scaling decisions.
+		updated.Status.ScalingHistory = appendScalingDecision(hra, now, previousDesiredReplicas, previousDesiredReplicas, reason, observed)
+		setConditions(hra, &updated.Status, scalingConditions(hra, recommendedReplicas, reason)...)
+		setConditions(hra, &updated.Status, recommendOnlyCondition(newDesiredReplicas, recommendedReplicas))
+	} else {
+		updated.Status.ScalingHistory = appendScalingDecision(hra, now, previousDesiredReplicas, newDesiredReplicas, reason, observed)
+		r.logScalingDecision(hra, now, previousDesiredReplicas, newDesiredReplicas, reason, observed)
+		setConditions(hra, &updated.Status, scalingConditions(hra, newDesiredReplicas, reason)...)
+	}
+
+	if githubStatusPolled {
+		setConditions(hra, &updated.Status, githubDegradedCondition(githubStatus))
+	}
+
+	if ovf != nil {
+		saturated := capacitySaturatedCondition(hra, *ovf)
+
+		setConditions(hra, &updated.Status, saturated)
+
+		r.notifyOverflowTransition(ctx, log, hra, capacity, *ovf, saturated)
+	}
+
+	var publishAfter time.Duration
+
+	if ovf != nil && hra.Spec.Overflow != nil && len(hra.Spec.Overflow.Sinks) > 0 && !isRecommendOnly(hra) {
+		var shortfall int
+
+		shortfall, publishAfter = overflowShortfallToPublish(now, hra, updated.Status.Conditions, *ovf)
+
+		n := OverflowNotification{
+			Namespace:                  hra.Namespace,
+			HorizontalRunnerAutoscaler: hra.Name,
+			Saturated:                  shortfall > 0,
+			DemandedReplicas:           ovf.demanded,
+			MaxReplicas:                capacity.maxReplicas,
+			BorrowedReplicas:           ovf.borrowed,
+			ShortfallReplicas:          shortfall,
+			Message:                    capacitySaturatedCondition(hra, *ovf).Message,
+			Time:                       now,
+		}
+
+		if r.publishShortfall(ctx, log, now, hra, n) {
+			updated.Status.PublishedShortfallReplicas = &shortfall
+		}
+
+		// The capacity reservations published to the other clusters are renewed before they expire.
+		if shortfall > 0 && publishAfter == 0 {
+			publishAfter = overflowReservationTTL / 2
+		}
+	} else {
+		updated.Status.PublishedShortfallReplicas = nil
+	}
+
+	if isRecommendOnly(hra) {
+		r.recordRecommendation(hra, st, newDesiredReplicas, recommendedReplicas, reason)
+	} else {
+		r.recordScalingDecision(hra, st, previousDesiredReplicas, newDesiredReplicas, reason)
+	}
+
 	var overridesSummary string
 
 	if (active != nil && upcoming == nil) || (active != nil && upcoming != nil && active.Period.EndTime.Before(upcoming.Period.StartTime)) {
@@ -347,11 +954,18 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 	}
 
 	if active == nil && upcoming != nil || (active != nil && upcoming != nil && active.Period.EndTime.After(upcoming.Period.StartTime)) {
-		if upcoming.ScheduledOverride.MinReplicas != nil {
-			overridesSummary = fmt.Sprintf("min=%d time=%s", *upcoming.ScheduledOverride.MinReplicas, upcoming.Period.StartTime)
+		if o := upcoming.ScheduledOverride; o.MinReplicas != nil && o.MaxReplicas != nil {
+			overridesSummary = fmt.Sprintf("min=%d max=%d time=%s", *o.MinReplicas, *o.MaxReplicas, upcoming.Period.StartTime)
+		} else if o.MinReplicas != nil {
+			overridesSummary = fmt.Sprintf("min=%d time=%s", *o.MinReplicas, upcoming.Period.StartTime)
+		} else if o.MaxReplicas != nil {
+			overridesSummary = fmt.Sprintf("max=%d time=%s", *o.MaxReplicas, upcoming.Period.StartTime)
 		}
 	}
 
+	updated.Status.ScheduledOverrides = scheduledOverrides
+	updated.Status.Metrics = metricStatuses(hra.Spec.Metrics, st.repositoryWorkflowJobs, st.metricWorkflowJobs, newDesiredReplicas)
+
 	if overridesSummary != "" {
 		updated.Status.ScheduledOverridesSummary = &overridesSummary
 	} else {
@@ -366,7 +980,41 @@ func (r *HorizontalRunnerAutoscalerReconciler) reconcile(ctx context.Context, re
 		}
 	}
 
-	return ctrl.Result{}, nil
+	requeueAfter := nextScheduledOverrideBoundary(now, active, upcoming)
+
+	if newDesiredReplicas == 0 && r.ScaleFromZeroPollInterval > 0 && (requeueAfter == 0 || r.ScaleFromZeroPollInterval < requeueAfter) {
+		requeueAfter = r.ScaleFromZeroPollInterval
+	}
+
+	if publishAfter > 0 && (requeueAfter == 0 || publishAfter < requeueAfter) {
+		requeueAfter = publishAfter
+	}
+
+	if len(degradedDependencies) > 0 && (requeueAfter == 0 || DependencyHealthCheckInterval < requeueAfter) {
+		requeueAfter = DependencyHealthCheckInterval
+	}
+
+	// Scale down as soon as a capacity reservation expires, rather than on the next sync period.
+	if nextExpiration != nil {
+		if d := nextExpiration.Sub(now); requeueAfter == 0 || d < requeueAfter {
+			requeueAfter = d
+		}
+	}
+
+	if scaleUpLimited {
+		nextScaleUp := autoscaling.ScaleUpPeriod(hra)
+		if last := hra.Status.LastSuccessfulScaleOutTime; last != nil && last.Add(nextScaleUp).After(now) {
+			nextScaleUp = last.Add(nextScaleUp).Sub(now)
+		}
+
+		if nextScaleUp > 0 && (requeueAfter == 0 || nextScaleUp < requeueAfter) {
+			requeueAfter = nextScaleUp
+		}
+	}
+
+	requeueAfter = r.syncSchedule.schedule(hra, now, requeueAfter)
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *HorizontalRunnerAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -378,9 +1026,9 @@ func (r *HorizontalRunnerAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager
 	r.Recorder = mgr.GetEventRecorderFor(name)
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
+		For(&v1alpha1.HorizontalRunnerAutoscaler{}, builder.WithPredicates(r.Shard.Predicate())).
 		Named(name).
-		Complete(r)
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.HorizontalRunnerAutoscaler{} }))
 }
 
 type Override struct {
@@ -400,15 +1048,12 @@ func (r *HorizontalRunnerAutoscalerReconciler) matchScheduledOverrides(log logr.
 			"endTime", o.EndTime,
 			"frequency", o.RecurrenceRule.Frequency,
 			"untilTime", o.RecurrenceRule.UntilTime,
+			"schedule", o.Schedule,
+			"duration", o.Duration.Duration,
+			"timeZone", o.TimeZone,
 		)
 
-		a, u, err := MatchSchedule(
-			now, o.StartTime.Time, o.EndTime.Time,
-			RecurrenceRule{
-				Frequency: o.RecurrenceRule.Frequency,
-				UntilTime: o.RecurrenceRule.UntilTime.Time,
-			},
-		)
+		a, u, err := matchScheduledOverride(now, o)
 		if err != nil {
 			return minReplicas, nil, nil, err
 		}
@@ -420,14 +1065,15 @@ func (r *HorizontalRunnerAutoscalerReconciler) matchScheduledOverrides(log logr.
 
 			if o.MinReplicas != nil {
 				minReplicas = o.MinReplicas
-
-				log.V(1).Info(
-					"Found active scheduled override",
-					"activeStartTime", a.StartTime,
-					"activeEndTime", a.EndTime,
-					"activeMinReplicas", minReplicas,
-				)
 			}
+
+			log.V(1).Info(
+				"Found active scheduled override",
+				"activeStartTime", a.StartTime,
+				"activeEndTime", a.EndTime,
+				"activeMinReplicas", o.MinReplicas,
+				"activeMaxReplicas", o.MaxReplicas,
+			)
 		}
 
 		if u != nil && (upcoming == nil || u.StartTime.Before(upcoming.Period.StartTime)) {
@@ -438,6 +1084,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) matchScheduledOverrides(log logr.
 				"upcomingStartTime", u.StartTime,
 				"upcomingEndTime", u.EndTime,
 				"upcomingMinReplicas", o.MinReplicas,
+				"upcomingMaxReplicas", o.MaxReplicas,
 			)
 		}
 	}
@@ -445,80 +1092,108 @@ func (r *HorizontalRunnerAutoscalerReconciler) matchScheduledOverrides(log logr.
 	return minReplicas, active, upcoming, nil
 }
 
-func (r *HorizontalRunnerAutoscalerReconciler) getMinReplicas(log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) (int, *Override, *Override, error) {
-	minReplicas := defaultReplicas
-	if hra.Spec.MinReplicas != nil && *hra.Spec.MinReplicas >= 0 {
-		minReplicas = *hra.Spec.MinReplicas
+// matchScheduledOverride returns the active and the upcoming periods of the scheduled override,
+// evaluating either its cron schedule or its recurring start and end times in its time zone.
+func matchScheduledOverride(now time.Time, o v1alpha1.ScheduledOverride) (*Period, *Period, error) {
+	if err := validateScheduledOverride(o); err != nil {
+		return nil, nil, err
 	}
 
-	m, active, upcoming, err := r.matchScheduledOverrides(log, now, hra)
+	loc, err := scheduledOverrideLocation(o)
 	if err != nil {
-		return 0, nil, nil, err
-	} else if m != nil {
-		minReplicas = *m
+		return nil, nil, err
 	}
 
-	return minReplicas, active, upcoming, nil
-}
-
-func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr.Logger, now time.Time, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, minReplicas int) (int, error) {
-	var suggestedReplicas int
+	if o.Schedule != "" {
+		active, upcoming, err := MatchCronSchedule(now, o.Schedule, o.Duration.Duration, loc)
+		if err == nil && active == nil && upcoming == nil {
+			return nil, nil, fmt.Errorf("schedule %q never matches within a year", o.Schedule)
+		}
 
-	v, err := r.suggestDesiredReplicas(st, hra)
-	if err != nil {
-		return 0, err
+		return active, upcoming, err
 	}
 
-	if v == nil {
-		suggestedReplicas = minReplicas
-	} else {
-		suggestedReplicas = *v
+	startTime, endTime, untilTime := o.StartTime.Time, o.EndTime.Time, o.RecurrenceRule.UntilTime.Time
+
+	if o.TimeZone != "" {
+		// Recur at the same local time in the time zone, even across DST transitions.
+		startTime, endTime, untilTime = startTime.In(loc), endTime.In(loc), untilTime.In(loc)
+		now = now.In(loc)
 	}
 
-	var reserved int
+	return MatchSchedule(
+		now, startTime, endTime,
+		RecurrenceRule{
+			Frequency: o.RecurrenceRule.Frequency,
+			UntilTime: untilTime,
+		},
+	)
+}
 
-	for _, reservation := range hra.Spec.CapacityReservations {
-		if reservation.ExpirationTime.Time.After(now) {
-			reserved += reservation.Replicas
-		}
+// getMaxReplicas returns maxReplicas overridden by the active scheduled override, if any.
+func getMaxReplicas(hra v1alpha1.HorizontalRunnerAutoscaler, active *Override) *int {
+	if active != nil && active.ScheduledOverride.MaxReplicas != nil {
+		return active.ScheduledOverride.MaxReplicas
 	}
 
-	newDesiredReplicas := suggestedReplicas + reserved
+	return hra.Spec.MaxReplicas
+}
+
+// nextScheduledOverrideBoundary returns the duration until the active scheduled override ends or the upcoming one starts,
+// whichever comes first, so that the replicas are recomputed right on the boundary.
+func nextScheduledOverrideBoundary(now time.Time, active, upcoming *Override) time.Duration {
+	var next time.Time
 
-	if newDesiredReplicas < minReplicas {
-		newDesiredReplicas = minReplicas
-	} else if hra.Spec.MaxReplicas != nil && newDesiredReplicas > *hra.Spec.MaxReplicas {
-		newDesiredReplicas = *hra.Spec.MaxReplicas
+	if active != nil && active.Period.EndTime.After(now) {
+		next = active.Period.EndTime
 	}
 
-	//
-	// Delay scaling-down for ScaleDownDelaySecondsAfterScaleUp or DefaultScaleDownDelay
-	//
+	if upcoming != nil && upcoming.Period.StartTime.After(now) && (next.IsZero() || upcoming.Period.StartTime.Before(next)) {
+		next = upcoming.Period.StartTime
+	}
 
-	var scaleDownDelay time.Duration
+	if next.IsZero() {
+		return 0
+	}
 
-	if hra.Spec.ScaleDownDelaySecondsAfterScaleUp != nil {
-		scaleDownDelay = time.Duration(*hra.Spec.ScaleDownDelaySecondsAfterScaleUp) * time.Second
-	} else {
-		scaleDownDelay = r.DefaultScaleDownDelay
+	return next.Sub(now)
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) getMinReplicas(log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) (int, *Override, *Override, error) {
+	minReplicas := defaultReplicas
+	if hra.Spec.MinReplicas != nil && *hra.Spec.MinReplicas >= 0 {
+		minReplicas = *hra.Spec.MinReplicas
 	}
 
-	var scaleDownDelayUntil *time.Time
+	m, active, upcoming, err := r.matchScheduledOverrides(log, now, hra)
+	if err != nil {
+		return 0, nil, nil, err
+	} else if m != nil {
+		minReplicas = *m
+	}
 
-	if hra.Status.DesiredReplicas == nil ||
-		*hra.Status.DesiredReplicas < newDesiredReplicas ||
-		hra.Status.LastSuccessfulScaleOutTime == nil {
+	return minReplicas, active, upcoming, nil
+}
 
-	} else if hra.Status.LastSuccessfulScaleOutTime != nil {
-		t := hra.Status.LastSuccessfulScaleOutTime.Add(scaleDownDelay)
+// computeReplicasWithCache returns the desired replicas along with the reason of the scaling decision,
+// and the recommendations to be kept for the scale down stabilization window.
+func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(ctx context.Context, log logr.Logger, now time.Time, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, minReplicas int) (int, string, []v1alpha1.ReplicaRecommendation, error) {
+	suggested, metric, metricReason, err := r.suggestDesiredReplicasWithRetries(ctx, log, st, hra)
+	if err != nil {
+		return 0, "", nil, err
+	}
 
-		// ScaleDownDelay is not passed
-		if t.After(now) {
-			scaleDownDelayUntil = &t
-			newDesiredReplicas = *hra.Status.DesiredReplicas
-		}
-	} else {
-		newDesiredReplicas = *hra.Status.DesiredReplicas
+	res, err := autoscaling.Compute(autoscaling.Input{
+		Now:                        now,
+		HorizontalRunnerAutoscaler: hra,
+		MinReplicas:                minReplicas,
+		Suggested:                  suggested,
+		Metric:                     metric,
+		MetricReason:               metricReason,
+		DefaultScaleDownDelay:      r.DefaultScaleDownDelay,
+	})
+	if err != nil {
+		return 0, "", nil, err
 	}
 
 	//
@@ -526,23 +1201,34 @@ func (r *HorizontalRunnerAutoscalerReconciler) computeReplicasWithCache(log logr
 	//
 
 	kvs := []interface{}{
-		"suggested", suggestedReplicas,
-		"reserved", reserved,
+		"suggested", res.Suggested,
+		"reserved", res.Reserved,
 		"min", minReplicas,
 	}
 
+	if res.Recommendations != nil {
+		kvs = append(kvs, "recommended", res.Recommended, "stabilized", res.Stabilized)
+	}
+
 	if maxReplicas := hra.Spec.MaxReplicas; maxReplicas != nil {
 		kvs = append(kvs, "max", *maxReplicas)
 	}
 
-	if scaleDownDelayUntil != nil {
+	if res.ScaleDownDelayUntil != nil {
 		kvs = append(kvs, "last_scale_up_time", *hra.Status.LastSuccessfulScaleOutTime)
-		kvs = append(kvs, "scale_down_delay_until", scaleDownDelayUntil)
+		kvs = append(kvs, "scale_down_delay_until", res.ScaleDownDelayUntil)
 	}
 
-	log.V(1).Info(fmt.Sprintf("Calculated desired replicas of %d", newDesiredReplicas),
+	kvs = append(kvs, "reason", res.Reason)
+
+	log.V(1).Info(fmt.Sprintf("Calculated desired replicas of %d", res.DesiredReplicas),
 		kvs...,
 	)
 
-	return newDesiredReplicas, nil
+	if st.observation != nil {
+		demanded := res.Recommended
+		st.observation.DemandedReplicas = &demanded
+	}
+
+	return res.DesiredReplicas, res.Reason, res.Recommendations, nil
 }
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultDependencyHTTPGetTimeout = 5 * time.Second
+
+	// DependencyHealthCheckInterval is the interval the dependencies are checked at while any of them is degraded,
+	// so that the cap is lifted soon after the dependency recovers.
+	DependencyHealthCheckInterval = 30 * time.Second
+)
+
+// checkDependencies runs the DependencyHealthChecks of the HRA and returns the degraded dependencies.
+// A dependency that was already degraded keeps the time it was found degraded.
+func (r *HorizontalRunnerAutoscalerReconciler) checkDependencies(ctx context.Context, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.DegradedDependency {
+	var degraded []v1alpha1.DegradedDependency
+
+	for _, check := range hra.Spec.DependencyHealthChecks {
+		err := r.checkDependency(ctx, hra.Namespace, check)
+		if err == nil {
+			continue
+		}
+
+		d := v1alpha1.DegradedDependency{
+			Name:    check.Name,
+			Message: err.Error(),
+			Since:   metav1.Time{Time: now},
+		}
+
+		for _, prev := range hra.Status.DegradedDependencies {
+			if prev.Name == check.Name {
+				d.Since = prev.Since
+			}
+		}
+
+		degraded = append(degraded, d)
+	}
+
+	return degraded
+}
+
+// checkDependency returns an error describing why the dependency is degraded, or nil if it's healthy.
+func (r *HorizontalRunnerAutoscalerReconciler) checkDependency(ctx context.Context, namespace string, check v1alpha1.DependencyHealthCheck) error {
+	switch {
+	case check.HTTPGet != nil:
+		return checkDependencyHTTPGet(ctx, *check.HTTPGet)
+	case check.Condition != nil:
+		return checkDependencyCondition(ctx, r.Client, namespace, *check.Condition)
+	}
+
+	// A misconfigured check caps the replicas too, so that it's noticed in the status rather than silently ignored.
+	return fmt.Errorf("neither httpGet nor condition is set")
+}
+
+func checkDependencyHTTPGet(ctx context.Context, check v1alpha1.DependencyHTTPGetCheck) error {
+	timeout := defaultDependencyHTTPGetTimeout
+	if check.TimeoutSeconds != nil {
+		timeout = time.Duration(*check.TimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 400 {
+		return fmt.Errorf("GET %s responded with status %d", check.URL, res.StatusCode)
+	}
+
+	return nil
+}
+
+// checkDependencyCondition reads the object as unstructured, which the manager's client reads directly from the API server
+// instead of starting an informer, so that only the permission to get the object is needed.
+func checkDependencyCondition(ctx context.Context, c client.Client, namespace string, check v1alpha1.DependencyConditionCheck) error {
+	var obj unstructured.Unstructured
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(check.APIVersion, check.Kind))
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: check.Name}, &obj); err != nil {
+		return fmt.Errorf("getting %s %s: %w", check.Kind, check.Name, err)
+	}
+
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return fmt.Errorf("reading conditions of %s %s: %w", check.Kind, check.Name, err)
+	}
+
+	for _, item := range conditions {
+		cond, ok := item.(map[string]interface{})
+		if !ok || cond["type"] != check.Type {
+			continue
+		}
+
+		if cond["status"] == string(metav1.ConditionTrue) {
+			return nil
+		}
+
+		return fmt.Errorf("condition %s of %s %s is %v: %v", check.Type, check.Kind, check.Name, cond["status"], cond["message"])
+	}
+
+	return fmt.Errorf("condition %s of %s %s is not found", check.Type, check.Kind, check.Name)
+}
+
+// recordDependencyTransitions emits events on the dependencies newly found degraded or recovered.
+func (r *HorizontalRunnerAutoscalerReconciler) recordDependencyTransitions(hra v1alpha1.HorizontalRunnerAutoscaler, degraded []v1alpha1.DegradedDependency) {
+	wasDegraded := map[string]bool{}
+	for _, d := range hra.Status.DegradedDependencies {
+		wasDegraded[d.Name] = true
+	}
+
+	isDegraded := map[string]bool{}
+	for _, d := range degraded {
+		isDegraded[d.Name] = true
+
+		if !wasDegraded[d.Name] {
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, "DependencyDegraded", fmt.Sprintf("Dependency %s is degraded: %s", d.Name, d.Message))
+		}
+	}
+
+	for _, d := range hra.Status.DegradedDependencies {
+		if !isDegraded[d.Name] {
+			r.Recorder.Event(&hra, corev1.EventTypeNormal, "DependencyRecovered", fmt.Sprintf("Dependency %s has recovered", d.Name))
+		}
+	}
+}
+
+// getDependencyMaxReplicas returns the lowest MaxReplicas of the DependencyHealthChecks of the degraded dependencies,
+// or nil if no dependency is degraded.
+func getDependencyMaxReplicas(hra v1alpha1.HorizontalRunnerAutoscaler, degraded []v1alpha1.DegradedDependency) *int {
+	var maxReplicas *int
+
+	for _, d := range degraded {
+		for _, check := range hra.Spec.DependencyHealthChecks {
+			if check.Name != d.Name {
+				continue
+			}
+
+			if maxReplicas == nil || check.MaxReplicas < *maxReplicas {
+				v := check.MaxReplicas
+				maxReplicas = &v
+			}
+		}
+	}
+
+	return maxReplicas
+}
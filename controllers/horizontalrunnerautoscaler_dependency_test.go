@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	since := metav1.NewTime(now.Add(-time.Hour))
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	newDeployment := func(name string, available corev1.ConditionStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue},
+					{Type: appsv1.DeploymentAvailable, Status: available, Message: "Deployment does not have minimum availability."},
+				},
+			},
+		}
+	}
+
+	condition := func(name string) *v1alpha1.DependencyConditionCheck {
+		return &v1alpha1.DependencyConditionCheck{APIVersion: "apps/v1", Kind: "Deployment", Name: name, Type: "Available"}
+	}
+
+	testcases := []struct {
+		description string
+		check       v1alpha1.DependencyHealthCheck
+		previous    []v1alpha1.DegradedDependency
+		want        bool
+		wantSince   metav1.Time
+	}{
+		{
+			description: "healthy url",
+			check:       v1alpha1.DependencyHealthCheck{HTTPGet: &v1alpha1.DependencyHTTPGetCheck{URL: healthy.URL}},
+		},
+		{
+			description: "unhealthy url",
+			check:       v1alpha1.DependencyHealthCheck{HTTPGet: &v1alpha1.DependencyHTTPGetCheck{URL: unhealthy.URL}},
+			want:        true,
+			wantSince:   metav1.NewTime(now),
+		},
+		{
+			description: "unhealthy url degraded before",
+			check:       v1alpha1.DependencyHealthCheck{HTTPGet: &v1alpha1.DependencyHTTPGetCheck{URL: unhealthy.URL}},
+			previous:    []v1alpha1.DegradedDependency{{Name: "dep", Since: since}},
+			want:        true,
+			wantSince:   since,
+		},
+		{
+			description: "available condition",
+			check:       v1alpha1.DependencyHealthCheck{Condition: condition("available")},
+		},
+		{
+			description: "unavailable condition",
+			check:       v1alpha1.DependencyHealthCheck{Condition: condition("unavailable")},
+			want:        true,
+			wantSince:   metav1.NewTime(now),
+		},
+		{
+			description: "missing object",
+			check:       v1alpha1.DependencyHealthCheck{Condition: condition("missing")},
+			want:        true,
+			wantSince:   metav1.NewTime(now),
+		},
+		{
+			description: "no check",
+			want:        true,
+			wantSince:   metav1.NewTime(now),
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			c := clientfake.NewFakeClientWithScheme(sc, newDeployment("available", corev1.ConditionTrue), newDeployment("unavailable", corev1.ConditionFalse))
+
+			r := &HorizontalRunnerAutoscalerReconciler{
+				Client: c,
+				Log:    logr.Discard(),
+			}
+
+			tc.check.Name = "dep"
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					DependencyHealthChecks: []v1alpha1.DependencyHealthCheck{tc.check},
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					DegradedDependencies: tc.previous,
+				},
+			}
+
+			got := r.checkDependencies(ctx, now, hra)
+
+			if (len(got) > 0) != tc.want {
+				t.Fatalf("unexpected degraded dependencies: want degraded=%v, got %+v", tc.want, got)
+			}
+
+			if tc.want && !got[0].Since.Equal(&tc.wantSince) {
+				t.Errorf("unexpected since: want %v, got %v", tc.wantSince, got[0].Since)
+			}
+
+			if tc.want && got[0].Message == "" {
+				t.Errorf("missing message")
+			}
+		})
+	}
+}
+
+func TestGetDependencyMaxReplicas(t *testing.T) {
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			DependencyHealthChecks: []v1alpha1.DependencyHealthCheck{
+				{Name: "artifacts", MaxReplicas: 10},
+				{Name: "license", MaxReplicas: 3},
+			},
+		},
+	}
+
+	testcases := []struct {
+		description string
+		degraded    []string
+		want        *int
+	}{
+		{description: "none degraded"},
+		{description: "one degraded", degraded: []string{"artifacts"}, want: intPtr(10)},
+		{description: "lowest of degraded", degraded: []string{"artifacts", "license"}, want: intPtr(3)},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var degraded []v1alpha1.DegradedDependency
+			for _, name := range tc.degraded {
+				degraded = append(degraded, v1alpha1.DegradedDependency{Name: name})
+			}
+
+			got := getDependencyMaxReplicas(hra, degraded)
+
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("unexpected max replicas: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRecordDependencyTransitions(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+
+	r := &HorizontalRunnerAutoscalerReconciler{Recorder: recorder}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+			DegradedDependencies: []v1alpha1.DegradedDependency{{Name: "artifacts"}, {Name: "license"}},
+		},
+	}
+
+	r.recordDependencyTransitions(hra, []v1alpha1.DegradedDependency{{Name: "license"}, {Name: "cache", Message: "down"}})
+
+	close(recorder.Events)
+
+	var events []string
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+
+	want := []string{
+		"Warning DependencyDegraded Dependency cache is degraded: down",
+		"Normal DependencyRecovered Dependency artifacts has recovered",
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("unexpected events: want %v, got %v", want, events)
+	}
+
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("unexpected event: want %q, got %q", want[i], events[i])
+		}
+	}
+}
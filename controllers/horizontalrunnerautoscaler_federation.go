@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// federationKubeconfigSecretKey is the key of the kubeconfig of a federation member cluster in the Secret referenced by the member.
+const federationKubeconfigSecretKey = "kubeconfig"
+
+// FederationClientFunc creates the client of a federation member cluster from its kubeconfig.
+type FederationClientFunc func(kubeconfig []byte) (client.Client, error)
+
+// NewFederationClient returns the FederationClientFunc that creates the clients with the scheme.
+func NewFederationClient(scheme *runtime.Scheme) FederationClientFunc {
+	return func(kubeconfig []byte) (client.Client, error) {
+		config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.New(config, client.Options{Scheme: scheme})
+	}
+}
+
+// federationClientCache caches the clients of the federation member clusters, keyed by the namespaced names of the kubeconfig Secrets.
+// A client is recreated when its Secret is updated.
+type federationClientCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]federationClient
+}
+
+type federationClient struct {
+	client.Client
+
+	resourceVersion string
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) federationClient(ctx context.Context, namespace string, ref v1alpha1.SecretReference) (client.Client, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("getting kubeconfig secret %s: %w", key, err)
+	}
+
+	c := &r.federationClients
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok && e.resourceVersion == secret.ResourceVersion {
+		return e.Client, nil
+	}
+
+	kubeconfig, ok := secret.Data[federationKubeconfigSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig secret %s has no %s key", key, federationKubeconfigSecretKey)
+	}
+
+	newClient := r.NewFederationClient
+	if newClient == nil {
+		newClient = NewFederationClient(r.Scheme)
+	}
+
+	memberClient, err := newClient(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating client from kubeconfig secret %s: %w", key, err)
+	}
+
+	if c.entries == nil {
+		c.entries = map[types.NamespacedName]federationClient{}
+	}
+	c.entries[key] = federationClient{Client: memberClient, resourceVersion: secret.ResourceVersion}
+
+	return memberClient, nil
+}
+
+// delegateShortfall delegates the shortfall replicas to the federation members of the HRA in order, each up to its maxReplicas,
+// by setting the replicas of their RunnerDeployments, and returns the replicas delegated to the members.
+//
+// As with the desired replicas of the HRA, the replicas of a member are decreased only after the scale down delay since they were last increased,
+// so that the runners in the member cluster aren't flapping along with the demand.
+// A member that is unreachable is skipped, so that its share spills into the next members, and keeps the replicas delegated to it before.
+func (r *HorizontalRunnerAutoscalerReconciler) delegateShortfall(ctx context.Context, log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, shortfall int) []v1alpha1.DelegatedReplicas {
+	members := hra.Spec.Overflow.Federation
+
+	previous := map[string]v1alpha1.DelegatedReplicas{}
+	for _, d := range hra.Status.DelegatedReplicas {
+		previous[d.Member] = d
+	}
+
+	delay := r.DefaultScaleDownDelay
+	if hra.Spec.ScaleDownDelaySecondsAfterScaleUp != nil {
+		delay = time.Duration(*hra.Spec.ScaleDownDelaySecondsAfterScaleUp) * time.Second
+	}
+
+	remaining := shortfall
+
+	var delegated []v1alpha1.DelegatedReplicas
+
+	for _, m := range members {
+		prev := previous[m.Name]
+
+		replicas, lastScaleUpTime := delegatedReplicas(now, remaining, m.MaxReplicas, prev, delay)
+
+		if err := r.scaleFederationMember(ctx, hra, m, replicas); err != nil {
+			log.Error(err, "Could not delegate replicas to the federation member", "member", m.Name, "replicas", replicas)
+
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, "FederationMemberUnavailable", fmt.Sprintf("Could not delegate %d replicas to %s: %v", replicas, m.Name, err))
+
+			if prev.Member != "" {
+				prev.Message = err.Error()
+				delegated = append(delegated, prev)
+			}
+
+			continue
+		}
+
+		if replicas != prev.Replicas {
+			log.Info("Delegated replicas to the federation member", "member", m.Name, "replicas", replicas, "previous", prev.Replicas, "shortfall", shortfall)
+		}
+
+		remaining -= replicas
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		delegated = append(delegated, v1alpha1.DelegatedReplicas{
+			Member:          m.Name,
+			Replicas:        replicas,
+			LastScaleUpTime: lastScaleUpTime,
+		})
+	}
+
+	return delegated
+}
+
+// delegatedReplicas returns the replicas delegated to a member for the remaining shortfall, along with the last time they were increased.
+// The previous replicas are kept while the scale down delay since their last increase hasn't passed.
+func delegatedReplicas(now time.Time, remaining, maxReplicas int, prev v1alpha1.DelegatedReplicas, delay time.Duration) (int, *metav1.Time) {
+	replicas := remaining
+	if replicas > maxReplicas {
+		replicas = maxReplicas
+	}
+
+	if replicas > prev.Replicas {
+		return replicas, &metav1.Time{Time: now}
+	}
+
+	if replicas < prev.Replicas && prev.LastScaleUpTime != nil && now.Before(prev.LastScaleUpTime.Add(delay)) {
+		replicas = prev.Replicas
+		if replicas > maxReplicas {
+			replicas = maxReplicas
+		}
+	}
+
+	return replicas, prev.LastScaleUpTime
+}
+
+// scaleFederationMember sets the replicas of the RunnerDeployment of the member in its cluster.
+func (r *HorizontalRunnerAutoscalerReconciler) scaleFederationMember(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, m v1alpha1.FederationMember, replicas int) error {
+	memberClient, err := r.federationClient(ctx, hra.Namespace, m.KubeconfigSecretRef)
+	if err != nil {
+		return err
+	}
+
+	namespace := m.Namespace
+	if namespace == "" {
+		namespace = hra.Namespace
+	}
+
+	var rd v1alpha1.RunnerDeployment
+	if err := memberClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: m.RunnerDeployment}, &rd); err != nil {
+		return err
+	}
+
+	if rd.Spec.Replicas != nil && *rd.Spec.Replicas == replicas {
+		return nil
+	}
+
+	updated := rd.DeepCopy()
+	updated.Spec.Replicas = &replicas
+
+	return memberClient.Patch(ctx, updated, client.MergeFrom(&rd))
+}
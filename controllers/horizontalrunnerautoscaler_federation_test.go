@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDelegatedReplicas(t *testing.T) {
+	now := time.Now()
+	recently := &metav1.Time{Time: now.Add(-time.Minute)}
+	longAgo := &metav1.Time{Time: now.Add(-time.Hour)}
+
+	testcases := []struct {
+		description  string
+		remaining    int
+		prev         v1alpha1.DelegatedReplicas
+		want         int
+		wantScaledUp bool
+	}{
+		{
+			description:  "scale up",
+			remaining:    3,
+			prev:         v1alpha1.DelegatedReplicas{Replicas: 1, LastScaleUpTime: longAgo},
+			want:         3,
+			wantScaledUp: true,
+		},
+		{
+			description:  "capped by maxReplicas",
+			remaining:    10,
+			want:         5,
+			wantScaledUp: true,
+		},
+		{
+			description: "scale down within the delay",
+			remaining:   1,
+			prev:        v1alpha1.DelegatedReplicas{Replicas: 4, LastScaleUpTime: recently},
+			want:        4,
+		},
+		{
+			description: "scale down after the delay",
+			remaining:   1,
+			prev:        v1alpha1.DelegatedReplicas{Replicas: 4, LastScaleUpTime: longAgo},
+			want:        1,
+		},
+		{
+			description: "maxReplicas lowered within the delay",
+			remaining:   0,
+			prev:        v1alpha1.DelegatedReplicas{Replicas: 8, LastScaleUpTime: recently},
+			want:        5,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, lastScaleUpTime := delegatedReplicas(now, tc.remaining, 5, tc.prev, 10*time.Minute)
+			if got != tc.want {
+				t.Errorf("unexpected replicas: want %d, got %d", tc.want, got)
+			}
+
+			if scaledUp := lastScaleUpTime != nil && lastScaleUpTime.Time.Equal(now); scaledUp != tc.wantScaledUp {
+				t.Errorf("unexpected last scale up time: %v", lastScaleUpTime)
+			}
+		})
+	}
+}
+
+func TestDelegateShortfall(t *testing.T) {
+	ctx := context.Background()
+
+	newMemberRunnerDeployment := func() *v1alpha1.RunnerDeployment {
+		return &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-runnerdeploy"},
+			Spec:       v1alpha1.RunnerDeploymentSpec{Replicas: intPtr(0)},
+		}
+	}
+
+	members := map[string]client.Client{
+		"east": fake.NewClientBuilder().WithScheme(sc).WithObjects(newMemberRunnerDeployment()).Build(),
+		"west": fake.NewClientBuilder().WithScheme(sc).WithObjects(newMemberRunnerDeployment()).Build(),
+	}
+
+	kubeconfigSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Data:       map[string][]byte{federationKubeconfigSecretKey: []byte(name)},
+		}
+	}
+
+	newReconciler := func(secrets ...client.Object) *HorizontalRunnerAutoscalerReconciler {
+		return &HorizontalRunnerAutoscalerReconciler{
+			Client:     fake.NewClientBuilder().WithScheme(sc).WithObjects(secrets...).Build(),
+			Recorder:   record.NewFakeRecorder(10),
+			Federation: true,
+			NewFederationClient: func(kubeconfig []byte) (client.Client, error) {
+				c, ok := members[string(kubeconfig)]
+				if !ok {
+					return nil, fmt.Errorf("unknown cluster %s", kubeconfig)
+				}
+				return c, nil
+			},
+		}
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-hra"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			Overflow: &v1alpha1.HorizontalRunnerAutoscalerOverflow{
+				Federation: []v1alpha1.FederationMember{
+					{Name: "east", KubeconfigSecretRef: v1alpha1.SecretReference{Name: "east"}, RunnerDeployment: "example-runnerdeploy", MaxReplicas: 2},
+					{Name: "west", KubeconfigSecretRef: v1alpha1.SecretReference{Name: "west"}, RunnerDeployment: "example-runnerdeploy", MaxReplicas: 5},
+				},
+			},
+		},
+	}
+
+	memberReplicas := func(name string) int {
+		var rd v1alpha1.RunnerDeployment
+		if err := members[name].Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-runnerdeploy"}, &rd); err != nil {
+			t.Fatal(err)
+		}
+		return *rd.Spec.Replicas
+	}
+
+	now := time.Now()
+
+	r := newReconciler(kubeconfigSecret("east"), kubeconfigSecret("west"))
+
+	delegated := r.delegateShortfall(ctx, logr.Discard(), now, hra, 4)
+
+	want := []v1alpha1.DelegatedReplicas{
+		{Member: "east", Replicas: 2, LastScaleUpTime: &metav1.Time{Time: now}},
+		{Member: "west", Replicas: 2, LastScaleUpTime: &metav1.Time{Time: now}},
+	}
+
+	if !reflect.DeepEqual(delegated, want) {
+		t.Errorf("unexpected delegated replicas: want %+v, got %+v", want, delegated)
+	}
+
+	if east, west := memberReplicas("east"), memberReplicas("west"); east != 2 || west != 2 {
+		t.Errorf("unexpected replicas of the members: east=%d, west=%d", east, west)
+	}
+
+	// The shortfall spills into west when east is unreachable, while east keeps the replicas delegated before.
+	hra.Status.DelegatedReplicas = delegated
+
+	r = newReconciler(kubeconfigSecret("west"))
+
+	delegated = r.delegateShortfall(ctx, logr.Discard(), now.Add(time.Minute), hra, 4)
+
+	if len(delegated) != 2 || delegated[0].Replicas != 2 || delegated[0].Message == "" || delegated[1].Replicas != 4 {
+		t.Errorf("unexpected delegated replicas: %+v", delegated)
+	}
+
+	if west := memberReplicas("west"); west != 4 {
+		t.Errorf("unexpected replicas of west: want 4, got %d", west)
+	}
+}
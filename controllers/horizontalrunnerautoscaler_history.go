@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultScalingHistoryLimit = 10
+
+// Reasons of the scaling decisions other than the metric types.
+const (
+	ScalingReasonMinReplicas              = autoscaling.ReasonMinReplicas
+	ScalingReasonMaxReplicas              = autoscaling.ReasonMaxReplicas
+	ScalingReasonCapacityReservations     = autoscaling.ReasonCapacityReservations
+	ScalingReasonScaleDownDelay           = autoscaling.ReasonScaleDownDelay
+	ScalingReasonScaleDownStabilization   = autoscaling.ReasonScaleDownStabilization
+	ScalingReasonScaleDownMaxStep         = autoscaling.ReasonScaleDownMaxStep
+	ScalingReasonScaleUpMaxStep           = autoscaling.ReasonScaleUpMaxStep
+	ScalingReasonMaxUnschedulableReplicas = "MaxUnschedulableReplicas"
+	ScalingReasonPickupConfirmation       = "PickupConfirmation"
+	ScalingReasonRunnerBudget             = "RunnerBudget"
+	ScalingReasonDrainMode                = "DrainMode"
+	ScalingReasonDependencyDegraded       = "DependencyDegraded"
+	ScalingReasonGitHubDegraded           = "GitHubDegraded"
+	ScalingReasonBusyRunners              = "BusyRunners"
+	ScalingReasonMetricsSum               = autoscaling.ReasonMetricsSum
+	ScalingReasonMetricsAverage           = autoscaling.ReasonMetricsAverage
+)
+
+// appendScalingDecision returns the scaling history with the decision appended, pruned to the latest entries up to the limit.
+// The decision is not recorded when the desired replicas is unchanged.
+func appendScalingDecision(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time, from, to int, reason string, observed *v1alpha1.ScalingObservation) []v1alpha1.ScalingDecision {
+	limit := defaultScalingHistoryLimit
+	if hra.Spec.ScalingHistoryLimit != nil {
+		limit = *hra.Spec.ScalingHistoryLimit
+	}
+
+	history := hra.Status.ScalingHistory
+
+	if from != to {
+		history = append(append([]v1alpha1.ScalingDecision{}, history...), v1alpha1.ScalingDecision{
+			Time:     metav1.Time{Time: now},
+			From:     from,
+			To:       to,
+			Reason:   reason,
+			Observed: observed,
+		})
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	return history
+}
+
+// scalingObservation returns the numbers in the observation that fed the scaling decision, or nil when none were observed.
+func scalingObservation(o *metrics.HorizontalRunnerAutoscalerObservation) *v1alpha1.ScalingObservation {
+	if o == nil || (o.QueuedWorkflowJobs == nil && o.InProgressWorkflowJobs == nil && o.BusyRunners == nil && o.DemandedReplicas == nil) {
+		return nil
+	}
+
+	copyInt := func(v *int) *int {
+		if v == nil {
+			return nil
+		}
+		c := *v
+		return &c
+	}
+
+	return &v1alpha1.ScalingObservation{
+		QueuedWorkflowJobs:     copyInt(o.QueuedWorkflowJobs),
+		InProgressWorkflowJobs: copyInt(o.InProgressWorkflowJobs),
+		BusyRunners:            copyInt(o.BusyRunners),
+		DemandedReplicas:       copyInt(o.DemandedReplicas),
+	}
+}
+
+// ScalingDecisionLogEntry is a line of the scaling decision log, which is the scaling decision along with the HRA that made it.
+type ScalingDecisionLogEntry struct {
+	Namespace                  string `json:"namespace"`
+	HorizontalRunnerAutoscaler string `json:"horizontalRunnerAutoscaler"`
+
+	v1alpha1.ScalingDecision
+}
+
+// logScalingDecision writes the change of the desired replicas, if any, to ScalingDecisionLog as a line of JSON.
+// The decisions are logged regardless of ScalingHistoryLimit, so that they can be kept longer than the history in the status.
+func (r *HorizontalRunnerAutoscalerReconciler) logScalingDecision(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time, from, to int, reason string, observed *v1alpha1.ScalingObservation) {
+	if r.ScalingDecisionLog == nil || from == to {
+		return
+	}
+
+	line, err := json.Marshal(ScalingDecisionLogEntry{
+		Namespace:                  hra.Namespace,
+		HorizontalRunnerAutoscaler: hra.Name,
+		ScalingDecision: v1alpha1.ScalingDecision{
+			Time:     metav1.Time{Time: now},
+			From:     from,
+			To:       to,
+			Reason:   reason,
+			Observed: observed,
+		},
+	})
+	if err != nil {
+		r.Log.Error(err, "Could not encode the scaling decision", "namespace", hra.Namespace, "horizontal_runner_autoscaler", hra.Name)
+		return
+	}
+
+	// The lines are written at once so that the ones of the concurrent reconciliations don't interleave.
+	r.scalingDecisionLogMu.Lock()
+	defer r.scalingDecisionLogMu.Unlock()
+
+	if _, err := r.ScalingDecisionLog.Write(append(line, '\n')); err != nil {
+		r.Log.Error(err, "Could not write the scaling decision log", "namespace", hra.Namespace, "horizontal_runner_autoscaler", hra.Name)
+	}
+}
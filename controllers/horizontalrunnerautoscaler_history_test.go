@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppendScalingDecision(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	decision := func(minutesAgo, from, to int) v1alpha1.ScalingDecision {
+		return v1alpha1.ScalingDecision{
+			Time:   metav1.NewTime(now.Add(-time.Duration(minutesAgo) * time.Minute)),
+			From:   from,
+			To:     to,
+			Reason: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy,
+		}
+	}
+
+	testcases := []struct {
+		description string
+		limit       *int
+		history     []v1alpha1.ScalingDecision
+		from, to    int
+		want        []int
+	}{
+		{
+			description: "first decision",
+			from:        1,
+			to:          3,
+			want:        []int{3},
+		},
+		{
+			description: "unchanged replicas are not recorded",
+			history:     []v1alpha1.ScalingDecision{decision(2, 1, 3)},
+			from:        3,
+			to:          3,
+			want:        []int{3},
+		},
+		{
+			description: "oldest decisions are pruned",
+			limit:       intPtr(2),
+			history:     []v1alpha1.ScalingDecision{decision(3, 1, 3), decision(2, 3, 5)},
+			from:        5,
+			to:          2,
+			want:        []int{5, 2},
+		},
+		{
+			description: "lowered limit prunes without a change",
+			limit:       intPtr(1),
+			history:     []v1alpha1.ScalingDecision{decision(3, 1, 3), decision(2, 3, 5)},
+			from:        5,
+			to:          5,
+			want:        []int{5},
+		},
+		{
+			description: "disabled",
+			limit:       intPtr(0),
+			history:     []v1alpha1.ScalingDecision{decision(3, 1, 3)},
+			from:        3,
+			to:          5,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec:   v1alpha1.HorizontalRunnerAutoscalerSpec{ScalingHistoryLimit: tc.limit},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{ScalingHistory: tc.history},
+			}
+
+			got := appendScalingDecision(hra, now, tc.from, tc.to, ScalingReasonMinReplicas, nil)
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("unexpected history length: want %d, got %d: %v", len(tc.want), len(got), got)
+			}
+
+			for j, to := range tc.want {
+				if got[j].To != to {
+					t.Errorf("unexpected decision %d: want to=%d, got %+v", j, to, got[j])
+				}
+			}
+
+			if tc.from != tc.to && len(got) > 0 {
+				last := got[len(got)-1]
+				if !last.Time.Time.Equal(now) || last.From != tc.from || last.Reason != ScalingReasonMinReplicas {
+					t.Errorf("unexpected latest decision: %+v", last)
+				}
+			}
+
+			if len(tc.history) > 0 && tc.history[0].To != hra.Status.ScalingHistory[0].To {
+				t.Errorf("the original history must not be modified")
+			}
+		})
+	}
+}
+
+func TestLogScalingDecision(t *testing.T) {
+	queued, busy := 3, 1
+
+	var buf bytes.Buffer
+
+	r := &HorizontalRunnerAutoscalerReconciler{Log: logr.Discard(), ScalingDecisionLog: &buf}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+	}
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	observed := scalingObservation(&metrics.HorizontalRunnerAutoscalerObservation{QueuedWorkflowJobs: &queued, BusyRunners: &busy})
+
+	r.logScalingDecision(hra, now, 1, 1, ScalingReasonMinReplicas, observed)
+	r.logScalingDecision(hra, now, 1, 4, v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners, observed)
+
+	want := `{"namespace":"default","horizontalRunnerAutoscaler":"example","time":"2022-03-01T10:00:00Z","from":1,"to":4,"reason":"QueuedJobsPlusBusyRunners","observed":{"queuedWorkflowJobs":3,"busyRunners":1}}` + "\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected log: want %s, got %s", want, got)
+	}
+
+	if scalingObservation(&metrics.HorizontalRunnerAutoscalerObservation{}) != nil {
+		t.Errorf("nothing observed must be omitted")
+	}
+}
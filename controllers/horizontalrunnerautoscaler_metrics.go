@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	githubmetrics "github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+)
+
+// githubContext returns the context for the GitHub API requests made to compute the desired replicas of the scale target.
+// The responses to the requests are counted in the observation of the reconciliation, if any,
+// so that the cache hits and the rate limit are attributed to the HRA even though the GitHub client is shared.
+// The lists fetched by the requests share the page budget of the reconciliation, if any.
+func (st scaleTarget) githubContext() context.Context {
+	ctx := github.WithPageBudget(context.Background(), st.pageBudget)
+
+	obs := st.observation
+	if obs == nil {
+		return ctx
+	}
+
+	return githubmetrics.WithResponseObserver(ctx, func(fromCache bool, rateLimitRemaining int) {
+		if fromCache {
+			obs.GitHubAPICacheHits++
+		} else {
+			obs.GitHubAPICacheMisses++
+		}
+
+		if rateLimitRemaining >= 0 {
+			obs.GitHubRateLimitRemaining = &rateLimitRemaining
+		}
+	})
+}
+
+func (st scaleTarget) observeWorkflowJobs(now time.Time, counts workflowJobCounts) {
+	if st.observation == nil {
+		return
+	}
+
+	st.observation.QueuedWorkflowJobs = &counts.queued
+	st.observation.InProgressWorkflowJobs = &counts.inProgress
+
+	jobs := make([]autoscaling.WorkflowJob, 0, len(counts.jobs))
+	for _, j := range counts.jobs {
+		jobs = append(jobs, autoscaling.WorkflowJob{Run: j.run, Job: j.job})
+	}
+
+	wait := autoscaling.OldestQueuedJobWaitTime(jobs, now)
+	st.observation.OldestQueuedWorkflowJobWait = &wait
+}
+
+// observeRepositoryWorkflowJobs records the breakdown of the workflow jobs counted by the metric of the type by repository,
+// which is reported in the status of the metric.
+func (st scaleTarget) observeRepositoryWorkflowJobs(metricType string, repos []v1alpha1.RepositoryWorkflowJobs) {
+	if st.repositoryWorkflowJobs == nil {
+		return
+	}
+
+	st.repositoryWorkflowJobs[metricType] = repos
+}
+
+// observeMetricWorkflowJobs records the weighted numbers of the workflow jobs counted by the metric of the type,
+// which the status of the QueuedJobsPerRunner metric is computed from.
+func (st scaleTarget) observeMetricWorkflowJobs(metricType string, counts workflowJobCounts) {
+	if st.metricWorkflowJobs == nil {
+		return
+	}
+
+	st.metricWorkflowJobs[metricType] = counts
+}
+
+// observeWorkflowJobCache counts a lookup of the jobs of a workflow run in the workflow job cache.
+func (st scaleTarget) observeWorkflowJobCache(hit bool) {
+	if st.observation == nil {
+		return
+	}
+
+	if hit {
+		st.observation.WorkflowJobCacheHits++
+	} else {
+		st.observation.WorkflowJobCacheMisses++
+	}
+}
+
+func (st scaleTarget) observeRunners(counts runnerCounts) {
+	if st.observation == nil {
+		return
+	}
+
+	st.observation.BusyRunners = &counts.busy
+}
+
+// isAtMaxReplicas returns true when the desired replicas is pinned at maxReplicas, which is worth alerting on
+// as the scale target can't keep up with the demand.
+func isAtMaxReplicas(maxReplicas *int, desiredReplicas int) bool {
+	return maxReplicas != nil && desiredReplicas >= *maxReplicas
+}
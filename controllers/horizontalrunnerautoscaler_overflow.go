@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CapacitySaturatedConditionType is the type of the condition set to HorizontalRunnerAutoscaler
+	// that is True while the demand for runners exceeds maxReplicas and the replicas it can borrow.
+	CapacitySaturatedConditionType = "CapacitySaturated"
+
+	conditionReasonDemandExceedsMaxReplicas = "DemandExceedsMaxReplicas"
+	conditionReasonBorrowedReplicas         = "BorrowedReplicas"
+	conditionReasonDemandWithinMaxReplicas  = "DemandWithinMaxReplicas"
+
+	overflowNotificationTimeout = 10 * time.Second
+)
+
+// overflowCapacity is the maxReplicas of a HorizontalRunnerAutoscaler adjusted for the replicas lent to and borrowed from
+// the other HorizontalRunnerAutoscalers.
+type overflowCapacity struct {
+	// maxReplicas is maxReplicas less the replicas lent to the borrowers.
+	maxReplicas int
+
+	// borrowable is the number of replicas that can be borrowed on top of maxReplicas.
+	borrowable int
+}
+
+// overflow is the outcome of the overflow handling of a reconciliation.
+type overflow struct {
+	demanded  int
+	shortfall int
+	borrowed  int
+}
+
+// getOverflowCapacity returns the capacity of the HorizontalRunnerAutoscaler, or nil when maxReplicas is missing.
+// The replicas borrowed by the other HorizontalRunnerAutoscalers are read from their status,
+// so that the borrower and the lender agree on them without updating each other.
+func (r *HorizontalRunnerAutoscalerReconciler) getOverflowCapacity(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler) (*overflowCapacity, error) {
+	if hra.Spec.MaxReplicas == nil {
+		return nil, nil
+	}
+
+	var hraList v1alpha1.HorizontalRunnerAutoscalerList
+	if err := r.List(ctx, &hraList, client.InNamespace(hra.Namespace)); err != nil {
+		return nil, err
+	}
+
+	capacity := &overflowCapacity{
+		maxReplicas: *hra.Spec.MaxReplicas - lentReplicas(hraList.Items, hra.Name, ""),
+	}
+
+	if capacity.maxReplicas < 0 {
+		capacity.maxReplicas = 0
+	}
+
+	if hra.Spec.Overflow == nil || hra.Spec.Overflow.BorrowFrom == nil {
+		return capacity, nil
+	}
+
+	borrow := hra.Spec.Overflow.BorrowFrom
+
+	var lender v1alpha1.HorizontalRunnerAutoscaler
+	if err := r.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: borrow.Name}, &lender); err != nil {
+		if kerrors.IsNotFound(err) {
+			return capacity, nil
+		}
+
+		return nil, err
+	}
+
+	if hraPriority(lender) >= hraPriority(hra) {
+		r.Recorder.Eventf(&hra, corev1.EventTypeWarning, "OverflowBorrowRejected",
+			"horizontalrunnerautoscaler %s must have a lower priority than %d to lend replicas", lender.Name, hraPriority(hra))
+
+		return capacity, nil
+	}
+
+	// The lender's unused replicas are the ones it isn't using itself nor lending to the other borrowers.
+	// The replicas lent to this borrower are unused by the lender, as the lender's desired replicas never exceeds
+	// its maxReplicas lowered by them.
+	unused := getIntOrDefault(lender.Spec.MaxReplicas, 0) - lentReplicas(hraList.Items, lender.Name, hra.Name) - getIntOrDefault(lender.Status.DesiredReplicas, 0)
+	if limit := borrow.MaxReplicas; limit != nil && unused > *limit {
+		unused = *limit
+	}
+
+	if unused > 0 {
+		capacity.borrowable = unused
+	}
+
+	return capacity, nil
+}
+
+// lentReplicas returns the sum of the replicas the HorizontalRunnerAutoscalers other than except borrow from the lender.
+func lentReplicas(hras []v1alpha1.HorizontalRunnerAutoscaler, lender, except string) int {
+	var lent int
+
+	for _, h := range hras {
+		if h.Name == except || !h.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if o := h.Spec.Overflow; o != nil && o.BorrowFrom != nil && o.BorrowFrom.Name == lender {
+			lent += getIntOrDefault(h.Status.BorrowedReplicas, 0)
+		}
+	}
+
+	return lent
+}
+
+// computeOverflow returns the shortfall and the borrowed replicas for the final desired replicas.
+func computeOverflow(capacity overflowCapacity, demanded, desiredReplicas int) overflow {
+	o := overflow{demanded: demanded}
+
+	if s := demanded - capacity.maxReplicas - capacity.borrowable; s > 0 {
+		o.shortfall = s
+	}
+
+	if b := desiredReplicas - capacity.maxReplicas; b > 0 {
+		o.borrowed = b
+		if o.borrowed > capacity.borrowable {
+			o.borrowed = capacity.borrowable
+		}
+	}
+
+	return o
+}
+
+// capacitySaturatedCondition returns the CapacitySaturated condition for the overflow.
+func capacitySaturatedCondition(hra v1alpha1.HorizontalRunnerAutoscaler, o overflow) metav1.Condition {
+	if o.shortfall > 0 {
+		return metav1.Condition{
+			Type:    CapacitySaturatedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  conditionReasonDemandExceedsMaxReplicas,
+			Message: fmt.Sprintf("%d replicas are demanded, %d short of the capacity", o.demanded, o.shortfall),
+		}
+	}
+
+	if o.borrowed > 0 {
+		return metav1.Condition{
+			Type:    CapacitySaturatedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonBorrowedReplicas,
+			Message: fmt.Sprintf("%d replicas are demanded, %d of them borrowed from %s", o.demanded, o.borrowed, hra.Spec.Overflow.BorrowFrom.Name),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    CapacitySaturatedConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonDemandWithinMaxReplicas,
+		Message: fmt.Sprintf("%d replicas are demanded", o.demanded),
+	}
+}
+
+// OverflowNotification is the JSON body POSTed to HorizontalRunnerAutoscalerOverflow.WebhookURL
+// when the CapacitySaturated condition of a HorizontalRunnerAutoscaler changes.
+type OverflowNotification struct {
+	Namespace                  string    `json:"namespace"`
+	HorizontalRunnerAutoscaler string    `json:"horizontalRunnerAutoscaler"`
+	Saturated                  bool      `json:"saturated"`
+	DemandedReplicas           int       `json:"demandedReplicas"`
+	MaxReplicas                int       `json:"maxReplicas"`
+	BorrowedReplicas           int       `json:"borrowedReplicas"`
+	ShortfallReplicas          int       `json:"shortfallReplicas"`
+	Message                    string    `json:"message"`
+	Time                       time.Time `json:"time"`
+}
+
+// notifyOverflowTransition POSTs the notification to the overflow webhook when the CapacitySaturated condition changes.
+// It's best-effort, so that a failed notification is recorded as an event and never fails the reconciliation.
+func (r *HorizontalRunnerAutoscalerReconciler) notifyOverflowTransition(ctx context.Context, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler, capacity *overflowCapacity, o overflow, saturated metav1.Condition) {
+	if hra.Spec.Overflow == nil || hra.Spec.Overflow.WebhookURL == "" {
+		return
+	}
+
+	prev := meta.FindStatusCondition(hra.Status.Conditions, CapacitySaturatedConditionType)
+	if (prev == nil && saturated.Status != metav1.ConditionTrue) || (prev != nil && prev.Status == saturated.Status) {
+		return
+	}
+
+	n := OverflowNotification{
+		Namespace:                  hra.Namespace,
+		HorizontalRunnerAutoscaler: hra.Name,
+		Saturated:                  saturated.Status == metav1.ConditionTrue,
+		DemandedReplicas:           o.demanded,
+		MaxReplicas:                capacity.maxReplicas,
+		BorrowedReplicas:           o.borrowed,
+		ShortfallReplicas:          o.shortfall,
+		Message:                    saturated.Message,
+		Time:                       r.now(),
+	}
+
+	if err := notifyOverflow(ctx, hra.Spec.Overflow.WebhookURL, n); err != nil {
+		log.Error(err, "Could not notify the overflow webhook")
+
+		r.Recorder.Event(&hra, corev1.EventTypeWarning, "OverflowNotificationFailed", err.Error())
+
+		return
+	}
+
+	log.V(1).Info("Notified the overflow webhook", "saturated", n.Saturated, "shortfall", o.shortfall)
+}
+
+func notifyOverflow(ctx context.Context, url string, n OverflowNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, overflowNotificationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("POST %s responded with status %d", url, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch
+
+// overflowReservationTTL is how long the capacity reservation published to a HorizontalRunnerAutoscaler in another cluster lasts.
+// It's renewed while the shortfall lasts, and expires in case the publisher is gone without withdrawing it.
+const overflowReservationTTL = 10 * time.Minute
+
+// overflowShortfallToPublish returns the shortfall replicas to publish to the overflow sinks, along with the time until
+// they are published when the CapacitySaturated condition in the conditions hasn't been True for PublishAfterSeconds yet.
+func overflowShortfallToPublish(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, conditions []metav1.Condition, o overflow) (int, time.Duration) {
+	saturated := meta.FindStatusCondition(conditions, CapacitySaturatedConditionType)
+	if saturated == nil || saturated.Status != metav1.ConditionTrue || o.shortfall == 0 {
+		return 0, 0
+	}
+
+	var after time.Duration
+	if s := hra.Spec.Overflow.PublishAfterSeconds; s != nil {
+		after = time.Duration(*s) * time.Second
+	}
+
+	if wait := saturated.LastTransitionTime.Add(after).Sub(now); wait > 0 {
+		return 0, wait
+	}
+
+	return o.shortfall, 0
+}
+
+// publishShortfall publishes the shortfall replicas to the overflow sinks of the HRA, and returns whether all of them succeeded.
+// A sink that failed is recorded as an event and retried on the next reconciliation, without failing the reconciliation.
+func (r *HorizontalRunnerAutoscalerReconciler) publishShortfall(ctx context.Context, log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, n OverflowNotification) bool {
+	ok := true
+
+	for _, s := range hra.Spec.Overflow.Sinks {
+		var err error
+
+		switch {
+		case s.ConfigMap != "":
+			err = r.publishShortfallToConfigMap(ctx, hra, s.ConfigMap, n)
+		case s.WebhookURL != "":
+			if getIntOrDefault(hra.Status.PublishedShortfallReplicas, 0) != n.ShortfallReplicas {
+				err = notifyOverflow(ctx, s.WebhookURL, n)
+			}
+		case s.HorizontalRunnerAutoscaler != nil:
+			if !r.Federation {
+				log.V(1).Info("Skipped publishing the shortfall replicas to the horizontalrunnerautoscaler in another cluster, as federation is disabled", "sink", s.Name)
+
+				continue
+			}
+
+			err = r.publishShortfallToHorizontalRunnerAutoscaler(ctx, now, hra, *s.HorizontalRunnerAutoscaler, n.ShortfallReplicas)
+		}
+
+		if err != nil {
+			ok = false
+
+			log.Error(err, "Could not publish the shortfall replicas to the overflow sink", "sink", s.Name, "shortfall", n.ShortfallReplicas)
+
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, "OverflowPublishFailed", fmt.Sprintf("Could not publish %d shortfall replicas to %s: %v", n.ShortfallReplicas, s.Name, err))
+		}
+	}
+
+	if ok && getIntOrDefault(hra.Status.PublishedShortfallReplicas, 0) != n.ShortfallReplicas {
+		log.Info("Published the shortfall replicas to the overflow sinks", "shortfall", n.ShortfallReplicas)
+	}
+
+	return ok
+}
+
+// publishShortfallToConfigMap sets the key named after the HRA in the ConfigMap to the notification.
+// The ConfigMap is left as is while the notification is unchanged apart from its time.
+func (r *HorizontalRunnerAutoscalerReconciler) publishShortfallToConfigMap(ctx context.Context, hra v1alpha1.HorizontalRunnerAutoscaler, name string, n OverflowNotification) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: name}, &cm); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		data, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: hra.Namespace, Name: name},
+			Data:       map[string]string{hra.Name: string(data)},
+		}
+
+		return r.Create(ctx, &cm)
+	}
+
+	if v, ok := cm.Data[hra.Name]; ok {
+		var published OverflowNotification
+		if err := json.Unmarshal([]byte(v), &published); err == nil {
+			unchanged := n
+			unchanged.Time = published.Time
+
+			if published == unchanged {
+				return nil
+			}
+		}
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	updated := cm.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string]string{}
+	}
+	updated.Data[hra.Name] = string(data)
+
+	return r.Patch(ctx, updated, client.MergeFrom(&cm))
+}
+
+// publishShortfallToHorizontalRunnerAutoscaler sets the capacity reservation of the HRA in another cluster to the shortfall replicas,
+// renewing it when half of its TTL has passed, and removes it once the shortfall is gone.
+func (r *HorizontalRunnerAutoscalerReconciler) publishShortfallToHorizontalRunnerAutoscaler(ctx context.Context, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, sink v1alpha1.OverflowHorizontalRunnerAutoscalerSink, shortfall int) error {
+	remoteClient, err := r.federationClient(ctx, hra.Namespace, sink.KubeconfigSecretRef)
+	if err != nil {
+		return err
+	}
+
+	namespace := sink.Namespace
+	if namespace == "" {
+		namespace = hra.Namespace
+	}
+
+	reservationName := sink.ReservationName
+	if reservationName == "" {
+		reservationName = fmt.Sprintf("overflow-%s-%s", hra.Namespace, hra.Name)
+	}
+
+	var remote v1alpha1.HorizontalRunnerAutoscaler
+	if err := remoteClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: sink.Name}, &remote); err != nil {
+		return err
+	}
+
+	var reservations []v1alpha1.CapacityReservation
+
+	var current *v1alpha1.CapacityReservation
+	for i, cr := range remote.Spec.CapacityReservations {
+		if cr.Name == reservationName {
+			current = &remote.Spec.CapacityReservations[i]
+			continue
+		}
+
+		reservations = append(reservations, cr)
+	}
+
+	if shortfall == 0 {
+		if current == nil {
+			return nil
+		}
+	} else {
+		if current != nil && current.Replicas == shortfall && current.ExpirationTime.Time.After(now.Add(overflowReservationTTL/2)) {
+			return nil
+		}
+
+		reservations = append(reservations, v1alpha1.CapacityReservation{
+			Name:           reservationName,
+			Replicas:       shortfall,
+			ExpirationTime: metav1.Time{Time: now.Add(overflowReservationTTL)},
+		})
+	}
+
+	updated := remote.DeepCopy()
+	updated.Spec.CapacityReservations = reservations
+
+	// The optimistic lock prevents the reservations added concurrently, e.g. by the webhook-based autoscaler, from being overwritten.
+	return remoteClient.Patch(ctx, updated, client.MergeFromWithOptions(&remote, client.MergeFromWithOptimisticLock{}))
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOverflowShortfallToPublish(t *testing.T) {
+	now := time.Now()
+
+	saturatedSince := func(d time.Duration) []metav1.Condition {
+		return []metav1.Condition{{Type: CapacitySaturatedConditionType, Status: metav1.ConditionTrue, LastTransitionTime: metav1.Time{Time: now.Add(-d)}}}
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			Overflow: &v1alpha1.HorizontalRunnerAutoscalerOverflow{PublishAfterSeconds: intPtr(300)},
+		},
+	}
+
+	testcases := []struct {
+		description string
+		conditions  []metav1.Condition
+		overflow    overflow
+		want        int
+		wantWait    time.Duration
+	}{
+		{
+			description: "not saturated",
+			conditions:  []metav1.Condition{{Type: CapacitySaturatedConditionType, Status: metav1.ConditionFalse}},
+			overflow:    overflow{demanded: 5},
+		},
+		{
+			description: "saturated shorter than publishAfterSeconds",
+			conditions:  saturatedSince(time.Minute),
+			overflow:    overflow{demanded: 14, shortfall: 4},
+			wantWait:    4 * time.Minute,
+		},
+		{
+			description: "saturated longer than publishAfterSeconds",
+			conditions:  saturatedSince(10 * time.Minute),
+			overflow:    overflow{demanded: 14, shortfall: 4},
+			want:        4,
+		},
+	}
+
+	for _, tc := range testcases {
+		got, wait := overflowShortfallToPublish(now, hra, tc.conditions, tc.overflow)
+		if got != tc.want || wait != tc.wantWait {
+			t.Errorf("%s: want %d after %s, got %d after %s", tc.description, tc.want, tc.wantWait, got, wait)
+		}
+	}
+}
+
+func TestPublishShortfall(t *testing.T) {
+	ctx := context.Background()
+
+	var received []OverflowNotification
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var n OverflowNotification
+		if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		received = append(received, n)
+	}))
+	defer server.Close()
+
+	backup := fake.NewClientBuilder().WithScheme(sc).WithObjects(&v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "burst", Name: "backup"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			CapacityReservations: []v1alpha1.CapacityReservation{{Name: "release-train", Replicas: 2}},
+		},
+	}).Build()
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client: fake.NewClientBuilder().WithScheme(sc).WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "backup"},
+			Data:       map[string][]byte{federationKubeconfigSecretKey: []byte("backup")},
+		}).Build(),
+		Recorder:   record.NewFakeRecorder(10),
+		Federation: true,
+		NewFederationClient: func(kubeconfig []byte) (client.Client, error) {
+			return backup, nil
+		},
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			Overflow: &v1alpha1.HorizontalRunnerAutoscalerOverflow{
+				Sinks: []v1alpha1.OverflowSink{
+					{Name: "configmap", ConfigMap: "overflow"},
+					{Name: "webhook", WebhookURL: server.URL},
+					{Name: "backup", HorizontalRunnerAutoscaler: &v1alpha1.OverflowHorizontalRunnerAutoscalerSink{
+						KubeconfigSecretRef: v1alpha1.SecretReference{Name: "backup"},
+						Namespace:           "burst",
+						Name:                "backup",
+					}},
+				},
+			},
+		},
+	}
+
+	now := time.Now()
+
+	publish := func(shortfall int) {
+		t.Helper()
+
+		n := OverflowNotification{Namespace: "default", HorizontalRunnerAutoscaler: "example", Saturated: shortfall > 0, ShortfallReplicas: shortfall, Time: now}
+
+		if !r.publishShortfall(ctx, logr.Discard(), now, hra, n) {
+			t.Fatalf("failed to publish %d shortfall replicas", shortfall)
+		}
+
+		hra.Status.PublishedShortfallReplicas = &shortfall
+	}
+
+	published := func() (OverflowNotification, []v1alpha1.CapacityReservation) {
+		t.Helper()
+
+		var cm corev1.ConfigMap
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "overflow"}, &cm); err != nil {
+			t.Fatal(err)
+		}
+
+		var n OverflowNotification
+		if err := json.Unmarshal([]byte(cm.Data["example"]), &n); err != nil {
+			t.Fatal(err)
+		}
+
+		var remote v1alpha1.HorizontalRunnerAutoscaler
+		if err := backup.Get(ctx, types.NamespacedName{Namespace: "burst", Name: "backup"}, &remote); err != nil {
+			t.Fatal(err)
+		}
+
+		return n, remote.Spec.CapacityReservations
+	}
+
+	publish(4)
+	publish(4)
+
+	n, reservations := published()
+	if n.ShortfallReplicas != 4 || !n.Saturated {
+		t.Errorf("unexpected notification in the configmap: %+v", n)
+	}
+
+	if len(reservations) != 2 || reservations[1].Name != "overflow-default-example" || reservations[1].Replicas != 4 {
+		t.Errorf("unexpected capacity reservations: %+v", reservations)
+	}
+
+	if len(received) != 1 || received[0].ShortfallReplicas != 4 {
+		t.Errorf("the webhook must be notified only when the shortfall changes: %+v", received)
+	}
+
+	publish(0)
+
+	n, reservations = published()
+	if n.ShortfallReplicas != 0 || n.Saturated {
+		t.Errorf("unexpected notification in the configmap: %+v", n)
+	}
+
+	if len(reservations) != 1 || reservations[0].Name != "release-train" {
+		t.Errorf("the capacity reservation must be withdrawn: %+v", reservations)
+	}
+
+	if len(received) != 2 || received[1].ShortfallReplicas != 0 {
+		t.Errorf("the webhook must be notified of the withdrawal: %+v", received)
+	}
+}
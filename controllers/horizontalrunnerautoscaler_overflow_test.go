@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetOverflowCapacity(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	newHRA := func(name string, priority, maxReplicas, desired int, borrowFrom string, borrowed int) *v1alpha1.HorizontalRunnerAutoscaler {
+		hra := &v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				Priority:    intPtr(priority),
+				MaxReplicas: intPtr(maxReplicas),
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(desired)},
+		}
+
+		if borrowFrom != "" {
+			hra.Spec.Overflow = &v1alpha1.HorizontalRunnerAutoscalerOverflow{BorrowFrom: &v1alpha1.OverflowBorrow{Name: borrowFrom}}
+		}
+
+		if borrowed > 0 {
+			hra.Status.BorrowedReplicas = intPtr(borrowed)
+		}
+
+		return hra
+	}
+
+	testcases := []struct {
+		description    string
+		objects        []client.Object
+		name           string
+		borrowMax      *int
+		wantMax        int
+		wantBorrowable int
+		wantEvent      bool
+	}{
+		{
+			description: "no overflow",
+			objects:     []client.Object{newHRA("critical", 2, 10, 10, "", 0)},
+			name:        "critical",
+			wantMax:     10,
+		},
+		{
+			description:    "borrower can borrow the unused replicas of the lender",
+			objects:        []client.Object{newHRA("critical", 2, 10, 10, "batch", 0), newHRA("batch", 1, 20, 12, "", 0)},
+			name:           "critical",
+			wantMax:        10,
+			wantBorrowable: 8,
+		},
+		{
+			description:    "borrowable replicas are limited by borrowFrom.maxReplicas",
+			objects:        []client.Object{newHRA("critical", 2, 10, 10, "batch", 0), newHRA("batch", 1, 20, 12, "", 0)},
+			name:           "critical",
+			borrowMax:      intPtr(3),
+			wantMax:        10,
+			wantBorrowable: 3,
+		},
+		{
+			description:    "replicas already borrowed remain borrowable",
+			objects:        []client.Object{newHRA("critical", 2, 10, 15, "batch", 5), newHRA("batch", 1, 20, 15, "", 0)},
+			name:           "critical",
+			wantMax:        10,
+			wantBorrowable: 5,
+		},
+		{
+			description: "lender's maxReplicas is lowered by the borrowed replicas",
+			objects:     []client.Object{newHRA("critical", 2, 10, 15, "batch", 5), newHRA("batch", 1, 20, 15, "", 0)},
+			name:        "batch",
+			wantMax:     15,
+		},
+		{
+			description: "lender with a higher priority doesn't lend",
+			objects:     []client.Object{newHRA("critical", 2, 10, 10, "batch", 0), newHRA("batch", 3, 20, 0, "", 0)},
+			name:        "critical",
+			wantMax:     10,
+			wantEvent:   true,
+		},
+		{
+			description: "missing lender doesn't lend",
+			objects:     []client.Object{newHRA("critical", 2, 10, 10, "batch", 0)},
+			name:        "critical",
+			wantMax:     10,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var self v1alpha1.HorizontalRunnerAutoscaler
+			for _, o := range tc.objects {
+				if o.GetName() == tc.name {
+					self = *o.(*v1alpha1.HorizontalRunnerAutoscaler)
+				}
+			}
+
+			if tc.borrowMax != nil {
+				self.Spec.Overflow.BorrowFrom.MaxReplicas = tc.borrowMax
+			}
+
+			recorder := record.NewFakeRecorder(1)
+
+			r := &HorizontalRunnerAutoscalerReconciler{
+				Client:   clientfake.NewClientBuilder().WithScheme(sc).WithObjects(tc.objects...).Build(),
+				Log:      logr.Discard(),
+				Recorder: recorder,
+			}
+
+			got, err := r.getOverflowCapacity(context.Background(), self)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.maxReplicas != tc.wantMax || got.borrowable != tc.wantBorrowable {
+				t.Errorf("unexpected capacity: want max %d and borrowable %d, got %+v", tc.wantMax, tc.wantBorrowable, *got)
+			}
+
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tc.wantEvent {
+				t.Errorf("unexpected event: want %v, got %v", tc.wantEvent, gotEvent)
+			}
+		})
+	}
+}
+
+func TestComputeOverflow(t *testing.T) {
+	testcases := []struct {
+		capacity overflowCapacity
+		demanded int
+		desired  int
+		want     overflow
+	}{
+		{capacity: overflowCapacity{maxReplicas: 10}, demanded: 5, desired: 5, want: overflow{demanded: 5}},
+		{capacity: overflowCapacity{maxReplicas: 10}, demanded: 14, desired: 10, want: overflow{demanded: 14, shortfall: 4}},
+		{capacity: overflowCapacity{maxReplicas: 10, borrowable: 3}, demanded: 14, desired: 13, want: overflow{demanded: 14, shortfall: 1, borrowed: 3}},
+		{capacity: overflowCapacity{maxReplicas: 10, borrowable: 8}, demanded: 14, desired: 12, want: overflow{demanded: 14, borrowed: 2}},
+		{capacity: overflowCapacity{maxReplicas: 10, borrowable: 8}, demanded: 8, desired: 8, want: overflow{demanded: 8}},
+	}
+
+	for i, tc := range testcases {
+		if got := computeOverflow(tc.capacity, tc.demanded, tc.desired); got != tc.want {
+			t.Errorf("[%d] unexpected overflow: want %+v, got %+v", i, tc.want, got)
+		}
+	}
+}
+
+func TestNotifyOverflowTransition(t *testing.T) {
+	var received []OverflowNotification
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var n OverflowNotification
+		if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		received = append(received, n)
+	}))
+	defer server.Close()
+
+	saturated := overflow{demanded: 14, shortfall: 4}
+	unsaturated := overflow{demanded: 5}
+
+	testcases := []struct {
+		description string
+		previous    []metav1.Condition
+		overflow    overflow
+		want        bool
+	}{
+		{
+			description: "first saturation is notified",
+			overflow:    saturated,
+			want:        true,
+		},
+		{
+			description: "first reconciliation within the capacity isn't notified",
+			overflow:    unsaturated,
+		},
+		{
+			description: "continued saturation isn't notified",
+			previous:    []metav1.Condition{{Type: CapacitySaturatedConditionType, Status: metav1.ConditionTrue}},
+			overflow:    saturated,
+		},
+		{
+			description: "recovery is notified",
+			previous:    []metav1.Condition{{Type: CapacitySaturatedConditionType, Status: metav1.ConditionTrue}},
+			overflow:    unsaturated,
+			want:        true,
+		},
+	}
+
+	for _, tc := range testcases {
+		received = nil
+
+		hra := v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				Overflow: &v1alpha1.HorizontalRunnerAutoscalerOverflow{WebhookURL: server.URL},
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{Conditions: tc.previous},
+		}
+
+		r := &HorizontalRunnerAutoscalerReconciler{Recorder: record.NewFakeRecorder(1)}
+
+		r.notifyOverflowTransition(context.Background(), logr.Discard(), hra, &overflowCapacity{maxReplicas: 10}, tc.overflow, capacitySaturatedCondition(hra, tc.overflow))
+
+		if got := len(received) == 1; got != tc.want {
+			t.Errorf("%s: unexpected notifications: %v", tc.description, received)
+			continue
+		}
+
+		if tc.want && (received[0].Saturated != (tc.overflow.shortfall > 0) || received[0].ShortfallReplicas != tc.overflow.shortfall || received[0].MaxReplicas != 10) {
+			t.Errorf("%s: unexpected notification: %+v", tc.description, received[0])
+		}
+	}
+}
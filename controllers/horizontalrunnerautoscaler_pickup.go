@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hasQueuedAndInProgressWorkflowRunsMetric(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	for _, m := range hra.Spec.Metrics {
+		if !m.Suspended && m.Type == v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns {
+			return true
+		}
+	}
+
+	return false
+}
+
+// confirmJobPickups confirms the job pickup of the runners held by the pickup reservations of the HRA
+// against the runners that are busy on GitHub.
+// It returns the remaining reservations and the number of replicas that must be kept not to scale down
+// either busy runners or runners that are going to pick up jobs.
+func (r *HorizontalRunnerAutoscalerReconciler) confirmJobPickups(ctx context.Context, now time.Time, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler) ([]v1alpha1.PickupReservation, int, error) {
+	if len(hra.Status.PickupReservations) == 0 {
+		return nil, 0, nil
+	}
+
+	runnerMap, err := st.getRunnerMap()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// ListRunners will return all runners managed by GitHub - not restricted to ns
+	runners, err := r.githubClient(st).ListRunners(ctx, st.enterprise, st.org, st.repo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	busy := map[string]time.Time{}
+
+	for _, runner := range runners {
+		if created, ok := runnerMap[runner.GetName()]; ok && runner.GetBusy() {
+			busy[runner.GetName()] = created
+		}
+	}
+
+	reservations, held := confirmPickupReservations(now, hra.Status.PickupReservations, busy)
+
+	return reservations, len(busy) + held, nil
+}
+
+// confirmPickupReservations drops expired reservations, and records each busy runner as confirmed
+// in the latest reservation created before the runner.
+// busy is the creation times of the busy runners keyed by the runner names.
+// It returns the reservations that still hold replicas and the number of the held replicas.
+func confirmPickupReservations(now time.Time, reservations []v1alpha1.PickupReservation, busy map[string]time.Time) ([]v1alpha1.PickupReservation, int) {
+	var active []v1alpha1.PickupReservation
+
+	confirmed := map[string]struct{}{}
+
+	for _, r := range reservations {
+		if !r.ExpirationTime.Time.After(now) {
+			continue
+		}
+
+		active = append(active, *r.DeepCopy())
+
+		for _, name := range r.ConfirmedRunners {
+			confirmed[name] = struct{}{}
+		}
+	}
+
+	var names []string
+	for name := range busy {
+		if _, ok := confirmed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		created := busy[name]
+
+		for i := len(active) - 1; i >= 0; i-- {
+			r := &active[i]
+
+			if r.CreationTime.Time.After(created) || len(r.ConfirmedRunners) >= r.Replicas {
+				continue
+			}
+
+			r.ConfirmedRunners = append(r.ConfirmedRunners, name)
+
+			break
+		}
+	}
+
+	var (
+		remaining []v1alpha1.PickupReservation
+		held      int
+	)
+
+	for _, r := range active {
+		if n := r.Replicas - len(r.ConfirmedRunners); n > 0 {
+			remaining = append(remaining, r)
+			held += n
+		}
+	}
+
+	return remaining, held
+}
+
+func newPickupReservation(now time.Time, replicas int, timeout time.Duration) v1alpha1.PickupReservation {
+	return v1alpha1.PickupReservation{
+		Replicas:       replicas,
+		CreationTime:   metav1.Time{Time: now},
+		ExpirationTime: metav1.Time{Time: now.Add(timeout)},
+	}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfirmPickupReservations(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	reservation := func(createdMinutesAgo, replicas int, confirmed ...string) v1alpha1.PickupReservation {
+		r := newPickupReservation(now.Add(-time.Duration(createdMinutesAgo)*time.Minute), replicas, 10*time.Minute)
+		r.ConfirmedRunners = confirmed
+		return r
+	}
+
+	minutesAgo := func(m int) time.Time {
+		return now.Add(-time.Duration(m) * time.Minute)
+	}
+
+	testcases := []struct {
+		name         string
+		reservations []v1alpha1.PickupReservation
+		busy         map[string]time.Time
+		want         []v1alpha1.PickupReservation
+		wantHeld     int
+	}{
+		{
+			name:         "nothing confirmed",
+			reservations: []v1alpha1.PickupReservation{reservation(1, 2)},
+			want:         []v1alpha1.PickupReservation{reservation(1, 2)},
+			wantHeld:     2,
+		},
+		{
+			name:         "expired",
+			reservations: []v1alpha1.PickupReservation{reservation(10, 2), reservation(1, 1)},
+			want:         []v1alpha1.PickupReservation{reservation(1, 1)},
+			wantHeld:     1,
+		},
+		{
+			name:         "busy runner created before the scale up",
+			reservations: []v1alpha1.PickupReservation{reservation(1, 2)},
+			busy:         map[string]time.Time{"old": minutesAgo(5)},
+			want:         []v1alpha1.PickupReservation{reservation(1, 2)},
+			wantHeld:     2,
+		},
+		{
+			name:         "partially confirmed",
+			reservations: []v1alpha1.PickupReservation{reservation(2, 2)},
+			busy:         map[string]time.Time{"new": minutesAgo(1)},
+			want:         []v1alpha1.PickupReservation{reservation(2, 2, "new")},
+			wantHeld:     1,
+		},
+		{
+			name:         "already confirmed",
+			reservations: []v1alpha1.PickupReservation{reservation(2, 2, "new")},
+			busy:         map[string]time.Time{"new": minutesAgo(1)},
+			want:         []v1alpha1.PickupReservation{reservation(2, 2, "new")},
+			wantHeld:     1,
+		},
+		{
+			name:         "fully confirmed",
+			reservations: []v1alpha1.PickupReservation{reservation(2, 2, "new")},
+			busy:         map[string]time.Time{"new": minutesAgo(1), "new2": minutesAgo(1)},
+			wantHeld:     0,
+		},
+		{
+			name:         "confirmed in the latest reservation created before the runner",
+			reservations: []v1alpha1.PickupReservation{reservation(5, 1), reservation(3, 1), reservation(1, 1)},
+			busy:         map[string]time.Time{"a": minutesAgo(2), "b": minutesAgo(2)},
+			want:         []v1alpha1.PickupReservation{reservation(1, 1)},
+			wantHeld:     1,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, held := confirmPickupReservations(now, tc.reservations, tc.busy)
+
+			if held != tc.wantHeld {
+				t.Errorf("unexpected held replicas: want %d, got %d", tc.wantHeld, held)
+			}
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected reservations: (-want +got)\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewPickupReservation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	got := newPickupReservation(now, 3, 5*time.Minute)
+
+	want := v1alpha1.PickupReservation{
+		Replicas:       3,
+		CreationTime:   metav1.Time{Time: now},
+		ExpirationTime: metav1.Time{Time: now.Add(5 * time.Minute)},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected reservation: (-want +got)\n%s", d)
+	}
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	githubmetrics "github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DefaultGitHubAPIRateLimitThreshold is the default number of remaining GitHub API requests below which
+// HRAs stop polling the GitHub API until the rate limit window resets.
+const DefaultGitHubAPIRateLimitThreshold = 100
+
+// rateLimitBackoff returns how long the HRA should wait before querying the GitHub API again,
+// which is until the rate limit window resets when the remaining requests are below the threshold.
+// It returns zero when the HRA can query the API now.
+func rateLimitBackoff(now time.Time, rl githubmetrics.RateLimit, threshold int) time.Duration {
+	if threshold <= 0 || rl.Remaining >= threshold {
+		return 0
+	}
+
+	if rl.Reset.IsZero() {
+		return retryDelayOnGitHubAPIRateLimitError
+	}
+
+	return rl.Reset.Sub(now)
+}
+
+// rateLimitErrorBackoff returns how long the HRA should wait after the GitHub API rejected a request for exceeding
+// the primary or the secondary rate limit. ok is false when err isn't caused by a rate limit.
+func rateLimitErrorBackoff(now time.Time, err error) (backoff time.Duration, ok bool) {
+	var rateLimitErr *gogithub.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		if d := rateLimitErr.Rate.Reset.Sub(now); d > 0 {
+			return d, true
+		}
+
+		return retryDelayOnGitHubAPIRateLimitError, true
+	}
+
+	var abuseErr *gogithub.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if d := abuseErr.GetRetryAfter(); d > 0 {
+			return d, true
+		}
+
+		return retryDelayOnGitHubAPIRateLimitError, true
+	}
+
+	return 0, false
+}
+
+// deferForRateLimit keeps the desired replicas of the scale target as is and requeues the HRA after the backoff,
+// recording why in the ScalingActive condition and the event, instead of failing the reconciliation.
+func (r *HorizontalRunnerAutoscalerReconciler) deferForRateLimit(ctx context.Context, log logr.Logger, hra v1alpha1.HorizontalRunnerAutoscaler, backoff time.Duration, cause error) (ctrl.Result, error) {
+	msg := fmt.Sprintf("Deferring the computation of the desired replicas for %s until the GitHub API rate limit resets: %v", backoff.Round(time.Second), cause)
+
+	log.Info(msg)
+
+	r.Recorder.Event(&hra, corev1.EventTypeWarning, conditionReasonRateLimited, msg)
+
+	if err := r.patchFailureCondition(ctx, hra, ScalingActiveConditionType, conditionReasonRateLimited, errors.New(msg)); err != nil {
+		log.Error(err, "Could not update the ScalingActive condition")
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
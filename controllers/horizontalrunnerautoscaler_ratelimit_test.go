@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	githubmetrics "github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRateLimitBackoff(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		description string
+		rl          githubmetrics.RateLimit
+		threshold   int
+		want        time.Duration
+	}{
+		{
+			description: "above threshold",
+			rl:          githubmetrics.RateLimit{Remaining: 100, Reset: now.Add(10 * time.Minute)},
+			threshold:   100,
+		},
+		{
+			description: "below threshold",
+			rl:          githubmetrics.RateLimit{Remaining: 99, Reset: now.Add(10 * time.Minute)},
+			threshold:   100,
+			want:        10 * time.Minute,
+		},
+		{
+			description: "already reset",
+			rl:          githubmetrics.RateLimit{Remaining: 0, Reset: now.Add(-time.Minute)},
+			threshold:   100,
+		},
+		{
+			description: "unknown reset",
+			rl:          githubmetrics.RateLimit{Remaining: 0},
+			threshold:   100,
+			want:        retryDelayOnGitHubAPIRateLimitError,
+		},
+		{
+			description: "disabled",
+			rl:          githubmetrics.RateLimit{Remaining: 0, Reset: now.Add(10 * time.Minute)},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got := rateLimitBackoff(now, tc.rl, tc.threshold)
+			if got < 0 {
+				got = 0
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected backoff: want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitErrorBackoff(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	retryAfter := 2 * time.Minute
+
+	testcases := []struct {
+		description string
+		err         error
+		want        time.Duration
+		wantOK      bool
+	}{
+		{
+			description: "rate limit",
+			err:         fmt.Errorf("listing workflow runs: %w", &gogithub.RateLimitError{Rate: gogithub.Rate{Reset: gogithub.Timestamp{Time: now.Add(10 * time.Minute)}}}),
+			want:        10 * time.Minute,
+			wantOK:      true,
+		},
+		{
+			description: "rate limit already reset",
+			err:         &gogithub.RateLimitError{Rate: gogithub.Rate{Reset: gogithub.Timestamp{Time: now.Add(-time.Minute)}}},
+			want:        retryDelayOnGitHubAPIRateLimitError,
+			wantOK:      true,
+		},
+		{
+			description: "secondary rate limit",
+			err:         &gogithub.AbuseRateLimitError{RetryAfter: &retryAfter},
+			want:        retryAfter,
+			wantOK:      true,
+		},
+		{
+			description: "secondary rate limit without retry-after",
+			err:         &gogithub.AbuseRateLimitError{},
+			want:        retryDelayOnGitHubAPIRateLimitError,
+			wantOK:      true,
+		},
+		{
+			description: "other error",
+			err:         errors.New("not found"),
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, ok := rateLimitErrorBackoff(now, tc.err)
+
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("unexpected backoff: want %s (%v), got %s (%v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestHorizontalRunnerAutoscalerReconciler_RateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	const credentials = "default/test-hra-rate-limit"
+	defer githubmetrics.DeleteCredentials(credentials)
+
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "API rate limit exceeded for installation ID 1."}`)
+	}))
+	defer server.Close()
+
+	ghc, err := (&github.Config{Token: "token", Credentials: credentials}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ghc.Client.BaseURL = baseURL
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Replicas: intPtr(2),
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+			MinReplicas:    intPtr(1),
+			MaxReplicas:    intPtr(10),
+			Metrics: []v1alpha1.MetricSpec{
+				{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns, RepositoryNames: []string{"valid"}},
+			},
+		},
+	}
+
+	c := clientfake.NewFakeClientWithScheme(sc, rd, hra)
+
+	recorder := record.NewFakeRecorder(10)
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client:                      c,
+		Log:                         logr.Discard(),
+		Recorder:                    recorder,
+		GitHubClient:                ghc,
+		GitHubAPIRateLimitThreshold: 100,
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	reconcile := func() ctrl.Result {
+		t.Helper()
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if d := time.Until(reset); res.RequeueAfter <= 0 || res.RequeueAfter > d+time.Second || res.RequeueAfter < d-time.Minute {
+			t.Errorf("unexpected requeue after: want about %s, got %s", d, res.RequeueAfter)
+		}
+
+		var got v1alpha1.HorizontalRunnerAutoscaler
+		if err := c.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		active := meta.FindStatusCondition(got.Status.Conditions, ScalingActiveConditionType)
+		if active == nil || active.Status != metav1.ConditionFalse || active.Reason != conditionReasonRateLimited {
+			t.Errorf("unexpected ScalingActive condition: %+v", active)
+		}
+
+		select {
+		case e := <-recorder.Events:
+			t.Logf("event: %s", e)
+		default:
+			t.Errorf("expected an event for the rate limit")
+		}
+
+		return res
+	}
+
+	// The first reconciliation hits the rate limit
+	reconcile()
+
+	if requests != 1 {
+		t.Fatalf("unexpected number of requests: want 1, got %d", requests)
+	}
+
+	if rl, ok := ghc.RateLimit(); !ok || rl.Limit != 5000 || rl.Remaining != 0 || !rl.Reset.Equal(reset) {
+		t.Errorf("unexpected rate limit of the client: %+v (%v)", rl, ok)
+	}
+
+	// The next reconciliation waits for the rate limit to reset without querying the GitHub API
+	reconcile()
+
+	if requests != 1 {
+		t.Errorf("unexpected requests made while rate limited: %d", requests-1)
+	}
+
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if *updated.Spec.Replicas != 2 {
+		t.Errorf("unexpected replicas: want 2, got %d", *updated.Spec.Replicas)
+	}
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const conditionReasonRecommendOnly = "RecommendOnly"
+
+// isRecommendOnly returns true when the HRA only recommends the desired replicas without updating the scale target.
+func isRecommendOnly(hra v1alpha1.HorizontalRunnerAutoscaler) bool {
+	return hra.Spec.Policy == v1alpha1.HorizontalRunnerAutoscalerPolicyRecommendOnly
+}
+
+// recommendOnlyCondition returns the AbleToScale condition of the HRA whose policy is RecommendOnly,
+// which tells the scale target is left as is.
+func recommendOnlyCondition(currentReplicas, recommendedReplicas int) metav1.Condition {
+	return metav1.Condition{
+		Type:    AbleToScaleConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonRecommendOnly,
+		Message: fmt.Sprintf("The scale target is left at %d replicas while %d replicas are recommended, as the policy is RecommendOnly", currentReplicas, recommendedReplicas),
+	}
+}
+
+// recordRecommendation emits an event when the recommended replicas of the HRA whose policy is RecommendOnly changes,
+// so that the recommendations can be compared with the actual replicas of the scale target.
+func (r *HorizontalRunnerAutoscalerReconciler) recordRecommendation(hra v1alpha1.HorizontalRunnerAutoscaler, st scaleTarget, currentReplicas, recommendedReplicas int, reason string) {
+	if last := hra.Status.RecommendedReplicas; last != nil && *last == recommendedReplicas {
+		return
+	}
+
+	r.Recorder.Event(&hra, corev1.EventTypeNormal, "ReplicasRecommended", fmt.Sprintf(
+		"Recommended %d replicas by %s while the %s has %d replicas", recommendedReplicas, reason, st.kind, currentReplicas,
+	))
+}
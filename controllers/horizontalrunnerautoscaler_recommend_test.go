@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHorizontalRunnerAutoscalerReconciler_RecommendOnly(t *testing.T) {
+	ctx := context.Background()
+
+	testcases := []struct {
+		description     string
+		policy          string
+		wantReplicas    int
+		wantRecommended *int
+		wantAbleToScale metav1.ConditionStatus
+		wantEvent       string
+	}{
+		{
+			description:     "auto",
+			wantReplicas:    1,
+			wantAbleToScale: metav1.ConditionTrue,
+			wantEvent:       "ScaledDown",
+		},
+		{
+			description:     "recommend only",
+			policy:          v1alpha1.HorizontalRunnerAutoscalerPolicyRecommendOnly,
+			wantReplicas:    3,
+			wantRecommended: intPtr(1),
+			wantAbleToScale: metav1.ConditionFalse,
+			wantEvent:       "ReplicasRecommended",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			noRuns := `{"total_count": 0, "workflow_runs": []}`
+
+			server := fake.NewServer(
+				fake.WithListRepositoryWorkflowRunsResponse(200, noRuns, noRuns, noRuns),
+				fake.WithListWorkflowJobsResponse(200, nil),
+				fake.WithListRunnersResponse(200, fake.RunnersListBody),
+			)
+			defer server.Close()
+
+			rd := &v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Replicas: intPtr(3),
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{
+							RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+						},
+					},
+				},
+			}
+
+			hra := &v1alpha1.HorizontalRunnerAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example"},
+					MinReplicas:    intPtr(1),
+					MaxReplicas:    intPtr(10),
+					Policy:         tc.policy,
+					Metrics: []v1alpha1.MetricSpec{
+						{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+					},
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(3)},
+			}
+
+			c := clientfake.NewFakeClientWithScheme(sc, rd, hra)
+
+			recorder := record.NewFakeRecorder(10)
+
+			r := &HorizontalRunnerAutoscalerReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     recorder,
+				GitHubClient: newGithubClient(server),
+			}
+
+			key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated v1alpha1.RunnerDeployment
+			if err := c.Get(ctx, key, &updated); err != nil {
+				t.Fatal(err)
+			}
+
+			if *updated.Spec.Replicas != tc.wantReplicas {
+				t.Errorf("unexpected replicas: want %d, got %d", tc.wantReplicas, *updated.Spec.Replicas)
+			}
+
+			var got v1alpha1.HorizontalRunnerAutoscaler
+			if err := c.Get(ctx, key, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Status.DesiredReplicas == nil || *got.Status.DesiredReplicas != tc.wantReplicas {
+				t.Errorf("unexpected desired replicas: want %d, got %v", tc.wantReplicas, got.Status.DesiredReplicas)
+			}
+
+			if w, g := tc.wantRecommended, got.Status.RecommendedReplicas; (w == nil) != (g == nil) || (w != nil && *w != *g) {
+				t.Errorf("unexpected recommended replicas: want %v, got %v", w, g)
+			}
+
+			if tc.policy == v1alpha1.HorizontalRunnerAutoscalerPolicyRecommendOnly && len(got.Status.ScalingHistory) != 0 {
+				t.Errorf("unexpected scaling history: %+v", got.Status.ScalingHistory)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, AbleToScaleConditionType)
+			if cond == nil || cond.Status != tc.wantAbleToScale {
+				t.Errorf("unexpected AbleToScale condition: %+v", cond)
+			}
+
+			select {
+			case e := <-recorder.Events:
+				if !strings.Contains(e, tc.wantEvent) {
+					t.Errorf("unexpected event: want %s, got %q", tc.wantEvent, e)
+				}
+			default:
+				t.Errorf("expected %s event, got none", tc.wantEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AnnotationKeyCapacityReservation is set to the name of the capacity reservation of the HorizontalRunnerAutoscaler
+// each runner of a RunnerDeployment was created for, or whose job the runner picked up.
+// The runner pod inherits it from the runner.
+const AnnotationKeyCapacityReservation = annotationKeyPrefix + "capacity-reservation"
+
+// runnerDeploymentAutoscaler returns the HorizontalRunnerAutoscaler that scales the runner deployment, or nil if there's none.
+func runnerDeploymentAutoscaler(ctx context.Context, c client.Client, namespace, rdName string) (*v1alpha1.HorizontalRunnerAutoscaler, error) {
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+	if err := c.List(ctx, &hras, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range hras.Items {
+		hra := &hras.Items[i]
+
+		if kind := hra.Spec.ScaleTargetRef.Kind; kind != "" && kind != "RunnerDeployment" {
+			continue
+		}
+
+		if hra.Spec.ScaleTargetRef.Name == rdName {
+			return hra, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// findCapacityReservation returns the index of the named capacity reservation unexpired at now, or -1 if there's none.
+func findCapacityReservation(now time.Time, reservations []v1alpha1.CapacityReservation, name string) int {
+	for i, r := range reservations {
+		if r.Name == name && r.ExpirationTime.Time.After(now) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// newCapacityReservationAwareRunnerFactory wraps create so that each new runner is linked to the oldest unexpired capacity reservation
+// of the HorizontalRunnerAutoscaler of the runner deployment that no live runner is linked to yet, by annotating the runner with its name.
+// A runner of a RunnerReplicaSet with multiple sizes is only linked to a reservation of the same size or without a size.
+// The reservations are looked up on the first runner created in the reconciliation, so that it costs nothing unless scaling up.
+func (r *RunnerReplicaSetReconciler) newCapacityReservationAwareRunnerFactory(ctx context.Context, log logr.Logger, rs v1alpha1.RunnerReplicaSet, live []v1alpha1.Runner, create func() client.Object) func() client.Object {
+	rdName := rs.Spec.Template.Labels[LabelKeyRunnerDeploymentName]
+	if rdName == "" {
+		return create
+	}
+
+	var (
+		unlinked []v1alpha1.CapacityReservation
+		loaded   bool
+	)
+
+	load := func() {
+		loaded = true
+
+		hra, err := runnerDeploymentAutoscaler(ctx, r.Client, rs.Namespace, rdName)
+		if err != nil {
+			log.Error(err, "Failed to get the horizontalrunnerautoscaler. Leaving the runners unlinked to capacity reservations")
+
+			return
+		}
+
+		if hra == nil {
+			return
+		}
+
+		linked := map[string]bool{}
+
+		for _, runner := range live {
+			if !runner.DeletionTimestamp.IsZero() {
+				continue
+			}
+
+			if name, ok := getAnnotation(&runner, AnnotationKeyCapacityReservation); ok {
+				linked[name] = true
+			}
+		}
+
+		now := time.Now()
+
+		for _, cr := range hra.Spec.CapacityReservations {
+			if cr.Name == "" || linked[cr.Name] || !cr.ExpirationTime.Time.After(now) {
+				continue
+			}
+
+			unlinked = append(unlinked, cr)
+		}
+
+		sort.SliceStable(unlinked, func(i, j int) bool {
+			return unlinked[i].EffectiveTime.Time.Before(unlinked[j].EffectiveTime.Time)
+		})
+	}
+
+	return func() client.Object {
+		runner := create().(*v1alpha1.Runner)
+
+		if !loaded {
+			load()
+		}
+
+		size, _ := getAnnotation(runner, AnnotationKeyRunnerSize)
+
+		for i, cr := range unlinked {
+			if cr.Size != "" && cr.Size != size {
+				continue
+			}
+
+			setAnnotation(&runner.ObjectMeta, AnnotationKeyCapacityReservation, cr.Name)
+
+			unlinked = append(unlinked[:i], unlinked[i+1:]...)
+
+			break
+		}
+
+		return runner
+	}
+}
+
+// linkRunnerCapacityReservation links the runner that picked up a job to the capacity reservation made for the job,
+// so that the reservation is released when the runner pod completes it, even when the runner was created for another reservation.
+// It does nothing unless the HorizontalRunnerAutoscaler of the runner deployment of the runner has the reservation.
+func linkRunnerCapacityReservation(ctx context.Context, c client.Client, namespace, runnerName, reservation string) error {
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := c.List(ctx, &runners, opts...); err != nil {
+		return err
+	}
+
+	for i := range runners.Items {
+		runner := &runners.Items[i]
+
+		if runner.Name != runnerName {
+			continue
+		}
+
+		if current, _ := getAnnotation(runner, AnnotationKeyCapacityReservation); current == reservation {
+			continue
+		}
+
+		rdName := runner.Labels[LabelKeyRunnerDeploymentName]
+		if rdName == "" {
+			continue
+		}
+
+		hra, err := runnerDeploymentAutoscaler(ctx, c, runner.Namespace, rdName)
+		if err != nil {
+			return err
+		}
+
+		if hra == nil || findCapacityReservation(time.Now(), hra.Spec.CapacityReservations, reservation) < 0 {
+			continue
+		}
+
+		updated := runner.DeepCopy()
+		setAnnotation(&updated.ObjectMeta, AnnotationKeyCapacityReservation, reservation)
+
+		if err := c.Patch(ctx, updated, client.MergeFrom(runner)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseCapacityReservation removes the capacity reservation linked to the runner from its HorizontalRunnerAutoscaler
+// once the runner pod has stopped after running a job, rather than waiting for the reservation to expire
+// when the workflow_job event of the job completion was missed.
+// A runner pod that stopped without ever running a job keeps the reservation, as the job is yet to be run by another runner.
+func (r *RunnerReconciler) releaseCapacityReservation(ctx context.Context, runner v1alpha1.Runner, pod *corev1.Pod, log logr.Logger) error {
+	name, ok := getAnnotation(&runner, AnnotationKeyCapacityReservation)
+	if !ok || !runnerPodOrContainerIsStopped(pod) || !runnerRanJob(runner) {
+		return nil
+	}
+
+	rdName := runner.Labels[LabelKeyRunnerDeploymentName]
+	if rdName == "" {
+		return nil
+	}
+
+	hra, err := runnerDeploymentAutoscaler(ctx, r.Client, runner.Namespace, rdName)
+	if err != nil || hra == nil {
+		return err
+	}
+
+	i := findCapacityReservation(time.Now(), hra.Spec.CapacityReservations, name)
+	if i < 0 {
+		return nil
+	}
+
+	updated := hra.DeepCopy()
+	updated.Spec.CapacityReservations = append(updated.Spec.CapacityReservations[:i:i], hra.Spec.CapacityReservations[i+1:]...)
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(hra)); err != nil {
+		return fmt.Errorf("releasing capacity reservation %s of horizontalrunnerautoscaler %s: %w", name, hra.Name, err)
+	}
+
+	r.Recorder.Event(&runner, corev1.EventTypeNormal, "CapacityReservationReleased", fmt.Sprintf("Released capacity reservation '%s' of horizontalrunnerautoscaler '%s' as the runner completed its job", name, hra.Name))
+	log.Info("Released the capacity reservation of the runner", "reservation", name, "horizontalrunnerautoscaler", hra.Name)
+
+	return nil
+}
+
+// runnerRanJob returns true if the runner is known to have picked up a job,
+// either from the workflow_job events or the runner status synced from GitHub.
+func runnerRanJob(runner v1alpha1.Runner) bool {
+	if len(runner.Status.RecentJobs) > 0 {
+		return true
+	}
+
+	return runner.Status.GitHub != nil && runner.Status.GitHub.LastBusyTime != nil
+}
+
+// capacityReservationRunners returns the runners linked to the unexpired capacity reservations of the HorizontalRunnerAutoscaler,
+// in the order of the reservations.
+func (r *HorizontalRunnerAutoscalerReconciler) capacityReservationRunners(ctx context.Context, log logr.Logger, now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.CapacityReservationRunner {
+	if len(hra.Spec.CapacityReservations) == 0 {
+		return nil
+	}
+
+	if kind := hra.Spec.ScaleTargetRef.Kind; kind != "" && kind != "RunnerDeployment" {
+		return nil
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := r.List(ctx, &runners, client.InNamespace(hra.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: hra.Spec.ScaleTargetRef.Name}); err != nil {
+		log.Error(err, "Failed to list the runners linked to the capacity reservations")
+
+		return nil
+	}
+
+	linked := map[string][]string{}
+
+	for _, runner := range runners.Items {
+		if !runner.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if name, ok := getAnnotation(&runner, AnnotationKeyCapacityReservation); ok {
+			linked[name] = append(linked[name], runner.Name)
+		}
+	}
+
+	var result []v1alpha1.CapacityReservationRunner
+
+	for _, cr := range hra.Spec.CapacityReservations {
+		if cr.Name == "" || !cr.ExpirationTime.Time.After(now) {
+			continue
+		}
+
+		names := linked[cr.Name]
+		sort.Strings(names)
+
+		for _, name := range names {
+			result = append(result, v1alpha1.CapacityReservationRunner{Name: cr.Name, Runner: name})
+		}
+	}
+
+	return result
+}
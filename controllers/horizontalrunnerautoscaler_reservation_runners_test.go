@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newReservationTestHRA(reservations ...v1alpha1.CapacityReservation) *v1alpha1.HorizontalRunnerAutoscaler {
+	return &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-hra", Namespace: "default"},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef:       v1alpha1.ScaleTargetRef{Name: "example"},
+			CapacityReservations: reservations,
+		},
+	}
+}
+
+func newReservationTestRunner(name, reservation string) *v1alpha1.Runner {
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+		},
+	}
+
+	if reservation != "" {
+		setAnnotation(&runner.ObjectMeta, AnnotationKeyCapacityReservation, reservation)
+	}
+
+	return runner
+}
+
+func newTestCapacityReservation(name string, effective, expiration time.Time) v1alpha1.CapacityReservation {
+	return v1alpha1.CapacityReservation{
+		Name:           name,
+		EffectiveTime:  metav1.Time{Time: effective},
+		ExpirationTime: metav1.Time{Time: expiration},
+		Replicas:       1,
+	}
+}
+
+func TestNewCapacityReservationAwareRunnerFactory(t *testing.T) {
+	now := time.Now()
+
+	hra := newReservationTestHRA(
+		newTestCapacityReservation("job-3", now.Add(-1*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("job-1", now.Add(-3*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("job-2", now.Add(-2*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("expired", now.Add(-20*time.Minute), now.Add(-10*time.Minute)),
+		v1alpha1.CapacityReservation{ExpirationTime: metav1.Time{Time: now.Add(10 * time.Minute)}, Replicas: 1},
+	)
+
+	live := []v1alpha1.Runner{*newReservationTestRunner("linked", "job-2")}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(hra).Build()
+
+	r := &RunnerReplicaSetReconciler{Client: c, Scheme: sc}
+
+	rs := v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-rs", Namespace: "default"},
+		Spec: v1alpha1.RunnerReplicaSetSpec{
+			Template: v1alpha1.RunnerTemplate{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelKeyRunnerDeploymentName: "example"}},
+			},
+		},
+	}
+
+	create := r.newCapacityReservationAwareRunnerFactory(context.Background(), logr.Discard(), rs, live, func() client.Object {
+		return &v1alpha1.Runner{}
+	})
+
+	var got []string
+
+	for i := 0; i < 3; i++ {
+		runner := create().(*v1alpha1.Runner)
+
+		name, _ := getAnnotation(runner, AnnotationKeyCapacityReservation)
+		got = append(got, name)
+	}
+
+	// The oldest unlinked reservations are linked first, and the rest of the runners are left unlinked
+	if want := []string{"job-1", "job-3", ""}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected reservations of the new runners: %s", cmp.Diff(want, got))
+	}
+}
+
+func TestNewCapacityReservationAwareRunnerFactory_Sizes(t *testing.T) {
+	now := time.Now()
+
+	large := newTestCapacityReservation("job-1", now.Add(-2*time.Minute), now.Add(10*time.Minute))
+	large.Size = "large"
+
+	small := newTestCapacityReservation("job-2", now.Add(-1*time.Minute), now.Add(10*time.Minute))
+	small.Size = "small"
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(newReservationTestHRA(large, small)).Build()
+
+	r := &RunnerReplicaSetReconciler{Client: c, Scheme: sc}
+
+	rs := v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-rs", Namespace: "default"},
+		Spec: v1alpha1.RunnerReplicaSetSpec{
+			Template: v1alpha1.RunnerTemplate{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{LabelKeyRunnerDeploymentName: "example"}},
+			},
+		},
+	}
+
+	create := r.newCapacityReservationAwareRunnerFactory(context.Background(), logr.Discard(), rs, nil, func() client.Object {
+		runner := &v1alpha1.Runner{}
+		setAnnotation(&runner.ObjectMeta, AnnotationKeyRunnerSize, "small")
+		return runner
+	})
+
+	runner := create().(*v1alpha1.Runner)
+
+	if got, _ := getAnnotation(runner, AnnotationKeyCapacityReservation); got != "job-2" {
+		t.Errorf("the runner is linked to the reservation of another size: %q", got)
+	}
+}
+
+func TestLinkRunnerCapacityReservation(t *testing.T) {
+	now := time.Now()
+
+	hra := newReservationTestHRA(
+		newTestCapacityReservation("job-1", now.Add(-2*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("job-2", now.Add(-1*time.Minute), now.Add(10*time.Minute)),
+	)
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(hra, newReservationTestRunner("runner", "job-1")).Build()
+
+	get := func() string {
+		t.Helper()
+
+		var runner v1alpha1.Runner
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "runner"}, &runner); err != nil {
+			t.Fatal(err)
+		}
+
+		name, _ := getAnnotation(&runner, AnnotationKeyCapacityReservation)
+
+		return name
+	}
+
+	// The runner created for job-1 picked up job-2
+	if err := linkRunnerCapacityReservation(context.Background(), c, "default", "runner", "job-2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := get(); got != "job-2" {
+		t.Errorf("the runner isn't linked to the reservation of the job it picked up: %q", got)
+	}
+
+	// The job the runner picked up has no reservation
+	if err := linkRunnerCapacityReservation(context.Background(), c, "default", "runner", "job-3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := get(); got != "job-2" {
+		t.Errorf("the runner is linked to the missing reservation: %q", got)
+	}
+}
+
+func TestReleaseCapacityReservation(t *testing.T) {
+	now := time.Now()
+
+	testcases := []struct {
+		name     string
+		jobs     []v1alpha1.RunnerJob
+		phase    corev1.PodPhase
+		released bool
+	}{
+		{
+			name:     "completed",
+			jobs:     []v1alpha1.RunnerJob{{Repository: "test/valid", StartedAt: metav1.Time{Time: now}}},
+			phase:    corev1.PodSucceeded,
+			released: true,
+		},
+		{
+			name:  "running",
+			jobs:  []v1alpha1.RunnerJob{{Repository: "test/valid", StartedAt: metav1.Time{Time: now}}},
+			phase: corev1.PodRunning,
+		},
+		{
+			name:  "stopped without running a job",
+			phase: corev1.PodFailed,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			hra := newReservationTestHRA(
+				newTestCapacityReservation("job-1", now.Add(-2*time.Minute), now.Add(10*time.Minute)),
+				newTestCapacityReservation("job-2", now.Add(-1*time.Minute), now.Add(10*time.Minute)),
+			)
+
+			c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(hra).Build()
+
+			r := &RunnerReconciler{Client: c, Scheme: sc, Recorder: record.NewFakeRecorder(10)}
+
+			runner := newReservationTestRunner("runner", "job-1")
+			runner.Status.RecentJobs = tc.jobs
+
+			pod := &corev1.Pod{Status: corev1.PodStatus{Phase: tc.phase}}
+
+			if err := r.releaseCapacityReservation(context.Background(), *runner, pod, logr.Discard()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var updated v1alpha1.HorizontalRunnerAutoscaler
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example-hra"}, &updated); err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, cr := range updated.Spec.CapacityReservations {
+				names = append(names, cr.Name)
+			}
+
+			want := []string{"job-1", "job-2"}
+			if tc.released {
+				want = []string{"job-2"}
+			}
+
+			if !cmp.Equal(want, names) {
+				t.Errorf("unexpected reservations: %s", cmp.Diff(want, names))
+			}
+		})
+	}
+}
+
+func TestCapacityReservationRunners(t *testing.T) {
+	now := time.Now()
+
+	hra := newReservationTestHRA(
+		newTestCapacityReservation("job-1", now.Add(-2*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("job-2", now.Add(-1*time.Minute), now.Add(10*time.Minute)),
+		newTestCapacityReservation("expired", now.Add(-20*time.Minute), now.Add(-10*time.Minute)),
+	)
+
+	other := newReservationTestRunner("other", "job-1")
+	other.Labels[LabelKeyRunnerDeploymentName] = "other"
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		newReservationTestRunner("runner-b", "job-2"),
+		newReservationTestRunner("runner-a", "job-1"),
+		newReservationTestRunner("runner-c", "expired"),
+		newReservationTestRunner("runner-d", ""),
+		other,
+	).Build()
+
+	r := &HorizontalRunnerAutoscalerReconciler{Client: c}
+
+	got := r.capacityReservationRunners(context.Background(), logr.Discard(), now, *hra)
+
+	want := []v1alpha1.CapacityReservationRunner{
+		{Name: "job-1", Runner: "runner-a"},
+		{Name: "job-2", Runner: "runner-b"},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected runners: %s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/metricprovider"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestComputeReplicasWithCache_ScaleUpMaxStep(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now := time.Now()
+	maxStep := intstr.FromInt(5)
+
+	testcases := []struct {
+		description string
+		maxStep     *intstr.IntOrString
+		reserved    int
+		want        int
+		wantReason  string
+	}{
+		{
+			description: "reservations are added at once by default",
+			reserved:    300,
+			want:        302,
+			wantReason:  ScalingReasonCapacityReservations,
+		},
+		{
+			description: "max step limits the reservations",
+			maxStep:     &maxStep,
+			reserved:    300,
+			want:        6,
+			wantReason:  ScalingReasonScaleUpMaxStep,
+		},
+		{
+			description: "max step limits the metric",
+			maxStep:     &maxStep,
+			want:        2,
+			wantReason:  v1alpha1.AutoscalingMetricTypeExternal,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:                   logr.Discard(),
+				DefaultScaleDownDelay: DefaultScaleDownDelay,
+				MetricProviders:       map[string]metricprovider.Provider{"stub": stubMetricProvider(2)},
+			}
+
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					MinReplicas:    intPtr(1),
+					MaxReplicas:    intPtr(500),
+					ScaleUpMaxStep: tc.maxStep,
+					Metrics: []v1alpha1.MetricSpec{
+						{
+							Type:     v1alpha1.AutoscalingMetricTypeExternal,
+							External: &v1alpha1.ExternalMetricSpec{Provider: "stub"},
+						},
+					},
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					DesiredReplicas: intPtr(1),
+				},
+			}
+
+			if tc.reserved > 0 {
+				hra.Spec.CapacityReservations = []v1alpha1.CapacityReservation{
+					{ExpirationTime: metav1.NewTime(now.Add(10 * time.Minute)), Replicas: tc.reserved},
+				}
+			}
+
+			got, reason, _, err := h.computeReplicasWithCache(context.Background(), logr.Discard(), now, scaleTarget{replicas: intPtr(1)}, hra, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("incorrect desired replicas: want %d, got %d", tc.want, got)
+			}
+
+			if reason != tc.wantReason {
+				t.Errorf("incorrect reason: want %s, got %s", tc.wantReason, reason)
+			}
+		})
+	}
+}
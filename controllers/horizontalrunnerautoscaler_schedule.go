@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// scheduledOverrideSimulationWindow is how far ahead the scheduled overrides are simulated to find problems
+	// like DST shifts and overlaps before they happen.
+	scheduledOverrideSimulationWindow = 14 * 24 * time.Hour
+
+	// maxSimulatedScheduledOverridePeriods bounds the simulation of a scheduled override that recurs very often.
+	maxSimulatedScheduledOverridePeriods = 100
+)
+
+// validateScheduledOverride returns an error when the scheduled override can never be evaluated as intended.
+func validateScheduledOverride(o v1alpha1.ScheduledOverride) error {
+	if o.Schedule != "" {
+		if !o.StartTime.IsZero() || !o.EndTime.IsZero() || o.RecurrenceRule.Frequency != "" {
+			return fmt.Errorf("schedule %q can't be combined with startTime, endTime and recurrenceRule", o.Schedule)
+		}
+
+		return nil
+	}
+
+	if o.StartTime.IsZero() || o.EndTime.IsZero() {
+		return fmt.Errorf("either schedule or both startTime and endTime must be set")
+	}
+
+	if !o.EndTime.After(o.StartTime.Time) {
+		return fmt.Errorf("endTime %s must be after startTime %s", o.EndTime.Format(time.RFC3339), o.StartTime.Format(time.RFC3339))
+	}
+
+	if until := o.RecurrenceRule.UntilTime; !until.IsZero() && until.Before(&o.StartTime) {
+		return fmt.Errorf("untilTime %s must not be before startTime %s", until.Format(time.RFC3339), o.StartTime.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// scheduledOverrideLocation returns the time zone of the scheduled override, which defaults to UTC.
+func scheduledOverrideLocation(o v1alpha1.ScheduledOverride) (*time.Location, error) {
+	if o.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(o.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", o.TimeZone, err)
+	}
+
+	return loc, nil
+}
+
+// simulateScheduledOverride returns the period of the scheduled override active at now, if any,
+// and the periods that start within the simulation window, including the active one.
+func simulateScheduledOverride(now time.Time, o v1alpha1.ScheduledOverride) (*Period, []Period, error) {
+	active, upcoming, err := matchScheduledOverride(now, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var periods []Period
+
+	if active != nil {
+		periods = append(periods, *active)
+	}
+
+	until := now.Add(scheduledOverrideSimulationWindow)
+
+	for upcoming != nil && upcoming.StartTime.Before(until) && len(periods) < maxSimulatedScheduledOverridePeriods {
+		periods = append(periods, *upcoming)
+
+		_, upcoming, err = matchScheduledOverride(upcoming.StartTime, o)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return active, periods, nil
+}
+
+// simulateScheduledOverrides computes the status of each scheduled override of the HRA.
+// The scheduled overrides that can't be evaluated are skipped, as they fail the reconciliation anyway.
+func simulateScheduledOverrides(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.ScheduledOverrideStatus {
+	var (
+		statuses  []v1alpha1.ScheduledOverrideStatus
+		simulated [][]Period
+	)
+
+	for i, o := range hra.Spec.ScheduledOverrides {
+		status := v1alpha1.ScheduledOverrideStatus{Index: i}
+
+		loc, err := scheduledOverrideLocation(o)
+		if err != nil {
+			statuses = append(statuses, status)
+			simulated = append(simulated, nil)
+			continue
+		}
+
+		active, periods, err := simulateScheduledOverride(now, o)
+		if err != nil {
+			statuses = append(statuses, status)
+			simulated = append(simulated, nil)
+			continue
+		}
+
+		next := periods
+		if active != nil {
+			status.ActiveUntil = active.EndTime.In(loc).Format(time.RFC3339)
+			next = periods[1:]
+		}
+
+		if len(next) > 0 {
+			status.NextStartTime = next[0].StartTime.In(loc).Format(time.RFC3339)
+			status.NextEndTime = next[0].EndTime.In(loc).Format(time.RFC3339)
+		}
+
+		status.Warnings = dstShiftWarnings(o, loc, periods)
+
+		for j := 0; j < i; j++ {
+			if w := overlapWarning(hra.Spec.ScheduledOverrides[j], o, j, simulated[j], periods, loc); w != "" {
+				status.Warnings = append(status.Warnings, w)
+			}
+		}
+
+		statuses = append(statuses, status)
+		simulated = append(simulated, periods)
+	}
+
+	return statuses
+}
+
+// dstShiftWarnings returns a warning for each period of the scheduled override that starts at a local time
+// other than the scheduled one, which happens when the scheduled local time is skipped by a DST transition.
+func dstShiftWarnings(o v1alpha1.ScheduledOverride, loc *time.Location, periods []Period) []string {
+	if o.TimeZone == "" {
+		return nil
+	}
+
+	var (
+		hours, minutes []int
+		want           string
+	)
+
+	if o.Schedule != "" {
+		opt, err := parseCronSchedule(o.Schedule)
+		if err != nil {
+			return nil
+		}
+
+		hours, minutes = opt.Byhour, opt.Byminute
+		want = "the scheduled local time"
+	} else {
+		start := o.StartTime.In(loc)
+
+		hours, minutes = []int{start.Hour()}, []int{start.Minute()}
+		want = start.Format("15:04")
+	}
+
+	var warnings []string
+
+	for _, p := range periods {
+		start := p.StartTime.In(loc)
+
+		if (hours == nil || containsInt(hours, start.Hour())) && (minutes == nil || containsInt(minutes, start.Minute())) {
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf("Starts at %s instead of %s due to a DST transition", start.Format(time.RFC3339), want))
+	}
+
+	return warnings
+}
+
+// overlapWarning returns a warning when a period of the scheduled override o overlaps with a period of
+// the higher priority scheduled override at index j that overrides different values.
+// While both are active, only the one at index j is in effect.
+func overlapWarning(higher, o v1alpha1.ScheduledOverride, j int, higherPeriods, periods []Period, loc *time.Location) string {
+	if reflect.DeepEqual(higher.MinReplicas, o.MinReplicas) && reflect.DeepEqual(higher.MaxReplicas, o.MaxReplicas) {
+		return ""
+	}
+
+	for _, p := range periods {
+		for _, hp := range higherPeriods {
+			if !p.StartTime.Before(hp.EndTime) || !hp.StartTime.Before(p.EndTime) {
+				continue
+			}
+
+			start := p.StartTime
+			if hp.StartTime.After(start) {
+				start = hp.StartTime
+			}
+
+			return fmt.Sprintf("Overlaps with scheduledOverrides[%d] from %s, during which only scheduledOverrides[%d] is in effect", j, start.In(loc).Format(time.RFC3339), j)
+		}
+	}
+
+	return ""
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordScheduledOverrideWarnings emits events on the warnings newly found by the simulation of the scheduled overrides.
+func (r *HorizontalRunnerAutoscalerReconciler) recordScheduledOverrideWarnings(hra v1alpha1.HorizontalRunnerAutoscaler, statuses []v1alpha1.ScheduledOverrideStatus) {
+	seen := map[string]bool{}
+	for _, s := range hra.Status.ScheduledOverrides {
+		for _, w := range s.Warnings {
+			seen[fmt.Sprintf("%d/%s", s.Index, w)] = true
+		}
+	}
+
+	for _, s := range statuses {
+		for _, w := range s.Warnings {
+			if seen[fmt.Sprintf("%d/%s", s.Index, w)] {
+				continue
+			}
+
+			r.Recorder.Event(&hra, corev1.EventTypeWarning, "ScheduledOverrideWarning", fmt.Sprintf("scheduledOverrides[%d]: %s", s.Index, w))
+		}
+	}
+}
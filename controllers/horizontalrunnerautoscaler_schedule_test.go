@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMatchScheduledOverride_Invalid(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	at := func(d time.Duration) metav1.Time {
+		return metav1.Time{Time: now.Add(d)}
+	}
+
+	testcases := []struct {
+		description string
+		override    v1alpha1.ScheduledOverride
+		wantErr     string
+	}{
+		{
+			description: "valid schedule",
+			override:    v1alpha1.ScheduledOverride{Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+		},
+		{
+			description: "valid start and end times",
+			override:    v1alpha1.ScheduledOverride{StartTime: at(0), EndTime: at(time.Hour)},
+		},
+		{
+			description: "schedule along with start time",
+			override:    v1alpha1.ScheduledOverride{Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: time.Hour}, StartTime: at(0)},
+			wantErr:     "can't be combined",
+		},
+		{
+			description: "neither schedule nor start and end times",
+			override:    v1alpha1.ScheduledOverride{StartTime: at(0)},
+			wantErr:     "either schedule or both startTime and endTime",
+		},
+		{
+			description: "end time before start time",
+			override:    v1alpha1.ScheduledOverride{StartTime: at(time.Hour), EndTime: at(0)},
+			wantErr:     "must be after startTime",
+		},
+		{
+			description: "until time before start time",
+			override: v1alpha1.ScheduledOverride{
+				StartTime:      at(0),
+				EndTime:        at(time.Hour),
+				RecurrenceRule: v1alpha1.RecurrenceRule{Frequency: "Daily", UntilTime: at(-time.Hour)},
+			},
+			wantErr: "must not be before startTime",
+		},
+		{
+			description: "schedule never matching",
+			override:    v1alpha1.ScheduledOverride{Schedule: "0 0 31 2 *", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr:     "never matches",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			_, _, err := matchScheduledOverride(now, tc.override)
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("unexpected error: want %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSimulateScheduledOverrides(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	// Two days before DST starts in New York at 2022-03-13T02:00:00-05:00
+	now := time.Date(2022, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScheduledOverrides: []v1alpha1.ScheduledOverride{
+				{
+					// Nightly maintenance window in New York, whose start time is skipped on the DST transition
+					Schedule:    "30 2 * * *",
+					Duration:    metav1.Duration{Duration: time.Hour},
+					TimeZone:    "America/New_York",
+					MinReplicas: intPtr(0),
+					MaxReplicas: intPtr(0),
+				},
+				{
+					// Overlaps with the maintenance window, which shadows it
+					Schedule:    "30 6 * * *",
+					Duration:    metav1.Duration{Duration: 2 * time.Hour},
+					MinReplicas: intPtr(3),
+				},
+				{
+					// Active now
+					StartTime:   metav1.Time{Time: now.Add(-time.Hour)},
+					EndTime:     metav1.Time{Time: now.Add(time.Hour)},
+					MinReplicas: intPtr(5),
+				},
+			},
+		},
+	}
+
+	statuses := simulateScheduledOverrides(now, hra)
+
+	if len(statuses) != 3 {
+		t.Fatalf("unexpected number of statuses: want 3, got %d", len(statuses))
+	}
+
+	maintenance := statuses[0]
+
+	if maintenance.NextStartTime != "2022-03-12T02:30:00-05:00" || maintenance.NextEndTime != "2022-03-12T03:30:00-05:00" {
+		t.Errorf("unexpected next period: %s - %s", maintenance.NextStartTime, maintenance.NextEndTime)
+	}
+
+	if len(maintenance.Warnings) != 1 || !strings.Contains(maintenance.Warnings[0], "Starts at 2022-03-13T01:30:00-05:00 instead of the scheduled local time due to a DST transition") {
+		t.Errorf("unexpected warnings: %v", maintenance.Warnings)
+	}
+
+	overlapping := statuses[1]
+
+	if len(overlapping.Warnings) != 1 || !strings.Contains(overlapping.Warnings[0], "Overlaps with scheduledOverrides[0] from 2022-03-12T07:30:00Z") {
+		t.Errorf("unexpected warnings: %v", overlapping.Warnings)
+	}
+
+	active := statuses[2]
+
+	if active.ActiveUntil != "2022-03-11T13:00:00Z" || active.NextStartTime != "" || len(active.Warnings) != 0 {
+		t.Errorf("unexpected status: %+v", active)
+	}
+
+	recorder := record.NewFakeRecorder(10)
+
+	r := &HorizontalRunnerAutoscalerReconciler{Recorder: recorder}
+
+	hra.Status.ScheduledOverrides = []v1alpha1.ScheduledOverrideStatus{maintenance}
+
+	r.recordScheduledOverrideWarnings(hra, statuses)
+
+	close(recorder.Events)
+
+	var events []string
+	for e := range recorder.Events {
+		events = append(events, e)
+	}
+
+	if len(events) != 1 || !strings.HasPrefix(events[0], "Warning ScheduledOverrideWarning scheduledOverrides[1]: Overlaps") {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
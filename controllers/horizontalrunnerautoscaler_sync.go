@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// syncPeriodJitterFactor is the max fraction of spec.syncPeriod added to each sync of the HRA,
+// so that the HRAs with the same sync period spread their GitHub API calls over time.
+const syncPeriodJitterFactor = 0.1
+
+// hraSyncSchedule is the next syncs of the HRAs with spec.syncPeriod, which skips the reconciliations triggered before them,
+// like the ones by the controller-wide resyncs and the status updates of the HRAs.
+// It's kept in memory, so an HRA is synced as usual after the controller restarts or a new leader is elected.
+type hraSyncSchedule struct {
+	mu   sync.Mutex
+	next map[types.NamespacedName]hraSync
+}
+
+type hraSync struct {
+	// generation and syncRequestedAt are the ones of the HRA synced last time,
+	// which trigger the sync right away when the spec is updated or a sync is requested via the admin API.
+	generation      int64
+	syncRequestedAt string
+
+	at time.Time
+}
+
+// wait returns the duration until the next sync of the HRA, and false when the HRA needs to be synced now.
+func (s *hraSyncSchedule) wait(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}
+
+	next, ok := s.next[key]
+	if !ok || hra.Spec.SyncPeriod == nil {
+		return 0, false
+	}
+
+	if next.generation != hra.Generation || next.syncRequestedAt != hra.Annotations[AnnotationKeySyncRequestedAt] || !now.Before(next.at) {
+		return 0, false
+	}
+
+	return next.at.Sub(now), true
+}
+
+// schedule records the next sync of the HRA after its sync at now, and returns the duration until it.
+// requeueAfter is the time the reconciliation needs to be requeued after for the other reasons, if any, like scheduled overrides.
+// The HRA without spec.syncPeriod is removed from the schedule, and requeueAfter is returned as is.
+func (s *hraSyncSchedule) schedule(hra v1alpha1.HorizontalRunnerAutoscaler, now time.Time, requeueAfter time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}
+
+	p := hra.Spec.SyncPeriod
+	if p == nil || p.Duration <= 0 {
+		delete(s.next, key)
+
+		return requeueAfter
+	}
+
+	if d := wait.Jitter(p.Duration, syncPeriodJitterFactor); requeueAfter == 0 || d < requeueAfter {
+		requeueAfter = d
+	}
+
+	if s.next == nil {
+		s.next = map[types.NamespacedName]hraSync{}
+	}
+
+	s.next[key] = hraSync{
+		generation:      hra.Generation,
+		syncRequestedAt: hra.Annotations[AnnotationKeySyncRequestedAt],
+		at:              now.Add(requeueAfter),
+	}
+
+	return requeueAfter
+}
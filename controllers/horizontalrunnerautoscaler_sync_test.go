@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHRASyncSchedule(t *testing.T) {
+	now := time.Now()
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example", Generation: 1},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			SyncPeriod: &metav1.Duration{Duration: 10 * time.Minute},
+		},
+	}
+
+	var s hraSyncSchedule
+
+	if _, ok := s.wait(hra, now); ok {
+		t.Fatalf("the HRA never synced must be synced")
+	}
+
+	d := s.schedule(hra, now, 0)
+	if d < 10*time.Minute || d > 11*time.Minute {
+		t.Fatalf("unexpected requeue after the sync: want 10m with up to 10%% jitter, got %v", d)
+	}
+
+	if w, ok := s.wait(hra, now.Add(time.Minute)); !ok || w != d-time.Minute {
+		t.Errorf("unexpected wait of the resync before the sync period: want %v, got %v (%v)", d-time.Minute, w, ok)
+	}
+
+	if _, ok := s.wait(hra, now.Add(d)); ok {
+		t.Errorf("the HRA must be synced once the sync period passes")
+	}
+
+	updated := *hra.DeepCopy()
+	updated.Generation = 2
+	if _, ok := s.wait(updated, now.Add(time.Minute)); ok {
+		t.Errorf("the HRA must be synced right away when the spec is updated")
+	}
+
+	requested := *hra.DeepCopy()
+	requested.Annotations = map[string]string{AnnotationKeySyncRequestedAt: now.Format(time.RFC3339Nano)}
+	if _, ok := s.wait(requested, now.Add(time.Minute)); ok {
+		t.Errorf("the HRA must be synced right away when a sync is requested")
+	}
+
+	if d := s.schedule(hra, now, 30*time.Second); d != 30*time.Second {
+		t.Errorf("the earlier requeue for the other reasons must be kept: want 30s, got %v", d)
+	}
+
+	unset := *hra.DeepCopy()
+	unset.Spec.SyncPeriod = nil
+	if d := s.schedule(unset, now, 0); d != 0 {
+		t.Errorf("unexpected requeue of the HRA without syncPeriod: %v", d)
+	}
+
+	if _, ok := s.wait(hra, now.Add(time.Second)); ok {
+		t.Errorf("the HRA whose syncPeriod is removed must not be skipped")
+	}
+}
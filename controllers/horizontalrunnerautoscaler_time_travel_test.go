@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	testingclock "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// timeTravelStep reconciles the HRA at the time of the scenario start plus At,
+// and expects the scale target to have the desired replicas.
+type timeTravelStep struct {
+	At           time.Duration
+	WantReplicas int
+}
+
+// timeTravelScenario is a sequence of reconciliations of an HRA on a fake clock, for the time-dependent scaling behaviors
+// like the scale down delay, the scheduled overrides, the capacity reservations and the wait time of the queued jobs.
+// The HRA scales a RunnerDeployment of one replica, and GitHub has no workflow runs unless the scenario says otherwise,
+// so that its desired replicas are decided by time alone.
+type timeTravelScenario struct {
+	Description string
+
+	// HRA returns the spec of the HRA whose times are relative to the scenario start.
+	HRA func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec
+
+	// WorkflowRuns is the response of the queued workflow runs of the repository, if any.
+	WorkflowRuns string
+	// WorkflowJobs returns the responses of the workflow jobs keyed by the run IDs, whose times are relative to the scenario start.
+	WorkflowJobs func(start time.Time) map[int]string
+
+	Steps []timeTravelStep
+}
+
+// timeTravelStart is a fixed time far from the real one, so that any use of the real clock is caught as a failure of the scenarios.
+var timeTravelStart = time.Date(2022, 3, 8, 10, 0, 0, 0, time.UTC)
+
+var timeTravelScenarios = []timeTravelScenario{
+	{
+		Description: "scale down is delayed for the default scale down delay after scale out",
+		HRA: func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec {
+			return v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas: intPtr(1),
+				MaxReplicas: intPtr(10),
+				CapacityReservations: []v1alpha1.CapacityReservation{
+					{Name: "burst", Replicas: 3, ExpirationTime: metav1.Time{Time: start.Add(5 * time.Minute)}},
+				},
+			}
+		},
+		Steps: []timeTravelStep{
+			{At: 0, WantReplicas: 4},
+			// The reservation expired, but it's within DefaultScaleDownDelay since the scale out
+			{At: 6 * time.Minute, WantReplicas: 4},
+			{At: DefaultScaleDownDelay - time.Second, WantReplicas: 4},
+			{At: DefaultScaleDownDelay, WantReplicas: 1},
+		},
+	},
+	{
+		Description: "scale down is delayed for scaleDownDelaySecondsAfterScaleOut after scale out",
+		HRA: func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec {
+			return v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas:                       intPtr(1),
+				MaxReplicas:                       intPtr(10),
+				ScaleDownDelaySecondsAfterScaleUp: intPtr(1800),
+				CapacityReservations: []v1alpha1.CapacityReservation{
+					{Name: "burst", Replicas: 3, ExpirationTime: metav1.Time{Time: start.Add(5 * time.Minute)}},
+				},
+			}
+		},
+		Steps: []timeTravelStep{
+			{At: 0, WantReplicas: 4},
+			{At: DefaultScaleDownDelay, WantReplicas: 4},
+			{At: 30*time.Minute - time.Second, WantReplicas: 4},
+			{At: 30 * time.Minute, WantReplicas: 1},
+		},
+	},
+	{
+		Description: "capacity reservations expire at their expiration time",
+		HRA: func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec {
+			return v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas:                       intPtr(1),
+				MaxReplicas:                       intPtr(10),
+				ScaleDownDelaySecondsAfterScaleUp: intPtr(0),
+				CapacityReservations: []v1alpha1.CapacityReservation{
+					{Name: "short", Replicas: 2, ExpirationTime: metav1.Time{Time: start.Add(5 * time.Minute)}},
+					{Name: "long", Replicas: 1, ExpirationTime: metav1.Time{Time: start.Add(10 * time.Minute)}},
+				},
+			}
+		},
+		Steps: []timeTravelStep{
+			{At: 0, WantReplicas: 4},
+			{At: 5*time.Minute - time.Second, WantReplicas: 4},
+			{At: 5 * time.Minute, WantReplicas: 2},
+			{At: 10 * time.Minute, WantReplicas: 1},
+		},
+	},
+	{
+		Description: "scheduled overrides start at their start time and end at their end time",
+		HRA: func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec {
+			return v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas:                       intPtr(1),
+				MaxReplicas:                       intPtr(10),
+				ScaleDownDelaySecondsAfterScaleUp: intPtr(0),
+				ScheduledOverrides: []v1alpha1.ScheduledOverride{
+					{
+						StartTime:   metav1.Time{Time: start.Add(10 * time.Minute)},
+						EndTime:     metav1.Time{Time: start.Add(20 * time.Minute)},
+						MinReplicas: intPtr(3),
+					},
+				},
+			}
+		},
+		Steps: []timeTravelStep{
+			{At: 0, WantReplicas: 1},
+			{At: 10*time.Minute - time.Second, WantReplicas: 1},
+			{At: 10 * time.Minute, WantReplicas: 3},
+			{At: 20*time.Minute - time.Second, WantReplicas: 3},
+			{At: 20 * time.Minute, WantReplicas: 1},
+		},
+	},
+	{
+		Description: "QueuedJobWaitTime scales up once the oldest queued job waited longer than the scale up wait time",
+		HRA: func(start time.Time) v1alpha1.HorizontalRunnerAutoscalerSpec {
+			return v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas:                       intPtr(1),
+				MaxReplicas:                       intPtr(10),
+				ScaleDownDelaySecondsAfterScaleUp: intPtr(0),
+				Metrics: []v1alpha1.MetricSpec{
+					{Type: v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime},
+				},
+			}
+		},
+		WorkflowRuns: `{"total_count": 1, "workflow_runs":[{"id": 1, "status":"queued"}]}`,
+		WorkflowJobs: func(start time.Time) map[int]string {
+			queuedAt := start.Format(time.RFC3339)
+			return map[int]string{
+				1: fmt.Sprintf(`{"jobs": [{"status":"queued", "labels":["self-hosted"], "started_at":%q}, {"status":"queued", "labels":["self-hosted"], "started_at":%q}, {"status":"queued", "labels":["self-hosted"], "started_at":%q}]}`, queuedAt, queuedAt, queuedAt),
+			}
+		},
+		Steps: []timeTravelStep{
+			{At: 0, WantReplicas: 1},
+			{At: autoscaling.DefaultScaleUpWaitTime, WantReplicas: 1},
+			{At: autoscaling.DefaultScaleUpWaitTime + time.Second, WantReplicas: 2},
+		},
+	},
+}
+
+// runTimeTravelScenario creates the HRA of the scenario along with its scale target in the namespace,
+// and runs the steps of the scenario against them, reconciling the HRA with a reconciler whose clock is moved to each step.
+// It returns the error describing the first step whose replicas are unexpected.
+func runTimeTravelScenario(ctx context.Context, c client.Client, ns string, s timeTravelScenario) error {
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: ns},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Replicas: intPtr(1),
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	hra := &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: ns},
+		Spec:       s.HRA(timeTravelStart),
+	}
+
+	hra.Spec.ScaleTargetRef = v1alpha1.ScaleTargetRef{Name: rd.Name}
+
+	for _, o := range []client.Object{rd, hra} {
+		if err := c.Create(ctx, o); err != nil {
+			return err
+		}
+	}
+
+	noRuns := `{"total_count": 0, "workflow_runs": []}`
+
+	queued := noRuns
+	if s.WorkflowRuns != "" {
+		queued = s.WorkflowRuns
+	}
+
+	var jobs map[int]string
+	if s.WorkflowJobs != nil {
+		jobs = s.WorkflowJobs(timeTravelStart)
+	}
+
+	server := fake.NewServer(
+		fake.WithListRepositoryWorkflowRunsResponse(200, queued, queued, noRuns),
+		fake.WithListWorkflowJobsResponse(200, jobs),
+		fake.WithListRunnersResponse(200, fake.RunnersListBody),
+	)
+	defer server.Close()
+
+	clock := testingclock.NewFakeClock(timeTravelStart)
+
+	r := &HorizontalRunnerAutoscalerReconciler{
+		Client:                c,
+		Log:                   logr.Discard(),
+		GitHubClient:          newGithubClient(server),
+		Recorder:              record.NewFakeRecorder(100),
+		DefaultScaleDownDelay: DefaultScaleDownDelay,
+		Clock:                 clock,
+	}
+
+	key := types.NamespacedName{Namespace: ns, Name: hra.Name}
+
+	for _, step := range s.Steps {
+		clock.SetTime(timeTravelStart.Add(step.At))
+
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			return fmt.Errorf("reconciling at %s: %w", step.At, err)
+		}
+
+		var updated v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, key, &updated); err != nil {
+			return err
+		}
+
+		if got := getIntOrDefault(updated.Spec.Replicas, defaultReplicas); got != step.WantReplicas {
+			return fmt.Errorf("unexpected replicas at %s: want %d, got %d", step.At, step.WantReplicas, got)
+		}
+	}
+
+	return nil
+}
+
+func TestHorizontalRunnerAutoscalerReconciler_TimeTravel(t *testing.T) {
+	for i := range timeTravelScenarios {
+		s := timeTravelScenarios[i]
+
+		t.Run(s.Description, func(t *testing.T) {
+			c := clientfake.NewFakeClientWithScheme(sc)
+
+			if err := runTimeTravelScenario(context.Background(), c, "default", s); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// The same scenarios are run against the real API server, so that the times and the replicas
+// survive the serialization and the status subresource of the custom resources.
+var _ = Context("ENVTEST: HorizontalRunnerAutoscaler on a fake clock", func() {
+	ctx := context.TODO()
+
+	for i := range timeTravelScenarios {
+		s := timeTravelScenarios[i]
+
+		It(s.Description, func() {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "testns-" + randStringRunes(5)},
+			}
+
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed(), "failed to create test namespace")
+
+			defer func() {
+				Expect(k8sClient.Delete(ctx, ns)).To(Succeed(), "failed to delete test namespace")
+			}()
+
+			Expect(runTimeTravelScenario(ctx, k8sClient, ns.Name, s)).To(Succeed())
+		})
+	}
+})
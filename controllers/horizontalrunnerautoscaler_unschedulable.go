@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isPodUnschedulable returns true when the pod is pending because the scheduler couldn't find a node for it.
+// That's when the cluster autoscaler, if any, is expected to provision a node for the pod.
+// A pod waiting for the admission by the queueing system is also considered unschedulable, as it's waiting for capacity.
+func isPodUnschedulable(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodPending || !pod.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && (c.Reason == corev1.PodReasonUnschedulable || c.Reason == podReasonSchedulingGated) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func countUnschedulableRunnerPods(st scaleTarget) (int, error) {
+	pods, err := st.listRunnerPods()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+
+	for _, pod := range pods {
+		if isPodUnschedulable(pod) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// clampScaleUpOnUnschedulable returns the desired replicas clamped to the current desired replicas,
+// or to minReplicas if greater, when the scale up is paused due to too many unschedulable runner pods.
+// The second return value is true when the desired replicas is clamped.
+func clampScaleUpOnUnschedulable(hra v1alpha1.HorizontalRunnerAutoscaler, current, desired, minReplicas, unschedulable int) (int, bool) {
+	maxUnschedulable := hra.Spec.MaxUnschedulableReplicas
+	if maxUnschedulable == nil || unschedulable <= *maxUnschedulable || desired <= current {
+		return desired, false
+	}
+
+	clamped := current
+	if clamped < minReplicas {
+		clamped = minReplicas
+	}
+
+	if clamped >= desired {
+		return desired, false
+	}
+
+	return clamped, true
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCountUnschedulableRunnerPods(t *testing.T) {
+	newPod := func(phase corev1.PodPhase, conditions ...corev1.PodCondition) corev1.Pod {
+		return corev1.Pod{Status: corev1.PodStatus{Phase: phase, Conditions: conditions}}
+	}
+
+	unschedulable := corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable}
+	gated := corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: podReasonSchedulingGated}
+	scheduled := corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}
+
+	st := scaleTarget{
+		listRunnerPods: func() ([]corev1.Pod, error) {
+			return []corev1.Pod{
+				newPod(corev1.PodPending, unschedulable),
+				newPod(corev1.PodPending, unschedulable),
+				newPod(corev1.PodPending, gated),
+				newPod(corev1.PodPending, scheduled),
+				newPod(corev1.PodPending),
+				newPod(corev1.PodRunning, scheduled),
+			}, nil
+		},
+	}
+
+	got, err := countUnschedulableRunnerPods(st)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != 3 {
+		t.Errorf("unexpected number of unschedulable runner pods: want 3, got %d", got)
+	}
+}
+
+func TestClampScaleUpOnUnschedulable(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	testcases := []struct {
+		name             string
+		maxUnschedulable *int
+		current          int
+		desired          int
+		min              int
+		unschedulable    int
+		want             int
+		wantClamped      bool
+	}{
+		{
+			name:          "disabled",
+			current:       2,
+			desired:       5,
+			unschedulable: 3,
+			want:          5,
+		},
+		{
+			name:             "within the limit",
+			maxUnschedulable: intPtr(3),
+			current:          2,
+			desired:          5,
+			unschedulable:    3,
+			want:             5,
+		},
+		{
+			name:             "scale up paused",
+			maxUnschedulable: intPtr(0),
+			current:          2,
+			desired:          5,
+			unschedulable:    1,
+			want:             2,
+			wantClamped:      true,
+		},
+		{
+			name:             "scale up to min replicas",
+			maxUnschedulable: intPtr(0),
+			current:          2,
+			desired:          5,
+			min:              3,
+			unschedulable:    1,
+			want:             3,
+			wantClamped:      true,
+		},
+		{
+			name:             "scale down",
+			maxUnschedulable: intPtr(0),
+			current:          5,
+			desired:          2,
+			unschedulable:    1,
+			want:             2,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{MaxUnschedulableReplicas: tc.maxUnschedulable},
+			}
+
+			got, clamped := clampScaleUpOnUnschedulable(hra, tc.current, tc.desired, tc.min, tc.unschedulable)
+
+			if got != tc.want || clamped != tc.wantClamped {
+				t.Errorf("unexpected result: want (%d, %v), got (%d, %v)", tc.want, tc.wantClamped, got, clamped)
+			}
+		})
+	}
+}
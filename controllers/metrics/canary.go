@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	canaryKind      = "kind"
+	canaryName      = "name"
+	canaryNamespace = "namespace"
+	canaryResult    = "result"
+)
+
+var (
+	canaryMetrics = []prometheus.Collector{
+		canaryPickupDuration,
+		canaryCompletionDuration,
+		canaryLastSuccess,
+		canaryProbes,
+	}
+)
+
+var (
+	canaryPickupDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "canary_pickup_duration_seconds",
+			Help: "seconds from the creation of the latest canary workflow run to the start of its job on the runner pool",
+		},
+		[]string{canaryKind, canaryName, canaryNamespace},
+	)
+	canaryCompletionDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "canary_completion_duration_seconds",
+			Help: "seconds from the creation of the latest canary workflow run to the completion of its job on the runner pool",
+		},
+		[]string{canaryKind, canaryName, canaryNamespace},
+	)
+	canaryLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "canary_last_success_timestamp_seconds",
+			Help: "unix time of the latest successful canary job on the runner pool",
+		},
+		[]string{canaryKind, canaryName, canaryNamespace},
+	)
+	canaryProbes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "canary_probes_total",
+			Help: "number of canary probes against the runner pool by result",
+		},
+		[]string{canaryKind, canaryName, canaryNamespace, canaryResult},
+	)
+)
+
+// SetCanaryResult records the result of a canary probe against the runner pool.
+// pickup and completion are ignored unless the result is "success".
+func SetCanaryResult(kind, namespace, name, result string, pickup, completion time.Duration, now time.Time) {
+	labels := prometheus.Labels{
+		canaryKind:      kind,
+		canaryName:      name,
+		canaryNamespace: namespace,
+	}
+
+	canaryProbes.With(prometheus.Labels{
+		canaryKind:      kind,
+		canaryName:      name,
+		canaryNamespace: namespace,
+		canaryResult:    result,
+	}).Inc()
+
+	if result != "success" {
+		return
+	}
+
+	canaryPickupDuration.With(labels).Set(pickup.Seconds())
+	canaryCompletionDuration.With(labels).Set(completion.Seconds())
+	canaryLastSuccess.With(labels).Set(float64(now.Unix()))
+}
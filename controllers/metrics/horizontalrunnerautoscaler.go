@@ -1,14 +1,17 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	hraName      = "horizontalrunnerautoscaler"
-	hraNamespace = "namespace"
+	hraName        = "horizontalrunnerautoscaler"
+	hraNamespace   = "namespace"
+	hraCacheResult = "result"
 )
 
 var (
@@ -16,6 +19,21 @@ var (
 		horizontalRunnerAutoscalerMinReplicas,
 		horizontalRunnerAutoscalerMaxReplicas,
 		horizontalRunnerAutoscalerDesiredReplicas,
+		horizontalRunnerAutoscalerRecommendedReplicas,
+		horizontalRunnerAutoscalerUnschedulableReplicas,
+		horizontalRunnerAutoscalerRequestedReplicas,
+		horizontalRunnerAutoscalerGrantedReplicas,
+		horizontalRunnerAutoscalerQueuedWorkflowJobs,
+		horizontalRunnerAutoscalerInProgressWorkflowJobs,
+		horizontalRunnerAutoscalerOldestQueuedWorkflowJobWaitSeconds,
+		horizontalRunnerAutoscalerBusyRunners,
+		horizontalRunnerAutoscalerAtMaxReplicas,
+		horizontalRunnerAutoscalerDemandedReplicas,
+		horizontalRunnerAutoscalerShortfallReplicas,
+		horizontalRunnerAutoscalerBorrowedReplicas,
+		horizontalRunnerAutoscalerGitHubAPICache,
+		horizontalRunnerAutoscalerGitHubRateLimitRemaining,
+		horizontalRunnerAutoscalerWorkflowJobCache,
 	}
 )
 
@@ -41,8 +59,136 @@ var (
 		},
 		[]string{hraName, hraNamespace},
 	)
+	horizontalRunnerAutoscalerRecommendedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_status_recommended_replicas",
+			Help: "recommendedReplicas of HorizontalRunnerAutoscaler, the desired replicas not applied to the scale target as the policy is RecommendOnly",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerUnschedulableReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_status_unschedulable_replicas",
+			Help: "unschedulableReplicas of HorizontalRunnerAutoscaler, the number of desired runner pods that are unschedulable",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerRequestedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_status_requested_replicas",
+			Help: "requestedReplicas of HorizontalRunnerAutoscaler, the desired replicas before runner budgets are applied",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerGrantedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_status_granted_replicas",
+			Help: "grantedReplicas of HorizontalRunnerAutoscaler, the share of runner budgets granted to it",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerQueuedWorkflowJobs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_queued_workflow_jobs",
+			Help: "number of queued workflow jobs, or workflow runs whose jobs are unavailable, observed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerInProgressWorkflowJobs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_in_progress_workflow_jobs",
+			Help: "number of in-progress workflow jobs, or workflow runs whose jobs are unavailable, observed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerOldestQueuedWorkflowJobWaitSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_oldest_queued_workflow_job_wait_seconds",
+			Help: "seconds the oldest queued workflow job observed by HorizontalRunnerAutoscaler has been waiting for a runner, or 0 when nothing is queued",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerBusyRunners = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_busy_runners",
+			Help: "number of busy runners of the scale target observed by HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerAtMaxReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_at_max_replicas",
+			Help: "1 when the desired replicas of HorizontalRunnerAutoscaler is at maxReplicas, including the one overridden by a scheduled override, or 0 otherwise",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerDemandedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_demanded_replicas",
+			Help: "the replicas suggested by the metrics plus the capacity reservations of HorizontalRunnerAutoscaler, before minReplicas and maxReplicas are applied",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerShortfallReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_shortfall_replicas",
+			Help: "the demanded replicas of HorizontalRunnerAutoscaler beyond maxReplicas and the replicas it can borrow",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerBorrowedReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_borrowed_replicas",
+			Help: "the replicas HorizontalRunnerAutoscaler borrows from another HorizontalRunnerAutoscaler on overflow",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerGitHubAPICache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizontalrunnerautoscaler_github_api_cache_total",
+			Help: "number of GitHub API responses to HorizontalRunnerAutoscaler by the result of the response cache, hit or miss",
+		},
+		[]string{hraName, hraNamespace, hraCacheResult},
+	)
+	horizontalRunnerAutoscalerGitHubRateLimitRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_github_rate_limit_remaining",
+			Help: "the number of GitHub API requests remaining in the current rate limit window of the credentials of HorizontalRunnerAutoscaler",
+		},
+		[]string{hraName, hraNamespace},
+	)
+	horizontalRunnerAutoscalerWorkflowJobCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "horizontalrunnerautoscaler_workflow_job_cache_total",
+			Help: "number of lookups of the jobs of workflow runs by HorizontalRunnerAutoscaler by the result of the workflow job cache, hit or miss",
+		},
+		[]string{hraName, hraNamespace, hraCacheResult},
+	)
 )
 
+// HorizontalRunnerAutoscalerObservation is what HorizontalRunnerAutoscaler observed in a reconciliation.
+// The nil fields are the ones that were not observed, e.g. because the metric type doesn't need them,
+// and keep their last values.
+type HorizontalRunnerAutoscalerObservation struct {
+	QueuedWorkflowJobs     *int
+	InProgressWorkflowJobs *int
+	BusyRunners            *int
+	AtMaxReplicas          bool
+	DemandedReplicas       *int
+	ShortfallReplicas      int
+	BorrowedReplicas       int
+
+	// OldestQueuedWorkflowJobWait is the time the oldest of the queued workflow jobs has been waiting for a runner.
+	OldestQueuedWorkflowJobWait *time.Duration
+
+	GitHubAPICacheHits       int
+	GitHubAPICacheMisses     int
+	GitHubRateLimitRemaining *int
+
+	WorkflowJobCacheHits   int
+	WorkflowJobCacheMisses int
+}
+
 func SetHorizontalRunnerAutoscalerSpec(o metav1.ObjectMeta, spec v1alpha1.HorizontalRunnerAutoscalerSpec) {
 	labels := prometheus.Labels{
 		hraName:      o.Name,
@@ -64,4 +210,70 @@ func SetHorizontalRunnerAutoscalerStatus(o metav1.ObjectMeta, status v1alpha1.Ho
 	if status.DesiredReplicas != nil {
 		horizontalRunnerAutoscalerDesiredReplicas.With(labels).Set(float64(*status.DesiredReplicas))
 	}
+	if status.RecommendedReplicas != nil {
+		horizontalRunnerAutoscalerRecommendedReplicas.With(labels).Set(float64(*status.RecommendedReplicas))
+	}
+	if status.UnschedulableReplicas != nil {
+		horizontalRunnerAutoscalerUnschedulableReplicas.With(labels).Set(float64(*status.UnschedulableReplicas))
+	}
+	if status.RequestedReplicas != nil {
+		horizontalRunnerAutoscalerRequestedReplicas.With(labels).Set(float64(*status.RequestedReplicas))
+	}
+	if status.GrantedReplicas != nil {
+		horizontalRunnerAutoscalerGrantedReplicas.With(labels).Set(float64(*status.GrantedReplicas))
+	}
+}
+
+func SetHorizontalRunnerAutoscalerObservation(o metav1.ObjectMeta, obs HorizontalRunnerAutoscalerObservation) {
+	labels := prometheus.Labels{
+		hraName:      o.Name,
+		hraNamespace: o.Namespace,
+	}
+	if obs.QueuedWorkflowJobs != nil {
+		horizontalRunnerAutoscalerQueuedWorkflowJobs.With(labels).Set(float64(*obs.QueuedWorkflowJobs))
+	}
+	if obs.InProgressWorkflowJobs != nil {
+		horizontalRunnerAutoscalerInProgressWorkflowJobs.With(labels).Set(float64(*obs.InProgressWorkflowJobs))
+	}
+	if obs.OldestQueuedWorkflowJobWait != nil {
+		horizontalRunnerAutoscalerOldestQueuedWorkflowJobWaitSeconds.With(labels).Set(obs.OldestQueuedWorkflowJobWait.Seconds())
+	}
+	if obs.BusyRunners != nil {
+		horizontalRunnerAutoscalerBusyRunners.With(labels).Set(float64(*obs.BusyRunners))
+	}
+	if obs.AtMaxReplicas {
+		horizontalRunnerAutoscalerAtMaxReplicas.With(labels).Set(1)
+	} else {
+		horizontalRunnerAutoscalerAtMaxReplicas.With(labels).Set(0)
+	}
+	if obs.DemandedReplicas != nil {
+		horizontalRunnerAutoscalerDemandedReplicas.With(labels).Set(float64(*obs.DemandedReplicas))
+	}
+	horizontalRunnerAutoscalerShortfallReplicas.With(labels).Set(float64(obs.ShortfallReplicas))
+	horizontalRunnerAutoscalerBorrowedReplicas.With(labels).Set(float64(obs.BorrowedReplicas))
+	if obs.GitHubRateLimitRemaining != nil {
+		horizontalRunnerAutoscalerGitHubRateLimitRemaining.With(labels).Set(float64(*obs.GitHubRateLimitRemaining))
+	}
+
+	horizontalRunnerAutoscalerGitHubAPICache.With(prometheus.Labels{
+		hraName:        o.Name,
+		hraNamespace:   o.Namespace,
+		hraCacheResult: "hit",
+	}).Add(float64(obs.GitHubAPICacheHits))
+	horizontalRunnerAutoscalerGitHubAPICache.With(prometheus.Labels{
+		hraName:        o.Name,
+		hraNamespace:   o.Namespace,
+		hraCacheResult: "miss",
+	}).Add(float64(obs.GitHubAPICacheMisses))
+
+	horizontalRunnerAutoscalerWorkflowJobCache.With(prometheus.Labels{
+		hraName:        o.Name,
+		hraNamespace:   o.Namespace,
+		hraCacheResult: "hit",
+	}).Add(float64(obs.WorkflowJobCacheHits))
+	horizontalRunnerAutoscalerWorkflowJobCache.With(prometheus.Labels{
+		hraName:        o.Name,
+		hraNamespace:   o.Namespace,
+		hraCacheResult: "miss",
+	}).Add(float64(obs.WorkflowJobCacheMisses))
 }
@@ -11,4 +11,10 @@ import (
 func init() {
 	metrics.Registry.MustRegister(runnerDeploymentMetrics...)
 	metrics.Registry.MustRegister(horizontalRunnerAutoscalerMetrics...)
+	metrics.Registry.MustRegister(canaryMetrics...)
+	metrics.Registry.MustRegister(runnerVersionMetrics...)
+	metrics.Registry.MustRegister(runnerEphemeralStorageMetrics...)
+	metrics.Registry.MustRegister(runnerPodRetentionMetrics...)
+	metrics.Registry.MustRegister(runnerRegistrationGCMetrics...)
+	metrics.Registry.MustRegister(reconcileErrorBudgetMetrics...)
 }
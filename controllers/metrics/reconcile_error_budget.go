@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	reconcileErrorBudgetController = "controller"
+)
+
+var (
+	reconcileErrorBudgetMetrics = []prometheus.Collector{
+		reconcileDegradedObjects,
+	}
+)
+
+var (
+	reconcileDegradedObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "reconcile_degraded_objects",
+			Help: "number of the objects whose reconciliations have failed more times in a row than the reconcile error threshold, which are retried only at the degraded requeue interval",
+		},
+		[]string{reconcileErrorBudgetController},
+	)
+)
+
+// SetReconcileDegradedObjects records the number of the degraded objects of the controller.
+func SetReconcileDegradedObjects(controller string, n int) {
+	reconcileDegradedObjects.With(prometheus.Labels{
+		reconcileErrorBudgetController: controller,
+	}).Set(float64(n))
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	runnerEphemeralStorageKind      = "kind"
+	runnerEphemeralStorageName      = "name"
+	runnerEphemeralStorageNamespace = "namespace"
+)
+
+var (
+	runnerEphemeralStorageMetrics = []prometheus.Collector{
+		runnerEphemeralStorageMaxUsageRatio,
+		runnerEphemeralStorageRunnersOverThreshold,
+		runnerEphemeralStorageRecycles,
+	}
+)
+
+var (
+	runnerEphemeralStorageMaxUsageRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_ephemeral_storage_max_usage_ratio",
+			Help: "highest ratio of the ephemeral storage used by a runner pod of the runner pool to its ephemeral storage limit",
+		},
+		[]string{runnerEphemeralStorageKind, runnerEphemeralStorageName, runnerEphemeralStorageNamespace},
+	)
+	runnerEphemeralStorageRunnersOverThreshold = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_ephemeral_storage_runners_over_threshold",
+			Help: "number of runner pods of the runner pool whose ephemeral storage usage is at or above the recycle threshold",
+		},
+		[]string{runnerEphemeralStorageKind, runnerEphemeralStorageName, runnerEphemeralStorageNamespace},
+	)
+	runnerEphemeralStorageRecycles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_ephemeral_storage_recycles_total",
+			Help: "number of runners of the runner pool recycled due to their ephemeral storage usage",
+		},
+		[]string{runnerEphemeralStorageKind, runnerEphemeralStorageName, runnerEphemeralStorageNamespace},
+	)
+)
+
+// SetRunnerEphemeralStorageUsage records the ephemeral storage usage of the runner pods of the runner pool.
+func SetRunnerEphemeralStorageUsage(kind, namespace, name string, maxUsageRatio float64, overThreshold int) {
+	labels := prometheus.Labels{
+		runnerEphemeralStorageKind:      kind,
+		runnerEphemeralStorageName:      name,
+		runnerEphemeralStorageNamespace: namespace,
+	}
+
+	runnerEphemeralStorageMaxUsageRatio.With(labels).Set(maxUsageRatio)
+	runnerEphemeralStorageRunnersOverThreshold.With(labels).Set(float64(overThreshold))
+}
+
+// IncRunnerEphemeralStorageRecycles counts a runner recycled due to its ephemeral storage usage.
+func IncRunnerEphemeralStorageRecycles(kind, namespace, name string) {
+	runnerEphemeralStorageRecycles.With(prometheus.Labels{
+		runnerEphemeralStorageKind:      kind,
+		runnerEphemeralStorageName:      name,
+		runnerEphemeralStorageNamespace: namespace,
+	}).Inc()
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	runnerPodRetentionPool      = "pool"
+	runnerPodRetentionNamespace = "namespace"
+	runnerPodRetentionResult    = "result"
+)
+
+var (
+	runnerPodRetentionMetrics = []prometheus.Collector{
+		runnerPodRetentionRetained,
+		runnerPodRetentionDeleted,
+	}
+)
+
+var (
+	runnerPodRetentionRetained = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_pod_retention_retained",
+			Help: "number of the pods of the completed or failed runners of the runner pool currently retained, by the result of the runner",
+		},
+		[]string{runnerPodRetentionPool, runnerPodRetentionNamespace, runnerPodRetentionResult},
+	)
+	runnerPodRetentionDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_pod_retention_deleted_total",
+			Help: "number of the retained pods of the runner pool deleted on their TTL or the failed pods limit, by the result of the runner",
+		},
+		[]string{runnerPodRetentionPool, runnerPodRetentionNamespace, runnerPodRetentionResult},
+	)
+)
+
+// SetRunnerPodRetentionRetained records the number of the retained pods of the runner pool.
+func SetRunnerPodRetentionRetained(namespace, pool string, succeeded, failed int) {
+	for result, n := range map[string]int{"succeeded": succeeded, "failed": failed} {
+		runnerPodRetentionRetained.With(prometheus.Labels{
+			runnerPodRetentionPool:      pool,
+			runnerPodRetentionNamespace: namespace,
+			runnerPodRetentionResult:    result,
+		}).Set(float64(n))
+	}
+}
+
+// IncRunnerPodRetentionDeleted counts a retained pod of the runner pool deleted.
+// result is either "succeeded" or "failed".
+func IncRunnerPodRetentionDeleted(namespace, pool, result string) {
+	runnerPodRetentionDeleted.With(prometheus.Labels{
+		runnerPodRetentionPool:      pool,
+		runnerPodRetentionNamespace: namespace,
+		runnerPodRetentionResult:    result,
+	}).Inc()
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	runnerRegistrationGCEnterprise   = "enterprise"
+	runnerRegistrationGCOrganization = "organization"
+	runnerRegistrationGCRepository   = "repository"
+)
+
+var (
+	runnerRegistrationGCMetrics = []prometheus.Collector{
+		runnerRegistrationGCOrphaned,
+		runnerRegistrationGCRemoved,
+	}
+)
+
+var (
+	runnerRegistrationGCOrphaned = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_registration_gc_orphaned",
+			Help: "number of the offline runner registrations on GitHub that have no live runner, including the ones not offline for long enough to be removed yet",
+		},
+		[]string{runnerRegistrationGCEnterprise, runnerRegistrationGCOrganization, runnerRegistrationGCRepository},
+	)
+	runnerRegistrationGCRemoved = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_registration_gc_removed_total",
+			Help: "number of the orphaned runner registrations removed from GitHub after being offline longer than the threshold",
+		},
+		[]string{runnerRegistrationGCEnterprise, runnerRegistrationGCOrganization, runnerRegistrationGCRepository},
+	)
+)
+
+// SetRunnerRegistrationGCOrphaned records the number of the orphaned runner registrations of the registration scope.
+func SetRunnerRegistrationGCOrphaned(enterprise, org, repo string, n int) {
+	runnerRegistrationGCOrphaned.With(prometheus.Labels{
+		runnerRegistrationGCEnterprise:   enterprise,
+		runnerRegistrationGCOrganization: org,
+		runnerRegistrationGCRepository:   repo,
+	}).Set(float64(n))
+}
+
+// IncRunnerRegistrationGCRemoved counts an orphaned runner registration removed from the registration scope.
+func IncRunnerRegistrationGCRemoved(enterprise, org, repo string) {
+	runnerRegistrationGCRemoved.With(prometheus.Labels{
+		runnerRegistrationGCEnterprise:   enterprise,
+		runnerRegistrationGCOrganization: org,
+		runnerRegistrationGCRepository:   repo,
+	}).Inc()
+}
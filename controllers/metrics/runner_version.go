@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	runnerVersionKind      = "kind"
+	runnerVersionName      = "name"
+	runnerVersionNamespace = "namespace"
+)
+
+var (
+	runnerVersionMetrics = []prometheus.Collector{
+		runnerVersionDrift,
+		runnerVersionStaleRunners,
+		runnerVersionRecycles,
+	}
+)
+
+var (
+	runnerVersionDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_version_drift_minor_versions",
+			Help: "number of minor versions the oldest runner of the runner pool is behind the latest release of actions/runner",
+		},
+		[]string{runnerVersionKind, runnerVersionName, runnerVersionNamespace},
+	)
+	runnerVersionStaleRunners = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runner_version_stale_runners",
+			Help: "number of runners of the runner pool whose version is older than the version of the runner pool's image",
+		},
+		[]string{runnerVersionKind, runnerVersionName, runnerVersionNamespace},
+	)
+	runnerVersionRecycles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runner_version_recycles_total",
+			Help: "number of runners of the runner pool recycled due to the version drift",
+		},
+		[]string{runnerVersionKind, runnerVersionName, runnerVersionNamespace},
+	)
+)
+
+// SetRunnerVersionDrift records the version drift of the runner pool.
+func SetRunnerVersionDrift(kind, namespace, name string, drift, stale int) {
+	labels := prometheus.Labels{
+		runnerVersionKind:      kind,
+		runnerVersionName:      name,
+		runnerVersionNamespace: namespace,
+	}
+
+	runnerVersionDrift.With(labels).Set(float64(drift))
+	runnerVersionStaleRunners.With(labels).Set(float64(stale))
+}
+
+// IncRunnerVersionRecycles counts a runner recycled due to the version drift.
+func IncRunnerVersionRecycles(kind, namespace, name string) {
+	runnerVersionRecycles.With(prometheus.Labels{
+		runnerVersionKind:      kind,
+		runnerVersionName:      name,
+		runnerVersionNamespace: namespace,
+	}).Inc()
+}
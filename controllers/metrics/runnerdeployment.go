@@ -13,6 +13,11 @@ const (
 var (
 	runnerDeploymentMetrics = []prometheus.Collector{
 		runnerDeploymentReplicas,
+		runnerDeploymentUtilizationRunnerHours,
+		runnerDeploymentUtilizationBusyHours,
+		runnerDeploymentUtilizationRatio,
+		runnerDeploymentUtilizationPeakRunners,
+		runnerDeploymentUtilizationPeakBusyRunners,
 	}
 )
 
@@ -24,6 +29,41 @@ var (
 		},
 		[]string{rdName, rdNamespace},
 	)
+	runnerDeploymentUtilizationRunnerHours = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runnerdeployment_utilization_runner_hours",
+			Help: "runner-hours of RunnerDeployment within the utilization report window",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentUtilizationBusyHours = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runnerdeployment_utilization_busy_hours",
+			Help: "busy runner-hours of RunnerDeployment within the utilization report window",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentUtilizationRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runnerdeployment_utilization_ratio",
+			Help: "busy runner-hours divided by runner-hours of RunnerDeployment within the utilization report window",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentUtilizationPeakRunners = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runnerdeployment_utilization_peak_runners",
+			Help: "peak concurrent runners of RunnerDeployment within the utilization report window",
+		},
+		[]string{rdName, rdNamespace},
+	)
+	runnerDeploymentUtilizationPeakBusyRunners = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "runnerdeployment_utilization_peak_busy_runners",
+			Help: "peak concurrent busy runners of RunnerDeployment within the utilization report window",
+		},
+		[]string{rdName, rdNamespace},
+	)
 )
 
 func SetRunnerDeployment(rd v1alpha1.RunnerDeployment) {
@@ -35,3 +75,36 @@ func SetRunnerDeployment(rd v1alpha1.RunnerDeployment) {
 		runnerDeploymentReplicas.With(labels).Set(float64(*rd.Spec.Replicas))
 	}
 }
+
+// SetRunnerDeploymentUtilizationReport records the utilization report of the RunnerDeployment.
+func SetRunnerDeploymentUtilizationReport(namespace, name string, report v1alpha1.RunnerUtilizationReport) {
+	labels := prometheus.Labels{
+		rdName:      name,
+		rdNamespace: namespace,
+	}
+
+	var ratio float64
+	if report.RegisteredSeconds > 0 {
+		ratio = float64(report.BusySeconds) / float64(report.RegisteredSeconds)
+	}
+
+	runnerDeploymentUtilizationRunnerHours.With(labels).Set(float64(report.RegisteredSeconds) / 3600)
+	runnerDeploymentUtilizationBusyHours.With(labels).Set(float64(report.BusySeconds) / 3600)
+	runnerDeploymentUtilizationRatio.With(labels).Set(ratio)
+	runnerDeploymentUtilizationPeakRunners.With(labels).Set(float64(report.PeakRunners))
+	runnerDeploymentUtilizationPeakBusyRunners.With(labels).Set(float64(report.PeakBusyRunners))
+}
+
+// DeleteRunnerDeploymentUtilizationReport removes the utilization report of the deleted RunnerDeployment.
+func DeleteRunnerDeploymentUtilizationReport(namespace, name string) {
+	labels := prometheus.Labels{
+		rdName:      name,
+		rdNamespace: namespace,
+	}
+
+	runnerDeploymentUtilizationRunnerHours.Delete(labels)
+	runnerDeploymentUtilizationBusyHours.Delete(labels)
+	runnerDeploymentUtilizationRatio.Delete(labels)
+	runnerDeploymentUtilizationPeakRunners.Delete(labels)
+	runnerDeploymentUtilizationPeakBusyRunners.Delete(labels)
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKeyGitHubAPICredentialsFrom is the annotation that contains the name of the Secret referenced by the githubAPICredentialsFrom of the runner.
+	// This is added onto the runner pod so that the runner pod controller and the pod runner token injector
+	// can use the same GitHub API credentials as the runner controller.
+	AnnotationKeyGitHubAPICredentialsFrom = annotationKeyPrefix + "github-api-credentials-from"
+
+	githubAPICredentialsKeyToken             = "github_token"
+	githubAPICredentialsKeyAppID             = "github_app_id"
+	githubAPICredentialsKeyAppInstallationID = "github_app_installation_id"
+	githubAPICredentialsKeyAppPrivateKey     = "github_app_private_key"
+)
+
+var githubAPICredentialsKeys = []string{
+	githubAPICredentialsKeyToken,
+	githubAPICredentialsKeyAppID,
+	githubAPICredentialsKeyAppInstallationID,
+	githubAPICredentialsKeyAppPrivateKey,
+}
+
+// MultiGitHubClient holds the GitHub clients for the credentials stored in the Secrets referenced by githubAPICredentialsFrom.
+// A client is created on first use and recreated only when the content of its Secret changes,
+// so that the cached registration tokens and the GitHub App installation tokens are reused across reconciliations.
+type MultiGitHubClient struct {
+	client client.Client
+
+	// config is the base configuration of every client, like the GitHub URLs.
+	// Its credentials are replaced with the ones stored in the Secrets.
+	config github.Config
+
+	mu      sync.Mutex
+	clients map[types.NamespacedName]*multiGitHubClientEntry
+}
+
+type multiGitHubClientEntry struct {
+	hash   string
+	client *github.Client
+}
+
+func NewMultiGitHubClient(c client.Client, config github.Config) *MultiGitHubClient {
+	return &MultiGitHubClient{
+		client:  c,
+		config:  config,
+		clients: map[types.NamespacedName]*multiGitHubClientEntry{},
+	}
+}
+
+// ClientFor returns the GitHub client for the credentials referenced by from in the namespace.
+// def is returned when from is nil, which is the case for resources that use the controller-wide credentials.
+func (c *MultiGitHubClient) ClientFor(ctx context.Context, def *github.Client, namespace string, from *v1alpha1.GitHubAPICredentialsFrom) (*github.Client, error) {
+	if from == nil {
+		return def, nil
+	}
+
+	if c == nil {
+		return nil, fmt.Errorf("githubAPICredentialsFrom is set but this controller is not configured to use per-resource GitHub API credentials")
+	}
+
+	key := types.NamespacedName{Namespace: namespace, Name: from.SecretRef.Name}
+
+	var secret corev1.Secret
+
+	if err := c.client.Get(ctx, key, &secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			c.forget(key)
+		}
+
+		return nil, fmt.Errorf("getting github api credentials from secret %s: %w", key, err)
+	}
+
+	hash := hashGitHubAPICredentials(secret.Data)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.clients[key]; ok && e.hash == hash {
+		return e.client, nil
+	}
+
+	conf, err := githubConfigFromSecret(c.config, secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("reading github api credentials from secret %s: %w", key, err)
+	}
+
+	conf.Credentials = key.String()
+
+	ghc, err := conf.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating github client for secret %s: %w", key, err)
+	}
+
+	c.clients[key] = &multiGitHubClientEntry{hash: hash, client: ghc}
+
+	return ghc, nil
+}
+
+// list returns the clients created for the credentials stored in the Secrets so far.
+func (c *MultiGitHubClient) list() []*github.Client {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clients := make([]*github.Client, 0, len(c.clients))
+	for _, e := range c.clients {
+		clients = append(clients, e.client)
+	}
+
+	return clients
+}
+
+func (c *MultiGitHubClient) forget(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.clients[key]; !ok {
+		return
+	}
+
+	delete(c.clients, key)
+
+	metrics.DeleteCredentials(key.String())
+}
+
+// githubConfigFromSecret returns a copy of base whose credentials are replaced with the ones in the secret data.
+func githubConfigFromSecret(base github.Config, data map[string][]byte) (github.Config, error) {
+	conf := base
+
+	conf.Token = string(data[githubAPICredentialsKeyToken])
+	conf.AppID = 0
+	conf.AppInstallationID = 0
+	conf.AppPrivateKey = string(data[githubAPICredentialsKeyAppPrivateKey])
+	conf.BasicauthUsername = ""
+	conf.BasicauthPassword = ""
+
+	if v, ok := data[githubAPICredentialsKeyAppID]; ok {
+		id, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return conf, fmt.Errorf("parsing %s: %w", githubAPICredentialsKeyAppID, err)
+		}
+		conf.AppID = id
+	}
+
+	if v, ok := data[githubAPICredentialsKeyAppInstallationID]; ok {
+		id, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return conf, fmt.Errorf("parsing %s: %w", githubAPICredentialsKeyAppInstallationID, err)
+		}
+		conf.AppInstallationID = id
+	}
+
+	return conf, nil
+}
+
+func hashGitHubAPICredentials(data map[string][]byte) string {
+	h := sha256.New()
+
+	for _, k := range githubAPICredentialsKeys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(data[k])
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// githubAPICredentialsKey identifies the credentials referenced by from in the namespace,
+// or the controller-wide credentials when from is nil.
+func githubAPICredentialsKey(namespace string, from *v1alpha1.GitHubAPICredentialsFrom) string {
+	if from == nil {
+		return metrics.DefaultCredentials
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: from.SecretRef.Name}.String()
+}
+
+// githubAPICredentialsFromPod returns the githubAPICredentialsFrom of the runner the pod was created for.
+func githubAPICredentialsFromPod(pod *corev1.Pod) *v1alpha1.GitHubAPICredentialsFrom {
+	name, ok := getAnnotation(pod, AnnotationKeyGitHubAPICredentialsFrom)
+	if !ok || name == "" {
+		return nil
+	}
+
+	return &v1alpha1.GitHubAPICredentialsFrom{SecretRef: v1alpha1.SecretReference{Name: name}}
+}
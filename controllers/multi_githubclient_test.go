@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMultiGitHubClient(t *testing.T) {
+	var gotAuth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotAuth = req.Header.Get("Authorization")
+		w.Write([]byte(`{"full_name": "test/valid"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-a"},
+		Data:       map[string][]byte{"github_token": []byte("token-a")},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, secret)
+
+	def := newGithubClient(server)
+	multi := NewMultiGitHubClient(c, github.Config{URL: server.URL, Token: "controller-token"})
+	from := &v1alpha1.GitHubAPICredentialsFrom{SecretRef: v1alpha1.SecretReference{Name: "team-a"}}
+
+	got, err := multi.ClientFor(ctx, def, "default", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != def {
+		t.Errorf("the default client must be used when githubAPICredentialsFrom is not set")
+	}
+
+	first, err := multi.ClientFor(ctx, def, "default", from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first == def {
+		t.Fatalf("the default client must not be used when githubAPICredentialsFrom is set")
+	}
+
+	if _, _, err := first.Repositories.Get(ctx, "test", "valid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer token-a" {
+		t.Errorf("unexpected authorization: %q", gotAuth)
+	}
+
+	second, err := multi.ClientFor(ctx, def, "default", from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second != first {
+		t.Errorf("the client must be reused while the secret is unchanged")
+	}
+
+	secret.Data["github_token"] = []byte("token-b")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rotated, err := multi.ClientFor(ctx, def, "default", from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rotated == first {
+		t.Errorf("the client must be recreated once the secret is changed")
+	}
+
+	if _, _, err := rotated.Repositories.Get(ctx, "test", "valid"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer token-b" {
+		t.Errorf("unexpected authorization after rotation: %q", gotAuth)
+	}
+
+	if _, err := multi.ClientFor(ctx, def, "other", from); err == nil {
+		t.Errorf("expected an error for the secret in another namespace")
+	}
+
+	var unconfigured *MultiGitHubClient
+	if _, err := unconfigured.ClientFor(ctx, def, "default", from); err == nil {
+		t.Errorf("expected an error when per-resource credentials are not configured")
+	}
+}
+
+func TestGitHubConfigFromSecret(t *testing.T) {
+	base := github.Config{URL: "https://github.example.com/api/v3", Token: "controller-token", AppID: 1}
+
+	testcases := []struct {
+		name    string
+		data    map[string][]byte
+		want    github.Config
+		wantErr bool
+	}{
+		{
+			name: "token",
+			data: map[string][]byte{"github_token": []byte("token")},
+			want: github.Config{URL: base.URL, Token: "token"},
+		},
+		{
+			name: "app",
+			data: map[string][]byte{
+				"github_app_id":              []byte("12"),
+				"github_app_installation_id": []byte("34"),
+				"github_app_private_key":     []byte("key"),
+			},
+			want: github.Config{URL: base.URL, AppID: 12, AppInstallationID: 34, AppPrivateKey: "key"},
+		},
+		{
+			name:    "invalid app id",
+			data:    map[string][]byte{"github_app_id": []byte("abc")},
+			wantErr: true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := githubConfigFromSecret(base, tc.data)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected config: want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
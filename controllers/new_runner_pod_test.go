@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	arcv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
@@ -907,6 +908,37 @@ func TestNewRunnerPodFromRunnerController(t *testing.T) {
 				// p.Spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
 			}),
 		},
+		{
+			description: "dnsPolicy, dnsConfig and hostAliases should be set to the pod",
+			runner: arcv1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "runner",
+				},
+				Spec: arcv1alpha1.RunnerSpec{
+					RunnerPodSpec: arcv1alpha1.RunnerPodSpec{
+						DnsPolicy: corev1.DNSNone,
+						DnsConfig: &corev1.PodDNSConfig{
+							Nameservers: []string{"10.0.0.53"},
+							Searches:    []string{"corp.example.com"},
+						},
+						HostAliases: []corev1.HostAlias{
+							{IP: "10.0.0.10", Hostnames: []string{"artifacts.example.com"}},
+						},
+					},
+				},
+			},
+
+			want: newTestPod(base, func(p *corev1.Pod) {
+				p.Spec.DNSPolicy = corev1.DNSNone
+				p.Spec.DNSConfig = &corev1.PodDNSConfig{
+					Nameservers: []string{"10.0.0.53"},
+					Searches:    []string{"corp.example.com"},
+				}
+				p.Spec.HostAliases = []corev1.HostAlias{
+					{IP: "10.0.0.10", Hostnames: []string{"artifacts.example.com"}},
+				}
+			}),
+		},
 	}
 
 	var (
@@ -932,7 +964,7 @@ func TestNewRunnerPodFromRunnerController(t *testing.T) {
 				GitHubClient:           &github.Client{GithubBaseURL: githubBaseURL},
 				Scheme:                 scheme,
 			}
-			got, err := r.newPod(tc.runner)
+			got, err := r.newPod(context.Background(), tc.runner)
 			require.NoError(t, err)
 			require.Equal(t, tc.want, got)
 		})
@@ -31,6 +31,8 @@ type PodRunnerTokenInjector struct {
 	Recorder     record.EventRecorder
 	GitHubClient *github.Client
 	decoder      *admission.Decoder
+	// GitHubClients holds the GitHub clients for the runner pods annotated with the githubAPICredentialsFrom of their runners.
+	GitHubClients *MultiGitHubClient
 }
 
 func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
@@ -55,7 +57,7 @@ func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Reque
 		}
 	}
 
-	if runnerContainer == nil {
+	if runnerContainer == nil || usesRegistrationSecret(&pod) {
 		return newEmptyResponse()
 	}
 
@@ -66,7 +68,13 @@ func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Reque
 		return newEmptyResponse()
 	}
 
-	rt, err := t.GitHubClient.GetRegistrationToken(context.Background(), enterprise, org, repo, pod.Name)
+	ghc, err := t.GitHubClients.ClientFor(context.Background(), t.GitHubClient, req.Namespace, githubAPICredentialsFromPod(&pod))
+	if err != nil {
+		t.Log.Error(err, "Failed to get the GitHub client for the githubAPICredentialsFrom")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	rt, err := ghc.GetRegistrationToken(context.Background(), enterprise, org, repo, pod.Name)
 	if err != nil {
 		t.Log.Error(err, "Failed to get new registration token")
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -78,8 +86,8 @@ func (t *PodRunnerTokenInjector) Handle(ctx context.Context, req admission.Reque
 
 	updated.Annotations[AnnotationKeyTokenExpirationDate] = ts
 
-	if pod.Spec.RestartPolicy != corev1.RestartPolicyOnFailure {
-		updated.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+	if restartPolicy := runnerPodRestartPolicy(&pod); pod.Spec.RestartPolicy != restartPolicy {
+		updated.Spec.RestartPolicy = restartPolicy
 	}
 
 	buf, err := json.Marshal(updated)
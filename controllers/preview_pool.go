@@ -0,0 +1,504 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/hash"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// LabelKeyPreviewPoolTemplate is the label to mark a RunnerDeployment as the template of preview pools.
+	// The label value is the name of the template that is referenced from `/arc pool create NAME=REPLICAS` comments.
+	LabelKeyPreviewPoolTemplate = annotationKeyPrefix + "preview-pool-template"
+
+	// LabelKeyPreviewPool is the label added to RunnerDeployments materialized from PR comments.
+	LabelKeyPreviewPool = annotationKeyPrefix + "preview-pool"
+
+	// AnnotationKeyPreviewPoolExpiresAt is the annotation that contains the time after which the preview pool is deleted.
+	AnnotationKeyPreviewPoolExpiresAt = annotationKeyPrefix + "preview-pool-expires-at"
+
+	// AnnotationKeyPreviewPoolPullRequest is the annotation that contains the pull request in the OWNER/REPO#NUMBER format
+	// that requested the preview pool.
+	AnnotationKeyPreviewPoolPullRequest = annotationKeyPrefix + "preview-pool-pull-request"
+
+	previewPoolCommandPrefix = "/arc pool"
+
+	DefaultPreviewPoolTTL         = 2 * time.Hour
+	DefaultPreviewPoolMaxTTL      = 24 * time.Hour
+	DefaultPreviewPoolMaxReplicas = 3
+
+	previewPoolCleanupInterval = time.Minute
+)
+
+// PreviewPools materializes short-lived RunnerDeployments and HorizontalRunnerAutoscalers
+// from `/arc pool` commands commented on pull requests, and deletes them once their TTL expires.
+//
+// Supported commands are:
+//
+//   /arc pool create TEMPLATE=MAX_REPLICAS [ttl=DURATION]
+//   /arc pool delete
+//
+// A preview pool is a copy of the template RunnerDeployment labeled with LabelKeyPreviewPoolTemplate=TEMPLATE,
+// that is scoped to the repository of the pull request and has the additional runner label `pr-NUMBER`
+// so that only the workflow jobs of the pull request can target it.
+type PreviewPools struct {
+	client.Client
+
+	Log          logr.Logger
+	GitHubClient *github.Client
+
+	// Namespace is the namespace of the template RunnerDeployments and the materialized preview pools.
+	Namespace string
+
+	// Repositories is the allowlist of repositories in the OWNER/REPO format. OWNER/* allows all the repositories of the owner.
+	Repositories []string
+
+	// Teams is the allowlist of teams in the ORG/TEAM_SLUG format whose members can run commands.
+	// When empty, any owner, member or collaborator of the repository can run commands.
+	Teams []string
+
+	DefaultTTL  time.Duration
+	MaxTTL      time.Duration
+	MaxReplicas int
+
+	now func() time.Time
+}
+
+var _ manager.LeaderElectionRunnable = &PreviewPools{}
+
+type previewPoolCommand struct {
+	action      string
+	template    string
+	maxReplicas int
+	ttl         time.Duration
+}
+
+// HandleIssueComment runs the command contained in the comment, if any, and replies the result to the pull request.
+func (p *PreviewPools) HandleIssueComment(ctx context.Context, e *gogithub.IssueCommentEvent) error {
+	msg, err := p.runCommand(ctx, e)
+	if err != nil || msg == "" {
+		return err
+	}
+
+	owner, repo, number := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName(), e.GetIssue().GetNumber()
+
+	if _, _, err := p.GitHubClient.Issues.CreateComment(ctx, owner, repo, number, &gogithub.IssueComment{Body: &msg}); err != nil {
+		// The command has already been run so we don't fail the webhook delivery, which results in GitHub redelivering it.
+		p.Log.Error(err, "Failed to reply to preview pool command", "repository", owner+"/"+repo, "pullRequest", number, "reply", msg)
+	}
+
+	return nil
+}
+
+// runCommand runs the command contained in the comment, and returns the message to be replied to the pull request.
+// It returns an empty message without an error when the comment is not a command for preview pools.
+func (p *PreviewPools) runCommand(ctx context.Context, e *gogithub.IssueCommentEvent) (string, error) {
+	if e.GetAction() != "created" || e.GetIssue() == nil || !e.GetIssue().IsPullRequest() {
+		return "", nil
+	}
+
+	cmd, err := p.parseCommand(e.GetComment().GetBody())
+	if err != nil {
+		return err.Error(), nil
+	} else if cmd == nil {
+		return "", nil
+	}
+
+	owner, repo := e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName()
+	ownerAndRepo := owner + "/" + repo
+	number := e.GetIssue().GetNumber()
+	user := e.GetComment().GetUser().GetLogin()
+
+	log := p.Log.WithValues("repository", ownerAndRepo, "pullRequest", number, "user", user, "action", cmd.action)
+
+	if !p.repositoryAllowed(ownerAndRepo) {
+		log.Info("Ignored preview pool command for a repository that is not allowed")
+
+		return fmt.Sprintf("Preview pools are not enabled for %s.", ownerAndRepo), nil
+	}
+
+	allowed, err := p.userAllowed(ctx, user, e.GetComment().GetAuthorAssociation())
+	if err != nil {
+		return "", err
+	}
+
+	if !allowed {
+		log.Info("Ignored preview pool command from a user who is not allowed")
+
+		return fmt.Sprintf("@%s is not allowed to manage preview pools.", user), nil
+	}
+
+	pullRequest := fmt.Sprintf("%s#%d", ownerAndRepo, number)
+
+	switch cmd.action {
+	case "create":
+		return p.create(ctx, log, ownerAndRepo, number, pullRequest, cmd)
+	case "delete":
+		deleted, err := p.deleteForPullRequest(ctx, pullRequest)
+		if err != nil {
+			return "", err
+		}
+
+		log.Info("Deleted preview pools", "deleted", deleted)
+
+		return fmt.Sprintf("Deleted %d preview pool(s).", deleted), nil
+	}
+
+	return "", nil
+}
+
+func (p *PreviewPools) create(ctx context.Context, log logr.Logger, ownerAndRepo string, number int, pullRequest string, cmd *previewPoolCommand) (string, error) {
+	var templates v1alpha1.RunnerDeploymentList
+
+	if err := p.List(ctx, &templates, client.InNamespace(p.Namespace), client.MatchingLabels{LabelKeyPreviewPoolTemplate: cmd.template}); err != nil {
+		return "", err
+	}
+
+	if len(templates.Items) != 1 {
+		return fmt.Sprintf("Preview pool template %q not found.", cmd.template), nil
+	}
+
+	rd := newPreviewPoolRunnerDeployment(templates.Items[0], ownerAndRepo, number, pullRequest, p.clock().Add(cmd.ttl))
+
+	if err := p.Create(ctx, rd); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			return fmt.Sprintf("Preview pool %s already exists. Run `/arc pool delete` first to recreate it.", rd.Name), nil
+		}
+
+		return "", err
+	}
+
+	hra := newPreviewPoolHorizontalRunnerAutoscaler(rd, cmd.maxReplicas)
+
+	if err := p.Create(ctx, hra); err != nil {
+		// The RunnerDeployment without the HRA is never scaled, so we delete it to let the user retry.
+		if delErr := p.Delete(ctx, rd); delErr != nil {
+			log.Error(delErr, "Failed to delete preview pool runnerdeployment after failing to create its horizontalrunnerautoscaler", "runnerdeployment", rd.Name)
+		}
+
+		return "", err
+	}
+
+	log.Info("Created preview pool", "runnerdeployment", rd.Name, "expiresAt", rd.Annotations[AnnotationKeyPreviewPoolExpiresAt])
+
+	return fmt.Sprintf(
+		"Created preview pool %s with up to %d runner(s), which expires at %s. Use `runs-on: [%s]` to run jobs of this pull request on it.",
+		rd.Name, cmd.maxReplicas, rd.Annotations[AnnotationKeyPreviewPoolExpiresAt], strings.Join(append([]string{"self-hosted"}, rd.Spec.Template.Spec.Labels...), ", "),
+	), nil
+}
+
+func newPreviewPoolRunnerDeployment(template v1alpha1.RunnerDeployment, ownerAndRepo string, number int, pullRequest string, expiresAt time.Time) *v1alpha1.RunnerDeployment {
+	name := fmt.Sprintf("preview-%s-pr%d-%s", template.Labels[LabelKeyPreviewPoolTemplate], number, hash.FNVHashStringObjects(ownerAndRepo))
+
+	spec := *template.Spec.DeepCopy()
+	spec.Replicas = nil
+	spec.Template.Spec.Enterprise = ""
+	spec.Template.Spec.Organization = ""
+	spec.Template.Spec.Repository = ownerAndRepo
+	spec.Template.Spec.Labels = append(spec.Template.Spec.Labels, fmt.Sprintf("pr-%d", number))
+
+	return &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: template.Namespace,
+			Labels: map[string]string{
+				LabelKeyPreviewPool: "true",
+			},
+			Annotations: map[string]string{
+				AnnotationKeyPreviewPoolExpiresAt:   expiresAt.UTC().Format(time.RFC3339),
+				AnnotationKeyPreviewPoolPullRequest: pullRequest,
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func newPreviewPoolHorizontalRunnerAutoscaler(rd *v1alpha1.RunnerDeployment, maxReplicas int) *v1alpha1.HorizontalRunnerAutoscaler {
+	minReplicas := 0
+	controller := true
+
+	return &v1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rd.Name,
+			Namespace: rd.Namespace,
+			Labels: map[string]string{
+				LabelKeyPreviewPool: "true",
+			},
+			// Let Kubernetes garbage-collect the HRA on the deletion of the RunnerDeployment.
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: v1alpha1.GroupVersion.String(),
+					Kind:       "RunnerDeployment",
+					Name:       rd.Name,
+					UID:        rd.UID,
+					Controller: &controller,
+				},
+			},
+		},
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: v1alpha1.ScaleTargetRef{
+				Kind: "RunnerDeployment",
+				Name: rd.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: &maxReplicas,
+			ScaleUpTriggers: []v1alpha1.ScaleUpTrigger{
+				{
+					GitHubEvent: &v1alpha1.GitHubEventScaleUpTriggerSpec{
+						WorkflowJob: &v1alpha1.WorkflowJobSpec{},
+					},
+					Amount:   1,
+					Duration: metav1.Duration{Duration: 30 * time.Minute},
+				},
+			},
+		},
+	}
+}
+
+func (p *PreviewPools) parseCommand(body string) (*previewPoolCommand, error) {
+	line := strings.TrimSpace(strings.SplitN(strings.TrimSpace(body), "\n", 2)[0])
+
+	if !strings.HasPrefix(line, previewPoolCommandPrefix) {
+		return nil, nil
+	}
+
+	usage := "Usage: `/arc pool create TEMPLATE=MAX_REPLICAS [ttl=DURATION]` or `/arc pool delete`"
+
+	args := strings.Fields(strings.TrimPrefix(line, previewPoolCommandPrefix))
+	if len(args) == 0 {
+		return nil, errors.New(usage)
+	}
+
+	switch args[0] {
+	case "delete":
+		if len(args) != 1 {
+			return nil, errors.New(usage)
+		}
+
+		return &previewPoolCommand{action: "delete"}, nil
+	case "create":
+	default:
+		return nil, errors.New(usage)
+	}
+
+	cmd := &previewPoolCommand{action: "create", ttl: p.defaultTTL()}
+
+	for _, arg := range args[1:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.New(usage)
+		}
+
+		if kv[0] == "ttl" {
+			ttl, err := time.ParseDuration(kv[1])
+			if err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("Invalid ttl %q. It must be a positive duration like 2h.", kv[1])
+			}
+
+			if max := p.maxTTL(); ttl > max {
+				return nil, fmt.Errorf("ttl %s exceeds the maximum of %s.", ttl, max)
+			}
+
+			cmd.ttl = ttl
+
+			continue
+		}
+
+		if cmd.template != "" {
+			return nil, errors.New("Only one template can be specified per command.")
+		}
+
+		replicas, err := strconv.Atoi(kv[1])
+		if err != nil || replicas <= 0 {
+			return nil, fmt.Errorf("Invalid number of runners %q for %s. It must be a positive integer.", kv[1], kv[0])
+		}
+
+		if max := p.maxReplicas(); replicas > max {
+			return nil, fmt.Errorf("%d runners exceeds the maximum of %d.", replicas, max)
+		}
+
+		cmd.template = kv[0]
+		cmd.maxReplicas = replicas
+	}
+
+	if cmd.template == "" {
+		return nil, errors.New(usage)
+	}
+
+	return cmd, nil
+}
+
+func (p *PreviewPools) repositoryAllowed(ownerAndRepo string) bool {
+	owner := strings.Split(ownerAndRepo, "/")[0]
+
+	for _, r := range p.Repositories {
+		if strings.EqualFold(r, ownerAndRepo) || strings.EqualFold(r, owner+"/*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *PreviewPools) userAllowed(ctx context.Context, user, authorAssociation string) (bool, error) {
+	if len(p.Teams) == 0 {
+		switch authorAssociation {
+		case "OWNER", "MEMBER", "COLLABORATOR":
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	for _, t := range p.Teams {
+		orgAndSlug := strings.SplitN(t, "/", 2)
+		if len(orgAndSlug) != 2 {
+			return false, fmt.Errorf("invalid team %q: it must be in the ORG/TEAM_SLUG format", t)
+		}
+
+		membership, res, err := p.GitHubClient.Teams.GetTeamMembershipBySlug(ctx, orgAndSlug[0], orgAndSlug[1], user)
+		if err != nil {
+			if res != nil && res.StatusCode == 404 {
+				continue
+			}
+
+			return false, err
+		}
+
+		if membership.GetState() == "active" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *PreviewPools) deleteForPullRequest(ctx context.Context, pullRequest string) (int, error) {
+	pools, err := p.list(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+
+	for i := range pools {
+		rd := &pools[i]
+
+		if rd.Annotations[AnnotationKeyPreviewPoolPullRequest] != pullRequest {
+			continue
+		}
+
+		if err := p.Delete(ctx, rd); client.IgnoreNotFound(err) != nil {
+			return deleted, err
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (p *PreviewPools) list(ctx context.Context) ([]v1alpha1.RunnerDeployment, error) {
+	var pools v1alpha1.RunnerDeploymentList
+
+	if err := p.List(ctx, &pools, client.InNamespace(p.Namespace), client.MatchingLabels{LabelKeyPreviewPool: "true"}); err != nil {
+		return nil, err
+	}
+
+	return pools.Items, nil
+}
+
+// Start periodically deletes the expired preview pools until the context is canceled.
+func (p *PreviewPools) Start(ctx context.Context) error {
+	ticker := time.NewTicker(previewPoolCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.deleteExpired(ctx); err != nil {
+			p.Log.Error(err, "Failed to delete expired preview pools")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PreviewPools) NeedLeaderElection() bool {
+	return true
+}
+
+func (p *PreviewPools) deleteExpired(ctx context.Context) error {
+	pools, err := p.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := p.clock()
+
+	for i := range pools {
+		rd := &pools[i]
+
+		expiresAt, err := time.Parse(time.RFC3339, rd.Annotations[AnnotationKeyPreviewPoolExpiresAt])
+		if err != nil {
+			p.Log.Error(err, "Deleting preview pool with invalid expiration time", "runnerdeployment", rd.Name)
+		} else if now.Before(expiresAt) {
+			continue
+		}
+
+		if err := p.Delete(ctx, rd); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		p.Log.Info("Deleted expired preview pool", "runnerdeployment", rd.Name, "pullRequest", rd.Annotations[AnnotationKeyPreviewPoolPullRequest])
+	}
+
+	return nil
+}
+
+func (p *PreviewPools) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+
+	return time.Now()
+}
+
+func (p *PreviewPools) defaultTTL() time.Duration {
+	if p.DefaultTTL > 0 {
+		return p.DefaultTTL
+	}
+
+	return DefaultPreviewPoolTTL
+}
+
+func (p *PreviewPools) maxTTL() time.Duration {
+	if p.MaxTTL > 0 {
+		return p.MaxTTL
+	}
+
+	return DefaultPreviewPoolMaxTTL
+}
+
+func (p *PreviewPools) maxReplicas() int {
+	if p.MaxReplicas > 0 {
+		return p.MaxReplicas
+	}
+
+	return DefaultPreviewPoolMaxReplicas
+}
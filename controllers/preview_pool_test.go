@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPreviewPoolsParseCommand(t *testing.T) {
+	p := &PreviewPools{}
+
+	testcases := []struct {
+		body    string
+		want    *previewPoolCommand
+		wantErr bool
+	}{
+		{body: "LGTM"},
+		{body: "/arc pool create gpu=1", want: &previewPoolCommand{action: "create", template: "gpu", maxReplicas: 1, ttl: DefaultPreviewPoolTTL}},
+		{body: "/arc pool create gpu=2 ttl=30m\nThanks!", want: &previewPoolCommand{action: "create", template: "gpu", maxReplicas: 2, ttl: 30 * time.Minute}},
+		{body: "/arc pool delete", want: &previewPoolCommand{action: "delete"}},
+		{body: "/arc pool", wantErr: true},
+		{body: "/arc pool create", wantErr: true},
+		{body: "/arc pool create gpu=0", wantErr: true},
+		{body: "/arc pool create gpu=4", wantErr: true},
+		{body: "/arc pool create gpu=1 ttl=48h", wantErr: true},
+		{body: "/arc pool create gpu=1 mac=1", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		got, err := p.parseCommand(tc.body)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", tc.body, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.body, err)
+			continue
+		}
+
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("%q: unexpected command: want %+v, got %+v", tc.body, tc.want, got)
+		}
+	}
+}
+
+func TestPreviewPools(t *testing.T) {
+	ctx := context.Background()
+
+	var replies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/test/valid/issues/123/comments" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var comment gogithub.IssueComment
+		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		replies = append(replies, comment.GetBody())
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	template := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gpu-template",
+			Namespace: "runners",
+			Labels:    map[string]string{LabelKeyPreviewPoolTemplate: "gpu"},
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Organization: "test",
+						Labels:       []string{"gpu"},
+					},
+				},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, template)
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	p := &PreviewPools{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+		Namespace:    "runners",
+		Repositories: []string{"test/*"},
+		now:          func() time.Time { return now },
+	}
+
+	comment := func(body, association string) *gogithub.IssueCommentEvent {
+		return &gogithub.IssueCommentEvent{
+			Action: gogithub.String("created"),
+			Issue: &gogithub.Issue{
+				Number:           gogithub.Int(123),
+				PullRequestLinks: &gogithub.PullRequestLinks{URL: gogithub.String("https://api.github.com/repos/test/valid/pulls/123")},
+			},
+			Comment: &gogithub.IssueComment{
+				Body:              gogithub.String(body),
+				AuthorAssociation: gogithub.String(association),
+				User:              &gogithub.User{Login: gogithub.String("developer")},
+			},
+			Repo: &gogithub.Repository{
+				Name:  gogithub.String("valid"),
+				Owner: &gogithub.User{Login: gogithub.String("test")},
+			},
+		}
+	}
+
+	listPools := func() []v1alpha1.RunnerDeployment {
+		t.Helper()
+
+		var rds v1alpha1.RunnerDeploymentList
+		if err := c.List(ctx, &rds, client.MatchingLabels{LabelKeyPreviewPool: "true"}); err != nil {
+			t.Fatal(err)
+		}
+
+		return rds.Items
+	}
+
+	if err := p.HandleIssueComment(ctx, comment("/arc pool create gpu=2 ttl=1h", "NONE")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pools := listPools(); len(pools) != 0 {
+		t.Fatalf("unexpected preview pools created by a user who isn't allowed: %v", pools)
+	}
+
+	if err := p.HandleIssueComment(ctx, comment("/arc pool create gpu=2 ttl=1h", "MEMBER")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pools := listPools()
+	if len(pools) != 1 {
+		t.Fatalf("unexpected number of preview pools: %d", len(pools))
+	}
+
+	rd := pools[0]
+
+	if rd.Spec.Template.Spec.Repository != "test/valid" || rd.Spec.Template.Spec.Organization != "" {
+		t.Errorf("unexpected scope of preview pool: %+v", rd.Spec.Template.Spec.RunnerConfig)
+	}
+
+	if labels := rd.Spec.Template.Spec.Labels; len(labels) != 2 || labels[1] != "pr-123" {
+		t.Errorf("unexpected runner labels of preview pool: %v", labels)
+	}
+
+	if got := rd.Annotations[AnnotationKeyPreviewPoolExpiresAt]; got != "2022-03-01T11:00:00Z" {
+		t.Errorf("unexpected expiration time: %s", got)
+	}
+
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "runners", Name: rd.Name}, &hra); err != nil {
+		t.Fatalf("getting preview pool hra: %v", err)
+	}
+
+	if *hra.Spec.MinReplicas != 0 || *hra.Spec.MaxReplicas != 2 {
+		t.Errorf("unexpected replicas of preview pool hra: min=%d max=%d", *hra.Spec.MinReplicas, *hra.Spec.MaxReplicas)
+	}
+
+	if len(replies) != 2 {
+		t.Fatalf("unexpected number of replies: %v", replies)
+	}
+
+	// Not yet expired
+	if err := p.deleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if pools := listPools(); len(pools) != 1 {
+		t.Fatalf("unexpected number of preview pools before expiration: %d", len(pools))
+	}
+
+	now = now.Add(time.Hour)
+
+	if err := p.deleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if pools := listPools(); len(pools) != 0 {
+		t.Fatalf("unexpected number of preview pools after expiration: %d", len(pools))
+	}
+}
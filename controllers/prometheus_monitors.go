@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get
+
+const (
+	DefaultPrometheusMonitorInterval = 1 * time.Minute
+	DefaultPrometheusMonitorPortName = "metrics"
+
+	// LabelKeyManagedBy marks the objects generated by the controller, which are updated and deleted along with their sources.
+	LabelKeyManagedBy = "app.kubernetes.io/managed-by"
+	managedByValue    = "actions-runner-controller"
+)
+
+var (
+	serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	podMonitorGVK     = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"}
+)
+
+// PrometheusMonitorGenerator periodically generates the ServiceMonitor and PodMonitor objects of the Prometheus Operator
+// for the metrics of the controller and the runner pods, so that the scrape configuration doesn't drift from the pods.
+//
+// A ServiceMonitor is generated for the metrics Service of the controller, selecting the Service by its current labels.
+// A PodMonitor is generated for each RunnerDeployment and RunnerSet whose pods expose a container port named PortName,
+// selecting the pods by the labels the controller puts on them, and is deleted once the port is removed.
+// The monitors are owned by their sources, so that they are garbage-collected along with them.
+// Nothing is generated while the CRDs of the Prometheus Operator aren't installed.
+type PrometheusMonitorGenerator struct {
+	client.Client
+	Log        logr.Logger
+	RESTMapper meta.RESTMapper
+
+	Interval  time.Duration
+	Namespace string
+
+	// PortName is the name of the container port of runner pods their metrics are scraped from. Defaults to metrics.
+	PortName string
+
+	// Labels are added to the generated monitors, e.g. to be selected by the serviceMonitorSelector and the podMonitorSelector of Prometheus.
+	Labels map[string]string
+
+	// ControllerService is the metrics Service of the controller. The ServiceMonitor of the controller isn't generated when nil.
+	ControllerService *types.NamespacedName
+
+	// ControllerScheme is the scheme the controller's metrics are scraped with. The https scheme scrapes them via kube-rbac-proxy
+	// with the service account token of Prometheus. Defaults to http.
+	ControllerScheme string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader generates the monitors.
+func (g *PrometheusMonitorGenerator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (g *PrometheusMonitorGenerator) Start(ctx context.Context) error {
+	interval := g.interval()
+
+	g.Log.Info("Starting Prometheus monitor generator", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.syncAll(ctx); err != nil {
+			g.Log.Error(err, "Failed to generate Prometheus monitors")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *PrometheusMonitorGenerator) interval() time.Duration {
+	if g.Interval <= 0 {
+		return DefaultPrometheusMonitorInterval
+	}
+
+	return g.Interval
+}
+
+func (g *PrometheusMonitorGenerator) portName() string {
+	if g.PortName == "" {
+		return DefaultPrometheusMonitorPortName
+	}
+
+	return g.PortName
+}
+
+// installed returns true if the CRD of the kind is installed.
+// The REST mapper of the manager rediscovers the API groups on a miss, so the CRDs installed later are noticed.
+func (g *PrometheusMonitorGenerator) installed(gvk schema.GroupVersionKind) bool {
+	_, err := g.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+
+	return err == nil
+}
+
+func (g *PrometheusMonitorGenerator) syncAll(ctx context.Context) error {
+	if g.ControllerService != nil {
+		if !g.installed(serviceMonitorGVK) {
+			g.Log.V(1).Info("Skipped generating the ServiceMonitor of the controller as the ServiceMonitor CRD isn't installed")
+		} else if err := g.syncControllerServiceMonitor(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !g.installed(podMonitorGVK) {
+		g.Log.V(1).Info("Skipped generating the PodMonitors of runner pods as the PodMonitor CRD isn't installed")
+
+		return nil
+	}
+
+	return g.syncRunnerPodMonitors(ctx)
+}
+
+func (g *PrometheusMonitorGenerator) syncControllerServiceMonitor(ctx context.Context) error {
+	var svc corev1.Service
+	if err := g.Get(ctx, *g.ControllerService, &svc); err != nil {
+		if kerrors.IsNotFound(err) {
+			g.Log.V(1).Info("Skipped generating the ServiceMonitor of the controller as its metrics service doesn't exist", "service", *g.ControllerService)
+
+			return nil
+		}
+
+		return err
+	}
+
+	if len(svc.Labels) == 0 || len(svc.Spec.Ports) == 0 || svc.Spec.Ports[0].Name == "" {
+		return fmt.Errorf("the metrics service %s of the controller needs labels and a named port to be selected by the ServiceMonitor", *g.ControllerService)
+	}
+
+	endpoint := map[string]interface{}{
+		"port": svc.Spec.Ports[0].Name,
+		"path": "/metrics",
+	}
+
+	if g.ControllerScheme == "https" {
+		endpoint["scheme"] = "https"
+		endpoint["bearerTokenFile"] = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		endpoint["tlsConfig"] = map[string]interface{}{"insecureSkipVerify": true}
+	}
+
+	monitor, err := g.newMonitor(serviceMonitorGVK, &svc, svc.Name, map[string]interface{}{
+		"selector":  map[string]interface{}{"matchLabels": svc.Labels},
+		"endpoints": []interface{}{endpoint},
+	})
+	if err != nil {
+		return err
+	}
+
+	return g.apply(ctx, monitor)
+}
+
+func (g *PrometheusMonitorGenerator) syncRunnerPodMonitors(ctx context.Context) error {
+	var opts []client.ListOption
+	if g.Namespace != "" {
+		opts = append(opts, client.InNamespace(g.Namespace))
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := g.List(ctx, &rds, opts...); err != nil {
+		return err
+	}
+
+	var rss v1alpha1.RunnerSetList
+	if err := g.List(ctx, &rss, opts...); err != nil {
+		return err
+	}
+
+	desired := map[types.NamespacedName]bool{}
+
+	for i := range rds.Items {
+		rd := &rds.Items[i]
+
+		spec := rd.Spec.Template.Spec
+		if !hasContainerPort(g.portName(), spec.Containers, spec.SidecarContainers) {
+			continue
+		}
+
+		if err := g.applyPodMonitor(ctx, rd, "runnerdeployment-"+rd.Name, LabelKeyRunnerDeploymentName, rd.Name); err != nil {
+			g.Log.Error(err, "Failed to generate PodMonitor", "runnerdeployment", types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name})
+
+			continue
+		}
+
+		desired[types.NamespacedName{Namespace: rd.Namespace, Name: "runnerdeployment-" + rd.Name}] = true
+	}
+
+	for i := range rss.Items {
+		rs := &rss.Items[i]
+
+		if !hasContainerPort(g.portName(), rs.Spec.Template.Spec.Containers) {
+			continue
+		}
+
+		if err := g.applyPodMonitor(ctx, rs, "runnerset-"+rs.Name, LabelKeyRunnerSetName, rs.Name); err != nil {
+			g.Log.Error(err, "Failed to generate PodMonitor", "runnerset", types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name})
+
+			continue
+		}
+
+		desired[types.NamespacedName{Namespace: rs.Namespace, Name: "runnerset-" + rs.Name}] = true
+	}
+
+	var existing unstructured.UnstructuredList
+	existing.SetGroupVersionKind(podMonitorGVK.GroupVersion().WithKind(podMonitorGVK.Kind + "List"))
+
+	if err := g.List(ctx, &existing, append(opts, client.MatchingLabels{LabelKeyManagedBy: managedByValue})...); err != nil {
+		return err
+	}
+
+	for i := range existing.Items {
+		m := &existing.Items[i]
+
+		if desired[types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()}] {
+			continue
+		}
+
+		if err := g.Delete(ctx, m); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		g.Log.Info("Deleted PodMonitor of the runner pods no longer exposing metrics", "podmonitor", types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()})
+	}
+
+	return nil
+}
+
+func (g *PrometheusMonitorGenerator) applyPodMonitor(ctx context.Context, owner client.Object, name, labelKey, labelValue string) error {
+	monitor, err := g.newMonitor(podMonitorGVK, owner, name, map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]string{labelKey: labelValue}},
+		"podMetricsEndpoints": []interface{}{
+			map[string]interface{}{"port": g.portName(), "path": "/metrics"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return g.apply(ctx, monitor)
+}
+
+// newMonitor returns the monitor of the kind with the spec, owned by the owner in the namespace of the owner.
+func (g *PrometheusMonitorGenerator) newMonitor(gvk schema.GroupVersionKind, owner client.Object, name string, spec map[string]interface{}) (*unstructured.Unstructured, error) {
+	// The spec is normalized into the JSON types, so that it's compared with the one read from the API server as is.
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+
+	monitor := &unstructured.Unstructured{Object: map[string]interface{}{"spec": normalized}}
+	monitor.SetGroupVersionKind(gvk)
+	monitor.SetNamespace(owner.GetNamespace())
+	monitor.SetName(name)
+
+	labels := map[string]string{}
+	for k, v := range g.Labels {
+		labels[k] = v
+	}
+	labels[LabelKeyManagedBy] = managedByValue
+	monitor.SetLabels(labels)
+
+	if err := controllerutil.SetControllerReference(owner, monitor, g.Scheme()); err != nil {
+		return nil, err
+	}
+
+	return monitor, nil
+}
+
+// apply creates the monitor, or updates the existing one generated by the controller when it differs.
+// The monitors of the same names not generated by the controller are left as is.
+func (g *PrometheusMonitorGenerator) apply(ctx context.Context, monitor *unstructured.Unstructured) error {
+	key := types.NamespacedName{Namespace: monitor.GetNamespace(), Name: monitor.GetName()}
+	log := g.Log.WithValues(monitor.GetKind(), key)
+
+	var existing unstructured.Unstructured
+	existing.SetGroupVersionKind(monitor.GroupVersionKind())
+
+	if err := g.Get(ctx, key, &existing); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if err := g.Create(ctx, monitor); err != nil {
+			return err
+		}
+
+		log.Info("Created Prometheus monitor")
+
+		return nil
+	}
+
+	if existing.GetLabels()[LabelKeyManagedBy] != managedByValue {
+		log.V(1).Info("Skipped updating Prometheus monitor not generated by the controller")
+
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.Object["spec"], monitor.Object["spec"]) &&
+		equality.Semantic.DeepEqual(existing.GetLabels(), monitor.GetLabels()) &&
+		equality.Semantic.DeepEqual(existing.GetOwnerReferences(), monitor.GetOwnerReferences()) {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+	updated.Object["spec"] = monitor.Object["spec"]
+	updated.SetLabels(monitor.GetLabels())
+	updated.SetOwnerReferences(monitor.GetOwnerReferences())
+
+	if err := g.Update(ctx, updated); err != nil {
+		return err
+	}
+
+	log.Info("Updated Prometheus monitor")
+
+	return nil
+}
+
+// hasContainerPort returns true if any of the containers has the port of the name.
+func hasContainerPort(name string, containerLists ...[]corev1.Container) bool {
+	for _, containers := range containerLists {
+		for _, c := range containers {
+			for _, p := range c.Ports {
+				if p.Name == name {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
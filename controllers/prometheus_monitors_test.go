@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPrometheusMonitorGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	metricsPort := []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9102}}
+
+	stale := &unstructured.Unstructured{}
+	stale.SetGroupVersionKind(podMonitorGVK)
+	stale.SetNamespace("default")
+	stale.SetName("runnerdeployment-removed")
+	stale.SetLabels(map[string]string{LabelKeyManagedBy: managedByValue})
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "with-metrics"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{Spec: v1alpha1.RunnerSpec{RunnerPodSpec: v1alpha1.RunnerPodSpec{
+					SidecarContainers: []corev1.Container{{Name: "exporter", Ports: metricsPort}},
+				}}},
+			},
+		},
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "without-metrics"},
+		},
+		&v1alpha1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "set"},
+			Spec: v1alpha1.RunnerSetSpec{
+				StatefulSetSpec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "runner", Ports: metricsPort}}}},
+				},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "actions-runner-system", Name: "controller-metrics", Labels: map[string]string{"app": "controller"}},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "metrics-port", Port: 8443}}},
+		},
+		stale,
+	).Build()
+
+	g := &PrometheusMonitorGenerator{
+		Client:            c,
+		Log:               logr.Discard(),
+		RESTMapper:        monitorRESTMapper(serviceMonitorGVK, podMonitorGVK),
+		Labels:            map[string]string{"release": "prometheus"},
+		ControllerService: &types.NamespacedName{Namespace: "actions-runner-system", Name: "controller-metrics"},
+		ControllerScheme:  "https",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := g.syncAll(ctx); err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+	}
+
+	get := func(gvk schema.GroupVersionKind, ns, name string) *unstructured.Unstructured {
+		t.Helper()
+
+		var m unstructured.Unstructured
+		m.SetGroupVersionKind(gvk)
+
+		if err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, &m); err != nil {
+			t.Fatalf("%s %s/%s: %v", gvk.Kind, ns, name, err)
+		}
+
+		return &m
+	}
+
+	sm := get(serviceMonitorGVK, "actions-runner-system", "controller-metrics")
+	if v, _, _ := unstructured.NestedString(sm.Object, "spec", "selector", "matchLabels", "app"); v != "controller" {
+		t.Errorf("unexpected ServiceMonitor selector: %v", sm.Object["spec"])
+	}
+	if eps, _, _ := unstructured.NestedSlice(sm.Object, "spec", "endpoints"); len(eps) != 1 || eps[0].(map[string]interface{})["scheme"] != "https" || eps[0].(map[string]interface{})["port"] != "metrics-port" {
+		t.Errorf("unexpected ServiceMonitor endpoints: %v", eps)
+	}
+	if sm.GetLabels()["release"] != "prometheus" {
+		t.Errorf("unexpected ServiceMonitor labels: %v", sm.GetLabels())
+	}
+
+	pm := get(podMonitorGVK, "default", "runnerdeployment-with-metrics")
+	if v, _, _ := unstructured.NestedString(pm.Object, "spec", "selector", "matchLabels", LabelKeyRunnerDeploymentName); v != "with-metrics" {
+		t.Errorf("unexpected PodMonitor selector: %v", pm.Object["spec"])
+	}
+	if refs := pm.GetOwnerReferences(); len(refs) != 1 || refs[0].Kind != "RunnerDeployment" || refs[0].Name != "with-metrics" {
+		t.Errorf("unexpected PodMonitor owner references: %v", refs)
+	}
+
+	pm = get(podMonitorGVK, "default", "runnerset-set")
+	if v, _, _ := unstructured.NestedString(pm.Object, "spec", "selector", "matchLabels", LabelKeyRunnerSetName); v != "set" {
+		t.Errorf("unexpected PodMonitor selector: %v", pm.Object["spec"])
+	}
+
+	var pms unstructured.UnstructuredList
+	pms.SetGroupVersionKind(podMonitorGVK.GroupVersion().WithKind("PodMonitorList"))
+	if err := c.List(ctx, &pms, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pms.Items) != 2 {
+		var names []string
+		for _, m := range pms.Items {
+			names = append(names, m.GetName())
+		}
+
+		t.Errorf("unexpected PodMonitors: %v", names)
+	}
+}
+
+func TestPrometheusMonitorGenerator_CRDsNotInstalled(t *testing.T) {
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "with-metrics"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{Spec: v1alpha1.RunnerSpec{RunnerPodSpec: v1alpha1.RunnerPodSpec{
+					Containers: []corev1.Container{{Name: "runner", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9102}}}},
+				}}},
+			},
+		},
+	).Build()
+
+	g := &PrometheusMonitorGenerator{
+		Client:     c,
+		Log:        logr.Discard(),
+		RESTMapper: monitorRESTMapper(),
+	}
+
+	if err := g.syncAll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func monitorRESTMapper(gvks ...schema.GroupVersionKind) meta.RESTMapper {
+	m := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range gvks {
+		m.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	return m
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DegradedConditionType is the type of the condition set to HorizontalRunnerAutoscaler, RunnerDeployment,
+	// RunnerSet and RunnerGroup. It's True while the reconciliations of the object keep failing
+	// and it's retried only at the degraded requeue interval.
+	DegradedConditionType = "Degraded"
+
+	DefaultReconcileErrorThreshold          = 10
+	DefaultReconcileDegradedRequeueInterval = 30 * time.Minute
+
+	conditionReasonReconcileFailing   = "ReconcileFailing"
+	conditionReasonReconcileSucceeded = "ReconcileSucceeded"
+)
+
+// ReconcileErrorBudget configures the circuit breaker of a controller that stops retrying the objects
+// whose reconciliations keep failing with the exponential backoff, so that a handful of permanently broken objects
+// don't flood the logs and occupy the workers.
+type ReconcileErrorBudget struct {
+	// Threshold is the number of consecutive reconcile failures of an object after which the object is degraded.
+	// Set to 0 to disable the circuit breaker.
+	Threshold int
+
+	// DegradedRequeueInterval is the interval at which a degraded object is retried.
+	// Defaults to DefaultReconcileDegradedRequeueInterval.
+	DegradedRequeueInterval time.Duration
+}
+
+// wrap returns the reconciler that counts the consecutive failures of inner per object,
+// or inner as is when the circuit breaker is disabled.
+func (b ReconcileErrorBudget) wrap(name string, inner reconcile.Reconciler, c client.Client, recorder record.EventRecorder, log logr.Logger, newObject func() client.Object) reconcile.Reconciler {
+	if b.Threshold <= 0 {
+		return inner
+	}
+
+	interval := b.DegradedRequeueInterval
+	if interval <= 0 {
+		interval = DefaultReconcileDegradedRequeueInterval
+	}
+
+	return &errorBudgetReconciler{
+		name:      name,
+		inner:     inner,
+		threshold: b.Threshold,
+		interval:  interval,
+		client:    c,
+		recorder:  recorder,
+		log:       log,
+		newObject: newObject,
+		failures:  map[types.NamespacedName]*reconcileFailures{},
+	}
+}
+
+type reconcileFailures struct {
+	count int
+	since time.Time
+}
+
+type errorBudgetReconciler struct {
+	name      string
+	inner     reconcile.Reconciler
+	threshold int
+	interval  time.Duration
+	client    client.Client
+	recorder  record.EventRecorder
+	log       logr.Logger
+	newObject func() client.Object
+
+	mu       sync.Mutex
+	failures map[types.NamespacedName]*reconcileFailures
+}
+
+func (r *errorBudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	res, err := r.inner.Reconcile(ctx, req)
+	if err == nil {
+		r.succeeded(ctx, req.NamespacedName)
+
+		return res, nil
+	}
+
+	f, degraded := r.failed(req.NamespacedName)
+	if !degraded {
+		return res, err
+	}
+
+	log := r.log.WithValues("object", req.NamespacedName)
+
+	obj := r.newObject()
+	if getErr := r.client.Get(ctx, req.NamespacedName, obj); getErr != nil {
+		if kerrors.IsNotFound(getErr) {
+			r.forget(req.NamespacedName)
+
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{RequeueAfter: r.interval}, nil
+	}
+
+	if f.count == r.threshold {
+		log.Error(err, "Reconciliation keeps failing. Retrying only at the degraded requeue interval until it succeeds", "failures", f.count, "since", f.since, "requeueAfter", r.interval)
+
+		r.recorder.Event(obj, corev1.EventTypeWarning, "ReconcileDegraded", fmt.Sprintf(
+			"Reconciliation failed %d times in a row since %s. Retrying every %s until it succeeds: %v", f.count, f.since.Format(time.RFC3339), r.interval, err,
+		))
+	}
+
+	if patchErr := r.patchDegradedCondition(ctx, obj, metav1.Condition{
+		Type:    DegradedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionReasonReconcileFailing,
+		Message: fmt.Sprintf("Reconciliation failed %d times in a row since %s: %v", r.threshold, f.since.Format(time.RFC3339), err),
+	}); patchErr != nil {
+		log.V(1).Info("Failed to patch the degraded condition", "error", patchErr.Error())
+	}
+
+	return ctrl.Result{RequeueAfter: r.interval}, nil
+}
+
+// failed counts the failure and returns the failures of the object along with whether it's degraded.
+func (r *errorBudgetReconciler) failed(key types.NamespacedName) (reconcileFailures, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.failures[key]
+	if !ok {
+		f = &reconcileFailures{since: time.Now()}
+		r.failures[key] = f
+	}
+
+	f.count++
+
+	r.updateMetrics()
+
+	return *f, f.count >= r.threshold
+}
+
+// forget drops the failures of the object, returning the failures that were recorded.
+func (r *errorBudgetReconciler) forget(key types.NamespacedName) (reconcileFailures, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.failures[key]
+	if !ok {
+		return reconcileFailures{}, false
+	}
+
+	delete(r.failures, key)
+
+	r.updateMetrics()
+
+	return *f, true
+}
+
+func (r *errorBudgetReconciler) updateMetrics() {
+	var degraded int
+
+	for _, f := range r.failures {
+		if f.count >= r.threshold {
+			degraded++
+		}
+	}
+
+	metrics.SetReconcileDegradedObjects(r.name, degraded)
+}
+
+// succeeded resets the failures of the object, recovering it from the degraded state if it was.
+// The Degraded condition is cleared even when no failures are recorded, so that
+// the condition left by the previous controller process is cleared after a restart, too.
+func (r *errorBudgetReconciler) succeeded(ctx context.Context, key types.NamespacedName) {
+	f, ok := r.forget(key)
+
+	wasDegraded := ok && f.count >= r.threshold
+
+	obj := r.newObject()
+	if err := r.client.Get(ctx, key, obj); err != nil {
+		return
+	}
+
+	if conds := objectConditions(obj); conds != nil && meta.IsStatusConditionTrue(*conds, DegradedConditionType) {
+		wasDegraded = true
+
+		if err := r.patchDegradedCondition(ctx, obj, metav1.Condition{
+			Type:    DegradedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonReconcileSucceeded,
+			Message: "Reconciliation succeeded",
+		}); err != nil {
+			r.log.V(1).Info("Failed to patch the degraded condition", "object", key, "error", err.Error())
+		}
+	}
+
+	if !wasDegraded {
+		return
+	}
+
+	r.log.Info("Reconciliation succeeded after being degraded", "object", key)
+
+	r.recorder.Event(obj, corev1.EventTypeNormal, "ReconcileRecovered", "Reconciliation succeeded after being degraded")
+}
+
+// patchDegradedCondition sets the condition to the object when it has conditions.
+func (r *errorBudgetReconciler) patchDegradedCondition(ctx context.Context, obj client.Object, cond metav1.Condition) error {
+	updated, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return nil
+	}
+
+	conds := objectConditions(updated)
+	if conds == nil {
+		return nil
+	}
+
+	cond.ObservedGeneration = obj.GetGeneration()
+	meta.SetStatusCondition(conds, cond)
+
+	if reflect.DeepEqual(objectConditions(obj), conds) {
+		return nil
+	}
+
+	return r.client.Status().Patch(ctx, updated, client.MergeFrom(obj))
+}
+
+// objectConditions returns the pointer to the conditions of the object, or nil when the object has no conditions.
+func objectConditions(obj client.Object) *[]metav1.Condition {
+	switch o := obj.(type) {
+	case *v1alpha1.HorizontalRunnerAutoscaler:
+		return &o.Status.Conditions
+	case *v1alpha1.RunnerDeployment:
+		return &o.Status.Conditions
+	case *v1alpha1.RunnerSet:
+		return &o.Status.Conditions
+	case *v1alpha1.RunnerGroup:
+		return &o.Status.Conditions
+	}
+
+	return nil
+}
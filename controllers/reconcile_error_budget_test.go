@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileErrorBudget(t *testing.T) {
+	ctx := context.Background()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+	}
+
+	c := clientfake.NewFakeClientWithScheme(sc, rd)
+
+	recorder := record.NewFakeRecorder(10)
+
+	var reconcileErr error
+
+	inner := reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+		return ctrl.Result{}, reconcileErr
+	})
+
+	b := ReconcileErrorBudget{Threshold: 3, DegradedRequeueInterval: time.Hour}
+
+	r := b.wrap("runnerdeployment-controller", inner, c, recorder, logr.Discard(), func() client.Object { return &v1alpha1.RunnerDeployment{} })
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	degradedCondition := func() *metav1.Condition {
+		t.Helper()
+
+		var got v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return meta.FindStatusCondition(got.Status.Conditions, DegradedConditionType)
+	}
+
+	reconcileErr = errors.New("broken")
+
+	for i := 1; i < b.Threshold; i++ {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+			t.Fatalf("failure %d: expected the error to be returned", i)
+		}
+	}
+
+	if cond := degradedCondition(); cond != nil {
+		t.Fatalf("unexpected degraded condition before reaching the threshold: %+v", cond)
+	}
+
+	for i := 0; i < 3; i++ {
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("unexpected error while degraded: %v", err)
+		}
+
+		if res.RequeueAfter != time.Hour {
+			t.Errorf("unexpected requeue after while degraded: %v", res.RequeueAfter)
+		}
+	}
+
+	if cond := degradedCondition(); cond == nil || cond.Status != metav1.ConditionTrue || !strings.Contains(cond.Message, "broken") {
+		t.Fatalf("unexpected degraded condition: %+v", cond)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected a single event while degraded, got %d", len(recorder.Events))
+	}
+
+	if e := <-recorder.Events; !strings.Contains(e, "ReconcileDegraded") {
+		t.Errorf("unexpected event: %q", e)
+	}
+
+	reconcileErr = nil
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cond := degradedCondition(); cond == nil || cond.Status != metav1.ConditionFalse {
+		t.Fatalf("unexpected degraded condition after recovery: %+v", cond)
+	}
+
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, "ReconcileRecovered") {
+			t.Errorf("unexpected event: %q", e)
+		}
+	default:
+		t.Errorf("expected ReconcileRecovered event, got none")
+	}
+
+	reconcileErr = errors.New("broken")
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Errorf("expected the failures to be reset on success")
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+)
+
+const (
+	DefaultRegistrationTokenRefreshInterval = time.Minute
+
+	// registrationTokenRefreshLead is how long before a cached registration token becomes too old to be given
+	// to runners it's refreshed. It's longer than the refresh interval so that a token is refreshed before
+	// it becomes too old even when a refresh fails once.
+	registrationTokenRefreshLead = 5 * time.Minute
+)
+
+// RegistrationTokenRefresher periodically refreshes the registration tokens cached by the GitHub clients
+// ahead of their expiry, so that a large scale out creates runners from the cached tokens
+// instead of requesting a new token from the GitHub API on the runner creation.
+//
+// Only the tokens of the enterprises, organizations and repositories runners were recently created for are refreshed.
+type RegistrationTokenRefresher struct {
+	Log           logr.Logger
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// The tokens are cached by each controller process, so every process refreshes its own tokens.
+func (r *RegistrationTokenRefresher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (r *RegistrationTokenRefresher) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultRegistrationTokenRefreshInterval
+	}
+
+	r.Log.Info("Starting registration token refresh", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		r.refresh(ctx)
+	}
+}
+
+func (r *RegistrationTokenRefresher) refresh(ctx context.Context) {
+	clients := r.GitHubClients.list()
+	if r.GitHubClient != nil {
+		clients = append(clients, r.GitHubClient)
+	}
+
+	for _, c := range clients {
+		if err := c.RefreshRegistrationTokens(ctx, registrationTokenRefreshLead); err != nil {
+			r.Log.Error(err, "Failed to refresh registration tokens")
+		}
+	}
+}
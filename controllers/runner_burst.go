@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AnnotationKeyBurstRunner is set to "true" on the runners created for the burst replicas of a RunnerReplicaSet.
+const AnnotationKeyBurstRunner = annotationKeyPrefix + "burst"
+
+// newBurstAwareRunnerFactory returns a function that creates runners for the RunnerReplicaSet.
+// Runners created while the existing non-burst runners already fill the replicas not backed by
+// the capacity reservations are burst runners, which are given the burst priority class.
+// Existing runners are never updated, so a runner keeps its priority class until it's recreated.
+func newBurstAwareRunnerFactory(rs v1alpha1.RunnerReplicaSet, desired v1alpha1.Runner, live []v1alpha1.Runner, replicas int) func() client.Object {
+	burstPriorityClassName := rs.Spec.BurstPriorityClassName
+	burstReplicas := getIntOrDefault(rs.Spec.BurstReplicas, 0)
+
+	if burstPriorityClassName == "" || burstReplicas <= 0 {
+		return func() client.Object { return desired.DeepCopy() }
+	}
+
+	steadyReplicas := replicas - burstReplicas
+	if steadyReplicas < 0 {
+		steadyReplicas = 0
+	}
+
+	var steady int
+	for _, r := range live {
+		if !r.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if _, ok := getAnnotation(&r, AnnotationKeyBurstRunner); !ok {
+			steady++
+		}
+	}
+
+	return func() client.Object {
+		runner := desired.DeepCopy()
+
+		if steady < steadyReplicas {
+			steady++
+			return runner
+		}
+
+		setAnnotation(&runner.ObjectMeta, AnnotationKeyBurstRunner, "true")
+		runner.Spec.PriorityClassName = burstPriorityClassName
+
+		return runner
+	}
+}
+
+// setBurstReplicas sets the number of the replicas backed by the active capacity reservations to the RunnerDeployment
+// that has the burst priority class, and returns true if it's changed.
+func setBurstReplicas(rd *v1alpha1.RunnerDeployment, reserved, replicas int) bool {
+	if rd.Spec.BurstPriorityClassName == "" {
+		return false
+	}
+
+	burst := reserved
+	if burst > replicas {
+		burst = replicas
+	}
+
+	if getIntOrDefault(rd.Spec.BurstReplicas, 0) == burst {
+		return false
+	}
+
+	rd.Spec.BurstReplicas = &burst
+
+	return true
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewBurstAwareRunnerFactory(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	newRunner := func(burst bool) v1alpha1.Runner {
+		var r v1alpha1.Runner
+		if burst {
+			setAnnotation(&r.ObjectMeta, AnnotationKeyBurstRunner, "true")
+		}
+		return r
+	}
+
+	deleting := newRunner(false)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	testcases := []struct {
+		description            string
+		burstPriorityClassName string
+		burstReplicas          *int
+		replicas               int
+		live                   []v1alpha1.Runner
+		create                 int
+		want                   []string
+	}{
+		{
+			description: "no burst priority class",
+			replicas:    3,
+			create:      2,
+			want:        []string{"", ""},
+		},
+		{
+			description:            "no burst replicas",
+			burstPriorityClassName: "burst",
+			replicas:               3,
+			create:                 2,
+			want:                   []string{"", ""},
+		},
+		{
+			description:            "fill the steady replicas first",
+			burstPriorityClassName: "burst",
+			burstReplicas:          intPtr(3),
+			replicas:               5,
+			live:                   []v1alpha1.Runner{newRunner(false)},
+			create:                 4,
+			want:                   []string{"", "burst", "burst", "burst"},
+		},
+		{
+			description:            "runners being deleted don't count",
+			burstPriorityClassName: "burst",
+			burstReplicas:          intPtr(1),
+			replicas:               3,
+			live:                   []v1alpha1.Runner{newRunner(false), deleting, newRunner(true)},
+			create:                 2,
+			want:                   []string{"", "burst"},
+		},
+		{
+			description:            "burst replicas exceeding the replicas",
+			burstPriorityClassName: "burst",
+			burstReplicas:          intPtr(5),
+			replicas:               2,
+			create:                 2,
+			want:                   []string{"burst", "burst"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			rs := v1alpha1.RunnerReplicaSet{
+				Spec: v1alpha1.RunnerReplicaSetSpec{
+					BurstPriorityClassName: tc.burstPriorityClassName,
+					BurstReplicas:          tc.burstReplicas,
+				},
+			}
+
+			create := newBurstAwareRunnerFactory(rs, v1alpha1.Runner{}, tc.live, tc.replicas)
+
+			for j := 0; j < tc.create; j++ {
+				runner := create().(*v1alpha1.Runner)
+
+				_, burst := getAnnotation(runner, AnnotationKeyBurstRunner)
+
+				if got := runner.Spec.PriorityClassName; got != tc.want[j] || burst != (tc.want[j] != "") {
+					t.Errorf("runner %d: unexpected priority class %q (burst=%v), want %q", j, got, burst, tc.want[j])
+				}
+			}
+		})
+	}
+}
+
+func TestSetBurstReplicas(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	testcases := []struct {
+		burstPriorityClassName string
+		current                *int
+		reserved, replicas     int
+		wantChanged            bool
+		want                   *int
+	}{
+		{reserved: 2, replicas: 5},
+		{burstPriorityClassName: "burst", reserved: 2, replicas: 5, wantChanged: true, want: intPtr(2)},
+		{burstPriorityClassName: "burst", current: intPtr(2), reserved: 2, replicas: 5, want: intPtr(2)},
+		{burstPriorityClassName: "burst", current: intPtr(2), reserved: 0, replicas: 5, wantChanged: true, want: intPtr(0)},
+		{burstPriorityClassName: "burst", reserved: 8, replicas: 5, wantChanged: true, want: intPtr(5)},
+	}
+
+	for i, tc := range testcases {
+		rd := &v1alpha1.RunnerDeployment{
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				BurstPriorityClassName: tc.burstPriorityClassName,
+				BurstReplicas:          tc.current,
+			},
+		}
+
+		changed := setBurstReplicas(rd, tc.reserved, tc.replicas)
+
+		if changed != tc.wantChanged {
+			t.Errorf("[%d] unexpected changed: want %v, got %v", i, tc.wantChanged, changed)
+		}
+
+		if getIntOrDefault(rd.Spec.BurstReplicas, -1) != getIntOrDefault(tc.want, -1) {
+			t.Errorf("[%d] unexpected burst replicas: want %v, got %v", i, tc.want, rd.Spec.BurstReplicas)
+		}
+	}
+}
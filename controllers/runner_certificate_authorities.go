@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// EnvVarRunnerCACertificatesDir is the directory of the CA certificates the runner entrypoint adds to the system trust store
+	// before registering the runner, so that the runner, the jobs and the actions trust them.
+	EnvVarRunnerCACertificatesDir = "RUNNER_CA_CERTIFICATES_DIR"
+
+	// EnvVarSSLCertDir is the colon-separated directories of the CA certificates read by OpenSSL and Go programs, including dockerd.
+	EnvVarSSLCertDir = "SSL_CERT_DIR"
+
+	runnerCACertificatesVolumeName = "runner-ca-certificates"
+	runnerCACertificatesMountPath  = "/etc/runner-ca-certificates"
+
+	// systemCACertificatesDir is the directory of the public CA certificates in both the runner and the docker images,
+	// which are still trusted along with the additional ones when SSL_CERT_DIR is set.
+	systemCACertificatesDir = "/etc/ssl/certs"
+)
+
+// applyCertificateAuthorities mounts the CA certificates of the runner spec into the runner and the docker containers,
+// and makes the runner entrypoint and the docker daemon trust them.
+//
+// The runner entrypoint adds them to the system trust store with update-ca-certificates, which covers git, curl, the runner and the actions.
+// The docker daemon, either in the docker sidecar or in the runner container, reads them via SSL_CERT_DIR, as it starts before the entrypoint,
+// so that images can be pulled via the TLS-intercepting proxies and from the registries with internal certificates.
+func applyCertificateAuthorities(pod *corev1.Pod, runnerSpec v1alpha1.RunnerConfig) {
+	if len(runnerSpec.CertificateAuthorities) == 0 {
+		return
+	}
+
+	var sources []corev1.VolumeProjection
+
+	for i, ca := range runnerSpec.CertificateAuthorities {
+		// update-ca-certificates only picks up the files with the .crt extension
+		path := fmt.Sprintf("ca-%d.crt", i)
+
+		if ref := ca.ConfigMapKeyRef; ref != nil {
+			sources = append(sources, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: ref.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: ref.Key, Path: path}},
+					Optional:             ref.Optional,
+				},
+			})
+		} else if ref := ca.SecretKeyRef; ref != nil {
+			sources = append(sources, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: ref.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: ref.Key, Path: path}},
+					Optional:             ref.Optional,
+				},
+			})
+		}
+	}
+
+	if len(sources) == 0 {
+		return
+	}
+
+	mode := int32(0644)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: runnerCACertificatesVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &mode,
+			},
+		},
+	})
+
+	sslCertDir := systemCACertificatesDir + ":" + runnerCACertificatesMountPath
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+
+		switch c.Name {
+		case containerName:
+			setContainerEnv(c, EnvVarRunnerCACertificatesDir, runnerCACertificatesMountPath)
+		case "docker":
+		default:
+			continue
+		}
+
+		setContainerEnv(c, EnvVarSSLCertDir, sslCertDir)
+
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      runnerCACertificatesVolumeName,
+			MountPath: runnerCACertificatesMountPath,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// setContainerEnv sets the environment variable of the container, unless it's already set by the user.
+func setContainerEnv(c *corev1.Container, key, value string) {
+	for _, env := range c.Env {
+		if env.Name == key {
+			return
+		}
+	}
+
+	c.Env = append(c.Env, corev1.EnvVar{Name: key, Value: value})
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyCertificateAuthorities(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName}, {Name: "docker"}, {Name: "sidecar"}},
+		},
+	}
+
+	applyCertificateAuthorities(&pod, v1alpha1.RunnerConfig{
+		CertificateAuthorities: []v1alpha1.CertificateAuthority{
+			{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "proxy-ca"},
+					Key:                  "ca.pem",
+				},
+			},
+			{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "internal-ca"},
+					Key:                  "tls.crt",
+				},
+			},
+		},
+	})
+
+	if len(pod.Spec.Volumes) != 1 {
+		t.Fatalf("unexpected volumes: %+v", pod.Spec.Volumes)
+	}
+
+	sources := pod.Spec.Volumes[0].Projected.Sources
+	if len(sources) != 2 {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+
+	if c := sources[0].ConfigMap; c == nil || c.Name != "proxy-ca" || c.Items[0].Key != "ca.pem" || c.Items[0].Path != "ca-0.crt" {
+		t.Errorf("unexpected configmap source: %+v", sources[0])
+	}
+
+	if s := sources[1].Secret; s == nil || s.Name != "internal-ca" || s.Items[0].Key != "tls.crt" || s.Items[0].Path != "ca-1.crt" {
+		t.Errorf("unexpected secret source: %+v", sources[1])
+	}
+
+	if got := getRunnerEnv(&pod, EnvVarRunnerCACertificatesDir); got != runnerCACertificatesMountPath {
+		t.Errorf("unexpected %s: %q", EnvVarRunnerCACertificatesDir, got)
+	}
+
+	for _, c := range pod.Spec.Containers[:2] {
+		if m := c.VolumeMounts; len(m) != 1 || m[0].MountPath != runnerCACertificatesMountPath {
+			t.Errorf("unexpected %s volume mounts: %+v", c.Name, m)
+		}
+
+		var sslCertDir string
+		for _, env := range c.Env {
+			if env.Name == EnvVarSSLCertDir {
+				sslCertDir = env.Value
+			}
+		}
+
+		if want := "/etc/ssl/certs:" + runnerCACertificatesMountPath; sslCertDir != want {
+			t.Errorf("unexpected %s of %s: want %q, got %q", EnvVarSSLCertDir, c.Name, want, sslCertDir)
+		}
+	}
+
+	if c := pod.Spec.Containers[2]; len(c.VolumeMounts) != 0 || len(c.Env) != 0 {
+		t.Errorf("unexpected changes to the sidecar: %+v", c)
+	}
+}
+
+func TestApplyCertificateAuthorities_None(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName}, {Name: "docker"}},
+		},
+	}
+
+	applyCertificateAuthorities(&pod, v1alpha1.RunnerConfig{})
+
+	if len(pod.Spec.Volumes) != 0 || len(pod.Spec.Containers[0].Env) != 0 || len(pod.Spec.Containers[1].Env) != 0 {
+		t.Errorf("unexpected changes to the pod: %+v", pod)
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,6 +38,9 @@ import (
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/podmutator"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
 )
 
 const (
@@ -74,6 +78,41 @@ type RunnerReconciler struct {
 	RegistrationRecheckJitter   time.Duration
 
 	UnregistrationRetryDelay time.Duration
+
+	// CentralImagePullSecret is the image pull secret copied into the namespace of each runner and attached to all the runner pods.
+	// The image pull secret isn't copied nor attached when nil.
+	CentralImagePullSecret *types.NamespacedName
+
+	// Provisioners are the runner provisioners that can be referenced from the provisioner field of runners.
+	Provisioners            map[string]provisioner.Provisioner
+	ProvisionerPollInterval time.Duration
+
+	// LabelMappings maps runner labels to the scheduling constraints applied to runner pods.
+	LabelMappings *labelmapping.Config
+
+	// RunnerPodDefaults is the node selector and the tolerations merged into every runner pod.
+	RunnerPodDefaults RunnerPodDefaults
+
+	// PodMutators are applied to every runner pod in order, after it's generated from the runner spec.
+	PodMutators *podmutator.Chain
+
+	// GitHubClients holds the GitHub clients for the runners that set githubAPICredentialsFrom.
+	GitHubClients *MultiGitHubClient
+
+	// DrainMode stops creating runner pods and provisioning runner instances,
+	// while runners are still unregistered and deleted, and their statuses are updated.
+	DrainMode bool
+
+	// ErrorBudget stops retrying the runners whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
+
+	// RunnerReadyRequiresOnline marks a runner ready only once GitHub reports the runner online,
+	// rather than as soon as the runner pod becomes Ready.
+	RunnerReadyRequiresOnline bool
+
+	// RunnerOnlineDeadline is the duration within which the runner has to come online since its pod became Ready,
+	// before the pod is recreated. Only used when RunnerReadyRequiresOnline is true.
+	RunnerOnlineDeadline time.Duration
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners,verbs=get;list;watch;create;update;patch;delete
@@ -81,6 +120,7 @@ type RunnerReconciler struct {
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -105,6 +145,8 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 			return ctrl.Result{}, nil
 		}
+	} else if runner.Spec.Provisioner != "" {
+		return r.processProvisionedRunnerDeletion(ctx, runner, log)
 	} else {
 		// Request to remove a runner. DeletionTimestamp was set in the runner - we need to unregister runner
 		var pod corev1.Pod
@@ -116,9 +158,20 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			// Pod was not found
 			return r.processRunnerDeletion(runner, ctx, log, nil)
 		}
+
+		// The runner of the completed runner pod may be deleted before the pod is reconciled below.
+		// The reservation is left to expire on failure so that the deletion isn't blocked.
+		if err := r.releaseCapacityReservation(ctx, runner, &pod, log); err != nil {
+			log.Error(err, "Failed to release the capacity reservation of the runner")
+		}
+
 		return r.processRunnerDeletion(runner, ctx, log, &pod)
 	}
 
+	if runner.Spec.Provisioner != "" {
+		return r.reconcileProvisionedRunner(ctx, runner, log)
+	}
+
 	var pod corev1.Pod
 	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
 		if !kerrors.IsNotFound(err) {
@@ -128,6 +181,27 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return r.processRunnerCreation(ctx, runner, log)
 	}
 
+	if _, retained := pod.Labels[LabelKeyRetainedRunnerPool]; retained {
+		// The pod was retained from a previous runner of the same name. Delete it to create the pod for this runner.
+		if err := r.Delete(ctx, &pod); err != nil && !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		log.Info("Deleted retained pod of the previous runner of the same name")
+
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.ensureJobDeadline(ctx, runner, &pod, log); err != nil {
+		log.Error(err, "Failed to set the job deadline of the runner pod")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.releaseCapacityReservation(ctx, runner, &pod, log); err != nil {
+		log.Error(err, "Failed to release the capacity reservation of the runner")
+		return ctrl.Result{}, err
+	}
+
 	phase := string(pod.Status.Phase)
 	if phase == "" {
 		phase = "Created"
@@ -135,7 +209,22 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	ready := runnerPodReady(&pod)
 
-	if runner.Status.Phase != phase || runner.Status.Ready != ready {
+	var requeueAfter time.Duration
+
+	if ready && r.RunnerReadyRequiresOnline {
+		online, after, err := r.ensureRunnerOnline(ctx, runner, &pod, log)
+		if err != nil {
+			log.Error(err, "Failed to check if the runner is online")
+			return ctrl.Result{}, err
+		}
+
+		ready = online
+		requeueAfter = after
+	}
+
+	reservation, _ := getAnnotation(&runner, AnnotationKeyCapacityReservation)
+
+	if runner.Status.Phase != phase || runner.Status.Ready != ready || runner.Status.CapacityReservation != reservation {
 		if pod.Status.Phase == corev1.PodRunning {
 			// Seeing this message, you can expect the runner to become `Running` soon.
 			log.V(1).Info(
@@ -149,6 +238,7 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		updated.Status.Ready = ready
 		updated.Status.Reason = pod.Status.Reason
 		updated.Status.Message = pod.Status.Message
+		updated.Status.CapacityReservation = reservation
 
 		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
 			log.Error(err, "Failed to update runner status for Phase/Reason/Message")
@@ -156,7 +246,7 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func runnerPodReady(pod *corev1.Pod) bool {
@@ -211,6 +301,12 @@ func (r *RunnerReconciler) processRunnerDeletion(runner v1alpha1.Runner, ctx con
 	finalizers, removed := removeFinalizer(runner.ObjectMeta.Finalizers, finalizerName)
 
 	if removed {
+		// The pod needs to be detached before the finalizer is removed. Otherwise it's garbage-collected along with the runner.
+		if _, err := retainRunnerPod(ctx, r.Client, log, runner, pod, time.Now()); err != nil {
+			log.Error(err, "Unable to retain runner pod")
+			return ctrl.Result{}, err
+		}
+
 		newRunner := runner.DeepCopy()
 		newRunner.ObjectMeta.Finalizers = finalizers
 
@@ -226,18 +322,58 @@ func (r *RunnerReconciler) processRunnerDeletion(runner v1alpha1.Runner, ctx con
 }
 
 func (r *RunnerReconciler) processRunnerCreation(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) (reconcile.Result, error) {
-	if updated, err := r.updateRegistrationToken(ctx, runner); err != nil {
-		return ctrl.Result{RequeueAfter: RetryDelayOnCreateRegistrationError}, nil
-	} else if updated {
-		return ctrl.Result{Requeue: true}, nil
+	if r.DrainMode {
+		log.V(1).Info("Skipped creating runner pod in drain mode")
+
+		return ctrl.Result{}, nil
 	}
 
-	newPod, err := r.newPod(runner)
+	// A runner with the user-provided registration secret registers itself with it,
+	// so we don't need a registration token.
+	if runner.Spec.RegistrationSecretRef == nil {
+		if updated, err := r.updateRegistrationToken(ctx, runner); err != nil {
+			return ctrl.Result{RequeueAfter: RetryDelayOnCreateRegistrationError}, nil
+		} else if updated {
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
+	if src := r.CentralImagePullSecret; src != nil {
+		if err := replicateImagePullSecret(ctx, r.Client, *src, runner.Namespace); err != nil {
+			log.Error(err, "Could not copy the central image pull secret")
+			r.Recorder.Event(&runner, corev1.EventTypeWarning, "ImagePullSecretCopyFailed", err.Error())
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	newPod, err := r.newPod(ctx, runner)
 	if err != nil {
 		log.Error(err, "Could not create pod")
 		return ctrl.Result{}, err
 	}
 
+	vars, err := r.runnerVariables(ctx, runner)
+	if err != nil {
+		log.Error(err, "Could not read the substitution variables")
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, "VariablesUnavailable", err.Error())
+
+		return ctrl.Result{}, err
+	}
+
+	if err := substituteRunnerVariables(&newPod, vars); err != nil {
+		log.Error(err, "Could not substitute the variables")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ensureRunnerServices(ctx, runner, log); err != nil {
+		log.Error(err, "Could not create the runner services")
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, "ServiceCreationFailed", err.Error())
+
+		return ctrl.Result{}, err
+	}
+
 	if err := r.Create(ctx, &newPod); err != nil {
 		if kerrors.IsAlreadyExists(err) {
 			// Gracefully handle pod-already-exists errors due to informer cache delay.
@@ -267,12 +403,25 @@ func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, runner v
 
 	log := r.Log.WithValues("runner", runner.Name)
 
-	rt, err := r.GitHubClient.GetRegistrationToken(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, "GitHubAPICredentialsUnavailable", err.Error())
+		log.Error(err, "Failed to get the GitHub client for the githubAPICredentialsFrom")
+		return false, err
+	}
+
+	rt, err := ghc.GetRegistrationToken(ctx, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
 	if err != nil {
 		// An error can be a permanent, permission issue like the below:
 		//    POST https://api.github.com/enterprises/YOUR_ENTERPRISE/actions/runners/registration-token: 403 Resource not accessible by integration []
 		// In such case retrying in seconds might not make much sense.
 
+		if reason, ok := repositoryInaccessibleReason(err); ok && runner.Spec.Repository != "" {
+			r.Recorder.Event(&runner, corev1.EventTypeWarning, "RepositoryInaccessible", fmt.Sprintf("Repository %s is inaccessible (%s). The runner can't be registered until it becomes accessible", runner.Spec.Repository, reason))
+			log.Info("Unable to get new registration token as the repository is inaccessible", "repository", runner.Spec.Repository, "reason", reason)
+			return false, err
+		}
+
 		r.Recorder.Event(&runner, corev1.EventTypeWarning, "FailedUpdateRegistrationToken", "Updating registration token failed")
 		log.Error(err, "Failed to get new registration token")
 		return false, err
@@ -298,7 +447,7 @@ func (r *RunnerReconciler) updateRegistrationToken(ctx context.Context, runner v
 	return true, nil
 }
 
-func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
+func (r *RunnerReconciler) newPod(ctx context.Context, runner v1alpha1.Runner) (corev1.Pod, error) {
 	var template corev1.Pod
 
 	labels := map[string]string{}
@@ -347,6 +496,16 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 
 	template.ObjectMeta = objectMeta
 
+	// The node selector tells the operating system of the runner to newRunnerPod.
+	template.Spec.NodeSelector = runner.Spec.NodeSelector
+
+	runnerImage := runner.Spec.Image
+	if runnerImage == "" {
+		runnerImage = r.RunnerImage
+	}
+
+	runnerOS, _ := runnerPlatform(runner.Spec.RunnerConfig, runner.Spec.NodeSelector, runnerImage)
+
 	if len(runner.Spec.Containers) == 0 {
 		template.Spec.Containers = append(template.Spec.Containers, corev1.Container{
 			Name:            "runner",
@@ -356,7 +515,7 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 			Resources:       runner.Spec.Resources,
 		})
 
-		if (runner.Spec.DockerEnabled == nil || *runner.Spec.DockerEnabled) && (runner.Spec.DockerdWithinRunnerContainer == nil || !*runner.Spec.DockerdWithinRunnerContainer) {
+		if runnerOS != v1alpha1.RunnerOSWindows && (runner.Spec.DockerEnabled == nil || *runner.Spec.DockerEnabled) && (runner.Spec.DockerdWithinRunnerContainer == nil || !*runner.Spec.DockerdWithinRunnerContainer) {
 			template.Spec.Containers = append(template.Spec.Containers, corev1.Container{
 				Name:         "docker",
 				VolumeMounts: runner.Spec.DockerVolumeMounts,
@@ -414,6 +573,7 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 	if runnerSpec.NodeSelector != nil {
 		pod.Spec.NodeSelector = runnerSpec.NodeSelector
 	}
+	applyRunnerPlatformNodeSelector(&pod, runnerSpec.RunnerConfig)
 	if runnerSpec.ServiceAccountName != "" {
 		pod.Spec.ServiceAccountName = runnerSpec.ServiceAccountName
 	}
@@ -437,6 +597,10 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		pod.Spec.Tolerations = runnerSpec.Tolerations
 	}
 
+	// Applied after the node selector and the tolerations of the runner spec, so that the mapped ones are merged into them
+	r.LabelMappings.Apply(runner.Spec.Labels, &pod)
+	r.RunnerPodDefaults.apply(&pod)
+
 	if len(runnerSpec.TopologySpreadConstraints) != 0 {
 		pod.Spec.TopologySpreadConstraints = runnerSpec.TopologySpreadConstraints
 	}
@@ -457,12 +621,44 @@ func (r *RunnerReconciler) newPod(runner v1alpha1.Runner) (corev1.Pod, error) {
 		pod.Spec.DNSConfig = runnerSpec.DnsConfig
 	}
 
+	if runnerSpec.DnsPolicy != "" {
+		pod.Spec.DNSPolicy = runnerSpec.DnsPolicy
+	}
+
+	applyRunnerNetworkExposure(&pod, runner.Name, runnerSpec.RunnerPodSpec)
+
 	if runnerSpec.RuntimeClassName != nil {
 		pod.Spec.RuntimeClassName = runnerSpec.RuntimeClassName
 	}
 
+	if runnerSpec.PriorityClassName != "" {
+		pod.Spec.PriorityClassName = runnerSpec.PriorityClassName
+	}
+
+	if src := r.CentralImagePullSecret; src != nil {
+		attachImagePullSecret(&pod.Spec, src.Name)
+	}
+
+	if err := applyRunnerExtendedResources(&pod, runnerSpec.RunnerConfig, r.LabelMappings); err != nil {
+		return pod, err
+	}
+
+	applyRunnerQueueing(&pod, runnerSpec.Queueing)
+
+	if err := applyRunnerPodTemplate(&pod, runnerSpec.PodTemplate); err != nil {
+		return pod, err
+	}
+
+	if _, ok := getAnnotation(&runner, AnnotationKeyRunnerSuspended); ok {
+		addRunnerSuspensionVolume(&pod)
+	}
+
 	pod.ObjectMeta.Name = runner.ObjectMeta.Name
 
+	if err := r.PodMutators.Apply(ctx, &pod, podmutator.Target{Kind: "Runner", Namespace: runner.Namespace, Name: runner.Name, RunnerConfig: runner.Spec.RunnerConfig}); err != nil {
+		return pod, err
+	}
+
 	// Inject the registration token and the runner name
 	updated := mutatePod(&pod, runner.Status.Registration.Token)
 
@@ -480,7 +676,7 @@ func mutatePod(pod *corev1.Pod, token string) *corev1.Pod {
 		setRunnerEnv(updated, EnvVarRunnerName, pod.ObjectMeta.Name)
 	}
 
-	if getRunnerEnv(pod, EnvVarRunnerToken) == "" {
+	if getRunnerEnv(pod, EnvVarRunnerToken) == "" && !usesRegistrationSecret(pod) {
 		setRunnerEnv(updated, EnvVarRunnerToken, token)
 	}
 
@@ -492,10 +688,37 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 		privileged                bool = true
 		dockerdInRunner           bool = runnerSpec.DockerdWithinRunnerContainer != nil && *runnerSpec.DockerdWithinRunnerContainer
 		dockerEnabled             bool = runnerSpec.DockerEnabled == nil || *runnerSpec.DockerEnabled
+		dockerRootless            bool = runnerSpec.DockerRootless != nil && *runnerSpec.DockerRootless
 		ephemeral                 bool = runnerSpec.Ephemeral == nil || *runnerSpec.Ephemeral
 		dockerdInRunnerPrivileged bool = dockerdInRunner
 	)
 
+	if dockerRootless && dockerdInRunner {
+		return template, fmt.Errorf("dockerRootless can't be used along with dockerdWithinRunnerContainer")
+	}
+
+	runnerImage := runnerSpec.Image
+	for _, c := range template.Spec.Containers {
+		if runnerImage == "" && c.Name == containerName {
+			runnerImage = c.Image
+		}
+	}
+	if runnerImage == "" {
+		runnerImage = defaultRunnerImage
+	}
+
+	runnerOS, _ := runnerPlatform(runnerSpec, template.Spec.NodeSelector, runnerImage)
+	windows := runnerOS == v1alpha1.RunnerOSWindows
+
+	if windows {
+		if dockerdInRunner || dockerRootless {
+			return template, fmt.Errorf("dockerdWithinRunnerContainer and dockerRootless can't be used for windows runners")
+		}
+
+		// Windows containers can't run dockerd in a sidecar nor privileged.
+		dockerEnabled = false
+	}
+
 	template = *template.DeepCopy()
 
 	// This label selector is used by default when rd.Spec.Selector is empty.
@@ -503,6 +726,9 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 	template.ObjectMeta.Labels = CloneAndAddLabel(template.ObjectMeta.Labels, LabelKeyPodMutation, LabelValuePodMutation)
 
 	workDir := runnerSpec.WorkDir
+	if workDir == "" && windows {
+		workDir = defaultWindowsRunnerWorkDir
+	}
 	if workDir == "" {
 		workDir = "/runner/_work"
 	}
@@ -567,6 +793,10 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 		)
 	}
 
+	if ref := runnerSpec.RegistrationSecretRef; ref != nil {
+		env = append(env, registrationSecretEnvVar(runnerName, *ref))
+	}
+
 	var seLinuxOptions *corev1.SELinuxOptions
 	if template.Spec.SecurityContext != nil {
 		seLinuxOptions = template.Spec.SecurityContext.SELinuxOptions
@@ -626,6 +856,9 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 	}
 	// Runner need to run privileged if it contains DinD
 	runnerContainer.SecurityContext.Privileged = &dockerdInRunnerPrivileged
+	if windows {
+		runnerContainer.SecurityContext.Privileged = nil
+	}
 
 	pod := template.DeepCopy()
 
@@ -673,6 +906,9 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 
 	runnerVolumeName := "runner"
 	runnerVolumeMountPath := "/runner"
+	if windows {
+		runnerVolumeMountPath = windowsRunnerVolumeMountPath
+	}
 	runnerVolumeEmptyDir := &corev1.EmptyDirVolumeSource{}
 
 	if runnerSpec.VolumeStorageMedium != nil {
@@ -736,20 +972,23 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 			},
 		)
 
-		runnerContainer.Env = append(runnerContainer.Env, []corev1.EnvVar{
-			{
-				Name:  "DOCKER_HOST",
-				Value: "tcp://localhost:2376",
-			},
-			{
-				Name:  "DOCKER_TLS_VERIFY",
-				Value: "1",
-			},
-			{
-				Name:  "DOCKER_CERT_PATH",
-				Value: "/certs/client",
-			},
-		}...)
+		// Rootless dockerd is used over the unix socket instead. See applyDockerRootless.
+		if !dockerRootless {
+			runnerContainer.Env = append(runnerContainer.Env, []corev1.EnvVar{
+				{
+					Name:  "DOCKER_HOST",
+					Value: "tcp://localhost:2376",
+				},
+				{
+					Name:  "DOCKER_TLS_VERIFY",
+					Value: "1",
+				},
+				{
+					Name:  "DOCKER_CERT_PATH",
+					Value: "/certs/client",
+				},
+			}...)
+		}
 
 		// Determine the volume mounts assigned to the docker sidecar. In case extra mounts are included in the RunnerSpec, append them to the standard
 		// set of mounts. See https://github.com/actions-runner-controller/actions-runner-controller/issues/435 for context.
@@ -772,6 +1011,10 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 			})
 		}
 
+		if dockerRootless {
+			applyDockerRootless(pod, runnerContainer, dockerdContainer, seLinuxOptions)
+		}
+
 		if dockerdContainer.Image == "" {
 			dockerdContainer.Image = defaultDockerImage
 		}
@@ -836,6 +1079,19 @@ func newRunnerPod(runnerName string, template corev1.Pod, runnerSpec v1alpha1.Ru
 		setRunnerEnv(pod, EnvVarRunnerFeatureFlagEphemeral, EnvVarTrue)
 	}
 
+	if from := runnerSpec.GitHubAPICredentialsFrom; from != nil {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[AnnotationKeyGitHubAPICredentialsFrom] = from.SecretRef.Name
+	}
+
+	applyDockerRestartPolicy(pod, runnerSpec.DockerRestartPolicy, dockerEnabled || dockerdInRunner)
+
+	applyRunnerScripts(pod, runnerSpec)
+
+	applyCertificateAuthorities(pod, runnerSpec)
+
 	return *pod, nil
 }
 
@@ -851,7 +1107,7 @@ func (r *RunnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.Runner{}).
 		Owns(&corev1.Pod{}).
 		Named(name).
-		Complete(r)
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.Runner{} }))
 }
 
 func addFinalizer(finalizers []string, finalizerName string) ([]string, bool) {
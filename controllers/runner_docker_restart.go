@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DockerRestartPolicyRestart  = "Restart"
+	DockerRestartPolicyFailFast = "FailFast"
+
+	// EnvVarDockerRestartPolicy tells the runner entrypoint how to react to the loss of dockerd.
+	// With FailFast, the entrypoint stops the runner once dockerd stops responding.
+	EnvVarDockerRestartPolicy = "DOCKER_RESTART_POLICY"
+)
+
+// applyDockerRestartPolicy configures the runner pod so that a crash of dockerd either restarts dockerd alone,
+// or stops the whole runner pod.
+//
+// With Restart, the pod restarts the crashed container in place, so that the docker sidecar comes back
+// while the runner keeps running the in-flight job.
+// With FailFast, no container is restarted in place, so that the pod stops once the runner is stopped
+// by the entrypoint on the loss of dockerd, and is recreated by the controller.
+func applyDockerRestartPolicy(pod *corev1.Pod, policy string, dockerEnabled bool) {
+	if policy == "" || !dockerEnabled {
+		return
+	}
+
+	switch policy {
+	case DockerRestartPolicyRestart:
+		if pod.Spec.RestartPolicy == corev1.RestartPolicyNever {
+			pod.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+		}
+	case DockerRestartPolicyFailFast:
+		pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+
+	setRunnerEnv(pod, EnvVarDockerRestartPolicy, policy)
+}
+
+// runnerPodRestartPolicy returns the restart policy of the runner pod created by the StatefulSet of a RunnerSet,
+// whose pod template can only have the restart policy of Always.
+func runnerPodRestartPolicy(pod *corev1.Pod) corev1.RestartPolicy {
+	if getRunnerEnv(pod, EnvVarDockerRestartPolicy) == DockerRestartPolicyFailFast {
+		return corev1.RestartPolicyNever
+	}
+
+	return corev1.RestartPolicyOnFailure
+}
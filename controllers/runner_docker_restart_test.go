@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyDockerRestartPolicy(t *testing.T) {
+	testcases := []struct {
+		description       string
+		policy            string
+		dockerEnabled     bool
+		restartPolicy     corev1.RestartPolicy
+		wantRestartPolicy corev1.RestartPolicy
+		wantEnv           string
+	}{
+		{
+			description:       "no policy",
+			dockerEnabled:     true,
+			restartPolicy:     corev1.RestartPolicyOnFailure,
+			wantRestartPolicy: corev1.RestartPolicyOnFailure,
+		},
+		{
+			description:       "restart",
+			policy:            DockerRestartPolicyRestart,
+			dockerEnabled:     true,
+			restartPolicy:     corev1.RestartPolicyOnFailure,
+			wantRestartPolicy: corev1.RestartPolicyOnFailure,
+			wantEnv:           DockerRestartPolicyRestart,
+		},
+		{
+			description:       "restart overriding never",
+			policy:            DockerRestartPolicyRestart,
+			dockerEnabled:     true,
+			restartPolicy:     corev1.RestartPolicyNever,
+			wantRestartPolicy: corev1.RestartPolicyOnFailure,
+			wantEnv:           DockerRestartPolicyRestart,
+		},
+		{
+			description:       "fail fast",
+			policy:            DockerRestartPolicyFailFast,
+			dockerEnabled:     true,
+			restartPolicy:     corev1.RestartPolicyOnFailure,
+			wantRestartPolicy: corev1.RestartPolicyNever,
+			wantEnv:           DockerRestartPolicyFailFast,
+		},
+		{
+			description:       "docker disabled",
+			policy:            DockerRestartPolicyFailFast,
+			restartPolicy:     corev1.RestartPolicyOnFailure,
+			wantRestartPolicy: corev1.RestartPolicyOnFailure,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			pod := corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy: tc.restartPolicy,
+					Containers:    []corev1.Container{{Name: containerName}},
+				},
+			}
+
+			applyDockerRestartPolicy(&pod, tc.policy, tc.dockerEnabled)
+
+			if pod.Spec.RestartPolicy != tc.wantRestartPolicy {
+				t.Errorf("unexpected restart policy: want %q, got %q", tc.wantRestartPolicy, pod.Spec.RestartPolicy)
+			}
+
+			if got := getRunnerEnv(&pod, EnvVarDockerRestartPolicy); got != tc.wantEnv {
+				t.Errorf("unexpected %s: want %q, got %q", EnvVarDockerRestartPolicy, tc.wantEnv, got)
+			}
+
+			// The restart policy of the RunnerSet pod, which is reset to Always by the StatefulSet, is restored on admission.
+			pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+
+			wantRestartPolicy := tc.wantRestartPolicy
+			if tc.policy == "" || !tc.dockerEnabled {
+				wantRestartPolicy = corev1.RestartPolicyOnFailure
+			}
+
+			if got := runnerPodRestartPolicy(&pod); got != wantRestartPolicy {
+				t.Errorf("unexpected restart policy on admission: want %q, got %q", wantRestartPolicy, got)
+			}
+		})
+	}
+}
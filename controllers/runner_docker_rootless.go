@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultDockerRootlessImage is the image of the docker sidecar with dockerRootless=true,
+	// unless the docker container of the runner pod template has its own image.
+	DefaultDockerRootlessImage = "docker:dind-rootless"
+
+	// dockerRootlessUID is the user rootless dockerd runs as, which is the rootless user of the docker:dind-rootless image
+	// and the runner user of the runner images.
+	dockerRootlessUID int64 = 1000
+
+	dockerRootlessSocketVolumeName = "docker-sock"
+
+	// dockerRootlessSocketDir is XDG_RUNTIME_DIR of the rootless user, where rootless dockerd creates its socket.
+	dockerRootlessSocketDir = "/run/user/1000"
+
+	annotationKeyAppArmorDockerContainer = "container.apparmor.security.beta.kubernetes.io/docker"
+)
+
+// applyDockerRootless configures the docker sidecar to run rootless dockerd as a non-root, unprivileged container,
+// and the runner container to use it over the unix socket shared via an emptyDir volume.
+//
+// Rootless dockerd creates user and network namespaces on its own, which requires the seccomp and AppArmor profiles
+// to be unconfined, and the nodes to allow unprivileged user namespaces.
+// The security context of the docker container in the runner pod template, if any, is kept as-is.
+func applyDockerRootless(pod *corev1.Pod, runnerContainer, dockerdContainer *corev1.Container, seLinuxOptions *corev1.SELinuxOptions) {
+	if dockerdContainer.Image == "" {
+		dockerdContainer.Image = DefaultDockerRootlessImage
+	}
+
+	if dockerdContainer.SecurityContext == nil {
+		var (
+			privileged   = false
+			runAsNonRoot = true
+			uid          = dockerRootlessUID
+		)
+
+		dockerdContainer.SecurityContext = &corev1.SecurityContext{
+			Privileged:     &privileged,
+			RunAsNonRoot:   &runAsNonRoot,
+			RunAsUser:      &uid,
+			RunAsGroup:     &uid,
+			SELinuxOptions: seLinuxOptions,
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeUnconfined,
+			},
+		}
+
+		if _, ok := pod.Annotations[annotationKeyAppArmorDockerContainer]; !ok {
+			if pod.Annotations == nil {
+				pod.Annotations = map[string]string{}
+			}
+			pod.Annotations[annotationKeyAppArmorDockerContainer] = "unconfined"
+		}
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: dockerRootlessSocketVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	socketMount := corev1.VolumeMount{
+		Name:      dockerRootlessSocketVolumeName,
+		MountPath: dockerRootlessSocketDir,
+	}
+
+	dockerdContainer.VolumeMounts = append(dockerdContainer.VolumeMounts, socketMount)
+	runnerContainer.VolumeMounts = append(runnerContainer.VolumeMounts, socketMount)
+
+	runnerContainer.Env = append(runnerContainer.Env, corev1.EnvVar{
+		Name:  "DOCKER_HOST",
+		Value: fmt.Sprintf("unix://%s/docker.sock", dockerRootlessSocketDir),
+	})
+}
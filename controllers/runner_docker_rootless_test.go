@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewRunnerPod_DockerRootless(t *testing.T) {
+	boolPtr := func(v bool) *bool {
+		return &v
+	}
+
+	uid := int64(2000)
+
+	testcases := []struct {
+		description string
+		template    corev1.Pod
+		config      v1alpha1.RunnerConfig
+
+		wantErr        bool
+		wantImage      string
+		wantUser       int64
+		wantPrivileged bool
+		wantAppArmor   string
+		wantDockerHost string
+	}{
+		{
+			description:    "privileged docker sidecar by default",
+			config:         v1alpha1.RunnerConfig{Repository: "myorg/myrepo"},
+			wantImage:      "docker-image",
+			wantPrivileged: true,
+			wantDockerHost: "tcp://localhost:2376",
+		},
+		{
+			description:    "rootless docker sidecar",
+			config:         v1alpha1.RunnerConfig{Repository: "myorg/myrepo", DockerRootless: boolPtr(true)},
+			wantImage:      DefaultDockerRootlessImage,
+			wantUser:       1000,
+			wantAppArmor:   "unconfined",
+			wantDockerHost: "unix:///run/user/1000/docker.sock",
+		},
+		{
+			description: "rootless docker sidecar with its own image and security context",
+			template: corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "docker",
+							Image:           "my-dind-rootless",
+							SecurityContext: &corev1.SecurityContext{RunAsUser: &uid},
+						},
+					},
+				},
+			},
+			config:         v1alpha1.RunnerConfig{Repository: "myorg/myrepo", DockerRootless: boolPtr(true)},
+			wantImage:      "my-dind-rootless",
+			wantUser:       2000,
+			wantDockerHost: "unix:///run/user/1000/docker.sock",
+		},
+		{
+			description: "rootless dockerd within the runner container",
+			config:      v1alpha1.RunnerConfig{Repository: "myorg/myrepo", DockerRootless: boolPtr(true), DockerdWithinRunnerContainer: boolPtr(true)},
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			pod, err := newRunnerPod("runner", tc.template, tc.config, "runner-image", nil, "docker-image", "", "", false)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var docker *corev1.Container
+			for i := range pod.Spec.Containers {
+				if pod.Spec.Containers[i].Name == "docker" {
+					docker = &pod.Spec.Containers[i]
+				}
+			}
+
+			if docker == nil {
+				t.Fatalf("docker container is missing: %v", pod.Spec.Containers)
+			}
+
+			if docker.Image != tc.wantImage {
+				t.Errorf("unexpected image: want %s, got %s", tc.wantImage, docker.Image)
+			}
+
+			securityContext := docker.SecurityContext
+
+			if privileged := securityContext.Privileged != nil && *securityContext.Privileged; privileged != tc.wantPrivileged {
+				t.Errorf("unexpected privileged: want %v, got %v", tc.wantPrivileged, privileged)
+			}
+
+			var user int64
+			if securityContext.RunAsUser != nil {
+				user = *securityContext.RunAsUser
+			}
+			if user != tc.wantUser {
+				t.Errorf("unexpected user: want %d, got %d", tc.wantUser, user)
+			}
+
+			if got := pod.Annotations[annotationKeyAppArmorDockerContainer]; got != tc.wantAppArmor {
+				t.Errorf("unexpected apparmor profile: want %q, got %q", tc.wantAppArmor, got)
+			}
+
+			if got := getRunnerEnv(&pod, "DOCKER_HOST"); got != tc.wantDockerHost {
+				t.Errorf("unexpected DOCKER_HOST: want %s, got %s", tc.wantDockerHost, got)
+			}
+
+			if tc.config.DockerRootless == nil {
+				return
+			}
+
+			if getRunnerEnv(&pod, "DOCKER_TLS_VERIFY") != "" {
+				t.Errorf("unexpected DOCKER_TLS_VERIFY for rootless dockerd")
+			}
+
+			for _, c := range []corev1.Container{pod.Spec.Containers[0], *docker} {
+				var mounted bool
+				for _, m := range c.VolumeMounts {
+					if m.Name == dockerRootlessSocketVolumeName && m.MountPath == dockerRootlessSocketDir {
+						mounted = true
+					}
+				}
+				if !mounted {
+					t.Errorf("docker socket is not mounted to container %s: %v", c.Name, c.VolumeMounts)
+				}
+			}
+		})
+	}
+}
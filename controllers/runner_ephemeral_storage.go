@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultRunnerEphemeralStorageInterval         = 1 * time.Minute
+	DefaultRunnerEphemeralStorageRecycleThreshold = 80
+)
+
+// +kubebuilder:rbac:groups=core,resources=nodes/proxy,verbs=get
+
+// NodeStatsFunc returns the stats summary of the node served by the kubelet at /stats/summary.
+type NodeStatsFunc func(ctx context.Context, node string) ([]byte, error)
+
+// NewKubeletNodeStats returns the NodeStatsFunc that reads the stats summary of the node via the node proxy of the API server.
+func NewKubeletNodeStats(config *rest.Config) (NodeStatsFunc, error) {
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, node string) ([]byte, error) {
+		return cs.CoreV1().RESTClient().Get().Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").DoRaw(ctx)
+	}, nil
+}
+
+// RunnerEphemeralStorageMonitor periodically reads the ephemeral storage used by each runner pod from the kubelet,
+// and recycles the runners whose usage reached RecycleThreshold percent of the ephemeral storage limit of their pods,
+// before they fail a build by filling up the disk or get evicted by the kubelet.
+//
+// A runner is recycled only while it's registered and idle on GitHub, so that a job is never interrupted.
+// A busy runner over the threshold is reported via an event, and recycled on the first check after its job completes.
+// Runner pods without ephemeral storage limits are not monitored.
+type RunnerEphemeralStorageMonitor struct {
+	client.Client
+	Log           logr.Logger
+	Recorder      record.EventRecorder
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	// NodeStats reads the stats summary of the nodes the runner pods run on.
+	NodeStats NodeStatsFunc
+
+	Interval time.Duration
+
+	// RecycleThreshold is the percentage of the ephemeral storage limit of a runner pod at or above which
+	// the runner is recycled once it's idle. Zero disables recycling, while the usage is still exported as metrics.
+	RecycleThreshold int
+
+	Namespace string
+}
+
+type runnerStoragePool struct {
+	kind string
+	key  types.NamespacedName
+}
+
+type runnerStorageUsage struct {
+	pod   *corev1.Pod
+	pool  runnerStoragePool
+	used  int64
+	limit int64
+}
+
+func (u runnerStorageUsage) ratio() float64 {
+	return float64(u.used) / float64(u.limit)
+}
+
+// kubeletStatsSummary is the part of the stats summary of the kubelet that contains the ephemeral storage used by the pods.
+type kubeletStatsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		EphemeralStorage *struct {
+			UsedBytes *int64 `json:"usedBytes"`
+		} `json:"ephemeral-storage"`
+	} `json:"pods"`
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader recycles runners.
+func (m *RunnerEphemeralStorageMonitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (m *RunnerEphemeralStorageMonitor) Start(ctx context.Context) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultRunnerEphemeralStorageInterval
+	}
+
+	m.Log.Info("Starting runner ephemeral storage monitor", "interval", interval, "recycleThreshold", m.RecycleThreshold)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.checkAll(ctx, time.Now()); err != nil {
+			m.Log.Error(err, "Failed to check runner ephemeral storage usage")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *RunnerEphemeralStorageMonitor) checkAll(ctx context.Context, now time.Time) error {
+	var opts []client.ListOption
+	if m.Namespace != "" {
+		opts = append(opts, client.InNamespace(m.Namespace))
+	}
+
+	var pods corev1.PodList
+	if err := m.List(ctx, &pods, append(opts, client.HasLabels{LabelKeyRunnerSetName})...); err != nil {
+		return err
+	}
+
+	byNode := map[string][]*corev1.Pod{}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if podEphemeralStorageLimit(pod) == 0 {
+			continue
+		}
+
+		byNode[pod.Spec.NodeName] = append(byNode[pod.Spec.NodeName], pod)
+	}
+
+	var nodes []string
+	for node := range byNode {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	type poolReport struct {
+		maxRatio      float64
+		overThreshold int
+	}
+
+	reports := map[runnerStoragePool]*poolReport{}
+
+	var over []runnerStorageUsage
+
+	for _, node := range nodes {
+		summary, err := m.NodeStats(ctx, node)
+		if err != nil {
+			m.Log.Error(err, "Failed to read the stats summary of the node", "node", node)
+			continue
+		}
+
+		used, err := parsePodEphemeralStorageUsage(summary)
+		if err != nil {
+			m.Log.Error(err, "Failed to parse the stats summary of the node", "node", node)
+			continue
+		}
+
+		for _, pod := range byNode[node] {
+			bytes, ok := used[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}]
+			if !ok {
+				continue
+			}
+
+			u := runnerStorageUsage{
+				pod:   pod,
+				pool:  runnerStoragePoolOf(pod),
+				used:  bytes,
+				limit: podEphemeralStorageLimit(pod),
+			}
+
+			r, ok := reports[u.pool]
+			if !ok {
+				r = &poolReport{}
+				reports[u.pool] = r
+			}
+
+			if u.ratio() > r.maxRatio {
+				r.maxRatio = u.ratio()
+			}
+
+			if m.RecycleThreshold > 0 && u.ratio()*100 >= float64(m.RecycleThreshold) {
+				r.overThreshold++
+				over = append(over, u)
+			}
+		}
+	}
+
+	for p, r := range reports {
+		metrics.SetRunnerEphemeralStorageUsage(p.kind, p.key.Namespace, p.key.Name, r.maxRatio, r.overThreshold)
+	}
+
+	registered := map[runnerUtilizationScope]map[string]*gogithub.Runner{}
+
+	for _, u := range over {
+		if err := m.recycle(ctx, now, u, registered); err != nil {
+			m.Log.Error(err, "Failed to recycle runner", "runner", types.NamespacedName{Namespace: u.pod.Namespace, Name: u.pod.Name})
+		}
+	}
+
+	return nil
+}
+
+// recycle deletes the runner whose ephemeral storage usage is over the threshold, unless it's busy or protected.
+// Runners of RunnerDeployments are recycled by deleting the runner so that the runner controller unregisters it before deleting the pod,
+// and the runner replica set recreates it. Runners of RunnerSets are recycled by deleting the runner pod, whose finalizer unregisters the runner.
+func (m *RunnerEphemeralStorageMonitor) recycle(ctx context.Context, now time.Time, u runnerStorageUsage, registered map[runnerUtilizationScope]map[string]*gogithub.Runner) error {
+	pod := u.pod
+
+	log := m.Log.WithValues("runner", types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+
+	from := githubAPICredentialsFromPod(pod)
+
+	var scope runnerUtilizationScope
+
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			scope.enterprise, _ = getEnv(&c, EnvVarEnterprise)
+			scope.org, _ = getEnv(&c, EnvVarOrg)
+			scope.repo, _ = getEnv(&c, EnvVarRepo)
+		}
+	}
+
+	scope.credentials = githubAPICredentialsKey(pod.Namespace, from)
+
+	byName, ok := registered[scope]
+	if !ok {
+		ghc, err := m.GitHubClients.ClientFor(ctx, m.GitHubClient, pod.Namespace, from)
+		if err != nil {
+			return err
+		}
+
+		ghRunners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+		if err != nil {
+			return err
+		}
+
+		byName = map[string]*gogithub.Runner{}
+		for _, r := range ghRunners {
+			byName[r.GetName()] = r
+		}
+
+		registered[scope] = byName
+	}
+
+	usage := fmt.Sprintf("%s of the ephemeral storage limit of %s (%.0f%%)", formatBytes(u.used), formatBytes(u.limit), u.ratio()*100)
+
+	// A runner that isn't registered yet or anymore might be about to run a job, or be completing one.
+	r, ok := byName[pod.Name]
+	if !ok || r.GetBusy() {
+		m.Recorder.Event(pod, corev1.EventTypeWarning, "EphemeralStorageHigh", fmt.Sprintf("Runner pod uses %s. The runner is recycled once it's idle", usage))
+		return nil
+	}
+
+	if isProtected(pod, now) {
+		log.V(1).Info("Skipped recycling protected runner over the ephemeral storage threshold", "used", u.used, "limit", u.limit)
+		return nil
+	}
+
+	var obj client.Object = pod
+
+	if isOwnedByRunner(pod) {
+		var runner v1alpha1.Runner
+		if err := m.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}, &runner); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		if isProtected(&runner, now) {
+			log.V(1).Info("Skipped recycling protected runner over the ephemeral storage threshold", "used", u.used, "limit", u.limit)
+			return nil
+		}
+
+		obj = &runner
+	}
+
+	if err := m.Delete(ctx, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	m.Recorder.Event(obj, corev1.EventTypeNormal, "EphemeralStorageRecycled", fmt.Sprintf("Recycled idle runner using %s", usage))
+
+	log.Info("Recycled idle runner due to the ephemeral storage usage", "used", u.used, "limit", u.limit)
+
+	metrics.IncRunnerEphemeralStorageRecycles(u.pool.kind, u.pool.key.Namespace, u.pool.key.Name)
+
+	return nil
+}
+
+// parsePodEphemeralStorageUsage returns the ephemeral storage used by each pod in the stats summary of the kubelet.
+func parsePodEphemeralStorageUsage(summary []byte) (map[types.NamespacedName]int64, error) {
+	var s kubeletStatsSummary
+	if err := json.Unmarshal(summary, &s); err != nil {
+		return nil, err
+	}
+
+	used := map[types.NamespacedName]int64{}
+
+	for _, p := range s.Pods {
+		if p.EphemeralStorage == nil || p.EphemeralStorage.UsedBytes == nil {
+			continue
+		}
+
+		used[types.NamespacedName{Namespace: p.PodRef.Namespace, Name: p.PodRef.Name}] = *p.EphemeralStorage.UsedBytes
+	}
+
+	return used, nil
+}
+
+// podEphemeralStorageLimit returns the sum of the ephemeral storage limits of the containers of the pod,
+// which the kubelet evicts the pod at. Zero means the pod has no limit.
+func podEphemeralStorageLimit(pod *corev1.Pod) int64 {
+	var limit int64
+
+	for _, c := range pod.Spec.Containers {
+		if q, ok := c.Resources.Limits[corev1.ResourceEphemeralStorage]; ok {
+			limit += q.Value()
+		}
+	}
+
+	return limit
+}
+
+// runnerStoragePoolOf returns the runner pool the runner pod belongs to.
+func runnerStoragePoolOf(pod *corev1.Pod) runnerStoragePool {
+	if name := pod.Labels[LabelKeyRunnerDeploymentName]; name != "" {
+		return runnerStoragePool{kind: "RunnerDeployment", key: types.NamespacedName{Namespace: pod.Namespace, Name: name}}
+	}
+
+	if isOwnedByRunner(pod) {
+		return runnerStoragePool{kind: "Runner", key: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}}
+	}
+
+	return runnerStoragePool{kind: "RunnerSet", key: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[LabelKeyRunnerSetName]}}
+}
+
+func isOwnedByRunner(pod *corev1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "Runner" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatBytes(b int64) string {
+	const unit = 1 << 30
+
+	return fmt.Sprintf("%.1fGi", float64(b)/unit)
+}
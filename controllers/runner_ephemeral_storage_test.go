@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParsePodEphemeralStorageUsage(t *testing.T) {
+	testcases := []struct {
+		description string
+		summary     string
+		want        map[types.NamespacedName]int64
+		wantErr     bool
+	}{
+		{
+			description: "pods with and without usage",
+			summary: `{"node": {"nodeName": "node-1"}, "pods": [
+				{"podRef": {"name": "runner-1", "namespace": "default"}, "ephemeral-storage": {"usedBytes": 1024}},
+				{"podRef": {"name": "runner-2", "namespace": "default"}, "ephemeral-storage": {}},
+				{"podRef": {"name": "runner-3", "namespace": "default"}}
+			]}`,
+			want: map[types.NamespacedName]int64{
+				{Namespace: "default", Name: "runner-1"}: 1024,
+			},
+		},
+		{
+			description: "invalid",
+			summary:     `not json`,
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := parsePodEphemeralStorageUsage([]byte(tc.summary))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("unexpected usage: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPodEphemeralStorageLimit(t *testing.T) {
+	container := func(name, limit string) corev1.Container {
+		c := corev1.Container{Name: name}
+		if limit != "" {
+			c.Resources.Limits = corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse(limit)}
+		}
+		return c
+	}
+
+	testcases := []struct {
+		description string
+		containers  []corev1.Container
+		want        int64
+	}{
+		{
+			description: "no limit",
+			containers:  []corev1.Container{container("runner", "")},
+		},
+		{
+			description: "runner",
+			containers:  []corev1.Container{container("runner", "10Gi")},
+			want:        10 << 30,
+		},
+		{
+			description: "runner and docker",
+			containers:  []corev1.Container{container("runner", "10Gi"), container("docker", "20Gi")},
+			want:        30 << 30,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got := podEphemeralStorageLimit(&corev1.Pod{Spec: corev1.PodSpec{Containers: tc.containers}})
+
+			if got != tc.want {
+				t.Errorf("unexpected limit: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerEphemeralStorageMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	newRunner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+		}
+	}
+
+	newPod := func(name, node string, limit string) *corev1.Pod {
+		c := corev1.Container{
+			Name: containerName,
+			Env:  []corev1.EnvVar{{Name: EnvVarRepo, Value: "test/valid"}},
+		}
+		if limit != "" {
+			c.Resources.Limits = corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse(limit)}
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					LabelKeyRunnerSetName:        "example-abcde",
+					LabelKeyRunnerDeploymentName: "example",
+				},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Runner", Name: name}},
+			},
+			Spec:   corev1.PodSpec{NodeName: node, Containers: []corev1.Container{c}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	objs := []runtime.Object{
+		// Busy and over the threshold
+		newRunner("runner-1"), newPod("runner-1", "node-1", "10Gi"),
+		// Idle and over the threshold
+		newRunner("runner-2"), newPod("runner-2", "node-1", "10Gi"),
+		// Idle and under the threshold
+		newRunner("runner-3"), newPod("runner-3", "node-2", "10Gi"),
+		// Without the limit
+		newRunner("runner-4"), newPod("runner-4", "node-2", ""),
+	}
+
+	summaries := map[string]string{
+		"node-1": `{"pods": [
+			{"podRef": {"name": "runner-1", "namespace": "default"}, "ephemeral-storage": {"usedBytes": 9663676416}},
+			{"podRef": {"name": "runner-2", "namespace": "default"}, "ephemeral-storage": {"usedBytes": 8589934592}}
+		]}`,
+		"node-2": `{"pods": [
+			{"podRef": {"name": "runner-3", "namespace": "default"}, "ephemeral-storage": {"usedBytes": 1073741824}},
+			{"podRef": {"name": "runner-4", "namespace": "default"}, "ephemeral-storage": {"usedBytes": 10737418240}}
+		]}`,
+	}
+
+	server := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 4, "runners": [
+		{"name": "runner-1", "status": "online", "busy": true},
+		{"name": "runner-2", "status": "online", "busy": false},
+		{"name": "runner-3", "status": "online", "busy": false},
+		{"name": "runner-4", "status": "online", "busy": false}
+	]}`))
+	defer server.Close()
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	recorder := record.NewFakeRecorder(10)
+
+	m := &RunnerEphemeralStorageMonitor{
+		Client:       c,
+		Log:          logr.Discard(),
+		Recorder:     recorder,
+		GitHubClient: newGithubClient(server),
+		NodeStats: func(ctx context.Context, node string) ([]byte, error) {
+			return []byte(summaries[node]), nil
+		},
+		RecycleThreshold: DefaultRunnerEphemeralStorageRecycleThreshold,
+	}
+
+	if err := m.checkAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exists := func(name string) bool {
+		var r v1alpha1.Runner
+		err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &r)
+		if err != nil && !kerrors.IsNotFound(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return err == nil
+	}
+
+	if !exists("runner-1") {
+		t.Errorf("busy runner-1 must not be recycled")
+	}
+
+	if exists("runner-2") {
+		t.Errorf("idle runner-2 over the threshold must be recycled")
+	}
+
+	for _, name := range []string{"runner-3", "runner-4"} {
+		if !exists(name) {
+			t.Errorf("%s must not be recycled", name)
+		}
+	}
+
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+
+	if len(events) != 2 || !strings.Contains(events[0], "EphemeralStorageHigh") || !strings.Contains(events[1], "EphemeralStorageRecycled") {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelKeyRunnerBusy is the label of the busy runner pods of the RunnerDeployments with spec.evictionProtection,
+	// which are selected by their PodDisruptionBudgets.
+	LabelKeyRunnerBusy = "actions-runner-controller/busy"
+
+	// AnnotationKeySafeToEvict is the annotation the cluster autoscaler reads to tell whether it can evict the pod to scale down its node.
+	AnnotationKeySafeToEvict = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+)
+
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
+// syncPodDisruptionBudget creates or updates the PodDisruptionBudget that disallows evicting the busy runner pods of the runner deployment
+// when spec.evictionProtection.podDisruptionBudget is enabled, and deletes it otherwise.
+func (r *RunnerDeploymentReconciler) syncPodDisruptionBudget(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) error {
+	var current policyv1.PodDisruptionBudget
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}, &current)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	exists := err == nil
+
+	if p := rd.Spec.EvictionProtection; p == nil || !p.PodDisruptionBudget {
+		if !exists || !metav1.IsControlledBy(&current, &rd) {
+			return nil
+		}
+
+		if err := r.Delete(ctx, &current); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		log.Info("Deleted the pod disruption budget of the busy runners")
+
+		return nil
+	}
+
+	desired := newBusyRunnersPodDisruptionBudget(rd)
+
+	if !exists {
+		if err := ctrl.SetControllerReference(&rd, desired, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+
+		log.Info("Created the pod disruption budget of the busy runners")
+
+		return nil
+	}
+
+	if !metav1.IsControlledBy(&current, &rd) {
+		return fmt.Errorf("pod disruption budget %s already exists and isn't owned by the runner deployment", rd.Name)
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) &&
+		equality.Semantic.DeepEqual(current.Spec.Selector, desired.Spec.Selector) {
+		return nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Spec = desired.Spec
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(&current)); err != nil {
+		return err
+	}
+
+	log.Info("Updated the pod disruption budget of the busy runners")
+
+	return nil
+}
+
+func newBusyRunnersPodDisruptionBudget(rd v1alpha1.RunnerDeployment) *policyv1.PodDisruptionBudget {
+	zero := intstr.FromInt(0)
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: rd.Namespace,
+			Name:      rd.Name,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &zero,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					LabelKeyRunnerDeploymentName: rd.Name,
+					LabelKeyRunnerBusy:           "true",
+				},
+			},
+		},
+	}
+}
+
+// evictionProtectedRunnerDeployments returns the namespaced names of the runner deployments with spec.evictionProtection.
+func (s *RunnerStatusSyncer) evictionProtectedRunnerDeployments(ctx context.Context, opts ...client.ListOption) (map[types.NamespacedName]bool, error) {
+	var rds v1alpha1.RunnerDeploymentList
+	if err := s.List(ctx, &rds, opts...); err != nil {
+		return nil, err
+	}
+
+	protected := map[types.NamespacedName]bool{}
+
+	for _, rd := range rds.Items {
+		if rd.Spec.EvictionProtection != nil {
+			protected[types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}] = true
+		}
+	}
+
+	return protected, nil
+}
+
+// syncEvictionProtection labels and annotates the pod of the busy runner to keep it from being evicted,
+// and restores the pod once the runner becomes idle.
+// The safe-to-evict annotation of the runner, copied from the template, is restored so that the idle runner pods with
+// emptyDir volumes are still evicted by the cluster autoscaler when they were allowed to be.
+func (s *RunnerStatusSyncer) syncEvictionProtection(ctx context.Context, runner *v1alpha1.Runner, busy bool) error {
+	var pod corev1.Pod
+	if err := s.Get(ctx, types.NamespacedName{Namespace: runner.Namespace, Name: runner.Name}, &pod); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !pod.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	if _, marked := pod.Labels[LabelKeyRunnerBusy]; marked == busy {
+		return nil
+	}
+
+	updated := pod.DeepCopy()
+
+	if busy {
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+
+		updated.Labels[LabelKeyRunnerBusy] = "true"
+		updated.Annotations[AnnotationKeySafeToEvict] = "false"
+	} else {
+		delete(updated.Labels, LabelKeyRunnerBusy)
+
+		if v, ok := getAnnotation(runner, AnnotationKeySafeToEvict); ok {
+			updated.Annotations[AnnotationKeySafeToEvict] = v
+		} else {
+			delete(updated.Annotations, AnnotationKeySafeToEvict)
+		}
+	}
+
+	return s.Patch(ctx, updated, client.MergeFrom(&pod))
+}
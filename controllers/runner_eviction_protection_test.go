@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncPodDisruptionBudget(t *testing.T) {
+	ctx := context.Background()
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "example"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			EvictionProtection: &v1alpha1.RunnerEvictionProtection{PodDisruptionBudget: true},
+		},
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).Build()
+
+	r := &RunnerDeploymentReconciler{Client: c, Scheme: sc}
+
+	if err := r.syncPodDisruptionBudget(ctx, logr.Discard(), rd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "example"}
+
+	var pdb policyv1.PodDisruptionBudget
+	if err := c.Get(ctx, key, &pdb); err != nil {
+		t.Fatal(err)
+	}
+
+	if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 0 {
+		t.Errorf("unexpected maxUnavailable: %v", pdb.Spec.MaxUnavailable)
+	}
+
+	if want := map[string]string{LabelKeyRunnerDeploymentName: "example", LabelKeyRunnerBusy: "true"}; !cmp.Equal(pdb.Spec.Selector.MatchLabels, want) {
+		t.Errorf("unexpected selector: %v", pdb.Spec.Selector.MatchLabels)
+	}
+
+	if !metav1.IsControlledBy(&pdb, &rd) {
+		t.Errorf("the pod disruption budget is not owned by the runner deployment: %+v", pdb.OwnerReferences)
+	}
+
+	// Reconciling again leaves it as is
+	if err := r.syncPodDisruptionBudget(ctx, logr.Discard(), rd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rd.Spec.EvictionProtection = nil
+
+	if err := r.syncPodDisruptionBudget(ctx, logr.Discard(), rd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Get(ctx, key, &pdb); !kerrors.IsNotFound(err) {
+		t.Errorf("the pod disruption budget is not deleted: %v", err)
+	}
+}
+
+func TestSyncPodDisruptionBudget_NotOwned(t *testing.T) {
+	ctx := context.Background()
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", UID: "example"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			EvictionProtection: &v1alpha1.RunnerEvictionProtection{PodDisruptionBudget: true},
+		},
+	}
+
+	other := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(other).Build()
+
+	r := &RunnerDeploymentReconciler{Client: c, Scheme: sc}
+
+	if err := r.syncPodDisruptionBudget(ctx, logr.Discard(), rd); err == nil {
+		t.Error("expected an error for the pod disruption budget not owned by the runner deployment")
+	}
+
+	rd.Spec.EvictionProtection = nil
+
+	if err := r.syncPodDisruptionBudget(ctx, logr.Discard(), rd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var pdb policyv1.PodDisruptionBudget
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &pdb); err != nil {
+		t.Errorf("the pod disruption budget not owned by the runner deployment is deleted: %v", err)
+	}
+}
+
+func TestRunnerStatusSyncer_EvictionProtection(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+
+	newRunner := func(name, rd string, annotations map[string]string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{LabelKeyRunnerDeploymentName: rd},
+				Annotations: annotations,
+			},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+		}
+	}
+
+	newPod := func(name, rd string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{LabelKeyRunnerDeploymentName: rd},
+				Annotations: annotations,
+			},
+		}
+	}
+
+	template := map[string]string{AnnotationKeySafeToEvict: "true"}
+
+	objs := []runtime.Object{
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "protected", Namespace: "default"},
+			Spec:       v1alpha1.RunnerDeploymentSpec{EvictionProtection: &v1alpha1.RunnerEvictionProtection{}},
+		},
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "unprotected", Namespace: "default"},
+		},
+		newRunner("busy", "protected", template),
+		newPod("busy", "protected", template),
+		newRunner("idle", "protected", template),
+		newPod("idle", "protected", template),
+		newRunner("unprotected", "unprotected", nil),
+		newPod("unprotected", "unprotected", nil),
+	}
+
+	server := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 3, "runners": [
+{"id": 1, "name": "busy", "status": "online", "busy": true},
+{"id": 2, "name": "idle", "status": "online", "busy": false},
+{"id": 3, "name": "unprotected", "status": "online", "busy": true}
+]}`))
+	defer server.Close()
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	s := &RunnerStatusSyncer{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	getPod := func(name string) corev1.Pod {
+		t.Helper()
+
+		var pod corev1.Pod
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &pod); err != nil {
+			t.Fatal(err)
+		}
+
+		return pod
+	}
+
+	if err := s.syncAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pod := getPod("busy"); pod.Labels[LabelKeyRunnerBusy] != "true" || pod.Annotations[AnnotationKeySafeToEvict] != "false" {
+		t.Errorf("the busy runner pod is not protected: labels=%v, annotations=%v", pod.Labels, pod.Annotations)
+	}
+
+	if pod := getPod("idle"); pod.Labels[LabelKeyRunnerBusy] != "" || pod.Annotations[AnnotationKeySafeToEvict] != "true" {
+		t.Errorf("the idle runner pod is protected: labels=%v, annotations=%v", pod.Labels, pod.Annotations)
+	}
+
+	if pod := getPod("unprotected"); pod.Labels[LabelKeyRunnerBusy] != "" || pod.Annotations[AnnotationKeySafeToEvict] != "" {
+		t.Errorf("the runner pod of the runner deployment without the eviction protection is protected: labels=%v, annotations=%v", pod.Labels, pod.Annotations)
+	}
+
+	// The busy runner becomes idle
+	server2 := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 1, "runners": [
+{"id": 1, "name": "busy", "status": "online", "busy": false}
+]}`))
+	defer server2.Close()
+
+	s.GitHubClient = newGithubClient(server2)
+
+	if err := s.syncAll(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pod := getPod("busy"); pod.Labels[LabelKeyRunnerBusy] != "" || pod.Annotations[AnnotationKeySafeToEvict] != "true" {
+		t.Errorf("the safe-to-evict annotation of the template is not restored: labels=%v, annotations=%v", pod.Labels, pod.Annotations)
+	}
+}
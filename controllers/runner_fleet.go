@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	githubmetrics "github.com/actions-runner-controller/actions-runner-controller/github/metrics"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RunnerFleet serves the summary of all the runner pools managed by the controller as JSON,
+// so that a fleet dashboard can watch a single endpoint instead of joining the lists of
+// RunnerDeployments, RunnerSets, HorizontalRunnerAutoscalers, runners and runner pods.
+//
+// Like RunnerInventory, the summary is compiled from the controller's informer caches, plus a single
+// GitHub API call per registration scope and credentials to obtain the busy runners.
+//
+// Requests must have the `Authorization: Bearer TOKEN` header whose TOKEN matches Token.
+type RunnerFleet struct {
+	client.Client
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+	Log           logr.Logger
+
+	// Token is the bearer token required to access the summary.
+	// The summary is never served when this is empty.
+	Token string
+
+	Namespace string
+}
+
+// RunnerFleetSummary is the summary of all the runner pools.
+type RunnerFleetSummary struct {
+	// Desired, Ready and Busy are the totals of the runner pools.
+	// Busy doesn't count the runner pools whose busy runners are unknown.
+	Desired int `json:"desired"`
+	Ready   int `json:"ready"`
+	Busy    int `json:"busy"`
+
+	Pools []RunnerFleetPool `json:"pools"`
+}
+
+// RunnerFleetPool is a RunnerDeployment or RunnerSet in the summary.
+type RunnerFleetPool struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	Enterprise   string `json:"enterprise,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+
+	Desired int `json:"desired"`
+	Ready   int `json:"ready"`
+	// Busy is nil when the controller failed to fetch runners from GitHub.
+	Busy *int `json:"busy"`
+
+	// HorizontalRunnerAutoscaler is the name of the HorizontalRunnerAutoscaler that scales the runner pool, if any.
+	// MinReplicas, MaxReplicas, LastScaleTime and LastScaleReason are read from it.
+	HorizontalRunnerAutoscaler string     `json:"horizontalRunnerAutoscaler,omitempty"`
+	MinReplicas                *int       `json:"minReplicas,omitempty"`
+	MaxReplicas                *int       `json:"maxReplicas,omitempty"`
+	LastScaleTime              *time.Time `json:"lastScaleTime,omitempty"`
+	LastScaleReason            string     `json:"lastScaleReason,omitempty"`
+
+	// Credentials identifies the GitHub API credentials of the runner pool, which is either `default` for
+	// the controller-wide credentials or NAMESPACE/NAME of the secret referenced by githubAPICredentialsFrom.
+	Credentials string `json:"credentials"`
+	// RateLimitRemaining is the number of GitHub API requests remaining for the credentials,
+	// as of the last response to the controller. It's nil before the first response.
+	RateLimitRemaining *int `json:"rateLimitRemaining"`
+}
+
+func (f *RunnerFleet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !hasBearerToken(r, f.Token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := f.Summarize(r.Context())
+	if err != nil {
+		f.Log.Error(err, "Failed to summarize runner fleet")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		f.Log.Error(err, "Failed writing runner fleet summary")
+	}
+}
+
+// runnerFleetPoolSource is a runner pool along with what's needed to count its busy runners.
+type runnerFleetPoolSource struct {
+	pool        *RunnerFleetPool
+	credentials *v1alpha1.GitHubAPICredentialsFrom
+}
+
+type runnerFleetScope struct {
+	credentials string
+	runnerScope
+}
+
+// Summarize compiles the runner fleet summary.
+func (f *RunnerFleet) Summarize(ctx context.Context) (*RunnerFleetSummary, error) {
+	var opts []client.ListOption
+	if f.Namespace != "" {
+		opts = append(opts, client.InNamespace(f.Namespace))
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := f.List(ctx, &rds, opts...); err != nil {
+		return nil, err
+	}
+
+	var runnerSets v1alpha1.RunnerSetList
+	if err := f.List(ctx, &runnerSets, opts...); err != nil {
+		return nil, err
+	}
+
+	var hras v1alpha1.HorizontalRunnerAutoscalerList
+	if err := f.List(ctx, &hras, opts...); err != nil {
+		return nil, err
+	}
+
+	var pods corev1.PodList
+	if err := f.List(ctx, &pods, append(opts, client.HasLabels{LabelKeyRunnerSetName})...); err != nil {
+		return nil, err
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := f.List(ctx, &runners, opts...); err != nil {
+		return nil, err
+	}
+
+	runnersByKey := map[types.NamespacedName]v1alpha1.Runner{}
+	for _, r := range runners.Items {
+		runnersByKey[types.NamespacedName{Namespace: r.Namespace, Name: r.Name}] = r
+	}
+
+	// Runner pod names by the runner pool in the NAMESPACE/KIND/NAME format
+	podNames := map[string][]string{}
+	for _, pod := range pods.Items {
+		key := pod.Namespace + "/" + runnerPool(pod, runnersByKey)
+		podNames[key] = append(podNames[key], pod.Name)
+	}
+
+	var sources []runnerFleetPoolSource
+
+	for _, rd := range rds.Items {
+		config := rd.Spec.Template.Spec.RunnerConfig
+
+		sources = append(sources, runnerFleetPoolSource{
+			pool: &RunnerFleetPool{
+				Kind:         "RunnerDeployment",
+				Name:         rd.Name,
+				Namespace:    rd.Namespace,
+				Enterprise:   config.Enterprise,
+				Organization: config.Organization,
+				Repository:   config.Repository,
+				Desired:      getIntOrDefault(rd.Spec.Replicas, defaultReplicas),
+				Ready:        getIntOrDefault(rd.Status.ReadyReplicas, 0),
+			},
+			credentials: config.GitHubAPICredentialsFrom,
+		})
+	}
+
+	for _, rs := range runnerSets.Items {
+		config := rs.Spec.RunnerConfig
+
+		desired := defaultReplicas
+		if rs.Spec.Replicas != nil {
+			desired = int(*rs.Spec.Replicas)
+		}
+
+		sources = append(sources, runnerFleetPoolSource{
+			pool: &RunnerFleetPool{
+				Kind:         "RunnerSet",
+				Name:         rs.Name,
+				Namespace:    rs.Namespace,
+				Enterprise:   config.Enterprise,
+				Organization: config.Organization,
+				Repository:   config.Repository,
+				Desired:      desired,
+				Ready:        getIntOrDefault(rs.Status.ReadyReplicas, 0),
+			},
+			credentials: config.GitHubAPICredentialsFrom,
+		})
+	}
+
+	busyByScope := map[runnerFleetScope]map[string]bool{}
+
+	summary := &RunnerFleetSummary{Pools: []RunnerFleetPool{}}
+
+	for _, s := range sources {
+		p := s.pool
+
+		for _, hra := range hras.Items {
+			kind := hra.Spec.ScaleTargetRef.Kind
+			if kind == "" {
+				kind = "RunnerDeployment"
+			}
+
+			if hra.Namespace != p.Namespace || kind != p.Kind || hra.Spec.ScaleTargetRef.Name != p.Name {
+				continue
+			}
+
+			p.HorizontalRunnerAutoscaler = hra.Name
+			p.MinReplicas = hra.Spec.MinReplicas
+			p.MaxReplicas = hra.Spec.MaxReplicas
+
+			if history := hra.Status.ScalingHistory; len(history) > 0 {
+				last := history[len(history)-1]
+				p.LastScaleTime = &last.Time.Time
+				p.LastScaleReason = last.Reason
+			} else if t := hra.Status.LastSuccessfulScaleOutTime; t != nil {
+				p.LastScaleTime = &t.Time
+			}
+		}
+
+		p.Credentials = githubmetrics.DefaultCredentials
+		if s.credentials != nil {
+			p.Credentials = types.NamespacedName{Namespace: p.Namespace, Name: s.credentials.SecretRef.Name}.String()
+		}
+
+		if remaining, ok := githubmetrics.RateLimitRemaining(p.Credentials); ok {
+			p.RateLimitRemaining = &remaining
+		}
+
+		scope := runnerFleetScope{
+			credentials: p.Credentials,
+			runnerScope: runnerScope{enterprise: p.Enterprise, org: p.Organization, repo: p.Repository},
+		}
+
+		busy, ok := busyByScope[scope]
+		if !ok {
+			busy = f.listBusyRunners(ctx, p.Namespace, s.credentials, scope.runnerScope)
+			busyByScope[scope] = busy
+		}
+
+		if busy != nil {
+			var n int
+			for _, name := range podNames[p.Namespace+"/"+p.Kind+"/"+p.Name] {
+				if busy[name] {
+					n++
+				}
+			}
+
+			p.Busy = &n
+			summary.Busy += n
+		}
+
+		summary.Desired += p.Desired
+		summary.Ready += p.Ready
+		summary.Pools = append(summary.Pools, *p)
+	}
+
+	sort.SliceStable(summary.Pools, func(a, b int) bool {
+		pa, pb := summary.Pools[a], summary.Pools[b]
+		if pa.Namespace != pb.Namespace {
+			return pa.Namespace < pb.Namespace
+		}
+		if pa.Kind != pb.Kind {
+			return pa.Kind < pb.Kind
+		}
+		return pa.Name < pb.Name
+	})
+
+	return summary, nil
+}
+
+// listBusyRunners returns the set of the names of the busy runners registered to the scope,
+// or nil when they couldn't be fetched from GitHub.
+func (f *RunnerFleet) listBusyRunners(ctx context.Context, namespace string, credentials *v1alpha1.GitHubAPICredentialsFrom, scope runnerScope) map[string]bool {
+	log := f.Log.WithValues("enterprise", scope.enterprise, "organization", scope.org, "repository", scope.repo)
+
+	ghc, err := f.GitHubClients.ClientFor(ctx, f.GitHubClient, namespace, credentials)
+	if err != nil {
+		log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+		return nil
+	}
+
+	ghRunners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+	if err != nil {
+		log.Error(err, "Failed to list runners on GitHub")
+		return nil
+	}
+
+	busy := map[string]bool{}
+	for _, r := range ghRunners {
+		if r.GetBusy() {
+			busy[r.GetName()] = true
+		}
+	}
+
+	return busy
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	ghfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerFleet(t *testing.T) {
+	scaled := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	runnerPod := func(name string, owner metav1.OwnerReference, labels map[string]string) *corev1.Pod {
+		l := map[string]string{LabelKeyRunnerSetName: name}
+		for k, v := range labels {
+			l[k] = v
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            name,
+				Labels:          l,
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+		}
+	}
+
+	runner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example-rd"},
+			},
+		}
+	}
+
+	rsReplicas := int32(1)
+
+	objs := []runtime.Object{
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-rd"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Replicas: intPtr(2),
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"}},
+				},
+			},
+			Status: v1alpha1.RunnerDeploymentStatus{ReadyReplicas: intPtr(2)},
+		},
+		&v1alpha1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-rs"},
+			Spec: v1alpha1.RunnerSetSpec{
+				RunnerConfig:    v1alpha1.RunnerConfig{Repository: "test/valid"},
+				StatefulSetSpec: appsv1.StatefulSetSpec{Replicas: &rsReplicas},
+			},
+			Status: v1alpha1.RunnerSetStatus{ReadyReplicas: intPtr(1)},
+		},
+		&v1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-hra"},
+			Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: v1alpha1.ScaleTargetRef{Name: "example-rd"},
+				MinReplicas:    intPtr(1),
+				MaxReplicas:    intPtr(5),
+			},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+				ScalingHistory: []v1alpha1.ScalingDecision{
+					{Time: metav1.NewTime(scaled.Add(-time.Hour)), From: 0, To: 1, Reason: "MinReplicas"},
+					{Time: metav1.NewTime(scaled), From: 1, To: 2, Reason: "TotalNumberOfQueuedAndInProgressWorkflowRuns"},
+				},
+			},
+		},
+		runner("example-rd-runner-1"),
+		runner("example-rd-runner-2"),
+		runnerPod("example-rd-runner-1", metav1.OwnerReference{Kind: "Runner", Name: "example-rd-runner-1"}, nil),
+		runnerPod("example-rd-runner-2", metav1.OwnerReference{Kind: "Runner", Name: "example-rd-runner-2"}, nil),
+		runnerPod("example-rs-abcde-0", metav1.OwnerReference{Kind: "StatefulSet", Name: "example-rs-abcde"}, map[string]string{LabelKeyRunnerSetName: "example-rs"}),
+	}
+
+	runners := ghfake.NewRunnersList()
+	runners.Add(&github.Runner{ID: github.Int64(1), Name: github.String("example-rd-runner-1"), Busy: github.Bool(true)})
+	runners.Add(&github.Runner{ID: github.Int64(2), Name: github.String("example-rd-runner-2"), Busy: github.Bool(false)})
+	runners.Add(&github.Runner{ID: github.Int64(3), Name: github.String("example-rs-abcde-0"), Busy: github.Bool(true)})
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	fleet := &RunnerFleet{
+		Client:       fake.NewFakeClientWithScheme(sc, objs...),
+		GitHubClient: newGithubClient(server),
+		Log:          logr.Discard(),
+		Token:        "secret",
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/fleet", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+
+		rec := httptest.NewRecorder()
+		fleet.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status: %d", rec.Code)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/fleet", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+		fleet.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var summary RunnerFleetSummary
+		if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("unexpected json: %v: %s", err, rec.Body.String())
+		}
+
+		if summary.Desired != 3 || summary.Ready != 3 || summary.Busy != 2 {
+			t.Errorf("unexpected totals: want desired=3 ready=3 busy=2, got desired=%d ready=%d busy=%d", summary.Desired, summary.Ready, summary.Busy)
+		}
+	})
+
+	t.Run("pools", func(t *testing.T) {
+		summary, err := fleet.Summarize(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(summary.Pools) != 2 {
+			t.Fatalf("unexpected number of pools: want 2, got %d", len(summary.Pools))
+		}
+
+		rd := summary.Pools[0]
+
+		if rd.Kind != "RunnerDeployment" || rd.Name != "example-rd" {
+			t.Fatalf("unexpected first pool: %s/%s", rd.Kind, rd.Name)
+		}
+
+		if rd.Desired != 2 || rd.Ready != 2 || rd.Busy == nil || *rd.Busy != 1 {
+			t.Errorf("unexpected replicas of %s: desired=%d ready=%d busy=%v", rd.Name, rd.Desired, rd.Ready, rd.Busy)
+		}
+
+		if rd.HorizontalRunnerAutoscaler != "example-hra" || *rd.MinReplicas != 1 || *rd.MaxReplicas != 5 {
+			t.Errorf("unexpected autoscaling of %s: hra=%s min=%v max=%v", rd.Name, rd.HorizontalRunnerAutoscaler, rd.MinReplicas, rd.MaxReplicas)
+		}
+
+		if rd.LastScaleTime == nil || !rd.LastScaleTime.Equal(scaled) || rd.LastScaleReason != "TotalNumberOfQueuedAndInProgressWorkflowRuns" {
+			t.Errorf("unexpected last scale of %s: %v %s", rd.Name, rd.LastScaleTime, rd.LastScaleReason)
+		}
+
+		if rd.Credentials != "default" {
+			t.Errorf("unexpected credentials of %s: %s", rd.Name, rd.Credentials)
+		}
+
+		rs := summary.Pools[1]
+
+		if rs.Kind != "RunnerSet" || rs.Desired != 1 || rs.Ready != 1 || rs.Busy == nil || *rs.Busy != 1 {
+			t.Errorf("unexpected runner set pool: %+v", rs)
+		}
+
+		if rs.HorizontalRunnerAutoscaler != "" {
+			t.Errorf("unexpected hra of %s: %s", rs.Name, rs.HorizontalRunnerAutoscaler)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const DefaultRunnerStatusSyncInterval = 1 * time.Minute
+
+// RunnerStatusSyncer periodically syncs the state of each runner on GitHub, like whether it's online and busy,
+// into its status.github, so that `kubectl get runners` shows which runners are busy, idle or offline.
+//
+// The list runners API is called once per registration scope and credentials on each sync,
+// which is usually served from the GitHub API cache. RunnerReplicaSets and HorizontalRunnerAutoscalers then read
+// the busy runners from the synced statuses instead of listing the runners via the GitHub API while the statuses are fresh.
+// Only Runners are synced, so runners of RunnerSets are not.
+//
+// The pods of the busy runners of the RunnerDeployments with spec.evictionProtection are also marked as busy on each sync,
+// so that they aren't evicted by the cluster autoscaler and their PodDisruptionBudgets.
+type RunnerStatusSyncer struct {
+	client.Client
+	Log           logr.Logger
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	Interval  time.Duration
+	Namespace string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader syncs the statuses.
+func (s *RunnerStatusSyncer) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (s *RunnerStatusSyncer) Start(ctx context.Context) error {
+	interval := s.interval()
+
+	s.Log.Info("Starting runner status syncer", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.syncAll(ctx, time.Now()); err != nil {
+			s.Log.Error(err, "Failed to sync runner statuses")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RunnerStatusSyncer) interval() time.Duration {
+	if s.Interval <= 0 {
+		return DefaultRunnerStatusSyncInterval
+	}
+
+	return s.Interval
+}
+
+func (s *RunnerStatusSyncer) syncAll(ctx context.Context, now time.Time) error {
+	var opts []client.ListOption
+	if s.Namespace != "" {
+		opts = append(opts, client.InNamespace(s.Namespace))
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := s.List(ctx, &runners, opts...); err != nil {
+		return err
+	}
+
+	protected, err := s.evictionProtectedRunnerDeployments(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	registered := map[runnerUtilizationScope]map[string]*gogithub.Runner{}
+
+	for i := range runners.Items {
+		runner := &runners.Items[i]
+
+		log := s.Log.WithValues("runner", types.NamespacedName{Namespace: runner.Namespace, Name: runner.Name})
+
+		scope := runnerUtilizationScope{
+			runnerScope: runnerScope{
+				enterprise: runner.Spec.Enterprise,
+				org:        runner.Spec.Organization,
+				repo:       runner.Spec.Repository,
+			},
+			credentials: githubAPICredentialsKey(runner.Namespace, runner.Spec.GitHubAPICredentialsFrom),
+		}
+
+		byName, ok := registered[scope]
+		if !ok {
+			ghc, err := s.GitHubClients.ClientFor(ctx, s.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+			if err != nil {
+				log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+			} else if ghRunners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo); err != nil {
+				log.Error(err, "Failed to list runners on GitHub", "enterprise", scope.enterprise, "organization", scope.org, "repository", scope.repo)
+			} else {
+				byName = map[string]*gogithub.Runner{}
+				for _, r := range ghRunners {
+					byName[r.GetName()] = r
+				}
+			}
+
+			registered[scope] = byName
+		}
+
+		// The statuses are left as is when the GitHub API failed, so that they go stale
+		// and the readers fall back to the GitHub API instead of trusting them.
+		if byName == nil {
+			continue
+		}
+
+		status := runnerGitHubStatus(now, runner.Status.GitHub, byName[runner.Name])
+
+		updated := runner.DeepCopy()
+		updated.Status.GitHub = &status
+
+		if err := s.Status().Patch(ctx, updated, client.MergeFrom(runner)); err != nil {
+			log.V(1).Info("Failed to update runner status", "error", err.Error())
+
+			continue
+		}
+
+		if prev := runner.Status.GitHub; prev == nil || prev.State != status.State {
+			log.V(2).Info("Runner state changed on GitHub", "state", status.State)
+		}
+
+		// The pods stay protected only while they are busy and their runner deployments enable the protection.
+		if rd := runner.Labels[LabelKeyRunnerDeploymentName]; rd != "" {
+			busy := status.State == v1alpha1.RunnerGitHubStateBusy && protected[types.NamespacedName{Namespace: runner.Namespace, Name: rd}]
+
+			if err := s.syncEvictionProtection(ctx, runner, busy); err != nil {
+				log.Error(err, "Failed to update the eviction protection of the runner pod")
+			}
+		}
+	}
+
+	return nil
+}
+
+// runnerGitHubStatus returns the status of the runner synced at now from the runner on GitHub.
+// ghRunner is nil when the runner isn't registered.
+func runnerGitHubStatus(now time.Time, prev *v1alpha1.RunnerGitHubStatus, ghRunner *gogithub.Runner) v1alpha1.RunnerGitHubStatus {
+	status := v1alpha1.RunnerGitHubStatus{
+		State:        v1alpha1.RunnerGitHubStateUnregistered,
+		LastSyncTime: metav1.Time{Time: now},
+	}
+
+	if prev != nil {
+		status.LastBusyTime = prev.LastBusyTime
+	}
+
+	if ghRunner == nil {
+		return status
+	}
+
+	status.ID = ghRunner.GetID()
+
+	for _, l := range ghRunner.Labels {
+		status.Labels = append(status.Labels, l.GetName())
+	}
+
+	switch {
+	case ghRunner.GetStatus() != "online":
+		status.State = v1alpha1.RunnerGitHubStateOffline
+	case ghRunner.GetBusy():
+		status.State = v1alpha1.RunnerGitHubStateBusy
+		status.LastBusyTime = &metav1.Time{Time: now}
+	default:
+		status.State = v1alpha1.RunnerGitHubStateIdle
+	}
+
+	return status
+}
+
+// syncedRunnerGitHubStates returns the states of the runners on GitHub by the runner names from their statuses.
+// It returns false when the sync is disabled by the zero maxAge, or any of the runners hasn't been synced within maxAge,
+// so that the caller lists the runners via the GitHub API instead.
+func syncedRunnerGitHubStates(now time.Time, maxAge time.Duration, runners []v1alpha1.Runner) (map[string]v1alpha1.RunnerGitHubState, bool) {
+	if maxAge <= 0 {
+		return nil, false
+	}
+
+	states := make(map[string]v1alpha1.RunnerGitHubState, len(runners))
+
+	for i := range runners {
+		st := runners[i].Status.GitHub
+		if st == nil || now.Sub(st.LastSyncTime.Time) > maxAge {
+			return nil, false
+		}
+
+		states[runners[i].Name] = st.State
+	}
+
+	return states, true
+}
+
+// busyRunnersFromStates returns the names of the busy runners among the states.
+func busyRunnersFromStates(states map[string]v1alpha1.RunnerGitHubState) map[string]bool {
+	busy := map[string]bool{}
+
+	for name, state := range states {
+		if state == v1alpha1.RunnerGitHubStateBusy {
+			busy[name] = true
+		}
+	}
+
+	return busy
+}
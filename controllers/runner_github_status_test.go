@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerStatusSyncer(t *testing.T) {
+	ctx := context.Background()
+
+	// Truncated as metav1.Time is serialized in seconds
+	now := time.Now().Truncate(time.Second)
+	lastBusy := &metav1.Time{Time: now.Add(-time.Hour)}
+
+	newRunner := func(name string, status v1alpha1.RunnerStatus) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+			Status: status,
+		}
+	}
+
+	objs := []runtime.Object{
+		newRunner("runner-1", v1alpha1.RunnerStatus{}),
+		newRunner("runner-2", v1alpha1.RunnerStatus{GitHub: &v1alpha1.RunnerGitHubStatus{State: v1alpha1.RunnerGitHubStateBusy, LastBusyTime: lastBusy}}),
+		newRunner("runner-3", v1alpha1.RunnerStatus{}),
+		newRunner("runner-4", v1alpha1.RunnerStatus{}),
+	}
+
+	server := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 3, "runners": [
+{"id": 1, "name": "runner-1", "status": "online", "busy": true, "labels": [{"name": "self-hosted"}, {"name": "linux"}]},
+{"id": 2, "name": "runner-2", "status": "online", "busy": false},
+{"id": 3, "name": "runner-3", "status": "offline", "busy": false}
+]}`))
+	defer server.Close()
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	s := &RunnerStatusSyncer{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	if err := s.syncAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	synced := metav1.Time{Time: now}
+
+	want := map[string]v1alpha1.RunnerGitHubStatus{
+		"runner-1": {State: v1alpha1.RunnerGitHubStateBusy, ID: 1, Labels: []string{"self-hosted", "linux"}, LastBusyTime: &synced, LastSyncTime: synced},
+		"runner-2": {State: v1alpha1.RunnerGitHubStateIdle, ID: 2, LastBusyTime: lastBusy, LastSyncTime: synced},
+		"runner-3": {State: v1alpha1.RunnerGitHubStateOffline, ID: 3, LastSyncTime: synced},
+		"runner-4": {State: v1alpha1.RunnerGitHubStateUnregistered, LastSyncTime: synced},
+	}
+
+	for name, w := range want {
+		var r v1alpha1.Runner
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if r.Status.GitHub == nil {
+			t.Errorf("%s: status not synced", name)
+			continue
+		}
+
+		if d := cmp.Diff(w, *r.Status.GitHub); d != "" {
+			t.Errorf("%s: unexpected status (-want +got):\n%s", name, d)
+		}
+	}
+}
+
+func TestSyncedRunnerGitHubStates(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	newRunner := func(name string, state v1alpha1.RunnerGitHubState, ago time.Duration) v1alpha1.Runner {
+		r := v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if state != "" {
+			r.Status.GitHub = &v1alpha1.RunnerGitHubStatus{State: state, LastSyncTime: metav1.NewTime(now.Add(-ago))}
+		}
+		return r
+	}
+
+	testcases := []struct {
+		description string
+		maxAge      time.Duration
+		runners     []v1alpha1.Runner
+		want        map[string]v1alpha1.RunnerGitHubState
+		wantOK      bool
+	}{
+		{
+			description: "fresh",
+			maxAge:      2 * time.Minute,
+			runners: []v1alpha1.Runner{
+				newRunner("runner-1", v1alpha1.RunnerGitHubStateBusy, time.Minute),
+				newRunner("runner-2", v1alpha1.RunnerGitHubStateIdle, 0),
+			},
+			want:   map[string]v1alpha1.RunnerGitHubState{"runner-1": v1alpha1.RunnerGitHubStateBusy, "runner-2": v1alpha1.RunnerGitHubStateIdle},
+			wantOK: true,
+		},
+		{
+			description: "stale",
+			maxAge:      2 * time.Minute,
+			runners: []v1alpha1.Runner{
+				newRunner("runner-1", v1alpha1.RunnerGitHubStateBusy, time.Minute),
+				newRunner("runner-2", v1alpha1.RunnerGitHubStateIdle, 3*time.Minute),
+			},
+		},
+		{
+			description: "not synced yet",
+			maxAge:      2 * time.Minute,
+			runners: []v1alpha1.Runner{
+				newRunner("runner-1", v1alpha1.RunnerGitHubStateBusy, time.Minute),
+				newRunner("runner-2", "", 0),
+			},
+		},
+		{
+			description: "disabled",
+			runners: []v1alpha1.Runner{
+				newRunner("runner-1", v1alpha1.RunnerGitHubStateBusy, 0),
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, ok := syncedRunnerGitHubStates(now, tc.maxAge, tc.runners)
+			if ok != tc.wantOK {
+				t.Fatalf("unexpected ok: want %v, got %v", tc.wantOK, ok)
+			}
+
+			if d := cmp.Diff(tc.want, got); d != "" {
+				t.Errorf("unexpected states (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// applyRunnerExtendedResources allocates the GPUs and the extended resources of the runner spec to the runner container,
+// and makes the pod schedulable to the GPU nodes according to the GPU profile in the label mappings.
+// Extended resources can't be overcommitted, so that they are set to both the requests and the limits.
+func applyRunnerExtendedResources(pod *corev1.Pod, config v1alpha1.RunnerConfig, mappings *labelmapping.Config) error {
+	if config.GPU == nil && len(config.ExtendedResources) == 0 {
+		return nil
+	}
+
+	var runner *corev1.Container
+
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			runner = &pod.Spec.Containers[i]
+			break
+		}
+	}
+
+	if runner == nil {
+		return fmt.Errorf("runner container %q not found to allocate the extended resources", containerName)
+	}
+
+	for name, q := range config.ExtendedResources {
+		setRequestAndLimit(&runner.Resources, name, q)
+	}
+
+	gpu := config.GPU
+	if gpu == nil {
+		return nil
+	}
+
+	setRequestAndLimit(&runner.Resources, gpu.GetResourceName(), *resource.NewQuantity(gpu.Count, resource.DecimalSI))
+
+	profileName := gpu.Profile
+	if profileName == "" {
+		profileName = string(gpu.GetResourceName())
+	}
+
+	profile, ok := mappings.GPUProfile(profileName)
+	if !ok {
+		if gpu.Profile != "" {
+			return fmt.Errorf("gpu profile %q is not configured in the label mappings", gpu.Profile)
+		}
+
+		// No profile for the resource name. The pod is scheduled only by the resource requests.
+		return nil
+	}
+
+	profile.ApplyGPUProfile(pod)
+
+	return nil
+}
+
+func setRequestAndLimit(r *corev1.ResourceRequirements, name corev1.ResourceName, q resource.Quantity) {
+	if r.Requests == nil {
+		r.Requests = corev1.ResourceList{}
+	}
+
+	if r.Limits == nil {
+		r.Limits = corev1.ResourceList{}
+	}
+
+	r.Requests[name] = q.DeepCopy()
+	r.Limits[name] = q.DeepCopy()
+}
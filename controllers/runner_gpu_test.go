@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestApplyRunnerExtendedResources(t *testing.T) {
+	mappings, err := labelmapping.Parse([]byte(`labels: {}
+gpuProfiles:
+  nvidia.com/gpu:
+    nodeSelector:
+      accelerator: nvidia
+    tolerations:
+    - key: nvidia.com/gpu
+      operator: Exists
+      effect: NoSchedule
+    runtimeClassName: nvidia
+  a100:
+    nodeSelector:
+      accelerator: nvidia-a100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPod := func() corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: containerName}, {Name: "docker"}},
+			},
+		}
+	}
+
+	nvidiaRuntime := "nvidia"
+	customRuntime := "custom"
+
+	testcases := []struct {
+		description      string
+		config           v1alpha1.RunnerConfig
+		runtimeClassName *string
+		wantResources    corev1.ResourceList
+		wantNodeSelector map[string]string
+		wantTolerations  int
+		wantRuntimeClass *string
+		wantErr          string
+	}{
+		{
+			description: "nothing requested",
+		},
+		{
+			description:      "gpu with the profile of the resource name",
+			config:           v1alpha1.RunnerConfig{GPU: &v1alpha1.RunnerGPU{Count: 2}},
+			wantResources:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+			wantNodeSelector: map[string]string{"accelerator": "nvidia"},
+			wantTolerations:  1,
+			wantRuntimeClass: &nvidiaRuntime,
+		},
+		{
+			description:      "runtime class in the runner spec takes precedence",
+			config:           v1alpha1.RunnerConfig{GPU: &v1alpha1.RunnerGPU{Count: 1}},
+			runtimeClassName: &customRuntime,
+			wantResources:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantNodeSelector: map[string]string{"accelerator": "nvidia"},
+			wantTolerations:  1,
+			wantRuntimeClass: &customRuntime,
+		},
+		{
+			description:      "gpu with the named profile",
+			config:           v1alpha1.RunnerConfig{GPU: &v1alpha1.RunnerGPU{Count: 1, Profile: "a100"}},
+			wantResources:    corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			wantNodeSelector: map[string]string{"accelerator": "nvidia-a100"},
+		},
+		{
+			description:   "gpu without a profile for the resource name",
+			config:        v1alpha1.RunnerConfig{GPU: &v1alpha1.RunnerGPU{Count: 1, ResourceName: "amd.com/gpu"}},
+			wantResources: corev1.ResourceList{"amd.com/gpu": resource.MustParse("1")},
+		},
+		{
+			description: "unknown profile",
+			config:      v1alpha1.RunnerConfig{GPU: &v1alpha1.RunnerGPU{Count: 1, Profile: "h100"}},
+			wantErr:     `gpu profile "h100" is not configured`,
+		},
+		{
+			description: "extended resources",
+			config: v1alpha1.RunnerConfig{ExtendedResources: corev1.ResourceList{
+				"hugepages-2Mi":    resource.MustParse("1Gi"),
+				"example.com/fpga": resource.MustParse("1"),
+			}},
+			wantResources: corev1.ResourceList{
+				"hugepages-2Mi":    resource.MustParse("1Gi"),
+				"example.com/fpga": resource.MustParse("1"),
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			pod := newPod()
+			pod.Spec.RuntimeClassName = tc.runtimeClassName
+
+			err := applyRunnerExtendedResources(&pod, tc.config, mappings)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			runner := pod.Spec.Containers[0]
+
+			if d := cmp.Diff(tc.wantResources, runner.Resources.Requests); d != "" {
+				t.Errorf("unexpected requests (-want +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tc.wantResources, runner.Resources.Limits); d != "" {
+				t.Errorf("unexpected limits (-want +got):\n%s", d)
+			}
+
+			if len(pod.Spec.Containers[1].Resources.Limits) != 0 {
+				t.Errorf("unexpected resources of the docker container: %v", pod.Spec.Containers[1].Resources)
+			}
+
+			if d := cmp.Diff(tc.wantNodeSelector, pod.Spec.NodeSelector); d != "" {
+				t.Errorf("unexpected node selector (-want +got):\n%s", d)
+			}
+
+			if len(pod.Spec.Tolerations) != tc.wantTolerations {
+				t.Errorf("unexpected tolerations: %v", pod.Spec.Tolerations)
+			}
+
+			if d := cmp.Diff(tc.wantRuntimeClass, pod.Spec.RuntimeClassName); d != "" {
+				t.Errorf("unexpected runtime class (-want +got):\n%s", d)
+			}
+		})
+	}
+}
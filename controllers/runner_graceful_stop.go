@@ -26,14 +26,34 @@ import (
 // This function is designed to complete a lengthy graceful stop process in a unblocking way.
 // When it wants to be retried later, the function returns a non-nil *ctrl.Result as the second return value, may or may not populating the error in the second return value.
 // The caller is expected to return the returned ctrl.Result and error to postpone the current reconcilation loop and trigger a scheduled retry.
-func tickRunnerGracefulStop(ctx context.Context, retryDelay time.Duration, log logr.Logger, ghClient *github.Client, c client.Client, enterprise, organization, repository, runner string, pod *corev1.Pod) (*corev1.Pod, *ctrl.Result, error) {
+// When timeout is greater than zero, the graceful stop stops waiting for the busy runner to complete its job once the timeout passes
+// since the unregistration started, so that a runner running a stuck job doesn't block the scale down forever.
+func tickRunnerGracefulStop(ctx context.Context, retryDelay, timeout time.Duration, log logr.Logger, ghClient *github.Client, c client.Client, enterprise, organization, repository, runner string, pod *corev1.Pod) (*corev1.Pod, *ctrl.Result, error) {
 	pod, err := annotatePodOnce(ctx, c, log, pod, AnnotationKeyUnregistrationStartTimestamp, time.Now().Format(time.RFC3339))
 	if err != nil {
 		return nil, &ctrl.Result{}, err
 	}
 
 	if res, err := ensureRunnerUnregistration(ctx, retryDelay, log, ghClient, c, enterprise, organization, repository, runner, pod); res != nil {
-		return nil, res, err
+		if err != nil {
+			return nil, res, err
+		}
+
+		if remaining, timedOut := unregistrationTimeoutRemaining(time.Now(), timeout, pod); !timedOut {
+			// An ephemeral runner waiting for its completion isn't requeued, so it's requeued on the timeout here.
+			if remaining > 0 && !res.Requeue && (res.RequeueAfter == 0 || remaining < res.RequeueAfter) {
+				res.RequeueAfter = remaining
+			}
+
+			return nil, res, nil
+		}
+
+		log.Info(
+			"Runner unregistration timed out while waiting for the runner to complete its job. "+
+				"Proceeding to delete the runner pod, which fails the job. "+
+				"The runner may remain registered on GitHub until GitHub removes the offline runner.",
+			"timeout", timeout,
+		)
 	}
 
 	pod, err = annotatePodOnce(ctx, c, log, pod, AnnotationKeyUnregistrationCompleteTimestamp, time.Now().Format(time.RFC3339))
@@ -44,6 +64,31 @@ func tickRunnerGracefulStop(ctx context.Context, retryDelay time.Duration, log l
 	return pod, nil, nil
 }
 
+// unregistrationTimeoutRemaining returns the time remaining until the timeout passes since the unregistration of the runner pod started,
+// and whether it has already passed. The remaining time is zero when there's no timeout.
+func unregistrationTimeoutRemaining(now time.Time, timeout time.Duration, pod *corev1.Pod) (time.Duration, bool) {
+	if timeout <= 0 {
+		return 0, false
+	}
+
+	v, ok := getAnnotation(pod, AnnotationKeyUnregistrationStartTimestamp)
+	if !ok {
+		return timeout, false
+	}
+
+	started, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, false
+	}
+
+	remaining := started.Add(timeout).Sub(now)
+	if remaining <= 0 {
+		return 0, true
+	}
+
+	return remaining, false
+}
+
 // annotatePodOnce annotates the pod if it wasn't.
 // Returns the provided pod as-is if it was already annotated.
 // Returns the updated pod if the pod was missing the annotation and the update to add the annotation succeeded.
@@ -146,6 +191,14 @@ func ensureRunnerUnregistration(ctx context.Context, retryDelay time.Duration, l
 				return nil, nil
 			}
 
+			if reason, ok := repositoryInaccessibleReason(err); ok && repository != "" {
+				log.Info("Unable to unregister as the repository is inaccessible. "+
+					"ARC considers it as already unregistered and continue removing the pod.",
+					"repository", repository, "reason", reason)
+
+				return nil, nil
+			}
+
 			runner, _ := getRunner(ctx, ghClient, enterprise, organization, repository, runner)
 
 			var runnerID int64
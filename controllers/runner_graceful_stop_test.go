@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUnregistrationTimeoutRemaining(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		timeout       time.Duration
+		started       string
+		wantRemaining time.Duration
+		wantTimedOut  bool
+	}{
+		{timeout: 0, started: "2022-03-01T09:00:00Z"},
+		{timeout: time.Hour, started: "2022-03-01T09:30:00Z", wantRemaining: 30 * time.Minute},
+		{timeout: time.Hour, started: "2022-03-01T09:00:00Z", wantTimedOut: true},
+		{timeout: time.Hour, started: "2022-03-01T08:00:00Z", wantTimedOut: true},
+		{timeout: time.Hour, wantRemaining: time.Hour},
+		{timeout: time.Hour, started: "yesterday"},
+	}
+
+	for i, tc := range testcases {
+		pod := &corev1.Pod{}
+		if tc.started != "" {
+			pod.Annotations = map[string]string{AnnotationKeyUnregistrationStartTimestamp: tc.started}
+		}
+
+		remaining, timedOut := unregistrationTimeoutRemaining(now, tc.timeout, pod)
+
+		if remaining != tc.wantRemaining || timedOut != tc.wantTimedOut {
+			t.Errorf("[%d] unexpected result: want (%s, %v), got (%s, %v)", i, tc.wantRemaining, tc.wantTimedOut, remaining, timedOut)
+		}
+	}
+}
+
+func TestSyncRunnerPodsOwners_IdleFirst(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	testcases := []struct {
+		description  string
+		replicas     int
+		busy         map[string]bool
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			description:  "oldest runners are deleted when busy runners are unknown",
+			replicas:     1,
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+		{
+			description:  "busy runner is retained in place of a newer idle one",
+			replicas:     1,
+			busy:         map[string]bool{"runner-1": true},
+			wantDeleted:  []string{"runner-2", "runner-3"},
+			wantRetained: []string{"runner-1"},
+		},
+		{
+			description:  "newest of busy runners are retained",
+			replicas:     1,
+			busy:         map[string]bool{"runner-1": true, "runner-2": true},
+			wantDeleted:  []string{"runner-1", "runner-3"},
+			wantRetained: []string{"runner-2"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			for i, name := range []string{"runner-1", "runner-2", "runner-3"} {
+				r := &v1alpha1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						CreationTimestamp: metav1.NewTime(created.Add(time.Duration(i) * time.Minute)),
+						Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+					},
+					Status: v1alpha1.RunnerStatus{Phase: "Running"},
+				}
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				}
+				objs = append(objs, r, pod)
+				owners = append(owners, r)
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := &v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "desired", Namespace: "default", Labels: map[string]string{LabelKeyRunnerTemplateHash: "abc"}},
+			}
+
+			listBusy := func() map[string]bool {
+				return tc.busy
+			}
+
+			_, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, tc.replicas, desired, func() client.Object { return desired.DeepCopy() }, false, false, "", 0, listBusy, nil, owners)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			check := func(names []string, wantDeleted bool) {
+				for _, name := range names {
+					var runner v1alpha1.Runner
+					if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+						t.Fatal(err)
+					}
+
+					if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted {
+						t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted, deleted)
+					}
+				}
+			}
+
+			check(tc.wantDeleted, true)
+			check(tc.wantRetained, false)
+		})
+	}
+}
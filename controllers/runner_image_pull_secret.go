@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKeyImagePullSecretSource is the annotation put on the copies of the central image pull secret,
+	// whose value is the NAMESPACE/NAME of the source secret.
+	// A secret without the annotation is never overwritten by the copy.
+	AnnotationKeyImagePullSecretSource = "actions-runner-controller/image-pull-secret-source"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// replicateImagePullSecret copies the central image pull secret into the namespace under the same name,
+// and keeps the copy up to date with the source.
+// It does nothing for the namespace of the source.
+func replicateImagePullSecret(ctx context.Context, c client.Client, source types.NamespacedName, namespace string) error {
+	if namespace == source.Namespace {
+		return nil
+	}
+
+	var src corev1.Secret
+	if err := c.Get(ctx, source, &src); err != nil {
+		return fmt.Errorf("getting image pull secret %s: %w", source, err)
+	}
+
+	var copied corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: source.Name}, &copied); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		copied = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        source.Name,
+				Annotations: map[string]string{AnnotationKeyImagePullSecretSource: source.String()},
+			},
+			Type: src.Type,
+			Data: src.Data,
+		}
+
+		return c.Create(ctx, &copied)
+	}
+
+	if v, ok := getAnnotation(&copied, AnnotationKeyImagePullSecretSource); !ok || v != source.String() {
+		return fmt.Errorf("secret %s/%s already exists and isn't a copy of the image pull secret %s", namespace, source.Name, source)
+	}
+
+	if copied.Type == src.Type && reflect.DeepEqual(copied.Data, src.Data) {
+		return nil
+	}
+
+	// The type of a secret is immutable, so the copy is recreated when the type of the source changes.
+	if copied.Type != src.Type {
+		if err := c.Delete(ctx, &copied); err != nil {
+			return err
+		}
+
+		copied.ObjectMeta = metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        source.Name,
+			Annotations: map[string]string{AnnotationKeyImagePullSecretSource: source.String()},
+		}
+		copied.Type = src.Type
+		copied.Data = src.Data
+
+		return c.Create(ctx, &copied)
+	}
+
+	updated := copied.DeepCopy()
+	updated.Data = src.Data
+
+	return c.Patch(ctx, updated, client.MergeFrom(&copied))
+}
+
+// attachImagePullSecret adds the image pull secret to the pod spec unless it's already there.
+func attachImagePullSecret(spec *corev1.PodSpec, name string) {
+	for _, s := range spec.ImagePullSecrets {
+		if s.Name == name {
+			return
+		}
+	}
+
+	spec.ImagePullSecrets = append(spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+}
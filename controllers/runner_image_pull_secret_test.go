@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReplicateImagePullSecret(t *testing.T) {
+	source := types.NamespacedName{Namespace: "arc-system", Name: "registry"}
+
+	newSecret := func(namespace string, secretType corev1.SecretType, data string, annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: source.Name, Annotations: annotations},
+			Type:       secretType,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(data)},
+		}
+	}
+
+	managed := map[string]string{AnnotationKeyImagePullSecretSource: source.String()}
+
+	src := newSecret(source.Namespace, corev1.SecretTypeDockerConfigJson, `{"auths":{"new":{}}}`, nil)
+
+	testcases := []struct {
+		description string
+		namespace   string
+		existing    client.Object
+		wantErr     bool
+		want        *corev1.Secret
+	}{
+		{
+			description: "copy is created",
+			namespace:   "team-a",
+			want:        newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"new":{}}}`, managed),
+		},
+		{
+			description: "outdated copy is updated",
+			namespace:   "team-a",
+			existing:    newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"old":{}}}`, managed),
+			want:        newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"new":{}}}`, managed),
+		},
+		{
+			description: "copy of a different type is recreated",
+			namespace:   "team-a",
+			existing:    newSecret("team-a", corev1.SecretTypeOpaque, `{"auths":{"old":{}}}`, managed),
+			want:        newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"new":{}}}`, managed),
+		},
+		{
+			description: "secret not copied by the controller is never overwritten",
+			namespace:   "team-a",
+			existing:    newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"own":{}}}`, nil),
+			wantErr:     true,
+			want:        newSecret("team-a", corev1.SecretTypeDockerConfigJson, `{"auths":{"own":{}}}`, nil),
+		},
+		{
+			description: "nothing is copied into the namespace of the source",
+			namespace:   source.Namespace,
+			want:        src,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := context.Background()
+
+			objs := []client.Object{src.DeepCopy()}
+			if tc.existing != nil {
+				objs = append(objs, tc.existing)
+			}
+
+			c := fake.NewClientBuilder().WithScheme(sc).WithObjects(objs...).Build()
+
+			err := replicateImagePullSecret(ctx, c, source, tc.namespace)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got corev1.Secret
+			if err := c.Get(ctx, types.NamespacedName{Namespace: tc.namespace, Name: source.Name}, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Type != tc.want.Type || !reflect.DeepEqual(got.Data, tc.want.Data) || !reflect.DeepEqual(got.Annotations, tc.want.Annotations) {
+				t.Errorf("unexpected secret: want type %s, data %s and annotations %v, got type %s, data %s and annotations %v",
+					tc.want.Type, tc.want.Data, tc.want.Annotations, got.Type, got.Data, got.Annotations)
+			}
+		})
+	}
+}
+
+func TestAttachImagePullSecret(t *testing.T) {
+	spec := corev1.PodSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "own"}}}
+
+	attachImagePullSecret(&spec, "registry")
+	attachImagePullSecret(&spec, "registry")
+
+	want := []corev1.LocalObjectReference{{Name: "own"}, {Name: "registry"}}
+	if !reflect.DeepEqual(spec.ImagePullSecrets, want) {
+		t.Errorf("unexpected image pull secrets: want %v, got %v", want, spec.ImagePullSecrets)
+	}
+}
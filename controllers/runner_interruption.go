@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultInterruptedJobRerunInterval = 1 * time.Minute
+
+	// DefaultInterruptedJobRerunWindow is how long after the last update of a failed workflow run
+	// it's considered for re-running.
+	DefaultInterruptedJobRerunWindow = 1 * time.Hour
+
+	// DefaultInterruptedJobMaxRunAttempts is the max number of attempts of a workflow run,
+	// so that a workflow run that keeps losing its runners isn't re-run forever.
+	DefaultInterruptedJobMaxRunAttempts = 3
+)
+
+// runnerLossMessages are the substrings of the annotations GitHub Actions adds to a job
+// when it failed because its runner was lost.
+var runnerLossMessages = []string{
+	// The self-hosted runner: NAME lost communication with the server. ...
+	"lost communication with the server",
+	// The runner has received a shutdown signal. ...
+	"received a shutdown signal",
+}
+
+// InterruptedJobRerunner periodically looks into the recently failed workflow runs of the repositories of
+// the runner pools with rerunInterruptedJobs enabled, and re-runs the failed jobs of a workflow run
+// when every failed job ran on a runner of such a runner pool and failed because the runner was lost,
+// like when the runner pod was evicted or its node was terminated on a spot instance interruption.
+//
+// A runner is considered to be of a runner pool when its name is prefixed with the name of the runner pool,
+// as the runner may already be gone along with its pod.
+// Workflow runs of organization and enterprise runners aren't re-run, as their repositories are unknown.
+type InterruptedJobRerunner struct {
+	client.Client
+	Log           logr.Logger
+	Recorder      record.EventRecorder
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	Interval       time.Duration
+	Window         time.Duration
+	MaxRunAttempts int
+	Namespace      string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader re-runs workflow runs.
+func (r *InterruptedJobRerunner) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (r *InterruptedJobRerunner) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultInterruptedJobRerunInterval
+	}
+
+	r.Log.Info("Starting interrupted job rerunner", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.rerunAll(ctx, time.Now()); err != nil {
+			r.Log.Error(err, "Failed to re-run interrupted jobs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// rerunPool is a runner pool of a repository, which may or may not have rerunInterruptedJobs enabled.
+type rerunPool struct {
+	object  client.Object
+	enabled bool
+}
+
+type rerunScope struct {
+	namespace   string
+	repo        string
+	credentials *v1alpha1.GitHubAPICredentialsFrom
+}
+
+func (r *InterruptedJobRerunner) rerunAll(ctx context.Context, now time.Time) error {
+	var opts []client.ListOption
+	if r.Namespace != "" {
+		opts = append(opts, client.InNamespace(r.Namespace))
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := r.List(ctx, &rds, opts...); err != nil {
+		return err
+	}
+
+	var runnerSets v1alpha1.RunnerSetList
+	if err := r.List(ctx, &runnerSets, opts...); err != nil {
+		return err
+	}
+
+	var scopes []rerunScope
+
+	// All the runner pools of the repositories are collected, so that a runner is attributed to the right runner pool
+	// even when the name of a runner pool with rerunInterruptedJobs enabled is a prefix of another.
+	pools := map[string][]rerunPool{}
+
+	add := func(obj client.Object, config v1alpha1.RunnerConfig) {
+		if config.Repository == "" {
+			return
+		}
+
+		pools[config.Repository] = append(pools[config.Repository], rerunPool{object: obj, enabled: config.RerunInterruptedJobs})
+
+		if !config.RerunInterruptedJobs {
+			return
+		}
+
+		scope := rerunScope{namespace: obj.GetNamespace(), repo: config.Repository, credentials: config.GitHubAPICredentialsFrom}
+
+		for _, s := range scopes {
+			if s.repo == scope.repo {
+				return
+			}
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	for i := range rds.Items {
+		rd := &rds.Items[i]
+		add(rd, rd.Spec.Template.Spec.RunnerConfig)
+	}
+
+	for i := range runnerSets.Items {
+		rs := &runnerSets.Items[i]
+		add(rs, rs.Spec.RunnerConfig)
+	}
+
+	for _, scope := range scopes {
+		log := r.Log.WithValues("repository", scope.repo)
+
+		ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, scope.namespace, scope.credentials)
+		if err != nil {
+			log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+			continue
+		}
+
+		if err := r.rerunRepository(ctx, log, now, ghc, scope.repo, pools[scope.repo]); err != nil {
+			log.Error(err, "Failed to re-run interrupted jobs of the repository")
+		}
+	}
+
+	return nil
+}
+
+func (r *InterruptedJobRerunner) rerunRepository(ctx context.Context, log logr.Logger, now time.Time, ghc *github.Client, repo string, pools []rerunPool) error {
+	owner, name, err := splitOwnerAndRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	runs, err := ghc.ListFailedWorkflowRuns(ctx, owner, name)
+	if err != nil {
+		return err
+	}
+
+	window := r.Window
+	if window <= 0 {
+		window = DefaultInterruptedJobRerunWindow
+	}
+
+	maxAttempts := r.MaxRunAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultInterruptedJobMaxRunAttempts
+	}
+
+	for _, run := range runs {
+		if run.GetUpdatedAt().Add(window).Before(now) || run.GetRunAttempt() >= maxAttempts {
+			continue
+		}
+
+		log := log.WithValues("run_id", run.GetID(), "run_attempt", run.GetRunAttempt())
+
+		jobs, err := ghc.ListWorkflowJobs(ctx, owner, name, run.GetID())
+		if err != nil {
+			log.Error(err, "Failed to list workflow jobs")
+			continue
+		}
+
+		interrupted, err := findInterruptedPools(ctx, ghc, owner, name, jobs, pools)
+		if err != nil {
+			log.Error(err, "Failed to determine if the workflow run was interrupted")
+			continue
+		}
+
+		if len(interrupted) == 0 {
+			log.V(2).Info("Skipped re-running the workflow run as not all of its failed jobs were interrupted by the loss of runners of runner pools with rerunInterruptedJobs enabled")
+			continue
+		}
+
+		if err := ghc.RerunFailedJobs(ctx, owner, name, run.GetID()); err != nil {
+			log.Error(err, "Failed to re-run the failed jobs of the interrupted workflow run")
+			continue
+		}
+
+		log.Info("Re-ran the failed jobs of the workflow run interrupted by the loss of runners")
+
+		for _, p := range interrupted {
+			r.Recorder.Eventf(p.object, corev1.EventTypeNormal, "InterruptedJobsRerun",
+				"Re-ran the failed jobs of the workflow run %s interrupted by the loss of runners (attempt %d)", run.GetHTMLURL(), run.GetRunAttempt())
+		}
+	}
+
+	return nil
+}
+
+// findInterruptedPools returns the runner pools whose runners were lost while running the failed jobs,
+// or nil when any of the failed jobs failed for another reason or ran on a runner of another runner pool.
+func findInterruptedPools(ctx context.Context, ghc *github.Client, owner, repo string, jobs []*github.WorkflowJob, pools []rerunPool) ([]rerunPool, error) {
+	var interrupted []rerunPool
+
+	seen := map[client.Object]bool{}
+
+	for _, job := range jobs {
+		if job.GetConclusion() != "failure" {
+			continue
+		}
+
+		pool := poolOfRunner(pools, job.GetRunnerName())
+		if pool == nil || !pool.enabled {
+			return nil, nil
+		}
+
+		annotations, err := ghc.ListWorkflowJobAnnotations(ctx, owner, repo, job)
+		if err != nil {
+			return nil, err
+		}
+
+		var lost bool
+
+		for _, a := range annotations {
+			if isRunnerLossMessage(a.GetMessage()) {
+				lost = true
+				break
+			}
+		}
+
+		if !lost {
+			return nil, nil
+		}
+
+		if !seen[pool.object] {
+			seen[pool.object] = true
+			interrupted = append(interrupted, *pool)
+		}
+	}
+
+	return interrupted, nil
+}
+
+// poolOfRunner returns the runner pool whose name is the longest prefix of the runner name,
+// so that runners of a runner pool named "foo-bar" aren't considered to be of a runner pool named "foo".
+func poolOfRunner(pools []rerunPool, runnerName string) *rerunPool {
+	var found *rerunPool
+
+	for i := range pools {
+		p := &pools[i]
+
+		if !strings.HasPrefix(runnerName, p.object.GetName()+"-") {
+			continue
+		}
+
+		if found == nil || len(p.object.GetName()) > len(found.object.GetName()) {
+			found = p
+		}
+	}
+
+	return found
+}
+
+func isRunnerLossMessage(message string) bool {
+	for _, m := range runnerLossMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+
+	return false
+}
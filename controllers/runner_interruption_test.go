@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPoolOfRunner(t *testing.T) {
+	newPool := func(name string) rerunPool {
+		return rerunPool{object: &v1alpha1.RunnerDeployment{ObjectMeta: metav1.ObjectMeta{Name: name}}, enabled: true}
+	}
+
+	pools := []rerunPool{newPool("example"), newPool("example-large"), newPool("other")}
+
+	testcases := []struct {
+		runner string
+		want   string
+	}{
+		{runner: "example-abcde-fghij", want: "example"},
+		{runner: "example-large-abcde-fghij", want: "example-large"},
+		{runner: "other-abcde-0", want: "other"},
+		{runner: "examples-abcde-fghij", want: ""},
+		{runner: "example", want: ""},
+		{runner: "", want: ""},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.runner, func(t *testing.T) {
+			var got string
+			if p := poolOfRunner(pools, tc.runner); p != nil {
+				got = p.object.GetName()
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected runner pool: want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInterruptedJobRerunner(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	const (
+		lost   = "The self-hosted runner: %s lost communication with the server. Verify the machine is running and has a healthy network connection."
+		failed = "Process completed with exit code 1."
+	)
+
+	type job struct {
+		runner     string
+		conclusion string
+		annotation string
+	}
+
+	runs := []struct {
+		id      int64
+		attempt int
+		updated time.Time
+		jobs    []job
+	}{
+		// Interrupted
+		{id: 1, attempt: 1, updated: now, jobs: []job{
+			{runner: "example-abcde-fghij", conclusion: "success"},
+			{runner: "example-abcde-klmno", conclusion: "failure", annotation: lost},
+		}},
+		// Failed for another reason
+		{id: 2, attempt: 1, updated: now, jobs: []job{
+			{runner: "example-abcde-fghij", conclusion: "failure", annotation: failed},
+		}},
+		// Interrupted along with another failure
+		{id: 3, attempt: 1, updated: now, jobs: []job{
+			{runner: "example-abcde-fghij", conclusion: "failure", annotation: lost},
+			{runner: "example-abcde-klmno", conclusion: "failure", annotation: failed},
+		}},
+		// Interrupted on a runner of a runner pool without rerunInterruptedJobs
+		{id: 4, attempt: 1, updated: now, jobs: []job{
+			{runner: "example-large-abcde-fghij", conclusion: "failure", annotation: lost},
+		}},
+		// Interrupted too many times
+		{id: 5, attempt: 3, updated: now, jobs: []job{
+			{runner: "example-abcde-fghij", conclusion: "failure", annotation: lost},
+		}},
+		// Interrupted long ago
+		{id: 6, attempt: 1, updated: now.Add(-2 * time.Hour), jobs: []job{
+			{runner: "example-abcde-fghij", conclusion: "failure", annotation: lost},
+		}},
+		// Interrupted on a runner of a RunnerSet
+		{id: 7, attempt: 2, updated: now, jobs: []job{
+			{runner: "example-set-abcde-0", conclusion: "failure", annotation: "The runner has received a shutdown signal. This can happen when the runner service is stopped, or a manually started runner is canceled."},
+		}},
+	}
+
+	var (
+		mu    sync.Mutex
+		reran []int64
+	)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/repos/test/valid/actions/runs", func(w http.ResponseWriter, req *http.Request) {
+		if s := req.URL.Query().Get("status"); s != "failure" {
+			t.Errorf("unexpected status query: %s", s)
+		}
+
+		body := `{"total_count": 0, "workflow_runs": [`
+		for i, r := range runs {
+			if i > 0 {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"id": %d, "run_attempt": %d, "status": "completed", "conclusion": "failure", "updated_at": %q}`, r.id, r.attempt, r.updated.UTC().Format(time.RFC3339))
+		}
+		body += `]}`
+
+		fmt.Fprint(w, body)
+	})
+
+	for _, r := range runs {
+		r := r
+
+		body := `{"jobs": [`
+		for i, j := range r.jobs {
+			if i > 0 {
+				body += ","
+			}
+
+			jobID := r.id*10 + int64(i)
+			body += fmt.Sprintf(`{"id": %d, "run_id": %d, "status": "completed", "conclusion": %q, "runner_name": %q, "check_run_url": "https://api.github.com/repos/test/valid/check-runs/%d"}`, jobID, r.id, j.conclusion, j.runner, jobID)
+
+			annotations := "[]"
+			if j.annotation != "" {
+				msg := j.annotation
+				if msg == lost {
+					msg = fmt.Sprintf(lost, j.runner)
+				}
+				annotations = fmt.Sprintf(`[{"path": ".github", "annotation_level": "failure", "message": %q}]`, msg)
+			}
+
+			mux.HandleFunc(fmt.Sprintf("/repos/test/valid/check-runs/%d/annotations", jobID), func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(w, annotations)
+			})
+		}
+		body += `]}`
+
+		mux.HandleFunc(fmt.Sprintf("/repos/test/valid/actions/runs/%d/jobs", r.id), func(w http.ResponseWriter, req *http.Request) {
+			if f := req.URL.Query().Get("filter"); f != "latest" {
+				t.Errorf("unexpected filter query: %s", f)
+			}
+
+			fmt.Fprint(w, body)
+		})
+
+		mux.HandleFunc(fmt.Sprintf("/repos/test/valid/actions/runs/%d/rerun-failed-jobs", r.id), func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost {
+				t.Errorf("unexpected method: %s", req.Method)
+			}
+
+			mu.Lock()
+			reran = append(reran, r.id)
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusCreated)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	newRD := func(name string, rerun bool) *v1alpha1.RunnerDeployment {
+		return &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{
+						RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid", RerunInterruptedJobs: rerun},
+					},
+				},
+			},
+		}
+	}
+
+	rs := &v1alpha1.RunnerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-set", Namespace: "default"},
+		Spec: v1alpha1.RunnerSetSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid", RerunInterruptedJobs: true},
+		},
+	}
+
+	c := clientfake.NewFakeClientWithScheme(sc, newRD("example", true), newRD("example-large", false), rs)
+
+	recorder := record.NewFakeRecorder(10)
+
+	r := &InterruptedJobRerunner{
+		Client:       c,
+		Log:          logr.Discard(),
+		Recorder:     recorder,
+		GitHubClient: newGithubClient(server),
+	}
+
+	if err := r.rerunAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Slice(reran, func(i, j int) bool { return reran[i] < reran[j] })
+
+	if fmt.Sprint(reran) != fmt.Sprint([]int64{1, 7}) {
+		t.Errorf("unexpected re-run workflow runs: want [1 7], got %v", reran)
+	}
+
+	if n := len(recorder.Events); n != 2 {
+		t.Errorf("unexpected number of events: want 2, got %d", n)
+	}
+}
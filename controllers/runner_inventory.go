@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RunnerInventory serves the list of all the runners managed by the controller as JSON or CSV,
+// for audits and capacity reviews.
+//
+// The inventory is compiled from the controller's informer caches, plus a single GitHub API call per
+// registration scope (enterprise, organization, or repository) to obtain the busy status, which is
+// usually served from the GitHub API cache.
+//
+// Requests must have the `Authorization: Bearer TOKEN` header whose TOKEN matches Token.
+type RunnerInventory struct {
+	client.Client
+	GitHubClient *github.Client
+	Log          logr.Logger
+
+	// Token is the bearer token required to access the inventory.
+	// The inventory is never served when this is empty.
+	Token string
+
+	Namespace string
+
+	now func() time.Time
+}
+
+// RunnerInventoryEntry is a runner in the inventory.
+type RunnerInventoryEntry struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Pool is the runner pool that manages the runner, in the KIND/NAME format like `RunnerDeployment/example`.
+	Pool string `json:"pool"`
+	Node string `json:"node"`
+	// Phase is the phase of the runner pod.
+	Phase string `json:"phase"`
+
+	Enterprise   string `json:"enterprise,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+
+	// Registered and Busy are nil when the controller failed to fetch runners from GitHub.
+	Registered *bool `json:"registered"`
+	Busy       *bool `json:"busy"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	Age       string    `json:"age"`
+}
+
+var runnerInventoryCSVHeader = []string{
+	"name", "namespace", "pool", "node", "phase", "enterprise", "organization", "repository", "registered", "busy", "createdAt", "age",
+}
+
+func (i *RunnerInventory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !hasBearerToken(r, i.Token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	entries, err := i.List(r.Context())
+	if err != nil {
+		i.Log.Error(err, "Failed to compile runner inventory")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+
+	switch format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			i.Log.Error(err, "Failed writing runner inventory")
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+
+		if err := writeRunnerInventoryCSV(w, entries); err != nil {
+			i.Log.Error(err, "Failed writing runner inventory")
+		}
+	default:
+		http.Error(w, "unsupported format "+strconv.Quote(format)+": it must be either json or csv", http.StatusBadRequest)
+	}
+}
+
+type runnerScope struct {
+	enterprise, org, repo string
+}
+
+// List compiles the runner inventory.
+func (i *RunnerInventory) List(ctx context.Context) ([]RunnerInventoryEntry, error) {
+	var opts []client.ListOption
+	if i.Namespace != "" {
+		opts = append(opts, client.InNamespace(i.Namespace))
+	}
+
+	var pods corev1.PodList
+	if err := i.Client.List(ctx, &pods, append(opts, client.HasLabels{LabelKeyRunnerSetName})...); err != nil {
+		return nil, err
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := i.Client.List(ctx, &runners, opts...); err != nil {
+		return nil, err
+	}
+
+	runnersByKey := map[types.NamespacedName]v1alpha1.Runner{}
+	for _, r := range runners.Items {
+		runnersByKey[types.NamespacedName{Namespace: r.Namespace, Name: r.Name}] = r
+	}
+
+	now := time.Now
+	if i.now != nil {
+		now = i.now
+	}
+
+	var (
+		entries []RunnerInventoryEntry
+		scopes  []runnerScope
+	)
+
+	for _, pod := range pods.Items {
+		var scope runnerScope
+
+		for _, c := range pod.Spec.Containers {
+			if c.Name == containerName {
+				scope.enterprise, _ = getEnv(&c, EnvVarEnterprise)
+				scope.org, _ = getEnv(&c, EnvVarOrg)
+				scope.repo, _ = getEnv(&c, EnvVarRepo)
+			}
+		}
+
+		scopes = append(scopes, scope)
+
+		entries = append(entries, RunnerInventoryEntry{
+			Name:         pod.Name,
+			Namespace:    pod.Namespace,
+			Pool:         runnerPool(pod, runnersByKey),
+			Node:         pod.Spec.NodeName,
+			Phase:        string(pod.Status.Phase),
+			Enterprise:   scope.enterprise,
+			Organization: scope.org,
+			Repository:   scope.repo,
+			CreatedAt:    pod.CreationTimestamp.Time,
+			Age:          now().Sub(pod.CreationTimestamp.Time).Round(time.Second).String(),
+		})
+	}
+
+	registered := map[runnerScope]map[string]*gogithub.Runner{}
+
+	for idx := range entries {
+		scope := scopes[idx]
+
+		byName, ok := registered[scope]
+		if !ok {
+			ghRunners, err := i.GitHubClient.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+			if err != nil {
+				i.Log.Error(err, "Failed to list runners on GitHub", "enterprise", scope.enterprise, "organization", scope.org, "repository", scope.repo)
+			} else {
+				byName = map[string]*gogithub.Runner{}
+				for _, r := range ghRunners {
+					byName[r.GetName()] = r
+				}
+			}
+
+			registered[scope] = byName
+		}
+
+		if byName == nil {
+			continue
+		}
+
+		r, isRegistered := byName[entries[idx].Name]
+		busy := isRegistered && r.GetBusy()
+
+		entries[idx].Registered = &isRegistered
+		entries[idx].Busy = &busy
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Namespace != entries[b].Namespace {
+			return entries[a].Namespace < entries[b].Namespace
+		}
+		return entries[a].Name < entries[b].Name
+	})
+
+	return entries, nil
+}
+
+// runnerPool returns the runner pool that manages the runner pod in the KIND/NAME format.
+func runnerPool(pod corev1.Pod, runners map[types.NamespacedName]v1alpha1.Runner) string {
+	for _, ref := range pod.OwnerReferences {
+		switch ref.Kind {
+		case "StatefulSet":
+			return "RunnerSet/" + pod.Labels[LabelKeyRunnerSetName]
+		case "Runner":
+			runner, ok := runners[types.NamespacedName{Namespace: pod.Namespace, Name: ref.Name}]
+			if !ok {
+				return "Runner/" + ref.Name
+			}
+
+			if rd := runner.Labels[LabelKeyRunnerDeploymentName]; rd != "" {
+				return "RunnerDeployment/" + rd
+			}
+
+			for _, runnerRef := range runner.OwnerReferences {
+				if runnerRef.Kind == "RunnerReplicaSet" {
+					return "RunnerReplicaSet/" + runnerRef.Name
+				}
+			}
+
+			return "Runner/" + runner.Name
+		}
+	}
+
+	return ""
+}
+
+func writeRunnerInventoryCSV(w io.Writer, entries []RunnerInventoryEntry) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(runnerInventoryCSVHeader); err != nil {
+		return err
+	}
+
+	optionalBool := func(b *bool) string {
+		if b == nil {
+			return ""
+		}
+		return strconv.FormatBool(*b)
+	}
+
+	for _, e := range entries {
+		record := []string{
+			e.Name,
+			e.Namespace,
+			e.Pool,
+			e.Node,
+			e.Phase,
+			e.Enterprise,
+			e.Organization,
+			e.Repository,
+			optionalBool(e.Registered),
+			optionalBool(e.Busy),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.Age,
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	ghfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerInventory(t *testing.T) {
+	created := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	runnerPod := func(name string, owner metav1.OwnerReference, labels map[string]string) *corev1.Pod {
+		l := map[string]string{LabelKeyRunnerSetName: name}
+		for k, v := range labels {
+			l[k] = v
+		}
+
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              name,
+				Labels:            l,
+				OwnerReferences:   []metav1.OwnerReference{owner},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "node1",
+				Containers: []corev1.Container{
+					{
+						Name: "runner",
+						Env: []corev1.EnvVar{
+							{Name: EnvVarEnterprise, Value: ""},
+							{Name: EnvVarOrg, Value: ""},
+							{Name: EnvVarRepo, Value: "test/valid"},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	objs := []runtime.Object{
+		&v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "rd-runner",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example-rd"},
+			},
+		},
+		runnerPod("rd-runner", metav1.OwnerReference{Kind: "Runner", Name: "rd-runner"}, nil),
+		runnerPod("rs-runner-0", metav1.OwnerReference{Kind: "StatefulSet", Name: "example-rs-abcde"}, map[string]string{LabelKeyRunnerSetName: "example-rs"}),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unrelated"}},
+	}
+
+	runners := ghfake.NewRunnersList()
+	runners.Add(&github.Runner{ID: github.Int64(1), Name: github.String("rd-runner"), Busy: github.Bool(true)})
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	inventory := &RunnerInventory{
+		Client:       fake.NewFakeClientWithScheme(sc, objs...),
+		GitHubClient: newGithubClient(server),
+		Log:          logr.Discard(),
+		Token:        "secret",
+		now:          func() time.Time { return created.Add(90 * time.Minute) },
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/runners", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+
+		rec := httptest.NewRecorder()
+		inventory.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status: %d", rec.Code)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/runners?format=csv", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+		inventory.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		want := strings.Join([]string{
+			"name,namespace,pool,node,phase,enterprise,organization,repository,registered,busy,createdAt,age",
+			"rd-runner,default,RunnerDeployment/example-rd,node1,Running,,,test/valid,true,true,2022-03-01T10:00:00Z,1h30m0s",
+			"rs-runner-0,default,RunnerSet/example-rs,node1,Running,,,test/valid,false,false,2022-03-01T10:00:00Z,1h30m0s",
+			"",
+		}, "\n")
+
+		if got := rec.Body.String(); got != want {
+			t.Errorf("unexpected csv:\nwant:\n%s\ngot:\n%s", want, got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/runners", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+		inventory.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if !bytes.Contains(rec.Body.Bytes(), []byte(`"pool":"RunnerDeployment/example-rd"`)) {
+			t.Errorf("unexpected json: %s", rec.Body.String())
+		}
+	})
+}
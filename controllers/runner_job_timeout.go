@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultJobTimeoutLabelPrefix = "timeout-"
+	DefaultJobTimeoutGrace       = 5 * time.Minute
+)
+
+// ensureJobDeadline sets activeDeadlineSeconds of the ephemeral runner pod by the timeout of the job the runner picked up,
+// so that Kubernetes stops the pod once the job exceeds the timeout even if the runner hangs.
+//
+// The deadline counts from the start of the pod, so the time the runner waited for the job is added to the timeout.
+// As Kubernetes allows activeDeadlineSeconds to be only shortened, the pod is never given a later deadline than it already has.
+func (r *RunnerReconciler) ensureJobDeadline(ctx context.Context, runner v1alpha1.Runner, pod *corev1.Pod, log logr.Logger) error {
+	timeouts := runner.Spec.JobTimeouts
+	if timeouts == nil || (runner.Spec.Ephemeral != nil && !*runner.Spec.Ephemeral) {
+		return nil
+	}
+
+	if pod.Status.StartTime == nil || !pod.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	deadline, ok := jobDeadlineSeconds(timeouts, runner.Spec.Labels, runner.Status, pod.Status.StartTime.Time)
+	if !ok {
+		return nil
+	}
+
+	if current := pod.Spec.ActiveDeadlineSeconds; current != nil && *current <= deadline {
+		return nil
+	}
+
+	updated := pod.DeepCopy()
+	updated.Spec.ActiveDeadlineSeconds = &deadline
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(pod)); err != nil {
+		return fmt.Errorf("setting active deadline of runner pod: %w", err)
+	}
+
+	log.Info("Set the active deadline of the runner pod by the job timeout", "activeDeadlineSeconds", deadline)
+
+	r.Recorder.Event(&runner, corev1.EventTypeNormal, "JobDeadlineSet", fmt.Sprintf("Set activeDeadlineSeconds of the runner pod to %d by the job timeout", deadline))
+
+	return nil
+}
+
+// jobDeadlineSeconds returns activeDeadlineSeconds of the runner pod started at podStartedAt, which is the time from the start of the pod
+// until the job the runner picked up times out, plus the grace.
+// It returns false when the runner isn't known to be running a job since the start of the pod, or the job has no timeout.
+func jobDeadlineSeconds(timeouts *v1alpha1.JobTimeouts, labels []string, st v1alpha1.RunnerStatus, podStartedAt time.Time) (int64, bool) {
+	pickedUpAt, job := jobPickedUpAt(st, podStartedAt)
+	if pickedUpAt.IsZero() {
+		return 0, false
+	}
+
+	timeout, ok := jobTimeout(timeouts, labels, job)
+	if !ok {
+		return 0, false
+	}
+
+	grace := DefaultJobTimeoutGrace
+	if timeouts.Grace != nil {
+		grace = timeouts.Grace.Duration
+	}
+
+	return int64(math.Ceil((pickedUpAt.Sub(podStartedAt) + timeout + grace).Seconds())), true
+}
+
+// jobPickedUpAt returns the time the runner picked up the job it's running since the start of its pod, along with the job if it's known.
+// The job is known when the pickup was recorded on the workflow_job webhook event.
+// Otherwise the runner utilization sample that first observed the runner busy is taken as the pickup time,
+// which is later than the actual one by up to the sampling interval.
+func jobPickedUpAt(st v1alpha1.RunnerStatus, podStartedAt time.Time) (time.Time, *v1alpha1.RunnerJob) {
+	if len(st.RecentJobs) > 0 && !st.RecentJobs[0].StartedAt.Time.Before(podStartedAt) {
+		return st.RecentJobs[0].StartedAt.Time, &st.RecentJobs[0]
+	}
+
+	if u := st.Utilization; st.Busy && u != nil && u.LastSampleTime != nil && !u.LastSampleTime.Time.Before(podStartedAt) {
+		return u.LastSampleTime.Time, nil
+	}
+
+	return time.Time{}, nil
+}
+
+// jobTimeout returns the timeout of the first of the timeouts.Jobs whose name pattern matches the job name,
+// or the one advertised by the runner label if none matches.
+func jobTimeout(timeouts *v1alpha1.JobTimeouts, labels []string, job *v1alpha1.RunnerJob) (time.Duration, bool) {
+	if job != nil {
+		for _, j := range timeouts.Jobs {
+			// Invalid patterns are rejected by the validating webhook.
+			if pattern, err := regexp.Compile(j.NamePattern); err == nil && pattern.MatchString(job.Name) {
+				return j.Timeout.Duration, true
+			}
+		}
+	}
+
+	prefix := timeouts.LabelPrefix
+	if prefix == "" {
+		prefix = DefaultJobTimeoutLabelPrefix
+	}
+
+	for _, l := range labels {
+		if !strings.HasPrefix(l, prefix) {
+			continue
+		}
+
+		if d, err := time.ParseDuration(strings.TrimPrefix(l, prefix)); err == nil && d > 0 {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
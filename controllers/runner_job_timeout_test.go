@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestJobDeadlineSeconds(t *testing.T) {
+	podStartedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	pickedUpAt := metav1.NewTime(podStartedAt.Add(10 * time.Minute))
+
+	jobs := []v1alpha1.JobTimeout{
+		{NamePattern: "^e2e", Timeout: metav1.Duration{Duration: 2 * time.Hour}},
+	}
+
+	testcases := []struct {
+		description string
+		timeouts    v1alpha1.JobTimeouts
+		labels      []string
+		status      v1alpha1.RunnerStatus
+		want        int64
+		wantOK      bool
+	}{
+		{
+			description: "idle runner",
+			labels:      []string{"timeout-30m"},
+		},
+		{
+			description: "runner label",
+			labels:      []string{"linux", "timeout-30m"},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "build", StartedAt: pickedUpAt}}},
+			want:        int64((10 + 30 + 5) * 60),
+			wantOK:      true,
+		},
+		{
+			description: "custom label prefix and grace",
+			timeouts:    v1alpha1.JobTimeouts{LabelPrefix: "max-", Grace: &metav1.Duration{Duration: time.Minute}},
+			labels:      []string{"timeout-30m", "max-1h"},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "build", StartedAt: pickedUpAt}}},
+			want:        int64((10 + 60 + 1) * 60),
+			wantOK:      true,
+		},
+		{
+			description: "job name pattern takes precedence over the label",
+			timeouts:    v1alpha1.JobTimeouts{Jobs: jobs},
+			labels:      []string{"timeout-30m"},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "e2e-tests", StartedAt: pickedUpAt}}},
+			want:        int64((10 + 120 + 5) * 60),
+			wantOK:      true,
+		},
+		{
+			description: "job name pattern not matching without label",
+			timeouts:    v1alpha1.JobTimeouts{Jobs: jobs},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "build", StartedAt: pickedUpAt}}},
+		},
+		{
+			description: "job picked up by the previous pod",
+			labels:      []string{"timeout-30m"},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "build", StartedAt: metav1.NewTime(podStartedAt.Add(-time.Minute))}}},
+		},
+		{
+			description: "busy runner observed by the utilization sampling",
+			labels:      []string{"timeout-30m"},
+			status: v1alpha1.RunnerStatus{
+				Busy:        true,
+				Utilization: &v1alpha1.RunnerUtilization{LastSampleTime: &pickedUpAt},
+			},
+			want:   int64((10 + 30 + 5) * 60),
+			wantOK: true,
+		},
+		{
+			description: "invalid label",
+			labels:      []string{"timeout-forever"},
+			status:      v1alpha1.RunnerStatus{RecentJobs: []v1alpha1.RunnerJob{{Name: "build", StartedAt: pickedUpAt}}},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, ok := jobDeadlineSeconds(&tc.timeouts, tc.labels, tc.status, podStartedAt)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("want %d (%v), got %d (%v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestEnsureJobDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	pickedUpAt := metav1.NewTime(startedAt.Add(10 * time.Minute))
+
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{
+				Repository:  "test/valid",
+				Labels:      []string{"timeout-30m"},
+				JobTimeouts: &v1alpha1.JobTimeouts{},
+			},
+		},
+		Status: v1alpha1.RunnerStatus{
+			RecentJobs: []v1alpha1.RunnerJob{{Repository: "test/valid", Name: "build", StartedAt: pickedUpAt}},
+		},
+	}
+
+	testcases := []struct {
+		description string
+		persistent  bool
+		// current and want are activeDeadlineSeconds of the pod, where zero means unset.
+		current int64
+		want    int64
+	}{
+		{
+			description: "no deadline",
+			want:        45 * 60,
+		},
+		{
+			description: "later deadline",
+			current:     60 * 60,
+			want:        45 * 60,
+		},
+		{
+			description: "earlier deadline",
+			current:     30 * 60,
+			want:        30 * 60,
+		},
+		{
+			description: "persistent runner",
+			persistent:  true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &startedAt},
+			}
+			if tc.current != 0 {
+				pod.Spec.ActiveDeadlineSeconds = &tc.current
+			}
+
+			r := &RunnerReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(sc).WithObjects(pod).Build(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			runner := *runner.DeepCopy()
+			if tc.persistent {
+				ephemeral := false
+				runner.Spec.Ephemeral = &ephemeral
+			}
+
+			if err := r.ensureJobDeadline(ctx, runner, pod, logr.Discard()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got corev1.Pod
+			if err := r.Get(ctx, client.ObjectKeyFromObject(pod), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			var deadline int64
+			if got.Spec.ActiveDeadlineSeconds != nil {
+				deadline = *got.Spec.ActiveDeadlineSeconds
+			}
+
+			if deadline != tc.want {
+				t.Errorf("unexpected activeDeadlineSeconds: want %d, got %d", tc.want, deadline)
+			}
+		})
+	}
+}
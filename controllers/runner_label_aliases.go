@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RunnerLabelAliases maps the old runner labels to the new ones, so that a runner label can be renamed across the organization gradually.
+// While the workflows and the runner pools migrate, the old and the new labels are considered the same label
+// on matching the workflow jobs to the runner pools, both in the metrics and the webhook-based autoscaler.
+type RunnerLabelAliases map[string]string
+
+// ParseRunnerLabelAliases parses the aliases in the OLD=NEW format.
+// An alias can't be renamed again, i.e. no new label can be the old label of another alias.
+func ParseRunnerLabelAliases(values []string) (RunnerLabelAliases, error) {
+	aliases := RunnerLabelAliases{}
+
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("runner label alias %q must be in the OLD=NEW format", v)
+		}
+
+		if kv[0] == kv[1] {
+			return nil, fmt.Errorf("runner label alias %q renames the label to itself", v)
+		}
+
+		if n, ok := aliases[kv[0]]; ok && n != kv[1] {
+			return nil, fmt.Errorf("runner label %q is renamed to both %q and %q", kv[0], n, kv[1])
+		}
+
+		aliases[kv[0]] = kv[1]
+	}
+
+	for old, n := range aliases {
+		if _, ok := aliases[n]; ok {
+			return nil, fmt.Errorf("runner label %q is renamed to %q, which is renamed again", old, n)
+		}
+	}
+
+	return aliases, nil
+}
+
+// canonicalize returns the labels with the old labels replaced by the new ones, without the duplicates, in the original order.
+// The labels are returned as is when there are no aliases.
+func (a RunnerLabelAliases) canonicalize(labels []string) []string {
+	if len(a) == 0 {
+		return labels
+	}
+
+	res := make([]string, 0, len(labels))
+	seen := make(map[string]struct{}, len(labels))
+
+	for _, l := range labels {
+		if n, ok := a[l]; ok {
+			l = n
+		}
+
+		if _, ok := seen[l]; ok {
+			continue
+		}
+
+		seen[l] = struct{}{}
+		res = append(res, l)
+	}
+
+	return res
+}
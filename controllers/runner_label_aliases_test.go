@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/google/go-cmp/cmp"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+func TestParseRunnerLabelAliases(t *testing.T) {
+	aliases, err := ParseRunnerLabelAliases([]string{"ubuntu=linux-x64", "big=large", "big=large"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := cmp.Diff(RunnerLabelAliases{"ubuntu": "linux-x64", "big": "large"}, aliases); d != "" {
+		t.Errorf("unexpected aliases (-want +got):\n%s", d)
+	}
+
+	for _, invalid := range [][]string{
+		{"ubuntu"},
+		{"=linux-x64"},
+		{"ubuntu="},
+		{"ubuntu=ubuntu"},
+		{"big=large", "big=xlarge"},
+		{"big=large", "large=xlarge"},
+	} {
+		if _, err := ParseRunnerLabelAliases(invalid); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
+
+func TestRunnerLabelAliases_Canonicalize(t *testing.T) {
+	aliases := RunnerLabelAliases{"ubuntu": "linux-x64"}
+
+	got := aliases.canonicalize([]string{"self-hosted", "ubuntu", "gpu", "linux-x64"})
+
+	if d := cmp.Diff([]string{"self-hosted", "linux-x64", "gpu"}, got); d != "" {
+		t.Errorf("unexpected labels (-want +got):\n%s", d)
+	}
+
+	labels := []string{"ubuntu"}
+
+	if got := RunnerLabelAliases(nil).canonicalize(labels); len(got) != 1 || got[0] != "ubuntu" {
+		t.Errorf("unexpected labels without aliases: %v", got)
+	}
+}
+
+func TestMatchWorkflowJobs_LabelAliases(t *testing.T) {
+	job := func(id int64, labels ...string) *github.WorkflowJob {
+		return &github.WorkflowJob{WorkflowJob: gogithub.WorkflowJob{ID: gogithub.Int64(id), Labels: labels}}
+	}
+
+	jobs := []*github.WorkflowJob{
+		job(1, "self-hosted", "ubuntu"),
+		job(2, "self-hosted", "linux-x64"),
+		job(3, "self-hosted", "gpu"),
+	}
+
+	aliases := RunnerLabelAliases{"ubuntu": "linux-x64"}
+
+	// The runner pool advertises both the old and the new labels while the workflows migrate.
+	runnerLabels := aliases.canonicalize([]string{"ubuntu", "linux-x64"})
+
+	var got []int64
+	for _, j := range matchWorkflowJobs(jobs, runnerLabels, nil, "", aliases) {
+		got = append(got, j.GetID())
+	}
+
+	if d := cmp.Diff([]int64{1, 2}, got); d != "" {
+		t.Errorf("unexpected matched jobs (-want +got):\n%s", d)
+	}
+
+	if matched := matchWorkflowJobs(jobs, []string{"ubuntu", "linux-x64"}, nil, "", nil); len(matched) != 0 {
+		t.Errorf("unexpected matched jobs without aliases: %d", len(matched))
+	}
+
+	extra, ok := matchJobLabels(aliases.canonicalize([]string{"self-hosted", "ubuntu"}), runnerLabels, nil)
+	if !ok || extra != 0 {
+		t.Errorf("unexpected match of the job with the old label: want (0, true), got (%d, %v)", extra, ok)
+	}
+}
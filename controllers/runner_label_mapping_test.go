@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewPod_LabelMappingsMergedIntoRunnerSpec(t *testing.T) {
+	mappings, err := labelmapping.Parse([]byte(`labels:
+  gpu:
+    nodeSelector:
+      acc: nvidia
+      pool: gpu
+    tolerations:
+    - key: nvidia.com/gpu
+      operator: Exists
+      effect: NoSchedule
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciToleration := corev1.Toleration{Key: "ci", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				Labels:     []string{"gpu"},
+			},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				NodeSelector: map[string]string{"pool": "ci"},
+				Tolerations:  []corev1.Toleration{ciToleration},
+			},
+		},
+	}
+
+	r := &RunnerReconciler{
+		RunnerImage:   "default-runner-image",
+		DockerImage:   "default-docker-image",
+		GitHubClient:  &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:        sc,
+		LabelMappings: mappings,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The node selector key set in the runner spec takes precedence over the mapped one
+	wantNodeSelector := map[string]string{"pool": "ci", "acc": "nvidia"}
+	if d := cmp.Diff(wantNodeSelector, pod.Spec.NodeSelector); d != "" {
+		t.Errorf("unexpected node selector (-want +got):\n%s", d)
+	}
+
+	wantTolerations := []corev1.Toleration{
+		ciToleration,
+		{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	if d := cmp.Diff(wantTolerations, pod.Spec.Tolerations); d != "" {
+		t.Errorf("unexpected tolerations (-want +got):\n%s", d)
+	}
+
+	if d := cmp.Diff(map[string]string{"pool": "ci"}, runner.Spec.NodeSelector); d != "" {
+		t.Errorf("the node selector of the runner spec was modified (-want +got):\n%s", d)
+	}
+
+	if len(runner.Spec.Tolerations) != 1 {
+		t.Errorf("the tolerations of the runner spec were modified: %+v", runner.Spec.Tolerations)
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxRunnerNameAttempts is the number of the names generated for a new runner until one isn't taken by another runner.
+	// The runner is named by the API server from its generateName like before once all of them are taken.
+	maxRunnerNameAttempts = 5
+
+	// runnerNameRandomLength and maxRunnerNameBaseLength are the same as the ones of the generateName of the API server.
+	runnerNameRandomLength  = 5
+	maxRunnerNameBaseLength = 63 - runnerNameRandomLength
+)
+
+// generateRunnerName appends a random suffix to the base like the generateName of the API server.
+func generateRunnerName(base string) string {
+	if len(base) > maxRunnerNameBaseLength {
+		base = base[:maxRunnerNameBaseLength]
+	}
+
+	return base + utilrand.String(runnerNameRandomLength)
+}
+
+// runnerNameIndex is the set of the names a new runner must not take.
+type runnerNameIndex map[string]struct{}
+
+func (i runnerNameIndex) add(name string) {
+	i[name] = struct{}{}
+}
+
+func (i runnerNameIndex) has(name string) bool {
+	_, ok := i[name]
+	return ok
+}
+
+// runnerNameIndex indexes the names of the runners in all the namespaces, including the runner pods of RunnerSets,
+// and the runners registered to the same registration scope as the runners of the runnerreplicaset on GitHub.
+//
+// The names generated by the API server are only unique within a namespace, and GitHub silently replaces the registration
+// of the runner with the same name, which leaves one of the runners unable to run any job.
+// The registered runners are indexed on a best-effort basis, as the index of the Runners alone covers the most of the collisions.
+func (r *RunnerReplicaSetReconciler) runnerNameIndex(ctx context.Context, log logr.Logger, rs v1alpha1.RunnerReplicaSet) (runnerNameIndex, error) {
+	index := runnerNameIndex{}
+
+	var runners v1alpha1.RunnerList
+	if err := r.List(ctx, &runners); err != nil {
+		return nil, fmt.Errorf("listing runners to index their names: %w", err)
+	}
+
+	for _, runner := range runners.Items {
+		index.add(runner.Name)
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.HasLabels{LabelKeyRunnerSetName}); err != nil {
+		return nil, fmt.Errorf("listing runnerset pods to index their names: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		index.add(pod.Name)
+	}
+
+	if r.GitHubClient == nil {
+		return index, nil
+	}
+
+	config := rs.Spec.Template.Spec.RunnerConfig
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rs.Namespace, config.GitHubAPICredentialsFrom)
+	if err != nil {
+		log.V(1).Info("Could not get the GitHub client to index the names of the registered runners", "error", err.Error())
+		return index, nil
+	}
+
+	registered, err := ghc.ListRunners(ctx, config.Enterprise, config.Organization, config.Repository)
+	if err != nil {
+		log.V(1).Info("Could not list runners to index the names of the registered runners", "error", err.Error())
+		return index, nil
+	}
+
+	for _, runner := range registered {
+		index.add(runner.GetName())
+	}
+
+	return index, nil
+}
+
+// newUniquelyNamedRunnerFactory wraps the factory of the runners of the runnerreplicaset to name each new runner
+// so that no other runner in the cluster or on GitHub has the same name.
+// The name is regenerated with a RunnerNameCollision event when the generated name is already taken.
+func (r *RunnerReplicaSetReconciler) newUniquelyNamedRunnerFactory(ctx context.Context, log logr.Logger, rs v1alpha1.RunnerReplicaSet, create func() client.Object) func() client.Object {
+	generate := r.generateRunnerName
+	if generate == nil {
+		generate = generateRunnerName
+	}
+
+	var index runnerNameIndex
+
+	return func() client.Object {
+		runner := create().(*v1alpha1.Runner)
+
+		if runner.Name != "" || runner.GenerateName == "" {
+			return runner
+		}
+
+		// The index is built on the first runner created in the reconciliation, so that it costs nothing unless scaling up.
+		if index == nil {
+			var err error
+
+			index, err = r.runnerNameIndex(ctx, log, rs)
+			if err != nil {
+				log.Error(err, "Failed to index runner names. Leaving the runner to be named by the API server")
+
+				return runner
+			}
+		}
+
+		for i := 0; i < maxRunnerNameAttempts; i++ {
+			name := generate(runner.GenerateName)
+
+			if !index.has(name) {
+				index.add(name)
+				runner.Name = name
+
+				return runner
+			}
+
+			log.Info("Regenerating the runner name already taken by another runner", "name", name)
+
+			r.Recorder.Event(&rs, corev1.EventTypeWarning, "RunnerNameCollision", fmt.Sprintf("Runner name '%s' is already taken by another runner in the cluster or on GitHub. Regenerating the name", name))
+		}
+
+		return runner
+	}
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewUniquelyNamedRunnerFactory(t *testing.T) {
+	server := fake.NewServer(fake.WithListRunnersResponse(200, fake.RunnersListBody))
+	defer server.Close()
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "example-aaaaa"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "third", Name: "example-bbbbb", Labels: map[string]string{LabelKeyRunnerSetName: "example"}}},
+	).Build()
+
+	// The names taken by the runner in another namespace, the runnerset pod and the runner registered on GitHub are regenerated,
+	// and so is the one taken by the runner created earlier in the same reconciliation.
+	names := []string{"example-aaaaa", "example-bbbbb", "test1", "example-ccccc", "example-ccccc", "example-ddddd"}
+
+	recorder := record.NewFakeRecorder(10)
+
+	r := &RunnerReplicaSetReconciler{
+		Client:       c,
+		Log:          logr.Discard(),
+		Recorder:     recorder,
+		GitHubClient: newGithubClient(server),
+		generateRunnerName: func(base string) string {
+			name := names[0]
+			names = names[1:]
+			return name
+		},
+	}
+
+	rs := v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: v1alpha1.RunnerReplicaSetSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+			},
+		},
+	}
+
+	create := r.newUniquelyNamedRunnerFactory(context.Background(), logr.Discard(), rs, func() client.Object {
+		return &v1alpha1.Runner{ObjectMeta: metav1.ObjectMeta{Namespace: "default", GenerateName: "example-"}}
+	})
+
+	for _, want := range []string{"example-ccccc", "example-ddddd"} {
+		if got := create().GetName(); got != want {
+			t.Errorf("unexpected runner name: want %s, got %s", want, got)
+		}
+	}
+
+	if got := len(recorder.Events); got != 4 {
+		t.Errorf("unexpected number of RunnerNameCollision events: want 4, got %d", got)
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/hash"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// LabelKeyRunnerName is the label of the runner pods with services, whose value is the name of the runner,
+	// so that the services of the runner select its pod.
+	LabelKeyRunnerName = "runner-name"
+)
+
+// +kubebuilder:rbac:groups=core,resources=services,verbs=create
+
+// applyRunnerNetworkExposure runs the runner pod in the host network and exposes the host ports of the runner container, if any,
+// and labels the pod to be selected by the services of the runner.
+func applyRunnerNetworkExposure(pod *corev1.Pod, runnerName string, runnerSpec v1alpha1.RunnerPodSpec) {
+	if runnerSpec.HostNetwork {
+		pod.Spec.HostNetwork = true
+
+		// Without it the runner pod resolves names with the DNS of the node instead of the cluster
+		if runnerSpec.DnsPolicy == "" {
+			pod.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+		}
+	}
+
+	if len(runnerSpec.HostPorts) > 0 {
+		for i := range pod.Spec.Containers {
+			c := &pod.Spec.Containers[i]
+
+			if c.Name != containerName {
+				continue
+			}
+
+			for _, p := range runnerSpec.HostPorts {
+				if p.HostPort == 0 {
+					p.HostPort = p.ContainerPort
+				}
+
+				c.Ports = append(c.Ports, p)
+			}
+		}
+	}
+
+	if len(runnerSpec.Services) > 0 {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+
+		pod.Labels[LabelKeyRunnerName] = runnerName
+	}
+}
+
+// ensureRunnerServices creates the services of the runner pointing at its pod, which are owned by the runner
+// so that they are garbage-collected along with it.
+// It's called before the runner pod is created, so that a failure is retried on the next attempt to create the pod.
+// The existing services are left as-is, as the runners of runner deployments are replaced rather than updated.
+func (r *RunnerReconciler) ensureRunnerServices(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) error {
+	for _, s := range runner.Spec.Services {
+		svc := newRunnerService(runner, s)
+
+		if err := ctrl.SetControllerReference(&runner, svc, r.Scheme); err != nil {
+			return err
+		}
+
+		if err := r.Create(ctx, svc); err != nil {
+			if kerrors.IsAlreadyExists(err) {
+				continue
+			}
+
+			return fmt.Errorf("creating service %s: %w", svc.Name, err)
+		}
+
+		r.Recorder.Event(&runner, corev1.EventTypeNormal, "ServiceCreated", fmt.Sprintf("Created service '%s'", svc.Name))
+		log.Info("Created runner service", "service", svc.Name)
+	}
+
+	return nil
+}
+
+func newRunnerService(runner v1alpha1.Runner, s v1alpha1.RunnerService) *corev1.Service {
+	svcType := s.Type
+	if svcType == "" {
+		svcType = corev1.ServiceTypeClusterIP
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        runnerServiceName(runner.Name, s.Name),
+			Namespace:   runner.Namespace,
+			Labels:      map[string]string{LabelKeyRunnerName: runner.Name},
+			Annotations: s.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     svcType,
+			Ports:    s.Ports,
+			Selector: map[string]string{LabelKeyRunnerName: runner.Name},
+		},
+	}
+}
+
+// runnerServiceName returns RUNNER_NAME-NAME, or the runner name truncated and suffixed with a hash
+// when it's longer than the 63 characters allowed for service names.
+func runnerServiceName(runnerName, name string) string {
+	svcName := runnerName + "-" + name
+
+	if len(svcName) <= validation.DNS1035LabelMaxLength {
+		return svcName
+	}
+
+	h := hash.FNVHashStringObjects(runnerName)
+
+	n := validation.DNS1035LabelMaxLength - len(h) - len(name) - 2
+	if n < 0 {
+		n = 0
+	}
+
+	return fmt.Sprintf("%s-%s-%s", runnerName[:n], h, name)
+}
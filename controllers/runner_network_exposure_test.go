@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewPod_NetworkExposure(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				HostNetwork: true,
+				HostPorts: []corev1.ContainerPort{
+					{ContainerPort: 8443},
+					{ContainerPort: 9000, HostPort: 9000, Protocol: corev1.ProtocolUDP},
+				},
+				Services: []v1alpha1.RunnerService{
+					{Name: "callback", Ports: []corev1.ServicePort{{Port: 443}}},
+				},
+			},
+		},
+	}
+
+	r := &RunnerReconciler{
+		RunnerImage:  "default-runner-image",
+		DockerImage:  "default-docker-image",
+		GitHubClient: &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:       sc,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !pod.Spec.HostNetwork || pod.Spec.DNSPolicy != corev1.DNSClusterFirstWithHostNet {
+		t.Errorf("unexpected network of the pod: hostNetwork=%v, dnsPolicy=%s", pod.Spec.HostNetwork, pod.Spec.DNSPolicy)
+	}
+
+	if got := pod.Labels[LabelKeyRunnerName]; got != "runner" {
+		t.Errorf("unexpected %s label: %q", LabelKeyRunnerName, got)
+	}
+
+	for _, c := range pod.Spec.Containers {
+		switch c.Name {
+		case containerName:
+			if len(c.Ports) != 2 || c.Ports[0].HostPort != 8443 || c.Ports[1].HostPort != 9000 || c.Ports[1].Protocol != corev1.ProtocolUDP {
+				t.Errorf("unexpected ports of the runner container: %+v", c.Ports)
+			}
+		default:
+			if len(c.Ports) != 0 {
+				t.Errorf("unexpected ports of the %s container: %+v", c.Name, c.Ports)
+			}
+		}
+	}
+}
+
+func TestNewPod_NetworkExposure_DnsPolicy(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				HostNetwork: true,
+				DnsPolicy:   corev1.DNSDefault,
+			},
+		},
+	}
+
+	r := &RunnerReconciler{
+		GitHubClient: &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:       sc,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pod.Spec.DNSPolicy != corev1.DNSDefault {
+		t.Errorf("the dns policy of the runner is overridden: %s", pod.Spec.DNSPolicy)
+	}
+
+	if _, ok := pod.Labels[LabelKeyRunnerName]; ok {
+		t.Errorf("unexpected %s label of the runner without services", LabelKeyRunnerName)
+	}
+}
+
+func TestEnsureRunnerServices(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				Services: []v1alpha1.RunnerService{
+					{
+						Name:        "callback",
+						Type:        corev1.ServiceTypeLoadBalancer,
+						Annotations: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "callback.example.com"},
+						Ports:       []corev1.ServicePort{{Port: 443, TargetPort: intstr.FromInt(8443)}},
+					},
+					{
+						Name:  "grpc",
+						Ports: []corev1.ServicePort{{Port: 9090}},
+					},
+				},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(sc).Build()
+
+	r := &RunnerReconciler{
+		Client:   c,
+		Scheme:   sc,
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	for i := 0; i < 2; i++ {
+		// The services created on the previous attempt to create the runner pod are left as-is
+		if err := r.ensureRunnerServices(context.Background(), runner, logr.Discard()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var callback corev1.Service
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "runner-callback"}, &callback); err != nil {
+		t.Fatal(err)
+	}
+
+	if callback.Spec.Type != corev1.ServiceTypeLoadBalancer || callback.Spec.Ports[0].TargetPort.IntValue() != 8443 {
+		t.Errorf("unexpected spec of the service: %+v", callback.Spec)
+	}
+
+	if callback.Spec.Selector[LabelKeyRunnerName] != "runner" {
+		t.Errorf("unexpected selector of the service: %v", callback.Spec.Selector)
+	}
+
+	if callback.Annotations["external-dns.alpha.kubernetes.io/hostname"] != "callback.example.com" {
+		t.Errorf("unexpected annotations of the service: %v", callback.Annotations)
+	}
+
+	if ref := metav1.GetControllerOf(&callback); ref == nil || ref.Name != "runner" {
+		t.Errorf("the service is not owned by the runner: %+v", callback.OwnerReferences)
+	}
+
+	var grpc corev1.Service
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "runner-grpc"}, &grpc); err != nil {
+		t.Fatal(err)
+	}
+
+	if grpc.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("unexpected type of the service: %s", grpc.Spec.Type)
+	}
+}
+
+func TestRunnerServiceName(t *testing.T) {
+	if got := runnerServiceName("example-runnerdeploy-b2g2g-j4mcp", "callback"); got != "example-runnerdeploy-b2g2g-j4mcp-callback" {
+		t.Errorf("unexpected name: %s", got)
+	}
+
+	long := strings.Repeat("a", 50) + "-b2g2g-j4mcp"
+
+	got := runnerServiceName(long, "callback")
+	if len(got) > 63 || !strings.HasPrefix(got, "aaaa") || !strings.HasSuffix(got, "-callback") {
+		t.Errorf("unexpected name: %s", got)
+	}
+
+	if other := runnerServiceName(strings.Repeat("a", 50)+"-b2g2g-x9zzz", "callback"); other == got {
+		t.Errorf("the names of the services of different runners collide: %s", got)
+	}
+}
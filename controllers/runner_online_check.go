@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultRunnerOnlineDeadline is the default duration within which a runner pod has to come online on GitHub
+	// after it becomes Ready, before it's recreated.
+	DefaultRunnerOnlineDeadline = registrationTimeout
+
+	// retryDelayOnRunnerOnlineCheck is the interval at which a Ready runner pod is checked until it comes online on GitHub.
+	retryDelayOnRunnerOnlineCheck = 10 * time.Second
+)
+
+// ensureRunnerOnline returns true if the runner of the Ready pod has come online on GitHub,
+// annotating the pod with the ID of the runner so that GitHub is queried only until it comes online.
+// The runner state synced by RunnerStatusSyncer is used when available, instead of listing the runners via the GitHub API.
+// The pod is deleted to be recreated when the runner doesn't come online within RunnerOnlineDeadline since the pod became Ready,
+// which is typically the case of the runner stuck in a registration loop.
+// requeueAfter is the delay until the runner should be checked again, if it's not online yet.
+func (r *RunnerReconciler) ensureRunnerOnline(ctx context.Context, runner v1alpha1.Runner, pod *corev1.Pod, log logr.Logger) (online bool, requeueAfter time.Duration, err error) {
+	if podRunnerID(pod) != "" {
+		return true, 0, nil
+	}
+
+	var id int64
+
+	if s := runner.Status.GitHub; s != nil && s.ID != 0 && (s.State == v1alpha1.RunnerGitHubStateIdle || s.State == v1alpha1.RunnerGitHubStateBusy) {
+		id = s.ID
+	} else {
+		ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+		if err != nil {
+			return false, 0, err
+		}
+
+		gr, err := getRunner(ctx, ghc, runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository, runner.Name)
+		if err != nil {
+			return false, 0, err
+		}
+
+		if gr != nil && gr.GetStatus() == "online" {
+			id = gr.GetID()
+		}
+	}
+
+	if id != 0 {
+		if _, err := annotatePodOnce(ctx, r.Client, log, pod, AnnotationKeyRunnerID, strconv.FormatInt(id, 10)); err != nil {
+			return false, 0, err
+		}
+
+		log.V(1).Info("Runner came online on GitHub", "runnerId", id)
+
+		return true, 0, nil
+	}
+
+	deadline := r.RunnerOnlineDeadline
+	if deadline <= 0 {
+		deadline = DefaultRunnerOnlineDeadline
+	}
+
+	readyTime := podConditionTransitionTime(pod, corev1.PodReady, corev1.ConditionTrue)
+	if readyTime == nil {
+		return false, retryDelayOnRunnerOnlineCheck, nil
+	}
+
+	if remaining := time.Until(readyTime.Add(deadline)); remaining > 0 {
+		if remaining > retryDelayOnRunnerOnlineCheck {
+			remaining = retryDelayOnRunnerOnlineCheck
+		}
+
+		return false, remaining, nil
+	}
+
+	if err := r.Delete(ctx, pod); err != nil {
+		return false, 0, client.IgnoreNotFound(err)
+	}
+
+	msg := fmt.Sprintf("Recreating the runner pod as the runner didn't come online on GitHub within %s since the pod became ready", deadline)
+
+	r.Recorder.Event(&runner, corev1.EventTypeWarning, "RunnerOnlineTimeout", msg)
+	log.Info(msg, "readyTime", readyTime)
+
+	return false, 0, nil
+}
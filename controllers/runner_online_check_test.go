@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureRunnerOnline(t *testing.T) {
+	server := fake.NewServer(fake.WithListRunnersResponse(http.StatusOK, fake.RunnersListBody))
+	defer server.Close()
+
+	readyPod := func(name string, readyFor time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				Conditions: []corev1.PodCondition{
+					{
+						Type:               corev1.PodReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-readyFor)},
+					},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		runner      string
+		readyFor    time.Duration
+		github      *v1alpha1.RunnerGitHubStatus
+		annotated   bool
+		online      bool
+		id          string
+		deleted     bool
+	}{
+		{
+			description: "online on github",
+			runner:      "test1",
+			readyFor:    time.Minute,
+			online:      true,
+			id:          "1",
+		},
+		{
+			description: "synced runner status",
+			runner:      "test3",
+			readyFor:    time.Minute,
+			github:      &v1alpha1.RunnerGitHubStatus{ID: 3, State: v1alpha1.RunnerGitHubStateBusy},
+			online:      true,
+			id:          "3",
+		},
+		{
+			description: "already annotated",
+			runner:      "test3",
+			readyFor:    time.Hour,
+			annotated:   true,
+			online:      true,
+			id:          "3",
+		},
+		{
+			description: "offline within the deadline",
+			runner:      "test2",
+			readyFor:    time.Minute,
+		},
+		{
+			description: "not registered past the deadline",
+			runner:      "test3",
+			readyFor:    time.Hour,
+			deleted:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := context.Background()
+
+			pod := readyPod(tc.runner, tc.readyFor)
+			if tc.annotated {
+				setAnnotation(&pod.ObjectMeta, AnnotationKeyRunnerID, "3")
+			}
+
+			c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(pod).Build()
+
+			r := &RunnerReconciler{
+				Client:               c,
+				Scheme:               sc,
+				Recorder:             record.NewFakeRecorder(10),
+				GitHubClient:         newGithubClient(server),
+				RunnerOnlineDeadline: 10 * time.Minute,
+			}
+
+			runner := v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.runner, Namespace: "default"},
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+				},
+				Status: v1alpha1.RunnerStatus{GitHub: tc.github},
+			}
+
+			online, requeueAfter, err := r.ensureRunnerOnline(ctx, runner, pod, logr.Discard())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if online != tc.online {
+				t.Errorf("unexpected online: want %v, got %v", tc.online, online)
+			}
+
+			if wantRequeue := !tc.online && !tc.deleted; (requeueAfter > 0) != wantRequeue {
+				t.Errorf("unexpected requeue after: %s", requeueAfter)
+			}
+
+			var got corev1.Pod
+			err = c.Get(ctx, types.NamespacedName{Namespace: "default", Name: tc.runner}, &got)
+
+			if tc.deleted {
+				if !kerrors.IsNotFound(err) {
+					t.Errorf("the pod of the runner that didn't come online isn't deleted: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id := podRunnerID(&got); id != tc.id {
+				t.Errorf("unexpected runner id annotation: want %q, got %q", tc.id, id)
+			}
+		})
+	}
+}
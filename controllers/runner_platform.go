@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultWindowsRunnerWorkDir is the default work directory of the Windows runners.
+	defaultWindowsRunnerWorkDir = `C:\runner\_work`
+
+	// windowsRunnerVolumeMountPath is where the runner volume is mounted in the Windows runner container.
+	windowsRunnerVolumeMountPath = `C:\runner`
+)
+
+var (
+	// runnerOSLabels and runnerArchLabels are the labels the runner agent registers for the operating system and the CPU architecture of the runner.
+	runnerOSLabels   = []string{v1alpha1.RunnerOSLinux, v1alpha1.RunnerOSWindows, "macos"}
+	runnerArchLabels = []string{v1alpha1.RunnerArchX64, v1alpha1.RunnerArchARM64, "arm"}
+
+	// nodeArchLabels maps the kubernetes.io/arch node labels to the runner labels.
+	nodeArchLabels = map[string]string{
+		"amd64": v1alpha1.RunnerArchX64,
+		"arm64": v1alpha1.RunnerArchARM64,
+	}
+)
+
+// runnerPlatform returns the operating system and the CPU architecture of the runner, in the form of the labels the runner registers.
+// They default to the kubernetes.io/os and kubernetes.io/arch node selectors, and then to the guesses from the name of the runner image.
+// The architecture is empty when it's unknown.
+func runnerPlatform(config v1alpha1.RunnerConfig, nodeSelector map[string]string, image string) (os, arch string) {
+	if image == "" {
+		image = config.Image
+	}
+
+	image = strings.ToLower(image)
+
+	os = config.OS
+	if os == "" {
+		os = nodeSelector[corev1.LabelOSStable]
+	}
+	if os == "" && strings.Contains(image, "windows") {
+		os = v1alpha1.RunnerOSWindows
+	}
+	if os == "" {
+		os = v1alpha1.RunnerOSLinux
+	}
+
+	arch = config.Arch
+	if arch == "" {
+		arch = nodeArchLabels[nodeSelector[corev1.LabelArchStable]]
+	}
+	if arch == "" && (strings.Contains(image, "arm64") || strings.Contains(image, "aarch64")) {
+		arch = v1alpha1.RunnerArchARM64
+	}
+
+	return os, arch
+}
+
+// runnerPlatformLabels returns the operating system and the CPU architecture labels the runners of the runner pool register to GitHub,
+// which aren't necessarily listed in the labels of the runner config.
+func runnerPlatformLabels(config v1alpha1.RunnerConfig, nodeSelector map[string]string) []string {
+	os, arch := runnerPlatform(config, nodeSelector, "")
+
+	labels := []string{os}
+	if arch != "" {
+		labels = append(labels, arch)
+	}
+
+	return labels
+}
+
+// applyRunnerPlatformNodeSelector schedules the runner pod to the nodes of the operating system and the CPU architecture
+// explicitly set in the runner config, unless the pod already selects the nodes of its own.
+func applyRunnerPlatformNodeSelector(pod *corev1.Pod, config v1alpha1.RunnerConfig) {
+	set := func(k, v string) {
+		if v == "" {
+			return
+		}
+
+		if _, ok := pod.Spec.NodeSelector[k]; ok {
+			return
+		}
+
+		// The node selector may be shared with the runner spec.
+		nodeSelector := make(map[string]string, len(pod.Spec.NodeSelector)+1)
+		for key, value := range pod.Spec.NodeSelector {
+			nodeSelector[key] = value
+		}
+		nodeSelector[k] = v
+
+		pod.Spec.NodeSelector = nodeSelector
+	}
+
+	set(corev1.LabelOSStable, config.OS)
+
+	for nodeArch, arch := range nodeArchLabels {
+		if arch == config.Arch {
+			set(corev1.LabelArchStable, nodeArch)
+		}
+	}
+}
+
+// jobPlatformMatches returns false when the workflow job requests an operating system or a CPU architecture
+// other than the ones of the runners, among the platform labels of the runners.
+// A job that requests none of them matches the runners of any platform.
+func jobPlatformMatches(jobLabels, platformLabels []string) bool {
+	for _, group := range [][]string{runnerOSLabels, runnerArchLabels} {
+		var runner string
+
+		for _, l := range platformLabels {
+			if containsFold(group, l) {
+				runner = l
+			}
+		}
+
+		if runner == "" {
+			continue
+		}
+
+		for _, l := range jobLabels {
+			if containsFold(group, l) && !strings.EqualFold(l, runner) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
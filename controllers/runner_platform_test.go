@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRunnerPlatform(t *testing.T) {
+	testcases := []struct {
+		description  string
+		config       v1alpha1.RunnerConfig
+		nodeSelector map[string]string
+		image        string
+		wantOS       string
+		wantArch     string
+	}{
+		{
+			description: "defaults",
+			image:       "summerwind/actions-runner:latest",
+			wantOS:      "linux",
+		},
+		{
+			description:  "node selector",
+			nodeSelector: map[string]string{"kubernetes.io/os": "windows", "kubernetes.io/arch": "amd64"},
+			wantOS:       "windows",
+			wantArch:     "x64",
+		},
+		{
+			description: "image",
+			image:       "example.com/runner:ltsc2022-windows-arm64",
+			wantOS:      "windows",
+			wantArch:    "arm64",
+		},
+		{
+			description:  "explicit",
+			config:       v1alpha1.RunnerConfig{OS: "linux", Arch: "arm64"},
+			nodeSelector: map[string]string{"kubernetes.io/os": "windows", "kubernetes.io/arch": "amd64"},
+			wantOS:       "linux",
+			wantArch:     "arm64",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			os, arch := runnerPlatform(tc.config, tc.nodeSelector, tc.image)
+
+			if os != tc.wantOS || arch != tc.wantArch {
+				t.Errorf("unexpected platform: want %s/%s, got %s/%s", tc.wantOS, tc.wantArch, os, arch)
+			}
+		})
+	}
+}
+
+func TestNewRunnerPod_Windows(t *testing.T) {
+	config := v1alpha1.RunnerConfig{Repository: "myorg/myrepo", OS: "windows", Arch: "x64"}
+
+	pod, err := newRunnerPod("runner", corev1.Pod{}, config, "runner-image", nil, "docker-image", "", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(pod.Spec.Containers); n != 1 {
+		t.Fatalf("unexpected number of containers: want 1, got %d", n)
+	}
+
+	runner := pod.Spec.Containers[0]
+
+	if runner.SecurityContext != nil && runner.SecurityContext.Privileged != nil {
+		t.Errorf("the windows runner container sets privileged: %v", *runner.SecurityContext.Privileged)
+	}
+
+	if got := getRunnerEnv(&pod, "RUNNER_WORKDIR"); got != `C:\runner\_work` {
+		t.Errorf("unexpected work directory: %s", got)
+	}
+
+	if got := getRunnerEnv(&pod, "DOCKER_ENABLED"); got != "false" {
+		t.Errorf("unexpected DOCKER_ENABLED: %s", got)
+	}
+
+	applyRunnerPlatformNodeSelector(&pod, config)
+
+	if got := pod.Spec.NodeSelector; got["kubernetes.io/os"] != "windows" || got["kubernetes.io/arch"] != "amd64" {
+		t.Errorf("unexpected node selector: %v", got)
+	}
+
+	dockerdInRunner := true
+	config.DockerdWithinRunnerContainer = &dockerdInRunner
+
+	if _, err := newRunnerPod("runner", corev1.Pod{}, config, "runner-image", nil, "docker-image", "", "", false); err == nil {
+		t.Error("expected an error for dockerd within the windows runner container")
+	}
+}
+
+func TestMatchJobLabels_PlatformLabels(t *testing.T) {
+	testcases := []struct {
+		job, runner, platform []string
+		wantExtra             int
+		wantOK                bool
+	}{
+		{job: []string{"self-hosted", "Linux", "X64"}, platform: []string{"linux", "x64"}, wantOK: true},
+		{job: []string{"self-hosted", "linux", "gpu"}, runner: []string{"gpu", "large"}, platform: []string{"linux"}, wantExtra: 1, wantOK: true},
+		{job: []string{"self-hosted", "windows"}, runner: []string{"gpu"}, platform: []string{"linux", "x64"}, wantOK: false},
+		{job: []string{"self-hosted", "arm64"}, platform: []string{"linux"}, wantOK: false},
+	}
+
+	for i, tc := range testcases {
+		extra, ok := matchJobLabels(tc.job, tc.runner, tc.platform)
+
+		if ok != tc.wantOK || (ok && extra != tc.wantExtra) {
+			t.Errorf("[%d] unexpected match: want (%d, %v), got (%d, %v)", i, tc.wantExtra, tc.wantOK, extra, ok)
+		}
+	}
+}
+
+func TestMatchWorkflowJobs_PlatformLabels(t *testing.T) {
+	job := func(id int64, labels ...string) *github.WorkflowJob {
+		return &github.WorkflowJob{WorkflowJob: gogithub.WorkflowJob{ID: gogithub.Int64(id), Labels: labels}}
+	}
+
+	jobs := []*github.WorkflowJob{
+		job(1, "self-hosted"),
+		job(2, "self-hosted", "Windows", "X64"),
+		job(3, "self-hosted", "windows", "arm64"),
+		job(4, "self-hosted", "linux"),
+	}
+
+	var got []int64
+	for _, j := range matchWorkflowJobs(jobs, nil, []string{"windows", "x64"}, "", nil) {
+		got = append(got, j.GetID())
+	}
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("unexpected matched jobs: %v", got)
+	}
+}
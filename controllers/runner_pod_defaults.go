@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RunnerPodDefaults is the controller-wide scheduling constraints merged into every runner pod the controller creates,
+// so that dedicating e.g. a tainted node pool to the runners doesn't require updating every runner pool.
+// The node selector keys and the tolerations set in the runner spec take precedence.
+type RunnerPodDefaults struct {
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+}
+
+// apply adds the default node selectors whose keys aren't selected by the pod yet,
+// and the default tolerations the pod doesn't tolerate yet.
+func (d RunnerPodDefaults) apply(pod *corev1.Pod) {
+	var missing []string
+
+	for k := range d.NodeSelector {
+		if _, ok := pod.Spec.NodeSelector[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	if len(missing) > 0 {
+		// The node selector may be shared with the runner spec.
+		nodeSelector := make(map[string]string, len(pod.Spec.NodeSelector)+len(missing))
+		for k, v := range pod.Spec.NodeSelector {
+			nodeSelector[k] = v
+		}
+		for _, k := range missing {
+			nodeSelector[k] = d.NodeSelector[k]
+		}
+
+		pod.Spec.NodeSelector = nodeSelector
+	}
+
+	var tolerations []corev1.Toleration
+
+	for _, t := range d.Tolerations {
+		if !hasToleration(pod.Spec.Tolerations, t) {
+			tolerations = append(tolerations, t)
+		}
+	}
+
+	if len(tolerations) > 0 {
+		// Copy so that the tolerations of the runner spec are never appended to in place.
+		pod.Spec.Tolerations = append(append([]corev1.Toleration{}, pod.Spec.Tolerations...), tolerations...)
+	}
+}
+
+func hasToleration(tolerations []corev1.Toleration, t corev1.Toleration) bool {
+	for _, existing := range tolerations {
+		if existing.MatchToleration(&t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseRunnerNodeSelector parses the node selector in the K=V format.
+func ParseRunnerNodeSelector(s string) (key, value string, err error) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return "", "", fmt.Errorf("node selector %q must be in the KEY=VALUE format", s)
+	}
+
+	return kv[0], kv[1], nil
+}
+
+// ParseRunnerToleration parses the toleration in the KEY[=VALUE][:EFFECT] format, which is the one of the taints in kubectl.
+// The toleration without a value tolerates the taints of the key with any value, and the one without an effect tolerates all the effects.
+func ParseRunnerToleration(s string) (corev1.Toleration, error) {
+	var t corev1.Toleration
+
+	keyValue := s
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		keyValue = s[:i]
+		t.Effect = corev1.TaintEffect(s[i+1:])
+
+		switch t.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return t, fmt.Errorf("toleration %q has the unsupported effect %q: it must be one of %s, %s and %s", s, t.Effect, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute)
+		}
+	}
+
+	if kv := strings.SplitN(keyValue, "=", 2); len(kv) == 2 {
+		t.Key = kv[0]
+		t.Operator = corev1.TolerationOpEqual
+		t.Value = kv[1]
+	} else {
+		t.Key = keyValue
+		t.Operator = corev1.TolerationOpExists
+	}
+
+	if t.Key == "" {
+		return t, fmt.Errorf("toleration %q must be in the KEY[=VALUE][:EFFECT] format", s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRunnerPodDefaults_Apply(t *testing.T) {
+	ciTaint := corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ci", Effect: corev1.TaintEffectNoSchedule}
+	spotTaint := corev1.Toleration{Key: "spot", Operator: corev1.TolerationOpExists}
+
+	defaults := RunnerPodDefaults{
+		NodeSelector: map[string]string{"pool": "ci", "kubernetes.io/os": "linux"},
+		Tolerations:  []corev1.Toleration{ciTaint, spotTaint},
+	}
+
+	specNodeSelector := map[string]string{"pool": "gpu"}
+	specTolerations := make([]corev1.Toleration, 1, 2)
+	specTolerations[0] = spotTaint
+
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector: specNodeSelector,
+			Tolerations:  specTolerations,
+		},
+	}
+
+	defaults.apply(&pod)
+
+	if d := cmp.Diff(map[string]string{"pool": "gpu", "kubernetes.io/os": "linux"}, pod.Spec.NodeSelector); d != "" {
+		t.Errorf("unexpected node selector (-want +got):\n%s", d)
+	}
+
+	if d := cmp.Diff([]corev1.Toleration{spotTaint, ciTaint}, pod.Spec.Tolerations); d != "" {
+		t.Errorf("unexpected tolerations (-want +got):\n%s", d)
+	}
+
+	if len(specNodeSelector) != 1 || len(specTolerations[:cap(specTolerations)][1].Key) != 0 {
+		t.Errorf("the node selector or the tolerations of the runner spec are modified in place")
+	}
+
+	var empty corev1.Pod
+
+	RunnerPodDefaults{}.apply(&empty)
+
+	if empty.Spec.NodeSelector != nil || empty.Spec.Tolerations != nil {
+		t.Errorf("empty defaults modified the pod: %v", empty.Spec)
+	}
+}
+
+func TestParseRunnerToleration(t *testing.T) {
+	testcases := []struct {
+		in      string
+		want    corev1.Toleration
+		wantErr bool
+	}{
+		{
+			in:   "dedicated=ci:NoSchedule",
+			want: corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ci", Effect: corev1.TaintEffectNoSchedule},
+		},
+		{
+			in:   "dedicated=ci",
+			want: corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ci"},
+		},
+		{
+			in:   "example.com/spot:NoExecute",
+			want: corev1.Toleration{Key: "example.com/spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		},
+		{
+			in:   "spot",
+			want: corev1.Toleration{Key: "spot", Operator: corev1.TolerationOpExists},
+		},
+		{
+			in:      "dedicated=ci:Never",
+			wantErr: true,
+		},
+		{
+			in:      "=ci",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := ParseRunnerToleration(tc.in)
+
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.in)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.in, err)
+			continue
+		}
+
+		if d := cmp.Diff(tc.want, got); d != "" {
+			t.Errorf("%s: unexpected toleration (-want +got):\n%s", tc.in, d)
+		}
+	}
+}
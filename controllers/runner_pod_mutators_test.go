@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/podmutator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewPod_PodMutators(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+		},
+	}
+
+	var target podmutator.Target
+
+	mutators := &podmutator.Chain{}
+	mutators.Add("priority", podmutator.Func(func(ctx context.Context, pod *corev1.Pod, t podmutator.Target) error {
+		target = t
+		pod.Spec.PriorityClassName = "ci"
+		return nil
+	}))
+
+	r := &RunnerReconciler{
+		RunnerImage:  "default-runner-image",
+		DockerImage:  "default-docker-image",
+		GitHubClient: &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:       sc,
+		PodMutators:  mutators,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pod.Spec.PriorityClassName != "ci" {
+		t.Errorf("the pod isn't mutated: %q", pod.Spec.PriorityClassName)
+	}
+
+	if target.Kind != "Runner" || target.Namespace != "default" || target.Name != "runner" || target.RunnerConfig.Repository != "test/valid" {
+		t.Errorf("unexpected target: %+v", target)
+	}
+
+	mutators.Add("failing", podmutator.Func(func(ctx context.Context, pod *corev1.Pod, t podmutator.Target) error {
+		return errors.New("boom")
+	}))
+
+	if _, err := r.newPod(context.Background(), runner); err == nil {
+		t.Errorf("expected the error of the failing mutator")
+	}
+}
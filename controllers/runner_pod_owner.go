@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -197,6 +199,20 @@ func getPodsForOwner(ctx context.Context, c client.Client, log logr.Logger, o cl
 		}
 	}
 
+	if runner != nil && runner.Spec.Provisioner != "" {
+		// A runner backed by the runner provisioner has no pod, so we count the runner itself based on the phase of the instance.
+		total++
+
+		switch runner.Status.Phase {
+		case provisioner.PhaseSucceeded, provisioner.PhaseFailed:
+			completed++
+		case provisioner.PhaseRunning:
+			running++
+		default:
+			pending++
+		}
+	}
+
 	templateHash, ok := owner.templateHash()
 	if !ok {
 		log.Info("Failed to get template hash of statefulset. It must be in an invalid state. Please manually delete the statefulset so that it is recreated")
@@ -249,7 +265,18 @@ type result struct {
 // The second call fails due to the first call mutated the client.Object to have .Revision.
 // Passing a factory function of client.Object and creating a brand-new client.Object per a client.Create call resolves this issue,
 // allowing us to create two or more replicas in one reconcilation loop without being rejected by K8s.
-func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger, effectiveTime *metav1.Time, newDesiredReplicas int, create func() client.Object, ephemeral bool, owners []client.Object) (*result, error) {
+//
+// desired is the owner object that has the desired template hash. create is called exactly once per object being created,
+// so that it can vary the created objects, like RunnerReplicaSet does for burst runners.
+//
+// No object is created when drain is true, while redundant and outdated objects are still deleted.
+// scaleDownPolicy is the order in which the runners are chosen for deletion on scale down, and empty means OldestFirst.
+// Runners that have registered or have been seen busy within scaleDownGracePeriod are never chosen for deletion on scale down.
+// listBusy returns the names of the busy runners, which are retained before idle ones on scale down.
+// It can be nil, or return nil, when the busy runners are unknown.
+// listWarmth returns the warmth of the runners by their names, and the warmer runners are retained before the colder ones
+// of the same busyness on scale down. It is nil unless the warm runner affinity is enabled.
+func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger, effectiveTime *metav1.Time, newDesiredReplicas int, desired client.Object, create func() client.Object, ephemeral bool, drain bool, scaleDownPolicy v1alpha1.ScaleDownPolicy, scaleDownGracePeriod time.Duration, listBusy func() map[string]bool, listWarmth func() map[string]int, owners []client.Object) (*result, error) {
 	state, err := collectPodsForOwners(ctx, c, log, owners)
 	if err != nil || state == nil {
 		return nil, err
@@ -279,7 +306,7 @@ func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger,
 	// Even though the error message includes "Forbidden", this error's reason is "Invalid".
 	// So we used to match these errors by using errors.IsInvalid. But that's another story...
 
-	desiredTemplateHash, ok := getRunnerTemplateHash(create())
+	desiredTemplateHash, ok := getRunnerTemplateHash(desired)
 	if !ok {
 		log.Info("Failed to get template hash of desired owner resource. It must be in an invalid state. Please manually delete the owner so that it is recreated")
 
@@ -362,6 +389,8 @@ func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger,
 			"Detected that some ephemeral runners have disappeared. " +
 				"Usually this is due to that ephemeral runner completions " +
 				"so ARC does not create new runners until EffectiveTime is updated, or DefaultRunnerPodRecreationDelayAfterWebhookScale is elapsed.")
+	} else if wantMoreRunners && drain {
+		log.V(1).Info("Skipped creating replica(s) in drain mode", "missing", newDesiredReplicas-maybeRunning)
 	} else if wantMoreRunners {
 		if alreadySyncedAfterEffectiveTime && !runnerPodRecreationDelayAfterWebhookScale {
 			log.V(2).Info("Adding more replicas because DefaultRunnerPodRecreationDelayAfterWebhookScale has been passed")
@@ -400,25 +429,88 @@ func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger,
 		// This runnerreplicaset controller doesn't count marked runners into the `running` value, hence you're unlikely to
 		// fall into this branch when you're using ephemeral runners with webhook-based-autoscaler.
 
-		var retained int
+		now := time.Now()
+
+		var busy map[string]bool
+		if listBusy != nil {
+			busy = listBusy()
+		}
+
+		var warmth map[string]int
+		if listWarmth != nil {
+			warmth = listWarmth()
+		}
+
+		idleOnly := scaleDownPolicy == v1alpha1.ScaleDownPolicyIdleOnly
+		if idleOnly && busy == nil {
+			log.V(1).Info("Skipped scale down as the busy runners are unknown", "scaleDownPolicy", scaleDownPolicy)
+
+			return nil, nil
+		}
+
+		// Protected runners are never chosen for deletion. They are retained before any other runner,
+		// even when that keeps more runners than desired until the protection is removed or expires.
+		// So are busy runners with the IdleOnly policy, and the runners within the scale down grace period,
+		// which may have just been assigned jobs that the GitHub API doesn't report yet.
+		retain := func(ss *podsForOwner) bool {
+			return ss.protected(now) || (idleOnly && ss.busy(busy)) || ss.inScaleDownGracePeriod(now, scaleDownGracePeriod)
+		}
+
+		var protected int
+		for _, ss := range currentObjects {
+			if retain(ss) {
+				protected += ss.running
+			}
+		}
+
+		retainable := newDesiredReplicas
+		if protected > retainable {
+			retainable = protected
+
+			log.V(1).Info("Keeping more replicas than desired due to protected runner(s)", "protected", protected)
+		}
+
+		retained := protected
+
+		// Busy runners are retained before idle ones, so that idle runners are removed right away
+		// instead of busy runners waiting for their jobs to complete.
+		// With the warm runner affinity, the warmer runners are retained before the colder ones of the same busyness.
+		// The rest is ordered by the scale down policy, which retains the newest runners first by default.
+		candidates := append([]*podsForOwner{}, currentObjects...)
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if bi, bj := candidates[i].busy(busy), candidates[j].busy(busy); bi != bj {
+				return bj
+			}
+
+			if wi, wj := candidates[i].warmth(warmth), candidates[j].warmth(warmth); wi != wj {
+				return wi < wj
+			}
+
+			return deletedBefore(scaleDownPolicy, candidates[i], candidates[j])
+		})
 
 		var delete []*podsForOwner
-		for i := len(currentObjects) - 1; i >= 0; i-- {
-			ss := currentObjects[i]
+		for i := len(candidates) - 1; i >= 0; i-- {
+			ss := candidates[i]
+
+			if retain(ss) {
+				continue
+			}
 
-			if ss.running == 0 || retained >= newDesiredReplicas {
+			if ss.running == 0 || retained >= retainable {
 				// In case the desired replicas is satisfied until i-1, or this owner has no running pods,
 				// this owner can be considered safe for deletion.
 				// Note that we already waited on this owner to create pods by waiting for
 				// `.Status.Replicas`(=total number of pods managed by owner, regardless of the runner is Running or Completed) to match the desired replicas in a previous step.
 				// So `.running == 0` means "the owner has created the desired number of pods before, and all of them are completed now".
 				delete = append(delete, ss)
-			} else if retained < newDesiredReplicas {
+			} else if retained < retainable {
 				retained += ss.running
 			}
 		}
 
-		if retained == newDesiredReplicas {
+		if retained == retainable {
 			for _, ss := range delete {
 				log := log.WithValues("owner", types.NamespacedName{Namespace: ss.owner.GetNamespace(), Name: ss.owner.GetName()})
 				// Statefulset termination process 1/4: Set unregistrationRequestTimestamp only after all the pods managed by the statefulset have
@@ -450,7 +542,7 @@ func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger,
 
 				log.V(2).Info("Redundant owner has been annotated to start the unregistration before deletion")
 			}
-		} else if retained > newDesiredReplicas {
+		} else if retained > retainable {
 			log.V(2).Info("Waiting sync before scale down", "retained", retained, "newDesiredReplicas", newDesiredReplicas)
 
 			return nil, nil
@@ -463,6 +555,12 @@ func syncRunnerPodsOwners(ctx context.Context, c client.Client, log logr.Logger,
 	for _, sss := range podsForOwnersPerTemplateHash {
 		for _, ss := range sss {
 			if ss.templateHash != desiredTemplateHash {
+				if ss.protected(time.Now()) {
+					log.V(1).Info("Skipped deleting outdated object as it's protected", "owner", ss.owner.GetName())
+
+					continue
+				}
+
 				if ss.owner.GetDeletionTimestamp().IsZero() {
 					if err := c.Delete(ctx, ss.object); err != nil {
 						log.Error(err, "Unable to delete object")
@@ -542,6 +640,12 @@ func collectPodsForOwners(ctx context.Context, c client.Client, log logr.Logger,
 				}
 			}
 
+			if res.runner != nil && res.runner.Spec.Provisioner != "" {
+				// The runner controller unregisters the runner, waiting for it to complete the job if it's busy,
+				// before deprovisioning the instance. So it's always safe to start deleting the runner.
+				deletionSafe = res.total
+			}
+
 			if deletionSafe == res.total {
 				log.V(2).Info("Marking owner for unregistration completion", "deletionSafe", deletionSafe, "total", res.total)
 
@@ -598,3 +702,94 @@ func collectPodsForOwners(ctx context.Context, c client.Client, log logr.Logger,
 
 	return &state{podsForOwnerPerTemplateHash, lastSyncTime}, nil
 }
+
+// busy returns true if any of the runners of the owner is busy.
+// The name of a runner is the name of its pod, for both Runners and StatefulSets.
+func (p *podsForOwner) busy(busyRunners map[string]bool) bool {
+	for i := range p.pods {
+		if busyRunners[p.pods[i].Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// warmth returns the highest warmth of the runners of the pods.
+func (p *podsForOwner) warmth(runnerWarmth map[string]int) int {
+	var w int
+	for i := range p.pods {
+		if v := runnerWarmth[p.pods[i].Name]; v > w {
+			w = v
+		}
+	}
+
+	return w
+}
+
+// lastBusy returns the latest time the runner of the owner was seen running a job, or the zero time when it's unknown,
+// like when the owner is a StatefulSet or the runner has never run a job.
+func (p *podsForOwner) lastBusy() time.Time {
+	if p.runner == nil {
+		return time.Time{}
+	}
+
+	var t time.Time
+
+	st := p.runner.Status
+	if len(st.RecentJobs) > 0 {
+		t = st.RecentJobs[0].StartedAt.Time
+	}
+
+	if u := st.Utilization; st.Busy && u != nil && u.LastSampleTime != nil && u.LastSampleTime.After(t) {
+		t = u.LastSampleTime.Time
+	}
+
+	if gh := st.GitHub; gh != nil && gh.LastBusyTime != nil && gh.LastBusyTime.After(t) {
+		t = gh.LastBusyTime.Time
+	}
+
+	return t
+}
+
+// deletedBefore returns true if a is chosen for deletion before b by the scale down policy.
+// currentObjects are sorted oldest first, so that OldestFirst and IdleOnly keep the order as is.
+func deletedBefore(policy v1alpha1.ScaleDownPolicy, a, b *podsForOwner) bool {
+	switch policy {
+	case v1alpha1.ScaleDownPolicyNewestFirst:
+		return a.owner.GetCreationTimestamp().After(b.owner.GetCreationTimestamp().Time)
+	case v1alpha1.ScaleDownPolicyLeastRecentlyBusy:
+		return a.lastBusy().Before(b.lastBusy())
+	default:
+		return false
+	}
+}
+
+// listBusyRunners returns the names of the runners registered with the config that are busy on GitHub.
+// It returns nil when the runners couldn't be listed, so that the scale down doesn't fail on GitHub API errors.
+func listBusyRunners(ctx context.Context, log logr.Logger, ghClient *github.Client, ghClients *MultiGitHubClient, namespace string, config v1alpha1.RunnerConfig) map[string]bool {
+	if ghClient == nil {
+		return nil
+	}
+
+	ghc, err := ghClients.ClientFor(ctx, ghClient, namespace, config.GitHubAPICredentialsFrom)
+	if err != nil {
+		log.V(1).Info("Could not get the GitHub client to find busy runners", "error", err.Error())
+		return nil
+	}
+
+	runners, err := ghc.ListRunners(ctx, config.Enterprise, config.Organization, config.Repository)
+	if err != nil {
+		log.V(1).Info("Could not list runners to find busy runners", "error", err.Error())
+		return nil
+	}
+
+	busy := map[string]bool{}
+	for _, r := range runners {
+		if r.GetBusy() {
+			busy[r.GetName()] = true
+		}
+	}
+
+	return busy
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelKeyRetainedRunnerPool is the label of a retained runner pod, whose value is the name of the runner deployment
+	// the runner belonged to, or the name of the runner itself for a standalone runner.
+	// Retained pods lose the runner pod labels so that they are never mistaken for the pods of live runners.
+	LabelKeyRetainedRunnerPool = "retained-runner-pool"
+
+	// AnnotationKeyRetainedAt is the time the runner pod was retained, in RFC3339.
+	AnnotationKeyRetainedAt = annotationKeyPrefix + "retained-at"
+
+	// AnnotationKeyRetentionTTL is spec.podRetention.ttl of the runner at the time the pod was retained.
+	AnnotationKeyRetentionTTL = annotationKeyPrefix + "retention-ttl"
+
+	// AnnotationKeyRetentionFailedPodsLimit is spec.podRetention.failedPodsLimit of the runner at the time the pod was retained.
+	AnnotationKeyRetentionFailedPodsLimit = annotationKeyPrefix + "retention-failed-pods-limit"
+
+	DefaultRunnerPodRetentionFailedPodsLimit = 3
+
+	runnerPodResultSucceeded = "succeeded"
+	runnerPodResultFailed    = "failed"
+)
+
+// RunnerPodRetentionReconciler deletes the retained pods of the completed and failed runners once their TTL expires,
+// while keeping the latest failed pods up to the failed pods limit of each runner pool for debugging.
+//
+// Runner pods are retained by the runner controller on deleting the runners with spec.podRetention.
+type RunnerPodRetentionReconciler struct {
+	client.Client
+	Log  logr.Logger
+	Name string
+
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch;delete
+
+func (r *RunnerPodRetentionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	pool, retained := pod.Labels[LabelKeyRetainedRunnerPool]
+	if !retained {
+		return ctrl.Result{}, nil
+	}
+
+	log := r.Log.WithValues("namespace", pod.Namespace, "pool", pool)
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(pod.Namespace), client.MatchingLabels{LabelKeyRetainedRunnerPool: pool}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	expired, requeueAfter := expiredRetainedRunnerPods(pods.Items, now)
+
+	var succeeded, failed int
+
+	for i := range pods.Items {
+		p := &pods.Items[i]
+
+		if !p.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if _, ok := expired[p.Name]; ok {
+			if err := r.Delete(ctx, p); err != nil && !kerrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
+			result := runnerPodResult(p)
+
+			metrics.IncRunnerPodRetentionDeleted(p.Namespace, pool, result)
+
+			log.V(1).Info("Deleted retained runner pod", "pod", p.Name, "result", result, "retained_at", p.Annotations[AnnotationKeyRetainedAt])
+
+			continue
+		}
+
+		if runnerPodFailed(p) {
+			failed++
+		} else {
+			succeeded++
+		}
+	}
+
+	metrics.SetRunnerPodRetentionRetained(pod.Namespace, pool, succeeded, failed)
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// expiredRetainedRunnerPods returns the names of the retained runner pods to be deleted,
+// and the duration until the next one expires, or zero if none is going to expire.
+//
+// A pod expires once its TTL elapses since it's retained, unless it's among the latest failed pods within the failed pods limit.
+func expiredRetainedRunnerPods(pods []corev1.Pod, now time.Time) (map[string]struct{}, time.Duration) {
+	var candidates []*corev1.Pod
+	for i := range pods {
+		if pods[i].DeletionTimestamp.IsZero() {
+			candidates = append(candidates, &pods[i])
+		}
+	}
+
+	// The latest pods come first so that the latest failed pods are the ones kept within the limit.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return retainedAt(candidates[i]).After(retainedAt(candidates[j]))
+	})
+
+	expired := map[string]struct{}{}
+
+	var (
+		keptFailed int
+		next       time.Duration
+	)
+
+	for _, pod := range candidates {
+		if runnerPodFailed(pod) && keptFailed < retentionFailedPodsLimit(pod) {
+			keptFailed++
+			continue
+		}
+
+		expiry := retainedAt(pod).Add(retentionTTL(pod))
+
+		if !now.Before(expiry) {
+			expired[pod.Name] = struct{}{}
+			continue
+		}
+
+		if d := expiry.Sub(now); next == 0 || d < next {
+			next = d
+		}
+	}
+
+	return expired, next
+}
+
+// retainRunnerPod detaches the stopped pod from the runner being deleted so that the pod survives the runner,
+// when the runner has spec.podRetention.
+// The pod is re-parented to the runner deployment, if any, so that it's still garbage-collected along with the runner deployment.
+// It returns true when the pod is retained.
+func retainRunnerPod(ctx context.Context, c client.Client, log logr.Logger, runner v1alpha1.Runner, pod *corev1.Pod, now time.Time) (bool, error) {
+	retention := runner.Spec.PodRetention
+	if retention == nil || pod == nil || !pod.DeletionTimestamp.IsZero() || !runnerPodOrContainerIsStopped(pod) {
+		return false, nil
+	}
+
+	if _, ok := pod.Labels[LabelKeyRetainedRunnerPool]; ok {
+		return false, nil
+	}
+
+	var ttl time.Duration
+	if retention.TTL != nil {
+		ttl = retention.TTL.Duration
+	}
+
+	limit := DefaultRunnerPodRetentionFailedPodsLimit
+	if retention.FailedPodsLimit != nil {
+		limit = *retention.FailedPodsLimit
+	}
+
+	if ttl <= 0 && (limit <= 0 || !runnerPodFailed(pod)) {
+		return false, nil
+	}
+
+	pool := pod.Labels[LabelKeyRunnerDeploymentName]
+	if pool == "" {
+		pool = runner.Name
+	}
+
+	owner, err := runnerDeploymentOwnerOf(ctx, c, runner)
+	if err != nil {
+		return false, err
+	}
+
+	updated := pod.DeepCopy()
+	updated.OwnerReferences = nil
+	if owner != nil {
+		updated.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+
+	// The runner has already been unregistered, so the pod doesn't need to be gracefully stopped on deletion.
+	updated.Finalizers, _ = removeFinalizer(updated.Finalizers, runnerPodFinalizerName)
+
+	delete(updated.Labels, LabelKeyRunnerSetName)
+	delete(updated.Labels, LabelKeyRunnerDeploymentName)
+	updated.Labels = CloneAndAddLabel(updated.Labels, LabelKeyRetainedRunnerPool, pool)
+
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[AnnotationKeyRetainedAt] = now.Format(time.RFC3339)
+	updated.Annotations[AnnotationKeyRetentionTTL] = ttl.String()
+	updated.Annotations[AnnotationKeyRetentionFailedPodsLimit] = strconv.Itoa(limit)
+
+	if err := c.Patch(ctx, updated, client.MergeFrom(pod)); err != nil {
+		return false, fmt.Errorf("retaining runner pod: %w", err)
+	}
+
+	log.Info("Retained runner pod", "pod", pod.Name, "result", runnerPodResult(pod), "ttl", ttl, "failed_pods_limit", limit)
+
+	return true, nil
+}
+
+// runnerDeploymentOwnerOf returns the owner reference to the runner deployment that manages the runner via a runner replica set,
+// or nil for a standalone runner.
+func runnerDeploymentOwnerOf(ctx context.Context, c client.Client, runner v1alpha1.Runner) (*metav1.OwnerReference, error) {
+	ref := metav1.GetControllerOf(&runner)
+	if ref == nil || ref.Kind != "RunnerReplicaSet" {
+		return nil, nil
+	}
+
+	var rs v1alpha1.RunnerReplicaSet
+	if err := c.Get(ctx, types.NamespacedName{Namespace: runner.Namespace, Name: ref.Name}, &rs); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	rdRef := metav1.GetControllerOf(&rs)
+	if rdRef == nil || rdRef.Kind != "RunnerDeployment" {
+		return nil, nil
+	}
+
+	return &metav1.OwnerReference{
+		APIVersion: rdRef.APIVersion,
+		Kind:       rdRef.Kind,
+		Name:       rdRef.Name,
+		UID:        rdRef.UID,
+	}, nil
+}
+
+// runnerPodFailed returns true when the runner pod failed, or its runner container exited with a non-zero code.
+func runnerPodFailed(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+
+	code := runnerContainerExitCode(pod)
+
+	return code != nil && *code != 0
+}
+
+func runnerPodResult(pod *corev1.Pod) string {
+	if runnerPodFailed(pod) {
+		return runnerPodResultFailed
+	}
+
+	return runnerPodResultSucceeded
+}
+
+func retainedAt(pod *corev1.Pod) time.Time {
+	if t, err := time.Parse(time.RFC3339, pod.Annotations[AnnotationKeyRetainedAt]); err == nil {
+		return t
+	}
+
+	return pod.CreationTimestamp.Time
+}
+
+func retentionTTL(pod *corev1.Pod) time.Duration {
+	d, err := time.ParseDuration(pod.Annotations[AnnotationKeyRetentionTTL])
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+func retentionFailedPodsLimit(pod *corev1.Pod) int {
+	n, err := strconv.Atoi(pod.Annotations[AnnotationKeyRetentionFailedPodsLimit])
+	if err != nil {
+		return DefaultRunnerPodRetentionFailedPodsLimit
+	}
+
+	return n
+}
+
+func (r *RunnerPodRetentionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnerpodretention-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{}).
+		Named(name).
+		Complete(r)
+}
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRetainRunnerPod(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	isController := true
+
+	rs := &v1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example-abcde",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "actions.summerwind.dev/v1alpha1", Kind: "RunnerDeployment", Name: "example", UID: "rd", Controller: &isController},
+			},
+		},
+	}
+
+	newRunner := func(retention *v1alpha1.RunnerPodRetention) v1alpha1.Runner {
+		return v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "example-abcde-fghij",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "actions.summerwind.dev/v1alpha1", Kind: "RunnerReplicaSet", Name: "example-abcde", UID: "rs", Controller: &isController},
+				},
+			},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerPodSpec: v1alpha1.RunnerPodSpec{PodRetention: retention},
+			},
+		}
+	}
+
+	newPod := func(phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "example-abcde-fghij",
+				Labels: map[string]string{
+					LabelKeyRunnerSetName:        "example-abcde-fghij",
+					LabelKeyRunnerDeploymentName: "example",
+				},
+				Finalizers: []string{runnerPodFinalizerName},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "actions.summerwind.dev/v1alpha1", Kind: "Runner", Name: "example-abcde-fghij", UID: "runner", Controller: &isController},
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		retention   *v1alpha1.RunnerPodRetention
+		phase       corev1.PodPhase
+
+		want          bool
+		wantTTL       string
+		wantLimit     string
+		wantOwnerName string
+	}{
+		{
+			description: "no retention",
+			phase:       corev1.PodFailed,
+		},
+		{
+			description: "running pod",
+			retention:   &v1alpha1.RunnerPodRetention{TTL: &metav1.Duration{Duration: time.Hour}},
+			phase:       corev1.PodRunning,
+		},
+		{
+			description: "succeeded pod without ttl",
+			retention:   &v1alpha1.RunnerPodRetention{},
+			phase:       corev1.PodSucceeded,
+		},
+		{
+			description:   "failed pod within the default failed pods limit",
+			retention:     &v1alpha1.RunnerPodRetention{},
+			phase:         corev1.PodFailed,
+			want:          true,
+			wantTTL:       "0s",
+			wantLimit:     "3",
+			wantOwnerName: "example",
+		},
+		{
+			description: "failed pod without the failed pods limit",
+			retention:   &v1alpha1.RunnerPodRetention{FailedPodsLimit: intPtr(0)},
+			phase:       corev1.PodFailed,
+		},
+		{
+			description:   "succeeded pod with ttl",
+			retention:     &v1alpha1.RunnerPodRetention{TTL: &metav1.Duration{Duration: time.Hour}, FailedPodsLimit: intPtr(1)},
+			phase:         corev1.PodSucceeded,
+			want:          true,
+			wantTTL:       "1h0m0s",
+			wantLimit:     "1",
+			wantOwnerName: "example",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := context.Background()
+
+			pod := newPod(tc.phase)
+
+			c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(rs, pod).Build()
+
+			got, err := retainRunnerPod(ctx, c, logr.Discard(), newRunner(tc.retention), pod, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("unexpected result: want %v, got %v", tc.want, got)
+			}
+
+			var updated corev1.Pod
+			if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: pod.Name}, &updated); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tc.want {
+				if _, ok := updated.Labels[LabelKeyRetainedRunnerPool]; ok {
+					t.Errorf("unexpected retention: %v", updated.Labels)
+				}
+				return
+			}
+
+			if updated.Labels[LabelKeyRetainedRunnerPool] != "example" {
+				t.Errorf("unexpected pool: want example, got %q", updated.Labels[LabelKeyRetainedRunnerPool])
+			}
+
+			for _, k := range []string{LabelKeyRunnerSetName, LabelKeyRunnerDeploymentName} {
+				if _, ok := updated.Labels[k]; ok {
+					t.Errorf("the runner pod label %s is not removed", k)
+				}
+			}
+
+			if len(updated.Finalizers) != 0 {
+				t.Errorf("unexpected finalizers: %v", updated.Finalizers)
+			}
+
+			if len(updated.OwnerReferences) != 1 || updated.OwnerReferences[0].Kind != "RunnerDeployment" || updated.OwnerReferences[0].Name != tc.wantOwnerName {
+				t.Errorf("unexpected owner references: %+v", updated.OwnerReferences)
+			}
+
+			want := map[string]string{
+				AnnotationKeyRetainedAt:               now.Format(time.RFC3339),
+				AnnotationKeyRetentionTTL:             tc.wantTTL,
+				AnnotationKeyRetentionFailedPodsLimit: tc.wantLimit,
+			}
+
+			if !reflect.DeepEqual(updated.Annotations, want) {
+				t.Errorf("unexpected annotations: want %v, got %v", want, updated.Annotations)
+			}
+		})
+	}
+}
+
+func TestExpiredRetainedRunnerPods(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	newPod := func(name string, phase corev1.PodPhase, retainedAgo time.Duration, ttl string, limit string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{LabelKeyRetainedRunnerPool: "example"},
+				Annotations: map[string]string{
+					AnnotationKeyRetainedAt:               now.Add(-retainedAgo).Format(time.RFC3339),
+					AnnotationKeyRetentionTTL:             ttl,
+					AnnotationKeyRetentionFailedPodsLimit: limit,
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		pods        []corev1.Pod
+
+		want         []string
+		requeueAfter time.Duration
+	}{
+		{
+			description: "succeeded pods expire on ttl",
+			pods: []corev1.Pod{
+				newPod("expired", corev1.PodSucceeded, 2*time.Hour, "1h", "3"),
+				newPod("retained", corev1.PodSucceeded, 20*time.Minute, "1h", "3"),
+			},
+			want:         []string{"expired"},
+			requeueAfter: 40 * time.Minute,
+		},
+		{
+			description: "the latest failed pods are kept within the limit",
+			pods: []corev1.Pod{
+				newPod("oldest", corev1.PodFailed, 3*time.Hour, "1h", "2"),
+				newPod("older", corev1.PodFailed, 2*time.Hour, "1h", "2"),
+				newPod("latest", corev1.PodFailed, 1*time.Hour, "1h", "2"),
+				newPod("succeeded", corev1.PodSucceeded, 30*time.Minute, "0s", "2"),
+			},
+			want: []string{"oldest", "succeeded"},
+		},
+		{
+			description: "failed pods beyond the limit are kept until ttl",
+			pods: []corev1.Pod{
+				newPod("older", corev1.PodFailed, 20*time.Minute, "1h", "1"),
+				newPod("latest", corev1.PodFailed, 10*time.Minute, "1h", "1"),
+			},
+			requeueAfter: 40 * time.Minute,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			expired, requeueAfter := expiredRetainedRunnerPods(tc.pods, now)
+
+			var got []string
+			for name := range expired {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected expired pods: want %v, got %v", tc.want, got)
+			}
+
+			if requeueAfter != tc.requeueAfter {
+				t.Errorf("unexpected requeue: want %v, got %v", tc.requeueAfter, requeueAfter)
+			}
+		})
+	}
+}
+
+func TestRunnerPodRetentionReconciler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	newPod := func(name string, retainedAgo time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{LabelKeyRetainedRunnerPool: "example"},
+				Annotations: map[string]string{
+					AnnotationKeyRetainedAt:   now.Add(-retainedAgo).Format(time.RFC3339),
+					AnnotationKeyRetentionTTL: "1h",
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		}
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		newPod("expired", 2*time.Hour),
+		newPod("retained", 30*time.Minute),
+	).Build()
+
+	r := &RunnerPodRetentionReconciler{
+		Client: c,
+		Log:    logr.Discard(),
+		now:    func() time.Time { return now },
+	}
+
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "retained"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.RequeueAfter != 30*time.Minute {
+		t.Errorf("unexpected requeue: want %v, got %v", 30*time.Minute, res.RequeueAfter)
+	}
+
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, client.InNamespace("default")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pods.Items) != 1 || pods.Items[0].Name != "retained" {
+		t.Errorf("unexpected pods after reconciliation: %v", pods.Items)
+	}
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// applyRunnerPodTemplate merges the podTemplate of the runner into the runner pod as a strategic merge patch,
+// so that any pod field can be set without being plumbed through the runner spec.
+func applyRunnerPodTemplate(pod *corev1.Pod, template *runtime.RawExtension) error {
+	if template == nil || len(template.Raw) == 0 {
+		return nil
+	}
+
+	original, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+
+	patched, err := strategicpatch.StrategicMergePatch(original, template.Raw, corev1.Pod{})
+	if err != nil {
+		return fmt.Errorf("applying podTemplate: %w", err)
+	}
+
+	var updated corev1.Pod
+	if err := json.Unmarshal(patched, &updated); err != nil {
+		return fmt.Errorf("applying podTemplate: %w", err)
+	}
+
+	*pod = updated
+
+	return nil
+}
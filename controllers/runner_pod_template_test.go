@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNewPod_PodTemplate(t *testing.T) {
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				Volumes: []corev1.Volume{{Name: "cache"}},
+				PodTemplate: &runtime.RawExtension{Raw: []byte(`{
+  "metadata": {"annotations": {"vault.example.com/agent-inject": "true"}},
+  "spec": {
+    "runtimeClassName": "gvisor",
+    "containers": [
+      {"name": "runner", "resources": {"limits": {"memory": "4Gi"}}},
+      {"name": "secrets-agent", "image": "secrets-agent:v1"}
+    ],
+    "initContainers": [{"name": "warmup", "image": "warmup:v1"}],
+    "volumes": [{"name": "secrets", "emptyDir": {"medium": "Memory"}}],
+    "topologySpreadConstraints": [{"maxSkew": 1, "topologyKey": "topology.kubernetes.io/zone", "whenUnsatisfiable": "ScheduleAnyway"}]
+  }
+}`)},
+			},
+		},
+	}
+
+	r := &RunnerReconciler{
+		RunnerImage:  "default-runner-image",
+		DockerImage:  "default-docker-image",
+		GitHubClient: &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:       sc,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pod.Name != "runner" || pod.Namespace != "default" {
+		t.Errorf("unexpected pod: %s/%s", pod.Namespace, pod.Name)
+	}
+
+	if pod.Annotations["vault.example.com/agent-inject"] != "true" {
+		t.Errorf("annotation is not merged: %v", pod.Annotations)
+	}
+
+	if pod.Spec.RuntimeClassName == nil || *pod.Spec.RuntimeClassName != "gvisor" {
+		t.Errorf("runtimeClassName is not merged: %v", pod.Spec.RuntimeClassName)
+	}
+
+	containers := map[string]corev1.Container{}
+	for _, c := range pod.Spec.Containers {
+		containers[c.Name] = c
+	}
+
+	for _, name := range []string{"runner", "docker", "secrets-agent"} {
+		if _, ok := containers[name]; !ok {
+			t.Errorf("container %s is missing: %v", name, pod.Spec.Containers)
+		}
+	}
+
+	runnerContainer := containers["runner"]
+	if runnerContainer.Image != "default-runner-image" || getRunnerEnv(&pod, EnvVarRunnerName) != "runner" {
+		t.Errorf("the runner container is not merged with the generated one: %+v", runnerContainer)
+	}
+
+	if got := runnerContainer.Resources.Limits[corev1.ResourceMemory]; !got.Equal(resource.MustParse("4Gi")) {
+		t.Errorf("unexpected memory limit of the runner container: %v", got)
+	}
+
+	if len(pod.Spec.InitContainers) != 1 || pod.Spec.InitContainers[0].Name != "warmup" {
+		t.Errorf("unexpected init containers: %v", pod.Spec.InitContainers)
+	}
+
+	volumes := map[string]bool{}
+	for _, v := range pod.Spec.Volumes {
+		volumes[v.Name] = true
+	}
+
+	for _, name := range []string{"work", "cache", "secrets"} {
+		if !volumes[name] {
+			t.Errorf("volume %s is missing: %v", name, pod.Spec.Volumes)
+		}
+	}
+
+	if len(pod.Spec.TopologySpreadConstraints) != 1 {
+		t.Errorf("unexpected topology spread constraints: %v", pod.Spec.TopologySpreadConstraints)
+	}
+
+	if ref := metav1.GetControllerOf(&pod); ref == nil || ref.Name != "runner" {
+		t.Errorf("unexpected controller: %v", ref)
+	}
+}
+
+func TestApplyRunnerPodTemplate_Invalid(t *testing.T) {
+	pod := corev1.Pod{}
+
+	if err := applyRunnerPodTemplate(&pod, &runtime.RawExtension{Raw: []byte(`{"spec": {"containers": "runner"}}`)}); err == nil {
+		t.Errorf("expected an error for the invalid pod template")
+	}
+}
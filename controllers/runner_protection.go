@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKeyProtected can be set to "true" on a runner or its pod to protect it from being deleted by the controllers
+	// on scale-in, rolling update, and version drift recycling, e.g. while an operator is debugging it.
+	AnnotationKeyProtected = "actions-runner-controller/protected"
+
+	// AnnotationKeyProtectedUntil can be set along with AnnotationKeyProtected to an RFC3339 timestamp
+	// after which the runner is no longer protected.
+	AnnotationKeyProtectedUntil = "actions-runner-controller/protected-until"
+)
+
+// isProtected returns true if the object has the protection annotation that hasn't expired as of now.
+// A malformed expiry is ignored so that a typo doesn't silently remove the protection.
+func isProtected(o client.Object, now time.Time) bool {
+	if v, _ := getAnnotation(o, AnnotationKeyProtected); v != "true" {
+		return false
+	}
+
+	until, ok := getAnnotation(o, AnnotationKeyProtectedUntil)
+	if !ok {
+		return true
+	}
+
+	t, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		return true
+	}
+
+	return now.Before(t)
+}
+
+// protected returns true if either the owner or any of its pods is protected.
+func (p *podsForOwner) protected(now time.Time) bool {
+	if isProtected(p.owner, now) {
+		return true
+	}
+
+	for i := range p.pods {
+		if isProtected(&p.pods[i], now) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsProtected(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		annotations map[string]string
+		want        bool
+	}{
+		{annotations: nil, want: false},
+		{annotations: map[string]string{AnnotationKeyProtected: "false"}, want: false},
+		{annotations: map[string]string{AnnotationKeyProtected: "true"}, want: true},
+		{annotations: map[string]string{AnnotationKeyProtected: "true", AnnotationKeyProtectedUntil: "2022-03-01T11:00:00Z"}, want: true},
+		{annotations: map[string]string{AnnotationKeyProtected: "true", AnnotationKeyProtectedUntil: "2022-03-01T09:00:00Z"}, want: false},
+		{annotations: map[string]string{AnnotationKeyProtected: "true", AnnotationKeyProtectedUntil: "tomorrow"}, want: true},
+		{annotations: map[string]string{AnnotationKeyProtectedUntil: "2022-03-01T11:00:00Z"}, want: false},
+	}
+
+	for i, tc := range testcases {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+
+		if got := isProtected(pod, now); got != tc.want {
+			t.Errorf("[%d] unexpected result: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestSyncRunnerPodsOwners_Protected(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	newRunner := func(name string, offset time.Duration, protected bool) *v1alpha1.Runner {
+		r := &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created.Add(offset)),
+				Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+			},
+			Status: v1alpha1.RunnerStatus{Phase: "Running"},
+		}
+
+		if protected {
+			r.Annotations = map[string]string{AnnotationKeyProtected: "true"}
+		}
+
+		return r
+	}
+
+	newRunnerPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	testcases := []struct {
+		description  string
+		replicas     int
+		protected    []string
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			description:  "oldest runners are deleted by default",
+			replicas:     1,
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+		{
+			description:  "protected runner is retained in place of a newer one",
+			replicas:     1,
+			protected:    []string{"runner-1"},
+			wantDeleted:  []string{"runner-2", "runner-3"},
+			wantRetained: []string{"runner-1"},
+		},
+		{
+			description:  "protected runners are retained beyond the desired replicas",
+			replicas:     0,
+			protected:    []string{"runner-1", "runner-3"},
+			wantDeleted:  []string{"runner-2"},
+			wantRetained: []string{"runner-1", "runner-3"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			protected := map[string]bool{}
+			for _, name := range tc.protected {
+				protected[name] = true
+			}
+
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			for i, name := range []string{"runner-1", "runner-2", "runner-3"} {
+				r := newRunner(name, time.Duration(i)*time.Minute, protected[name])
+				objs = append(objs, r, newRunnerPod(name))
+				owners = append(owners, r)
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := newRunner("desired", 0, false)
+
+			_, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, tc.replicas, desired, func() client.Object { return desired.DeepCopy() }, false, false, "", 0, nil, nil, owners)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			check := func(names []string, wantDeleted bool) {
+				for _, name := range names {
+					var runner v1alpha1.Runner
+					if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+						t.Fatal(err)
+					}
+
+					if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted {
+						t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted, deleted)
+					}
+				}
+			}
+
+			check(tc.wantDeleted, true)
+			check(tc.wantRetained, false)
+		})
+	}
+}
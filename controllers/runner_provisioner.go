@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultProvisionerPollInterval is the interval at which the runner controller asks the provisioner for
+	// the status of the provisioned machine.
+	DefaultProvisionerPollInterval = 30 * time.Second
+)
+
+// reconcileProvisionedRunner is the counterpart of the pod creation and the pod status sync in Reconcile,
+// for a runner that is backed by the runner provisioner.
+func (r *RunnerReconciler) reconcileProvisionedRunner(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) (ctrl.Result, error) {
+	p, ok := r.Provisioners[runner.Spec.Provisioner]
+	if !ok {
+		r.Recorder.Event(&runner, corev1.EventTypeWarning, "ProvisionerNotFound", fmt.Sprintf("Runner provisioner %q is not registered to the controller", runner.Spec.Provisioner))
+		log.Info("Runner provisioner is not registered to the controller. Add it via the --runner-provisioner flag", "provisioner", runner.Spec.Provisioner)
+
+		return ctrl.Result{}, nil
+	}
+
+	if runner.Status.InstanceID == "" {
+		if r.DrainMode {
+			log.V(1).Info("Skipped provisioning runner instance in drain mode")
+
+			return ctrl.Result{}, nil
+		}
+
+		if updated, err := r.updateRegistrationToken(ctx, runner); err != nil {
+			return ctrl.Result{RequeueAfter: RetryDelayOnCreateRegistrationError}, nil
+		} else if updated {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		req := r.provisionerRunner(runner)
+		req.Token = runner.Status.Registration.Token
+
+		instance, err := p.Provision(ctx, req)
+		if err != nil {
+			r.Recorder.Event(&runner, corev1.EventTypeWarning, "FailedProvision", err.Error())
+			log.Error(err, "Failed to provision runner instance")
+
+			return ctrl.Result{}, err
+		}
+
+		updated := runner.DeepCopy()
+		updated.Status.InstanceID = instance.ID
+		updated.Status.Phase = instance.Phase
+		updated.Status.Ready = instance.Phase == provisioner.PhaseRunning
+		updated.Status.Message = instance.Message
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
+			// The provisioner is expected to return the same instance for the same runner on retry.
+			log.Error(err, "Failed to update runner status for InstanceID")
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Event(&runner, corev1.EventTypeNormal, "InstanceProvisioned", fmt.Sprintf("Provisioned instance '%s'", instance.ID))
+		log.Info("Provisioned runner instance", "repository", runner.Spec.Repository, "instanceID", instance.ID)
+
+		return ctrl.Result{RequeueAfter: r.provisionerPollInterval()}, nil
+	}
+
+	instance, err := p.Status(ctx, r.provisionerRunner(runner), runner.Status.InstanceID)
+	if err != nil {
+		log.Error(err, "Failed to get runner instance status", "instanceID", runner.Status.InstanceID)
+		return ctrl.Result{}, err
+	}
+
+	ready := instance.Phase == provisioner.PhaseRunning
+
+	if runner.Status.Phase != instance.Phase || runner.Status.Ready != ready || runner.Status.Message != instance.Message {
+		updated := runner.DeepCopy()
+		updated.Status.Phase = instance.Phase
+		updated.Status.Ready = ready
+		updated.Status.Message = instance.Message
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&runner)); err != nil {
+			log.Error(err, "Failed to update runner status for Phase/Message")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: r.provisionerPollInterval()}, nil
+}
+
+// processProvisionedRunnerDeletion unregisters the runner from GitHub and deprovisions the machine
+// before removing the finalizer.
+// Like a runner pod, a busy runner is never deprovisioned until it completes the job.
+func (r *RunnerReconciler) processProvisionedRunnerDeletion(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) (ctrl.Result, error) {
+	if runner.Status.InstanceID == "" {
+		return r.processRunnerDeletion(runner, ctx, log, nil)
+	}
+
+	p, ok := r.Provisioners[runner.Spec.Provisioner]
+	if !ok {
+		log.Info("Unable to deprovision runner instance because the runner provisioner is not registered to the controller", "provisioner", runner.Spec.Provisioner)
+
+		return ctrl.Result{RequeueAfter: r.unregistrationRetryDelay()}, nil
+	}
+
+	stopped := runner.Status.Phase == provisioner.PhaseSucceeded || runner.Status.Phase == provisioner.PhaseFailed
+
+	if !stopped {
+		if res, err := r.unregisterProvisionedRunner(ctx, runner, log); res != nil || err != nil {
+			return *res, err
+		}
+	}
+
+	instance, err := p.Deprovision(ctx, r.provisionerRunner(runner), runner.Status.InstanceID)
+	if err != nil {
+		log.Error(err, "Failed to deprovision runner instance", "instanceID", runner.Status.InstanceID)
+		return ctrl.Result{}, err
+	}
+
+	if instance.Phase != provisioner.PhaseDeleted {
+		log.V(1).Info("Waiting for runner instance to be deprovisioned", "instanceID", runner.Status.InstanceID, "phase", instance.Phase)
+
+		return ctrl.Result{RequeueAfter: r.provisionerPollInterval()}, nil
+	}
+
+	r.Recorder.Event(&runner, corev1.EventTypeNormal, "InstanceDeprovisioned", fmt.Sprintf("Deprovisioned instance '%s'", runner.Status.InstanceID))
+	log.Info("Deprovisioned runner instance", "instanceID", runner.Status.InstanceID)
+
+	return r.processRunnerDeletion(runner, ctx, log, nil)
+}
+
+// unregisterProvisionedRunner returns a non-nil result when the runner is still busy or the unregistration needs to be retried.
+func (r *RunnerReconciler) unregisterProvisionedRunner(ctx context.Context, runner v1alpha1.Runner, log logr.Logger) (*ctrl.Result, error) {
+	enterprise, org, repo := runner.Spec.Enterprise, runner.Spec.Organization, runner.Spec.Repository
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	ghRunner, err := getRunner(ctx, ghc, enterprise, org, repo, runner.Name)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+
+	if ghRunner == nil || ghRunner.ID == nil {
+		log.Info("Runner was not found on GitHub. Deprovisioning the instance anyway because there's nothing ARC can unregister.")
+
+		return nil, nil
+	}
+
+	if _, err := unregisterRunner(ctx, ghc, enterprise, org, repo, runner.Name, *ghRunner.ID); err != nil {
+		if errors.Is(err, &gogithub.RateLimitError{}) {
+			return &ctrl.Result{RequeueAfter: retryDelayOnGitHubAPIRateLimitError}, err
+		}
+
+		errRes := &gogithub.ErrorResponse{}
+		if errors.As(err, &errRes) && errRes.Response.StatusCode == 422 {
+			log.V(2).Info("Retrying runner unregistration because the runner is still busy")
+
+			return &ctrl.Result{RequeueAfter: r.unregistrationRetryDelay()}, nil
+		}
+
+		return &ctrl.Result{}, err
+	}
+
+	log.Info("Runner has just been unregistered.")
+
+	return nil, nil
+}
+
+func (r *RunnerReconciler) provisionerRunner(runner v1alpha1.Runner) provisioner.Runner {
+	return provisioner.Runner{
+		Namespace:    runner.Namespace,
+		Name:         runner.Name,
+		Enterprise:   runner.Spec.Enterprise,
+		Organization: runner.Spec.Organization,
+		Repository:   runner.Spec.Repository,
+		Labels:       runner.Spec.Labels,
+		Group:        runner.Spec.Group,
+		Ephemeral:    runner.Spec.Ephemeral == nil || *runner.Spec.Ephemeral,
+		GitHubURL:    r.GitHubClient.GithubBaseURL,
+	}
+}
+
+func (r *RunnerReconciler) provisionerPollInterval() time.Duration {
+	if r.ProvisionerPollInterval > 0 {
+		return r.ProvisionerPollInterval
+	}
+
+	return DefaultProvisionerPollInterval
+}
+
+func (r *RunnerReconciler) unregistrationRetryDelay() time.Duration {
+	if r.UnregistrationRetryDelay > 0 {
+		return r.UnregistrationRetryDelay
+	}
+
+	return DefaultUnregistrationRetryDelay
+}
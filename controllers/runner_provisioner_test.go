@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeProvisioner struct {
+	phase   string
+	actions []string
+	token   string
+}
+
+func (p *fakeProvisioner) Provision(ctx context.Context, runner provisioner.Runner) (*provisioner.Instance, error) {
+	p.actions = append(p.actions, provisioner.ActionProvision)
+	p.token = runner.Token
+
+	return &provisioner.Instance{ID: "i-" + runner.Name, Phase: provisioner.PhasePending}, nil
+}
+
+func (p *fakeProvisioner) Status(ctx context.Context, runner provisioner.Runner, instanceID string) (*provisioner.Instance, error) {
+	p.actions = append(p.actions, provisioner.ActionStatus)
+
+	return &provisioner.Instance{ID: instanceID, Phase: p.phase}, nil
+}
+
+func (p *fakeProvisioner) Deprovision(ctx context.Context, runner provisioner.Runner, instanceID string) (*provisioner.Instance, error) {
+	p.actions = append(p.actions, provisioner.ActionDeprovision)
+
+	return &provisioner.Instance{ID: instanceID, Phase: provisioner.PhaseDeleted}, nil
+}
+
+func TestReconcileProvisionedRunner(t *testing.T) {
+	ctx := context.Background()
+
+	runners := fake.NewRunnersList()
+	runners.Add(&gogithub.Runner{ID: gogithub.Int64(1), Name: gogithub.String("mac-runner"), OS: gogithub.String("macos"), Status: gogithub.String("online")})
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	runner := &v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "mac-runner",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			Provisioner:  "mac",
+		},
+		Status: v1alpha1.RunnerStatus{
+			Registration: v1alpha1.RunnerStatusRegistration{
+				Repository: "test/valid",
+				Token:      "registration-token",
+				ExpiresAt:  metav1.NewTime(time.Now().Add(time.Hour)),
+			},
+		},
+	}
+
+	p := &fakeProvisioner{phase: provisioner.PhaseRunning}
+
+	client := clientfake.NewFakeClientWithScheme(sc, runner)
+
+	r := &RunnerReconciler{
+		Client:       client,
+		Log:          logr.Discard(),
+		Recorder:     record.NewFakeRecorder(10),
+		GitHubClient: newGithubClient(server),
+		Provisioners: map[string]provisioner.Provisioner{"mac": p},
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "mac-runner"}
+	req := ctrl.Request{NamespacedName: key}
+
+	get := func() v1alpha1.Runner {
+		t.Helper()
+
+		var got v1alpha1.Runner
+		if err := client.Get(ctx, key, &got); err != nil {
+			t.Fatalf("getting runner: %v", err)
+		}
+
+		return got
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("provisioning: %v", err)
+	}
+
+	if got := get(); got.Status.InstanceID != "i-mac-runner" || got.Status.Phase != provisioner.PhasePending || got.Status.Ready {
+		t.Errorf("unexpected status after provisioning: %+v", got.Status)
+	}
+
+	if p.token != "registration-token" {
+		t.Errorf("unexpected registration token passed to the provisioner: %q", p.token)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("syncing status: %v", err)
+	}
+
+	if got := get(); got.Status.Phase != provisioner.PhaseRunning || !got.Status.Ready {
+		t.Errorf("unexpected status after sync: %+v", got.Status)
+	}
+
+	running := get()
+	if err := client.Delete(ctx, &running); err != nil {
+		t.Fatalf("deleting runner: %v", err)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("deprovisioning: %v", err)
+	}
+
+	var deleted v1alpha1.Runner
+	if err := client.Get(ctx, key, &deleted); !kerrors.IsNotFound(err) {
+		t.Errorf("expected the runner to be deleted, got %v: %+v", err, deleted)
+	}
+
+	if rs, err := r.GitHubClient.ListRunners(ctx, "", "", "test/valid"); err != nil || len(rs) != 0 {
+		t.Errorf("expected the runner to be unregistered, got %v: %v", err, rs)
+	}
+
+	want := []string{provisioner.ActionProvision, provisioner.ActionStatus, provisioner.ActionDeprovision}
+	if len(p.actions) != len(want) {
+		t.Fatalf("unexpected provisioner actions: want %v, got %v", want, p.actions)
+	}
+	for i := range want {
+		if p.actions[i] != want[i] {
+			t.Errorf("unexpected provisioner actions: want %v, got %v", want, p.actions)
+		}
+	}
+}
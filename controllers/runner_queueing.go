@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	RunnerQueueingProviderKueue   = "kueue"
+	RunnerQueueingProviderVolcano = "volcano"
+
+	// LabelKeyKueueQueueName makes Kueue gate the pod until its workload is admitted to the LocalQueue.
+	LabelKeyKueueQueueName = "kueue.x-k8s.io/queue-name"
+
+	// AnnotationKeyVolcanoQueueName makes Volcano schedule the pod within the capacity of the Queue.
+	AnnotationKeyVolcanoQueueName = "scheduling.volcano.sh/queue-name"
+
+	volcanoSchedulerName = "volcano"
+
+	// podReasonSchedulingGated is the reason of the PodScheduled condition of a pod that is waiting for its scheduling gates,
+	// like the one Kueue adds until the pod is admitted.
+	podReasonSchedulingGated = "SchedulingGated"
+)
+
+// applyRunnerQueueing labels and annotates the runner pod so that it's submitted through the queueing system.
+// Each runner pod is queued on its own rather than as a gang, as every runner is able to run a job without the others.
+func applyRunnerQueueing(pod *corev1.Pod, queueing *v1alpha1.RunnerQueueing) {
+	if queueing == nil {
+		return
+	}
+
+	switch queueing.Provider {
+	case RunnerQueueingProviderKueue:
+		pod.ObjectMeta.Labels = CloneAndAddLabel(pod.ObjectMeta.Labels, LabelKeyKueueQueueName, queueing.QueueName)
+	case RunnerQueueingProviderVolcano:
+		setAnnotation(&pod.ObjectMeta, AnnotationKeyVolcanoQueueName, queueing.QueueName)
+
+		if pod.Spec.SchedulerName == "" {
+			pod.Spec.SchedulerName = volcanoSchedulerName
+		}
+	}
+}
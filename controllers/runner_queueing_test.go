@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyRunnerQueueing(t *testing.T) {
+	testcases := []struct {
+		description   string
+		queueing      *v1alpha1.RunnerQueueing
+		schedulerName string
+		wantLabel     string
+		wantAnnot     string
+		wantScheduler string
+	}{
+		{
+			description: "no queueing",
+		},
+		{
+			description: "kueue",
+			queueing:    &v1alpha1.RunnerQueueing{Provider: RunnerQueueingProviderKueue, QueueName: "ci"},
+			wantLabel:   "ci",
+		},
+		{
+			description:   "volcano",
+			queueing:      &v1alpha1.RunnerQueueing{Provider: RunnerQueueingProviderVolcano, QueueName: "ci"},
+			wantAnnot:     "ci",
+			wantScheduler: "volcano",
+		},
+		{
+			description:   "volcano with custom scheduler",
+			queueing:      &v1alpha1.RunnerQueueing{Provider: RunnerQueueingProviderVolcano, QueueName: "ci"},
+			schedulerName: "volcano-ci",
+			wantAnnot:     "ci",
+			wantScheduler: "volcano-ci",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			pod := corev1.Pod{}
+			pod.Spec.SchedulerName = tc.schedulerName
+
+			applyRunnerQueueing(&pod, tc.queueing)
+
+			if got := pod.Labels[LabelKeyKueueQueueName]; got != tc.wantLabel {
+				t.Errorf("unexpected kueue queue name: want %q, got %q", tc.wantLabel, got)
+			}
+
+			if got := pod.Annotations[AnnotationKeyVolcanoQueueName]; got != tc.wantAnnot {
+				t.Errorf("unexpected volcano queue name: want %q, got %q", tc.wantAnnot, got)
+			}
+
+			if pod.Spec.SchedulerName != tc.wantScheduler {
+				t.Errorf("unexpected scheduler name: want %q, got %q", tc.wantScheduler, pod.Spec.SchedulerName)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultRunnerRegistrationGCInterval         = 10 * time.Minute
+	DefaultRunnerRegistrationGCOfflineThreshold = 1 * time.Hour
+)
+
+// RunnerRegistrationGC periodically removes the orphaned runner registrations from GitHub, which are
+// the offline runners left registered without live runners, like the runners whose nodes crashed before they were unregistered.
+// They otherwise count against the limit of the number of runners and skew the PercentageRunnersBusy metric.
+//
+// Only the runners named after the RunnerDeployments and the RunnerSets in the cluster are considered managed by the controller,
+// so that the other self-hosted runners registered to the same organizations and repositories are never removed.
+// A registration is removed once it's been seen offline without a live runner for OfflineThreshold, as GitHub doesn't tell
+// since when a runner is offline.
+//
+// The list runners API is called once per registration scope and credentials on each collection,
+// which is usually served from the GitHub API cache.
+type RunnerRegistrationGC struct {
+	client.Client
+	Log           logr.Logger
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	Interval         time.Duration
+	OfflineThreshold time.Duration
+	Namespace        string
+
+	// offlineSince is the time each orphaned registration was first seen offline.
+	offlineSince map[runnerRegistrationKey]time.Time
+}
+
+type runnerRegistrationScope struct {
+	runnerScope
+
+	credentials string
+}
+
+type runnerRegistrationKey struct {
+	runnerRegistrationScope
+
+	id int64
+}
+
+// runnerRegistrationPools are the runner pools registered to a registration scope.
+type runnerRegistrationPools struct {
+	namespace       string
+	credentialsFrom *v1alpha1.GitHubAPICredentialsFrom
+
+	// prefixes are the prefixes of the names of the runners of the runner pools.
+	prefixes []string
+}
+
+func (p *runnerRegistrationPools) manages(name string) bool {
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader removes runner registrations.
+func (gc *RunnerRegistrationGC) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (gc *RunnerRegistrationGC) Start(ctx context.Context) error {
+	interval := gc.Interval
+	if interval <= 0 {
+		interval = DefaultRunnerRegistrationGCInterval
+	}
+
+	gc.Log.Info("Starting runner registration garbage collection", "interval", interval, "offline_threshold", gc.offlineThreshold())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := gc.collect(ctx, time.Now()); err != nil {
+			gc.Log.Error(err, "Failed to collect orphaned runner registrations")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (gc *RunnerRegistrationGC) offlineThreshold() time.Duration {
+	if gc.OfflineThreshold <= 0 {
+		return DefaultRunnerRegistrationGCOfflineThreshold
+	}
+
+	return gc.OfflineThreshold
+}
+
+func (gc *RunnerRegistrationGC) collect(ctx context.Context, now time.Time) error {
+	scopes, live, err := gc.listRunnerRegistrationPools(ctx)
+	if err != nil {
+		return err
+	}
+
+	offlineSince := map[runnerRegistrationKey]time.Time{}
+
+	for scope, pools := range scopes {
+		log := gc.Log.WithValues("enterprise", scope.enterprise, "organization", scope.org, "repository", scope.repo)
+
+		ghc, err := gc.GitHubClients.ClientFor(ctx, gc.GitHubClient, pools.namespace, pools.credentialsFrom)
+		if err != nil {
+			log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+			gc.keepOfflineSince(offlineSince, scope)
+			continue
+		}
+
+		ghRunners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo)
+		if err != nil {
+			log.Error(err, "Failed to list runners on GitHub")
+			gc.keepOfflineSince(offlineSince, scope)
+			continue
+		}
+
+		var orphaned int
+
+		for _, r := range ghRunners {
+			name := r.GetName()
+
+			if r.GetStatus() != "offline" || live[name] || !pools.manages(name) {
+				continue
+			}
+
+			key := runnerRegistrationKey{runnerRegistrationScope: scope, id: r.GetID()}
+
+			since, ok := gc.offlineSince[key]
+			if !ok {
+				since = now
+			}
+
+			if now.Sub(since) < gc.offlineThreshold() {
+				offlineSince[key] = since
+				orphaned++
+				continue
+			}
+
+			if err := ghc.RemoveRunner(ctx, scope.enterprise, scope.org, scope.repo, r.GetID()); err != nil {
+				log.Error(err, "Failed to remove orphaned runner registration", "runner", name, "id", r.GetID())
+				offlineSince[key] = since
+				orphaned++
+				continue
+			}
+
+			metrics.IncRunnerRegistrationGCRemoved(scope.enterprise, scope.org, scope.repo)
+
+			log.Info("Removed orphaned runner registration", "runner", name, "id", r.GetID(), "offline_since", since)
+		}
+
+		metrics.SetRunnerRegistrationGCOrphaned(scope.enterprise, scope.org, scope.repo, orphaned)
+	}
+
+	gc.offlineSince = offlineSince
+
+	return nil
+}
+
+// keepOfflineSince carries over the offline times of the registrations of the scope that couldn't be listed,
+// so that a transient GitHub API failure doesn't reset them.
+func (gc *RunnerRegistrationGC) keepOfflineSince(offlineSince map[runnerRegistrationKey]time.Time, scope runnerRegistrationScope) {
+	for key, since := range gc.offlineSince {
+		if key.runnerRegistrationScope == scope {
+			offlineSince[key] = since
+		}
+	}
+}
+
+// listRunnerRegistrationPools returns the runner pools by the registration scopes,
+// and the names of the live runners, which are the runners and the runner pods in the cluster.
+func (gc *RunnerRegistrationGC) listRunnerRegistrationPools(ctx context.Context) (map[runnerRegistrationScope]*runnerRegistrationPools, map[string]bool, error) {
+	var opts []client.ListOption
+	if gc.Namespace != "" {
+		opts = append(opts, client.InNamespace(gc.Namespace))
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := gc.List(ctx, &rds, opts...); err != nil {
+		return nil, nil, err
+	}
+
+	var runnerSets v1alpha1.RunnerSetList
+	if err := gc.List(ctx, &runnerSets, opts...); err != nil {
+		return nil, nil, err
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := gc.List(ctx, &runners, opts...); err != nil {
+		return nil, nil, err
+	}
+
+	var pods corev1.PodList
+	if err := gc.List(ctx, &pods, append(opts, client.HasLabels{LabelKeyRunnerSetName})...); err != nil {
+		return nil, nil, err
+	}
+
+	scopes := map[runnerRegistrationScope]*runnerRegistrationPools{}
+
+	poolsFor := func(namespace string, config v1alpha1.RunnerConfig) *runnerRegistrationPools {
+		scope := runnerRegistrationScope{
+			runnerScope: runnerScope{
+				enterprise: config.Enterprise,
+				org:        config.Organization,
+				repo:       config.Repository,
+			},
+			credentials: githubAPICredentialsKey(namespace, config.GitHubAPICredentialsFrom),
+		}
+
+		pools, ok := scopes[scope]
+		if !ok {
+			pools = &runnerRegistrationPools{
+				namespace:       namespace,
+				credentialsFrom: config.GitHubAPICredentialsFrom,
+			}
+			scopes[scope] = pools
+		}
+
+		return pools
+	}
+
+	for _, rd := range rds.Items {
+		pools := poolsFor(rd.Namespace, rd.Spec.Template.Spec.RunnerConfig)
+		pools.prefixes = append(pools.prefixes, rd.Name+"-")
+	}
+
+	for _, rs := range runnerSets.Items {
+		pools := poolsFor(rs.Namespace, rs.Spec.RunnerConfig)
+		pools.prefixes = append(pools.prefixes, rs.Name+"-")
+	}
+
+	live := map[string]bool{}
+
+	for _, r := range runners.Items {
+		live[r.Name] = true
+	}
+
+	for _, pod := range pods.Items {
+		live[pod.Name] = true
+	}
+
+	return scopes, live, nil
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	ghfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerRegistrationGC(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	config := v1alpha1.RunnerConfig{Repository: "test/valid"}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{Spec: v1alpha1.RunnerSpec{RunnerConfig: config}},
+			},
+		},
+		&v1alpha1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-set"},
+			Spec:       v1alpha1.RunnerSetSpec{RunnerConfig: config},
+		},
+		&v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-abcde-live"},
+			Spec:       v1alpha1.RunnerSpec{RunnerConfig: config},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-set-abcde-0", Labels: map[string]string{LabelKeyRunnerSetName: "example-set"}},
+		},
+	).Build()
+
+	runners := ghfake.NewRunnersList()
+
+	add := func(id int64, name, status string) {
+		runners.Add(&github.Runner{ID: github.Int64(id), Name: github.String(name), Status: github.String(status)})
+	}
+
+	add(1, "example-abcde-ghost", "offline")
+	add(2, "example-abcde-live", "offline")
+	add(3, "example-abcde-online", "online")
+	add(4, "example-set-abcde-0", "offline")
+	add(5, "example-set-fghij-0", "offline")
+	add(6, "vm-runner", "offline")
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	gc := &RunnerRegistrationGC{
+		Client:           c,
+		Log:              logr.Discard(),
+		GitHubClient:     newGithubClient(server),
+		OfflineThreshold: time.Hour,
+	}
+
+	registered := func() []string {
+		list, err := gc.GitHubClient.ListRunners(ctx, "", "", "test/valid")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var names []string
+		for _, r := range list {
+			names = append(names, r.GetName())
+		}
+		sort.Strings(names)
+
+		return names
+	}
+
+	all := []string{"example-abcde-ghost", "example-abcde-live", "example-abcde-online", "example-set-abcde-0", "example-set-fghij-0", "vm-runner"}
+
+	testcases := []struct {
+		description string
+		now         time.Time
+		want        []string
+	}{
+		{
+			description: "orphaned registrations are first seen offline",
+			now:         now,
+			want:        all,
+		},
+		{
+			description: "orphaned registrations offline shorter than the threshold are kept",
+			now:         now.Add(59 * time.Minute),
+			want:        all,
+		},
+		{
+			description: "orphaned registrations offline longer than the threshold are removed",
+			now:         now.Add(time.Hour),
+			want:        []string{"example-abcde-live", "example-abcde-online", "example-set-abcde-0", "vm-runner"},
+		},
+	}
+
+	for _, tc := range testcases {
+		if err := gc.collect(ctx, tc.now); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.description, err)
+		}
+
+		if got := registered(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: unexpected registrations: want %v, got %v", tc.description, tc.want, got)
+		}
+	}
+
+	if len(gc.offlineSince) != 0 {
+		t.Errorf("unexpected offline registrations left: %v", gc.offlineSince)
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultRegistrationSecretTokenKey = "token"
+
+// registrationSecretEnvVar returns the runner container's environment variable that reads
+// either the registration token or the JIT config from the user-provided registration secret.
+// The kubelet keeps the runner container waiting until the secret is created, so the controller doesn't need to.
+func registrationSecretEnvVar(runnerName string, ref v1alpha1.RunnerRegistrationSecretRef) corev1.EnvVar {
+	name := ref.Name
+	if name == "" {
+		name = runnerName
+	}
+
+	envName, key := EnvVarRunnerToken, ref.TokenKey
+	if ref.JITConfigKey != "" {
+		envName, key = EnvVarRunnerJITConfig, ref.JITConfigKey
+	} else if key == "" {
+		key = defaultRegistrationSecretTokenKey
+	}
+
+	return corev1.EnvVar{
+		Name: envName,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// usesRegistrationSecret returns true when the runner pod registers the runner with the user-provided registration secret,
+// so that neither the runner controller nor the pod runner token injector should inject a registration token.
+func usesRegistrationSecret(pod *corev1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != containerName {
+			continue
+		}
+
+		for _, e := range c.Env {
+			if e.Name == EnvVarRunnerJITConfig || (e.Name == EnvVarRunnerToken && e.ValueFrom != nil) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewRunnerPodWithRegistrationSecret(t *testing.T) {
+	testcases := []struct {
+		name string
+		ref  v1alpha1.RunnerRegistrationSecretRef
+		want corev1.EnvVar
+	}{
+		{
+			name: "token with defaults",
+			ref:  v1alpha1.RunnerRegistrationSecretRef{},
+			want: corev1.EnvVar{
+				Name: EnvVarRunnerToken,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "runner"},
+					Key:                  "token",
+				}},
+			},
+		},
+		{
+			name: "jit config",
+			ref:  v1alpha1.RunnerRegistrationSecretRef{Name: "brokered", JITConfigKey: "jitconfig"},
+			want: corev1.EnvVar{
+				Name: EnvVarRunnerJITConfig,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "brokered"},
+					Key:                  "jitconfig",
+				}},
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ref := tc.ref
+			config := v1alpha1.RunnerConfig{Repository: "myorg/myrepo", RegistrationSecretRef: &ref}
+
+			pod, err := newRunnerPod("runner", corev1.Pod{}, config, "runner-image", nil, "docker-image", "", "", false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !usesRegistrationSecret(&pod) {
+				t.Fatal("expected the pod to use the registration secret")
+			}
+
+			// The controller must not overwrite the user-provided registration token with its own
+			mutated := mutatePod(&pod, "controller-token")
+
+			var got []corev1.EnvVar
+			for _, e := range mutated.Spec.Containers[0].Env {
+				if e.Name == EnvVarRunnerToken || e.Name == EnvVarRunnerJITConfig {
+					got = append(got, e)
+				}
+			}
+
+			if d := cmp.Diff([]corev1.EnvVar{tc.want}, got); d != "" {
+				t.Errorf("unexpected registration env: (-want +got)\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RepositoryAccessibleConditionType is the type of the condition set to RunnerDeployment
+	// on each check of the accessibility of the repository its runners are registered to.
+	RepositoryAccessibleConditionType = "RepositoryAccessible"
+
+	DefaultRepositoryAccessCheckInterval = 10 * time.Minute
+)
+
+// repositoryAccessCache caches the results of the repository access checks by the repository names,
+// so that RunnerDeployments are reconciled without calling the GitHub API each time.
+type repositoryAccessCache struct {
+	mu      sync.Mutex
+	entries map[string]repositoryAccessCacheEntry
+}
+
+type repositoryAccessCacheEntry struct {
+	cond      metav1.Condition
+	checkedAt time.Time
+}
+
+// repositoryInaccessibleReason returns the reason of the RepositoryAccessible condition
+// when the error from the GitHub API indicates that the repository no longer exists or is no longer accessible.
+func repositoryInaccessibleReason(err error) (string, bool) {
+	var errRes *gogithub.ErrorResponse
+	if !errors.As(err, &errRes) || errRes.Response == nil {
+		return "", false
+	}
+
+	switch errRes.Response.StatusCode {
+	case http.StatusNotFound:
+		return "NotFound", true
+	case http.StatusGone:
+		return "Gone", true
+	}
+
+	return "", false
+}
+
+// checkRepositoryAccess returns the RepositoryAccessible condition for the repository.
+// A renamed repository is considered inaccessible, as runners can't be registered with the old name even though
+// GitHub redirects the API requests to the new name.
+// It returns nil when the accessibility can't be determined, e.g. due to a transient error.
+// The results are cached per credentials, identified by credentials, as the accessibility differs among credentials.
+func (r *RunnerDeploymentReconciler) checkRepositoryAccess(ctx context.Context, log logr.Logger, ghc *github.Client, credentials, repository string) *metav1.Condition {
+	interval := r.RepositoryAccessCheckInterval
+	if interval == 0 {
+		interval = DefaultRepositoryAccessCheckInterval
+	}
+
+	c := &r.repositoryAccess
+
+	c.mu.Lock()
+	key := credentials + "/" + repository
+
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Since(entry.checkedAt) < interval {
+		return &entry.cond
+	}
+
+	owner, repo, err := splitOwnerAndRepo(repository)
+	if err != nil {
+		return nil
+	}
+
+	cond := metav1.Condition{Type: RepositoryAccessibleConditionType}
+
+	got, _, err := ghc.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		reason, inaccessible := repositoryInaccessibleReason(err)
+		if !inaccessible {
+			log.V(1).Info("Unable to check the accessibility of the repository", "repository", repository, "error", err.Error())
+
+			return nil
+		}
+
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reason
+		cond.Message = fmt.Sprintf("Repository %s doesn't exist or is no longer accessible with the controller's credentials", repository)
+	} else {
+		switch {
+		case got.GetArchived():
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "Archived"
+			cond.Message = fmt.Sprintf("Repository %s is archived", repository)
+		case got.GetFullName() != "" && !strings.EqualFold(got.GetFullName(), repository):
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "Renamed"
+			cond.Message = fmt.Sprintf("Repository %s has been renamed to %s. Update spec.template.spec.repository", repository, got.GetFullName())
+		default:
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = "Accessible"
+			cond.Message = fmt.Sprintf("Repository %s is accessible", repository)
+		}
+	}
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]repositoryAccessCacheEntry{}
+	}
+	c.entries[key] = repositoryAccessCacheEntry{cond: cond, checkedAt: time.Now()}
+	c.mu.Unlock()
+
+	return &cond
+}
+
+// syncRepositoryAccess updates the RepositoryAccessible condition of the RunnerDeployment of repository runners,
+// and returns true when the repository is inaccessible so that no runner pod should be created.
+// Events are emitted only when the accessibility changes.
+func (r *RunnerDeploymentReconciler) syncRepositoryAccess(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) (bool, error) {
+	repository := rd.Spec.Template.Spec.Repository
+	if repository == "" || r.GitHubClient == nil {
+		return false, nil
+	}
+
+	from := rd.Spec.Template.Spec.GitHubAPICredentialsFrom
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rd.Namespace, from)
+	if err != nil {
+		return false, err
+	}
+
+	cond := r.checkRepositoryAccess(ctx, log, ghc, githubAPICredentialsKey(rd.Namespace, from), repository)
+	if cond == nil {
+		// Keep the last known condition, so that a transient error doesn't resume the registrations against a dead repository.
+		last := meta.FindStatusCondition(rd.Status.Conditions, RepositoryAccessibleConditionType)
+		return last != nil && last.Status == metav1.ConditionFalse, nil
+	}
+
+	inaccessible := cond.Status == metav1.ConditionFalse
+
+	last := meta.FindStatusCondition(rd.Status.Conditions, RepositoryAccessibleConditionType)
+	if last != nil && last.Status == cond.Status && last.Reason == cond.Reason && last.Message == cond.Message && last.ObservedGeneration == rd.Generation {
+		return inaccessible, nil
+	}
+
+	if last == nil || last.Status != cond.Status || last.Reason != cond.Reason {
+		if inaccessible {
+			r.Recorder.Event(&rd, corev1.EventTypeWarning, "RepositoryInaccessible", cond.Message+". Stopped creating runners")
+			log.Info("Stopped creating runners as the repository is inaccessible", "repository", repository, "reason", cond.Reason)
+		} else if last != nil {
+			r.Recorder.Event(&rd, corev1.EventTypeNormal, "RepositoryAccessible", cond.Message+". Resumed creating runners")
+			log.Info("Resumed creating runners as the repository became accessible", "repository", repository)
+		}
+	}
+
+	updated := rd.DeepCopy()
+	c := *cond
+	c.ObservedGeneration = rd.Generation
+	meta.SetStatusCondition(&updated.Status.Conditions, c)
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		return inaccessible, err
+	}
+
+	return inaccessible, nil
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncRepositoryAccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/repos/test/valid":
+			w.Write([]byte(`{"full_name": "test/valid"}`))
+		case "/repos/test/archived":
+			w.Write([]byte(`{"full_name": "test/archived", "archived": true}`))
+		case "/repos/test/old-name":
+			// GitHub redirects the requests for a renamed repository to the new one
+			w.Write([]byte(`{"full_name": "test/new-name"}`))
+		case "/repos/test/deleted":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		case "/repos/test/gone":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"message": "Repository access blocked"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	testcases := []struct {
+		repository       string
+		lastStatus       metav1.ConditionStatus
+		wantInaccessible bool
+		wantStatus       metav1.ConditionStatus
+		wantReason       string
+		wantEvents       int
+	}{
+		{repository: "test/valid", wantStatus: metav1.ConditionTrue, wantReason: "Accessible"},
+		{repository: "test/archived", wantInaccessible: true, wantStatus: metav1.ConditionFalse, wantReason: "Archived", wantEvents: 1},
+		{repository: "test/old-name", wantInaccessible: true, wantStatus: metav1.ConditionFalse, wantReason: "Renamed", wantEvents: 1},
+		{repository: "test/deleted", wantInaccessible: true, wantStatus: metav1.ConditionFalse, wantReason: "NotFound", wantEvents: 1},
+		{repository: "test/gone", wantInaccessible: true, wantStatus: metav1.ConditionFalse, wantReason: "Gone", wantEvents: 1},
+		{repository: "test/valid", lastStatus: metav1.ConditionFalse, wantStatus: metav1.ConditionTrue, wantReason: "Accessible", wantEvents: 1},
+		// A transient error keeps the last known condition
+		{repository: "test/error", lastStatus: metav1.ConditionFalse, wantInaccessible: true, wantStatus: metav1.ConditionFalse, wantReason: "NotFound"},
+		{repository: "test/error"},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.repository, func(t *testing.T) {
+			rd := &v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{RunnerConfig: v1alpha1.RunnerConfig{Repository: tc.repository}},
+					},
+				},
+			}
+
+			if tc.lastStatus != "" {
+				meta.SetStatusCondition(&rd.Status.Conditions, metav1.Condition{
+					Type:   RepositoryAccessibleConditionType,
+					Status: tc.lastStatus,
+					Reason: "NotFound",
+				})
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, rd)
+			recorder := record.NewFakeRecorder(10)
+
+			r := &RunnerDeploymentReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     recorder,
+				GitHubClient: newGithubClient(server),
+			}
+
+			inaccessible, err := r.syncRepositoryAccess(context.Background(), logr.Discard(), *rd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if inaccessible != tc.wantInaccessible {
+				t.Errorf("unexpected inaccessible: want %v, got %v", tc.wantInaccessible, inaccessible)
+			}
+
+			var got v1alpha1.RunnerDeployment
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, RepositoryAccessibleConditionType)
+
+			if tc.wantStatus == "" {
+				if cond != nil {
+					t.Errorf("unexpected condition: %+v", *cond)
+				}
+			} else if cond == nil {
+				t.Errorf("missing condition")
+			} else if cond.Status != tc.wantStatus || cond.Reason != tc.wantReason {
+				t.Errorf("unexpected condition: %+v", *cond)
+			}
+
+			if len(recorder.Events) != tc.wantEvents {
+				t.Errorf("unexpected number of events: want %d, got %d", tc.wantEvents, len(recorder.Events))
+			}
+		})
+	}
+}
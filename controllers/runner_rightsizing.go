@@ -0,0 +1,507 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultRunnerRightsizingInterval = 1 * time.Minute
+
+	defaultRightsizingHeadroomPercent  = 20
+	defaultRightsizingMinChangePercent = 10
+	defaultRightsizingMinApplyInterval = 24 * time.Hour
+
+	// rightsizingPeakHalfLife is the duration after which a peak usage that isn't observed again is halved,
+	// so that the recommendation follows a decrease of the usage within days.
+	rightsizingPeakHalfLife = 24 * time.Hour
+
+	// minRightsizingSamples is the number of the samples the recommendation must be based on to be applied.
+	minRightsizingSamples = 60
+
+	rightsizingPrometheusCPUQuery    = `sum by (pod, container) (rate(container_cpu_usage_seconds_total{namespace=%q,pod=~%q,container!="",container!="POD"}[5m]))`
+	rightsizingPrometheusMemoryQuery = `max by (pod, container) (container_memory_working_set_bytes{namespace=%q,pod=~%q,container!="",container!="POD"})`
+)
+
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// RunnerRightsizer periodically samples the actual CPU and memory usage of the containers of the runner pods
+// of each RunnerDeployment, and writes the resource requests recommended from the peak usage to its status.resourceRecommendation.
+// The usage is read from the metrics API served by metrics-server, or queried from Prometheus when PrometheusURL is set.
+//
+// The recommendation is applied to the template of the RunnerDeployments whose spec.rightsizing.autoApply is true,
+// once enough samples are taken, within the bounds and no more often than the minApplyInterval.
+// Only RunnerDeployments are right-sized, so RunnerSets are not.
+type RunnerRightsizer struct {
+	client.Client
+	Log logr.Logger
+
+	// PrometheusURL is the URL of the Prometheus server to query the usage from, like `http://prometheus.monitoring:9090`.
+	PrometheusURL string
+	HTTPClient    *http.Client
+
+	Interval  time.Duration
+	Namespace string
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader samples the usage and updates the RunnerDeployments.
+func (s *RunnerRightsizer) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (s *RunnerRightsizer) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultRunnerRightsizingInterval
+	}
+
+	s.Log.Info("Starting runner rightsizer", "interval", interval, "prometheus", s.PrometheusURL)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sampleAll(ctx, time.Now()); err != nil {
+			s.Log.Error(err, "Failed to sample runner resource usage")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RunnerRightsizer) sampleAll(ctx context.Context, now time.Time) error {
+	var opts []client.ListOption
+	if s.Namespace != "" {
+		opts = append(opts, client.InNamespace(s.Namespace))
+	}
+
+	var rds v1alpha1.RunnerDeploymentList
+	if err := s.List(ctx, &rds, opts...); err != nil {
+		return err
+	}
+
+	for i := range rds.Items {
+		rd := &rds.Items[i]
+
+		if err := s.sample(ctx, now, rd); err != nil {
+			s.Log.Error(err, "Failed to right-size runner deployment", "runnerdeployment", types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name})
+		}
+	}
+
+	return nil
+}
+
+func (s *RunnerRightsizer) sample(ctx context.Context, now time.Time, rd *v1alpha1.RunnerDeployment) error {
+	log := s.Log.WithValues("runnerdeployment", types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name})
+
+	var pods corev1.PodList
+	if err := s.List(ctx, &pods, client.InNamespace(rd.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: rd.Name}); err != nil {
+		return err
+	}
+
+	var running []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			running = append(running, pod.Name)
+		}
+	}
+
+	if len(running) == 0 {
+		return nil
+	}
+
+	usage, err := s.containerUsage(ctx, rd.Namespace, running)
+	if err != nil {
+		return err
+	}
+
+	if len(usage) == 0 {
+		return nil
+	}
+
+	headroomPercent := defaultRightsizingHeadroomPercent
+	if rs := rd.Spec.Rightsizing; rs != nil && rs.HeadroomPercent != nil {
+		headroomPercent = *rs.HeadroomPercent
+	}
+
+	rec := updateResourceRecommendation(now, rd.Status.ResourceRecommendation, usage, headroomPercent)
+
+	updated := rd.DeepCopy()
+
+	if applyResourceRecommendation(now, updated, rec) {
+		if err := s.Patch(ctx, updated, client.MergeFromWithOptions(rd, client.MergeFromWithOptimisticLock{})); err != nil {
+			return err
+		}
+
+		rec.LastAppliedTime = &metav1.Time{Time: now}
+
+		log.Info("Applied the recommended resource requests to the template", "samples", rec.Samples)
+	}
+
+	withStatus := updated.DeepCopy()
+	withStatus.Status.ResourceRecommendation = &rec
+
+	return s.Status().Patch(ctx, withStatus, client.MergeFrom(updated))
+}
+
+// containerUsage returns the highest usage of each container across the pods.
+func (s *RunnerRightsizer) containerUsage(ctx context.Context, namespace string, pods []string) (map[string]corev1.ResourceList, error) {
+	if s.PrometheusURL != "" {
+		return s.prometheusUsage(ctx, namespace, pods)
+	}
+
+	return s.metricsServerUsage(ctx, namespace, pods)
+}
+
+func (s *RunnerRightsizer) metricsServerUsage(ctx context.Context, namespace string, pods []string) (map[string]corev1.ResourceList, error) {
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(podMetricsListGVK)
+
+	if err := s.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing pod metrics: %w", err)
+	}
+
+	return podMetricsUsage(list.Items, pods), nil
+}
+
+// podMetricsUsage returns the highest usage of each container across the PodMetrics of the pods.
+func podMetricsUsage(items []unstructured.Unstructured, pods []string) map[string]corev1.ResourceList {
+	names := map[string]bool{}
+	for _, p := range pods {
+		names[p] = true
+	}
+
+	usage := map[string]corev1.ResourceList{}
+
+	for _, item := range items {
+		if !names[item.GetName()] {
+			continue
+		}
+
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+
+		for _, c := range containers {
+			m, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(m, "name")
+			values, _, _ := unstructured.NestedStringMap(m, "usage")
+
+			for _, r := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				q, err := resource.ParseQuantity(values[string(r)])
+				if err != nil {
+					continue
+				}
+
+				addPeakUsage(usage, name, r, q)
+			}
+		}
+	}
+
+	return usage
+}
+
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (s *RunnerRightsizer) prometheusUsage(ctx context.Context, namespace string, pods []string) (map[string]corev1.ResourceList, error) {
+	podRegex := strings.Join(pods, "|")
+
+	usage := map[string]corev1.ResourceList{}
+
+	queries := []struct {
+		resource corev1.ResourceName
+		query    string
+		quantity func(float64) *resource.Quantity
+	}{
+		{
+			resource: corev1.ResourceCPU,
+			query:    fmt.Sprintf(rightsizingPrometheusCPUQuery, namespace, podRegex),
+			quantity: func(v float64) *resource.Quantity {
+				return resource.NewMilliQuantity(int64(math.Ceil(v*1000)), resource.DecimalSI)
+			},
+		},
+		{
+			resource: corev1.ResourceMemory,
+			query:    fmt.Sprintf(rightsizingPrometheusMemoryQuery, namespace, podRegex),
+			quantity: func(v float64) *resource.Quantity {
+				return resource.NewQuantity(int64(math.Ceil(v)), resource.BinarySI)
+			},
+		},
+	}
+
+	for _, q := range queries {
+		res, err := s.queryPrometheus(ctx, q.query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range res.Data.Result {
+			if len(r.Value) != 2 {
+				continue
+			}
+
+			str, _ := r.Value[1].(string)
+
+			v, err := strconv.ParseFloat(str, 64)
+			if err != nil || math.IsNaN(v) {
+				continue
+			}
+
+			addPeakUsage(usage, r.Metric["container"], q.resource, *q.quantity(v))
+		}
+	}
+
+	return usage, nil
+}
+
+func (s *RunnerRightsizer) queryPrometheus(ctx context.Context, query string) (*prometheusQueryResponse, error) {
+	u := strings.TrimSuffix(s.PrometheusURL, "/") + "/api/v1/query?" + url.Values{"query": []string{query}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := s.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying prometheus: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading prometheus response: %w", err)
+	}
+
+	var r prometheusQueryResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("querying prometheus: unexpected response with status %d: %s", res.StatusCode, body)
+	}
+
+	if r.Status != "success" {
+		return nil, fmt.Errorf("querying prometheus: %s", r.Error)
+	}
+
+	return &r, nil
+}
+
+func addPeakUsage(usage map[string]corev1.ResourceList, container string, r corev1.ResourceName, q resource.Quantity) {
+	if container == "" {
+		return
+	}
+
+	if usage[container] == nil {
+		usage[container] = corev1.ResourceList{}
+	}
+
+	if cur, ok := usage[container][r]; !ok || q.Cmp(cur) > 0 {
+		usage[container][r] = q
+	}
+}
+
+// updateResourceRecommendation returns the recommendation updated with the usage sampled at now.
+// The previous peak usage decays by rightsizingPeakHalfLife, and is replaced by the sampled usage when it's higher.
+func updateResourceRecommendation(now time.Time, rec *v1alpha1.RunnerResourceRecommendation, usage map[string]corev1.ResourceList, headroomPercent int) v1alpha1.RunnerResourceRecommendation {
+	var updated v1alpha1.RunnerResourceRecommendation
+	if rec != nil {
+		updated = *rec.DeepCopy()
+	}
+
+	decay := 1.0
+	if t := updated.LastSampleTime; t != nil && now.After(t.Time) {
+		decay = math.Pow(0.5, float64(now.Sub(t.Time))/float64(rightsizingPeakHalfLife))
+	}
+
+	peaks := map[string]corev1.ResourceList{}
+
+	for _, c := range updated.Containers {
+		peaks[c.Name] = corev1.ResourceList{}
+		for r, q := range c.Peak {
+			peaks[c.Name][r] = scaleQuantity(r, q, decay, false)
+		}
+	}
+
+	for name, u := range usage {
+		for r, q := range u {
+			addPeakUsage(peaks, name, r, q)
+		}
+	}
+
+	var names []string
+	for name := range peaks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	updated.Containers = nil
+
+	for _, name := range names {
+		c := v1alpha1.ContainerResourceRecommendation{
+			Name:     name,
+			Peak:     peaks[name],
+			Requests: corev1.ResourceList{},
+		}
+
+		for r, q := range c.Peak {
+			c.Requests[r] = scaleQuantity(r, q, 1+float64(headroomPercent)/100, true)
+		}
+
+		updated.Containers = append(updated.Containers, c)
+	}
+
+	updated.Samples++
+	updated.LastSampleTime = &metav1.Time{Time: now}
+
+	return updated
+}
+
+// scaleQuantity returns the CPU or memory quantity multiplied by the factor.
+// The quantity is rounded up to 10m of CPU or 1Mi of memory when round is true, so that the requests are readable.
+func scaleQuantity(r corev1.ResourceName, q resource.Quantity, factor float64, round bool) resource.Quantity {
+	if r == corev1.ResourceCPU {
+		v := float64(q.MilliValue()) * factor
+		if round {
+			v = math.Ceil(v/10) * 10
+		}
+		return *resource.NewMilliQuantity(int64(math.Ceil(v)), resource.DecimalSI)
+	}
+
+	v := float64(q.Value()) * factor
+	if round {
+		return *resource.NewQuantity(int64(math.Ceil(v/(1<<20)))<<20, resource.BinarySI)
+	}
+	return *resource.NewQuantity(int64(math.Ceil(v)), resource.BinarySI)
+}
+
+// applyResourceRecommendation updates the requests of the containers of the template of the runner deployment
+// to the recommended ones within the guardrails of spec.rightsizing.
+// It returns false when nothing is changed.
+func applyResourceRecommendation(now time.Time, rd *v1alpha1.RunnerDeployment, rec v1alpha1.RunnerResourceRecommendation) bool {
+	rs := rd.Spec.Rightsizing
+	if rs == nil || !rs.AutoApply || rec.Samples < minRightsizingSamples {
+		return false
+	}
+
+	minApplyInterval := defaultRightsizingMinApplyInterval
+	if rs.MinApplyInterval != nil {
+		minApplyInterval = rs.MinApplyInterval.Duration
+	}
+
+	if t := rec.LastAppliedTime; t != nil && now.Sub(t.Time) < minApplyInterval {
+		return false
+	}
+
+	minChangePercent := defaultRightsizingMinChangePercent
+	if rs.MinChangePercent != nil {
+		minChangePercent = *rs.MinChangePercent
+	}
+
+	var changed bool
+
+	for _, c := range rec.Containers {
+		res := templateContainerResources(&rd.Spec.Template.Spec, c.Name)
+		if res == nil {
+			continue
+		}
+
+		for _, r := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			want, ok := c.Requests[r]
+			if !ok {
+				continue
+			}
+
+			if min, ok := rs.MinAllowed[r]; ok && want.Cmp(min) < 0 {
+				want = min
+			}
+
+			if max, ok := rs.MaxAllowed[r]; ok && want.Cmp(max) > 0 {
+				want = max
+			}
+
+			if limit, ok := res.Limits[r]; ok && want.Cmp(limit) > 0 {
+				want = limit
+			}
+
+			if cur, ok := res.Requests[r]; ok {
+				diff := math.Abs(want.AsApproximateFloat64() - cur.AsApproximateFloat64())
+				if diff*100 < cur.AsApproximateFloat64()*float64(minChangePercent) {
+					continue
+				}
+			}
+
+			if res.Requests == nil {
+				res.Requests = corev1.ResourceList{}
+			}
+
+			res.Requests[r] = want
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// templateContainerResources returns the resources of the container of the runner pod template, if any.
+func templateContainerResources(spec *v1alpha1.RunnerSpec, name string) *corev1.ResourceRequirements {
+	if len(spec.Containers) > 0 {
+		for i := range spec.Containers {
+			if spec.Containers[i].Name == name {
+				return &spec.Containers[i].Resources
+			}
+		}
+
+		return nil
+	}
+
+	switch name {
+	case containerName:
+		return &spec.Resources
+	case "docker":
+		return &spec.DockerdContainerResources
+	}
+
+	return nil
+}
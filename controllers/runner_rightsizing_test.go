@@ -0,0 +1,380 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func resourceList(cpu, memory string) corev1.ResourceList {
+	l := corev1.ResourceList{}
+	if cpu != "" {
+		l[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		l[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return l
+}
+
+func resourceListString(l corev1.ResourceList) string {
+	cpu, memory := l[corev1.ResourceCPU], l[corev1.ResourceMemory]
+	return fmt.Sprintf("cpu=%s,memory=%s", cpu.String(), memory.String())
+}
+
+func TestUpdateResourceRecommendation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	previous := func(ago time.Duration, cpu, memory string) *v1alpha1.RunnerResourceRecommendation {
+		return &v1alpha1.RunnerResourceRecommendation{
+			Containers: []v1alpha1.ContainerResourceRecommendation{
+				{Name: containerName, Peak: resourceList(cpu, memory)},
+			},
+			Samples:        10,
+			LastSampleTime: &metav1.Time{Time: now.Add(-ago)},
+		}
+	}
+
+	testcases := []struct {
+		description  string
+		rec          *v1alpha1.RunnerResourceRecommendation
+		usage        string
+		wantPeak     string
+		wantRequests string
+	}{
+		{
+			description:  "first sample",
+			usage:        "1,1Gi",
+			wantPeak:     "cpu=1,memory=1Gi",
+			wantRequests: "cpu=1200m,memory=1229Mi",
+		},
+		{
+			description:  "higher usage",
+			rec:          previous(time.Minute, "500m", "512Mi"),
+			usage:        "1,1Gi",
+			wantPeak:     "cpu=1,memory=1Gi",
+			wantRequests: "cpu=1200m,memory=1229Mi",
+		},
+		{
+			description:  "peak halves a day later",
+			rec:          previous(24*time.Hour, "2", "2Gi"),
+			usage:        "500m,512Mi",
+			wantPeak:     "cpu=1,memory=1Gi",
+			wantRequests: "cpu=1200m,memory=1229Mi",
+		},
+		{
+			description:  "rounded up",
+			usage:        "1m,1",
+			wantPeak:     "cpu=1m,memory=1",
+			wantRequests: "cpu=10m,memory=1Mi",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			parts := strings.Split(tc.usage, ",")
+			usage := map[string]corev1.ResourceList{containerName: resourceList(parts[0], parts[1])}
+
+			got := updateResourceRecommendation(now, tc.rec, usage, 20)
+
+			if len(got.Containers) != 1 {
+				t.Fatalf("unexpected containers: %+v", got.Containers)
+			}
+
+			if s := resourceListString(got.Containers[0].Peak); s != tc.wantPeak {
+				t.Errorf("unexpected peak: want %s, got %s", tc.wantPeak, s)
+			}
+
+			if s := resourceListString(got.Containers[0].Requests); s != tc.wantRequests {
+				t.Errorf("unexpected requests: want %s, got %s", tc.wantRequests, s)
+			}
+
+			wantSamples := 1
+			if tc.rec != nil {
+				wantSamples = tc.rec.Samples + 1
+			}
+
+			if got.Samples != wantSamples || got.LastSampleTime == nil || !got.LastSampleTime.Time.Equal(now) {
+				t.Errorf("unexpected samples: %d at %v", got.Samples, got.LastSampleTime)
+			}
+		})
+	}
+}
+
+func TestApplyResourceRecommendation(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	recommend := func(samples int, lastApplied time.Duration, cpu, memory string) v1alpha1.RunnerResourceRecommendation {
+		rec := v1alpha1.RunnerResourceRecommendation{
+			Containers: []v1alpha1.ContainerResourceRecommendation{
+				{Name: containerName, Requests: resourceList(cpu, memory)},
+				{Name: "docker", Requests: resourceList("200m", "")},
+			},
+			Samples: samples,
+		}
+		if lastApplied > 0 {
+			rec.LastAppliedTime = &metav1.Time{Time: now.Add(-lastApplied)}
+		}
+		return rec
+	}
+
+	testcases := []struct {
+		description  string
+		rightsizing  *v1alpha1.RunnerRightsizing
+		limits       corev1.ResourceList
+		rec          v1alpha1.RunnerResourceRecommendation
+		wantChanged  bool
+		wantRunner   string
+		wantDocker   string
+		useContainer bool
+	}{
+		{
+			description: "not enabled",
+			rightsizing: &v1alpha1.RunnerRightsizing{},
+			rec:         recommend(100, 0, "2", "2Gi"),
+			wantRunner:  "cpu=1,memory=1Gi",
+			wantDocker:  "cpu=0,memory=0",
+		},
+		{
+			description: "applied",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			rec:         recommend(100, 0, "2", "2Gi"),
+			wantChanged: true,
+			wantRunner:  "cpu=2,memory=2Gi",
+			wantDocker:  "cpu=200m,memory=0",
+		},
+		{
+			description: "too few samples",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			rec:         recommend(10, 0, "2", "2Gi"),
+			wantRunner:  "cpu=1,memory=1Gi",
+			wantDocker:  "cpu=0,memory=0",
+		},
+		{
+			description: "applied recently",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			rec:         recommend(100, time.Hour, "2", "2Gi"),
+			wantRunner:  "cpu=1,memory=1Gi",
+			wantDocker:  "cpu=0,memory=0",
+		},
+		{
+			description: "applied long ago",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true, MinApplyInterval: &metav1.Duration{Duration: time.Hour}},
+			rec:         recommend(100, 2*time.Hour, "2", "2Gi"),
+			wantChanged: true,
+			wantRunner:  "cpu=2,memory=2Gi",
+			wantDocker:  "cpu=200m,memory=0",
+		},
+		{
+			description: "small changes are ignored",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			rec:         recommend(100, 0, "1050m", "1Gi"),
+			wantChanged: true,
+			wantRunner:  "cpu=1,memory=1Gi",
+			wantDocker:  "cpu=200m,memory=0",
+		},
+		{
+			description: "bounded",
+			rightsizing: &v1alpha1.RunnerRightsizing{
+				AutoApply:  true,
+				MinAllowed: resourceList("", "1536Mi"),
+				MaxAllowed: resourceList("1500m", ""),
+			},
+			rec:         recommend(100, 0, "2", "512Mi"),
+			wantChanged: true,
+			wantRunner:  "cpu=1500m,memory=1536Mi",
+			wantDocker:  "cpu=200m,memory=0",
+		},
+		{
+			description: "capped at limits",
+			rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			limits:      resourceList("1200m", ""),
+			rec:         recommend(100, 0, "2", "2Gi"),
+			wantChanged: true,
+			wantRunner:  "cpu=1200m,memory=2Gi",
+			wantDocker:  "cpu=200m,memory=0",
+		},
+		{
+			description:  "containers",
+			rightsizing:  &v1alpha1.RunnerRightsizing{AutoApply: true},
+			rec:          recommend(100, 0, "2", "2Gi"),
+			useContainer: true,
+			wantChanged:  true,
+			wantRunner:   "cpu=2,memory=2Gi",
+			wantDocker:   "cpu=0,memory=0",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			runnerResources := corev1.ResourceRequirements{Requests: resourceList("1", "1Gi"), Limits: tc.limits}
+
+			rd := &v1alpha1.RunnerDeployment{
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					Rightsizing: tc.rightsizing,
+				},
+			}
+
+			if tc.useContainer {
+				rd.Spec.Template.Spec.Containers = []corev1.Container{{Name: containerName, Resources: runnerResources}}
+			} else {
+				rd.Spec.Template.Spec.Resources = runnerResources
+			}
+
+			changed := applyResourceRecommendation(now, rd, tc.rec)
+			if changed != tc.wantChanged {
+				t.Errorf("unexpected change: want %v, got %v", tc.wantChanged, changed)
+			}
+
+			got := rd.Spec.Template.Spec.Resources.Requests
+			if tc.useContainer {
+				got = rd.Spec.Template.Spec.Containers[0].Resources.Requests
+			}
+
+			if s := resourceListString(got); s != tc.wantRunner {
+				t.Errorf("unexpected runner requests: want %s, got %s", tc.wantRunner, s)
+			}
+
+			if s := resourceListString(rd.Spec.Template.Spec.DockerdContainerResources.Requests); s != tc.wantDocker {
+				t.Errorf("unexpected docker requests: want %s, got %s", tc.wantDocker, s)
+			}
+		})
+	}
+}
+
+func TestPodMetricsUsage(t *testing.T) {
+	podMetrics := func(name string, cpu, memory string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  containerName,
+					"usage": map[string]interface{}{"cpu": cpu, "memory": memory},
+				},
+			},
+		}}
+	}
+
+	items := []unstructured.Unstructured{
+		podMetrics("runner-1", "250000000n", "512Mi"),
+		podMetrics("runner-2", "500m", "256Mi"),
+		podMetrics("other", "4", "8Gi"),
+	}
+
+	got := podMetricsUsage(items, []string{"runner-1", "runner-2"})
+
+	if s := resourceListString(got[containerName]); s != "cpu=500m,memory=512Mi" {
+		t.Errorf("unexpected usage: %s", s)
+	}
+}
+
+func TestRunnerRightsizer(t *testing.T) {
+	ctx := context.Background()
+
+	// Truncated as metav1.Time is serialized in seconds
+	now := time.Now().Truncate(time.Second)
+
+	var queries []string
+
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		queries = append(queries, q)
+
+		value := "1073741824"
+		if strings.Contains(q, "container_cpu_usage_seconds_total") {
+			value = "0.5"
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"runner-1","container":"runner"},"value":[1646128800,%q]}]}}`, value)
+	}))
+	defer prometheus.Close()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Rightsizing: &v1alpha1.RunnerRightsizing{AutoApply: true},
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerPodSpec: v1alpha1.RunnerPodSpec{
+						Resources: corev1.ResourceRequirements{Requests: resourceList("2", "4Gi")},
+					},
+				},
+			},
+		},
+		Status: v1alpha1.RunnerDeploymentStatus{
+			ResourceRecommendation: &v1alpha1.RunnerResourceRecommendation{
+				Samples:        minRightsizingSamples,
+				LastSampleTime: &metav1.Time{Time: now.Add(-time.Minute)},
+			},
+		},
+	}
+
+	newPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	objs := []runtime.Object{
+		rd,
+		newPod("runner-1", corev1.PodRunning),
+		newPod("runner-2", corev1.PodPending),
+	}
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	s := &RunnerRightsizer{
+		Client:        c,
+		Log:           logr.Discard(),
+		PrometheusURL: prometheus.URL,
+	}
+
+	if err := s.sampleAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(queries) != 2 || !strings.Contains(queries[0], `namespace="default",pod=~"runner-1"`) {
+		t.Errorf("unexpected prometheus queries: %v", queries)
+	}
+
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &updated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s := resourceListString(updated.Spec.Template.Spec.Resources.Requests); s != "cpu=600m,memory=1229Mi" {
+		t.Errorf("unexpected requests: %s", s)
+	}
+
+	rec := updated.Status.ResourceRecommendation
+	if rec == nil || rec.Samples != minRightsizingSamples+1 || rec.LastAppliedTime == nil || !rec.LastAppliedTime.Time.Equal(now) {
+		t.Fatalf("unexpected recommendation: %+v", rec)
+	}
+
+	if len(rec.Containers) != 1 || resourceListString(rec.Containers[0].Peak) != "cpu=500m,memory=1Gi" {
+		t.Errorf("unexpected recommendation: %+v", rec.Containers)
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultScaleDownGracePeriod is long enough for the busy flag of a runner that has just picked up a job to propagate
+// through the GitHub API, whose responses can be cached for up to 60 seconds.
+const DefaultScaleDownGracePeriod = 90 * time.Second
+
+// inScaleDownGracePeriod returns true if the runner of the owner has registered or has been seen busy within the grace period,
+// so that it isn't chosen for scale down while the runner list of the GitHub API may still report it idle,
+// even though it has just been assigned a job.
+func (p *podsForOwner) inScaleDownGracePeriod(now time.Time, grace time.Duration) bool {
+	if grace <= 0 {
+		return false
+	}
+
+	if t := p.lastBusy(); !t.IsZero() && now.Before(t.Add(grace)) {
+		return true
+	}
+
+	for i := range p.pods {
+		if t := runnerStartedAt(&p.pods[i]); !t.IsZero() && now.Before(t.Add(grace)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runnerStartedAt returns the time the runner container of the pod started running, after which the runner registers
+// itself and can be assigned a job, or the zero time when it isn't running.
+func runnerStartedAt(pod *corev1.Pod) time.Time {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == containerName && s.State.Running != nil {
+			return s.State.Running.StartedAt.Time
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncRunnerPodsOwners_ScaleDownGracePeriod(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	created := metav1.NewTime(now.Add(-time.Hour))
+	longAgo := metav1.NewTime(now.Add(-30 * time.Minute))
+	recently := metav1.NewTime(now.Add(-10 * time.Second))
+
+	testcases := []struct {
+		description  string
+		grace        time.Duration
+		startedAt    map[string]metav1.Time
+		recentJobs   map[string][]v1alpha1.RunnerJob
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			description:  "no runner within the grace period",
+			grace:        time.Minute,
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+		{
+			description:  "runner that has just registered is retained",
+			grace:        time.Minute,
+			startedAt:    map[string]metav1.Time{"runner-1": recently},
+			wantDeleted:  []string{"runner-2", "runner-3"},
+			wantRetained: []string{"runner-1"},
+		},
+		{
+			description:  "runner that has just picked up a job is retained while GitHub reports it idle",
+			grace:        time.Minute,
+			recentJobs:   map[string][]v1alpha1.RunnerJob{"runner-2": {{Repository: "org/repo", StartedAt: recently}}},
+			wantDeleted:  []string{"runner-1", "runner-3"},
+			wantRetained: []string{"runner-2"},
+		},
+		{
+			description:  "runners within the grace period are retained even when that keeps more runners than desired",
+			grace:        time.Minute,
+			startedAt:    map[string]metav1.Time{"runner-1": recently},
+			recentJobs:   map[string][]v1alpha1.RunnerJob{"runner-2": {{Repository: "org/repo", StartedAt: recently}}},
+			wantDeleted:  []string{"runner-3"},
+			wantRetained: []string{"runner-1", "runner-2"},
+		},
+		{
+			description:  "disabled",
+			startedAt:    map[string]metav1.Time{"runner-1": recently},
+			recentJobs:   map[string][]v1alpha1.RunnerJob{"runner-2": {{Repository: "org/repo", StartedAt: recently}}},
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			for i, name := range []string{"runner-1", "runner-2", "runner-3"} {
+				r := &v1alpha1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						CreationTimestamp: metav1.NewTime(created.Add(time.Duration(i) * time.Minute)),
+						Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+					},
+					Status: v1alpha1.RunnerStatus{Phase: "Running", RecentJobs: tc.recentJobs[name]},
+				}
+
+				startedAt, ok := tc.startedAt[name]
+				if !ok {
+					startedAt = longAgo
+				}
+
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						ContainerStatuses: []corev1.ContainerStatus{
+							{Name: containerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}},
+						},
+					},
+				}
+				objs = append(objs, r, pod)
+				owners = append(owners, r)
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := &v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "desired", Namespace: "default", Labels: map[string]string{LabelKeyRunnerTemplateHash: "abc"}},
+			}
+
+			listBusy := func() map[string]bool {
+				return map[string]bool{}
+			}
+
+			_, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, 1, desired, func() client.Object { return desired.DeepCopy() }, false, false, "", tc.grace, listBusy, nil, owners)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			check := func(names []string, wantDeleted bool) {
+				for _, name := range names {
+					var runner v1alpha1.Runner
+					if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+						t.Fatal(err)
+					}
+
+					if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted {
+						t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted, deleted)
+					}
+				}
+			}
+
+			check(tc.wantDeleted, true)
+			check(tc.wantRetained, false)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncRunnerPodsOwners_ScaleDownPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	// runner-1 is the oldest and runner-3 is the newest. runner-2 ran a job most recently, and runner-3 never ran a job.
+	recentJobs := map[string][]v1alpha1.RunnerJob{
+		"runner-1": {{Repository: "org/repo", StartedAt: metav1.NewTime(created.Add(10 * time.Minute))}},
+		"runner-2": {{Repository: "org/repo", StartedAt: metav1.NewTime(created.Add(20 * time.Minute))}},
+	}
+
+	testcases := []struct {
+		description  string
+		policy       v1alpha1.ScaleDownPolicy
+		replicas     int
+		busy         map[string]bool
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			description:  "oldest runners are deleted first by default",
+			replicas:     1,
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+		{
+			description:  "OldestFirst",
+			policy:       v1alpha1.ScaleDownPolicyOldestFirst,
+			replicas:     2,
+			wantDeleted:  []string{"runner-1"},
+			wantRetained: []string{"runner-2", "runner-3"},
+		},
+		{
+			description:  "NewestFirst",
+			policy:       v1alpha1.ScaleDownPolicyNewestFirst,
+			replicas:     1,
+			wantDeleted:  []string{"runner-2", "runner-3"},
+			wantRetained: []string{"runner-1"},
+		},
+		{
+			description:  "LeastRecentlyBusy",
+			policy:       v1alpha1.ScaleDownPolicyLeastRecentlyBusy,
+			replicas:     2,
+			wantDeleted:  []string{"runner-3"},
+			wantRetained: []string{"runner-1", "runner-2"},
+		},
+		{
+			description:  "LeastRecentlyBusy retains the runner that ran a job most recently",
+			policy:       v1alpha1.ScaleDownPolicyLeastRecentlyBusy,
+			replicas:     1,
+			wantDeleted:  []string{"runner-1", "runner-3"},
+			wantRetained: []string{"runner-2"},
+		},
+		{
+			description:  "busy runners are retained before idle ones regardless of the policy",
+			policy:       v1alpha1.ScaleDownPolicyNewestFirst,
+			replicas:     1,
+			busy:         map[string]bool{"runner-3": true},
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+		{
+			description:  "IdleOnly deletes idle runners only, even when that keeps more runners than desired",
+			policy:       v1alpha1.ScaleDownPolicyIdleOnly,
+			replicas:     0,
+			busy:         map[string]bool{"runner-1": true, "runner-2": true},
+			wantDeleted:  []string{"runner-3"},
+			wantRetained: []string{"runner-1", "runner-2"},
+		},
+		{
+			description:  "IdleOnly deletes no runner while the busy runners are unknown",
+			policy:       v1alpha1.ScaleDownPolicyIdleOnly,
+			replicas:     0,
+			wantRetained: []string{"runner-1", "runner-2", "runner-3"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			for i, name := range []string{"runner-1", "runner-2", "runner-3"} {
+				r := &v1alpha1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						CreationTimestamp: metav1.NewTime(created.Add(time.Duration(i) * time.Minute)),
+						Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+					},
+					Status: v1alpha1.RunnerStatus{Phase: "Running", RecentJobs: recentJobs[name]},
+				}
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				}
+				objs = append(objs, r, pod)
+				owners = append(owners, r)
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := &v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "desired", Namespace: "default", Labels: map[string]string{LabelKeyRunnerTemplateHash: "abc"}},
+			}
+
+			listBusy := func() map[string]bool {
+				if tc.busy == nil && tc.policy != v1alpha1.ScaleDownPolicyIdleOnly {
+					return map[string]bool{}
+				}
+
+				return tc.busy
+			}
+
+			_, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, tc.replicas, desired, func() client.Object { return desired.DeepCopy() }, false, false, tc.policy, 0, listBusy, nil, owners)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			check := func(names []string, wantDeleted bool) {
+				for _, name := range names {
+					var runner v1alpha1.Runner
+					if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+						t.Fatal(err)
+					}
+
+					if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted {
+						t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted, deleted)
+					}
+				}
+			}
+
+			check(tc.wantDeleted, true)
+			check(tc.wantRetained, false)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// EnvVarRunnerPreRunScript is the path of the script the runner entrypoint runs before registering the runner.
+	EnvVarRunnerPreRunScript = "RUNNER_PRE_RUN_SCRIPT"
+
+	// EnvVarRunnerHookJobStarted and EnvVarRunnerHookJobCompleted are the paths of the scripts
+	// the runner runs before and after each job.
+	// See https://docs.github.com/en/actions/hosting-your-own-runners/running-scripts-before-or-after-a-job
+	EnvVarRunnerHookJobStarted   = "ACTIONS_RUNNER_HOOK_JOB_STARTED"
+	EnvVarRunnerHookJobCompleted = "ACTIONS_RUNNER_HOOK_JOB_COMPLETED"
+
+	// AnnotationKeyRunnerScriptPrefix is the prefix of the annotations holding the inline scripts of the runner pod,
+	// which are projected into the script files.
+	AnnotationKeyRunnerScriptPrefix = annotationKeyPrefix + "script-"
+
+	runnerScriptsVolumeName = "runner-scripts"
+	runnerScriptsMountPath  = "/etc/runner-scripts"
+)
+
+// applyRunnerScripts mounts the pre-run, pre-job and post-job scripts of the runner spec into the runner container,
+// and points the runner entrypoint and the runner hooks to them.
+//
+// All the scripts are projected into a single volume, either from the keys of the ConfigMaps,
+// or from the annotations of the pod for the inline scripts, so that no extra resource has to be managed for the inline scripts.
+func applyRunnerScripts(pod *corev1.Pod, runnerSpec v1alpha1.RunnerConfig) {
+	scripts := []struct {
+		name   string
+		env    string
+		script *v1alpha1.RunnerScript
+	}{
+		{"pre-run", EnvVarRunnerPreRunScript, runnerSpec.PreRunScript},
+		{"pre-job", EnvVarRunnerHookJobStarted, runnerSpec.PreJobScript},
+		{"post-job", EnvVarRunnerHookJobCompleted, runnerSpec.PostJobScript},
+	}
+
+	var sources []corev1.VolumeProjection
+
+	for _, s := range scripts {
+		if s.script == nil {
+			continue
+		}
+
+		path := s.name + ".sh"
+
+		if ref := s.script.ConfigMapKeyRef; ref != nil {
+			sources = append(sources, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: ref.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: ref.Key, Path: path}},
+					Optional:             ref.Optional,
+				},
+			})
+		} else {
+			key := AnnotationKeyRunnerScriptPrefix + s.name
+			setAnnotation(&pod.ObjectMeta, key, s.script.Inline)
+
+			sources = append(sources, corev1.VolumeProjection{
+				DownwardAPI: &corev1.DownwardAPIProjection{
+					Items: []corev1.DownwardAPIVolumeFile{
+						{
+							Path:     path,
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations['" + key + "']"},
+						},
+					},
+				},
+			})
+		}
+
+		setRunnerEnv(pod, s.env, runnerScriptsMountPath+"/"+path)
+	}
+
+	if len(sources) == 0 {
+		return
+	}
+
+	mode := int32(0755)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: runnerScriptsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &mode,
+			},
+		},
+	})
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+
+		if c.Name != containerName {
+			continue
+		}
+
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      runnerScriptsVolumeName,
+			MountPath: runnerScriptsMountPath,
+			ReadOnly:  true,
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestApplyRunnerScripts(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName}, {Name: "docker"}},
+		},
+	}
+
+	applyRunnerScripts(&pod, v1alpha1.RunnerConfig{
+		PreRunScript: &v1alpha1.RunnerScript{Inline: "docker login registry.example.com"},
+		PostJobScript: &v1alpha1.RunnerScript{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "hooks"},
+				Key:                  "scrub.sh",
+			},
+		},
+	})
+
+	if got := pod.Annotations[AnnotationKeyRunnerScriptPrefix+"pre-run"]; got != "docker login registry.example.com" {
+		t.Errorf("unexpected pre-run script annotation: %q", got)
+	}
+
+	wantEnv := map[string]string{
+		EnvVarRunnerPreRunScript:     "/etc/runner-scripts/pre-run.sh",
+		EnvVarRunnerHookJobStarted:   "",
+		EnvVarRunnerHookJobCompleted: "/etc/runner-scripts/post-job.sh",
+	}
+
+	for k, want := range wantEnv {
+		if got := getRunnerEnv(&pod, k); got != want {
+			t.Errorf("unexpected %s: want %q, got %q", k, want, got)
+		}
+	}
+
+	if len(pod.Spec.Volumes) != 1 {
+		t.Fatalf("unexpected volumes: %+v", pod.Spec.Volumes)
+	}
+
+	sources := pod.Spec.Volumes[0].Projected.Sources
+	if len(sources) != 2 {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+
+	if d := sources[0].DownwardAPI; d == nil || d.Items[0].Path != "pre-run.sh" {
+		t.Errorf("unexpected pre-run script source: %+v", sources[0])
+	}
+
+	if c := sources[1].ConfigMap; c == nil || c.Name != "hooks" || c.Items[0].Key != "scrub.sh" || c.Items[0].Path != "post-job.sh" {
+		t.Errorf("unexpected post-job script source: %+v", sources[1])
+	}
+
+	if m := pod.Spec.Containers[0].VolumeMounts; len(m) != 1 || m[0].MountPath != runnerScriptsMountPath {
+		t.Errorf("unexpected runner volume mounts: %+v", m)
+	}
+
+	if m := pod.Spec.Containers[1].VolumeMounts; len(m) != 0 {
+		t.Errorf("unexpected docker volume mounts: %+v", m)
+	}
+}
+
+func TestApplyRunnerScripts_None(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName}},
+		},
+	}
+
+	applyRunnerScripts(&pod, v1alpha1.RunnerConfig{})
+
+	if len(pod.Spec.Volumes) != 0 || len(pod.Spec.Containers[0].Env) != 0 || len(pod.Annotations) != 0 {
+		t.Errorf("unexpected changes to the pod: %+v", pod)
+	}
+}
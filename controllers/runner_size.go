@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AnnotationKeyRunnerSize is set to the size label of each runner of a RunnerReplicaSet that advertises multiple sizes.
+const AnnotationKeyRunnerSize = annotationKeyPrefix + "size"
+
+// newSizeAwareRunnerFactory wraps create so that each runner is registered with one of the sizes of the RunnerReplicaSet.
+// A runner is given the first size whose runners fall short of SizeReplicas, in the order of the sizes,
+// or the first size otherwise.
+// Existing runners are never updated, so a runner keeps its size until it's recreated.
+func newSizeAwareRunnerFactory(rs v1alpha1.RunnerReplicaSet, live []v1alpha1.Runner, create func() client.Object) func() client.Object {
+	sizes := rs.Spec.Sizes
+
+	if len(sizes) == 0 {
+		return create
+	}
+
+	short := map[string]int{}
+
+	for size, replicas := range rs.Spec.SizeReplicas {
+		short[size] = replicas
+	}
+
+	for _, r := range live {
+		if !r.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if size, ok := getAnnotation(&r, AnnotationKeyRunnerSize); ok {
+			short[size]--
+		}
+	}
+
+	return func() client.Object {
+		runner := create().(*v1alpha1.Runner)
+
+		size := sizes[0]
+
+		for _, s := range sizes {
+			if short[s] > 0 {
+				size = s
+				break
+			}
+		}
+
+		short[size]--
+
+		setAnnotation(&runner.ObjectMeta, AnnotationKeyRunnerSize, size)
+		runner.Spec.Labels = append(runner.Spec.Labels, size)
+
+		return runner
+	}
+}
+
+// setSizeReplicas sets the number of the replicas reserved for each size by the active capacity reservations
+// to the RunnerDeployment that advertises multiple sizes, and returns true if it's changed.
+// Sizes earlier in the list are given the replicas first when the reservations exceed the replicas.
+func setSizeReplicas(rd *v1alpha1.RunnerDeployment, reservations []v1alpha1.CapacityReservation, now time.Time, replicas int) bool {
+	if len(rd.Spec.Sizes) == 0 {
+		return false
+	}
+
+	reserved := map[string]int{}
+
+	for _, r := range reservations {
+		if r.Size != "" && r.ExpirationTime.Time.After(now) {
+			reserved[r.Size] += r.Replicas
+		}
+	}
+
+	var sizeReplicas map[string]int
+
+	remaining := replicas
+
+	for _, size := range rd.Spec.Sizes {
+		n := reserved[size]
+		if n > remaining {
+			n = remaining
+		}
+
+		if n <= 0 {
+			continue
+		}
+
+		if sizeReplicas == nil {
+			sizeReplicas = map[string]int{}
+		}
+
+		sizeReplicas[size] = n
+		remaining -= n
+	}
+
+	if sameSizeReplicas(rd.Spec.SizeReplicas, sizeReplicas) {
+		return false
+	}
+
+	rd.Spec.SizeReplicas = sizeReplicas
+
+	return true
+}
+
+func sameSizeReplicas(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for size, n := range a {
+		if m, ok := b[size]; !ok || m != n {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jobSize returns the size label requested by the workflow job among the sizes, if any.
+func jobSize(jobLabels, sizes []string) string {
+	for _, l := range jobLabels {
+		for _, s := range sizes {
+			if l == s {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
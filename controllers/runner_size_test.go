@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNewSizeAwareRunnerFactory(t *testing.T) {
+	newRunner := func(size string) v1alpha1.Runner {
+		var r v1alpha1.Runner
+		if size != "" {
+			setAnnotation(&r.ObjectMeta, AnnotationKeyRunnerSize, size)
+		}
+		return r
+	}
+
+	deleting := newRunner("16-core")
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	testcases := []struct {
+		description  string
+		sizes        []string
+		sizeReplicas map[string]int
+		live         []v1alpha1.Runner
+		create       int
+		want         []string
+	}{
+		{
+			description: "no sizes",
+			create:      2,
+			want:        []string{"", ""},
+		},
+		{
+			description: "first size by default",
+			sizes:       []string{"4-core", "16-core"},
+			create:      2,
+			want:        []string{"4-core", "4-core"},
+		},
+		{
+			description:  "reserved sizes first",
+			sizes:        []string{"4-core", "16-core", "64-core"},
+			sizeReplicas: map[string]int{"16-core": 1, "64-core": 2},
+			live:         []v1alpha1.Runner{newRunner("64-core"), deleting},
+			create:       3,
+			want:         []string{"16-core", "64-core", "4-core"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			rs := v1alpha1.RunnerReplicaSet{
+				Spec: v1alpha1.RunnerReplicaSetSpec{
+					Sizes:        tc.sizes,
+					SizeReplicas: tc.sizeReplicas,
+				},
+			}
+
+			desired := v1alpha1.Runner{}
+			desired.Spec.Labels = []string{"linux"}
+
+			create := newSizeAwareRunnerFactory(rs, tc.live, func() client.Object { return desired.DeepCopy() })
+
+			for j := 0; j < tc.create; j++ {
+				runner := create().(*v1alpha1.Runner)
+
+				size, _ := getAnnotation(runner, AnnotationKeyRunnerSize)
+				if size != tc.want[j] {
+					t.Errorf("runner %d: unexpected size: want %q, got %q", j, tc.want[j], size)
+				}
+
+				wantLabels := []string{"linux"}
+				if tc.want[j] != "" {
+					wantLabels = append(wantLabels, tc.want[j])
+				}
+
+				if !reflect.DeepEqual(runner.Spec.Labels, wantLabels) {
+					t.Errorf("runner %d: unexpected labels: want %v, got %v", j, wantLabels, runner.Spec.Labels)
+				}
+			}
+
+			if !reflect.DeepEqual(desired.Spec.Labels, []string{"linux"}) {
+				t.Errorf("desired runner must not be modified: %v", desired.Spec.Labels)
+			}
+		})
+	}
+}
+
+func TestSetSizeReplicas(t *testing.T) {
+	now := time.Now()
+
+	reservation := func(size string, replicas int, expiresIn time.Duration) v1alpha1.CapacityReservation {
+		return v1alpha1.CapacityReservation{
+			Size:           size,
+			Replicas:       replicas,
+			ExpirationTime: metav1.Time{Time: now.Add(expiresIn)},
+		}
+	}
+
+	testcases := []struct {
+		sizes        []string
+		current      map[string]int
+		reservations []v1alpha1.CapacityReservation
+		replicas     int
+		wantChanged  bool
+		want         map[string]int
+	}{
+		{
+			reservations: []v1alpha1.CapacityReservation{reservation("16-core", 1, time.Hour)},
+			replicas:     1,
+		},
+		{
+			sizes: []string{"4-core", "16-core"},
+			reservations: []v1alpha1.CapacityReservation{
+				reservation("", 1, time.Hour),
+				reservation("16-core", 1, time.Hour),
+				reservation("16-core", 1, time.Hour),
+				reservation("4-core", 1, -time.Hour),
+				reservation("unknown", 1, time.Hour),
+			},
+			replicas:    3,
+			wantChanged: true,
+			want:        map[string]int{"16-core": 2},
+		},
+		{
+			sizes:        []string{"4-core", "16-core"},
+			current:      map[string]int{"16-core": 2},
+			reservations: []v1alpha1.CapacityReservation{reservation("16-core", 2, time.Hour)},
+			replicas:     3,
+			want:         map[string]int{"16-core": 2},
+		},
+		{
+			sizes:   []string{"4-core", "16-core"},
+			current: map[string]int{"16-core": 2},
+			reservations: []v1alpha1.CapacityReservation{
+				reservation("16-core", 2, time.Hour),
+				reservation("4-core", 2, time.Hour),
+			},
+			replicas:    3,
+			wantChanged: true,
+			want:        map[string]int{"4-core": 2, "16-core": 1},
+		},
+		{
+			sizes:       []string{"4-core", "16-core"},
+			current:     map[string]int{"16-core": 2},
+			replicas:    3,
+			wantChanged: true,
+		},
+	}
+
+	for i, tc := range testcases {
+		rd := &v1alpha1.RunnerDeployment{
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Sizes:        tc.sizes,
+				SizeReplicas: tc.current,
+			},
+		}
+
+		changed := setSizeReplicas(rd, tc.reservations, now, tc.replicas)
+
+		if changed != tc.wantChanged {
+			t.Errorf("[%d] unexpected changed: want %v, got %v", i, tc.wantChanged, changed)
+		}
+
+		if !sameSizeReplicas(rd.Spec.SizeReplicas, tc.want) {
+			t.Errorf("[%d] unexpected size replicas: want %v, got %v", i, tc.want, rd.Spec.SizeReplicas)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const DefaultRunnerUtilizationSamplingInterval = 1 * time.Minute
+
+// RunnerUtilizationSampler periodically samples whether each runner is busy on GitHub, and accumulates
+// the registered time, the busy time and the number of jobs of the runner in its status.utilization.
+// The numbers are also summed up per RunnerDeployment in its status.utilization, which outlives the runners,
+// so that the utilization of a runner pool can be reviewed to right-size its minReplicas.
+//
+// The list runners API is called once per registration scope and credentials on each sample,
+// which is usually served from the GitHub API cache.
+//
+// As the busy status is sampled, the busy time between two samples is estimated to be half the interval
+// when the runner was busy on only one of them. Only Runners are sampled, so runners of RunnerSets are not.
+//
+// When ReportWindow is set, the utilization and the peak concurrency of the runners of each RunnerDeployment
+// over the rolling window are also reported in its status.utilizationReport and the metrics,
+// for reviewing which runner pools are over-provisioned.
+type RunnerUtilizationSampler struct {
+	client.Client
+	Log           logr.Logger
+	GitHubClient  *github.Client
+	GitHubClients *MultiGitHubClient
+
+	Interval     time.Duration
+	ReportWindow time.Duration
+	Namespace    string
+
+	// reported is the runner deployments whose utilization reports are exported as the metrics,
+	// so that the metrics of the deleted ones are removed.
+	reported map[types.NamespacedName]bool
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader accumulates the utilization.
+func (s *RunnerUtilizationSampler) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (s *RunnerUtilizationSampler) Start(ctx context.Context) error {
+	interval := s.interval()
+
+	s.Log.Info("Starting runner utilization sampler", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sampleAll(ctx, time.Now()); err != nil {
+			s.Log.Error(err, "Failed to sample runner utilization")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *RunnerUtilizationSampler) interval() time.Duration {
+	if s.Interval <= 0 {
+		return DefaultRunnerUtilizationSamplingInterval
+	}
+
+	return s.Interval
+}
+
+type runnerUtilizationScope struct {
+	runnerScope
+
+	credentials string
+}
+
+func (s *RunnerUtilizationSampler) sampleAll(ctx context.Context, now time.Time) error {
+	var opts []client.ListOption
+	if s.Namespace != "" {
+		opts = append(opts, client.InNamespace(s.Namespace))
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := s.List(ctx, &runners, opts...); err != nil {
+		return err
+	}
+
+	registered := map[runnerUtilizationScope]map[string]*gogithub.Runner{}
+	deltas := map[types.NamespacedName]v1alpha1.RunnerUtilization{}
+	concurrency := map[types.NamespacedName]runnerConcurrency{}
+
+	for i := range runners.Items {
+		runner := &runners.Items[i]
+
+		log := s.Log.WithValues("runner", types.NamespacedName{Namespace: runner.Namespace, Name: runner.Name})
+
+		scope := runnerUtilizationScope{
+			runnerScope: runnerScope{
+				enterprise: runner.Spec.Enterprise,
+				org:        runner.Spec.Organization,
+				repo:       runner.Spec.Repository,
+			},
+			credentials: githubAPICredentialsKey(runner.Namespace, runner.Spec.GitHubAPICredentialsFrom),
+		}
+
+		byName, ok := registered[scope]
+		if !ok {
+			ghc, err := s.GitHubClients.ClientFor(ctx, s.GitHubClient, runner.Namespace, runner.Spec.GitHubAPICredentialsFrom)
+			if err != nil {
+				log.Error(err, "Could not get the GitHub client for the githubAPICredentialsFrom")
+			} else if ghRunners, err := ghc.ListRunners(ctx, scope.enterprise, scope.org, scope.repo); err != nil {
+				log.Error(err, "Failed to list runners on GitHub", "enterprise", scope.enterprise, "organization", scope.org, "repository", scope.repo)
+			} else {
+				byName = map[string]*gogithub.Runner{}
+				for _, r := range ghRunners {
+					byName[r.GetName()] = r
+				}
+			}
+
+			registered[scope] = byName
+		}
+
+		// The runners aren't sampled at all when the GitHub API failed, so that the failure isn't accounted as idle time.
+		if byName == nil {
+			continue
+		}
+
+		ghRunner, isRegistered := byName[runner.Name]
+
+		if !isRegistered && runner.Status.Utilization == nil {
+			continue
+		}
+
+		utilization, delta := sampleRunnerUtilization(now, 2*s.interval(), runner.Status, isRegistered, ghRunner.GetBusy())
+
+		if rd := runner.Labels[LabelKeyRunnerDeploymentName]; rd != "" && isRegistered {
+			key := types.NamespacedName{Namespace: runner.Namespace, Name: rd}
+
+			c := concurrency[key]
+			c.runners++
+			if ghRunner.GetBusy() {
+				c.busy++
+			}
+			concurrency[key] = c
+		}
+
+		updated := runner.DeepCopy()
+		updated.Status.Busy = isRegistered && ghRunner.GetBusy()
+		updated.Status.Utilization = &utilization
+
+		if err := s.Status().Patch(ctx, updated, client.MergeFromWithOptions(runner, client.MergeFromWithOptimisticLock{})); err != nil {
+			// The sample is dropped on a conflict, and the next sample covers the interval instead.
+			log.V(1).Info("Failed to update runner utilization", "error", err.Error())
+
+			continue
+		}
+
+		if rd := runner.Labels[LabelKeyRunnerDeploymentName]; rd != "" {
+			key := types.NamespacedName{Namespace: runner.Namespace, Name: rd}
+			deltas[key] = addRunnerUtilization(deltas[key], delta)
+		}
+	}
+
+	keys := map[types.NamespacedName]bool{}
+	for key := range deltas {
+		keys[key] = true
+	}
+
+	if s.ReportWindow > 0 {
+		// Runner deployments without runners are also reported, so that their reports reflect the idle hours.
+		var rds v1alpha1.RunnerDeploymentList
+		if err := s.List(ctx, &rds, opts...); err != nil {
+			return err
+		}
+
+		for _, rd := range rds.Items {
+			keys[types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}] = true
+		}
+
+		for key := range s.reported {
+			if !keys[key] {
+				metrics.DeleteRunnerDeploymentUtilizationReport(key.Namespace, key.Name)
+				delete(s.reported, key)
+			}
+		}
+	}
+
+	for key := range keys {
+		delta, sampled := deltas[key]
+
+		if err := s.addToRunnerDeployment(ctx, now, key, delta, sampled, concurrency[key]); err != nil {
+			s.Log.Error(err, "Failed to update runner deployment utilization", "runnerdeployment", key)
+		}
+	}
+
+	return nil
+}
+
+// sampleRunnerUtilization returns the utilization of the runner updated with a sample taken at now,
+// along with the increase of the utilization to be added to the runner pool.
+//
+// The interval between samples is capped at maxInterval, so that the time the controller was not running
+// or failed to sample the runner isn't accounted.
+func sampleRunnerUtilization(now time.Time, maxInterval time.Duration, status v1alpha1.RunnerStatus, registered, busy bool) (v1alpha1.RunnerUtilization, v1alpha1.RunnerUtilization) {
+	var utilization v1alpha1.RunnerUtilization
+	if status.Utilization != nil {
+		utilization = *status.Utilization
+	}
+
+	var elapsed time.Duration
+	if last := utilization.LastSampleTime; last != nil && now.After(last.Time) {
+		elapsed = now.Sub(last.Time)
+	}
+
+	if elapsed > maxInterval {
+		elapsed = maxInterval
+	}
+
+	busy = registered && busy
+
+	var delta v1alpha1.RunnerUtilization
+
+	if registered {
+		delta.RegisteredSeconds = int64(elapsed.Round(time.Second).Seconds())
+	}
+
+	var busyTime time.Duration
+
+	if status.Busy {
+		busyTime += elapsed / 2
+	}
+
+	if busy {
+		busyTime += elapsed / 2
+	}
+
+	delta.BusySeconds = int64(busyTime.Round(time.Second).Seconds())
+
+	if busy && !status.Busy {
+		delta.JobsExecuted = 1
+	}
+
+	utilization = addRunnerUtilization(utilization, delta)
+	utilization.LastSampleTime = &metav1.Time{Time: now}
+
+	return utilization, delta
+}
+
+func addRunnerUtilization(u, delta v1alpha1.RunnerUtilization) v1alpha1.RunnerUtilization {
+	u.RegisteredSeconds += delta.RegisteredSeconds
+	u.BusySeconds += delta.BusySeconds
+	u.JobsExecuted += delta.JobsExecuted
+
+	return u
+}
+
+// addToRunnerDeployment adds the increase of the utilization of the runners to the runner deployment,
+// along with the concurrency of the runners to its utilization report when enabled.
+// The utilization is left as is unless any runner of the runner deployment was sampled.
+// It's retried on conflicts, as the increase would otherwise be lost while the runners' utilizations are already updated.
+func (s *RunnerUtilizationSampler) addToRunnerDeployment(ctx context.Context, now time.Time, key types.NamespacedName, delta v1alpha1.RunnerUtilization, sampled bool, c runnerConcurrency) error {
+	var err error
+
+	for i := 0; i < 3; i++ {
+		var rd v1alpha1.RunnerDeployment
+		if err := s.Get(ctx, key, &rd); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		updated := rd.DeepCopy()
+
+		if sampled {
+			var utilization v1alpha1.RunnerUtilization
+			if rd.Status.Utilization != nil {
+				utilization = *rd.Status.Utilization
+			}
+
+			utilization = addRunnerUtilization(utilization, delta)
+			utilization.LastSampleTime = &metav1.Time{Time: now}
+
+			updated.Status.Utilization = &utilization
+		}
+
+		if s.ReportWindow > 0 {
+			report := updateRunnerUtilizationReport(rd.Status.UtilizationReport, now, s.ReportWindow, delta, c)
+			updated.Status.UtilizationReport = &report
+		}
+
+		err = nil
+
+		if !equality.Semantic.DeepEqual(rd.Status, updated.Status) {
+			err = s.Status().Patch(ctx, updated, client.MergeFromWithOptions(&rd, client.MergeFromWithOptimisticLock{}))
+		}
+
+		if err == nil && s.ReportWindow > 0 {
+			if s.reported == nil {
+				s.reported = map[types.NamespacedName]bool{}
+			}
+
+			s.reported[key] = true
+
+			metrics.SetRunnerDeploymentUtilizationReport(key.Namespace, key.Name, *updated.Status.UtilizationReport)
+		}
+
+		if !kerrors.IsConflict(err) {
+			return err
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return err
+}
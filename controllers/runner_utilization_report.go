@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const runnerUtilizationReportBucketSize = time.Hour
+
+// runnerConcurrency is the number of the runners of a runner pool registered and busy at the time of a utilization sample.
+type runnerConcurrency struct {
+	runners int
+	busy    int
+}
+
+// updateRunnerUtilizationReport returns the report updated with the increase of the utilization and the concurrency of
+// the runner pool sampled at now. The increase is added to the hourly bucket of now, the buckets that ended before
+// the window are dropped, and the numbers of the report are summed up from the remaining buckets.
+func updateRunnerUtilizationReport(report *v1alpha1.RunnerUtilizationReport, now time.Time, window time.Duration, delta v1alpha1.RunnerUtilization, c runnerConcurrency) v1alpha1.RunnerUtilizationReport {
+	hours := (window + runnerUtilizationReportBucketSize - 1) / runnerUtilizationReportBucketSize
+	if hours < 1 {
+		hours = 1
+	}
+
+	window = hours * runnerUtilizationReportBucketSize
+
+	var buckets []v1alpha1.RunnerUtilizationBucket
+	if report != nil {
+		buckets = append(buckets, report.Buckets...)
+	}
+
+	start := now.Truncate(runnerUtilizationReportBucketSize)
+
+	if n := len(buckets); n == 0 || buckets[n-1].StartTime.Time.Before(start) {
+		buckets = append(buckets, v1alpha1.RunnerUtilizationBucket{StartTime: metav1.Time{Time: start}})
+	}
+
+	// A sample taken before the latest bucket, as in a clock skew between the controller replicas, goes to the latest bucket.
+	b := &buckets[len(buckets)-1]
+	b.RegisteredSeconds += delta.RegisteredSeconds
+	b.BusySeconds += delta.BusySeconds
+
+	if c.runners > b.PeakRunners {
+		b.PeakRunners = c.runners
+	}
+
+	if c.busy > b.PeakBusyRunners {
+		b.PeakBusyRunners = c.busy
+	}
+
+	from := start.Add(runnerUtilizationReportBucketSize - window)
+
+	updated := v1alpha1.RunnerUtilizationReport{
+		Window: metav1.Duration{Duration: window},
+	}
+
+	for _, b := range buckets {
+		if b.StartTime.Time.Before(from) {
+			continue
+		}
+
+		updated.Buckets = append(updated.Buckets, b)
+		updated.RegisteredSeconds += b.RegisteredSeconds
+		updated.BusySeconds += b.BusySeconds
+
+		if b.PeakRunners > updated.PeakRunners {
+			updated.PeakRunners = b.PeakRunners
+		}
+
+		if b.PeakBusyRunners > updated.PeakBusyRunners {
+			updated.PeakBusyRunners = b.PeakBusyRunners
+		}
+	}
+
+	if updated.RegisteredSeconds > 0 {
+		updated.UtilizationPercent = int(updated.BusySeconds * 100 / updated.RegisteredSeconds)
+	}
+
+	return updated
+}
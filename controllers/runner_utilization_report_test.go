@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateRunnerUtilizationReport(t *testing.T) {
+	now := time.Date(2022, 3, 8, 10, 30, 0, 0, time.UTC)
+
+	bucket := func(hoursAgo int, registered, busy int64, runners, busyRunners int) v1alpha1.RunnerUtilizationBucket {
+		return v1alpha1.RunnerUtilizationBucket{
+			StartTime:         metav1.Time{Time: now.Truncate(time.Hour).Add(-time.Duration(hoursAgo) * time.Hour)},
+			RegisteredSeconds: registered,
+			BusySeconds:       busy,
+			PeakRunners:       runners,
+			PeakBusyRunners:   busyRunners,
+		}
+	}
+
+	t.Run("first sample", func(t *testing.T) {
+		got := updateRunnerUtilizationReport(nil, now, 3*time.Hour, v1alpha1.RunnerUtilization{RegisteredSeconds: 120, BusySeconds: 30}, runnerConcurrency{runners: 2, busy: 1})
+
+		if len(got.Buckets) != 1 || got.Buckets[0] != bucket(0, 120, 30, 2, 1) {
+			t.Errorf("unexpected buckets: %+v", got.Buckets)
+		}
+
+		if got.Window.Duration != 3*time.Hour || got.RegisteredSeconds != 120 || got.BusySeconds != 30 || got.UtilizationPercent != 25 || got.PeakRunners != 2 || got.PeakBusyRunners != 1 {
+			t.Errorf("unexpected report: %+v", got)
+		}
+	})
+
+	t.Run("sample within the latest bucket", func(t *testing.T) {
+		report := &v1alpha1.RunnerUtilizationReport{
+			Buckets: []v1alpha1.RunnerUtilizationBucket{bucket(1, 3600, 3600, 5, 5), bucket(0, 600, 0, 1, 0)},
+		}
+
+		got := updateRunnerUtilizationReport(report, now, 3*time.Hour, v1alpha1.RunnerUtilization{RegisteredSeconds: 120, BusySeconds: 60}, runnerConcurrency{runners: 2, busy: 1})
+
+		if len(got.Buckets) != 2 || got.Buckets[1] != bucket(0, 720, 60, 2, 1) {
+			t.Errorf("unexpected buckets: %+v", got.Buckets)
+		}
+
+		if got.RegisteredSeconds != 4320 || got.BusySeconds != 3660 || got.UtilizationPercent != 84 || got.PeakRunners != 5 || got.PeakBusyRunners != 5 {
+			t.Errorf("unexpected report: %+v", got)
+		}
+
+		if report.Buckets[1] != bucket(0, 600, 0, 1, 0) {
+			t.Errorf("the given report was modified: %+v", report.Buckets)
+		}
+	})
+
+	t.Run("buckets out of the window are dropped", func(t *testing.T) {
+		report := &v1alpha1.RunnerUtilizationReport{
+			Buckets: []v1alpha1.RunnerUtilizationBucket{bucket(3, 3600, 3600, 10, 10), bucket(2, 3600, 0, 1, 0), bucket(1, 3600, 1800, 1, 1)},
+		}
+
+		got := updateRunnerUtilizationReport(report, now, 150*time.Minute, v1alpha1.RunnerUtilization{}, runnerConcurrency{})
+
+		want := []v1alpha1.RunnerUtilizationBucket{bucket(2, 3600, 0, 1, 0), bucket(1, 3600, 1800, 1, 1), bucket(0, 0, 0, 0, 0)}
+
+		if len(got.Buckets) != len(want) {
+			t.Fatalf("unexpected buckets: %+v", got.Buckets)
+		}
+
+		for i := range want {
+			if got.Buckets[i] != want[i] {
+				t.Errorf("unexpected bucket %d: want %+v, got %+v", i, want[i], got.Buckets[i])
+			}
+		}
+
+		if got.Window.Duration != 3*time.Hour || got.RegisteredSeconds != 7200 || got.BusySeconds != 1800 || got.UtilizationPercent != 25 || got.PeakRunners != 1 || got.PeakBusyRunners != 1 {
+			t.Errorf("unexpected report: %+v", got)
+		}
+	})
+}
+
+func TestRunnerUtilizationSampler_Report(t *testing.T) {
+	ctx := context.Background()
+
+	// Truncated as metav1.Time is serialized in seconds
+	now := time.Now().Truncate(time.Second)
+	lastSample := &metav1.Time{Time: now.Add(-time.Minute)}
+
+	newRunner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+			Status: v1alpha1.RunnerStatus{Utilization: &v1alpha1.RunnerUtilization{LastSampleTime: lastSample}},
+		}
+	}
+
+	objs := []runtime.Object{
+		&v1alpha1.RunnerDeployment{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}},
+		&v1alpha1.RunnerDeployment{ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "default"}},
+		newRunner("runner-1"),
+		newRunner("runner-2"),
+	}
+
+	server := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 2, "runners": [{"name": "runner-1", "status": "online", "busy": true}, {"name": "runner-2", "status": "online", "busy": false}]}`))
+	defer server.Close()
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	s := &RunnerUtilizationSampler{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+		ReportWindow: 24 * time.Hour,
+	}
+
+	if err := s.sampleAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	get := func(name string) v1alpha1.RunnerDeployment {
+		var rd v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &rd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return rd
+	}
+
+	if got := get("example").Status.UtilizationReport; got == nil || got.RegisteredSeconds != 120 || got.BusySeconds != 30 || got.UtilizationPercent != 25 || got.PeakRunners != 2 || got.PeakBusyRunners != 1 {
+		t.Errorf("unexpected utilization report of the runner deployment: %+v", got)
+	}
+
+	idle := get("idle")
+
+	if got := idle.Status.UtilizationReport; got == nil || got.RegisteredSeconds != 0 || got.PeakRunners != 0 || len(got.Buckets) != 1 {
+		t.Errorf("unexpected utilization report of the runner deployment without runners: %+v", got)
+	}
+
+	if idle.Status.Utilization != nil {
+		t.Errorf("the utilization of the runner deployment without runners must not be updated: %+v", idle.Status.Utilization)
+	}
+}
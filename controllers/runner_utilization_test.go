@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSampleRunnerUtilization(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	sampled := func(ago time.Duration, registered, busy, jobs int64) *v1alpha1.RunnerUtilization {
+		return &v1alpha1.RunnerUtilization{
+			RegisteredSeconds: registered,
+			BusySeconds:       busy,
+			JobsExecuted:      jobs,
+			LastSampleTime:    &metav1.Time{Time: now.Add(-ago)},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		status      v1alpha1.RunnerStatus
+		registered  bool
+		busy        bool
+		want        v1alpha1.RunnerUtilization
+	}{
+		{
+			description: "first sample",
+			registered:  true,
+			busy:        true,
+			want:        v1alpha1.RunnerUtilization{JobsExecuted: 1},
+		},
+		{
+			description: "idle",
+			status:      v1alpha1.RunnerStatus{Utilization: sampled(time.Minute, 60, 0, 0)},
+			registered:  true,
+			want:        v1alpha1.RunnerUtilization{RegisteredSeconds: 120},
+		},
+		{
+			description: "started a job",
+			status:      v1alpha1.RunnerStatus{Utilization: sampled(time.Minute, 60, 0, 0)},
+			registered:  true,
+			busy:        true,
+			want:        v1alpha1.RunnerUtilization{RegisteredSeconds: 120, BusySeconds: 30, JobsExecuted: 1},
+		},
+		{
+			description: "running a job",
+			status:      v1alpha1.RunnerStatus{Busy: true, Utilization: sampled(time.Minute, 120, 30, 1)},
+			registered:  true,
+			busy:        true,
+			want:        v1alpha1.RunnerUtilization{RegisteredSeconds: 180, BusySeconds: 90, JobsExecuted: 1},
+		},
+		{
+			description: "unregistered after a job",
+			status:      v1alpha1.RunnerStatus{Busy: true, Utilization: sampled(time.Minute, 180, 90, 1)},
+			want:        v1alpha1.RunnerUtilization{RegisteredSeconds: 180, BusySeconds: 120, JobsExecuted: 1},
+		},
+		{
+			description: "long gap is capped",
+			status:      v1alpha1.RunnerStatus{Busy: true, Utilization: sampled(time.Hour, 60, 60, 1)},
+			registered:  true,
+			busy:        true,
+			want:        v1alpha1.RunnerUtilization{RegisteredSeconds: 180, BusySeconds: 180, JobsExecuted: 1},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got, _ := sampleRunnerUtilization(now, 2*time.Minute, tc.status, tc.registered, tc.busy)
+
+			if got.LastSampleTime == nil || !got.LastSampleTime.Time.Equal(now) {
+				t.Errorf("unexpected last sample time: %v", got.LastSampleTime)
+			}
+
+			got.LastSampleTime = nil
+
+			if got != tc.want {
+				t.Errorf("unexpected utilization: want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerUtilizationSampler(t *testing.T) {
+	ctx := context.Background()
+
+	// Truncated as metav1.Time is serialized in seconds
+	now := time.Now().Truncate(time.Second)
+	lastSample := &metav1.Time{Time: now.Add(-time.Minute)}
+
+	newRunner := func(name string, status v1alpha1.RunnerStatus) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+			Spec: v1alpha1.RunnerSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+			},
+			Status: status,
+		}
+	}
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Status: v1alpha1.RunnerDeploymentStatus{
+			Utilization: &v1alpha1.RunnerUtilization{RegisteredSeconds: 1000, BusySeconds: 500, JobsExecuted: 10},
+		},
+	}
+
+	objs := []runtime.Object{
+		rd,
+		// Started a job
+		newRunner("runner-1", v1alpha1.RunnerStatus{Utilization: &v1alpha1.RunnerUtilization{LastSampleTime: lastSample}}),
+		// Idle
+		newRunner("runner-2", v1alpha1.RunnerStatus{Utilization: &v1alpha1.RunnerUtilization{LastSampleTime: lastSample}}),
+		// Not registered yet
+		newRunner("runner-3", v1alpha1.RunnerStatus{}),
+	}
+
+	server := fake.NewServer(fake.WithListRunnersResponse(200, `{"total_count": 2, "runners": [{"name": "runner-1", "status": "online", "busy": true}, {"name": "runner-2", "status": "online", "busy": false}]}`))
+	defer server.Close()
+
+	c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+	s := &RunnerUtilizationSampler{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+	}
+
+	if err := s.sampleAll(ctx, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	get := func(name string) v1alpha1.Runner {
+		var r v1alpha1.Runner
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return r
+	}
+
+	if r := get("runner-1"); !r.Status.Busy || r.Status.Utilization == nil || r.Status.Utilization.JobsExecuted != 1 || r.Status.Utilization.BusySeconds != 30 {
+		t.Errorf("unexpected status of runner-1: %+v", r.Status)
+	}
+
+	if r := get("runner-2"); r.Status.Busy || r.Status.Utilization == nil || r.Status.Utilization.RegisteredSeconds != 60 || r.Status.Utilization.BusySeconds != 0 {
+		t.Errorf("unexpected status of runner-2: %+v", r.Status)
+	}
+
+	if r := get("runner-3"); r.Status.Utilization != nil {
+		t.Errorf("unregistered runner must not be sampled: %+v", r.Status)
+	}
+
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &updated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := updated.Status.Utilization
+	if got == nil || got.RegisteredSeconds != 1120 || got.BusySeconds != 530 || got.JobsExecuted != 11 {
+		t.Errorf("unexpected utilization of the runner deployment: %+v", got)
+	}
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+
+// runnerVariableReference matches the ${NAME} references to the substitution variables of runner pods.
+var runnerVariableReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// runnerVariables returns the substitution variables read from the ConfigMap referenced by spec.variablesFrom of the runner, if any.
+func (r *RunnerReconciler) runnerVariables(ctx context.Context, runner v1alpha1.Runner) (map[string]string, error) {
+	ref := runner.Spec.VariablesFrom
+	if ref == nil {
+		return nil, nil
+	}
+
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Namespace: runner.Namespace, Name: ref.Name}, &cm); err != nil {
+		return nil, fmt.Errorf("getting configmap %q of the substitution variables: %w", ref.Name, err)
+	}
+
+	return cm.Data, nil
+}
+
+// substituteRunnerVariables replaces the ${NAME} references to the variables in the string fields of the pod with their values.
+// The references to undefined variables are left as is, so that e.g. the shell variables in the commands of the containers keep working.
+func substituteRunnerVariables(pod *corev1.Pod, vars map[string]string) error {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+
+	// The references never contain any character escaped in JSON strings, so they are replaced in the JSON as is,
+	// with the values escaped as JSON strings.
+	substituted := runnerVariableReference.ReplaceAllFunc(data, func(ref []byte) []byte {
+		value, ok := vars[string(runnerVariableReference.FindSubmatch(ref)[1])]
+		if !ok {
+			return ref
+		}
+
+		quoted, _ := json.Marshal(value)
+
+		return quoted[1 : len(quoted)-1]
+	})
+
+	var result corev1.Pod
+	if err := json.Unmarshal(substituted, &result); err != nil {
+		return fmt.Errorf("substituting variables: %w", err)
+	}
+
+	*pod = result
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSubstituteRunnerVariables(t *testing.T) {
+	ctx := context.Background()
+
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner", UID: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerConfig: v1alpha1.RunnerConfig{
+				Repository: "test/valid",
+				Image:      "${REGISTRY}/actions-runner:latest",
+				Labels:     []string{"${ENVIRONMENT}"},
+			},
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{
+				Env: []corev1.EnvVar{
+					{Name: "ARTIFACTORY_URL", Value: "${ARTIFACTORY_URL}"},
+					{Name: "CACHE_DIR", Value: "${HOME}/.cache"},
+				},
+				VariablesFrom: &corev1.LocalObjectReference{Name: "runner-variables"},
+			},
+		},
+	}
+
+	c := clientfake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner-variables"},
+			Data: map[string]string{
+				"ENVIRONMENT":     "stage",
+				"REGISTRY":        "registry.stage.example.com",
+				"ARTIFACTORY_URL": `https://artifactory.stage.example.com/?q="a&b"`,
+			},
+		},
+	).Build()
+
+	r := &RunnerReconciler{
+		Client:       c,
+		RunnerImage:  "default-runner-image",
+		DockerImage:  "default-docker-image",
+		GitHubClient: &github.Client{GithubBaseURL: "api.github.com"},
+		Scheme:       sc,
+	}
+
+	pod, err := r.newPod(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vars, err := r.runnerVariables(ctx, runner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := substituteRunnerVariables(&pod, vars); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := pod.Spec.Containers[0].Image; got != "registry.stage.example.com/actions-runner:latest" {
+		t.Errorf("unexpected image: %s", got)
+	}
+
+	env := map[string]string{}
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+
+	if got := env["ARTIFACTORY_URL"]; got != `https://artifactory.stage.example.com/?q="a&b"` {
+		t.Errorf("unexpected ARTIFACTORY_URL: %s", got)
+	}
+
+	if got := env["CACHE_DIR"]; got != "${HOME}/.cache" {
+		t.Errorf("the reference to the undefined variable must be left as is: %s", got)
+	}
+
+	if got := env["RUNNER_LABELS"]; got != "stage" {
+		t.Errorf("unexpected RUNNER_LABELS: %s", got)
+	}
+
+	if pod.Name != "runner" || len(pod.OwnerReferences) != 1 {
+		t.Errorf("unexpected pod metadata: %+v", pod.ObjectMeta)
+	}
+}
+
+func TestRunnerVariables_NotFound(t *testing.T) {
+	r := &RunnerReconciler{Client: clientfake.NewClientBuilder().WithScheme(sc).Build()}
+
+	runner := v1alpha1.Runner{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "runner"},
+		Spec: v1alpha1.RunnerSpec{
+			RunnerPodSpec: v1alpha1.RunnerPodSpec{VariablesFrom: &corev1.LocalObjectReference{Name: "missing"}},
+		},
+	}
+
+	if _, err := r.runnerVariables(context.Background(), runner); err == nil {
+		t.Errorf("expected an error for the missing configmap")
+	}
+}
@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RunnerVersionConditionType is the type of the condition set to RunnerDeployment and RunnerSet
+	// on each version drift check.
+	RunnerVersionConditionType = "RunnerVersionUpToDate"
+
+	// AnnotationKeyRunnerVersionRecycleDisabled can be set to "true" on a RunnerDeployment or a RunnerSet
+	// to prevent its runners from being recycled due to the version drift.
+	AnnotationKeyRunnerVersionRecycleDisabled = annotationKeyPrefix + "runner-version-recycle-disabled"
+
+	DefaultRunnerVersionDriftInterval = 1 * time.Hour
+)
+
+var runnerVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
+
+// RunnerVersionDriftDetector periodically compares the versions of the runners of each runner pool
+// against the latest release of actions/runner and the version of the runner pool's image.
+//
+// GitHub's list runners API doesn't expose the version of the registered runners,
+// so the version of a runner is read from the tag of its runner container image, like `v2.290.1-ubuntu-20.04`.
+// The list runners API is used to tell which runners are registered and busy.
+// Runner pools whose images don't have a version tag are reported to have an unknown version.
+//
+// When RecycleThreshold is positive, runners that are at least that many minor versions behind the latest release,
+// and older than the runner pool's image, are recycled one at a time per runner pool on each check.
+// That happens e.g. after the default runner image of the controller is updated,
+// which doesn't trigger the replacement of existing runners.
+type RunnerVersionDriftDetector struct {
+	client.Client
+	Log          logr.Logger
+	GitHubClient *github.Client
+
+	Interval         time.Duration
+	RecycleThreshold int
+
+	// RunnerImage is the default runner image of the controller, used for runner pools that don't specify one.
+	RunnerImage string
+	Namespace   string
+}
+
+type runnerVersion struct {
+	major, minor, patch int
+}
+
+func (v runnerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func (v runnerVersion) less(o runnerVersion) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.minor != o.minor {
+		return v.minor < o.minor
+	}
+	return v.patch < o.patch
+}
+
+// minorDrift returns the number of minor versions v is behind latest.
+// A runner behind the latest major version is considered to be behind all the minor versions of the latest major version.
+func (v runnerVersion) minorDrift(latest runnerVersion) int {
+	switch {
+	case !v.less(latest):
+		return 0
+	case v.major < latest.major:
+		return latest.minor + 1
+	default:
+		return latest.minor - v.minor
+	}
+}
+
+// parseRunnerVersion parses the runner version out of a version string like `v2.290.1`,
+// or the tag of an image like `summerwind/actions-runner:v2.290.1-ubuntu-20.04`.
+func parseRunnerVersion(s string) (runnerVersion, bool) {
+	if i := strings.Index(s, "@"); i >= 0 {
+		s = s[:i]
+	}
+
+	if i := strings.LastIndex(s, ":"); i >= 0 && !strings.Contains(s[i:], "/") {
+		s = s[i+1:]
+	}
+
+	m := runnerVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return runnerVersion{}, false
+	}
+
+	var v runnerVersion
+
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+
+	return v, true
+}
+
+type runnerVersionPool struct {
+	kind string
+	key  types.NamespacedName
+
+	object client.Object
+	config v1alpha1.RunnerConfig
+	image  string
+	pods   []corev1.Pod
+}
+
+type runnerVersionReport struct {
+	drift int
+	stale []corev1.Pod
+
+	oldest  *runnerVersion
+	pool    *runnerVersion
+	unknown int
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that
+// only the leader recycles runners.
+func (d *RunnerVersionDriftDetector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable.
+func (d *RunnerVersionDriftDetector) Start(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultRunnerVersionDriftInterval
+	}
+
+	d.Log.Info("Starting runner version drift detector", "interval", interval, "recycleThreshold", d.RecycleThreshold)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.checkAll(ctx); err != nil {
+			d.Log.Error(err, "Failed to check runner versions")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *RunnerVersionDriftDetector) checkAll(ctx context.Context) error {
+	release, _, err := d.GitHubClient.Repositories.GetLatestRelease(ctx, "actions", "runner")
+	if err != nil {
+		return fmt.Errorf("getting the latest release of actions/runner: %w", err)
+	}
+
+	latest, ok := parseRunnerVersion(release.GetTagName())
+	if !ok {
+		return fmt.Errorf("unable to parse the version of the latest release of actions/runner: %s", release.GetTagName())
+	}
+
+	pools, err := d.listPools(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pools {
+		log := d.Log.WithValues("kind", p.kind, "name", p.key)
+
+		report := d.check(p, latest)
+
+		metrics.SetRunnerVersionDrift(p.kind, p.key.Namespace, p.key.Name, report.drift, len(report.stale))
+
+		if err := d.setCondition(ctx, p, latest, report); err != nil {
+			log.Error(err, "Failed to update runner version condition")
+		}
+
+		if d.RecycleThreshold <= 0 || report.drift < d.RecycleThreshold || len(report.stale) == 0 {
+			continue
+		}
+
+		if p.object.GetAnnotations()[AnnotationKeyRunnerVersionRecycleDisabled] == "true" {
+			continue
+		}
+
+		if err := d.recycleOne(ctx, log, p, report.stale); err != nil {
+			log.Error(err, "Failed to recycle runner")
+		}
+	}
+
+	return nil
+}
+
+func (d *RunnerVersionDriftDetector) listPools(ctx context.Context) ([]runnerVersionPool, error) {
+	var opts []client.ListOption
+	if d.Namespace != "" {
+		opts = append(opts, client.InNamespace(d.Namespace))
+	}
+
+	var pools []runnerVersionPool
+
+	var rdList v1alpha1.RunnerDeploymentList
+	if err := d.List(ctx, &rdList, opts...); err != nil {
+		return nil, err
+	}
+
+	for i := range rdList.Items {
+		rd := &rdList.Items[i]
+
+		var pods corev1.PodList
+		if err := d.List(ctx, &pods, client.InNamespace(rd.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: rd.Name}); err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, runnerVersionPool{
+			kind:   "RunnerDeployment",
+			key:    types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name},
+			object: rd,
+			config: rd.Spec.Template.Spec.RunnerConfig,
+			image:  d.poolImage(rd.Spec.Template.Spec.RunnerConfig, rd.Spec.Template.Spec.Containers),
+			pods:   pods.Items,
+		})
+	}
+
+	var rsList v1alpha1.RunnerSetList
+	if err := d.List(ctx, &rsList, opts...); err != nil {
+		return nil, err
+	}
+
+	for i := range rsList.Items {
+		rs := &rsList.Items[i]
+
+		selector, err := metav1.LabelSelectorAsSelector(getRunnerSetSelector(rs))
+		if err != nil {
+			return nil, err
+		}
+
+		var pods corev1.PodList
+		if err := d.List(ctx, &pods, client.InNamespace(rs.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, runnerVersionPool{
+			kind:   "RunnerSet",
+			key:    types.NamespacedName{Namespace: rs.Namespace, Name: rs.Name},
+			object: rs,
+			config: rs.Spec.RunnerConfig,
+			image:  d.poolImage(rs.Spec.RunnerConfig, rs.Spec.Template.Spec.Containers),
+			pods:   pods.Items,
+		})
+	}
+
+	return pools, nil
+}
+
+// poolImage returns the runner image new runners of the runner pool are created with,
+// following the same precedence as newRunnerPod.
+func (d *RunnerVersionDriftDetector) poolImage(rc v1alpha1.RunnerConfig, containers []corev1.Container) string {
+	if rc.Image != "" {
+		return rc.Image
+	}
+
+	for _, c := range containers {
+		if c.Name == containerName && c.Image != "" {
+			return c.Image
+		}
+	}
+
+	return d.RunnerImage
+}
+
+func (d *RunnerVersionDriftDetector) check(p runnerVersionPool, latest runnerVersion) runnerVersionReport {
+	var report runnerVersionReport
+
+	if v, ok := parseRunnerVersion(p.image); ok {
+		report.pool = &v
+	}
+
+	for _, pod := range p.pods {
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		v, ok := parseRunnerVersion(podRunnerImage(&pod))
+		if !ok {
+			report.unknown++
+			continue
+		}
+
+		if report.oldest == nil || v.less(*report.oldest) {
+			v := v
+			report.oldest = &v
+		}
+
+		if drift := v.minorDrift(latest); drift > report.drift {
+			report.drift = drift
+		}
+
+		if report.pool != nil && v.less(*report.pool) {
+			report.stale = append(report.stale, pod)
+		}
+	}
+
+	// Oldest first, so that the most outdated runners are recycled first.
+	sort.SliceStable(report.stale, func(i, j int) bool {
+		vi, _ := parseRunnerVersion(podRunnerImage(&report.stale[i]))
+		vj, _ := parseRunnerVersion(podRunnerImage(&report.stale[j]))
+		return vi.less(vj)
+	})
+
+	return report
+}
+
+func podRunnerImage(pod *corev1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			return c.Image
+		}
+	}
+
+	return ""
+}
+
+// recycleOne deletes one of the stale runners that isn't busy running a workflow job nor protected.
+// Runners of RunnerDeployments are recycled by deleting the runner so that the runner controller unregisters it before deleting the pod,
+// and the runner replica set recreates it with the latest template.
+// Runners of RunnerSets are recycled by deleting the runner pod, whose finalizer unregisters the runner.
+func (d *RunnerVersionDriftDetector) recycleOne(ctx context.Context, log logr.Logger, p runnerVersionPool, stale []corev1.Pod) error {
+	runners, err := d.GitHubClient.ListRunners(ctx, p.config.Enterprise, p.config.Organization, p.config.Repository)
+	if err != nil {
+		return err
+	}
+
+	registered := map[string]*gogithub.Runner{}
+	for _, r := range runners {
+		registered[r.GetName()] = r
+	}
+
+	for i := range stale {
+		pod := &stale[i]
+
+		if r, ok := registered[pod.Name]; !ok || r.GetBusy() {
+			continue
+		}
+
+		if isProtected(pod, time.Now()) {
+			continue
+		}
+
+		var obj client.Object = pod
+
+		if p.kind == "RunnerDeployment" {
+			var runner v1alpha1.Runner
+			if err := d.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}, &runner); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+
+			if isProtected(&runner, time.Now()) {
+				continue
+			}
+
+			obj = &runner
+		}
+
+		if err := d.Delete(ctx, obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		log.Info("Recycled runner due to the version drift", "runner", pod.Name, "image", podRunnerImage(pod), "poolImage", p.image)
+
+		metrics.IncRunnerVersionRecycles(p.kind, p.key.Namespace, p.key.Name)
+
+		return nil
+	}
+
+	return nil
+}
+
+func (d *RunnerVersionDriftDetector) setCondition(ctx context.Context, p runnerVersionPool, latest runnerVersion, report runnerVersionReport) error {
+	cond := metav1.Condition{Type: RunnerVersionConditionType}
+
+	switch {
+	case report.oldest == nil:
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = "UnknownVersion"
+		cond.Message = fmt.Sprintf("None of the %d runners have a runner image tagged with the runner version", len(p.pods))
+	case report.drift > 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Drifted"
+		cond.Message = fmt.Sprintf("The oldest runner runs %s which is %d minor versions behind the latest release %s", report.oldest, report.drift, latest)
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "UpToDate"
+		cond.Message = fmt.Sprintf("All the runners run the latest minor version of %s", latest)
+	}
+
+	if len(report.stale) > 0 {
+		cond.Message += fmt.Sprintf(". %d runners are older than the runner pool's image %s", len(report.stale), p.image)
+	}
+
+	if report.unknown > 0 && report.oldest != nil {
+		cond.Message += fmt.Sprintf(". %d runners have unknown versions", report.unknown)
+	}
+
+	switch p.kind {
+	case "RunnerDeployment":
+		var rd v1alpha1.RunnerDeployment
+		if err := d.Get(ctx, p.key, &rd); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		updated := rd.DeepCopy()
+		cond.ObservedGeneration = rd.Generation
+		meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+		return d.Status().Patch(ctx, updated, client.MergeFrom(&rd))
+	case "RunnerSet":
+		var rs v1alpha1.RunnerSet
+		if err := d.Get(ctx, p.key, &rs); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		updated := rs.DeepCopy()
+		cond.ObservedGeneration = rs.Generation
+		meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+		return d.Status().Patch(ctx, updated, client.MergeFrom(&rs))
+	}
+
+	return fmt.Errorf("unsupported kind of runner pool: %s", p.kind)
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseRunnerVersion(t *testing.T) {
+	testcases := []struct {
+		s    string
+		want string
+	}{
+		{s: "v2.290.1", want: "2.290.1"},
+		{s: "2.291.0", want: "2.291.0"},
+		{s: "summerwind/actions-runner:v2.290.1-ubuntu-20.04", want: "2.290.1"},
+		{s: "registry.example.com:5000/actions-runner:v2.289.2", want: "2.289.2"},
+		{s: "summerwind/actions-runner:v2.290.1@sha256:0123456789abcdef", want: "2.290.1"},
+		{s: "summerwind/actions-runner:latest"},
+		{s: "summerwind/actions-runner"},
+		{s: "registry.example.com:5000/actions-runner"},
+	}
+
+	for _, tc := range testcases {
+		v, ok := parseRunnerVersion(tc.s)
+		if tc.want == "" {
+			if ok {
+				t.Errorf("%s: expected no version, got %s", tc.s, v)
+			}
+			continue
+		}
+
+		if !ok || v.String() != tc.want {
+			t.Errorf("%s: want %s, got %s (ok=%v)", tc.s, tc.want, v, ok)
+		}
+	}
+}
+
+func TestRunnerVersionMinorDrift(t *testing.T) {
+	latest := runnerVersion{major: 2, minor: 291, patch: 1}
+
+	testcases := []struct {
+		v    runnerVersion
+		want int
+	}{
+		{v: runnerVersion{major: 2, minor: 291, patch: 1}, want: 0},
+		{v: runnerVersion{major: 2, minor: 291, patch: 0}, want: 0},
+		{v: runnerVersion{major: 2, minor: 288, patch: 5}, want: 3},
+		{v: runnerVersion{major: 1, minor: 300, patch: 0}, want: 292},
+		{v: runnerVersion{major: 3, minor: 0, patch: 0}, want: 0},
+	}
+
+	for _, tc := range testcases {
+		if got := tc.v.minorDrift(latest); got != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.v, tc.want, got)
+		}
+	}
+}
+
+func TestRunnerVersionDriftDetector(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/actions/runner/releases/latest":
+			w.Write([]byte(`{"tag_name": "v2.291.1"}`))
+		case "/repos/test/valid/actions/runners":
+			w.Write([]byte(`{"total_count": 2, "runners": [{"id": 1, "name": "example-busy", "busy": true}, {"id": 2, "name": "example-idle", "busy": false}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{
+						Repository: "test/valid",
+					},
+				},
+			},
+		},
+	}
+
+	objs := []runtime.Object{rd}
+
+	for _, name := range []string{"example-busy", "example-idle", "example-new"} {
+		image := "summerwind/actions-runner:v2.288.1-ubuntu-20.04"
+		if name == "example-new" {
+			image = "summerwind/actions-runner:v2.291.1-ubuntu-20.04"
+		}
+
+		objs = append(objs,
+			&v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: containerName, Image: image}},
+				},
+			},
+		)
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, objs...)
+
+	d := &RunnerVersionDriftDetector{
+		Client:       c,
+		Log:          logr.Discard(),
+		GitHubClient: newGithubClient(server),
+		RunnerImage:  "summerwind/actions-runner:v2.291.1-ubuntu-20.04",
+	}
+
+	if err := d.checkAll(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	cond := meta.FindStatusCondition(updated.Status.Conditions, RunnerVersionConditionType)
+	if cond == nil {
+		t.Fatalf("missing %s condition", RunnerVersionConditionType)
+	}
+
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "Drifted" {
+		t.Errorf("unexpected condition: %+v", cond)
+	}
+
+	// Recycling is disabled by default
+	var runners v1alpha1.RunnerList
+	if err := c.List(ctx, &runners); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runners.Items) != 3 {
+		t.Fatalf("unexpected number of runners: %d", len(runners.Items))
+	}
+
+	d.RecycleThreshold = 3
+
+	if err := d.checkAll(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.List(ctx, &runners); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range runners.Items {
+		names = append(names, r.Name)
+	}
+
+	if len(names) != 2 || names[0] != "example-busy" || names[1] != "example-new" {
+		t.Errorf("unexpected runners after recycling: %v", names)
+	}
+}
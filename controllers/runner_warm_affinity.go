@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultWarmRunnerAffinityWindow = time.Hour
+
+	// maxRunnerRecentJobs is the maximum number of the recent jobs recorded in the status of each runner.
+	maxRunnerRecentJobs = 10
+)
+
+func warmRunnerAffinityWindow(affinity *v1alpha1.WarmRunnerAffinity) time.Duration {
+	if affinity.Window == nil || affinity.Window.Duration <= 0 {
+		return defaultWarmRunnerAffinityWindow
+	}
+
+	return affinity.Window.Duration
+}
+
+// runnerWarmth returns the warmth of the runners by their names.
+//
+// The activity of a repository is the number of the jobs of the repository the runners picked up within the window,
+// and the warmth of a runner is the activity of the most active repository among the ones it picked up jobs of within the window.
+// So the runners that have warm caches for the repositories most likely to run jobs again soon are the warmest.
+func runnerWarmth(now time.Time, window time.Duration, runners []v1alpha1.Runner) map[string]int {
+	since := now.Add(-window)
+
+	activity := map[string]int{}
+
+	for _, r := range runners {
+		for _, job := range r.Status.RecentJobs {
+			if job.StartedAt.Time.After(since) {
+				activity[job.Repository]++
+			}
+		}
+	}
+
+	warmth := map[string]int{}
+
+	for _, r := range runners {
+		for _, job := range r.Status.RecentJobs {
+			if job.StartedAt.Time.After(since) && activity[job.Repository] > warmth[r.Name] {
+				warmth[r.Name] = activity[job.Repository]
+			}
+		}
+	}
+
+	return warmth
+}
+
+// recordRunnerJob prepends the job to the recent jobs in the status of the runner named runnerName.
+// It does nothing when there's no such runner, like when the job was picked up by a runner of a RunnerSet
+// or a runner not managed by the controller.
+func recordRunnerJob(ctx context.Context, c client.Client, namespace, runnerName string, job v1alpha1.RunnerJob) error {
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	var runners v1alpha1.RunnerList
+	if err := c.List(ctx, &runners, opts...); err != nil {
+		return err
+	}
+
+	for i := range runners.Items {
+		runner := &runners.Items[i]
+
+		if runner.Name != runnerName {
+			continue
+		}
+
+		jobs := append([]v1alpha1.RunnerJob{job}, runner.Status.RecentJobs...)
+		if len(jobs) > maxRunnerRecentJobs {
+			jobs = jobs[:maxRunnerRecentJobs]
+		}
+
+		updated := runner.DeepCopy()
+		updated.Status.RecentJobs = jobs
+
+		if err := c.Status().Patch(ctx, updated, client.MergeFrom(runner)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerWarmth(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	runner := func(name string, jobs ...v1alpha1.RunnerJob) v1alpha1.Runner {
+		return v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1alpha1.RunnerStatus{RecentJobs: jobs},
+		}
+	}
+
+	job := func(repo string, ago time.Duration) v1alpha1.RunnerJob {
+		return v1alpha1.RunnerJob{Repository: repo, StartedAt: metav1.NewTime(now.Add(-ago))}
+	}
+
+	runners := []v1alpha1.Runner{
+		runner("hot", job("org/hot", time.Minute), job("org/cold", 2*time.Minute)),
+		runner("hot-too", job("org/hot", 10*time.Minute)),
+		runner("cold", job("org/cold", 20*time.Minute)),
+		runner("stale", job("org/hot", 2*time.Hour)),
+		runner("fresh"),
+	}
+
+	got := runnerWarmth(now, time.Hour, runners)
+
+	want := map[string]int{"hot": 2, "hot-too": 2, "cold": 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected warmth: want %v, got %v", want, got)
+	}
+
+	got = runnerWarmth(now, 15*time.Minute, runners)
+
+	want = map[string]int{"hot": 2, "hot-too": 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected warmth within the shorter window: want %v, got %v", want, got)
+	}
+}
+
+func TestSyncRunnerPodsOwners_WarmRunnerAffinity(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	testcases := []struct {
+		description  string
+		replicas     int
+		busy         map[string]bool
+		warmth       map[string]int
+		wantDeleted  []string
+		wantRetained []string
+	}{
+		{
+			description:  "warmest idle runner is retained in place of newer colder ones",
+			replicas:     1,
+			warmth:       map[string]int{"runner-1": 3, "runner-2": 1},
+			wantDeleted:  []string{"runner-2", "runner-3"},
+			wantRetained: []string{"runner-1"},
+		},
+		{
+			description:  "newest runners are retained among equally warm ones",
+			replicas:     2,
+			warmth:       map[string]int{"runner-1": 1, "runner-2": 1, "runner-3": 1},
+			wantDeleted:  []string{"runner-1"},
+			wantRetained: []string{"runner-2", "runner-3"},
+		},
+		{
+			description:  "busy runner is retained before a warmer idle one",
+			replicas:     1,
+			busy:         map[string]bool{"runner-3": true},
+			warmth:       map[string]int{"runner-1": 3},
+			wantDeleted:  []string{"runner-1", "runner-2"},
+			wantRetained: []string{"runner-3"},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				objs   []runtime.Object
+				owners []client.Object
+			)
+
+			for i, name := range []string{"runner-1", "runner-2", "runner-3"} {
+				r := &v1alpha1.Runner{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              name,
+						Namespace:         "default",
+						CreationTimestamp: metav1.NewTime(created.Add(time.Duration(i) * time.Minute)),
+						Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+					},
+					Status: v1alpha1.RunnerStatus{Phase: "Running"},
+				}
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				}
+				objs = append(objs, r, pod)
+				owners = append(owners, r)
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, objs...)
+
+			desired := &v1alpha1.Runner{
+				ObjectMeta: metav1.ObjectMeta{Name: "desired", Namespace: "default", Labels: map[string]string{LabelKeyRunnerTemplateHash: "abc"}},
+			}
+
+			listBusy := func() map[string]bool {
+				return tc.busy
+			}
+
+			listWarmth := func() map[string]int {
+				return tc.warmth
+			}
+
+			_, err := syncRunnerPodsOwners(ctx, c, logr.Discard(), nil, tc.replicas, desired, func() client.Object { return desired.DeepCopy() }, false, false, "", 0, listBusy, listWarmth, owners)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			check := func(names []string, wantDeleted bool) {
+				for _, name := range names {
+					var runner v1alpha1.Runner
+					if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+						t.Fatal(err)
+					}
+
+					if _, deleted := getAnnotation(&runner, AnnotationKeyUnregistrationRequestTimestamp); deleted != wantDeleted {
+						t.Errorf("%s: unexpected unregistration request: want %v, got %v", name, wantDeleted, deleted)
+					}
+				}
+			}
+
+			check(tc.wantDeleted, true)
+			check(tc.wantRetained, false)
+		})
+	}
+}
+
+func TestRecordRunnerJob(t *testing.T) {
+	ctx := context.Background()
+
+	startedAt := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	var recent []v1alpha1.RunnerJob
+	for i := 0; i < maxRunnerRecentJobs; i++ {
+		recent = append(recent, v1alpha1.RunnerJob{Repository: "org/old", StartedAt: startedAt})
+	}
+
+	c := fake.NewClientBuilder().WithScheme(sc).WithObjects(
+		&v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-abcde"},
+			Status:     v1alpha1.RunnerStatus{RecentJobs: recent},
+		},
+	).Build()
+
+	job := v1alpha1.RunnerJob{Repository: "org/new", Workflow: "ci", Name: "build", StartedAt: startedAt}
+
+	if err := recordRunnerJob(ctx, c, "", "example-abcde", job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := recordRunnerJob(ctx, c, "", "unmanaged", job); err != nil {
+		t.Fatalf("unexpected error for an unmanaged runner: %v", err)
+	}
+
+	var runner v1alpha1.Runner
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-abcde"}, &runner); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := runner.Status.RecentJobs
+	if len(jobs) != maxRunnerRecentJobs {
+		t.Fatalf("unexpected number of recent jobs: %d", len(jobs))
+	}
+
+	if jobs[0].Repository != "org/new" || jobs[0].Name != "build" || jobs[1].Repository != "org/old" {
+		t.Errorf("unexpected recent jobs: %v", jobs)
+	}
+}
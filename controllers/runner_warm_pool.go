@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationKeyRunnerSuspended is set to "true" on runners of the warm pool and their pods.
+	// The runner container of a suspended runner registers the runner but doesn't start accepting jobs
+	// until the annotation is updated to "false" on activation.
+	AnnotationKeyRunnerSuspended = annotationKeyPrefix + "suspended"
+
+	// EnvVarRunnerSuspensionFile is the envvar that tells the runner container the path to the file
+	// that contains the value of AnnotationKeyRunnerSuspended of the pod, projected via the downward API.
+	EnvVarRunnerSuspensionFile = "RUNNER_SUSPENSION_FILE"
+
+	runnerSuspensionVolumeName = "runner-suspension"
+	runnerSuspensionMountPath  = "/etc/runner-suspension"
+)
+
+func isRunnerSuspended(o client.Object) bool {
+	v, _ := getAnnotation(o, AnnotationKeyRunnerSuspended)
+
+	return v == "true"
+}
+
+// activateWarmRunners activates suspended runners of the warm pool until the number of active runners reaches replicas.
+// Runners whose pods are already running, hence are likely to have been registered, are activated first, oldest first.
+func activateWarmRunners(ctx context.Context, c client.Client, log logr.Logger, objects []*podsForOwner, replicas int) error {
+	var (
+		active    int
+		suspended []*podsForOwner
+	)
+
+	for _, o := range objects {
+		if o.runner == nil || !o.owner.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		if _, ok := getAnnotation(o.owner, AnnotationKeyUnregistrationRequestTimestamp); ok {
+			continue
+		}
+
+		if isRunnerSuspended(o.owner) {
+			suspended = append(suspended, o)
+		} else {
+			active++
+		}
+	}
+
+	sort.SliceStable(suspended, func(i, j int) bool {
+		return suspended[i].running > suspended[j].running
+	})
+
+	for i := 0; i < replicas-active && i < len(suspended); i++ {
+		o := suspended[i]
+
+		for _, pod := range o.pods {
+			updated := pod.DeepCopy()
+			setAnnotation(&updated.ObjectMeta, AnnotationKeyRunnerSuspended, "false")
+
+			if err := c.Patch(ctx, updated, client.MergeFrom(&pod)); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+		}
+
+		if err := c.Patch(ctx, o.owner.withAnnotation(AnnotationKeyRunnerSuspended, "false"), client.MergeFrom(o.object)); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		log.V(1).Info("Activated warm runner", "runner", o.owner.GetName())
+	}
+
+	return nil
+}
+
+// addRunnerSuspensionVolume projects the suspension annotation of the pod into a file in the runner container,
+// so that the runner container can wait for the activation.
+// The kubelet updates the file after the annotation is updated, which usually takes up to a minute.
+func addRunnerSuspensionVolume(pod *corev1.Pod) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: runnerSuspensionVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{
+						Path: "suspended",
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: "metadata.annotations['" + AnnotationKeyRunnerSuspended + "']",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+
+		if c.Name != containerName {
+			continue
+		}
+
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      runnerSuspensionVolumeName,
+			MountPath: runnerSuspensionMountPath,
+			ReadOnly:  true,
+		})
+
+		c.Env = append(c.Env, corev1.EnvVar{
+			Name:  EnvVarRunnerSuspensionFile,
+			Value: runnerSuspensionMountPath + "/suspended",
+		})
+	}
+}
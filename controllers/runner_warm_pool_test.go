@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestActivateWarmRunners(t *testing.T) {
+	ctx := context.Background()
+
+	created := metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	newRunner := func(name string, suspended bool, offset time.Duration) *v1alpha1.Runner {
+		r := &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created.Add(offset)),
+				Labels:            map[string]string{LabelKeyRunnerTemplateHash: "abc"},
+			},
+		}
+
+		if suspended {
+			r.Annotations = map[string]string{AnnotationKeyRunnerSuspended: "true"}
+		}
+
+		return r
+	}
+
+	newRunnerPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{AnnotationKeyRunnerSuspended: "true"},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	runners := []*v1alpha1.Runner{
+		newRunner("active", false, 0),
+		newRunner("warm-pending", true, time.Minute),
+		newRunner("warm-running", true, 2*time.Minute),
+		newRunner("warm-running-2", true, 3*time.Minute),
+	}
+
+	objs := []runtime.Object{
+		newRunnerPod("warm-pending", corev1.PodPending),
+		newRunnerPod("warm-running", corev1.PodRunning),
+		newRunnerPod("warm-running-2", corev1.PodRunning),
+	}
+	for _, r := range runners {
+		objs = append(objs, r)
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, objs...)
+
+	var objects []*podsForOwner
+	for _, r := range runners {
+		var runner v1alpha1.Runner
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: r.Name}, &runner); err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := getPodsForOwner(ctx, c, logr.Discard(), &runner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		objects = append(objects, o)
+	}
+
+	if err := activateWarmRunners(ctx, c, logr.Discard(), objects, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"warm-pending":   "true",
+		"warm-running":   "false",
+		"warm-running-2": "true",
+	}
+
+	for name, suspended := range want {
+		var runner v1alpha1.Runner
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &runner); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := runner.Annotations[AnnotationKeyRunnerSuspended]; got != suspended {
+			t.Errorf("%s: unexpected suspension of runner: want %s, got %s", name, suspended, got)
+		}
+
+		var pod corev1.Pod
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &pod); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := pod.Annotations[AnnotationKeyRunnerSuspended]; got != suspended {
+			t.Errorf("%s: unexpected suspension of runner pod: want %s, got %s", name, suspended, got)
+		}
+	}
+}
+
+func TestAddRunnerSuspensionVolume(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerName}, {Name: "docker"}},
+		},
+	}
+
+	addRunnerSuspensionVolume(&pod)
+
+	if len(pod.Spec.Volumes) != 1 || pod.Spec.Volumes[0].DownwardAPI == nil {
+		t.Fatalf("unexpected volumes: %+v", pod.Spec.Volumes)
+	}
+
+	if got := getRunnerEnv(&pod, EnvVarRunnerSuspensionFile); got != "/etc/runner-suspension/suspended" {
+		t.Errorf("unexpected %s: %s", EnvVarRunnerSuspensionFile, got)
+	}
+
+	if len(pod.Spec.Containers[1].VolumeMounts) != 0 || len(pod.Spec.Containers[1].Env) != 0 {
+		t.Errorf("unexpected changes to the docker container: %+v", pod.Spec.Containers[1])
+	}
+}
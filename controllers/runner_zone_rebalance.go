@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultZoneRebalanceIdle            = 10 * time.Minute
+	defaultZoneRebalanceMaxReplacements = 1
+	defaultZoneRebalancePeriod          = time.Hour
+)
+
+// zoneSpreadConstraint returns the topology spread constraint on the zones of the runner template, if any.
+func zoneSpreadConstraint(spec v1alpha1.RunnerSpec) *corev1.TopologySpreadConstraint {
+	for i := range spec.TopologySpreadConstraints {
+		c := spec.TopologySpreadConstraints[i]
+
+		if c.TopologyKey == corev1.LabelTopologyZone || c.TopologyKey == corev1.LabelFailureDomainBetaZone {
+			return &c
+		}
+	}
+
+	return nil
+}
+
+// planZoneRebalance returns the number of runners to be replaced in each zone to bring the skew of the runners
+// across the zones within maxSkew, up to budget runners in total.
+// Each replacement is assumed to land in the least crowded zone, as the scheduler places it according to the constraint.
+func planZoneRebalance(counts map[string]int, maxSkew, budget int) map[string]int {
+	if maxSkew < 1 {
+		maxSkew = 1
+	}
+
+	zones := make([]string, 0, len(counts))
+	for z := range counts {
+		zones = append(zones, z)
+	}
+
+	if len(zones) < 2 {
+		return nil
+	}
+
+	sort.Strings(zones)
+
+	simulated := make(map[string]int, len(counts))
+	for z, c := range counts {
+		simulated[z] = c
+	}
+
+	var plan map[string]int
+
+	for i := 0; i < budget; i++ {
+		most, least := zones[0], zones[0]
+
+		for _, z := range zones[1:] {
+			if simulated[z] > simulated[most] {
+				most = z
+			}
+
+			if simulated[z] < simulated[least] {
+				least = z
+			}
+		}
+
+		if simulated[most]-simulated[least] <= maxSkew {
+			break
+		}
+
+		if plan == nil {
+			plan = map[string]int{}
+		}
+
+		plan[most]++
+		simulated[most]--
+		simulated[least]++
+	}
+
+	return plan
+}
+
+// pruneZoneRebalanceTimes returns the times of the replacements made within the period of the churn budget.
+func pruneZoneRebalanceTimes(now time.Time, times []metav1.Time, period time.Duration) []metav1.Time {
+	var pruned []metav1.Time
+
+	for _, t := range times {
+		if t.Add(period).After(now) {
+			pruned = append(pruned, t)
+		}
+	}
+
+	return pruned
+}
+
+// rebalanceZones replaces idle runners in the zones crowded beyond the maxSkew of the zone spread constraint of the template,
+// while the RunnerReplicaSet has been idle and within the churn budget.
+// It returns the times of the replacements within the period of the churn budget, to be recorded in the status.
+//
+// A runner is replaced by requesting its unregistration as done on scale down, and the replacement is created
+// once it is deleted. The scheduler then places the replacement in a less crowded zone according to the constraint.
+func (r *RunnerReplicaSetReconciler) rebalanceZones(ctx context.Context, log logr.Logger, now time.Time, rs v1alpha1.RunnerReplicaSet, objects []*podsForOwner, replicas int) ([]metav1.Time, error) {
+	spec := rs.Spec.ZoneRebalance
+	if spec == nil {
+		return nil, nil
+	}
+
+	period := defaultZoneRebalancePeriod
+	if spec.Period != nil && spec.Period.Duration > 0 {
+		period = spec.Period.Duration
+	}
+
+	times := pruneZoneRebalanceTimes(now, rs.Status.ZoneRebalanceTimes, period)
+
+	constraint := zoneSpreadConstraint(rs.Spec.Template.Spec)
+	if constraint == nil {
+		log.V(1).Info("Skipped zone rebalance as the runner template has no topologySpreadConstraint on zones")
+
+		return times, nil
+	}
+
+	budget := defaultZoneRebalanceMaxReplacements
+	if spec.MaxReplacements != nil {
+		budget = *spec.MaxReplacements
+	}
+
+	budget -= len(times)
+	if budget <= 0 {
+		return times, nil
+	}
+
+	idle := defaultZoneRebalanceIdle
+	if spec.IdleSeconds != nil {
+		idle = time.Duration(*spec.IdleSeconds) * time.Second
+	}
+
+	if len(objects) != replicas {
+		return times, nil
+	}
+
+	var latestCreation time.Time
+
+	for _, o := range objects {
+		// Any runner that is starting, stopping, or being replaced means the RunnerReplicaSet is not idle.
+		if o.runner == nil || o.total != 1 || o.running != 1 || len(o.pods) != 1 || !o.owner.GetDeletionTimestamp().IsZero() {
+			return times, nil
+		}
+
+		if _, ok := getAnnotation(o.owner, AnnotationKeyUnregistrationRequestTimestamp); ok {
+			return times, nil
+		}
+
+		if t := o.owner.GetCreationTimestamp().Time; t.After(latestCreation) {
+			latestCreation = t
+		}
+	}
+
+	if latestCreation.Add(idle).After(now) {
+		return times, nil
+	}
+
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList); err != nil {
+		return nil, err
+	}
+
+	nodeSelector := labels.SelectorFromSet(rs.Spec.Template.Spec.NodeSelector)
+
+	nodeZones := map[string]string{}
+	counts := map[string]int{}
+
+	for _, n := range nodeList.Items {
+		zone, ok := n.Labels[constraint.TopologyKey]
+		if !ok {
+			continue
+		}
+
+		nodeZones[n.Name] = zone
+
+		// Only the zones the runner pods can be scheduled to are the domains of the constraint.
+		if _, ok := counts[zone]; !ok && !n.Spec.Unschedulable && nodeSelector.Matches(labels.Set(n.Labels)) {
+			counts[zone] = 0
+		}
+	}
+
+	runnersByZone := map[string][]*podsForOwner{}
+
+	for _, o := range objects {
+		zone, ok := nodeZones[o.pods[0].Spec.NodeName]
+		if !ok {
+			continue
+		}
+
+		counts[zone]++
+		runnersByZone[zone] = append(runnersByZone[zone], o)
+	}
+
+	plan := planZoneRebalance(counts, int(constraint.MaxSkew), budget)
+	if len(plan) == 0 {
+		return times, nil
+	}
+
+	log.V(1).Info("Rebalancing runners across zones", "counts", counts, "plan", plan)
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rs.Namespace, rs.Spec.Template.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	enterprise, org, repo := rs.Spec.Template.Spec.Enterprise, rs.Spec.Template.Spec.Organization, rs.Spec.Template.Spec.Repository
+
+	zones := make([]string, 0, len(plan))
+	for z := range plan {
+		zones = append(zones, z)
+	}
+
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		runners := runnersByZone[zone]
+
+		// Replace the oldest runners first.
+		sort.SliceStable(runners, func(i, j int) bool {
+			return runners[i].owner.GetCreationTimestamp().Time.Before(runners[j].owner.GetCreationTimestamp().Time)
+		})
+
+		var replaced int
+
+		for _, o := range runners {
+			if replaced >= plan[zone] {
+				break
+			}
+
+			if o.protected(now) {
+				continue
+			}
+
+			name := o.owner.GetName()
+
+			busy, err := ghc.IsRunnerBusy(ctx, enterprise, org, repo, name)
+			if err != nil || busy {
+				// Only idle runners known to GitHub are moved.
+				log.V(2).Info("Skipped replacing runner for zone rebalance as it's not idle", "runner", name, "busy", busy, "error", err)
+
+				continue
+			}
+
+			pod := o.pods[0]
+			if _, err := annotatePodOnce(ctx, r.Client, log, &pod, AnnotationKeyUnregistrationRequestTimestamp, now.Format(time.RFC3339)); err != nil {
+				return nil, err
+			}
+
+			updated := o.owner.withAnnotation(AnnotationKeyUnregistrationRequestTimestamp, now.Format(time.RFC3339))
+			if err := r.Patch(ctx, updated, client.MergeFrom(o.owner)); err != nil {
+				return nil, err
+			}
+
+			r.Recorder.Eventf(&rs, corev1.EventTypeNormal, "ZoneRebalance", "Replacing runner %s in zone %s to restore the zone spread", name, zone)
+
+			log.Info("Replacing runner to restore the zone spread", "runner", name, "zone", zone)
+
+			replaced++
+			times = append(times, metav1.Time{Time: now})
+		}
+	}
+
+	return times, nil
+}
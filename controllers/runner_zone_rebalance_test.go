@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPlanZoneRebalance(t *testing.T) {
+	testcases := []struct {
+		description string
+		counts      map[string]int
+		maxSkew     int
+		budget      int
+		want        map[string]int
+	}{
+		{
+			description: "balanced",
+			counts:      map[string]int{"a": 2, "b": 2, "c": 1},
+			maxSkew:     1,
+			budget:      3,
+		},
+		{
+			description: "skewed",
+			counts:      map[string]int{"a": 5, "b": 1, "c": 0},
+			maxSkew:     1,
+			budget:      3,
+			want:        map[string]int{"a": 3},
+		},
+		{
+			description: "limited by budget",
+			counts:      map[string]int{"a": 5, "b": 1, "c": 0},
+			maxSkew:     1,
+			budget:      1,
+			want:        map[string]int{"a": 1},
+		},
+		{
+			description: "within max skew",
+			counts:      map[string]int{"a": 4, "b": 1},
+			maxSkew:     3,
+			budget:      3,
+		},
+		{
+			description: "single zone",
+			counts:      map[string]int{"a": 4},
+			maxSkew:     1,
+			budget:      3,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			got := planZoneRebalance(tc.counts, tc.maxSkew, tc.budget)
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected plan: want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRebalanceZones(t *testing.T) {
+	now := time.Now()
+
+	newRunner := func(name, node string, age time.Duration) (*v1alpha1.Runner, *corev1.Pod, *podsForOwner) {
+		runner := &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "default",
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+
+		return runner, pod, &podsForOwner{
+			total:   1,
+			running: 1,
+			runner:  runner,
+			owner:   &ownerRunner{Object: runner, Log: logr.Discard(), Runner: runner},
+			object:  runner,
+			pods:    []corev1.Pod{*pod},
+		}
+	}
+
+	newNode := func(name, zone string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{corev1.LabelTopologyZone: zone},
+			},
+		}
+	}
+
+	testcases := []struct {
+		description string
+		age         time.Duration
+		times       []metav1.Time
+		busy        []string
+		want        []string
+	}{
+		{
+			description: "oldest idle runner in the crowded zone is replaced",
+			age:         time.Hour,
+			want:        []string{"runner-1"},
+		},
+		{
+			description: "busy runners are kept",
+			age:         time.Hour,
+			busy:        []string{"runner-1"},
+			want:        []string{"runner-2"},
+		},
+		{
+			description: "recently scaled",
+			age:         time.Minute,
+		},
+		{
+			description: "churn budget exhausted",
+			age:         time.Hour,
+			times:       []metav1.Time{metav1.NewTime(now.Add(-30 * time.Minute))},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			objs := []runtime.Object{newNode("node-a", "a"), newNode("node-b", "b")}
+
+			var (
+				objects []*podsForOwner
+				runners []v1alpha1.Runner
+			)
+
+			for j, node := range []string{"node-a", "node-a", "node-a", "node-b"} {
+				runner, pod, o := newRunner(fmt.Sprintf("runner-%d", j+1), node, tc.age+time.Duration(4-j)*time.Minute)
+
+				objs = append(objs, runner, pod)
+				objects = append(objects, o)
+				runners = append(runners, *runner)
+			}
+
+			var body string
+			for _, r := range runners {
+				var busy bool
+				for _, b := range tc.busy {
+					busy = busy || b == r.Name
+				}
+				body += fmt.Sprintf(`,{"name": %q, "status": "online", "busy": %v}`, r.Name, busy)
+			}
+			body = fmt.Sprintf(`{"total_count": %d, "runners": [%s]}`, len(runners), body[1:])
+
+			server := fake.NewServer(fake.WithListRunnersResponse(200, body))
+			defer server.Close()
+
+			c := clientfake.NewFakeClientWithScheme(sc, objs...)
+
+			r := &RunnerReplicaSetReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     record.NewFakeRecorder(10),
+				GitHubClient: newGithubClient(server),
+			}
+
+			rs := v1alpha1.RunnerReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+				Spec: v1alpha1.RunnerReplicaSetSpec{
+					ZoneRebalance: &v1alpha1.ZoneRebalanceSpec{},
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{
+							RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid"},
+							RunnerPodSpec: v1alpha1.RunnerPodSpec{
+								TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+									{MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.ScheduleAnyway},
+								},
+							},
+						},
+					},
+				},
+				Status: v1alpha1.RunnerReplicaSetStatus{ZoneRebalanceTimes: tc.times},
+			}
+
+			times, err := r.rebalanceZones(context.Background(), logr.Discard(), now, rs, objects, len(objects))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var replaced []string
+
+			for _, runner := range runners {
+				var updated v1alpha1.Runner
+				if err := c.Get(context.Background(), client.ObjectKeyFromObject(&runner), &updated); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if _, ok := getAnnotation(&updated, AnnotationKeyUnregistrationRequestTimestamp); !ok {
+					continue
+				}
+
+				var pod corev1.Pod
+				if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: runner.Name}, &pod); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if _, ok := getAnnotation(&pod, AnnotationKeyUnregistrationRequestTimestamp); !ok {
+					t.Errorf("the pod of the replaced runner %s must be annotated for unregistration", runner.Name)
+				}
+
+				replaced = append(replaced, runner.Name)
+			}
+
+			if !reflect.DeepEqual(replaced, tc.want) {
+				t.Errorf("unexpected replaced runners: want %v, got %v", tc.want, replaced)
+			}
+
+			if want := len(tc.times) + len(tc.want); len(times) != want {
+				t.Errorf("unexpected number of rebalance times: want %d, got %d", want, len(times))
+			}
+		})
+	}
+}
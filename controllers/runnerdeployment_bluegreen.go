@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultBlueGreenGreenPercent       = 10
+	DefaultBlueGreenVerificationPeriod = 10 * time.Minute
+	DefaultBlueGreenProgressDeadline   = 30 * time.Minute
+
+	// blueGreenRequeueInterval is the interval between the health checks of the green runners while they are verified.
+	blueGreenRequeueInterval = 30 * time.Second
+)
+
+// reconcileBlueGreen rolls out the template change of the runnerdeployment by switching from the blue runnerreplicaset,
+// which runs the runners of the last verified template, to the green one of the new template.
+// It returns false when there's nothing to switch, in which case the runnerreplicasets are reconciled as usual.
+//
+// sets must be sorted from the newest to the oldest.
+func (r *RunnerDeploymentReconciler) reconcileBlueGreen(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, sets []v1alpha1.RunnerReplicaSet, desiredRS *v1alpha1.RunnerReplicaSet, now time.Time) (ctrl.Result, bool, error) {
+	desiredHash, _ := getTemplateHash(desiredRS)
+
+	status := rd.Status.BlueGreen
+
+	// The blue template is the one whose runners are serving the jobs, which is the template of the newest runnerreplicaset
+	// unless a rollout is being verified or has been aborted.
+	blueHash, _ := getTemplateHash(&sets[0])
+	if status != nil && status.Phase != v1alpha1.BlueGreenPhasePromoted {
+		blueHash = status.BlueTemplateHash
+	}
+
+	var blue, green *v1alpha1.RunnerReplicaSet
+
+	for i := range sets {
+		switch hash, _ := getTemplateHash(&sets[i]); hash {
+		case blueHash:
+			if blue == nil {
+				blue = &sets[i]
+			}
+		case desiredHash:
+			if green == nil {
+				green = &sets[i]
+			}
+		}
+	}
+
+	// There are no blue runners to keep serving the jobs, e.g. because the blue runnerreplicaset was deleted manually.
+	if blue == nil {
+		return ctrl.Result{}, false, nil
+	}
+
+	rejected := status != nil && status.Phase == v1alpha1.BlueGreenPhaseAborted && status.GreenTemplateHash == desiredHash
+
+	if desiredHash == blueHash || rejected {
+		var next *v1alpha1.RunnerDeploymentBlueGreenStatus
+		if status != nil {
+			next = status.DeepCopy()
+		}
+
+		if status != nil && status.Phase == v1alpha1.BlueGreenPhaseVerifying {
+			next.Phase = v1alpha1.BlueGreenPhaseAborted
+			next.CompletionTime = &metav1.Time{Time: now}
+			next.Message = "The template was reverted to the blue one"
+
+			r.Recorder.Event(&rd, corev1.EventTypeNormal, "BlueGreenAborted", next.Message)
+		} else if !rejected && (status == nil || status.Phase == v1alpha1.BlueGreenPhasePromoted || len(sets) == 1) {
+			return ctrl.Result{}, false, nil
+		}
+
+		return r.keepBlueRunnerReplicaSet(ctx, log, rd, sets, blue, desiredRS, next)
+	}
+
+	spec := rd.Spec.BlueGreen
+
+	next := status.DeepCopy()
+	if status == nil || status.Phase != v1alpha1.BlueGreenPhaseVerifying || status.BlueTemplateHash != blueHash || status.GreenTemplateHash != desiredHash {
+		next = &v1alpha1.RunnerDeploymentBlueGreenStatus{
+			Phase:             v1alpha1.BlueGreenPhaseVerifying,
+			BlueTemplateHash:  blueHash,
+			GreenTemplateHash: desiredHash,
+			StartTime:         metav1.Time{Time: now},
+			Message:           "Waiting for the green runners to become available",
+		}
+	}
+
+	desiredReplicas := getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas)
+	greenReplicas := blueGreenGreenReplicas(desiredReplicas, spec)
+	blueReplicas := desiredReplicas - greenReplicas
+	if blueReplicas < 0 {
+		blueReplicas = 0
+	}
+
+	if green == nil {
+		green = newGreenRunnerReplicaSet(desiredRS, spec, greenReplicas)
+
+		if err := r.Client.Create(ctx, green); err != nil {
+			log.Error(err, "Failed to create green runnerreplicaset resource")
+
+			return ctrl.Result{}, true, err
+		}
+
+		log.Info("Created green runnerreplicaset", "runnerreplicaset", green.Name, "replicas", greenReplicas)
+
+		r.Recorder.Event(&rd, corev1.EventTypeNormal, "BlueGreenStarted", fmt.Sprintf("Created green runnerreplicaset '%s' with %d of %d replicas", green.Name, greenReplicas, desiredReplicas))
+
+		sets = append(sets, *green)
+	}
+
+	var others []v1alpha1.RunnerReplicaSet
+	for i := range sets {
+		if sets[i].Name != blue.Name && sets[i].Name != green.Name {
+			others = append(others, sets[i])
+		}
+	}
+
+	if err := r.cleanupRunnerReplicaSets(ctx, log, rd, others); err != nil {
+		return ctrl.Result{}, true, err
+	}
+
+	healthy := green.Spec.Replicas != nil && *green.Spec.Replicas == greenReplicas &&
+		green.Status.AvailableReplicas != nil && *green.Status.AvailableReplicas >= greenReplicas
+
+	if !healthy {
+		next.HealthySince = nil
+	} else if next.HealthySince == nil {
+		next.HealthySince = &metav1.Time{Time: now}
+		next.Message = "Verifying the green runners"
+	}
+
+	verificationPeriod := DefaultBlueGreenVerificationPeriod
+	if spec.VerificationPeriod != nil {
+		verificationPeriod = spec.VerificationPeriod.Duration
+	}
+
+	progressDeadline := DefaultBlueGreenProgressDeadline
+	if spec.ProgressDeadline != nil {
+		progressDeadline = spec.ProgressDeadline.Duration
+	}
+
+	switch {
+	case healthy && !now.Before(next.HealthySince.Add(verificationPeriod)):
+		// Shift all the replicas to the green runners first, so that the jobs keep being served while the blue runners are drained.
+		updated := green.DeepCopy()
+		updateRunnerReplicaSetScaling(updated, desiredRS)
+		updated.Spec.Template = *desiredRS.Spec.Template.DeepCopy()
+
+		if err := r.Client.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to promote green runnerreplicaset")
+
+			return ctrl.Result{}, true, err
+		}
+
+		if err := r.cleanupRunnerReplicaSets(ctx, log, rd, []v1alpha1.RunnerReplicaSet{*blue}); err != nil {
+			return ctrl.Result{}, true, err
+		}
+
+		next.Phase = v1alpha1.BlueGreenPhasePromoted
+		next.CompletionTime = &metav1.Time{Time: now}
+		next.Message = fmt.Sprintf("Shifted all the replicas to the green runnerreplicaset '%s' and drained the blue one", green.Name)
+
+		log.Info("Promoted green runnerreplicaset", "runnerreplicaset", green.Name)
+
+		r.Recorder.Event(&rd, corev1.EventTypeNormal, "BlueGreenPromoted", next.Message)
+
+		return r.patchBlueGreenStatus(ctx, log, rd, updated, sets, desiredReplicas, next, ctrl.Result{RequeueAfter: 5 * time.Second})
+	case now.After(next.StartTime.Add(progressDeadline)):
+		updated := blue.DeepCopy()
+		if updateRunnerReplicaSetScaling(updated, desiredRS) {
+			if err := r.Client.Update(ctx, updated); err != nil {
+				log.Error(err, "Failed to update blue runnerreplicaset resource")
+
+				return ctrl.Result{}, true, err
+			}
+		}
+
+		if err := r.cleanupRunnerReplicaSets(ctx, log, rd, []v1alpha1.RunnerReplicaSet{*green}); err != nil {
+			return ctrl.Result{}, true, err
+		}
+
+		next.Phase = v1alpha1.BlueGreenPhaseAborted
+		next.CompletionTime = &metav1.Time{Time: now}
+		next.Message = fmt.Sprintf("The green runners were not verified within %s. Keeping the blue runners until the template is changed", progressDeadline)
+
+		log.Info("Aborted blue/green rollout", "runnerreplicaset", green.Name)
+
+		r.Recorder.Event(&rd, corev1.EventTypeWarning, "BlueGreenAborted", next.Message)
+
+		return r.patchBlueGreenStatus(ctx, log, rd, updated, sets, desiredReplicas, next, ctrl.Result{RequeueAfter: 5 * time.Second})
+	}
+
+	blueDesired := desiredRS.DeepCopy()
+	blueDesired.Spec.Replicas = &blueReplicas
+
+	updated := blue.DeepCopy()
+	if updateRunnerReplicaSetScaling(updated, blueDesired) {
+		if err := r.Client.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update blue runnerreplicaset resource")
+
+			return ctrl.Result{}, true, err
+		}
+	}
+
+	if green.Spec.Replicas == nil || *green.Spec.Replicas != greenReplicas {
+		updated := green.DeepCopy()
+		updated.Spec.Replicas = &greenReplicas
+		updated.Spec.EffectiveTime = desiredRS.Spec.EffectiveTime
+
+		if err := r.Client.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update green runnerreplicaset resource")
+
+			return ctrl.Result{}, true, err
+		}
+	}
+
+	return r.patchBlueGreenStatus(ctx, log, rd, green, sets, desiredReplicas, next, ctrl.Result{RequeueAfter: blueGreenRequeueInterval})
+}
+
+// keepBlueRunnerReplicaSet scales the blue runnerreplicaset to the desired replicas and drains the others,
+// after the blue/green rollout is aborted or the template is reverted to the blue one.
+func (r *RunnerDeploymentReconciler) keepBlueRunnerReplicaSet(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, sets []v1alpha1.RunnerReplicaSet, blue, desiredRS *v1alpha1.RunnerReplicaSet, next *v1alpha1.RunnerDeploymentBlueGreenStatus) (ctrl.Result, bool, error) {
+	updated := blue.DeepCopy()
+	if updateRunnerReplicaSetScaling(updated, desiredRS) {
+		if err := r.Client.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update blue runnerreplicaset resource")
+
+			return ctrl.Result{}, true, err
+		}
+	}
+
+	var others []v1alpha1.RunnerReplicaSet
+	for i := range sets {
+		if sets[i].Name != blue.Name {
+			others = append(others, sets[i])
+		}
+	}
+
+	if err := r.cleanupRunnerReplicaSets(ctx, log, rd, others); err != nil {
+		return ctrl.Result{}, true, err
+	}
+
+	var res ctrl.Result
+	if len(others) > 0 {
+		res.RequeueAfter = 5 * time.Second
+	}
+
+	return r.patchBlueGreenStatus(ctx, log, rd, updated, sets, getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas), next, res)
+}
+
+func (r *RunnerDeploymentReconciler) patchBlueGreenStatus(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, updatedSet *v1alpha1.RunnerReplicaSet, sets []v1alpha1.RunnerReplicaSet, desiredReplicas int, blueGreen *v1alpha1.RunnerDeploymentBlueGreenStatus, res ctrl.Result) (ctrl.Result, bool, error) {
+	status := newRunnerDeploymentStatus(rd, updatedSet, sets, desiredReplicas)
+	status.BlueGreen = blueGreen
+
+	if !reflect.DeepEqual(rd.Status, status) {
+		updated := rd.DeepCopy()
+		updated.Status = status
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			log.Info("Failed to patch runnerdeployment status. Retrying immediately", "error", err.Error())
+			return ctrl.Result{
+				Requeue: true,
+			}, true, nil
+		}
+	}
+
+	return res, true, nil
+}
+
+// blueGreenGreenReplicas returns the replicas given to the green runners while they are verified,
+// which is greenPercent of the desired replicas rounded up, and at least one so that they can be verified.
+func blueGreenGreenReplicas(desiredReplicas int, spec *v1alpha1.RunnerDeploymentBlueGreen) int {
+	percent := DefaultBlueGreenGreenPercent
+	if spec.GreenPercent != nil {
+		percent = *spec.GreenPercent
+	}
+
+	replicas := int(math.Ceil(float64(desiredReplicas) * float64(percent) / 100))
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	return replicas
+}
+
+// newGreenRunnerReplicaSet returns the green runnerreplicaset for the desired one, whose runners are registered
+// with the labels and to the group the jobs are steered to the green runners with.
+// Only the replicas are set, as the warm, burst and size replicas are all served by the blue runners until promotion.
+func newGreenRunnerReplicaSet(desiredRS *v1alpha1.RunnerReplicaSet, spec *v1alpha1.RunnerDeploymentBlueGreen, replicas int) *v1alpha1.RunnerReplicaSet {
+	green := desiredRS.DeepCopy()
+
+	green.Spec.Replicas = &replicas
+	green.Spec.WarmReplicas = nil
+	green.Spec.BurstReplicas = nil
+	green.Spec.SizeReplicas = nil
+
+	green.Spec.Template.Spec.Labels = append(green.Spec.Template.Spec.Labels, spec.Labels...)
+	if spec.Group != "" {
+		green.Spec.Template.Spec.Group = spec.Group
+	}
+
+	return green
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBlueGreenGreenReplicas(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	testcases := []struct {
+		desired int
+		percent *int
+		want    int
+	}{
+		{desired: 20, want: 2},
+		{desired: 20, percent: intPtr(25), want: 5},
+		{desired: 3, percent: intPtr(50), want: 2},
+		{desired: 0, want: 1},
+		{desired: 4, percent: intPtr(100), want: 4},
+	}
+
+	for _, tc := range testcases {
+		if got := blueGreenGreenReplicas(tc.desired, &v1alpha1.RunnerDeploymentBlueGreen{GreenPercent: tc.percent}); got != tc.want {
+			t.Errorf("blueGreenGreenReplicas(%d, %v): want %d, got %d", tc.desired, tc.percent, tc.want, got)
+		}
+	}
+}
+
+func TestReconcileBlueGreen(t *testing.T) {
+	ctx := context.Background()
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	start := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	newRunnerDeployment := func(image string) *v1alpha1.RunnerDeployment {
+		return &v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example", UID: "rd"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Replicas: intPtr(8),
+				BlueGreen: &v1alpha1.RunnerDeploymentBlueGreen{
+					GreenPercent:       intPtr(25),
+					Labels:             []string{"green"},
+					Group:              "canary",
+					VerificationPeriod: &metav1.Duration{Duration: 10 * time.Minute},
+					ProgressDeadline:   &metav1.Duration{Duration: 30 * time.Minute},
+				},
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{
+						RunnerConfig: v1alpha1.RunnerConfig{
+							Repository: "test/valid",
+							Image:      image,
+							Labels:     []string{"linux"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// setup returns the reconciler for the runnerdeployment being rolled out from the blue template to the green one,
+	// with the blue runnerreplicaset serving all the replicas.
+	setup := func(t *testing.T) (*RunnerDeploymentReconciler, client.Client, string) {
+		t.Helper()
+
+		blue, err := newRunnerReplicaSet(newRunnerDeployment("runner:v1"), nil, sc)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		blue.Name = "example-blue"
+		blue.Status.Replicas = intPtr(8)
+		blue.Status.AvailableReplicas = intPtr(8)
+
+		c := fake.NewFakeClientWithScheme(sc, newRunnerDeployment("runner:v2"), blue)
+
+		r := &RunnerDeploymentReconciler{
+			Client:   c,
+			Log:      logr.Discard(),
+			Recorder: record.NewFakeRecorder(10),
+			Scheme:   sc,
+		}
+
+		blueHash, _ := getTemplateHash(blue)
+
+		return r, c, blueHash
+	}
+
+	// reconcile reconciles the runnerdeployment at the time, with the runnerreplicasets sorted from the newest to the oldest.
+	reconcile := func(t *testing.T, r *RunnerDeploymentReconciler, c client.Client, blueHash string, now time.Time) bool {
+		t.Helper()
+
+		var rd v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &rd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var list v1alpha1.RunnerReplicaSetList
+		if err := c.List(ctx, &list); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sets := list.Items
+		sort.SliceStable(sets, func(i, j int) bool {
+			hash, _ := getTemplateHash(&sets[j])
+			return hash == blueHash
+		})
+
+		desiredRS, err := r.newRunnerReplicaSet(rd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, handled, err := r.reconcileBlueGreen(ctx, logr.Discard(), rd, sets, desiredRS, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return handled
+	}
+
+	// get returns the status of the rollout and the blue and the green runnerreplicasets.
+	get := func(t *testing.T, c client.Client, blueHash string) (*v1alpha1.RunnerDeploymentBlueGreenStatus, *v1alpha1.RunnerReplicaSet, *v1alpha1.RunnerReplicaSet) {
+		t.Helper()
+
+		var rd v1alpha1.RunnerDeployment
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example"}, &rd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var list v1alpha1.RunnerReplicaSetList
+		if err := c.List(ctx, &list); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var blue, green *v1alpha1.RunnerReplicaSet
+		for i := range list.Items {
+			if hash, _ := getTemplateHash(&list.Items[i]); hash == blueHash {
+				blue = &list.Items[i]
+			} else {
+				green = &list.Items[i]
+			}
+		}
+
+		return rd.Status.BlueGreen, blue, green
+	}
+
+	setAvailable := func(t *testing.T, c client.Client, rs *v1alpha1.RunnerReplicaSet, available int) {
+		t.Helper()
+
+		rs.Status.Replicas = &available
+		rs.Status.AvailableReplicas = &available
+
+		if err := c.Update(ctx, rs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Run("promoted", func(t *testing.T) {
+		r, c, blueHash := setup(t)
+
+		if !reconcile(t, r, c, blueHash, start) {
+			t.Fatalf("the template change is not rolled out by blue/green")
+		}
+
+		status, blue, green := get(t, c, blueHash)
+		if status == nil || status.Phase != v1alpha1.BlueGreenPhaseVerifying || status.BlueTemplateHash != blueHash {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		if green == nil {
+			t.Fatalf("green runnerreplicaset is not created")
+		}
+
+		if *green.Spec.Replicas != 2 || *blue.Spec.Replicas != 6 {
+			t.Errorf("unexpected replicas: want green=2 and blue=6, got green=%d and blue=%d", *green.Spec.Replicas, *blue.Spec.Replicas)
+		}
+
+		if want := []string{"linux", "green"}; !reflect.DeepEqual(green.Spec.Template.Spec.Labels, want) || green.Spec.Template.Spec.Group != "canary" {
+			t.Errorf("unexpected green runners: want labels %v in group canary, got %v in group %q", want, green.Spec.Template.Spec.Labels, green.Spec.Template.Spec.Group)
+		}
+
+		setAvailable(t, c, green, 2)
+		reconcile(t, r, c, blueHash, start.Add(time.Minute))
+
+		status, _, _ = get(t, c, blueHash)
+		if status.Phase != v1alpha1.BlueGreenPhaseVerifying || status.HealthySince == nil || !status.HealthySince.Time.Equal(start.Add(time.Minute)) {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		reconcile(t, r, c, blueHash, start.Add(11*time.Minute))
+
+		status, blue, green = get(t, c, blueHash)
+		if status.Phase != v1alpha1.BlueGreenPhasePromoted {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		if *green.Spec.Replicas != 8 || *blue.Spec.Replicas != 0 {
+			t.Errorf("unexpected replicas: want green=8 and blue=0, got green=%d and blue=%d", *green.Spec.Replicas, *blue.Spec.Replicas)
+		}
+
+		if want := []string{"linux"}; !reflect.DeepEqual(green.Spec.Template.Spec.Labels, want) || green.Spec.Template.Spec.Group != "" {
+			t.Errorf("unexpected green runners after promotion: want labels %v in the default group, got %v in group %q", want, green.Spec.Template.Spec.Labels, green.Spec.Template.Spec.Group)
+		}
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		r, c, blueHash := setup(t)
+
+		reconcile(t, r, c, blueHash, start)
+
+		_, _, green := get(t, c, blueHash)
+		setAvailable(t, c, green, 1)
+
+		reconcile(t, r, c, blueHash, start.Add(31*time.Minute))
+
+		status, blue, green := get(t, c, blueHash)
+		if status.Phase != v1alpha1.BlueGreenPhaseAborted {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		if *green.Spec.Replicas != 0 || *blue.Spec.Replicas != 8 {
+			t.Errorf("unexpected replicas: want green=0 and blue=8, got green=%d and blue=%d", *green.Spec.Replicas, *blue.Spec.Replicas)
+		}
+
+		// The rejected template is never rolled out again
+		setAvailable(t, c, green, 0)
+
+		if !reconcile(t, r, c, blueHash, start.Add(32*time.Minute)) {
+			t.Fatalf("the rejected template is rolled out")
+		}
+
+		status, blue, green = get(t, c, blueHash)
+		if status.Phase != v1alpha1.BlueGreenPhaseAborted || green != nil || *blue.Spec.Replicas != 8 {
+			t.Errorf("unexpected state after abort: status=%+v, green=%v, blue=%d", status, green, *blue.Spec.Replicas)
+		}
+	})
+}
@@ -33,12 +33,16 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
 )
 
 const (
@@ -56,6 +60,22 @@ type RunnerDeploymentReconciler struct {
 	Scheme             *runtime.Scheme
 	CommonRunnerLabels []string
 	Name               string
+
+	// GitHubClient is used to cancel pending jobs on teardown of runnerdeployments
+	// that have the teardown policy, and to check the accessibility of the repositories of repository runners.
+	GitHubClient *github.Client
+
+	// GitHubClients holds the GitHub clients for the runnerdeployments that set githubAPICredentialsFrom.
+	GitHubClients *MultiGitHubClient
+
+	// RepositoryAccessCheckInterval is the minimum interval between the checks of the accessibility of a repository.
+	// Defaults to DefaultRepositoryAccessCheckInterval.
+	RepositoryAccessCheckInterval time.Duration
+
+	repositoryAccess repositoryAccessCache
+
+	// ErrorBudget stops retrying the runnerdeployments whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments,verbs=get;list;watch;create;update;patch;delete
@@ -63,6 +83,7 @@ type RunnerDeploymentReconciler struct {
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerdeployments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerreplicasets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnerreplicasets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnergroups,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -74,11 +95,42 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	if !rd.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.processTeardown(ctx, log, rd)
+	}
+
+	if updated, err := r.syncTeardownFinalizer(ctx, rd); err != nil {
+		log.Error(err, "Failed to update teardown finalizer")
+
+		return ctrl.Result{}, err
+	} else if updated {
 		return ctrl.Result{}, nil
 	}
 
 	metrics.SetRunnerDeployment(rd)
 
+	repositoryInaccessible, err := r.syncRepositoryAccess(ctx, log, rd)
+	if err != nil {
+		log.Error(err, "Failed to update the repository accessibility condition")
+
+		return ctrl.Result{}, err
+	}
+
+	runnerGroup, runnerGroupNotReady, err := r.syncRunnerGroupRef(ctx, log, rd)
+	if err != nil {
+		log.Error(err, "Failed to update the runner group readiness condition")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncPodDisruptionBudget(ctx, log, rd); err != nil {
+		log.Error(err, "Failed to sync the pod disruption budget of the busy runners")
+
+		return ctrl.Result{}, err
+	}
+
+	// The runners of a runner deployment with spec.runnerGroupRef are registered to the runner group resolved from the RunnerGroup.
+	rd.Spec.Template.Spec.Group = runnerGroup
+
 	var myRunnerReplicaSetList v1alpha1.RunnerReplicaSetList
 	if err := r.List(ctx, &myRunnerReplicaSetList, client.InNamespace(req.Namespace), client.MatchingFields{runnerSetOwnerKey: req.Name}); err != nil {
 		return ctrl.Result{}, err
@@ -111,6 +163,32 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	// The replicas of the active window of the schedule take precedence over spec.replicas.
+	// An invalid schedule is ignored, so that it doesn't prevent the runner deployment from being reconciled.
+	activeSchedule, scheduleBoundary, err := matchScheduledReplicas(time.Now(), rd.Spec.Schedule)
+	if err != nil {
+		r.Recorder.Event(&rd, corev1.EventTypeWarning, "InvalidSchedule", err.Error())
+
+		log.Error(err, "Ignoring the invalid schedule")
+	} else if activeSchedule != nil {
+		log.V(1).Info("Using the replicas of the active schedule", "index", activeSchedule.Index, "replicas", activeSchedule.Replicas, "endTime", activeSchedule.EndTime)
+
+		replicas := activeSchedule.Replicas
+		desiredRS.Spec.Replicas = &replicas
+	}
+
+	if repositoryInaccessible || runnerGroupNotReady || poolDrained(&rd) {
+		// Runners can't be registered to a dead repository. Scale to zero instead of letting the runners crash-loop,
+		// until the repository becomes accessible again.
+		// The same applies to a runner group that isn't synced to GitHub yet.
+		// A drained runner deployment is scaled to zero in the same way, until it's undrained.
+		zero := 0
+		desiredRS.Spec.Replicas = &zero
+		desiredRS.Spec.WarmReplicas = nil
+		desiredRS.Spec.BurstReplicas = nil
+		desiredRS.Spec.SizeReplicas = nil
+	}
+
 	if newestSet == nil {
 		if err := r.Client.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
@@ -137,7 +215,20 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, nil
 	}
 
+	if rd.Spec.BlueGreen != nil {
+		res, handled, err := r.reconcileBlueGreen(ctx, log, rd, myRunnerReplicaSets, desiredRS, time.Now())
+		if handled || err != nil {
+			return res, err
+		}
+	}
+
 	if newestTemplateHash != desiredTemplateHash {
+		if rd.Spec.Strategy != nil {
+			// All the existing runnerreplicasets are old ones to be replaced by the new one.
+			replicas := rolloutNewReplicas(rd.Spec.Strategy, 0, myRunnerReplicaSets, getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas))
+			desiredRS.Spec.Replicas = &replicas
+		}
+
 		if err := r.Client.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
 
@@ -173,27 +264,31 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
-	const defaultReplicas = 1
-
 	currentDesiredReplicas := getIntOrDefault(newestSet.Spec.Replicas, defaultReplicas)
 	newDesiredReplicas := getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas)
 
-	// Please add more conditions that we can in-place update the newest runnerreplicaset without disruption
-	if currentDesiredReplicas != newDesiredReplicas {
-		newestSet.Spec.Replicas = &newDesiredReplicas
-		newestSet.Spec.EffectiveTime = rd.Spec.EffectiveTime
+	if rd.Spec.Strategy != nil && len(oldSets) > 0 {
+		// The newest runnerreplicaset is scaled up as the runners of the old ones are removed.
+		replicas := rolloutNewReplicas(rd.Spec.Strategy, currentDesiredReplicas, oldSets, newDesiredReplicas)
+		desiredRS.Spec.Replicas = &replicas
+	}
 
+	if updateRunnerReplicaSetScaling(newestSet, desiredRS) {
 		if err := r.Client.Update(ctx, newestSet); err != nil {
 			log.Error(err, "Failed to update runnerreplicaset resource")
 
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, err
+		return ctrl.Result{RequeueAfter: scheduleBoundary}, err
 	}
 
 	// Do we have old runner replica sets that should eventually deleted?
-	if len(oldSets) > 0 {
+	if len(oldSets) > 0 && rd.Spec.Strategy != nil {
+		if err := r.rolloutOldRunnerReplicaSets(ctx, log, rd, newestSet, oldSets, newDesiredReplicas); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if len(oldSets) > 0 {
 		var readyReplicas int
 		if newestSet.Status.ReadyReplicas != nil {
 			readyReplicas = *newestSet.Status.ReadyReplicas
@@ -223,50 +318,118 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 				Info("The newest runnerreplicaset is 100% available. Deleting old runnerreplicasets")
 		}
 
-		for i := range oldSets {
-			rs := oldSets[i]
+		if err := r.cleanupRunnerReplicaSets(ctx, log, rd, oldSets); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var replicaSets []v1alpha1.RunnerReplicaSet
+
+	replicaSets = append(replicaSets, *newestSet)
+	replicaSets = append(replicaSets, oldSets...)
+
+	status := newRunnerDeploymentStatus(rd, newestSet, replicaSets, newDesiredReplicas)
+	status.ActiveSchedule = activeSchedule
+	if rd.Spec.RunnerGroupRef != nil {
+		status.RunnerGroup = runnerGroup
+	}
+
+	if !reflect.DeepEqual(rd.Status, status) {
+		updated := rd.DeepCopy()
+		updated.Status = status
+
+		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+			log.Info("Failed to patch runnerdeployment status. Retrying immediately", "error", err.Error())
+			return ctrl.Result{
+				Requeue: true,
+			}, nil
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: scheduleBoundary}, nil
+}
+
+// updateRunnerReplicaSetScaling updates the replicas and the other fields of the runnerreplicaset that can be updated in-place
+// without replacing the runners to the desired ones, and returns true if anything was changed.
+func updateRunnerReplicaSetScaling(rs, desired *v1alpha1.RunnerReplicaSet) bool {
+	currentDesiredReplicas := getIntOrDefault(rs.Spec.Replicas, defaultReplicas)
+	newDesiredReplicas := getIntOrDefault(desired.Spec.Replicas, defaultReplicas)
+
+	currentWarmReplicas := getIntOrDefault(rs.Spec.WarmReplicas, 0)
+	newWarmReplicas := getIntOrDefault(desired.Spec.WarmReplicas, 0)
 
-			rslog := log.WithValues("runnerreplicaset", rs.Name)
+	currentBurstReplicas := getIntOrDefault(rs.Spec.BurstReplicas, 0)
+	newBurstReplicas := getIntOrDefault(desired.Spec.BurstReplicas, 0)
 
-			if rs.Status.Replicas != nil && *rs.Status.Replicas > 0 {
-				if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 {
-					rslog.V(2).Info("Waiting for runnerreplicaset to scale to zero")
+	// Please add more conditions that we can in-place update the newest runnerreplicaset without disruption
+	if currentDesiredReplicas == newDesiredReplicas && currentWarmReplicas == newWarmReplicas &&
+		currentBurstReplicas == newBurstReplicas && rs.Spec.BurstPriorityClassName == desired.Spec.BurstPriorityClassName &&
+		reflect.DeepEqual(rs.Spec.Sizes, desired.Spec.Sizes) && sameSizeReplicas(rs.Spec.SizeReplicas, desired.Spec.SizeReplicas) &&
+		reflect.DeepEqual(rs.Spec.ZoneRebalance, desired.Spec.ZoneRebalance) &&
+		reflect.DeepEqual(rs.Spec.WarmRunnerAffinity, desired.Spec.WarmRunnerAffinity) &&
+		rs.Spec.ScaleDownPolicy == desired.Spec.ScaleDownPolicy {
+		return false
+	}
 
-					continue
-				}
+	rs.Spec.Replicas = &newDesiredReplicas
+	rs.Spec.WarmReplicas = desired.Spec.WarmReplicas
+	rs.Spec.BurstPriorityClassName = desired.Spec.BurstPriorityClassName
+	rs.Spec.BurstReplicas = desired.Spec.BurstReplicas
+	rs.Spec.Sizes = desired.Spec.Sizes
+	rs.Spec.SizeReplicas = desired.Spec.SizeReplicas
+	rs.Spec.ZoneRebalance = desired.Spec.ZoneRebalance
+	rs.Spec.WarmRunnerAffinity = desired.Spec.WarmRunnerAffinity
+	rs.Spec.ScaleDownPolicy = desired.Spec.ScaleDownPolicy
+	rs.Spec.EffectiveTime = desired.Spec.EffectiveTime
+
+	return true
+}
 
-				updated := rs.DeepCopy()
-				zero := 0
-				updated.Spec.Replicas = &zero
-				if err := r.Client.Update(ctx, updated); err != nil {
-					rslog.Error(err, "Failed to scale runnerreplicaset to zero")
+// cleanupRunnerReplicaSets scales the runnerreplicasets to zero, and deletes the ones that have no runner left.
+func (r *RunnerDeploymentReconciler) cleanupRunnerReplicaSets(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, sets []v1alpha1.RunnerReplicaSet) error {
+	for i := range sets {
+		rs := sets[i]
 
-					return ctrl.Result{}, err
-				}
+		rslog := log.WithValues("runnerreplicaset", rs.Name)
 
-				rslog.Info("Scaled runnerreplicaset to zero")
+		if rs.Status.Replicas != nil && *rs.Status.Replicas > 0 {
+			if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 {
+				rslog.V(2).Info("Waiting for runnerreplicaset to scale to zero")
 
 				continue
 			}
 
-			if err := r.Client.Delete(ctx, &rs); err != nil {
-				rslog.Error(err, "Failed to delete runnerreplicaset resource")
+			updated := rs.DeepCopy()
+			zero := 0
+			updated.Spec.Replicas = &zero
+			if err := r.Client.Update(ctx, updated); err != nil {
+				rslog.Error(err, "Failed to scale runnerreplicaset to zero")
 
-				return ctrl.Result{}, err
+				return err
 			}
 
-			r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerReplicaSetDeleted", fmt.Sprintf("Deleted runnerreplicaset '%s'", rs.Name))
+			rslog.Info("Scaled runnerreplicaset to zero")
 
-			rslog.Info("Deleted runnerreplicaset")
+			continue
 		}
-	}
 
-	var replicaSets []v1alpha1.RunnerReplicaSet
+		if err := r.Client.Delete(ctx, &rs); err != nil {
+			rslog.Error(err, "Failed to delete runnerreplicaset resource")
 
-	replicaSets = append(replicaSets, *newestSet)
-	replicaSets = append(replicaSets, oldSets...)
+			return err
+		}
+
+		r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerReplicaSetDeleted", fmt.Sprintf("Deleted runnerreplicaset '%s'", rs.Name))
 
-	var totalCurrentReplicas, totalStatusAvailableReplicas, updatedReplicas int
+		rslog.Info("Deleted runnerreplicaset")
+	}
+
+	return nil
+}
+
+// newRunnerDeploymentStatus returns the status of the runnerdeployment, whose runners of the updated template are managed by updatedSet.
+func newRunnerDeploymentStatus(rd v1alpha1.RunnerDeployment, updatedSet *v1alpha1.RunnerReplicaSet, replicaSets []v1alpha1.RunnerReplicaSet, desiredReplicas int) v1alpha1.RunnerDeploymentStatus {
+	var totalCurrentReplicas, totalStatusAvailableReplicas, updatedReplicas, updatedReadyReplicas int
 
 	for _, rs := range replicaSets {
 		var current, available int
@@ -283,31 +446,31 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		totalStatusAvailableReplicas += available
 	}
 
-	if newestSet.Status.Replicas != nil {
-		updatedReplicas = *newestSet.Status.Replicas
+	if updatedSet.Status.Replicas != nil {
+		updatedReplicas = *updatedSet.Status.Replicas
+	}
+
+	if updatedSet.Status.AvailableReplicas != nil {
+		updatedReadyReplicas = *updatedSet.Status.AvailableReplicas
 	}
 
 	var status v1alpha1.RunnerDeploymentStatus
 
 	status.AvailableReplicas = &totalStatusAvailableReplicas
 	status.ReadyReplicas = &totalStatusAvailableReplicas
-	status.DesiredReplicas = &newDesiredReplicas
+	status.DesiredReplicas = &desiredReplicas
 	status.Replicas = &totalCurrentReplicas
 	status.UpdatedReplicas = &updatedReplicas
-
-	if !reflect.DeepEqual(rd.Status, status) {
-		updated := rd.DeepCopy()
-		updated.Status = status
-
-		if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
-			log.Info("Failed to patch runnerdeployment status. Retrying immediately", "error", err.Error())
-			return ctrl.Result{
-				Requeue: true,
-			}, nil
-		}
+	status.UpdatedReadyReplicas = &updatedReadyReplicas
+	if selector, err := metav1.LabelSelectorAsSelector(getSelector(&rd)); err == nil {
+		status.Selector = selector.String()
 	}
+	// Conditions and Utilization are maintained by other components like the canary prober and the runner utilization sampler.
+	status.Conditions = rd.Status.Conditions
+	status.Utilization = rd.Status.Utilization
+	status.BlueGreen = rd.Status.BlueGreen
 
-	return ctrl.Result{}, nil
+	return status
 }
 
 func getIntOrDefault(p *int, d int) int {
@@ -445,10 +608,18 @@ func newRunnerReplicaSet(rd *v1alpha1.RunnerDeployment, commonRunnerLabels []str
 			Labels:       newRSTemplate.ObjectMeta.Labels,
 		},
 		Spec: v1alpha1.RunnerReplicaSetSpec{
-			Replicas:      rd.Spec.Replicas,
-			Selector:      newRSSelector,
-			Template:      newRSTemplate,
-			EffectiveTime: rd.Spec.EffectiveTime,
+			Replicas:               rd.Spec.Replicas,
+			WarmReplicas:           rd.Spec.WarmReplicas,
+			BurstPriorityClassName: rd.Spec.BurstPriorityClassName,
+			BurstReplicas:          rd.Spec.BurstReplicas,
+			Sizes:                  rd.Spec.Sizes,
+			SizeReplicas:           rd.Spec.SizeReplicas,
+			ZoneRebalance:          rd.Spec.ZoneRebalance,
+			WarmRunnerAffinity:     rd.Spec.WarmRunnerAffinity,
+			ScaleDownPolicy:        rd.Spec.ScaleDownPolicy,
+			Selector:               newRSSelector,
+			Template:               newRSTemplate,
+			EffectiveTime:          rd.Spec.EffectiveTime,
 		},
 	}
 
@@ -486,6 +657,8 @@ func (r *RunnerDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.RunnerDeployment{}).
 		Owns(&v1alpha1.RunnerReplicaSet{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&source.Kind{Type: &v1alpha1.RunnerGroup{}}, handler.EnqueueRequestsFromMapFunc(r.runnerDeploymentsForRunnerGroup)).
 		Named(name).
-		Complete(r)
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.RunnerDeployment{} }))
 }
@@ -0,0 +1,437 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/pmezard/go-difflib/difflib"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultPreviewRunnerStartupTime is the time a new runner is assumed to take to become ready in rollout previews.
+	DefaultPreviewRunnerStartupTime = 2 * time.Minute
+	// DefaultPreviewJobDuration is the time a busy runner is assumed to take to complete its job in rollout previews.
+	DefaultPreviewJobDuration = 10 * time.Minute
+
+	// maxPreviewBodySize is the maximum size of a RunnerDeployment manifest accepted by the previewer.
+	maxPreviewBodySize = 1 << 20
+)
+
+// RunnerDeploymentPreviewer serves dry-runs of RunnerDeployment changes.
+// Given a modified RunnerDeployment in the request body as YAML or JSON, it renders the runner pod the controller would create
+// for it, diffs the pod against the one rendered for the current RunnerDeployment, and shows the rollout plan,
+// so that you can review the impact of the change before applying it.
+// Nothing is changed in the cluster.
+//
+// Requests must have the `Authorization: Bearer TOKEN` header whose TOKEN matches Token.
+type RunnerDeploymentPreviewer struct {
+	client.Client
+	Log logr.Logger
+
+	// Token is the bearer token required to request previews.
+	// Previews are never served when this is empty.
+	Token string
+
+	// RunnerReconciler renders runner pods in the same way as the controller does.
+	RunnerReconciler   *RunnerReconciler
+	CommonRunnerLabels []string
+}
+
+// RunnerDeploymentPreview is the result of a dry-run of a RunnerDeployment change.
+type RunnerDeploymentPreview struct {
+	// RunnerDeployment is the modified RunnerDeployment in the NAMESPACE/NAME format.
+	RunnerDeployment string `json:"runnerDeployment"`
+
+	// Exists is false when the RunnerDeployment is not found in the cluster, so that the change creates it.
+	Exists bool `json:"exists"`
+
+	// Pod is the runner pod rendered for the modified RunnerDeployment.
+	Pod corev1.Pod `json:"pod"`
+
+	// PodDiff is the unified diff between the YAML of the runner pods rendered for the current and the modified RunnerDeployment.
+	// It's empty when there's no difference.
+	PodDiff string `json:"podDiff,omitempty"`
+
+	Plan RolloutPlan `json:"plan"`
+}
+
+// RolloutPlan is how the controller would roll out a RunnerDeployment change.
+type RolloutPlan struct {
+	// TemplateChanged is true when the runner template changes, so that every runner is replaced.
+	TemplateChanged bool `json:"templateChanged"`
+
+	CurrentTemplateHash string `json:"currentTemplateHash,omitempty"`
+	NewTemplateHash     string `json:"newTemplateHash"`
+
+	// CurrentRunners is the number of the existing runners of the RunnerDeployment.
+	CurrentRunners int `json:"currentRunners"`
+
+	// DesiredReplicas is the desired replicas of the modified RunnerDeployment,
+	// or of the current one when the modified one doesn't specify it, as it's usually managed by a HorizontalRunnerAutoscaler.
+	DesiredReplicas int `json:"desiredReplicas"`
+
+	// Replacements is the number of the existing runners to be replaced by new runners.
+	Replacements int `json:"replacements"`
+
+	// BusyRunners is the number of the runners to be replaced that are running jobs.
+	// They are deleted only after they complete their jobs.
+	// It's nil when the controller failed to fetch runners from GitHub.
+	BusyRunners *int `json:"busyRunners"`
+
+	// ExpectedDrainTime is the estimated time until the last runner to be replaced is gone.
+	// It assumes that new runners take the runner startup time to become ready,
+	// and busy runners take the job duration to complete their jobs, both of which can be specified per request.
+	ExpectedDrainTime string `json:"expectedDrainTime"`
+
+	// Steps is the human-readable list of the steps of the rollout.
+	Steps []string `json:"steps"`
+}
+
+func (p *RunnerDeploymentPreviewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !hasBearerToken(r, p.Token) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		http.Error(w, "unsupported format "+strconv.Quote(format)+": it must be either json or text", http.StatusBadRequest)
+		return
+	}
+
+	runnerStartupTime, err := durationOrDefault(query.Get("runnerStartupTime"), DefaultPreviewRunnerStartupTime)
+	if err != nil {
+		http.Error(w, "invalid runnerStartupTime: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobDuration, err := durationOrDefault(query.Get("jobDuration"), DefaultPreviewJobDuration)
+	if err != nil {
+		http.Error(w, "invalid jobDuration: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPreviewBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var rd v1alpha1.RunnerDeployment
+	if err := yaml.UnmarshalStrict(body, &rd); err != nil {
+		http.Error(w, "parsing runnerdeployment: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if rd.Namespace == "" {
+		rd.Namespace = "default"
+	}
+
+	rd.Default()
+
+	if err := rd.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := p.Preview(r.Context(), rd, runnerStartupTime, jobDuration)
+	if err != nil {
+		p.Log.Error(err, "Failed to preview runnerdeployment", "runnerdeployment", types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain")
+
+		if err := writeRunnerDeploymentPreviewText(w, *preview); err != nil {
+			p.Log.Error(err, "Failed writing runnerdeployment preview")
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		p.Log.Error(err, "Failed writing runnerdeployment preview")
+	}
+}
+
+func durationOrDefault(s string, d time.Duration) (time.Duration, error) {
+	if s == "" {
+		return d, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// Preview renders the runner pod for the modified runnerdeployment and plans the rollout of the change.
+func (p *RunnerDeploymentPreviewer) Preview(ctx context.Context, modified v1alpha1.RunnerDeployment, runnerStartupTime, jobDuration time.Duration) (*RunnerDeploymentPreview, error) {
+	preview := RunnerDeploymentPreview{
+		RunnerDeployment: types.NamespacedName{Namespace: modified.Namespace, Name: modified.Name}.String(),
+	}
+
+	newRS, newPod, err := p.render(ctx, modified)
+	if err != nil {
+		return nil, fmt.Errorf("rendering runner pod for the modified runnerdeployment: %w", err)
+	}
+
+	preview.Pod = newPod
+	preview.Plan.NewTemplateHash, _ = getTemplateHash(newRS)
+
+	var current v1alpha1.RunnerDeployment
+	if err := p.Get(ctx, types.NamespacedName{Namespace: modified.Namespace, Name: modified.Name}, &current); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		preview.Plan.DesiredReplicas = getIntOrDefault(modified.Spec.Replicas, defaultReplicas)
+		preview.Plan.BusyRunners = new(int)
+		preview.Plan.ExpectedDrainTime = time.Duration(0).String()
+		preview.Plan.Steps = []string{
+			fmt.Sprintf("Create the runnerdeployment and a runnerreplicaset with %d runner(s)", preview.Plan.DesiredReplicas),
+		}
+
+		return &preview, nil
+	}
+
+	preview.Exists = true
+
+	currentRS, currentPod, err := p.render(ctx, current)
+	if err != nil {
+		return nil, fmt.Errorf("rendering runner pod for the current runnerdeployment: %w", err)
+	}
+
+	preview.PodDiff, err = diffPodYAML(currentPod, newPod)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Plan.CurrentTemplateHash, _ = getTemplateHash(currentRS)
+
+	// The template hash of the newest runnerreplicaset is what the controller compares the new one with.
+	// It can differ from the one computed from the current runnerdeployment while a rollout is in progress.
+	var rsList v1alpha1.RunnerReplicaSetList
+	if err := p.List(ctx, &rsList, client.InNamespace(current.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var newest *v1alpha1.RunnerReplicaSet
+	for i := range rsList.Items {
+		rs := rsList.Items[i]
+
+		owner := rs.GetOwnerReferences()
+		if len(owner) == 0 || owner[0].Kind != "RunnerDeployment" || owner[0].Name != current.Name {
+			continue
+		}
+
+		if newest == nil || rs.CreationTimestamp.After(newest.CreationTimestamp.Time) {
+			newest = &rs
+		}
+	}
+
+	if newest != nil {
+		if hash, ok := getTemplateHash(newest); ok {
+			preview.Plan.CurrentTemplateHash = hash
+		}
+	}
+
+	var runnerList v1alpha1.RunnerList
+	if err := p.List(ctx, &runnerList, client.InNamespace(current.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: current.Name}); err != nil {
+		return nil, err
+	}
+
+	runners := runnerList.Items
+
+	preview.Plan.CurrentRunners = len(runners)
+	preview.Plan.TemplateChanged = preview.Plan.CurrentTemplateHash != preview.Plan.NewTemplateHash
+
+	replicas := modified.Spec.Replicas
+	if replicas == nil {
+		replicas = current.Spec.Replicas
+	}
+
+	preview.Plan.DesiredReplicas = getIntOrDefault(replicas, defaultReplicas)
+
+	if preview.Plan.TemplateChanged {
+		preview.Plan.Replacements = len(runners)
+	}
+
+	busy := p.countBusyRunners(ctx, current, runners)
+	preview.Plan.BusyRunners = busy
+
+	var drainTime time.Duration
+
+	switch {
+	case preview.Plan.TemplateChanged:
+		drainTime = runnerStartupTime
+		if busy == nil || *busy > 0 {
+			drainTime += jobDuration
+		}
+
+		preview.Plan.Steps = []string{
+			fmt.Sprintf("Create a new runnerreplicaset with %d runner(s) from the new template", preview.Plan.DesiredReplicas),
+			fmt.Sprintf("Wait until all the %d new runner(s) become ready", preview.Plan.DesiredReplicas),
+			fmt.Sprintf("Scale the old runnerreplicaset(s) to zero, deleting %d existing runner(s)", preview.Plan.Replacements),
+		}
+
+		if busy == nil {
+			preview.Plan.Steps = append(preview.Plan.Steps, "Wait for busy runners, if any, to complete their jobs before they are deleted")
+		} else if *busy > 0 {
+			preview.Plan.Steps = append(preview.Plan.Steps, fmt.Sprintf("Wait for %d busy runner(s) to complete their jobs before they are deleted", *busy))
+		}
+	case preview.Plan.DesiredReplicas != preview.Plan.CurrentRunners:
+		preview.Plan.Steps = []string{
+			fmt.Sprintf("Scale the runnerreplicaset in place from %d to %d runner(s) without replacing runners", preview.Plan.CurrentRunners, preview.Plan.DesiredReplicas),
+		}
+	default:
+		preview.Plan.Steps = []string{"Nothing to roll out"}
+	}
+
+	preview.Plan.ExpectedDrainTime = drainTime.String()
+
+	return &preview, nil
+}
+
+// render returns the runnerreplicaset and the runner pod the controller would create for the runnerdeployment.
+func (p *RunnerDeploymentPreviewer) render(ctx context.Context, rd v1alpha1.RunnerDeployment) (*v1alpha1.RunnerReplicaSet, corev1.Pod, error) {
+	rs, err := newRunnerReplicaSet(&rd, p.CommonRunnerLabels, p.RunnerReconciler.Scheme)
+	if err != nil {
+		return nil, corev1.Pod{}, err
+	}
+
+	// The runner name is generated by the API server on creation, so we use a stable placeholder
+	// to not let it show up in the diff.
+	runner := v1alpha1.Runner{
+		ObjectMeta: *rs.Spec.Template.ObjectMeta.DeepCopy(),
+		Spec:       rs.Spec.Template.Spec,
+	}
+	runner.Name = rd.Name + "-preview"
+	runner.Namespace = rd.Namespace
+
+	pod, err := p.RunnerReconciler.newPod(ctx, runner)
+	if err != nil {
+		return nil, corev1.Pod{}, err
+	}
+
+	return rs, pod, nil
+}
+
+func (p *RunnerDeploymentPreviewer) countBusyRunners(ctx context.Context, rd v1alpha1.RunnerDeployment, runners []v1alpha1.Runner) *int {
+	ghc := p.RunnerReconciler.GitHubClient
+	if ghc == nil {
+		return nil
+	}
+
+	spec := rd.Spec.Template.Spec
+
+	registered, err := ghc.ListRunners(ctx, spec.Enterprise, spec.Organization, spec.Repository)
+	if err != nil {
+		p.Log.Error(err, "Failed to list runners from GitHub to count busy runners", "runnerdeployment", types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name})
+		return nil
+	}
+
+	names := map[string]struct{}{}
+	for _, r := range runners {
+		names[r.Name] = struct{}{}
+	}
+
+	var busy int
+	for _, r := range registered {
+		if _, ok := names[r.GetName()]; ok && r.GetBusy() {
+			busy++
+		}
+	}
+
+	return &busy
+}
+
+func diffPodYAML(current, modified corev1.Pod) (string, error) {
+	a, err := yaml.Marshal(current)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := yaml.Marshal(modified)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "current",
+		ToFile:   "modified",
+		Context:  3,
+	})
+}
+
+func writeRunnerDeploymentPreviewText(w io.Writer, preview RunnerDeploymentPreview) error {
+	plan := preview.Plan
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "RunnerDeployment %s\n\n", preview.RunnerDeployment)
+
+	if !preview.Exists {
+		fmt.Fprintf(&b, "The runnerdeployment doesn't exist yet.\n\n")
+	} else {
+		template := "unchanged"
+		if plan.TemplateChanged {
+			template = fmt.Sprintf("changed (%s -> %s)", plan.CurrentTemplateHash, plan.NewTemplateHash)
+		}
+
+		busy := "unknown"
+		if plan.BusyRunners != nil {
+			busy = strconv.Itoa(*plan.BusyRunners)
+		}
+
+		fmt.Fprintf(&b, "Runner template:     %s\n", template)
+		fmt.Fprintf(&b, "Current runners:     %d (busy: %s)\n", plan.CurrentRunners, busy)
+	}
+
+	fmt.Fprintf(&b, "Desired replicas:    %d\n", plan.DesiredReplicas)
+	fmt.Fprintf(&b, "Replacements:        %d\n", plan.Replacements)
+	fmt.Fprintf(&b, "Expected drain time: %s\n\n", plan.ExpectedDrainTime)
+
+	fmt.Fprintf(&b, "Rollout plan:\n")
+	for i, s := range plan.Steps {
+		fmt.Fprintf(&b, "  %d. %s\n", i+1, s)
+	}
+
+	if preview.Exists {
+		fmt.Fprintf(&b, "\nRunner pod diff (-current +modified):\n")
+
+		if preview.PodDiff == "" {
+			fmt.Fprintf(&b, "  (no changes)\n")
+		} else {
+			b.WriteString(preview.PodDiff)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	ghfake "github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnerDeploymentPreview(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	newRD := func(image string, replicas *int) v1alpha1.RunnerDeployment {
+		return v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Replicas: replicas,
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{
+						RunnerConfig: v1alpha1.RunnerConfig{Repository: "test/valid", Image: image},
+					},
+				},
+			},
+		}
+	}
+
+	current := newRD("runner:v1", intPtr(2))
+
+	newRunner := func(name string) *v1alpha1.Runner {
+		return &v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "example"},
+			},
+		}
+	}
+
+	runners := ghfake.NewRunnersList()
+	runners.Add(&github.Runner{ID: github.Int64(1), Name: github.String("example-abcde-1"), Busy: github.Bool(true)})
+	runners.Add(&github.Runner{ID: github.Int64(2), Name: github.String("example-abcde-2"), Busy: github.Bool(false)})
+
+	server := runners.GetServer()
+	defer server.Close()
+
+	c := fake.NewFakeClientWithScheme(sc, &current, newRunner("example-abcde-1"), newRunner("example-abcde-2"))
+
+	p := &RunnerDeploymentPreviewer{
+		Client: c,
+		Log:    logr.Discard(),
+		Token:  "secret",
+		RunnerReconciler: &RunnerReconciler{
+			Client:       c,
+			Scheme:       sc,
+			GitHubClient: newGithubClient(server),
+			RunnerImage:  "runner:default",
+			DockerImage:  "docker:dind",
+		},
+	}
+
+	ctx := context.Background()
+
+	t.Run("template change", func(t *testing.T) {
+		preview, err := p.Preview(ctx, newRD("runner:v2", nil), time.Minute, 10*time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		plan := preview.Plan
+
+		if !preview.Exists || !plan.TemplateChanged || plan.CurrentRunners != 2 || plan.DesiredReplicas != 2 || plan.Replacements != 2 {
+			t.Errorf("unexpected plan: %+v", plan)
+		}
+
+		if plan.BusyRunners == nil || *plan.BusyRunners != 1 {
+			t.Errorf("unexpected busy runners: %v", plan.BusyRunners)
+		}
+
+		if plan.ExpectedDrainTime != "11m0s" {
+			t.Errorf("unexpected expected drain time: %s", plan.ExpectedDrainTime)
+		}
+
+		if !strings.Contains(preview.PodDiff, "-    image: runner:v1\n") || !strings.Contains(preview.PodDiff, "+    image: runner:v2\n") {
+			t.Errorf("unexpected pod diff:\n%s", preview.PodDiff)
+		}
+
+		if got := preview.Pod.Spec.Containers[0].Image; got != "runner:v2" {
+			t.Errorf("unexpected image of the rendered pod: %s", got)
+		}
+	})
+
+	t.Run("scale only", func(t *testing.T) {
+		preview, err := p.Preview(ctx, newRD("runner:v1", intPtr(3)), time.Minute, 10*time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		plan := preview.Plan
+
+		if plan.TemplateChanged || plan.Replacements != 0 || plan.DesiredReplicas != 3 || plan.ExpectedDrainTime != "0s" {
+			t.Errorf("unexpected plan: %+v", plan)
+		}
+
+		if preview.PodDiff != "" {
+			t.Errorf("unexpected pod diff:\n%s", preview.PodDiff)
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		body := `apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: example
+spec:
+  template:
+    spec:
+      repository: test/valid
+      image: runner:v2
+`
+
+		req := httptest.NewRequest(http.MethodPost, "/runnerdeployments/preview?format=text", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer secret")
+
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+		}
+
+		for _, want := range []string{"RunnerDeployment default/example", "Replacements:        2", "Wait for 1 busy runner(s)", "Runner pod diff (-current +modified):"} {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("expected the output to contain %q:\n%s", want, rec.Body.String())
+			}
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/runnerdeployments/preview", bytes.NewBufferString(body))
+
+		rec = httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status without the token: %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// RunnerGroupReadyConditionType is the type of the condition set to RunnerDeployment with spec.runnerGroupRef
+// telling whether the referenced RunnerGroup is synced to GitHub so that runners can be registered to it.
+const RunnerGroupReadyConditionType = "RunnerGroupReady"
+
+// checkRunnerGroupRef returns the RunnerGroupReady condition for the RunnerDeployment with spec.runnerGroupRef,
+// along with the name of the runner group on GitHub when it's ready.
+func (r *RunnerDeploymentReconciler) checkRunnerGroupRef(ctx context.Context, rd v1alpha1.RunnerDeployment) (metav1.Condition, string, error) {
+	ref := rd.Spec.RunnerGroupRef
+
+	cond := metav1.Condition{Type: RunnerGroupReadyConditionType, Status: metav1.ConditionFalse}
+
+	var rg v1alpha1.RunnerGroup
+	if err := r.Get(ctx, types.NamespacedName{Namespace: rd.Namespace, Name: ref.Name}, &rg); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return cond, "", err
+		}
+
+		cond.Reason = "NotFound"
+		cond.Message = fmt.Sprintf("RunnerGroup %s doesn't exist", ref.Name)
+
+		return cond, "", nil
+	}
+
+	rc := rd.Spec.Template.Spec.RunnerConfig
+
+	if rg.Spec.Enterprise != rc.Enterprise || rg.Spec.Organization != rc.Organization {
+		cond.Reason = "Mismatch"
+		cond.Message = fmt.Sprintf("RunnerGroup %s belongs to enterprise %q and organization %q, which differ from the ones of the runners", ref.Name, rg.Spec.Enterprise, rg.Spec.Organization)
+
+		return cond, "", nil
+	}
+
+	synced := meta.FindStatusCondition(rg.Status.Conditions, RunnerGroupSyncedConditionType)
+	if synced == nil || synced.Status != metav1.ConditionTrue || rg.Status.Name == "" {
+		cond.Reason = "NotSynced"
+		cond.Message = fmt.Sprintf("RunnerGroup %s isn't synced to GitHub yet", ref.Name)
+
+		return cond, "", nil
+	}
+
+	cond.Status = metav1.ConditionTrue
+	cond.Reason = "Synced"
+	cond.Message = fmt.Sprintf("RunnerGroup %s is synced to GitHub as runner group %s", ref.Name, rg.Status.Name)
+
+	return cond, rg.Status.Name, nil
+}
+
+// syncRunnerGroupRef updates the RunnerGroupReady condition of the RunnerDeployment with spec.runnerGroupRef,
+// and returns the name of the runner group the runners are registered to, and true when the referenced RunnerGroup
+// isn't ready so that no runner pod should be created.
+// While the RunnerGroup isn't ready, the last resolved runner group is returned so that the runners aren't replaced needlessly.
+// Events are emitted only when the readiness changes.
+func (r *RunnerDeploymentReconciler) syncRunnerGroupRef(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) (string, bool, error) {
+	if rd.Spec.RunnerGroupRef == nil {
+		return rd.Spec.Template.Spec.Group, false, nil
+	}
+
+	cond, group, err := r.checkRunnerGroupRef(ctx, rd)
+	if err != nil {
+		return rd.Status.RunnerGroup, true, err
+	}
+
+	notReady := cond.Status == metav1.ConditionFalse
+	if notReady {
+		group = rd.Status.RunnerGroup
+	}
+
+	last := meta.FindStatusCondition(rd.Status.Conditions, RunnerGroupReadyConditionType)
+	if last != nil && last.Status == cond.Status && last.Reason == cond.Reason && last.Message == cond.Message && last.ObservedGeneration == rd.Generation {
+		return group, notReady, nil
+	}
+
+	if last == nil || last.Status != cond.Status || last.Reason != cond.Reason {
+		if notReady {
+			r.Recorder.Event(&rd, corev1.EventTypeWarning, "RunnerGroupNotReady", cond.Message+". Stopped creating runners")
+			log.Info("Stopped creating runners as the runner group isn't ready", "runnergroup", rd.Spec.RunnerGroupRef.Name, "reason", cond.Reason)
+		} else if last != nil {
+			r.Recorder.Event(&rd, corev1.EventTypeNormal, "RunnerGroupReady", cond.Message+". Resumed creating runners")
+			log.Info("Resumed creating runners as the runner group became ready", "runnergroup", rd.Spec.RunnerGroupRef.Name)
+		}
+	}
+
+	updated := rd.DeepCopy()
+	cond.ObservedGeneration = rd.Generation
+	meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rd)); err != nil {
+		return group, notReady, err
+	}
+
+	return group, notReady, nil
+}
+
+// runnerDeploymentsForRunnerGroup returns the requests to reconcile the RunnerDeployments referencing the RunnerGroup,
+// so that they start or stop creating runners as soon as the RunnerGroup is synced.
+func (r *RunnerDeploymentReconciler) runnerDeploymentsForRunnerGroup(obj client.Object) []reconcile.Request {
+	var rds v1alpha1.RunnerDeploymentList
+	if err := r.List(context.Background(), &rds, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list runnerdeployments referencing runnergroup", "runnergroup", obj.GetName())
+
+		return nil
+	}
+
+	var reqs []reconcile.Request
+
+	for _, rd := range rds.Items {
+		if ref := rd.Spec.RunnerGroupRef; ref != nil && ref.Name == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: rd.Namespace, Name: rd.Name}})
+		}
+	}
+
+	return reqs
+}
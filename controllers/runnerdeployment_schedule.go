@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scheduledReplicasOverride returns the ScheduledOverride whose windows are the same as the ScheduledReplicas,
+// so that both are evaluated by matchScheduledOverride.
+func scheduledReplicasOverride(s v1alpha1.ScheduledReplicas) v1alpha1.ScheduledOverride {
+	return v1alpha1.ScheduledOverride{
+		StartTime:      s.StartTime,
+		EndTime:        s.EndTime,
+		Schedule:       s.Schedule,
+		Duration:       s.Duration,
+		TimeZone:       s.TimeZone,
+		RecurrenceRule: s.RecurrenceRule,
+	}
+}
+
+// matchScheduledReplicas returns the first entry of the schedule whose window is active at now, if any,
+// along with the duration until the active window ends or the next window starts, whichever comes first,
+// so that the runner deployment is reconciled right on the boundary. The duration is zero when there's no such boundary.
+func matchScheduledReplicas(now time.Time, schedule []v1alpha1.ScheduledReplicas) (*v1alpha1.ActiveScheduledReplicas, time.Duration, error) {
+	var (
+		active           *v1alpha1.ActiveScheduledReplicas
+		activeOverride   *Override
+		upcomingOverride *Override
+	)
+
+	for i, s := range schedule {
+		o := scheduledReplicasOverride(s)
+
+		a, u, err := matchScheduledOverride(now, o)
+		if err != nil {
+			return nil, 0, fmt.Errorf("spec.schedule[%d]: %w", i, err)
+		}
+
+		// The earlier entries take precedence over the later ones, like the scheduled overrides of HRA.
+		if a != nil && active == nil {
+			active = &v1alpha1.ActiveScheduledReplicas{Index: i, Replicas: s.Replicas, EndTime: metav1.NewTime(a.EndTime)}
+			activeOverride = &Override{Period: *a, ScheduledOverride: o}
+		}
+
+		if u != nil && (upcomingOverride == nil || u.StartTime.Before(upcomingOverride.Period.StartTime)) {
+			upcomingOverride = &Override{Period: *u, ScheduledOverride: o}
+		}
+	}
+
+	return active, nextScheduledOverrideBoundary(now, activeOverride, upcomingOverride), nil
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchScheduledReplicas(t *testing.T) {
+	// Weekdays from 9 to 18, and a one-off maintenance window taking precedence over them.
+	workHours := v1alpha1.ScheduledReplicas{Schedule: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 9 * time.Hour}, Replicas: 20}
+	maintenance := v1alpha1.ScheduledReplicas{
+		StartTime: metav1.NewTime(time.Date(2022, 3, 2, 12, 0, 0, 0, time.UTC)),
+		EndTime:   metav1.NewTime(time.Date(2022, 3, 2, 13, 0, 0, 0, time.UTC)),
+		Replicas:  0,
+	}
+
+	schedule := []v1alpha1.ScheduledReplicas{maintenance, workHours}
+
+	testcases := []struct {
+		description  string
+		now          time.Time
+		schedule     []v1alpha1.ScheduledReplicas
+		wantIndex    int
+		wantReplicas *int
+		wantBoundary time.Duration
+		wantErr      bool
+	}{
+		{
+			description:  "outside the windows",
+			now:          time.Date(2022, 3, 1, 7, 0, 0, 0, time.UTC),
+			schedule:     schedule,
+			wantBoundary: 2 * time.Hour,
+		},
+		{
+			description:  "within the working hours",
+			now:          time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+			schedule:     schedule,
+			wantIndex:    1,
+			wantReplicas: intPtr(20),
+			wantBoundary: 8 * time.Hour,
+		},
+		{
+			description:  "earlier entry takes precedence",
+			now:          time.Date(2022, 3, 2, 12, 30, 0, 0, time.UTC),
+			schedule:     schedule,
+			wantIndex:    0,
+			wantReplicas: intPtr(0),
+			wantBoundary: 30 * time.Minute,
+		},
+		{
+			description:  "upcoming earlier entry ends the window of the later one",
+			now:          time.Date(2022, 3, 2, 11, 0, 0, 0, time.UTC),
+			schedule:     schedule,
+			wantIndex:    1,
+			wantReplicas: intPtr(20),
+			wantBoundary: time.Hour,
+		},
+		{
+			description: "invalid schedule",
+			now:         time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+			schedule:    []v1alpha1.ScheduledReplicas{{Schedule: "0 9 * *", Replicas: 20}},
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			active, boundary, err := matchScheduledReplicas(tc.now, tc.schedule)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.wantReplicas == nil {
+				if active != nil {
+					t.Errorf("unexpected active schedule: %+v", *active)
+				}
+			} else if active == nil || active.Index != tc.wantIndex || active.Replicas != *tc.wantReplicas {
+				t.Errorf("unexpected active schedule: want index %d and replicas %d, got %+v", tc.wantIndex, *tc.wantReplicas, active)
+			}
+
+			if boundary != tc.wantBoundary {
+				t.Errorf("unexpected boundary: want %s, got %s", tc.wantBoundary, boundary)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	defaultRollingUpdateMaxSurge       = intstr.FromString("25%")
+	defaultRollingUpdateMaxUnavailable = intstr.FromString("25%")
+)
+
+// rollingUpdateLimits returns the maxSurge and the maxUnavailable of the rolling update strategy resolved against the desired replicas.
+// Like Deployment, maxUnavailable is raised to 1 when both are 0 so that the update can make progress.
+func rollingUpdateLimits(strategy *v1alpha1.RunnerDeploymentStrategy, desiredReplicas int) (int, int) {
+	maxSurgeValue, maxUnavailableValue := defaultRollingUpdateMaxSurge, defaultRollingUpdateMaxUnavailable
+
+	if ru := strategy.RollingUpdate; ru != nil {
+		if ru.MaxSurge != nil {
+			maxSurgeValue = *ru.MaxSurge
+		}
+
+		if ru.MaxUnavailable != nil {
+			maxUnavailableValue = *ru.MaxUnavailable
+		}
+	}
+
+	// The values are validated by the webhook. An invalid one is treated as 0.
+	maxSurge, err := intstr.GetScaledValueFromIntOrPercent(&maxSurgeValue, desiredReplicas, true)
+	if err != nil || maxSurge < 0 {
+		maxSurge = 0
+	}
+
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailableValue, desiredReplicas, false)
+	if err != nil || maxUnavailable < 0 {
+		maxUnavailable = 0
+	}
+
+	if maxUnavailable > desiredReplicas {
+		maxUnavailable = desiredReplicas
+	}
+
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxUnavailable = 1
+	}
+
+	return maxSurge, maxUnavailable
+}
+
+// oldRunnerReplicaSetReplicas returns the number of the runners of the old runnerreplicaset,
+// including the busy runners that are still completing their jobs after the runnerreplicaset was scaled down.
+func oldRunnerReplicaSetReplicas(rs v1alpha1.RunnerReplicaSet) int {
+	replicas := getIntOrDefault(rs.Spec.Replicas, defaultReplicas)
+
+	if rs.Status.Replicas != nil && *rs.Status.Replicas > replicas {
+		replicas = *rs.Status.Replicas
+	}
+
+	return replicas
+}
+
+// rolloutNewReplicas returns the replicas of the newest runnerreplicaset while the runners of the old runnerreplicasets are replaced
+// according to the strategy. currentReplicas is the current replicas of the newest runnerreplicaset, which is 0 before it's created.
+func rolloutNewReplicas(strategy *v1alpha1.RunnerDeploymentStrategy, currentReplicas int, oldSets []v1alpha1.RunnerReplicaSet, desiredReplicas int) int {
+	var oldReplicas int
+	for _, rs := range oldSets {
+		oldReplicas += oldRunnerReplicaSetReplicas(rs)
+	}
+
+	if strategy.Type == v1alpha1.RunnerDeploymentStrategyRecreate {
+		// No new runner is created until all the old runners are gone.
+		if oldReplicas > 0 {
+			return 0
+		}
+
+		return desiredReplicas
+	}
+
+	maxSurge, _ := rollingUpdateLimits(strategy, desiredReplicas)
+
+	replicas := desiredReplicas + maxSurge - oldReplicas
+	if replicas > desiredReplicas {
+		replicas = desiredReplicas
+	}
+
+	// The new runners are never removed to make room for the old ones, unless the desired replicas decreased.
+	if currentReplicas > desiredReplicas {
+		currentReplicas = desiredReplicas
+	}
+
+	if replicas < currentReplicas {
+		replicas = currentReplicas
+	}
+
+	if replicas < 0 {
+		replicas = 0
+	}
+
+	return replicas
+}
+
+// rolloutOldRunnerReplicaSets scales down the old runnerreplicasets according to the strategy, and deletes the ones that have no runner left.
+// The runners are removed by the runnerreplicasets, which remove idle runners first and wait for the busy ones to complete their jobs.
+//
+// oldSets must be sorted from the newest to the oldest.
+func (r *RunnerDeploymentReconciler) rolloutOldRunnerReplicaSets(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment, newestSet *v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet, desiredReplicas int) error {
+	if rd.Spec.Strategy.Type == v1alpha1.RunnerDeploymentStrategyRecreate {
+		return r.cleanupRunnerReplicaSets(ctx, log, rd, oldSets)
+	}
+
+	_, maxUnavailable := rollingUpdateLimits(rd.Spec.Strategy, desiredReplicas)
+
+	available := getIntOrDefault(newestSet.Status.AvailableReplicas, 0)
+	for _, rs := range oldSets {
+		available += getIntOrDefault(rs.Status.AvailableReplicas, 0)
+	}
+
+	// The number of the available runners that can be removed without going below the desired replicas minus maxUnavailable.
+	budget := available - (desiredReplicas - maxUnavailable)
+
+	var scaledToZero []v1alpha1.RunnerReplicaSet
+
+	for i := len(oldSets) - 1; i >= 0; i-- {
+		rs := oldSets[i]
+
+		replicas := getIntOrDefault(rs.Spec.Replicas, defaultReplicas)
+		if replicas == 0 {
+			scaledToZero = append(scaledToZero, rs)
+
+			continue
+		}
+
+		// The unavailable runners are removed regardless of the budget, as removing them doesn't reduce the available runners.
+		unavailable := replicas - getIntOrDefault(rs.Status.AvailableReplicas, 0)
+		if unavailable < 0 {
+			unavailable = 0
+		}
+
+		scaleDown := unavailable
+		if budget > 0 {
+			scaleDown += budget
+		}
+
+		if scaleDown > replicas {
+			scaleDown = replicas
+		}
+
+		if scaleDown == 0 {
+			continue
+		}
+
+		if scaleDown > unavailable {
+			budget -= scaleDown - unavailable
+		}
+
+		updated := rs.DeepCopy()
+		newReplicas := replicas - scaleDown
+		updated.Spec.Replicas = &newReplicas
+
+		if err := r.Client.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to scale down old runnerreplicaset", "runnerreplicaset", rs.Name)
+
+			return err
+		}
+
+		log.Info("Scaled down old runnerreplicaset", "runnerreplicaset", rs.Name, "replicas_before", replicas, "replicas_after", newReplicas)
+	}
+
+	return r.cleanupRunnerReplicaSets(ctx, log, rd, scaledToZero)
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRollingUpdateLimits(t *testing.T) {
+	intOrString := func(v intstr.IntOrString) *intstr.IntOrString {
+		return &v
+	}
+
+	testcases := []struct {
+		rollingUpdate      *v1alpha1.RollingUpdateRunnerDeployment
+		desired            int
+		wantMaxSurge       int
+		wantMaxUnavailable int
+	}{
+		{desired: 10, wantMaxSurge: 3, wantMaxUnavailable: 2},
+		{desired: 1, wantMaxSurge: 1, wantMaxUnavailable: 0},
+		{
+			rollingUpdate: &v1alpha1.RollingUpdateRunnerDeployment{MaxSurge: intOrString(intstr.FromInt(0)), MaxUnavailable: intOrString(intstr.FromString("50%"))},
+			desired:       5, wantMaxSurge: 0, wantMaxUnavailable: 2,
+		},
+		{
+			rollingUpdate: &v1alpha1.RollingUpdateRunnerDeployment{MaxSurge: intOrString(intstr.FromInt(0)), MaxUnavailable: intOrString(intstr.FromInt(0))},
+			desired:       5, wantMaxSurge: 0, wantMaxUnavailable: 1,
+		},
+		{
+			rollingUpdate: &v1alpha1.RollingUpdateRunnerDeployment{MaxUnavailable: intOrString(intstr.FromInt(10))},
+			desired:       4, wantMaxSurge: 1, wantMaxUnavailable: 4,
+		},
+	}
+
+	for i, tc := range testcases {
+		maxSurge, maxUnavailable := rollingUpdateLimits(&v1alpha1.RunnerDeploymentStrategy{RollingUpdate: tc.rollingUpdate}, tc.desired)
+		if maxSurge != tc.wantMaxSurge || maxUnavailable != tc.wantMaxUnavailable {
+			t.Errorf("[%d] want maxSurge %d and maxUnavailable %d, got %d and %d", i, tc.wantMaxSurge, tc.wantMaxUnavailable, maxSurge, maxUnavailable)
+		}
+	}
+}
+
+func TestRolloutNewReplicas(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	old := func(spec, current int) v1alpha1.RunnerReplicaSet {
+		return v1alpha1.RunnerReplicaSet{
+			Spec:   v1alpha1.RunnerReplicaSetSpec{Replicas: intPtr(spec)},
+			Status: v1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(current)},
+		}
+	}
+
+	rollingUpdate := &v1alpha1.RunnerDeploymentStrategy{Type: v1alpha1.RunnerDeploymentStrategyRollingUpdate}
+	recreate := &v1alpha1.RunnerDeploymentStrategy{Type: v1alpha1.RunnerDeploymentStrategyRecreate}
+
+	testcases := []struct {
+		name     string
+		strategy *v1alpha1.RunnerDeploymentStrategy
+		current  int
+		oldSets  []v1alpha1.RunnerReplicaSet
+		desired  int
+		want     int
+	}{
+		{name: "surge on creation", strategy: rollingUpdate, oldSets: []v1alpha1.RunnerReplicaSet{old(10, 10)}, desired: 10, want: 3},
+		{name: "scale up as old runners are removed", strategy: rollingUpdate, current: 3, oldSets: []v1alpha1.RunnerReplicaSet{old(6, 6)}, desired: 10, want: 7},
+		{name: "busy old runners count towards surge", strategy: rollingUpdate, current: 3, oldSets: []v1alpha1.RunnerReplicaSet{old(6, 10)}, desired: 10, want: 3},
+		{name: "never exceed desired", strategy: rollingUpdate, current: 3, oldSets: []v1alpha1.RunnerReplicaSet{old(0, 0)}, desired: 10, want: 10},
+		{name: "desired decreased", strategy: rollingUpdate, current: 8, oldSets: []v1alpha1.RunnerReplicaSet{old(2, 2)}, desired: 5, want: 5},
+		{name: "recreate waits for old runners", strategy: recreate, oldSets: []v1alpha1.RunnerReplicaSet{old(0, 2)}, desired: 10, want: 0},
+		{name: "recreate after old runners are gone", strategy: recreate, oldSets: []v1alpha1.RunnerReplicaSet{old(0, 0)}, desired: 10, want: 10},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rolloutNewReplicas(tc.strategy, tc.current, tc.oldSets, tc.desired); got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRolloutOldRunnerReplicaSets(t *testing.T) {
+	ctx := context.Background()
+
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	rs := func(name string, spec, current, available int) *v1alpha1.RunnerReplicaSet {
+		return &v1alpha1.RunnerReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       v1alpha1.RunnerReplicaSetSpec{Replicas: intPtr(spec)},
+			Status:     v1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(current), AvailableReplicas: intPtr(available)},
+		}
+	}
+
+	rd := v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			Replicas: intPtr(10),
+			Strategy: &v1alpha1.RunnerDeploymentStrategy{Type: v1alpha1.RunnerDeploymentStrategyRollingUpdate},
+		},
+	}
+
+	// 10 desired runners with maxUnavailable of 2, of which 3 new ones and 8 old ones are available.
+	// The 2 unavailable runners of the oldest runnerreplicaset are removed regardless of the budget,
+	// and the budget of 3 available runners is spent from the oldest runnerreplicaset.
+	newest := rs("example-new", 3, 3, 3)
+	older := rs("example-older", 4, 4, 4)
+	oldest := rs("example-oldest", 6, 6, 4)
+	drained := rs("example-drained", 0, 0, 0)
+
+	c := fake.NewFakeClientWithScheme(sc, newest, older, oldest, drained)
+
+	r := &RunnerDeploymentReconciler{
+		Client:   c,
+		Log:      logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+		Scheme:   sc,
+	}
+
+	if err := r.rolloutOldRunnerReplicaSets(ctx, logr.Discard(), rd, newest, []v1alpha1.RunnerReplicaSet{*older, *oldest, *drained}, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replicas := func(name string) int {
+		t.Helper()
+
+		var got v1alpha1.RunnerReplicaSet
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return *got.Spec.Replicas
+	}
+
+	if got := replicas("example-oldest"); got != 1 {
+		t.Errorf("unexpected replicas of the oldest runnerreplicaset: want 1, got %d", got)
+	}
+
+	if got := replicas("example-older"); got != 4 {
+		t.Errorf("unexpected replicas of the older runnerreplicaset: want 4, got %d", got)
+	}
+
+	var got v1alpha1.RunnerReplicaSet
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-drained"}, &got); err == nil {
+		t.Errorf("the drained runnerreplicaset must be deleted")
+	}
+}
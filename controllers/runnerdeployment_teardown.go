@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const runnerDeploymentTeardownFinalizerName = "runnerdeployment.actions.summerwind.dev/teardown"
+
+// defaultRunnerLabels are the labels the runner agent adds to every self-hosted runner on registration,
+// in addition to the labels specified in the runner spec.
+// We don't know the OS and the architecture of the runners of a pool that don't exist, so all the possible ones are included.
+var defaultRunnerLabels = []string{"self-hosted", "linux", "windows", "macos", "x64", "arm", "arm64"}
+
+func cancelsPendingJobsOnTeardown(rd v1alpha1.RunnerDeployment) bool {
+	return rd.Spec.TeardownPolicy != nil && rd.Spec.TeardownPolicy.CancelPendingJobs
+}
+
+// syncTeardownFinalizer adds the teardown finalizer to the runnerdeployment when it has the teardown policy,
+// and removes it otherwise.
+// It returns true when the runnerdeployment has been updated.
+func (r *RunnerDeploymentReconciler) syncTeardownFinalizer(ctx context.Context, rd v1alpha1.RunnerDeployment) (bool, error) {
+	var (
+		finalizers []string
+		changed    bool
+	)
+
+	if cancelsPendingJobsOnTeardown(rd) {
+		finalizers, changed = addFinalizer(rd.ObjectMeta.Finalizers, runnerDeploymentTeardownFinalizerName)
+	} else {
+		finalizers, changed = removeFinalizer(rd.ObjectMeta.Finalizers, runnerDeploymentTeardownFinalizerName)
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	updated := rd.DeepCopy()
+	updated.ObjectMeta.Finalizers = finalizers
+
+	if err := r.Update(ctx, updated); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// processTeardown cancels the pending jobs of the runnerdeployment being deleted according to its teardown policy,
+// and then removes the teardown finalizer to let the deletion continue.
+func (r *RunnerDeploymentReconciler) processTeardown(ctx context.Context, log logr.Logger, rd v1alpha1.RunnerDeployment) (ctrl.Result, error) {
+	finalizers, removed := removeFinalizer(rd.ObjectMeta.Finalizers, runnerDeploymentTeardownFinalizerName)
+	if !removed {
+		return ctrl.Result{}, nil
+	}
+
+	if cancelsPendingJobsOnTeardown(rd) && r.GitHubClient != nil {
+		cancelled, err := r.cancelPendingJobs(ctx, rd)
+		if err != nil {
+			log.Error(err, "Failed to cancel pending jobs. Continuing the deletion")
+
+			r.Recorder.Event(&rd, corev1.EventTypeWarning, "PendingJobsCancellationFailed", err.Error())
+		} else if len(cancelled) > 0 {
+			log.Info("Cancelled pending workflow runs that only this runnerdeployment could run", "workflowRuns", cancelled)
+
+			r.Recorder.Event(&rd, corev1.EventTypeNormal, "PendingJobsCancelled", fmt.Sprintf("Cancelled %d pending workflow run(s): %s", len(cancelled), strings.Join(cancelled, ", ")))
+		}
+	}
+
+	updated := rd.DeepCopy()
+	updated.ObjectMeta.Finalizers = finalizers
+
+	if err := r.Update(ctx, updated); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	log.Info("Removed teardown finalizer")
+
+	return ctrl.Result{}, nil
+}
+
+// cancelPendingJobs cancels the queued workflow runs whose pending jobs can be run by the runners of the runnerdeployment,
+// but by no other runner.
+// Other runners are the runners registered to GitHub except the ones of the runnerdeployment,
+// and the runners of the other runnerdeployments and runnersets, that may be scaled to zero.
+// It returns the cancelled workflow runs in the OWNER/REPO#ID format.
+func (r *RunnerDeploymentReconciler) cancelPendingJobs(ctx context.Context, rd v1alpha1.RunnerDeployment) ([]string, error) {
+	rc := rd.Spec.Template.Spec.RunnerConfig
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rd.Namespace, rc.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+
+	if rc.Repository != "" {
+		repos = append(repos, rc.Repository)
+	} else if rc.Organization != "" {
+		for _, name := range rd.Spec.TeardownPolicy.RepositoryNames {
+			repos = append(repos, rc.Organization+"/"+name)
+		}
+	}
+
+	if len(repos) == 0 {
+		return nil, nil
+	}
+
+	poolLabels := append(append(append([]string{}, defaultRunnerLabels...), r.CommonRunnerLabels...), rc.Labels...)
+
+	others, err := r.listOtherRunnerLabels(ctx, ghc, rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled []string
+
+	for _, repo := range repos {
+		owner, name, err := splitOwnerAndRepo(repo)
+		if err != nil {
+			return cancelled, err
+		}
+
+		poolOthers := append([][]string{}, others.registered...)
+		for _, p := range others.pools {
+			if p.canServe(owner, repo) {
+				poolOthers = append(poolOthers, p.labels)
+			}
+		}
+
+		runs, err := ghc.ListRepositoryWorkflowRuns(ctx, owner, name)
+		if err != nil {
+			return cancelled, err
+		}
+
+		for _, run := range runs {
+			if run.GetStatus() != "queued" {
+				continue
+			}
+
+			jobs, err := r.listWorkflowJobs(ctx, ghc, owner, name, run.GetID())
+			if err != nil {
+				return cancelled, err
+			}
+
+			if !onlyRunnableBy(jobs, poolLabels, poolOthers) {
+				continue
+			}
+
+			if _, err := ghc.Actions.CancelWorkflowRunByID(ctx, owner, name, run.GetID()); err != nil {
+				var accepted *gogithub.AcceptedError
+				if !errors.As(err, &accepted) {
+					return cancelled, fmt.Errorf("cancelling workflow run %s#%d: %w", repo, run.GetID(), err)
+				}
+			}
+
+			cancelled = append(cancelled, fmt.Sprintf("%s#%d", repo, run.GetID()))
+		}
+	}
+
+	return cancelled, nil
+}
+
+type teardownPool struct {
+	enterprise, org, repo string
+	labels                []string
+}
+
+func (p teardownPool) canServe(owner, repo string) bool {
+	// We can't tell which organizations an enterprise runner serves, so we conservatively assume it serves any.
+	return p.enterprise != "" || p.org == owner || p.repo == repo
+}
+
+type otherRunnerLabels struct {
+	// registered is the labels of the runners registered to GitHub, except the ones of the runnerdeployment being deleted.
+	registered [][]string
+	// pools is the runner pools in the cluster other than the runnerdeployment being deleted.
+	pools []teardownPool
+}
+
+func (r *RunnerDeploymentReconciler) listOtherRunnerLabels(ctx context.Context, ghc *github.Client, rd v1alpha1.RunnerDeployment) (*otherRunnerLabels, error) {
+	var res otherRunnerLabels
+
+	rc := rd.Spec.Template.Spec.RunnerConfig
+
+	var ownRunners v1alpha1.RunnerList
+	if err := r.List(ctx, &ownRunners, client.InNamespace(rd.Namespace), client.MatchingLabels{LabelKeyRunnerDeploymentName: rd.Name}); err != nil {
+		return nil, err
+	}
+
+	own := map[string]struct{}{}
+	for _, runner := range ownRunners.Items {
+		own[runner.Name] = struct{}{}
+	}
+
+	registered, err := ghc.ListRunners(ctx, rc.Enterprise, rc.Organization, rc.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.Repository != "" {
+		// Organizational runners can run the jobs of repositories in the organization, too.
+		// We can't list them when the owner is a user or we don't have the permission, which is fine.
+		owner, _, err := splitOwnerAndRepo(rc.Repository)
+		if err != nil {
+			return nil, err
+		}
+
+		orgRunners, err := ghc.ListRunners(ctx, "", owner, "")
+		if err != nil {
+			var errRes *gogithub.ErrorResponse
+			if !errors.As(err, &errRes) || errRes.Response == nil || (errRes.Response.StatusCode != http.StatusNotFound && errRes.Response.StatusCode != http.StatusForbidden) {
+				return nil, err
+			}
+		}
+
+		registered = append(registered, orgRunners...)
+	}
+
+	for _, runner := range registered {
+		if _, ok := own[runner.GetName()]; ok {
+			continue
+		}
+
+		var labels []string
+		for _, l := range runner.Labels {
+			labels = append(labels, l.GetName())
+		}
+
+		res.registered = append(res.registered, labels)
+	}
+
+	newPool := func(rc v1alpha1.RunnerConfig) teardownPool {
+		labels := append(append(append([]string{}, defaultRunnerLabels...), r.CommonRunnerLabels...), rc.Labels...)
+
+		return teardownPool{enterprise: rc.Enterprise, org: rc.Organization, repo: rc.Repository, labels: labels}
+	}
+
+	var rdList v1alpha1.RunnerDeploymentList
+	if err := r.List(ctx, &rdList); err != nil {
+		return nil, err
+	}
+
+	for _, other := range rdList.Items {
+		if other.Namespace == rd.Namespace && other.Name == rd.Name {
+			continue
+		}
+
+		res.pools = append(res.pools, newPool(other.Spec.Template.Spec.RunnerConfig))
+	}
+
+	var rsList v1alpha1.RunnerSetList
+	if err := r.List(ctx, &rsList); err != nil {
+		return nil, err
+	}
+
+	for _, rs := range rsList.Items {
+		res.pools = append(res.pools, newPool(rs.Spec.RunnerConfig))
+	}
+
+	return &res, nil
+}
+
+func (r *RunnerDeploymentReconciler) listWorkflowJobs(ctx context.Context, ghc *github.Client, owner, repo string, runID int64) ([]*gogithub.WorkflowJob, error) {
+	var allJobs []*gogithub.WorkflowJob
+
+	opt := gogithub.ListWorkflowJobsOptions{ListOptions: gogithub.ListOptions{PerPage: 50}}
+
+	for {
+		jobs, resp, err := ghc.Actions.ListWorkflowJobs(ctx, owner, repo, runID, &opt)
+		if err != nil {
+			return nil, fmt.Errorf("listing workflow jobs of %s/%s#%d: %w", owner, repo, runID, err)
+		}
+
+		allJobs = append(allJobs, jobs.Jobs...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return allJobs, nil
+}
+
+// onlyRunnableBy returns true when the workflow run has one or more pending jobs,
+// and every pending job can be run by a runner that has poolLabels but by no runner that has any of others.
+func onlyRunnableBy(jobs []*gogithub.WorkflowJob, poolLabels []string, others [][]string) bool {
+	var pending int
+
+	for _, job := range jobs {
+		if job.GetStatus() == "completed" {
+			continue
+		}
+
+		pending++
+
+		if !canRunJob(poolLabels, job.Labels) {
+			return false
+		}
+
+		for _, labels := range others {
+			if canRunJob(labels, job.Labels) {
+				return false
+			}
+		}
+	}
+
+	return pending > 0
+}
+
+// canRunJob returns true when a runner that has the runner labels is able to run a job that has the job labels.
+// GitHub compares labels case-insensitively.
+func canRunJob(runnerLabels, jobLabels []string) bool {
+	if len(jobLabels) == 0 {
+		return false
+	}
+
+JOB:
+	for _, jl := range jobLabels {
+		for _, rl := range runnerLabels {
+			if strings.EqualFold(jl, rl) {
+				continue JOB
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCanRunJob(t *testing.T) {
+	testcases := []struct {
+		runner []string
+		job    []string
+		want   bool
+	}{
+		{runner: []string{"self-hosted", "linux", "gpu"}, job: []string{"self-hosted", "gpu"}, want: true},
+		{runner: []string{"self-hosted", "linux", "gpu"}, job: []string{"Self-Hosted", "GPU"}, want: true},
+		{runner: []string{"self-hosted", "linux"}, job: []string{"self-hosted", "gpu"}, want: false},
+		{runner: []string{"self-hosted", "linux"}, job: nil, want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := canRunJob(tc.runner, tc.job); got != tc.want {
+			t.Errorf("canRunJob(%v, %v): want %v, got %v", tc.runner, tc.job, tc.want, got)
+		}
+	}
+}
+
+func TestRunnerDeploymentTeardown(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu        sync.Mutex
+		cancelled []string
+	)
+
+	jobs := map[int]string{
+		// Only the pool being deleted can run it
+		1: `[{"status": "queued", "labels": ["self-hosted", "gpu"]}, {"status": "completed", "labels": ["self-hosted", "linux"]}]`,
+		// The registered runner outside of the pool can run it
+		2: `[{"status": "queued", "labels": ["self-hosted", "linux"]}]`,
+		// The pool being deleted can't run it
+		3: `[{"status": "queued", "labels": ["self-hosted", "gpu", "big"]}]`,
+		// The runnerset can run it
+		4: `[{"status": "queued", "labels": ["self-hosted", "highmem"]}]`,
+		// It's in progress
+		5: `[{"status": "in_progress", "labels": ["self-hosted", "gpu"]}]`,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/myorg/app/actions/runners", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 2, "runners": [
+{"id": 1, "name": "gpu-abcde", "labels": [{"name": "self-hosted"}, {"name": "gpu"}]},
+{"id": 2, "name": "vm", "labels": [{"name": "self-hosted"}, {"name": "linux"}]}
+]}`)
+	})
+	mux.HandleFunc("/orgs/myorg/actions/runners", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+	})
+	mux.HandleFunc("/repos/myorg/app/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("status") != "queued" {
+			fmt.Fprint(w, `{"total_count": 1, "workflow_runs": [{"id": 5, "status": "in_progress"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"total_count": 4, "workflow_runs": [{"id": 1, "status": "queued"}, {"id": 2, "status": "queued"}, {"id": 3, "status": "queued"}, {"id": 4, "status": "queued"}]}`)
+	})
+	for id, body := range jobs {
+		body := body
+		mux.HandleFunc(fmt.Sprintf("/repos/myorg/app/actions/runs/%d/jobs", id), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"jobs": %s}`, body)
+		})
+		mux.HandleFunc(fmt.Sprintf("/repos/myorg/app/actions/runs/%d/cancel", id), func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			cancelled = append(cancelled, r.URL.Path)
+
+			w.WriteHeader(http.StatusAccepted)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	now := metav1.Now()
+
+	rd := &v1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "gpu",
+			Finalizers:        []string{runnerDeploymentTeardownFinalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec: v1alpha1.RunnerDeploymentSpec{
+			TeardownPolicy: &v1alpha1.RunnerDeploymentTeardownPolicy{CancelPendingJobs: true},
+			Template: v1alpha1.RunnerTemplate{
+				Spec: v1alpha1.RunnerSpec{
+					RunnerConfig: v1alpha1.RunnerConfig{Repository: "myorg/app", Labels: []string{"gpu", "highmem"}},
+				},
+			},
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc,
+		rd,
+		&v1alpha1.Runner{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "gpu-abcde",
+				Labels:    map[string]string{LabelKeyRunnerDeploymentName: "gpu"},
+			},
+		},
+		&v1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gpu-for-another-repo"},
+			Spec: v1alpha1.RunnerDeploymentSpec{
+				Template: v1alpha1.RunnerTemplate{
+					Spec: v1alpha1.RunnerSpec{
+						RunnerConfig: v1alpha1.RunnerConfig{Repository: "myorg/another", Labels: []string{"gpu"}},
+					},
+				},
+			},
+		},
+		&v1alpha1.RunnerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "highmem"},
+			Spec: v1alpha1.RunnerSetSpec{
+				RunnerConfig: v1alpha1.RunnerConfig{Organization: "myorg", Labels: []string{"highmem"}},
+			},
+		},
+	)
+
+	r := &RunnerDeploymentReconciler{
+		Client:       c,
+		Log:          logr.Discard(),
+		Recorder:     record.NewFakeRecorder(10),
+		GitHubClient: newGithubClient(server),
+	}
+
+	if _, err := r.processTeardown(ctx, logr.Discard(), *rd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"/repos/myorg/app/actions/runs/1/cancel"}
+	if !reflect.DeepEqual(cancelled, want) {
+		t.Errorf("unexpected cancellations: want %v, got %v", want, cancelled)
+	}
+
+	// The runnerdeployment is gone once the teardown finalizer is removed
+	var updated v1alpha1.RunnerDeployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gpu"}, &updated); !kerrors.IsNotFound(err) {
+		t.Errorf("unexpected result of getting the runnerdeployment: finalizers=%v, err=%v", updated.Finalizers, err)
+	}
+}
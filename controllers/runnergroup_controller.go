@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// RunnerGroupSyncedConditionType is the type of the condition set to RunnerGroup
+	// on each sync of the runner group on GitHub with the spec.
+	RunnerGroupSyncedConditionType = "Synced"
+
+	runnerGroupFinalizerName = "runnergroup.actions.summerwind.dev/delete"
+
+	// DefaultRunnerGroupSyncPeriod is the interval at which RunnerGroups are re-synced,
+	// so that the changes made to the runner groups outside of the controller, e.g. via the GitHub UI, are reverted.
+	DefaultRunnerGroupSyncPeriod = 10 * time.Minute
+)
+
+// RunnerGroupReconciler reconciles a RunnerGroup object by creating the runner group on GitHub,
+// and keeping its visibility and repository or organization access in sync with the spec.
+type RunnerGroupReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Name     string
+
+	GitHubClient *github.Client
+
+	// GitHubClients holds the GitHub clients for the runnergroups that set githubAPICredentialsFrom.
+	GitHubClients *MultiGitHubClient
+
+	// SyncPeriod is the interval at which each RunnerGroup is re-synced. Defaults to DefaultRunnerGroupSyncPeriod.
+	SyncPeriod time.Duration
+
+	// ErrorBudget stops retrying the runnergroups whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
+}
+
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnergroups,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnergroups/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnergroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+func (r *RunnerGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("runnergroup", req.NamespacedName)
+
+	var rg v1alpha1.RunnerGroup
+	if err := r.Get(ctx, req.NamespacedName, &rg); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !rg.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.processDeletion(ctx, log, rg)
+	}
+
+	var finalizers []string
+	var changed bool
+
+	if deletesRunnerGroupOnGitHub(rg) {
+		finalizers, changed = addFinalizer(rg.ObjectMeta.Finalizers, runnerGroupFinalizerName)
+	} else {
+		finalizers, changed = removeFinalizer(rg.ObjectMeta.Finalizers, runnerGroupFinalizerName)
+	}
+
+	if changed {
+		updated := rg.DeepCopy()
+		updated.ObjectMeta.Finalizers = finalizers
+
+		if err := r.Update(ctx, updated); err != nil {
+			log.Error(err, "Failed to update runnergroup finalizers")
+
+			return ctrl.Result{}, err
+		}
+
+		// Updates of the finalizers don't trigger reconciliations on their own, as they don't change the generation.
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	syncPeriod := r.SyncPeriod
+	if syncPeriod == 0 {
+		syncPeriod = DefaultRunnerGroupSyncPeriod
+	}
+
+	group, syncErr := r.syncRunnerGroup(ctx, log, rg)
+
+	cond := metav1.Condition{
+		Type:               RunnerGroupSyncedConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            fmt.Sprintf("Runner group %s is in sync with the spec", rg.GroupName()),
+		ObservedGeneration: rg.Generation,
+	}
+
+	if syncErr != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "SyncFailed"
+		cond.Message = syncErr.Error()
+	}
+
+	updated := rg.DeepCopy()
+	if group != nil {
+		updated.Status.ID = group.GetID()
+		updated.Status.Name = group.GetName()
+	}
+	if syncErr == nil {
+		now := metav1.Now()
+		updated.Status.ObservedGeneration = rg.Generation
+		updated.Status.LastSyncTime = &now
+	}
+
+	last := meta.FindStatusCondition(rg.Status.Conditions, RunnerGroupSyncedConditionType)
+	if last == nil || last.Status != cond.Status || last.Reason != cond.Reason {
+		if syncErr != nil {
+			r.Recorder.Event(&rg, corev1.EventTypeWarning, "RunnerGroupSyncFailed", cond.Message)
+		} else {
+			r.Recorder.Event(&rg, corev1.EventTypeNormal, "RunnerGroupSynced", cond.Message)
+		}
+	}
+
+	meta.SetStatusCondition(&updated.Status.Conditions, cond)
+
+	if err := r.Status().Patch(ctx, updated, client.MergeFrom(&rg)); err != nil {
+		log.Error(err, "Failed to patch runnergroup status")
+
+		return ctrl.Result{}, err
+	}
+
+	if syncErr != nil {
+		log.Error(syncErr, "Failed to sync runner group")
+
+		return ctrl.Result{}, syncErr
+	}
+
+	return ctrl.Result{RequeueAfter: syncPeriod}, nil
+}
+
+func deletesRunnerGroupOnGitHub(rg v1alpha1.RunnerGroup) bool {
+	return rg.Spec.DeletionPolicy == v1alpha1.RunnerGroupDeletionPolicyDelete
+}
+
+// processDeletion deletes the runner group on GitHub when the RunnerGroup has the Delete deletion policy,
+// and then removes the finalizer to let the deletion continue.
+func (r *RunnerGroupReconciler) processDeletion(ctx context.Context, log logr.Logger, rg v1alpha1.RunnerGroup) (ctrl.Result, error) {
+	finalizers, removed := removeFinalizer(rg.ObjectMeta.Finalizers, runnerGroupFinalizerName)
+	if !removed {
+		return ctrl.Result{}, nil
+	}
+
+	if deletesRunnerGroupOnGitHub(rg) && rg.Status.ID != 0 {
+		ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rg.Namespace, rg.Spec.GitHubAPICredentialsFrom)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := ghc.DeleteRunnerGroup(ctx, rg.Spec.Enterprise, rg.Spec.Organization, rg.Status.ID); err != nil && !isGitHubNotFound(err) {
+			log.Error(err, "Failed to delete runner group", "id", rg.Status.ID)
+
+			r.Recorder.Event(&rg, corev1.EventTypeWarning, "RunnerGroupDeletionFailed", err.Error())
+
+			return ctrl.Result{}, err
+		}
+
+		log.Info("Deleted runner group", "id", rg.Status.ID, "name", rg.Status.Name)
+	}
+
+	updated := rg.DeepCopy()
+	updated.ObjectMeta.Finalizers = finalizers
+
+	if err := r.Update(ctx, updated); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// validateRunnerGroupSpec returns an error when the runner group can't be synced to GitHub as specified.
+func validateRunnerGroupSpec(spec v1alpha1.RunnerGroupSpec) error {
+	if (spec.Enterprise == "") == (spec.Organization == "") {
+		return errors.New("exactly one of spec.enterprise and spec.organization must be set")
+	}
+
+	if spec.Enterprise != "" && spec.Visibility == v1alpha1.RunnerGroupVisibilityPrivate {
+		return errors.New("spec.visibility can't be private for an enterprise runner group")
+	}
+
+	if spec.Enterprise != "" && len(spec.Repositories) > 0 {
+		return errors.New("spec.repositories can't be set for an enterprise runner group. Use spec.organizations instead")
+	}
+
+	if spec.Organization != "" && len(spec.Organizations) > 0 {
+		return errors.New("spec.organizations can't be set for an organization runner group. Use spec.repositories instead")
+	}
+
+	return nil
+}
+
+// syncRunnerGroup creates the runner group on GitHub, or adopts the existing one of the same name, and updates it to match the spec.
+// A runner group renamed via spec.name is renamed on GitHub in place, as long as it still exists.
+// The returned runner group is non-nil whenever it exists on GitHub, even when the rest of the sync failed.
+func (r *RunnerGroupReconciler) syncRunnerGroup(ctx context.Context, log logr.Logger, rg v1alpha1.RunnerGroup) (*gogithub.RunnerGroup, error) {
+	if err := validateRunnerGroupSpec(rg.Spec); err != nil {
+		return nil, err
+	}
+
+	if r.GitHubClient == nil {
+		return nil, errors.New("the controller has no GitHub client to manage runner groups")
+	}
+
+	ghc, err := r.GitHubClients.ClientFor(ctx, r.GitHubClient, rg.Namespace, rg.Spec.GitHubAPICredentialsFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	enterprise, org, name := rg.Spec.Enterprise, rg.Spec.Organization, rg.GroupName()
+
+	visibility := rg.Spec.Visibility
+	if visibility == "" {
+		visibility = v1alpha1.RunnerGroupVisibilityAll
+	}
+
+	settings := github.RunnerGroupSettings{
+		Name:                     name,
+		Visibility:               visibility,
+		AllowsPublicRepositories: rg.Spec.AllowsPublicRepositories,
+	}
+
+	if rg.Status.ID != 0 && rg.Status.Name != "" && rg.Status.Name != name {
+		if err := ghc.UpdateRunnerGroup(ctx, enterprise, org, rg.Status.ID, settings); err == nil {
+			log.Info("Renamed runner group", "id", rg.Status.ID, "from", rg.Status.Name, "to", name)
+		} else if !isGitHubNotFound(err) {
+			return nil, err
+		}
+	}
+
+	group, err := ghc.GetRunnerGroupByName(ctx, enterprise, org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if group == nil {
+		group, err = ghc.CreateRunnerGroup(ctx, enterprise, org, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Info("Created runner group", "id", group.GetID(), "name", name)
+	} else if group.GetVisibility() != visibility || group.GetAllowsPublicRepositories() != settings.AllowsPublicRepositories {
+		if err := ghc.UpdateRunnerGroup(ctx, enterprise, org, group.GetID(), settings); err != nil {
+			return group, err
+		}
+
+		group.Visibility = &settings.Visibility
+		group.AllowsPublicRepositories = &settings.AllowsPublicRepositories
+
+		log.Info("Updated runner group", "id", group.GetID(), "name", name, "visibility", visibility, "allowsPublicRepositories", settings.AllowsPublicRepositories)
+	}
+
+	if visibility != v1alpha1.RunnerGroupVisibilitySelected {
+		return group, nil
+	}
+
+	want, err := runnerGroupAccessIDs(ctx, ghc, rg.Spec)
+	if err != nil {
+		return group, err
+	}
+
+	got, err := ghc.ListRunnerGroupAccess(ctx, enterprise, org, group.GetID())
+	if err != nil {
+		return group, err
+	}
+
+	if equalIDs(want, got) {
+		return group, nil
+	}
+
+	if err := ghc.SetRunnerGroupAccess(ctx, enterprise, org, group.GetID(), want); err != nil {
+		return group, err
+	}
+
+	log.Info("Updated runner group access", "id", group.GetID(), "name", name, "repositories", rg.Spec.Repositories, "organizations", rg.Spec.Organizations)
+
+	return group, nil
+}
+
+// runnerGroupAccessIDs returns the IDs of the organizations of an enterprise runner group,
+// or the repositories of an organization runner group, that are allowed to use the runner group.
+func runnerGroupAccessIDs(ctx context.Context, ghc *github.Client, spec v1alpha1.RunnerGroupSpec) ([]int64, error) {
+	var ids []int64
+
+	if spec.Enterprise != "" {
+		for _, name := range spec.Organizations {
+			org, _, err := ghc.Organizations.Get(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get organization %s: %w", name, err)
+			}
+
+			ids = append(ids, org.GetID())
+		}
+
+		return ids, nil
+	}
+
+	for _, name := range spec.Repositories {
+		repo, _, err := ghc.Repositories.Get(ctx, spec.Organization, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository %s/%s: %w", spec.Organization, name, err)
+		}
+
+		ids = append(ids, repo.GetID())
+	}
+
+	return ids, nil
+}
+
+// equalIDs returns true when a and b contain the same IDs regardless of their order.
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]int64{}, a...)
+	b = append([]int64{}, b...)
+
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isGitHubNotFound(err error) bool {
+	var errRes *gogithub.ErrorResponse
+
+	return errors.As(err, &errRes) && errRes.Response != nil && errRes.Response.StatusCode == http.StatusNotFound
+}
+
+func (r *RunnerGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	name := "runnergroup-controller"
+	if r.Name != "" {
+		name = r.Name
+	}
+
+	r.Recorder = mgr.GetEventRecorderFor(name)
+
+	// Only the spec changes trigger reconciliations, so that the status updates of each sync don't cause another sync.
+	// The drifts on GitHub are reverted on the periodic re-syncs instead.
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RunnerGroup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(name).
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.RunnerGroup{} }))
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeRunnerGroupServer serves the runner groups API of the organization "test",
+// whose repositories are "test/repo1" (ID 1) and "test/repo2" (ID 2).
+type fakeRunnerGroupServer struct {
+	mu      sync.Mutex
+	groups  map[int64]map[string]interface{}
+	access  map[int64][]int64
+	nextID  int64
+	deleted []int64
+}
+
+func (s *fakeRunnerGroupServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var id int64
+
+	switch {
+	case req.URL.Path == "/repos/test/repo1":
+		w.Write([]byte(`{"id": 1, "full_name": "test/repo1"}`))
+	case req.URL.Path == "/repos/test/repo2":
+		w.Write([]byte(`{"id": 2, "full_name": "test/repo2"}`))
+	case req.URL.Path == "/orgs/test/actions/runner-groups" && req.Method == http.MethodGet:
+		var groups []map[string]interface{}
+		for _, g := range s.groups {
+			groups = append(groups, g)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(groups), "runner_groups": groups})
+	case req.URL.Path == "/orgs/test/actions/runner-groups" && req.Method == http.MethodPost:
+		var g map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&g)
+		s.nextID++
+		g["id"] = s.nextID
+		s.groups[s.nextID] = g
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(g)
+	case scan(req.URL.Path, "/orgs/test/actions/runner-groups/%d/repositories", &id):
+		if req.Method == http.MethodPut {
+			var body map[string][]int64
+			json.NewDecoder(req.Body).Decode(&body)
+			s.access[id] = body["selected_repository_ids"]
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var repos []map[string]int64
+		for _, r := range s.access[id] {
+			repos = append(repos, map[string]int64{"id": r})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(repos), "repositories": repos})
+	case scan(req.URL.Path, "/orgs/test/actions/runner-groups/%d", &id):
+		g, ok := s.groups[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		if req.Method == http.MethodDelete {
+			delete(s.groups, id)
+			s.deleted = append(s.deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewDecoder(req.Body).Decode(&g)
+		json.NewEncoder(w).Encode(g)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+func scan(path, format string, id *int64) bool {
+	var rest string
+	n, _ := fmt.Sscanf(path+" x", format+" %s", id, &rest)
+	return n == 2
+}
+
+func TestRunnerGroupReconciler(t *testing.T) {
+	s := &fakeRunnerGroupServer{
+		groups: map[int64]map[string]interface{}{
+			// Adopted by the RunnerGroup of the same name
+			1: {"id": 1, "name": "existing", "visibility": "all"},
+		},
+		access: map[int64][]int64{},
+		nextID: 1,
+	}
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	testcases := []struct {
+		name       string
+		spec       v1alpha1.RunnerGroupSpec
+		wantID     int64
+		wantSynced metav1.ConditionStatus
+		wantAccess []int64
+	}{
+		{
+			name:       "new",
+			spec:       v1alpha1.RunnerGroupSpec{Organization: "test", Visibility: "selected", Repositories: []string{"repo2", "repo1"}},
+			wantID:     2,
+			wantSynced: metav1.ConditionTrue,
+			wantAccess: []int64{1, 2},
+		},
+		{
+			name:       "adopted",
+			spec:       v1alpha1.RunnerGroupSpec{Organization: "test", Name: "existing", Visibility: "private"},
+			wantID:     1,
+			wantSynced: metav1.ConditionTrue,
+		},
+		{
+			name:       "invalid",
+			spec:       v1alpha1.RunnerGroupSpec{Organization: "test", Enterprise: "test"},
+			wantSynced: metav1.ConditionFalse,
+		},
+		{
+			name:       "missing-repository",
+			spec:       v1alpha1.RunnerGroupSpec{Organization: "test", Visibility: "selected", Repositories: []string{"missing"}},
+			wantID:     3,
+			wantSynced: metav1.ConditionFalse,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rg := &v1alpha1.RunnerGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: tc.name},
+				Spec:       tc.spec,
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, rg)
+
+			r := &RunnerGroupReconciler{
+				Client:       c,
+				Log:          logr.Discard(),
+				Recorder:     record.NewFakeRecorder(10),
+				GitHubClient: newGithubClient(server),
+			}
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: tc.name}})
+			if tc.wantSynced == metav1.ConditionTrue && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if tc.wantSynced == metav1.ConditionFalse && err == nil {
+				t.Fatalf("expected error, got none")
+			}
+
+			var got v1alpha1.RunnerGroup
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: tc.name}, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Status.ID != tc.wantID {
+				t.Errorf("unexpected id: want %d, got %d", tc.wantID, got.Status.ID)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, RunnerGroupSyncedConditionType)
+			if cond == nil || cond.Status != tc.wantSynced {
+				t.Errorf("unexpected condition: %+v", cond)
+			}
+
+			if tc.wantSynced != metav1.ConditionTrue {
+				return
+			}
+
+			if got.Status.Name != got.GroupName() {
+				t.Errorf("unexpected name: want %s, got %s", got.GroupName(), got.Status.Name)
+			}
+
+			if want, visibility := tc.spec.Visibility, s.groups[tc.wantID]["visibility"]; visibility != want {
+				t.Errorf("unexpected visibility: want %s, got %v", want, visibility)
+			}
+
+			access := append([]int64{}, s.access[tc.wantID]...)
+			sort.Slice(access, func(i, j int) bool { return access[i] < access[j] })
+
+			if !equalIDs(access, tc.wantAccess) {
+				t.Errorf("unexpected access: want %v, got %v", tc.wantAccess, access)
+			}
+		})
+	}
+}
+
+func TestRunnerGroupReconciler_Delete(t *testing.T) {
+	s := &fakeRunnerGroupServer{
+		groups: map[int64]map[string]interface{}{
+			1: {"id": 1, "name": "example", "visibility": "all"},
+		},
+		access: map[int64][]int64{},
+		nextID: 1,
+	}
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	now := metav1.Now()
+
+	rg := &v1alpha1.RunnerGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "example",
+			Finalizers:        []string{runnerGroupFinalizerName},
+			DeletionTimestamp: &now,
+		},
+		Spec:   v1alpha1.RunnerGroupSpec{Organization: "test", DeletionPolicy: v1alpha1.RunnerGroupDeletionPolicyDelete},
+		Status: v1alpha1.RunnerGroupStatus{ID: 1, Name: "example"},
+	}
+
+	c := fake.NewFakeClientWithScheme(sc, rg)
+
+	r := &RunnerGroupReconciler{
+		Client:       c,
+		Log:          logr.Discard(),
+		Recorder:     record.NewFakeRecorder(10),
+		GitHubClient: newGithubClient(server),
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "example"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s.deleted) != 1 || s.deleted[0] != 1 {
+		t.Errorf("unexpected deleted runner groups: %v", s.deleted)
+	}
+}
+
+func TestSyncRunnerGroupRef(t *testing.T) {
+	synced := v1alpha1.RunnerGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "synced"},
+		Spec:       v1alpha1.RunnerGroupSpec{Organization: "test", Name: "on-github"},
+		Status: v1alpha1.RunnerGroupStatus{
+			Name:       "on-github",
+			Conditions: []metav1.Condition{{Type: RunnerGroupSyncedConditionType, Status: metav1.ConditionTrue, Reason: "Synced"}},
+		},
+	}
+
+	pending := v1alpha1.RunnerGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pending"},
+		Spec:       v1alpha1.RunnerGroupSpec{Organization: "test"},
+	}
+
+	testcases := []struct {
+		ref          string
+		org          string
+		lastGroup    string
+		wantGroup    string
+		wantNotReady bool
+		wantReason   string
+	}{
+		{ref: "synced", org: "test", wantGroup: "on-github", wantReason: "Synced"},
+		{ref: "synced", org: "other", lastGroup: "on-github", wantGroup: "on-github", wantNotReady: true, wantReason: "Mismatch"},
+		{ref: "pending", org: "test", wantNotReady: true, wantReason: "NotSynced"},
+		{ref: "missing", org: "test", wantNotReady: true, wantReason: "NotFound"},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.ref+"/"+tc.org, func(t *testing.T) {
+			rd := &v1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+				Spec: v1alpha1.RunnerDeploymentSpec{
+					RunnerGroupRef: &v1alpha1.RunnerGroupReference{Name: tc.ref},
+					Template: v1alpha1.RunnerTemplate{
+						Spec: v1alpha1.RunnerSpec{RunnerConfig: v1alpha1.RunnerConfig{Organization: tc.org}},
+					},
+				},
+				Status: v1alpha1.RunnerDeploymentStatus{RunnerGroup: tc.lastGroup},
+			}
+
+			c := fake.NewFakeClientWithScheme(sc, rd, synced.DeepCopy(), pending.DeepCopy())
+
+			r := &RunnerDeploymentReconciler{
+				Client:   c,
+				Log:      logr.Discard(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+
+			group, notReady, err := r.syncRunnerGroupRef(context.Background(), logr.Discard(), *rd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if group != tc.wantGroup {
+				t.Errorf("unexpected group: want %q, got %q", tc.wantGroup, group)
+			}
+
+			if notReady != tc.wantNotReady {
+				t.Errorf("unexpected notReady: want %v, got %v", tc.wantNotReady, notReady)
+			}
+
+			var got v1alpha1.RunnerDeployment
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example"}, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, RunnerGroupReadyConditionType)
+			if cond == nil || cond.Reason != tc.wantReason {
+				t.Errorf("unexpected condition: %+v", cond)
+			}
+		})
+	}
+}
@@ -43,6 +43,29 @@ type RunnerReplicaSetReconciler struct {
 	Scheme       *runtime.Scheme
 	GitHubClient *github.Client
 	Name         string
+
+	// GitHubClients holds the GitHub clients for the runnerreplicasets whose templates set githubAPICredentialsFrom.
+	GitHubClients *MultiGitHubClient
+
+	// DrainMode stops creating runners, while redundant runners are still unregistered and deleted.
+	DrainMode bool
+
+	// ScaleDownGracePeriod is the period after a runner registers or is seen busy during which it isn't chosen for scale down.
+	ScaleDownGracePeriod time.Duration
+
+	// RunnerStatusMaxAge is the age within which the runner statuses synced by RunnerStatusSyncer are used
+	// to find busy runners instead of listing the runners via the GitHub API. Zero always lists the runners via the GitHub API.
+	RunnerStatusMaxAge time.Duration
+
+	// ErrorBudget stops retrying the runnerreplicasets whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
+
+	// RunnerReadyRequiresOnline counts a running runner as available and ready only once the runner controller
+	// marks it ready after GitHub reports it online.
+	RunnerReadyRequiresOnline bool
+
+	// generateRunnerName overrides the generation of the names of the new runners in tests.
+	generateRunnerName func(base string) string
 }
 
 const (
@@ -55,6 +78,7 @@ const (
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runners/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
 func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("runnerreplicaset", req.NamespacedName)
@@ -82,7 +106,15 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if rs.ObjectMeta.Labels[LabelKeyRunnerTemplateHash] == "" {
 		template := rs.Spec.DeepCopy()
 		template.Replicas = nil
+		template.WarmReplicas = nil
+		template.BurstReplicas = nil
+		template.BurstPriorityClassName = ""
+		template.Sizes = nil
+		template.SizeReplicas = nil
 		template.EffectiveTime = nil
+		template.ZoneRebalance = nil
+		template.WarmRunnerAffinity = nil
+		template.ScaleDownPolicy = ""
 		templateHash := ComputeHash(template)
 
 		log.Info("Using auto-generated template hash", "value", templateHash)
@@ -114,6 +146,11 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		replicas = *rs.Spec.Replicas
 	}
 
+	var warmReplicas int
+	if rs.Spec.WarmReplicas != nil {
+		warmReplicas = *rs.Spec.WarmReplicas
+	}
+
 	effectiveTime := rs.Spec.EffectiveTime
 	ephemeral := rs.Spec.Template.Spec.Ephemeral == nil || *rs.Spec.Template.Spec.Ephemeral
 
@@ -124,17 +161,55 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	// Runners of the warm pool are created suspended, and activated one by one below
+	// as long as the number of active runners is less than the desired replicas.
+	if warmReplicas > 0 {
+		setAnnotation(&desired.ObjectMeta, AnnotationKeyRunnerSuspended, "true")
+	}
+
 	var live []client.Object
 	for _, r := range runnerList.Items {
 		r := r
 		live = append(live, &r)
 	}
 
-	res, err := syncRunnerPodsOwners(ctx, r.Client, log, effectiveTime, replicas, func() client.Object { return desired.DeepCopy() }, ephemeral, live)
+	create := newSizeAwareRunnerFactory(rs, runnerList.Items, newBurstAwareRunnerFactory(rs, desired, runnerList.Items, replicas+warmReplicas))
+	create = r.newCapacityReservationAwareRunnerFactory(ctx, log, rs, runnerList.Items, create)
+	create = r.newUniquelyNamedRunnerFactory(ctx, log, rs, create)
+
+	listBusy := func() map[string]bool {
+		if states, ok := syncedRunnerGitHubStates(time.Now(), r.RunnerStatusMaxAge, runnerList.Items); ok {
+			return busyRunnersFromStates(states)
+		}
+
+		return listBusyRunners(ctx, log, r.GitHubClient, r.GitHubClients, rs.Namespace, rs.Spec.Template.Spec.RunnerConfig)
+	}
+
+	var listWarmth func() map[string]int
+	if affinity := rs.Spec.WarmRunnerAffinity; affinity != nil {
+		listWarmth = func() map[string]int {
+			return runnerWarmth(time.Now(), warmRunnerAffinityWindow(affinity), runnerList.Items)
+		}
+	}
+
+	res, err := syncRunnerPodsOwners(ctx, r.Client, log, effectiveTime, replicas+warmReplicas, &desired, create, ephemeral, r.DrainMode, rs.Spec.ScaleDownPolicy, r.ScaleDownGracePeriod, listBusy, listWarmth, live)
 	if err != nil || res == nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := activateWarmRunners(ctx, r.Client, log, res.currentObjects, replicas); err != nil {
+		log.Error(err, "Failed to activate warm runners")
+
+		return ctrl.Result{}, err
+	}
+
+	zoneRebalanceTimes, err := r.rebalanceZones(ctx, log, time.Now(), rs, res.currentObjects, replicas+warmReplicas)
+	if err != nil {
+		log.Error(err, "Failed to rebalance runners across zones")
+
+		return ctrl.Result{}, err
+	}
+
 	var (
 		status v1alpha1.RunnerReplicaSetStatus
 
@@ -143,6 +218,11 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	for _, o := range res.currentObjects {
 		current += o.total
+
+		if r.RunnerReadyRequiresOnline && o.runner != nil && o.runner.Spec.Provisioner == "" && !o.runner.Status.Ready {
+			continue
+		}
+
 		available += o.running
 		ready += o.running
 	}
@@ -150,6 +230,7 @@ func (r *RunnerReplicaSetReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	status.Replicas = &current
 	status.AvailableReplicas = &available
 	status.ReadyReplicas = &ready
+	status.ZoneRebalanceTimes = zoneRebalanceTimes
 
 	if !reflect.DeepEqual(rs.Status, status) {
 		updated := rs.DeepCopy()
@@ -203,7 +284,7 @@ func (r *RunnerReplicaSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.RunnerReplicaSet{}).
 		Owns(&v1alpha1.Runner{}).
 		Named(name).
-		Complete(r)
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.RunnerReplicaSet{} }))
 }
 
 func registrationOnlyRunnerNameFor(rsName string) string {
@@ -24,6 +24,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,6 +34,9 @@ import (
 
 	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
 	"github.com/actions-runner-controller/actions-runner-controller/controllers/metrics"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/podmutator"
 	"github.com/go-logr/logr"
 )
 
@@ -51,6 +55,34 @@ type RunnerSetReconciler struct {
 	RunnerImagePullSecrets []string
 	DockerImage            string
 	DockerRegistryMirror   string
+
+	// LabelMappings maps runner labels to the scheduling constraints applied to runner pods.
+	LabelMappings *labelmapping.Config
+
+	// RunnerPodDefaults is the node selector and the tolerations merged into every runner pod.
+	RunnerPodDefaults RunnerPodDefaults
+
+	// PodMutators are applied to the pod template of every runner statefulset in order, after it's generated from the runnerset spec.
+	PodMutators *podmutator.Chain
+
+	// DrainMode stops creating statefulsets for new runners, while redundant ones are still deleted.
+	DrainMode bool
+
+	// ScaleDownGracePeriod is the period after a runner registers or is seen busy during which it isn't chosen for scale down.
+	ScaleDownGracePeriod time.Duration
+
+	// GitHubClient is used to find busy runners, which are retained before idle ones on scale down.
+	GitHubClient *github.Client
+
+	// GitHubClients holds the GitHub clients for the runnersets that set githubAPICredentialsFrom.
+	GitHubClients *MultiGitHubClient
+
+	// CentralImagePullSecret is the image pull secret copied into the namespace of each runnerset and attached to all the runner pods.
+	// The image pull secret isn't copied nor attached when nil.
+	CentralImagePullSecret *types.NamespacedName
+
+	// ErrorBudget stops retrying the runnersets whose reconciliations keep failing with the exponential backoff.
+	ErrorBudget ReconcileErrorBudget
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=runnersets,verbs=get;list;watch;create;update;patch;delete
@@ -96,7 +128,7 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	desiredStatefulSet, err := r.newStatefulSet(runnerSet)
+	desiredStatefulSet, err := r.newStatefulSet(ctx, runnerSet)
 	if err != nil {
 		r.Recorder.Event(runnerSet, corev1.EventTypeNormal, "RunnerAutoscalingFailure", err.Error())
 
@@ -105,6 +137,16 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	if src := r.CentralImagePullSecret; src != nil {
+		if err := replicateImagePullSecret(ctx, r.Client, *src, runnerSet.Namespace); err != nil {
+			r.Recorder.Event(runnerSet, corev1.EventTypeWarning, "ImagePullSecretCopyFailed", err.Error())
+
+			log.Error(err, "Could not copy the central image pull secret")
+
+			return ctrl.Result{}, err
+		}
+	}
+
 	addedReplicas := int32(1)
 	create := desiredStatefulSet.DeepCopy()
 	create.Spec.Replicas = &addedReplicas
@@ -119,6 +161,10 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	newDesiredReplicas := getIntOrDefault(replicasOfDesiredStatefulSet, defaultReplicas)
 
+	if poolDrained(runnerSet) {
+		newDesiredReplicas = 0
+	}
+
 	effectiveTime := runnerSet.Spec.EffectiveTime
 	ephemeral := runnerSet.Spec.Ephemeral == nil || *runnerSet.Spec.Ephemeral
 
@@ -129,7 +175,11 @@ func (r *RunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		owners = append(owners, &ss)
 	}
 
-	res, err := syncRunnerPodsOwners(ctx, r.Client, log, effectiveTime, newDesiredReplicas, func() client.Object { return create.DeepCopy() }, ephemeral, owners)
+	listBusy := func() map[string]bool {
+		return listBusyRunners(ctx, log, r.GitHubClient, r.GitHubClients, runnerSet.Namespace, runnerSet.Spec.RunnerConfig)
+	}
+
+	res, err := syncRunnerPodsOwners(ctx, r.Client, log, effectiveTime, newDesiredReplicas, create, func() client.Object { return create.DeepCopy() }, ephemeral, r.DrainMode, "", r.ScaleDownGracePeriod, listBusy, nil, owners)
 	if err != nil || res == nil {
 		return ctrl.Result{}, err
 	}
@@ -178,7 +228,7 @@ func getRunnerSetSelector(runnerSet *v1alpha1.RunnerSet) *metav1.LabelSelector {
 var LabelKeyPodMutation = "actions-runner-controller/inject-registration-token"
 var LabelValuePodMutation = "true"
 
-func (r *RunnerSetReconciler) newStatefulSet(runnerSet *v1alpha1.RunnerSet) (*appsv1.StatefulSet, error) {
+func (r *RunnerSetReconciler) newStatefulSet(ctx context.Context, runnerSet *v1alpha1.RunnerSet) (*appsv1.StatefulSet, error) {
 	runnerSetWithOverrides := *runnerSet.Spec.DeepCopy()
 
 	for _, l := range r.CommonRunnerLabels {
@@ -195,6 +245,22 @@ func (r *RunnerSetReconciler) newStatefulSet(runnerSet *v1alpha1.RunnerSet) (*ap
 		return nil, err
 	}
 
+	applyRunnerPlatformNodeSelector(&pod, runnerSet.Spec.RunnerConfig)
+	r.LabelMappings.Apply(runnerSetWithOverrides.Labels, &pod)
+	r.RunnerPodDefaults.apply(&pod)
+
+	if err := applyRunnerExtendedResources(&pod, runnerSet.Spec.RunnerConfig, r.LabelMappings); err != nil {
+		return nil, err
+	}
+
+	if src := r.CentralImagePullSecret; src != nil {
+		attachImagePullSecret(&pod.Spec, src.Name)
+	}
+
+	if err := r.PodMutators.Apply(ctx, &pod, podmutator.Target{Kind: "RunnerSet", Namespace: runnerSet.Namespace, Name: runnerSet.Name, RunnerConfig: runnerSet.Spec.RunnerConfig}); err != nil {
+		return nil, err
+	}
+
 	runnerSetWithOverrides.StatefulSetSpec.Template.ObjectMeta = pod.ObjectMeta
 	runnerSetWithOverrides.StatefulSetSpec.Template.Spec = pod.Spec
 	// NOTE: Seems like the only supported restart policy for statefulset is "Always"?
@@ -264,5 +330,5 @@ func (r *RunnerSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.RunnerSet{}).
 		Owns(&appsv1.StatefulSet{}).
 		Named(name).
-		Complete(r)
+		Complete(r.ErrorBudget.wrap(name, r, r.Client, r.Recorder, r.Log, func() client.Object { return &v1alpha1.RunnerSet{} }))
 }
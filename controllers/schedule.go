@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/teambition/rrule-go"
@@ -120,3 +122,188 @@ func calculateActiveAndUpcomingRecurringPeriods(now, startTime, endTime time.Tim
 
 	return active, next, nil
 }
+
+// MatchCronSchedule returns the active and the upcoming periods of the overrides that start at the times denoted by
+// the 5-field cron expression evaluated in loc, each lasting for duration.
+// The upcoming period is searched for up to a year later than now.
+func MatchCronSchedule(now time.Time, schedule string, duration time.Duration, loc *time.Location) (*Period, *Period, error) {
+	if duration <= 0 {
+		return nil, nil, fmt.Errorf("invalid duration %s: It must be positive", duration)
+	}
+
+	opt, err := parseCronSchedule(schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now = now.In(loc)
+
+	// Start the recurrence at the earliest time an active override could have started,
+	// rather than iterating over the recurrences from the distant past.
+	opt.Dtstart = now.Add(-duration).Truncate(time.Minute)
+	opt.Until = now.AddDate(1, 0, 1)
+
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var active, next *Period
+
+	if start := r.Before(now, true); !start.IsZero() && start.Add(duration).After(now) {
+		active = &Period{StartTime: start, EndTime: start.Add(duration)}
+	}
+
+	if start := r.After(now, false); !start.IsZero() {
+		next = &Period{StartTime: start, EndTime: start.Add(duration)}
+	}
+
+	return active, next, nil
+}
+
+var cronWeekdays = []rrule.Weekday{rrule.SU, rrule.MO, rrule.TU, rrule.WE, rrule.TH, rrule.FR, rrule.SA}
+
+var cronNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// parseCronSchedule translates the 5-field cron expression into the equivalent recurrence rule options.
+// Restricting both the day of month and the day of week isn't supported,
+// as cron matches either of them while a recurrence rule would match both.
+func parseCronSchedule(schedule string) (*rrule.ROption, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: It must be a cron expression with 5 fields", schedule)
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minute in schedule %q: %w", schedule, err)
+	}
+
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hour in schedule %q: %w", schedule, err)
+	}
+
+	days, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day of month in schedule %q: %w", schedule, err)
+	}
+
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month in schedule %q: %w", schedule, err)
+	}
+
+	weekdays, err := parseCronField(fields[4], 0, 7)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day of week in schedule %q: %w", schedule, err)
+	}
+
+	if days != nil && weekdays != nil {
+		return nil, fmt.Errorf("invalid schedule %q: Restricting both the day of month and the day of week is not supported", schedule)
+	}
+
+	opt := &rrule.ROption{
+		Bysecond:   []int{0},
+		Byminute:   minutes,
+		Byhour:     hours,
+		Bymonthday: days,
+		Bymonth:    months,
+	}
+
+	for _, d := range weekdays {
+		opt.Byweekday = append(opt.Byweekday, cronWeekdays[d%7])
+	}
+
+	// Iterate by the finest unrestricted unit, so that each recurrence is generated exactly once.
+	switch {
+	case minutes == nil:
+		opt.Freq = rrule.MINUTELY
+	case hours == nil:
+		opt.Freq = rrule.HOURLY
+	default:
+		opt.Freq = rrule.DAILY
+	}
+
+	return opt, nil
+}
+
+// parseCronField returns the values matched by the cron field, or nil when it matches every value.
+func parseCronField(field string, min, max int) ([]int, error) {
+	if field == "*" {
+		return nil, nil
+	}
+
+	matched := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part)
+			}
+
+			rng, step = part[:i], s
+		}
+
+		lo, hi := min, max
+
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			v, err := parseCronValue(bounds[0], min, max)
+			if err != nil {
+				return nil, err
+			}
+
+			lo, hi = v, v
+
+			if len(bounds) == 2 {
+				if hi, err = parseCronValue(bounds[1], min, max); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+
+			if lo > hi {
+				return nil, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			matched[v] = true
+		}
+	}
+
+	var values []int
+
+	for v := min; v <= max; v++ {
+		if matched[v] {
+			values = append(values, v)
+		}
+	}
+
+	return values, nil
+}
+
+func parseCronValue(s string, min, max int) (int, error) {
+	v, ok := cronNames[strings.ToLower(s)]
+	if !ok {
+		var err error
+		if v, err = strconv.Atoi(s); err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+	}
+
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+	}
+
+	return v, nil
+}
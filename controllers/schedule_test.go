@@ -3,6 +3,10 @@ package controllers
 import (
 	"testing"
 	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCalculateActiveAndUpcomingRecurringPeriods(t *testing.T) {
@@ -605,3 +609,200 @@ func parseAndMatchRecurringPeriod(now time.Time, start, end, frequency, until st
 
 	return MatchSchedule(now, startTime, endTime, RecurrenceRule{Frequency: frequency, UntilTime: untilTime})
 }
+
+func TestMatchCronSchedule(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		description  string
+		now          string
+		schedule     string
+		duration     time.Duration
+		loc          *time.Location
+		wantActive   string
+		wantUpcoming string
+		wantErr      bool
+	}{
+		{
+			description:  "business hours on a weekday",
+			now:          "2021-05-05T10:00:00Z",
+			schedule:     "0 9 * * 1-5",
+			duration:     9 * time.Hour,
+			loc:          time.UTC,
+			wantActive:   "2021-05-05T09:00:00Z-2021-05-05T18:00:00Z",
+			wantUpcoming: "2021-05-06T09:00:00Z-2021-05-06T18:00:00Z",
+		},
+		{
+			description:  "business hours on friday evening",
+			now:          "2021-05-07T19:00:00Z",
+			schedule:     "0 9 * * mon-fri",
+			duration:     9 * time.Hour,
+			loc:          time.UTC,
+			wantUpcoming: "2021-05-10T09:00:00Z-2021-05-10T18:00:00Z",
+		},
+		{
+			description:  "right on the start",
+			now:          "2021-05-05T09:00:00Z",
+			schedule:     "0 9 * * *",
+			duration:     time.Hour,
+			loc:          time.UTC,
+			wantActive:   "2021-05-05T09:00:00Z-2021-05-05T10:00:00Z",
+			wantUpcoming: "2021-05-06T09:00:00Z-2021-05-06T10:00:00Z",
+		},
+		{
+			description:  "right on the end",
+			now:          "2021-05-05T10:00:00Z",
+			schedule:     "0 9 * * *",
+			duration:     time.Hour,
+			loc:          time.UTC,
+			wantUpcoming: "2021-05-06T09:00:00Z-2021-05-06T10:00:00Z",
+		},
+		{
+			description:  "overnight window spanning midnight",
+			now:          "2021-05-06T02:00:00Z",
+			schedule:     "0 20 * * *",
+			duration:     12 * time.Hour,
+			loc:          time.UTC,
+			wantActive:   "2021-05-05T20:00:00Z-2021-05-06T08:00:00Z",
+			wantUpcoming: "2021-05-06T20:00:00Z-2021-05-07T08:00:00Z",
+		},
+		{
+			description:  "every 15 minutes",
+			now:          "2021-05-05T10:20:00Z",
+			schedule:     "*/15 * * * *",
+			duration:     5 * time.Minute,
+			loc:          time.UTC,
+			wantUpcoming: "2021-05-05T10:30:00Z-2021-05-05T10:35:00Z",
+		},
+		{
+			description:  "same local time across the DST transition",
+			now:          "2021-03-13T20:00:00Z",
+			schedule:     "0 9 * * *",
+			duration:     time.Hour,
+			loc:          newYork,
+			wantUpcoming: "2021-03-14T09:00:00-04:00-2021-03-14T10:00:00-04:00",
+		},
+		{
+			description: "both day of month and day of week",
+			now:         "2021-05-05T10:00:00Z",
+			schedule:    "0 9 1 * 1",
+			duration:    time.Hour,
+			loc:         time.UTC,
+			wantErr:     true,
+		},
+		{
+			description: "missing duration",
+			now:         "2021-05-05T10:00:00Z",
+			schedule:    "0 9 * * *",
+			loc:         time.UTC,
+			wantErr:     true,
+		},
+		{
+			description: "too few fields",
+			now:         "2021-05-05T10:00:00Z",
+			schedule:    "0 9 * *",
+			duration:    time.Hour,
+			loc:         time.UTC,
+			wantErr:     true,
+		},
+		{
+			description: "out of range",
+			now:         "2021-05-05T10:00:00Z",
+			schedule:    "0 24 * * *",
+			duration:    time.Hour,
+			loc:         time.UTC,
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tc.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			active, upcoming, err := MatchCronSchedule(now, tc.schedule, tc.duration, tc.loc)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if active.String() != tc.wantActive {
+				t.Errorf("unexpected active: want %q, got %q", tc.wantActive, active)
+			}
+
+			if upcoming.String() != tc.wantUpcoming {
+				t.Errorf("unexpected upcoming: want %q, got %q", tc.wantUpcoming, upcoming)
+			}
+		})
+	}
+}
+
+func TestMatchScheduledOverrides(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now, err := time.Parse(time.RFC3339, "2021-05-08T10:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hra := v1alpha1.HorizontalRunnerAutoscaler{
+		Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+			MinReplicas: intPtr(2),
+			MaxReplicas: intPtr(10),
+			ScheduledOverrides: []v1alpha1.ScheduledOverride{
+				{
+					// Saturdays and Sundays in Tokyo
+					Schedule:    "0 0 * * sat",
+					Duration:    metav1.Duration{Duration: 48 * time.Hour},
+					TimeZone:    "Asia/Tokyo",
+					MinReplicas: intPtr(0),
+					MaxReplicas: intPtr(0),
+				},
+				{
+					StartTime:   metav1.Time{Time: now.Add(-time.Hour)},
+					EndTime:     metav1.Time{Time: now.Add(time.Hour)},
+					MinReplicas: intPtr(5),
+				},
+			},
+		},
+	}
+
+	r := &HorizontalRunnerAutoscalerReconciler{}
+
+	minReplicas, active, upcoming, err := r.getMinReplicas(logr.Discard(), now, hra)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if minReplicas != 0 {
+		t.Errorf("unexpected min replicas: want 0, got %d", minReplicas)
+	}
+
+	if got := getMaxReplicas(hra, active); got == nil || *got != 0 {
+		t.Errorf("unexpected max replicas: want 0, got %v", got)
+	}
+
+	// The weekend in Tokyo ends at 2021-05-09T15:00:00Z
+	if got, want := nextScheduledOverrideBoundary(now, active, upcoming), 29*time.Hour; got != want {
+		t.Errorf("unexpected requeue: want %s, got %s", want, got)
+	}
+
+	hra.Spec.ScheduledOverrides[0].TimeZone = "Mars/Olympus_Mons"
+
+	if _, _, _, err := r.getMinReplicas(logr.Discard(), now, hra); err == nil {
+		t.Errorf("expected error on invalid time zone, got none")
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard is the share of the HorizontalRunnerAutoscalers reconciled by a controller replica,
+// so that the reconciliations and the GitHub API calls of a large installation are spread across replicas.
+//
+// Each shard elects its own leader, and the HRAs are assigned to the shards by the consistent hash of their namespaces and names,
+// or by the label selector of the shard when it's set. The other controllers and the periodic tasks run only on the primary shard,
+// which is the shard at index 0.
+// A nil Shard is the only shard, which reconciles all the HRAs.
+type Shard struct {
+	Count int
+	Index int
+
+	// Selector assigns the HRAs matching it to the shard instead of the hash, if any.
+	Selector labels.Selector
+}
+
+// NewShard returns the shard at the index of count shards, which owns the HRAs matching the selector if it's not empty.
+// It returns nil when there's only one shard without any selector.
+func NewShard(count, index int, selector string) (*Shard, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("the number of shards must be 1 or greater, but got %d", count)
+	}
+
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("the shard index must be 0 or greater and less than the number of shards %d, but got %d", count, index)
+	}
+
+	if count == 1 && selector == "" {
+		return nil, nil
+	}
+
+	s := &Shard{Count: count, Index: index}
+
+	if selector != "" {
+		sel, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing shard selector %q: %w", selector, err)
+		}
+
+		s.Selector = sel
+	}
+
+	return s, nil
+}
+
+// Owns returns true if the object is assigned to the shard.
+func (s *Shard) Owns(obj metav1.Object) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.Selector != nil {
+		return s.Selector.Matches(labels.Set(obj.GetLabels()))
+	}
+
+	return shardIndexOf(obj.GetNamespace()+"/"+obj.GetName(), s.Count) == s.Index
+}
+
+// Primary returns true if the shard runs the controllers and the tasks other than the HorizontalRunnerAutoscaler controller.
+func (s *Shard) Primary() bool {
+	return s == nil || s.Index == 0
+}
+
+// LeaderElectionID returns the leader election ID of the shard, so that a leader is elected per shard.
+func (s *Shard) LeaderElectionID(id string) string {
+	if s == nil {
+		return id
+	}
+
+	return fmt.Sprintf("%s-shard-%d", id, s.Index)
+}
+
+// Predicate filters the events of the objects assigned to the other shards.
+func (s *Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Owns(obj)
+	})
+}
+
+// PrimaryManager returns the manager the controllers and the tasks run only by the primary shard are added to.
+// On the other shards, the controllers and the tasks added to it are never started,
+// while e.g. the webhooks and the field indexes are still set up as usual.
+func (s *Shard) PrimaryManager(mgr manager.Manager) manager.Manager {
+	if s.Primary() {
+		return mgr
+	}
+
+	return secondaryShardManager{Manager: mgr}
+}
+
+type secondaryShardManager struct {
+	manager.Manager
+}
+
+// Add drops the runnable, which is run by the primary shard.
+func (m secondaryShardManager) Add(manager.Runnable) error {
+	return nil
+}
+
+// shardIndexOf returns the shard of the key among count shards by the jump consistent hash,
+// which moves only about 1/count of the keys when a shard is added.
+func shardIndexOf(key string, count int) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	k := h.Sum64()
+
+	var b, j int64 = -1, 0
+	for j < int64(count) {
+		b = j
+		k = k*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((k>>33)+1)))
+	}
+
+	return int(b)
+}
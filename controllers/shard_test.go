@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewShard(t *testing.T) {
+	testcases := []struct {
+		count, index int
+		selector     string
+
+		wantNil bool
+		wantErr bool
+	}{
+		{count: 1, index: 0, wantNil: true},
+		{count: 3, index: 2},
+		{count: 1, index: 0, selector: "shard=large"},
+		{count: 0, index: 0, wantErr: true},
+		{count: 3, index: 3, wantErr: true},
+		{count: 3, index: -1, wantErr: true},
+		{count: 2, index: 0, selector: "shard in (", wantErr: true},
+	}
+
+	for i, tc := range testcases {
+		s, err := NewShard(tc.count, tc.index, tc.selector)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("[%d] expected an error", i)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if (s == nil) != tc.wantNil {
+			t.Errorf("[%d] unexpected shard: %+v", i, s)
+		}
+	}
+}
+
+func TestShard_Owns(t *testing.T) {
+	hra := func(name string, labels map[string]string) *v1alpha1.HorizontalRunnerAutoscaler {
+		return &v1alpha1.HorizontalRunnerAutoscaler{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+
+	t.Run("the only shard", func(t *testing.T) {
+		var s *Shard
+
+		if !s.Owns(hra("example", nil)) || !s.Primary() {
+			t.Errorf("the nil shard must own every HRA and be primary")
+		}
+
+		if got := s.LeaderElectionID("actions-runner-controller"); got != "actions-runner-controller" {
+			t.Errorf("unexpected leader election ID: %s", got)
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		const count = 4
+
+		shards := make([]*Shard, count)
+		for i := range shards {
+			s, err := NewShard(count, i, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shards[i] = s
+		}
+
+		owned := make([]int, count)
+
+		for i := 0; i < 400; i++ {
+			obj := hra(fmt.Sprintf("hra-%d", i), nil)
+
+			var owners int
+			for j, s := range shards {
+				if s.Owns(obj) {
+					owners++
+					owned[j]++
+				}
+			}
+
+			if owners != 1 {
+				t.Fatalf("%s is owned by %d shards", obj.Name, owners)
+			}
+		}
+
+		for j, n := range owned {
+			if n < 50 {
+				t.Errorf("shard %d owns only %d of 400 HRAs", j, n)
+			}
+		}
+
+		if got := shards[2].LeaderElectionID("actions-runner-controller"); got != "actions-runner-controller-shard-2" {
+			t.Errorf("unexpected leader election ID: %s", got)
+		}
+
+		if !shards[0].Primary() || shards[1].Primary() {
+			t.Errorf("only the shard at index 0 must be primary")
+		}
+	})
+
+	t.Run("selector", func(t *testing.T) {
+		s, err := NewShard(2, 1, "shard=large")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !s.Owns(hra("example", map[string]string{"shard": "large"})) {
+			t.Errorf("the HRA matching the selector must be owned")
+		}
+
+		if s.Owns(hra("example", nil)) {
+			t.Errorf("the HRA not matching the selector must not be owned")
+		}
+	})
+}
+
+func TestShardIndexOf_Consistent(t *testing.T) {
+	var moved int
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("default/hra-%d", i)
+
+		before, after := shardIndexOf(key, 4), shardIndexOf(key, 5)
+		if before != after {
+			if after != 4 {
+				t.Fatalf("%s moved from shard %d to the existing shard %d", key, before, after)
+			}
+
+			moved++
+		}
+	}
+
+	if moved > 300 {
+		t.Errorf("too many keys moved by adding a shard: %d of 1000", moved)
+	}
+}
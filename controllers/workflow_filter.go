@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/actionsglob"
+)
+
+// maxCachedWorkflowPaths bounds the number of the workflow paths cached for filtering workflow jobs by workflows.
+const maxCachedWorkflowPaths = 1000
+
+// matchWorkflow returns true when either the name or the path of the workflow matches any of the patterns.
+// The patterns are GitHub Actions glob patterns like `.github/workflows/deploy-*.yml` or `Deploy *`.
+func matchWorkflow(patterns []string, name, path string) bool {
+	for _, pat := range patterns {
+		if pat == "" {
+			continue
+		}
+
+		if name != "" && actionsglob.Match(pat, name) {
+			return true
+		}
+
+		if path != "" && actionsglob.Match(pat, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchBranch returns true when the branch matches one of the GitHub Actions glob patterns, like `main` and `release/*`.
+func matchBranch(patterns []string, branch string) bool {
+	if branch == "" {
+		return false
+	}
+
+	for _, pat := range patterns {
+		if pat != "" && actionsglob.Match(pat, branch) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// workflowPathCache caches the paths of the workflows by the workflow IDs, so that
+// filtering workflow jobs by the workflow paths doesn't cost a GitHub API call per job.
+type workflowPathCache struct {
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+// get returns the path of the workflow like `.github/workflows/deploy.yml`.
+func (c *workflowPathCache) get(ctx context.Context, ghc *github.Client, owner, repo string, workflowID int64) (string, error) {
+	key := fmt.Sprintf("%s/%s/%d", owner, repo, workflowID)
+
+	c.mu.Lock()
+	path, ok := c.paths[key]
+	c.mu.Unlock()
+
+	if ok {
+		return path, nil
+	}
+
+	workflow, _, err := ghc.Actions.GetWorkflowByID(ctx, owner, repo, workflowID)
+	if err != nil {
+		return "", fmt.Errorf("getting workflow %d of %s/%s: %w", workflowID, owner, repo, err)
+	}
+
+	path = workflow.GetPath()
+
+	c.mu.Lock()
+	if c.paths == nil || len(c.paths) >= maxCachedWorkflowPaths {
+		c.paths = map[string]string{}
+	}
+	c.paths[key] = path
+	c.mu.Unlock()
+
+	return path, nil
+}
+
+// matchWorkflowRun returns true when the workflow of the run matches any of the patterns.
+// The workflow path is fetched only when the workflow name doesn't match.
+func (c *workflowPathCache) matchWorkflowRun(ctx context.Context, ghc *github.Client, owner, repo string, patterns []string, name string, workflowID int64) (bool, error) {
+	if matchWorkflow(patterns, name, "") {
+		return true, nil
+	}
+
+	if ghc == nil || workflowID == 0 {
+		return false, nil
+	}
+
+	path, err := c.get(ctx, ghc, owner, repo, workflowID)
+	if err != nil {
+		return false, err
+	}
+
+	return matchWorkflow(patterns, "", path), nil
+}
+
+// jobWorkflow is the workflow of the job of a workflow_job event, which is matched against the workflows of the workflowJob scale-up triggers.
+// The workflow path, and the workflow name when the payload lacks it, are fetched only when needed,
+// as they cost GitHub API calls.
+type jobWorkflow struct {
+	owner, repo string
+	runID       int64
+	name        string
+
+	ghc   *github.Client
+	paths *workflowPathCache
+
+	workflowID int64
+}
+
+// workflowJobPayload is the part of the workflow_job event payload that isn't parsed by go-github.
+type workflowJobPayload struct {
+	WorkflowJob struct {
+		WorkflowName string `json:"workflow_name"`
+		RunnerName   string `json:"runner_name"`
+	} `json:"workflow_job"`
+}
+
+func (w *jobWorkflow) match(ctx context.Context, patterns []string) (bool, error) {
+	if matchWorkflow(patterns, w.name, "") {
+		return true, nil
+	}
+
+	if w.ghc == nil || w.runID == 0 {
+		return false, nil
+	}
+
+	if w.workflowID == 0 {
+		run, _, err := w.ghc.Actions.GetWorkflowRunByID(ctx, w.owner, w.repo, w.runID)
+		if err != nil {
+			return false, fmt.Errorf("getting workflow run %d of %s/%s: %w", w.runID, w.owner, w.repo, err)
+		}
+
+		w.workflowID = run.GetWorkflowID()
+
+		if w.name == "" {
+			w.name = run.GetName()
+
+			if matchWorkflow(patterns, w.name, "") {
+				return true, nil
+			}
+		}
+	}
+
+	return w.paths.matchWorkflowRun(ctx, w.ghc, w.owner, w.repo, patterns, "", w.workflowID)
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/go-logr/logr"
+)
+
+func TestMatchWorkflow(t *testing.T) {
+	testcases := []struct {
+		patterns   []string
+		name, path string
+		want       bool
+	}{
+		{patterns: []string{".github/workflows/deploy-*.yml"}, name: "Deploy", path: ".github/workflows/deploy-prod.yml", want: true},
+		{patterns: []string{".github/workflows/deploy-*.yml"}, name: "CI", path: ".github/workflows/ci.yml", want: false},
+		{patterns: []string{"Deploy*"}, name: "Deploy to production", want: true},
+		{patterns: []string{"Deploy*"}, name: "CI", want: false},
+		{patterns: []string{"CI", ".github/workflows/deploy-*.yml"}, name: "CI", want: true},
+		{patterns: []string{""}, name: "CI", path: ".github/workflows/ci.yml", want: false},
+		{patterns: nil, name: "CI", want: false},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(fmt.Sprintf("%v %s %s", tc.patterns, tc.name, tc.path), func(t *testing.T) {
+			if got := matchWorkflow(tc.patterns, tc.name, tc.path); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchBranch(t *testing.T) {
+	testcases := []struct {
+		patterns []string
+		branch   string
+		want     bool
+	}{
+		{patterns: []string{"main"}, branch: "main", want: true},
+		{patterns: []string{"main"}, branch: "maintenance", want: false},
+		{patterns: []string{"main", "release/*"}, branch: "release/v1", want: true},
+		{patterns: []string{"!main"}, branch: "feature/x", want: true},
+		{patterns: []string{"main"}, branch: "", want: false},
+		{patterns: []string{""}, branch: "main", want: false},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(fmt.Sprintf("%v %s", tc.patterns, tc.branch), func(t *testing.T) {
+			if got := matchBranch(tc.patterns, tc.branch); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCountWorkflowJobs_Workflows(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	var workflowRequests int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/valid/actions/runs", func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("status") {
+		case "queued":
+			fmt.Fprint(w, `{"total_count": 2, "workflow_runs": [
+				{"id": 1, "name": "Deploy", "workflow_id": 10, "head_branch": "main", "status": "queued"},
+				{"id": 2, "name": "CI", "workflow_id": 20, "head_branch": "feature/x", "status": "queued"}
+			]}`)
+		default:
+			fmt.Fprint(w, `{"total_count": 0, "workflow_runs": []}`)
+		}
+	})
+	mux.HandleFunc("/repos/test/valid/actions/runs/1/jobs", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"jobs": [{"status": "queued", "labels": ["self-hosted"]}, {"status": "queued", "labels": ["self-hosted"]}]}`)
+	})
+	mux.HandleFunc("/repos/test/valid/actions/runs/2/jobs", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"jobs": [{"status": "queued", "labels": ["self-hosted"]}]}`)
+	})
+	mux.HandleFunc("/repos/test/valid/actions/workflows/10", func(w http.ResponseWriter, req *http.Request) {
+		workflowRequests++
+		fmt.Fprint(w, `{"id": 10, "name": "Deploy", "path": ".github/workflows/deploy-prod.yml"}`)
+	})
+	mux.HandleFunc("/repos/test/valid/actions/workflows/20", func(w http.ResponseWriter, req *http.Request) {
+		workflowRequests++
+		fmt.Fprint(w, `{"id": 20, "name": "CI", "path": ".github/workflows/ci.yml"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testcases := []struct {
+		description          string
+		workflows            []string
+		branches             []string
+		wantQueued           int
+		wantWorkflowRequests int
+	}{
+		{
+			description: "no filter",
+			wantQueued:  3,
+		},
+		{
+			description:          "by path",
+			workflows:            []string{".github/workflows/deploy-*.yml"},
+			wantQueued:           2,
+			wantWorkflowRequests: 2,
+		},
+		{
+			description: "by name",
+			workflows:   []string{"CI"},
+			wantQueued:  1,
+			// The path of the Deploy workflow is fetched as its name doesn't match, which is then cached.
+			wantWorkflowRequests: 1,
+		},
+		{
+			description: "by branch",
+			branches:    []string{"main", "release/*"},
+			wantQueued:  2,
+		},
+		{
+			description: "by negated branch",
+			branches:    []string{"!main"},
+			wantQueued:  1,
+		},
+		{
+			description: "by workflow and branch",
+			workflows:   []string{"CI"},
+			branches:    []string{"main"},
+			wantQueued:  0,
+			// The CI workflow is skipped by its branch before its name is matched.
+			wantWorkflowRequests: 1,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			workflowRequests = 0
+
+			h := &HorizontalRunnerAutoscalerReconciler{
+				Log:          logr.Discard(),
+				GitHubClient: newGithubClient(server),
+			}
+
+			metrics := &v1alpha1.MetricSpec{
+				Type:      v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+				Workflows: tc.workflows,
+				Branches:  tc.branches,
+			}
+
+			st := scaleTarget{repo: "test/valid", replicas: intPtr(1)}
+
+			counts, err := h.countWorkflowJobs(st, v1alpha1.HorizontalRunnerAutoscaler{}, metrics)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if counts.queued != tc.wantQueued {
+				t.Errorf("incorrect queued workflow jobs: want %d, got %d", tc.wantQueued, counts.queued)
+			}
+
+			if _, err := h.countWorkflowJobs(st, v1alpha1.HorizontalRunnerAutoscaler{}, metrics); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if workflowRequests != tc.wantWorkflowRequests {
+				t.Errorf("incorrect number of workflow requests: want %d, got %d", tc.wantWorkflowRequests, workflowRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// AuthConfig is the part of Config that authenticates the client.
+// Unlike the rest of Config, it can be replaced without recreating the client via Client.ReloadAuth,
+// e.g. when the credentials are rotated in an external secret store.
+type AuthConfig struct {
+	Token             string `json:"token,omitempty"`
+	AppID             int64  `json:"appID,omitempty"`
+	AppInstallationID int64  `json:"appInstallationID,omitempty"`
+	AppPrivateKey     string `json:"appPrivateKey,omitempty"`
+	BasicauthUsername string `json:"basicauthUsername,omitempty"`
+	BasicauthPassword string `json:"basicauthPassword,omitempty"`
+}
+
+// Auth returns the credentials of the config.
+func (c *Config) Auth() AuthConfig {
+	return AuthConfig{
+		Token:             c.Token,
+		AppID:             c.AppID,
+		AppInstallationID: c.AppInstallationID,
+		AppPrivateKey:     c.AppPrivateKey,
+		BasicauthUsername: c.BasicauthUsername,
+		BasicauthPassword: c.BasicauthPassword,
+	}
+}
+
+// SetAuth replaces all the credentials of the config with a, including the ones a leaves empty.
+func (c *Config) SetAuth(a AuthConfig) {
+	c.Token = a.Token
+	c.AppID = a.AppID
+	c.AppInstallationID = a.AppInstallationID
+	c.AppPrivateKey = a.AppPrivateKey
+	c.BasicauthUsername = a.BasicauthUsername
+	c.BasicauthPassword = a.BasicauthPassword
+}
+
+// reloadableAuthTransport authenticates the requests with the latest credentials given to reload.
+type reloadableAuthTransport struct {
+	mu        sync.RWMutex
+	config    Config
+	transport http.RoundTripper
+}
+
+func newReloadableAuthTransport(c Config) (*reloadableAuthTransport, error) {
+	transport, err := newAuthTransport(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reloadableAuthTransport{config: c, transport: transport}, nil
+}
+
+func (t *reloadableAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	transport := t.transport
+	t.mu.RUnlock()
+
+	return transport.RoundTrip(req)
+}
+
+func (t *reloadableAuthTransport) reload(a AuthConfig) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.config.Auth() == a {
+		return false, nil
+	}
+
+	c := t.config
+	c.SetAuth(a)
+
+	transport, err := newAuthTransport(c)
+	if err != nil {
+		return false, err
+	}
+
+	t.config = c
+	t.transport = transport
+
+	return true, nil
+}
+
+// ReloadAuth replaces the credentials of the client with a, if they differ from the current ones.
+// The requests made afterwards are authenticated with the new credentials, while the ones in flight complete with the old ones.
+// The current credentials are kept when the new ones are invalid.
+// It returns true when the credentials are replaced.
+func (c *Client) ReloadAuth(a AuthConfig) (bool, error) {
+	if c.auth == nil {
+		return false, fmt.Errorf("the credentials of the client can't be reloaded")
+	}
+
+	return c.auth.reload(a)
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ConcurrencyLimitTransport is a http.RoundTripper that limits the number of the in-flight requests,
+// so that bursts of reconciliations don't hit the secondary rate limits GitHub applies to concurrent requests.
+// A request is in flight until the body of its response is closed.
+type ConcurrencyLimitTransport struct {
+	Transport http.RoundTripper
+
+	slots chan struct{}
+}
+
+// NewConcurrencyLimitTransport returns a ConcurrencyLimitTransport that lets at most limit requests be in flight at once.
+func NewConcurrencyLimitTransport(transport http.RoundTripper, limit int) *ConcurrencyLimitTransport {
+	return &ConcurrencyLimitTransport{
+		Transport: transport,
+		slots:     make(chan struct{}, limit),
+	}
+}
+
+func (t *ConcurrencyLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil || res.Body == nil {
+		<-t.slots
+		return res, err
+	}
+
+	res.Body = &releasingBody{ReadCloser: res.Body, release: func() { <-t.slots }}
+
+	return res, nil
+}
+
+// releasingBody releases the slot of the request once the response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// DryRunTransport is a http.RoundTripper that never sends the requests mutating anything on GitHub,
+// like removing runners and re-running jobs, and answers them with 204 No Content instead.
+// The registration tokens are still issued, as issuing one changes nothing until a runner registers with it.
+type DryRunTransport struct {
+	Transport http.RoundTripper
+
+	Log *logr.Logger
+}
+
+func (t DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isMutatingRequest(req) {
+		return t.Transport.RoundTrip(req)
+	}
+
+	if t.Log != nil {
+		t.Log.Info("Dry run: would send GitHub API request", "method", req.Method, "url", req.URL.String())
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      req.Proto,
+		ProtoMajor: req.ProtoMajor,
+		ProtoMinor: req.ProtoMinor,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// isMutatingRequest returns true when the request may change anything on GitHub other than issuing a registration token.
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return !strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/registration-token")
+}
@@ -88,6 +88,12 @@ func NewServer(opts ...Option) *httptest.Server {
 	}
 
 	routes := map[string]http.Handler{
+		// For the repository accessibility check of RunnerDeployments
+		"/repos/test/valid": &Handler{
+			Status: http.StatusOK,
+			Body:   `{"full_name": "test/valid"}`,
+		},
+
 		// For CreateRegistrationToken
 		"/repos/test/valid/actions/runners/registration-token": &Handler{
 			Status: http.StatusCreated,
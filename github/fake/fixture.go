@@ -0,0 +1,98 @@
+package fake
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/fixture"
+)
+
+// ReplayHandler serves the recorded responses of a fixture.
+//
+// Requests are matched by the method, the path, and the query.
+// When the same request was recorded more than once, the responses are replayed in the recorded order
+// and the last one is repeated once all of them are consumed.
+// Unmatched requests result in 404 and are remembered for assertions via Unmatched.
+type ReplayHandler struct {
+	mu        sync.Mutex
+	responses map[string][]fixture.Interaction
+	served    map[string]int
+	unmatched []string
+
+	// BaseURL is prepended to the URLs in the recorded Link headers so that
+	// paginated requests are sent to the replay server.
+	BaseURL string
+}
+
+// NewReplayHandler creates a ReplayHandler that serves the interactions recorded in the fixture.
+func NewReplayHandler(f *fixture.Fixture) *ReplayHandler {
+	h := &ReplayHandler{
+		responses: map[string][]fixture.Interaction{},
+		served:    map[string]int{},
+	}
+
+	for _, i := range f.Interactions {
+		h.responses[i.Key()] = append(h.responses[i.Key()], i)
+	}
+
+	return h
+}
+
+func (h *ReplayHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	key := fixture.Key(req.Method, req.URL.Path, req.URL.RawQuery)
+
+	h.mu.Lock()
+	recorded := h.responses[key]
+	n := h.served[key]
+	if len(recorded) == 0 {
+		h.unmatched = append(h.unmatched, key)
+	} else {
+		h.served[key] = n + 1
+	}
+	h.mu.Unlock()
+
+	if len(recorded) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"message": "no recorded interaction for %s"}`, key)
+		return
+	}
+
+	if n >= len(recorded) {
+		n = len(recorded) - 1
+	}
+
+	i := recorded[n]
+
+	for k, v := range i.Header {
+		if k == "Link" {
+			v = strings.ReplaceAll(v, "<"+fixture.LinkPlaceholder, "<"+h.BaseURL)
+		}
+		w.Header().Set(k, v)
+	}
+
+	w.WriteHeader(i.Status)
+	io.WriteString(w, i.Body)
+}
+
+// Unmatched returns the keys of the requests that had no recorded interaction.
+func (h *ReplayHandler) Unmatched() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string{}, h.unmatched...)
+}
+
+// NewReplayServer creates a fake server that replays the interactions recorded in the fixture.
+func NewReplayServer(f *fixture.Fixture) (*httptest.Server, *ReplayHandler) {
+	h := NewReplayHandler(f)
+
+	server := httptest.NewServer(h)
+
+	h.BaseURL = server.URL
+
+	return server, h
+}
@@ -23,6 +23,23 @@ func WithListRepositoryWorkflowRunsResponse(status int, body, queued, in_progres
 	}
 }
 
+// WithListRepositoryWaitingWorkflowRunsResponse adds the responses to the waiting and pending workflow runs.
+// It must follow WithListRepositoryWorkflowRunsResponse, whose body is returned for the statuses without responses.
+func WithListRepositoryWaitingWorkflowRunsResponse(waiting, pending string) Option {
+	return func(c *ServerConfig) {
+		h := c.FixedResponses.ListRepositoryWorkflowRuns
+		if h == nil {
+			h = &Handler{Status: 200}
+			c.FixedResponses.ListRepositoryWorkflowRuns = h
+		}
+		if h.Statuses == nil {
+			h.Statuses = map[string]string{}
+		}
+		h.Statuses["waiting"] = waiting
+		h.Statuses["pending"] = pending
+	}
+}
+
 func WithListWorkflowJobsResponse(status int, bodies map[int]string) Option {
 	return func(c *ServerConfig) {
 		c.FixedResponses.ListWorkflowJobs = &MapHandler{
@@ -0,0 +1,592 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/gorilla/mux"
+)
+
+// Response is a canned response served by a Scenario.
+type Response struct {
+	Status int
+	Header map[string]string
+	Body   string
+
+	// Latency delays the response, which is useful for testing timeouts and concurrent requests.
+	Latency time.Duration
+
+	// CloseConnection closes the connection without writing any response, which results in a transport error on the client.
+	CloseConnection bool
+
+	passthrough bool
+}
+
+// Delayed returns a copy of the response that is served after the latency.
+func (r Response) Delayed(latency time.Duration) Response {
+	r.Latency = latency
+	return r
+}
+
+// JSONResponse returns the response with v encoded in JSON as the body.
+func JSONResponse(status int, v interface{}) Response {
+	j, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return Response{Status: status, Body: string(j)}
+}
+
+// ErrorResponse returns the response with the error message in the format of GitHub API.
+func ErrorResponse(status int, message string) Response {
+	return JSONResponse(status, map[string]string{"message": message})
+}
+
+// RateLimited returns the response GitHub API responds with once the primary rate limit is exhausted.
+// Note that go-github refuses to send any request until reset once it has seen the response,
+// so reset should be in the past unless the test is meant to stay rate-limited.
+func RateLimited(reset time.Time) Response {
+	r := ErrorResponse(http.StatusForbidden, "API rate limit exceeded for installation.")
+	r.Header = map[string]string{
+		"X-RateLimit-Limit":     "5000",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+	}
+	return r
+}
+
+// SecondaryRateLimited returns the response GitHub API responds with when the secondary rate limit is hit.
+func SecondaryRateLimited(retryAfter time.Duration) Response {
+	r := JSONResponse(http.StatusForbidden, map[string]string{
+		"message":           "You have exceeded a secondary rate limit. Please wait a few minutes before you try again.",
+		"documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#abuse-rate-limits",
+	})
+	r.Header = map[string]string{
+		"Retry-After": strconv.Itoa(int(retryAfter.Seconds())),
+	}
+	return r
+}
+
+// Passthrough returns the response that is served from the runners and the workflow runs of the scenario,
+// as if no response was scripted for the request.
+func Passthrough() Response {
+	return Response{passthrough: true}
+}
+
+// Request is a request received by a Scenario.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   string
+}
+
+type scenarioRoute struct {
+	method    string
+	pattern   string
+	responses []Response
+	served    int
+}
+
+type scenarioJob struct {
+	job        *github.WorkflowJob
+	runnerName string
+}
+
+// Scenario is a fake GitHub API server whose responses can be scripted per request and whose state
+// can be changed between reconciliations.
+//
+// The runners and the workflow runs added to the scenario are served by the runner, workflow run and workflow job endpoints,
+// with the pagination and the status filters applied. Responses scripted via On take precedence over the state,
+// which is useful for injecting errors, rate limits and latencies.
+// Every request is recorded for assertions.
+type Scenario struct {
+	mu sync.Mutex
+
+	routes   []*scenarioRoute
+	requests []Request
+	perPage  int
+
+	runners      []*github.Runner
+	nextRunnerID int64
+
+	runs map[string][]*github.WorkflowRun
+	jobs map[int64][]*scenarioJob
+
+	router *mux.Router
+}
+
+// NewScenario creates a Scenario without any runners and workflow runs.
+func NewScenario() *Scenario {
+	s := &Scenario{
+		nextRunnerID: 1,
+		runs:         map[string][]*github.WorkflowRun{},
+		jobs:         map[int64][]*scenarioJob{},
+	}
+
+	router := mux.NewRouter()
+
+	for _, scope := range []string{"/repos/{owner}/{repo}", "/orgs/{org}", "/enterprises/{enterprise}"} {
+		router.HandleFunc(scope+"/actions/runners", s.handleListRunners).Methods(http.MethodGet)
+		router.HandleFunc(scope+"/actions/runners/registration-token", s.handleCreateRegistrationToken).Methods(http.MethodPost)
+		router.HandleFunc(scope+"/actions/runners/{id}", s.handleRemoveRunner).Methods(http.MethodDelete)
+	}
+
+	router.HandleFunc("/repos/{owner}/{repo}", s.handleGetRepository).Methods(http.MethodGet)
+	router.HandleFunc("/repos/{owner}/{repo}/actions/runs", s.handleListWorkflowRuns).Methods(http.MethodGet)
+	router.HandleFunc("/repos/{owner}/{repo}/actions/runs/{id}/jobs", s.handleListWorkflowJobs).Methods(http.MethodGet)
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeResponse(w, ErrorResponse(http.StatusNotFound, "Not Found"))
+	})
+
+	s.router = router
+
+	return s
+}
+
+// On scripts the responses to the requests matching the method and the path.
+//
+// The path may contain `{name}` segments that match any segment, and a query like `?status=queued`,
+// whose parameters must all be in the request.
+// The responses are served in order, and the last one is repeated once all of them are served.
+// When more than one scripted route matches a request, the one scripted first wins.
+func (s *Scenario) On(method, path string, responses ...Response) *Scenario {
+	if len(responses) == 0 {
+		panic(fmt.Sprintf("no responses scripted for %s %s", method, path))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = append(s.routes, &scenarioRoute{method: method, pattern: path, responses: responses})
+
+	return s
+}
+
+// Paginate limits the number of items per page served from the state of the scenario,
+// regardless of the per_page parameter of the requests, so that the clients need to follow the pagination.
+func (s *Scenario) Paginate(perPage int) *Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.perPage = perPage
+
+	return s
+}
+
+// AddRunners adds the runners to the scenario. Runners without IDs are given unique ones.
+func (s *Scenario) AddRunners(runners ...*github.Runner) *Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range runners {
+		if r.ID == nil {
+			r.ID = github.Int64(s.nextRunnerID)
+		}
+		if r.GetID() >= s.nextRunnerID {
+			s.nextRunnerID = r.GetID() + 1
+		}
+		s.runners = append(s.runners, r)
+	}
+
+	return s
+}
+
+// SetRunnerStatus changes the status and the busyness of the runner.
+func (s *Scenario) SetRunnerStatus(name, status string, busy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.runners {
+		if r.GetName() == name {
+			r.Status = github.String(status)
+			r.Busy = github.Bool(busy)
+		}
+	}
+}
+
+// Runners returns the runners that are registered at the moment.
+func (s *Scenario) Runners() []*github.Runner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*github.Runner{}, s.runners...)
+}
+
+// AddWorkflowRun adds the workflow run of the repository along with its jobs.
+// The run ID of the jobs is set to the ID of the run.
+func (s *Scenario) AddWorkflowRun(owner, repo string, run *github.WorkflowRun, jobs ...*github.WorkflowJob) *Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := owner + "/" + repo
+	s.runs[key] = append(s.runs[key], run)
+
+	for _, j := range jobs {
+		j.RunID = run.ID
+		s.jobs[run.GetID()] = append(s.jobs[run.GetID()], &scenarioJob{job: j})
+	}
+
+	return s
+}
+
+// SetWorkflowRunStatus changes the status of the workflow run, like from queued to in_progress and to completed.
+// The jobs of the run that haven't completed yet are changed to the same status.
+func (s *Scenario) SetWorkflowRunStatus(runID int64, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, runs := range s.runs {
+		for _, r := range runs {
+			if r.GetID() == runID {
+				r.Status = github.String(status)
+			}
+		}
+	}
+
+	for _, j := range s.jobs[runID] {
+		if j.job.GetStatus() != "completed" {
+			j.job.Status = github.String(status)
+		}
+	}
+}
+
+// SetWorkflowJobStatus changes the status of the workflow job and the name of the runner that picked it up.
+func (s *Scenario) SetWorkflowJobStatus(jobID int64, status, runnerName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, jobs := range s.jobs {
+		for _, j := range jobs {
+			if j.job.GetID() == jobID {
+				j.job.Status = github.String(status)
+				j.runnerName = runnerName
+			}
+		}
+	}
+}
+
+// Requests returns the requests received so far in the order they were received.
+func (s *Scenario) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// ResetRequests forgets the requests received so far, which is useful for asserting the requests made per reconciliation.
+func (s *Scenario) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// RequestCount returns the number of the received requests matching the method and the path,
+// which is matched the same way as On.
+func (s *Scenario) RequestCount(method, path string) int {
+	var n int
+
+	for _, req := range s.Requests() {
+		if matchRequest(method, path, req.Method, req.Path, req.Query) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// AssertRequested fails the test unless the scenario received n requests matching the method and the path.
+func (s *Scenario) AssertRequested(t testing.TB, method, path string, n int) {
+	t.Helper()
+
+	if got := s.RequestCount(method, path); got != n {
+		t.Errorf("unexpected number of %s %s requests: want %d, got %d", method, path, n, got)
+	}
+}
+
+// AssertNotRequested fails the test if the scenario received any request matching the method and the path.
+func (s *Scenario) AssertNotRequested(t testing.TB, method, path string) {
+	t.Helper()
+
+	s.AssertRequested(t, method, path, 0)
+}
+
+// GetServer starts the fake server serving the scenario.
+func (s *Scenario) GetServer() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+func (s *Scenario) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body string
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: req.Method, Path: req.URL.Path, Query: req.URL.Query(), Body: body})
+	res, scripted := s.scriptedResponse(req)
+	s.mu.Unlock()
+
+	if !scripted {
+		s.router.ServeHTTP(w, req)
+		return
+	}
+
+	if res.Latency > 0 {
+		select {
+		case <-time.After(res.Latency):
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	if res.CloseConnection {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	if res.passthrough {
+		s.router.ServeHTTP(w, req)
+		return
+	}
+
+	writeResponse(w, res)
+}
+
+// scriptedResponse returns the next response scripted for the request, if any.
+func (s *Scenario) scriptedResponse(req *http.Request) (Response, bool) {
+	for _, r := range s.routes {
+		if !matchRequest(r.method, r.pattern, req.Method, req.URL.Path, req.URL.Query()) {
+			continue
+		}
+
+		n := r.served
+		if n >= len(r.responses) {
+			n = len(r.responses) - 1
+		}
+		r.served++
+
+		return r.responses[n], true
+	}
+
+	return Response{}, false
+}
+
+func matchRequest(method, pattern, reqMethod, reqPath string, reqQuery url.Values) bool {
+	if method != reqMethod {
+		return false
+	}
+
+	path, rawQuery := pattern, ""
+	if i := strings.Index(pattern, "?"); i >= 0 {
+		path, rawQuery = pattern[:i], pattern[i+1:]
+	}
+
+	want := strings.Split(strings.Trim(path, "/"), "/")
+	got := strings.Split(strings.Trim(reqPath, "/"), "/")
+
+	if len(want) != len(got) {
+		return false
+	}
+
+	for i := range want {
+		if strings.HasPrefix(want[i], "{") && strings.HasSuffix(want[i], "}") {
+			continue
+		}
+		if want[i] != got[i] {
+			return false
+		}
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		panic(fmt.Sprintf("invalid query in %s: %v", pattern, err))
+	}
+
+	for k, vs := range query {
+		for _, v := range vs {
+			if !containsString(reqQuery[k], v) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeResponse(w http.ResponseWriter, r Response) {
+	for k, v := range r.Header {
+		w.Header().Set(k, v)
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	io.WriteString(w, r.Body)
+}
+
+// paginate returns the range of the n items to be served for the page requested by req,
+// and sets the Link header pointing to the next page if any.
+// limit caps the number of items per page unless it's zero.
+func paginate(w http.ResponseWriter, req *http.Request, n, limit int) (int, int) {
+	q := req.URL.Query()
+
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+
+	if limit > 0 && limit < perPage {
+		perPage = limit
+	}
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * perPage
+	if start > n {
+		start = n
+	}
+
+	end := start + perPage
+	if end >= n {
+		return start, n
+	}
+
+	next := *req.URL
+	next.Scheme = "http"
+	next.Host = req.Host
+	q.Set("page", strconv.Itoa(page+1))
+	next.RawQuery = q.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+
+	return start, end
+}
+
+func (s *Scenario) handleListRunners(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, end := paginate(w, req, len(s.runners), s.perPage)
+
+	writeResponse(w, JSONResponse(http.StatusOK, github.Runners{
+		TotalCount: len(s.runners),
+		Runners:    s.runners[start:end],
+	}))
+}
+
+func (s *Scenario) handleRemoveRunner(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.runners {
+		if strconv.FormatInt(r.GetID(), 10) == id {
+			s.runners = append(s.runners[:i], s.runners[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeResponse(w, ErrorResponse(http.StatusNotFound, "Not Found"))
+}
+
+func (s *Scenario) handleCreateRegistrationToken(w http.ResponseWriter, req *http.Request) {
+	writeResponse(w, JSONResponse(http.StatusCreated, map[string]string{
+		"token":      RegistrationToken,
+		"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+	}))
+}
+
+func (s *Scenario) handleGetRepository(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	writeResponse(w, JSONResponse(http.StatusOK, map[string]string{"full_name": vars["owner"] + "/" + vars["repo"]}))
+}
+
+func (s *Scenario) handleListWorkflowRuns(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	status := req.URL.Query().Get("status")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []*github.WorkflowRun
+
+	for _, r := range s.runs[vars["owner"]+"/"+vars["repo"]] {
+		if status == "" || r.GetStatus() == status || r.GetConclusion() == status {
+			runs = append(runs, r)
+		}
+	}
+
+	start, end := paginate(w, req, len(runs), s.perPage)
+
+	writeResponse(w, JSONResponse(http.StatusOK, github.WorkflowRuns{
+		TotalCount:   github.Int(len(runs)),
+		WorkflowRuns: runs[start:end],
+	}))
+}
+
+func (s *Scenario) handleListWorkflowJobs(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeResponse(w, ErrorResponse(http.StatusNotFound, "Not Found"))
+		return
+	}
+
+	type workflowJob struct {
+		*github.WorkflowJob
+		RunnerName string `json:"runner_name,omitempty"`
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []workflowJob
+
+	for _, j := range s.jobs[id] {
+		jobs = append(jobs, workflowJob{WorkflowJob: j.job, RunnerName: j.runnerName})
+	}
+
+	start, end := paginate(w, req, len(jobs), s.perPage)
+
+	writeResponse(w, JSONResponse(http.StatusOK, map[string]interface{}{
+		"total_count": len(jobs),
+		"jobs":        jobs[start:end],
+	}))
+}
@@ -0,0 +1,105 @@
+// Package fixture defines the format of the GitHub API interactions recorded by github.RecordingTransport
+// and replayed by the fake GitHub server.
+//
+// It's kept apart from the fake GitHub server so that the controller can record fixtures
+// without linking the fake server into its binary.
+package fixture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Interaction is a single GitHub API request and response pair captured by github.RecordingTransport.
+// Fixtures are stored as JSON lines, one Interaction per line.
+type Interaction struct {
+	Method string `json:"method"`
+	// Path is the request path without the scheme and the host, like `/repos/owner/repo/actions/runs`.
+	Path string `json:"path"`
+	// Query is the raw query string of the request, like `status=queued&per_page=100`.
+	Query string `json:"query,omitempty"`
+
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// Key returns the identifier used for matching a replayed request to recorded interactions.
+func (i Interaction) Key() string {
+	return Key(i.Method, i.Path, i.Query)
+}
+
+// Key returns the identifier of the request used for matching it to recorded interactions.
+// Query parameters are sorted so that the order of parameters doesn't matter.
+func Key(method, path, rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	sort.Strings(params)
+
+	return method + " " + path + "?" + strings.Join(params, "&")
+}
+
+// LinkPlaceholder is written by the recorder in place of the scheme and the host of URLs in Link headers,
+// so that the replay server can point paginated requests to itself.
+const LinkPlaceholder = "{baseURL}"
+
+// Fixture is the sequence of recorded interactions.
+type Fixture struct {
+	Interactions []Interaction
+}
+
+// Load reads a fixture written by github.RecordingTransport.
+func Load(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads a fixture in the JSON lines format.
+func Read(r io.Reader) (*Fixture, error) {
+	var fixture Fixture
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var i Interaction
+		if err := json.Unmarshal([]byte(line), &i); err != nil {
+			return nil, fmt.Errorf("parsing interaction at line %d: %w", n, err)
+		}
+
+		fixture.Interactions = append(fixture.Interactions, i)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &fixture, nil
+}
+
+// Write appends the interaction to w as a line of the JSON lines format.
+// Callers writing from multiple goroutines need to serialize the calls.
+func Write(w io.Writer, i Interaction) error {
+	line, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+const (
+	// ForgeGitHub is the forge of GitHub and GitHub Enterprise Server.
+	ForgeGitHub = "github"
+
+	// ForgeGitea is the experimental forge of Gitea and Forgejo Actions, which serve a GitHub-compatible Actions API
+	// under /api/v1 of the instance.
+	ForgeGitea = "gitea"
+)
+
+// giteaRegistrationTokenTTL is the expiry given to the registration tokens of Gitea, which don't expire until they're reset,
+// so that the client caches them for a while but eventually picks up a reset token.
+const giteaRegistrationTokenTTL = 1 * time.Hour
+
+// forge is the part of the API of the forge that differs between GitHub and the GitHub-compatible forges,
+// i.e. the runner registration and management endpoints.
+// The workflow runs and jobs are listed via the endpoints shared by all the forges.
+type forge interface {
+	createRegistrationToken(ctx context.Context, enterprise, org, repo string) (*github.RegistrationToken, *github.Response, error)
+	removeRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Response, error)
+	listRunners(ctx context.Context, enterprise, org, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error)
+}
+
+func newForge(name string, client *github.Client) (forge, error) {
+	switch name {
+	case "", ForgeGitHub:
+		return githubForge{client: client}, nil
+	case ForgeGitea:
+		return giteaForge{client: client}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported forge %q: it must be either %q or %q", name, ForgeGitHub, ForgeGitea)
+}
+
+// githubForge switches between the enterprise, organization and repository endpoints of GitHub,
+// so the calling functions don't need to switch and their code is a bit cleaner.
+type githubForge struct {
+	client *github.Client
+}
+
+func (f githubForge) createRegistrationToken(ctx context.Context, enterprise, org, repo string) (*github.RegistrationToken, *github.Response, error) {
+	if len(repo) > 0 {
+		return f.client.Actions.CreateRegistrationToken(ctx, org, repo)
+	}
+	if len(org) > 0 {
+		return f.client.Actions.CreateOrganizationRegistrationToken(ctx, org)
+	}
+	return f.client.Enterprise.CreateRegistrationToken(ctx, enterprise)
+}
+
+func (f githubForge) removeRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Response, error) {
+	if len(repo) > 0 {
+		return f.client.Actions.RemoveRunner(ctx, org, repo, runnerID)
+	}
+	if len(org) > 0 {
+		return f.client.Actions.RemoveOrganizationRunner(ctx, org, runnerID)
+	}
+	return f.client.Enterprise.RemoveRunner(ctx, enterprise, runnerID)
+}
+
+func (f githubForge) listRunners(ctx context.Context, enterprise, org, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
+	if len(repo) > 0 {
+		return f.client.Actions.ListRunners(ctx, org, repo, opts)
+	}
+	if len(org) > 0 {
+		return f.client.Actions.ListOrganizationRunners(ctx, org, opts)
+	}
+	return f.client.Enterprise.ListRunners(ctx, enterprise, opts)
+}
+
+// giteaForge calls the runner endpoints of Gitea and Forgejo Actions.
+// There are no enterprises in Gitea, so the enterprise runners are registered to the whole instance,
+// which requires the credentials of an administrator.
+type giteaForge struct {
+	client *github.Client
+}
+
+// giteaRunners is the runners returned by Gitea, whose statuses are either offline, idle or active.
+type giteaRunners struct {
+	TotalCount int              `json:"total_count"`
+	Runners    []*github.Runner `json:"runners"`
+}
+
+func giteaRunnersPath(enterprise, org, repo string) string {
+	if len(repo) > 0 {
+		return fmt.Sprintf("repos/%v/%v/actions/runners", org, repo)
+	}
+	if len(org) > 0 {
+		return fmt.Sprintf("orgs/%v/actions/runners", org)
+	}
+	return "admin/actions/runners"
+}
+
+func (f giteaForge) createRegistrationToken(ctx context.Context, enterprise, org, repo string) (*github.RegistrationToken, *github.Response, error) {
+	u := giteaRunnersPath(enterprise, org, repo) + "/registration-token"
+	if len(repo) == 0 && len(org) == 0 {
+		// The instance-wide endpoint predates the other runner endpoints of the admin API.
+		u = "admin/runners/registration-token"
+	}
+
+	req, err := f.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var token github.RegistrationToken
+	res, err := f.client.Do(ctx, req, &token)
+	if err != nil {
+		return nil, res, err
+	}
+
+	token.ExpiresAt = &github.Timestamp{Time: time.Now().Add(giteaRegistrationTokenTTL)}
+
+	return &token, res, nil
+}
+
+func (f giteaForge) removeRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Response, error) {
+	u := fmt.Sprintf("%s/%d", giteaRunnersPath(enterprise, org, repo), runnerID)
+
+	req, err := f.client.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.client.Do(ctx, req, nil)
+}
+
+func (f giteaForge) listRunners(ctx context.Context, enterprise, org, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
+	page := opts.Page
+	if page == 0 {
+		page = 1
+	}
+
+	u := fmt.Sprintf("%s?page=%d&limit=%d", giteaRunnersPath(enterprise, org, repo), page, opts.PerPage)
+
+	req, err := f.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var list giteaRunners
+	res, err := f.client.Do(ctx, req, &list)
+	if err != nil {
+		return nil, res, err
+	}
+
+	// Gitea reports the online runners as either idle or active, while GitHub reports them as online.
+	for _, r := range list.Runners {
+		if r.GetStatus() != "offline" {
+			r.Status = github.String("online")
+		}
+	}
+
+	return &github.Runners{TotalCount: list.TotalCount, Runners: list.Runners}, res, nil
+}
@@ -0,0 +1,137 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newGiteaTestServer(t *testing.T, requests map[string]int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	for _, path := range []string{
+		"/api/v1/repos/test/valid/actions/runners/registration-token",
+		"/api/v1/orgs/test/actions/runners/registration-token",
+		"/api/v1/admin/runners/registration-token",
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+			requests[req.Method+" "+req.URL.Path]++
+			fmt.Fprint(w, `{"token": "gitea-token"}`)
+		})
+	}
+
+	for _, path := range []string{
+		"/api/v1/repos/test/valid/actions/runners",
+		"/api/v1/orgs/test/actions/runners",
+		"/api/v1/admin/actions/runners",
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+			requests[req.Method+" "+req.URL.Path]++
+			fmt.Fprint(w, `{"total_count": 3, "runners": [
+				{"id": 1, "name": "idle", "status": "idle", "busy": false, "labels": [{"id": 1, "name": "ubuntu-latest", "type": "custom"}]},
+				{"id": 2, "name": "active", "status": "active", "busy": true},
+				{"id": 3, "name": "offline", "status": "offline", "busy": false}
+			]}`)
+		})
+	}
+
+	mux.HandleFunc("/api/v1/repos/test/valid/actions/runners/1", func(w http.ResponseWriter, req *http.Request) {
+		requests[req.Method+" "+req.URL.Path]++
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGiteaForge(t *testing.T) {
+	ctx := context.Background()
+
+	requests := map[string]int{}
+
+	server := newGiteaTestServer(t, requests)
+	defer server.Close()
+
+	client, err := (&Config{Token: "token", URL: server.URL + "/api/v1", Forge: ForgeGitea}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := server.URL + "/"; client.GithubBaseURL != want {
+		t.Errorf("unexpected base url for runners: want %s, got %s", want, client.GithubBaseURL)
+	}
+
+	scopes := []struct {
+		enterprise, org, repo string
+	}{
+		{repo: "test/valid"},
+		{org: "test"},
+		{enterprise: "test"},
+	}
+
+	for _, s := range scopes {
+		rt, err := client.GetRegistrationToken(ctx, s.enterprise, s.org, s.repo, "test")
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", s, err)
+		}
+
+		if rt.GetToken() != "gitea-token" || !rt.GetExpiresAt().After(time.Now().Add(30*time.Minute)) {
+			t.Errorf("%+v: unexpected registration token: %+v", s, rt)
+		}
+
+		runners, err := client.ListRunners(ctx, s.enterprise, s.org, s.repo)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", s, err)
+		}
+
+		var got []string
+		for _, r := range runners {
+			got = append(got, fmt.Sprintf("%s:%s:%v", r.GetName(), r.GetStatus(), r.GetBusy()))
+		}
+
+		if want := "[idle:online:false active:online:true offline:offline:false]"; fmt.Sprint(got) != want {
+			t.Errorf("%+v: unexpected runners: want %s, got %v", s, want, got)
+		}
+	}
+
+	busy, err := client.IsRunnerBusy(ctx, "", "", "test/valid", "active")
+	if err != nil || !busy {
+		t.Errorf("unexpected busy status of the active runner: %v, %v", busy, err)
+	}
+
+	if err := client.RemoveRunner(ctx, "", "", "test/valid", 1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, r := range []string{
+		"GET /api/v1/repos/test/valid/actions/runners/registration-token",
+		"GET /api/v1/orgs/test/actions/runners/registration-token",
+		"GET /api/v1/admin/runners/registration-token",
+		"GET /api/v1/admin/actions/runners",
+		"DELETE /api/v1/repos/test/valid/actions/runners/1",
+	} {
+		if requests[r] != 1 {
+			t.Errorf("unexpected number of requests to %s: want 1, got %d", r, requests[r])
+		}
+	}
+}
+
+func TestNewForge(t *testing.T) {
+	for _, name := range []string{"", ForgeGitHub, ForgeGitea} {
+		if _, err := newForge(name, nil); err != nil {
+			t.Errorf("unexpected error for %q: %v", name, err)
+		}
+	}
+
+	if _, err := newForge("gitlab", nil); err == nil {
+		t.Errorf("expected an error for an unsupported forge")
+	}
+
+	if _, err := (&Config{Token: "token", Forge: ForgeGitea}).NewClient(); err == nil {
+		t.Errorf("expected an error for the gitea forge without the url")
+	}
+}
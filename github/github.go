@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +34,29 @@ type Config struct {
 	BasicauthPassword string `split_words:"true"`
 	RunnerGitHubURL   string `split_words:"true"`
 
+	// RecordFixtures is the path to the file to which every GitHub API interaction is appended
+	// as a sanitized fixture for the fake server. This is intended only for creating regression tests.
+	RecordFixtures string `split_words:"true"`
+
+	// Credentials identifies the credentials of the client in the metrics of the GitHub API rate limits.
+	// Defaults to "default", which is for the controller-wide credentials.
+	Credentials string `ignored:"true"`
+
+	// ResponseCacheTTL is the duration for which the workflow runs and jobs listed by the client are reused
+	// by the subsequent queries for the same repository, workflow run and status. Zero disables it.
+	ResponseCacheTTL time.Duration `split_words:"true" default:"10s"`
+
+	// Forge is the forge that serves the API at URL, either "github" or the experimental "gitea" for Gitea and Forgejo Actions.
+	// Defaults to "github".
+	Forge string
+
+	// MaxConcurrentRequests is the max number of the in-flight requests of the client. Zero means no limit.
+	// See ConcurrencyLimitTransport.
+	MaxConcurrentRequests int `split_words:"true"`
+
+	// DryRun makes the client answer the requests mutating anything on GitHub without sending them. See DryRunTransport.
+	DryRun bool `ignored:"true"`
+
 	Log *logr.Logger
 }
 
@@ -39,9 +64,20 @@ type Config struct {
 type Client struct {
 	*github.Client
 	regTokens map[string]*github.RegistrationToken
-	mu        sync.Mutex
+	// regTokenScopes is the scope each cached registration token was issued for and when it was last requested,
+	// which RefreshRegistrationTokens uses to refresh only the tokens still in use.
+	regTokenScopes map[string]registrationTokenScope
+	mu             sync.Mutex
 	// GithubBaseURL to Github without API suffix.
 	GithubBaseURL string
+	// credentials identifies the credentials of the client in the rate limits tracked by the metrics transport.
+	credentials string
+	// responses caches the workflow runs and jobs listed by the client for Config.ResponseCacheTTL.
+	responses *responseCache
+	// forge calls the runner endpoints of the forge configured via Config.Forge.
+	forge forge
+	// auth authenticates the requests with the credentials that can be replaced via ReloadAuth.
+	auth *reloadableAuthTransport
 }
 
 type BasicAuthTransport struct {
@@ -56,47 +92,47 @@ func (p BasicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 // NewClient creates a Github Client
 func (c *Config) NewClient() (*Client, error) {
-	var transport http.RoundTripper
-	if len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0 {
-		transport = BasicAuthTransport{Username: c.BasicauthUsername, Password: c.BasicauthPassword}
-	} else if len(c.Token) > 0 {
-		transport = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Token})).Transport
-	} else {
-		var tr *ghinstallation.Transport
+	auth, err := newReloadableAuthTransport(*c)
+	if err != nil {
+		return nil, err
+	}
 
-		if _, err := os.Stat(c.AppPrivateKey); err == nil {
-			tr, err = ghinstallation.NewKeyFromFile(http.DefaultTransport, c.AppID, c.AppInstallationID, c.AppPrivateKey)
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key at %s: %v", c.AppPrivateKey, err)
-			}
-		} else {
-			tr, err = ghinstallation.New(http.DefaultTransport, c.AppID, c.AppInstallationID, []byte(c.AppPrivateKey))
-			if err != nil {
-				return nil, fmt.Errorf("authentication failed: using private key of size %d (%s...): %v", len(c.AppPrivateKey), strings.Split(c.AppPrivateKey, "\n")[0], err)
-			}
-		}
+	var transport http.RoundTripper = auth
 
-		if len(c.EnterpriseURL) > 0 {
-			githubAPIURL, err := getEnterpriseApiUrl(c.EnterpriseURL)
-			if err != nil {
-				return nil, fmt.Errorf("enterprise url incorrect: %v", err)
-			}
-			tr.BaseURL = githubAPIURL
-		}
-		transport = tr
+	if c.MaxConcurrentRequests > 0 {
+		transport = NewConcurrencyLimitTransport(transport, c.MaxConcurrentRequests)
 	}
 
 	cached := httpcache.NewTransport(httpcache.NewMemoryCache())
 	cached.Transport = transport
-	loggingTransport := logging.Transport{Transport: cached, Log: c.Log}
-	metricsTransport := metrics.Transport{Transport: loggingTransport}
+
+	var apiTransport http.RoundTripper = cached
+	if len(c.RecordFixtures) > 0 {
+		recorder, err := NewRecordingTransport(cached, c.RecordFixtures)
+		if err != nil {
+			return nil, err
+		}
+		apiTransport = recorder
+	}
+
+	if c.DryRun {
+		apiTransport = DryRunTransport{Transport: apiTransport, Log: c.Log}
+	}
+
+	loggingTransport := logging.Transport{Transport: apiTransport, Log: c.Log}
+	metricsTransport := metrics.Transport{Transport: loggingTransport, Credentials: c.Credentials}
 	httpClient := &http.Client{Transport: metricsTransport}
 
 	var client *github.Client
 	var githubBaseURL string
 	if len(c.EnterpriseURL) > 0 {
+		uploadURL := c.EnterpriseURL
+		if len(c.UploadURL) > 0 {
+			uploadURL = c.UploadURL
+		}
+
 		var err error
-		client, err = github.NewEnterpriseClient(c.EnterpriseURL, c.EnterpriseURL, httpClient)
+		client, err = github.NewEnterpriseClient(c.EnterpriseURL, uploadURL, httpClient)
 		if err != nil {
 			return nil, fmt.Errorf("enterprise client creation failed: %v", err)
 		}
@@ -127,6 +163,11 @@ func (c *Config) NewClient() (*Client, error) {
 			client.UploadURL = uploadUrl
 		}
 
+		if c.Forge == ForgeGitea {
+			// Runners register to the instance, whose API is served under /api/v1.
+			githubBaseURL = fmt.Sprintf("%s://%s%s", client.BaseURL.Scheme, client.BaseURL.Host, strings.TrimSuffix(client.BaseURL.Path, "api/v1/"))
+		}
+
 		if len(c.RunnerGitHubURL) > 0 {
 			githubBaseURL = c.RunnerGitHubURL
 			if !strings.HasSuffix(githubBaseURL, "/") {
@@ -137,22 +178,88 @@ func (c *Config) NewClient() (*Client, error) {
 
 	client.UserAgent = "actions-runner-controller"
 
+	if c.Forge == ForgeGitea && len(c.URL) == 0 {
+		return nil, fmt.Errorf("github client creation failed: the url of the api of the gitea instance, like https://gitea.example.com/api/v1/, is required for the gitea forge")
+	}
+
+	f, err := newForge(c.Forge, client)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := c.Credentials
+	if credentials == "" {
+		credentials = metrics.DefaultCredentials
+	}
+
 	return &Client{
-		Client:        client,
-		regTokens:     map[string]*github.RegistrationToken{},
-		mu:            sync.Mutex{},
-		GithubBaseURL: githubBaseURL,
+		Client:         client,
+		regTokens:      map[string]*github.RegistrationToken{},
+		regTokenScopes: map[string]registrationTokenScope{},
+		mu:             sync.Mutex{},
+		GithubBaseURL:  githubBaseURL,
+		credentials:    credentials,
+		responses:      newResponseCache(c.ResponseCacheTTL),
+		forge:          f,
+		auth:           auth,
 	}, nil
 }
 
-// GetRegistrationToken returns a registration token tied with the name of repository and runner.
-func (c *Client) GetRegistrationToken(ctx context.Context, enterprise, org, repo, name string) (*github.RegistrationToken, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// newAuthTransport returns the transport that authenticates the requests with the credentials of the config.
+func newAuthTransport(c Config) (http.RoundTripper, error) {
+	var transport http.RoundTripper
+	if len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0 {
+		transport = BasicAuthTransport{Username: c.BasicauthUsername, Password: c.BasicauthPassword}
+	} else if len(c.Token) > 0 {
+		transport = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Token})).Transport
+	} else {
+		if c.AppID == 0 || c.AppInstallationID == 0 || len(c.AppPrivateKey) == 0 {
+			return nil, fmt.Errorf("authentication failed: either a token, basic auth credentials, or the id, installation id and private key of a github app must be provided")
+		}
 
-	key := getRegistrationKey(org, repo, enterprise)
-	rt, ok := c.regTokens[key]
+		// The installation token is cached by the transport, and refreshed on the first request made
+		// within a minute before its expiry, so that it never expires while the client is in use.
+		var tr *ghinstallation.Transport
 
+		if _, err := os.Stat(c.AppPrivateKey); err == nil {
+			tr, err = ghinstallation.NewKeyFromFile(http.DefaultTransport, c.AppID, c.AppInstallationID, c.AppPrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("authentication failed: using private key at %s: %v", c.AppPrivateKey, err)
+			}
+		} else {
+			tr, err = ghinstallation.New(http.DefaultTransport, c.AppID, c.AppInstallationID, []byte(c.AppPrivateKey))
+			if err != nil {
+				return nil, fmt.Errorf("authentication failed: using private key of size %d (%s...): %v", len(c.AppPrivateKey), strings.Split(c.AppPrivateKey, "\n")[0], err)
+			}
+		}
+
+		if len(c.EnterpriseURL) > 0 {
+			githubAPIURL, err := getEnterpriseApiUrl(c.EnterpriseURL)
+			if err != nil {
+				return nil, fmt.Errorf("enterprise url incorrect: %v", err)
+			}
+			tr.BaseURL = githubAPIURL
+		} else if len(c.URL) > 0 {
+			// The installation token needs to be issued by the same API host that the client calls,
+			// which is e.g. the GHES instance configured via URL rather than github.com.
+			tr.BaseURL = strings.TrimSuffix(c.URL, "/")
+		}
+		transport = tr
+	}
+
+	return transport, nil
+}
+
+// RateLimit returns the rate limit of the client's credentials as of the last response from the GitHub API,
+// read from the X-RateLimit headers. ok is false when no response has been received yet.
+// It's shared by all the clients created with the same credentials.
+func (c *Client) RateLimit() (rl metrics.RateLimit, ok bool) {
+	return metrics.GetRateLimit(c.credentials)
+}
+
+const (
+	// registrationTokenMinRemaining is the time a cached registration token must be valid for to be given to a runner.
+	//
 	// We'd like to allow the runner just starting up to miss the expiration date by a bit.
 	// Note that this means that we're going to cache Creation Registraion Token API response longer than the
 	// recommended cache duration.
@@ -164,16 +271,108 @@ func (c *Client) GetRegistrationToken(ctx context.Context, enterprise, org, repo
 	//
 	// This is currently set to 30 minutes as the result of the discussion took place at the following issue:
 	// https://github.com/actions-runner-controller/actions-runner-controller/issues/1295
-	runnerStartupTimeout := 30 * time.Minute
+	registrationTokenMinRemaining = 30 * time.Minute
+
+	// registrationTokenIdleTimeout is the time since the last request for the token of a scope after which
+	// the token is no longer refreshed by RefreshRegistrationTokens. It's the lifetime of a registration token.
+	registrationTokenIdleTimeout = time.Hour
+)
+
+type registrationTokenScope struct {
+	enterprise, org, repo string
+	lastUsed              time.Time
+}
+
+// GetRegistrationToken returns a registration token tied with the name of repository and runner.
+// The token of each enterprise, organization or repository is cached, and reused while it's valid
+// for registrationTokenMinRemaining or longer.
+func (c *Client) GetRegistrationToken(ctx context.Context, enterprise, org, repo, name string) (*github.RegistrationToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := getRegistrationKey(org, repo, enterprise)
+	rt, ok := c.regTokens[key]
+
+	c.regTokenScopes[key] = registrationTokenScope{enterprise: enterprise, org: org, repo: repo, lastUsed: time.Now()}
+
+	if ok && rt.GetExpiresAt().After(time.Now().Add(registrationTokenMinRemaining)) {
+		metrics.IncRegistrationTokenCache(c.credentials, metrics.RegistrationTokenCacheHit)
 
-	if ok && rt.GetExpiresAt().After(time.Now().Add(runnerStartupTimeout)) {
 		return rt, nil
 	}
 
+	metrics.IncRegistrationTokenCache(c.credentials, metrics.RegistrationTokenCacheMiss)
+
+	issued, err := c.issueRegistrationToken(ctx, enterprise, org, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.IncRegistrationTokenIssued(c.credentials, metrics.RegistrationTokenIssuedOnRequest)
+
+	c.regTokens[key] = issued
+	go func() {
+		c.cleanup()
+	}()
+
+	return issued, nil
+}
+
+// RefreshRegistrationTokens issues new registration tokens in place of the cached ones that will become too old
+// to be given to runners within lead, so that the runners created on scale outs get the tokens from the cache
+// instead of waiting for the API.
+// Only the tokens requested within registrationTokenIdleTimeout are refreshed, so that the tokens of
+// the scopes no longer in use expire as usual.
+func (c *Client) RefreshRegistrationTokens(ctx context.Context, lead time.Duration) error {
+	now := time.Now()
+
+	c.mu.Lock()
+
+	due := map[string]registrationTokenScope{}
+	for key, rt := range c.regTokens {
+		scope, ok := c.regTokenScopes[key]
+		if !ok || now.Sub(scope.lastUsed) > registrationTokenIdleTimeout {
+			continue
+		}
+
+		if rt.GetExpiresAt().After(now.Add(registrationTokenMinRemaining + lead)) {
+			continue
+		}
+
+		due[key] = scope
+	}
+
+	c.mu.Unlock()
+
+	var errs []string
+
+	for key, scope := range due {
+		rt, err := c.issueRegistrationToken(ctx, scope.enterprise, scope.org, scope.repo)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+
+		metrics.IncRegistrationTokenIssued(c.credentials, metrics.RegistrationTokenIssuedOnRefresh)
+
+		c.mu.Lock()
+		c.regTokens[key] = rt
+		c.mu.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to refresh registration tokens: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// issueRegistrationToken creates a new registration token via the API.
+func (c *Client) issueRegistrationToken(ctx context.Context, enterprise, org, repo string) (*github.RegistrationToken, error) {
 	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
 
 	if err != nil {
-		return rt, err
+		return nil, err
 	}
 
 	rt, res, err := c.createRegistrationToken(ctx, enterprise, owner, repo)
@@ -182,14 +381,15 @@ func (c *Client) GetRegistrationToken(ctx context.Context, enterprise, org, repo
 		return nil, fmt.Errorf("failed to create registration token: %v", err)
 	}
 
-	if res.StatusCode != 201 {
-		return nil, fmt.Errorf("unexpected status: %d", res.StatusCode)
+	wantStatus := 201
+	if _, ok := c.forge.(giteaForge); ok {
+		// Gitea returns the existing token instead of creating one.
+		wantStatus = 200
 	}
 
-	c.regTokens[key] = rt
-	go func() {
-		c.cleanup()
-	}()
+	if res.StatusCode != wantStatus {
+		return nil, fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
 
 	return rt, nil
 }
@@ -216,6 +416,7 @@ func (c *Client) RemoveRunner(ctx context.Context, enterprise, org, repo string,
 }
 
 // ListRunners returns a list of runners of specified owner/repository name.
+// The list is cut short when the page budget of the context, if any, is exhausted. See PageBudget.
 func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string) ([]*github.Runner, error) {
 	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
 
@@ -234,7 +435,7 @@ func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string)
 		}
 
 		runners = append(runners, list.Runners...)
-		if res.NextPage == 0 {
+		if res.NextPage == 0 || !nextPage(ctx) {
 			break
 		}
 		opts.Page = res.NextPage
@@ -243,6 +444,27 @@ func (c *Client) ListRunners(ctx context.Context, enterprise, org, repo string)
 	return runners, nil
 }
 
+// ListOrganizationRepositories returns all the repositories of the organization visible to the credentials of the client.
+func (c *Client) ListOrganizationRepositories(ctx context.Context, org string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+
+	opts := github.RepositoryListByOrgOptions{Type: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		list, res, err := c.Client.Repositories.ListByOrg(ctx, org, &opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization repositories: %w", err)
+		}
+
+		repos = append(repos, list...)
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return repos, nil
+}
+
 // ListOrganizationRunnerGroups returns all the runner groups defined in the organization and
 // inherited to the organization from an enterprise.
 func (c *Client) ListOrganizationRunnerGroups(ctx context.Context, org string) ([]*github.RunnerGroup, error) {
@@ -353,43 +575,25 @@ func (c *Client) cleanup() {
 	for key, rt := range c.regTokens {
 		if rt.GetExpiresAt().Before(time.Now()) {
 			delete(c.regTokens, key)
+			delete(c.regTokenScopes, key)
 		}
 	}
 }
 
-// wrappers for github functions (switch between enterprise/organization/repository mode)
-// so the calling functions don't need to switch and their code is a bit cleaner
-
 func (c *Client) createRegistrationToken(ctx context.Context, enterprise, org, repo string) (*github.RegistrationToken, *github.Response, error) {
-	if len(repo) > 0 {
-		return c.Client.Actions.CreateRegistrationToken(ctx, org, repo)
-	}
-	if len(org) > 0 {
-		return c.Client.Actions.CreateOrganizationRegistrationToken(ctx, org)
-	}
-	return c.Client.Enterprise.CreateRegistrationToken(ctx, enterprise)
+	return c.forge.createRegistrationToken(ctx, enterprise, org, repo)
 }
 
 func (c *Client) removeRunner(ctx context.Context, enterprise, org, repo string, runnerID int64) (*github.Response, error) {
-	if len(repo) > 0 {
-		return c.Client.Actions.RemoveRunner(ctx, org, repo, runnerID)
-	}
-	if len(org) > 0 {
-		return c.Client.Actions.RemoveOrganizationRunner(ctx, org, runnerID)
-	}
-	return c.Client.Enterprise.RemoveRunner(ctx, enterprise, runnerID)
+	return c.forge.removeRunner(ctx, enterprise, org, repo, runnerID)
 }
 
 func (c *Client) listRunners(ctx context.Context, enterprise, org, repo string, opts *github.ListOptions) (*github.Runners, *github.Response, error) {
-	if len(repo) > 0 {
-		return c.Client.Actions.ListRunners(ctx, org, repo, opts)
-	}
-	if len(org) > 0 {
-		return c.Client.Actions.ListOrganizationRunners(ctx, org, opts)
-	}
-	return c.Client.Enterprise.ListRunners(ctx, enterprise, opts)
+	return c.forge.listRunners(ctx, enterprise, org, repo, opts)
 }
 
+// ListRepositoryWorkflowRuns returns the queued and in-progress workflow runs of the repository.
+// Each list is cut short when the page budget of the context, if any, is exhausted. See PageBudget.
 func (c *Client) ListRepositoryWorkflowRuns(ctx context.Context, user string, repoName string) ([]*github.WorkflowRun, error) {
 	queued, err := c.listRepositoryWorkflowRuns(ctx, user, repoName, "queued")
 	if err != nil {
@@ -409,7 +613,47 @@ func (c *Client) ListRepositoryWorkflowRuns(ctx context.Context, user string, re
 	return workflowRuns, nil
 }
 
+// ListRepositoryWaitingWorkflowRuns returns the workflow runs of the repository that are waiting for the approvals of
+// the deployment environments, and the ones pending on their concurrency groups.
+// Each list is cut short when the page budget of the context, if any, is exhausted. See PageBudget.
+func (c *Client) ListRepositoryWaitingWorkflowRuns(ctx context.Context, user string, repoName string) ([]*github.WorkflowRun, error) {
+	waiting, err := c.listRepositoryWorkflowRuns(ctx, user, repoName, "waiting")
+	if err != nil {
+		return nil, fmt.Errorf("listing waiting workflow runs: %w", err)
+	}
+
+	pending, err := c.listRepositoryWorkflowRuns(ctx, user, repoName, "pending")
+	if err != nil {
+		return nil, fmt.Errorf("listing pending workflow runs: %w", err)
+	}
+
+	var workflowRuns []*github.WorkflowRun
+
+	workflowRuns = append(workflowRuns, waiting...)
+	workflowRuns = append(workflowRuns, pending...)
+
+	return workflowRuns, nil
+}
+
 func (c *Client) listRepositoryWorkflowRuns(ctx context.Context, user string, repoName, status string) ([]*github.WorkflowRun, error) {
+	key := fmt.Sprintf("runs/%s/%s/%s", user, repoName, status)
+
+	v, err := c.responses.get(key, func() (interface{}, error) {
+		runs, truncated, err := c.fetchRepositoryWorkflowRuns(ctx, user, repoName, status)
+		if truncated {
+			return uncacheable{value: runs}, err
+		}
+		return runs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*github.WorkflowRun), nil
+}
+
+// fetchRepositoryWorkflowRuns fetches the workflow runs of the status, along with whether they're cut short by the page budget of the context.
+func (c *Client) fetchRepositoryWorkflowRuns(ctx context.Context, user string, repoName, status string) ([]*github.WorkflowRun, bool, error) {
 	var workflowRuns []*github.WorkflowRun
 
 	opts := github.ListWorkflowRunsOptions{
@@ -423,17 +667,183 @@ func (c *Client) listRepositoryWorkflowRuns(ctx context.Context, user string, re
 		list, res, err := c.Client.Actions.ListRepositoryWorkflowRuns(ctx, user, repoName, &opts)
 
 		if err != nil {
-			return workflowRuns, fmt.Errorf("failed to list workflow runs: %v", err)
+			return workflowRuns, false, fmt.Errorf("failed to list workflow runs: %w", err)
 		}
 
 		workflowRuns = append(workflowRuns, list.WorkflowRuns...)
 		if res.NextPage == 0 {
 			break
 		}
+		if !nextPage(ctx) {
+			return workflowRuns, true, nil
+		}
 		opts.Page = res.NextPage
 	}
 
-	return workflowRuns, nil
+	return workflowRuns, false, nil
+}
+
+// WorkflowRun is a workflow run along with its attempt number, which isn't available in go-github v39.
+type WorkflowRun struct {
+	github.WorkflowRun
+
+	RunAttempt *int `json:"run_attempt,omitempty"`
+}
+
+// GetRunAttempt returns the attempt number of the workflow run, which starts at 1.
+func (r *WorkflowRun) GetRunAttempt() int {
+	if r == nil || r.RunAttempt == nil {
+		return 1
+	}
+	return *r.RunAttempt
+}
+
+type workflowRuns struct {
+	TotalCount   *int           `json:"total_count,omitempty"`
+	WorkflowRuns []*WorkflowRun `json:"workflow_runs,omitempty"`
+}
+
+// ListFailedWorkflowRuns returns the most recent failed workflow runs of the repository.
+// Only the first page of up to 100 runs is returned, as this is intended for looking into the runs that failed recently.
+func (c *Client) ListFailedWorkflowRuns(ctx context.Context, owner, repo string) ([]*WorkflowRun, error) {
+	u := fmt.Sprintf("repos/%v/%v/actions/runs?status=failure&per_page=100", owner, repo)
+
+	req, err := c.Client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs workflowRuns
+	if _, err := c.Client.Do(ctx, req, &runs); err != nil {
+		return nil, fmt.Errorf("failed to list failed workflow runs: %w", err)
+	}
+
+	return runs.WorkflowRuns, nil
+}
+
+// WorkflowJob is a workflow job along with the name of the runner that ran it and its runner group,
+// which aren't available in go-github v39.
+type WorkflowJob struct {
+	github.WorkflowJob
+
+	RunnerName      *string `json:"runner_name,omitempty"`
+	RunnerGroupName *string `json:"runner_group_name,omitempty"`
+}
+
+// GetRunnerName returns the name of the runner that ran the job, or an empty string if it's unknown.
+func (j *WorkflowJob) GetRunnerName() string {
+	if j == nil || j.RunnerName == nil {
+		return ""
+	}
+	return *j.RunnerName
+}
+
+// GetRunnerGroupName returns the name of the runner group of the runner that picked up the job,
+// or an empty string if the job isn't picked up yet.
+func (j *WorkflowJob) GetRunnerGroupName() string {
+	if j == nil || j.RunnerGroupName == nil {
+		return ""
+	}
+	return *j.RunnerGroupName
+}
+
+type workflowJobs struct {
+	TotalCount *int           `json:"total_count,omitempty"`
+	Jobs       []*WorkflowJob `json:"jobs,omitempty"`
+}
+
+// ListWorkflowJobs returns the jobs of the latest attempt of the workflow run.
+// The list is cut short when the page budget of the context, if any, is exhausted. See PageBudget.
+// The returned jobs may be shared with the other callers within the response cache TTL, so they must not be modified.
+func (c *Client) ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64) ([]*WorkflowJob, error) {
+	key := fmt.Sprintf("jobs/%s/%s/%d", owner, repo, runID)
+
+	v, err := c.responses.get(key, func() (interface{}, error) {
+		jobs, truncated, err := c.fetchWorkflowJobs(ctx, owner, repo, runID)
+		if truncated {
+			return uncacheable{value: jobs}, err
+		}
+		return jobs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*WorkflowJob), nil
+}
+
+// ListLatestWorkflowJobs is ListWorkflowJobs bypassing the response cache, for the workflow run that is known to have been re-run,
+// whose jobs of the previous attempt may still be cached.
+func (c *Client) ListLatestWorkflowJobs(ctx context.Context, owner, repo string, runID int64) ([]*WorkflowJob, error) {
+	jobs, _, err := c.fetchWorkflowJobs(ctx, owner, repo, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// fetchWorkflowJobs fetches the jobs of the workflow run, along with whether they're cut short by the page budget of the context.
+func (c *Client) fetchWorkflowJobs(ctx context.Context, owner, repo string, runID int64) ([]*WorkflowJob, bool, error) {
+	var jobs []*WorkflowJob
+
+	for page := 1; page != 0; {
+		u := fmt.Sprintf("repos/%v/%v/actions/runs/%v/jobs?filter=latest&per_page=100&page=%d", owner, repo, runID, page)
+
+		req, err := c.Client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var list workflowJobs
+		res, err := c.Client.Do(ctx, req, &list)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list workflow jobs: %w", err)
+		}
+
+		jobs = append(jobs, list.Jobs...)
+		if res.NextPage != 0 && !nextPage(ctx) {
+			return jobs, true, nil
+		}
+		page = res.NextPage
+	}
+
+	return jobs, false, nil
+}
+
+// ListWorkflowJobAnnotations returns the annotations of the check run of the workflow job,
+// which contain the errors reported by GitHub Actions, like the loss of the runner.
+func (c *Client) ListWorkflowJobAnnotations(ctx context.Context, owner, repo string, job *WorkflowJob) ([]*github.CheckRunAnnotation, error) {
+	// The ID of a workflow job is the ID of its check run, but the check run URL is preferred when it's available.
+	checkRunID := job.GetID()
+	if u := job.GetCheckRunURL(); u != "" {
+		if id, err := strconv.ParseInt(path.Base(u), 10, 64); err == nil {
+			checkRunID = id
+		}
+	}
+
+	annotations, _, err := c.Client.Checks.ListCheckRunAnnotations(ctx, owner, repo, checkRunID, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check run annotations: %w", err)
+	}
+
+	return annotations, nil
+}
+
+// RerunFailedJobs re-runs the failed jobs of the workflow run, along with their dependent jobs.
+func (c *Client) RerunFailedJobs(ctx context.Context, owner, repo string, runID int64) error {
+	u := fmt.Sprintf("repos/%v/%v/actions/runs/%v/rerun-failed-jobs", owner, repo, runID)
+
+	req, err := c.Client.NewRequest("POST", u, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("failed to re-run failed jobs: %w", err)
+	}
+
+	return nil
 }
 
 // Validates enterprise, organization and repo arguments. Both are optional, but at least one should be specified
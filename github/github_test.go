@@ -2,8 +2,18 @@ package github
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -153,9 +163,338 @@ func TestCleanup(t *testing.T) {
 	}
 }
 
+func TestRefreshRegistrationTokens(t *testing.T) {
+	token := "token"
+	now := time.Now()
+
+	newToken := func(expiresIn time.Duration) *github.RegistrationToken {
+		return &github.RegistrationToken{Token: &token, ExpiresAt: &github.Timestamp{Time: now.Add(expiresIn)}}
+	}
+
+	due := getRegistrationKey("", "test/valid", "")
+	idle := getRegistrationKey("test", "", "")
+	fresh := getRegistrationKey("", "", "test")
+
+	client := newTestClient()
+	client.regTokens = map[string]*github.RegistrationToken{
+		due:   newToken(33 * time.Minute),
+		idle:  newToken(33 * time.Minute),
+		fresh: newToken(50 * time.Minute),
+	}
+	client.regTokenScopes = map[string]registrationTokenScope{
+		due:   {repo: "test/valid", lastUsed: now.Add(-10 * time.Minute)},
+		idle:  {org: "test", lastUsed: now.Add(-2 * time.Hour)},
+		fresh: {enterprise: "test", lastUsed: now},
+	}
+
+	if err := client.RefreshRegistrationTokens(context.Background(), 5*time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := client.regTokens[due]; got.GetToken() != fake.RegistrationToken || !got.GetExpiresAt().After(now.Add(50*time.Minute)) {
+		t.Errorf("token about to become too old was not refreshed: %v", got)
+	}
+
+	for _, key := range []string{idle, fresh} {
+		if got := client.regTokens[key]; got.GetToken() != token {
+			t.Errorf("%s: unexpected refresh: %v", key, got)
+		}
+	}
+
+	// The refreshed token is served from the cache.
+	rt, err := client.GetRegistrationToken(context.Background(), "", "", "test/valid", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rt != client.regTokens[due] {
+		t.Errorf("refreshed token was not reused")
+	}
+}
+
 func TestUserAgent(t *testing.T) {
 	client := newTestClient()
 	if client.UserAgent != "actions-runner-controller" {
 		t.Errorf("UserAgent should be set to actions-runner-controller")
 	}
 }
+
+func TestNewClient_URLs(t *testing.T) {
+	tests := []struct {
+		config        Config
+		baseURL       string
+		uploadURL     string
+		githubBaseURL string
+	}{
+		{
+			config:        Config{Token: "token"},
+			baseURL:       "https://api.github.com/",
+			uploadURL:     "https://uploads.github.com/",
+			githubBaseURL: "https://github.com/",
+		},
+		{
+			config:        Config{Token: "token", URL: "https://ghes.example.com/api/v3", UploadURL: "https://ghes.example.com/api/uploads", RunnerGitHubURL: "https://ghes.example.com"},
+			baseURL:       "https://ghes.example.com/api/v3/",
+			uploadURL:     "https://ghes.example.com/api/uploads/",
+			githubBaseURL: "https://ghes.example.com/",
+		},
+		{
+			config:        Config{Token: "token", EnterpriseURL: "https://ghes.example.com"},
+			baseURL:       "https://ghes.example.com/api/v3/",
+			uploadURL:     "https://ghes.example.com/api/uploads/",
+			githubBaseURL: "https://ghes.example.com/",
+		},
+		{
+			config:        Config{Token: "token", EnterpriseURL: "https://ghes.example.com", UploadURL: "https://uploads.ghes.example.com"},
+			baseURL:       "https://ghes.example.com/api/v3/",
+			uploadURL:     "https://uploads.ghes.example.com/api/uploads/",
+			githubBaseURL: "https://ghes.example.com/",
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := tt.config.NewClient()
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+		if got := client.BaseURL.String(); got != tt.baseURL {
+			t.Errorf("[%d] unexpected base url: want %s, got %s", i, tt.baseURL, got)
+		}
+		if got := client.UploadURL.String(); got != tt.uploadURL {
+			t.Errorf("[%d] unexpected upload url: want %s, got %s", i, tt.uploadURL, got)
+		}
+		if client.GithubBaseURL != tt.githubBaseURL {
+			t.Errorf("[%d] unexpected github base url: want %s, got %s", i, tt.githubBaseURL, client.GithubBaseURL)
+		}
+	}
+}
+
+func TestNewClient_GitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	tests := []struct {
+		expiresIn     time.Duration
+		wantIssuances int
+		wantLastToken string
+	}{
+		{expiresIn: time.Hour, wantIssuances: 1, wantLastToken: "token-1"},
+		{expiresIn: 30 * time.Second, wantIssuances: 2, wantLastToken: "token-2"},
+	}
+
+	for i, tt := range tests {
+		var (
+			issued    int
+			lastToken string
+		)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/app/installations/2/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+				t.Errorf("[%d] installation token must be requested with the app jwt", i)
+			}
+			issued++
+			fmt.Fprintf(w, `{"token": "token-%d", "expires_at": %q}`, issued, time.Now().Add(tt.expiresIn).Format(time.RFC3339))
+		})
+		mux.HandleFunc("/repos/test/valid/actions/runners", func(w http.ResponseWriter, r *http.Request) {
+			lastToken = strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+			fmt.Fprint(w, fake.RunnersListBody)
+		})
+		server := httptest.NewServer(mux)
+
+		c := Config{
+			AppID:             1,
+			AppInstallationID: 2,
+			AppPrivateKey:     privateKey,
+			URL:               server.URL,
+		}
+		client, err := c.NewClient()
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		for j := 0; j < 2; j++ {
+			if _, err := client.ListRunners(context.Background(), "", "", "test/valid"); err != nil {
+				t.Fatalf("[%d] unexpected error: %v", i, err)
+			}
+		}
+
+		if issued != tt.wantIssuances {
+			t.Errorf("[%d] unexpected number of installation token issuances: want %d, got %d", i, tt.wantIssuances, issued)
+		}
+		if lastToken != tt.wantLastToken {
+			t.Errorf("[%d] unexpected installation token: want %s, got %s", i, tt.wantLastToken, lastToken)
+		}
+
+		server.Close()
+	}
+}
+
+func TestNewClient_MissingCredentials(t *testing.T) {
+	tests := []Config{
+		{},
+		{AppID: 1, AppInstallationID: 2},
+		{AppID: 1, AppPrivateKey: "key"},
+	}
+
+	for i, c := range tests {
+		if _, err := c.NewClient(); err == nil {
+			t.Errorf("[%d] expected error, got none", i)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var sent []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/registration-token"):
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": %q, "expires_at": %q}`, fake.RegistrationToken, time.Now().Add(time.Hour).Format(time.RFC3339))
+		default:
+			fmt.Fprint(w, fake.RunnersListBody)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := Config{Token: "token", URL: server.URL, DryRun: true}
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); err != nil {
+		t.Fatalf("unexpected error listing runners: %v", err)
+	}
+	if _, err := client.GetRegistrationToken(ctx, "", "", "test/valid", "runner"); err != nil {
+		t.Fatalf("unexpected error getting registration token: %v", err)
+	}
+	if err := client.RemoveRunner(ctx, "", "", "test/valid", 1); err != nil {
+		t.Fatalf("unexpected error removing runner: %v", err)
+	}
+	if err := client.RerunFailedJobs(ctx, "test", "valid", 1); err != nil {
+		t.Fatalf("unexpected error re-running failed jobs: %v", err)
+	}
+
+	want := []string{
+		"GET /repos/test/valid/actions/runners",
+		"POST /repos/test/valid/actions/runners/registration-token",
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("unexpected requests sent in the dry-run mode: want %v, got %v", want, sent)
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		fmt.Fprint(w, fake.RunnersListBody)
+	}))
+	defer server.Close()
+
+	c := Config{Token: "token", URL: server.URL, MaxConcurrentRequests: 2}
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			if _, err := client.ListRunners(context.Background(), "", "", fmt.Sprintf("test/repo%d", i)); err != nil {
+				t.Errorf("unexpected error listing runners: %v", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("unexpected number of the in-flight requests: want at most 2, got %d", got)
+	}
+}
+
+func TestReloadAuth(t *testing.T) {
+	var mu sync.Mutex
+	var authorization string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+
+		fmt.Fprint(w, fake.RunnersListBody)
+	}))
+	defer server.Close()
+
+	c := Config{Token: "old", URL: server.URL}
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	listRunners := func() string {
+		t.Helper()
+
+		if _, err := client.ListRunners(context.Background(), "", "", "test/valid"); err != nil {
+			t.Fatalf("unexpected error listing runners: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return authorization
+	}
+
+	if got := listRunners(); got != "Bearer old" {
+		t.Errorf("unexpected authorization: %q", got)
+	}
+
+	if reloaded, err := client.ReloadAuth(AuthConfig{Token: "old"}); err != nil || reloaded {
+		t.Errorf("unexpected reload of the same credentials: reloaded=%v, err=%v", reloaded, err)
+	}
+
+	if reloaded, err := client.ReloadAuth(AuthConfig{Token: "new"}); err != nil || !reloaded {
+		t.Fatalf("unexpected reload: reloaded=%v, err=%v", reloaded, err)
+	}
+
+	if got := listRunners(); got != "Bearer new" {
+		t.Errorf("unexpected authorization after the reload: %q", got)
+	}
+
+	if _, err := client.ReloadAuth(AuthConfig{AppID: 1}); err == nil {
+		t.Errorf("expected an error reloading the incomplete credentials")
+	}
+
+	if got := listRunners(); got != "Bearer new" {
+		t.Errorf("unexpected authorization after the failed reload: %q", got)
+	}
+}
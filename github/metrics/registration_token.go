@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metricRegistrationTokenCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_registration_token_cache_total",
+			Help: "The number of registration tokens requested from the client by the result of the token cache, hit or miss",
+		},
+		[]string{"credentials", "result"},
+	)
+	metricRegistrationTokenIssued = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_registration_token_issued_total",
+			Help: "The number of registration tokens issued by the GitHub API by the trigger, request on a cache miss or refresh ahead of the expiry",
+		},
+		[]string{"credentials", "trigger"},
+	)
+)
+
+const (
+	RegistrationTokenCacheHit  = "hit"
+	RegistrationTokenCacheMiss = "miss"
+
+	RegistrationTokenIssuedOnRequest = "request"
+	RegistrationTokenIssuedOnRefresh = "refresh"
+)
+
+// IncRegistrationTokenCache counts a registration token requested from the client by the result of the token cache.
+func IncRegistrationTokenCache(credentials, result string) {
+	metricRegistrationTokenCache.WithLabelValues(credentials, result).Inc()
+}
+
+// IncRegistrationTokenIssued counts a registration token issued by the GitHub API by the trigger.
+func IncRegistrationTokenIssued(credentials, trigger string) {
+	metricRegistrationTokenIssued.WithLabelValues(credentials, trigger).Inc()
+}
@@ -5,59 +5,168 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/gregjones/httpcache"
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 func init() {
-	metrics.Registry.MustRegister(metricRateLimit, metricRateLimitRemaining)
+	metrics.Registry.MustRegister(metricRateLimit, metricRateLimitRemaining, metricRegistrationTokenCache, metricRegistrationTokenIssued)
 }
 
 var (
 	// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
-	metricRateLimit = prometheus.NewGauge(
+	metricRateLimit = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "github_rate_limit",
 			Help: "The maximum number of requests you're permitted to make per hour",
 		},
+		[]string{"credentials"},
 	)
-	metricRateLimitRemaining = prometheus.NewGauge(
+	metricRateLimitRemaining = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "github_rate_limit_remaining",
 			Help: "The number of requests remaining in the current rate limit window",
 		},
+		[]string{"credentials"},
 	)
 )
 
+var (
+	rateLimitsMu sync.Mutex
+	rateLimits   = map[string]RateLimit{}
+)
+
+// RateLimit is the rate limit of the credentials as of the last response to a request made with them.
+type RateLimit struct {
+	// Limit is the maximum number of requests permitted per hour. Zero when unknown.
+	Limit int
+	// Remaining is the number of requests remaining in the current rate limit window.
+	Remaining int
+	// Reset is the time the current rate limit window resets. Zero when unknown.
+	Reset time.Time
+}
+
+// DefaultCredentials is the credentials label of the metrics of the controller-wide credentials.
+const DefaultCredentials = "default"
+
 const (
 	// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
 	headerRateLimit          = "X-RateLimit-Limit"
 	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
 )
 
 // Transport wraps a transport with metrics monitoring
 type Transport struct {
 	Transport http.RoundTripper
+
+	// Credentials identifies the credentials the rate limits are tracked for.
+	// Defaults to DefaultCredentials.
+	Credentials string
 }
 
 func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	resp, err := t.Transport.RoundTrip(req)
 	if resp != nil {
-		parseResponse(resp)
+		parseResponse(t.credentials(), resp)
+		observeResponse(req.Context(), resp)
 	}
 	return resp, err
 }
 
-func parseResponse(resp *http.Response) {
+// ResponseObserver is called on every GitHub API response to the requests made with the context it's attached to.
+// rateLimitRemaining is negative when the response is served from the cache or lacks the rate limit header,
+// because the cached value is outdated.
+type ResponseObserver func(fromCache bool, rateLimitRemaining int)
+
+type responseObserverKey struct{}
+
+// WithResponseObserver returns a copy of ctx that makes the GitHub client report the responses to o,
+// so that the caller can tell the API usage of its own requests from the others made with the same client.
+func WithResponseObserver(ctx context.Context, o ResponseObserver) context.Context {
+	return context.WithValue(ctx, responseObserverKey{}, o)
+}
+
+func observeResponse(ctx context.Context, resp *http.Response) {
+	o, ok := ctx.Value(responseObserverKey{}).(ResponseObserver)
+	if !ok || o == nil {
+		return
+	}
+
+	fromCache := resp.Header.Get(httpcache.XFromCache) == "1"
+
+	remaining := -1
+	if !fromCache {
+		if v, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining)); err == nil {
+			remaining = v
+		}
+	}
+
+	o(fromCache, remaining)
+}
+
+func (t Transport) credentials() string {
+	if t.Credentials == "" {
+		return DefaultCredentials
+	}
+	return t.Credentials
+}
+
+func parseResponse(credentials string, resp *http.Response) {
 	rateLimit, err := strconv.Atoi(resp.Header.Get(headerRateLimit))
 	if err == nil {
-		metricRateLimit.Set(float64(rateLimit))
+		metricRateLimit.WithLabelValues(credentials).Set(float64(rateLimit))
 	}
-	rateLimitRemaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining))
+	remaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining))
 	if err == nil {
-		metricRateLimitRemaining.Set(float64(rateLimitRemaining))
+		metricRateLimitRemaining.WithLabelValues(credentials).Set(float64(remaining))
 	}
+
+	// A cached response carries the rate limit as of when it was fetched, which is outdated.
+	if err == nil && resp.Header.Get(httpcache.XFromCache) != "1" {
+		rl := RateLimit{Limit: rateLimit, Remaining: remaining}
+		if reset, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil {
+			rl.Reset = time.Unix(reset, 0)
+		}
+
+		rateLimitsMu.Lock()
+		rateLimits[credentials] = rl
+		rateLimitsMu.Unlock()
+	}
+}
+
+// RateLimitRemaining returns the number of requests remaining in the current rate limit window of the credentials,
+// as of the last response to a request made with them. ok is false when no such response has been received yet.
+func RateLimitRemaining(credentials string) (remaining int, ok bool) {
+	rl, ok := GetRateLimit(credentials)
+
+	return rl.Remaining, ok
+}
+
+// GetRateLimit returns the rate limit of the credentials as of the last response to a request made with them.
+// ok is false when no such response has been received yet.
+func GetRateLimit(credentials string) (rl RateLimit, ok bool) {
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	rl, ok = rateLimits[credentials]
+
+	return rl, ok
+}
+
+// DeleteCredentials stops exporting the rate limits of the credentials, e.g. after they are rotated or removed.
+func DeleteCredentials(credentials string) {
+	metricRateLimit.DeleteLabelValues(credentials)
+	metricRateLimitRemaining.DeleteLabelValues(credentials)
+
+	rateLimitsMu.Lock()
+	delete(rateLimits, credentials)
+	rateLimitsMu.Unlock()
 }
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gregjones/httpcache"
+)
+
+func TestTransport_ResponseObserver(t *testing.T) {
+	testcases := []struct {
+		name          string
+		header        map[string]string
+		wantFromCache bool
+		wantRemaining int
+	}{
+		{
+			name:          "fetched",
+			header:        map[string]string{headerRateLimitRemaining: "4999"},
+			wantRemaining: 4999,
+		},
+		{
+			name:          "cached",
+			header:        map[string]string{headerRateLimitRemaining: "4999", httpcache.XFromCache: "1"},
+			wantFromCache: true,
+			wantRemaining: -1,
+		},
+		{
+			name:          "no rate limit",
+			wantRemaining: -1,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+			}))
+			defer server.Close()
+
+			var calls int
+			var gotFromCache bool
+			var gotRemaining int
+
+			ctx := WithResponseObserver(context.Background(), func(fromCache bool, rateLimitRemaining int) {
+				calls++
+				gotFromCache = fromCache
+				gotRemaining = rateLimitRemaining
+			})
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := Transport{Transport: http.DefaultTransport}.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if calls != 1 {
+				t.Fatalf("unexpected number of observations: want 1, got %d", calls)
+			}
+
+			if gotFromCache != tc.wantFromCache {
+				t.Errorf("unexpected fromCache: want %v, got %v", tc.wantFromCache, gotFromCache)
+			}
+
+			if gotRemaining != tc.wantRemaining {
+				t.Errorf("unexpected rate limit remaining: want %d, got %d", tc.wantRemaining, gotRemaining)
+			}
+		})
+	}
+}
+
+func TestTransport_RateLimitRemaining(t *testing.T) {
+	const credentials = "default/test-rate-limit-remaining"
+
+	header := map[string]string{headerRateLimit: "5000", headerRateLimitRemaining: "4999", headerRateLimitReset: "1646128800"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+	}))
+	defer server.Close()
+
+	get := func() {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := Transport{Transport: http.DefaultTransport, Credentials: credentials}.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if _, ok := RateLimitRemaining(credentials); ok {
+		t.Fatalf("unexpected rate limit remaining before any response")
+	}
+
+	get()
+
+	if remaining, ok := RateLimitRemaining(credentials); !ok || remaining != 4999 {
+		t.Errorf("unexpected rate limit remaining: want 4999, got %d (%v)", remaining, ok)
+	}
+
+	if rl, ok := GetRateLimit(credentials); !ok || rl.Limit != 5000 || !rl.Reset.Equal(time.Unix(1646128800, 0)) {
+		t.Errorf("unexpected rate limit: %+v (%v)", rl, ok)
+	}
+
+	// The outdated rate limit of a cached response is ignored
+	header = map[string]string{headerRateLimitRemaining: "5000", httpcache.XFromCache: "1"}
+
+	get()
+
+	if remaining, ok := RateLimitRemaining(credentials); !ok || remaining != 4999 {
+		t.Errorf("unexpected rate limit remaining: want 4999, got %d (%v)", remaining, ok)
+	}
+
+	DeleteCredentials(credentials)
+
+	if _, ok := RateLimitRemaining(credentials); ok {
+		t.Errorf("unexpected rate limit remaining after deleting the credentials")
+	}
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// PageBudget caps the number of the additional pages fetched by the list queries made with the context it's attached to,
+// so that a reconciliation of a busy scale target doesn't exhaust the rate limit paging through all the workflow runs, jobs and runners.
+//
+// The first page of each list is always fetched, so that a query never returns nothing because of the budget.
+// The lists cut short by the budget aren't cached in the response cache.
+type PageBudget struct {
+	mu        sync.Mutex
+	remaining int
+	truncated bool
+}
+
+// NewPageBudget returns the PageBudget allowing the pages beyond the first page of each list up to the number in total.
+// It returns nil, which allows any number of pages, when pages is zero or less.
+func NewPageBudget(pages int) *PageBudget {
+	if pages <= 0 {
+		return nil
+	}
+
+	return &PageBudget{remaining: pages}
+}
+
+// Truncated returns true when any list was cut short because the budget was exhausted.
+func (b *PageBudget) Truncated() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.truncated
+}
+
+func (b *PageBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.remaining <= 0 {
+		b.truncated = true
+		return false
+	}
+
+	b.remaining--
+
+	return true
+}
+
+type pageBudgetKey struct{}
+
+// WithPageBudget returns the context whose list queries fetch their additional pages within the budget.
+func WithPageBudget(ctx context.Context, b *PageBudget) context.Context {
+	if b == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, pageBudgetKey{}, b)
+}
+
+// nextPage returns true when the next page of the list can be fetched within the page budget of the context, if any.
+func nextPage(ctx context.Context) bool {
+	b, _ := ctx.Value(pageBudgetKey{}).(*PageBudget)
+
+	return b.take()
+}
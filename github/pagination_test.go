@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/google/go-github/v39/github"
+)
+
+func TestPageBudget(t *testing.T) {
+	s := fake.NewScenario().Paginate(1)
+	for id := int64(1); id <= 3; id++ {
+		s.AddWorkflowRun("test", "valid",
+			&github.WorkflowRun{ID: github.Int64(id), Status: github.String("queued")},
+			&github.WorkflowJob{ID: github.Int64(id * 10), Status: github.String("queued")},
+			&github.WorkflowJob{ID: github.Int64(id*10 + 1), Status: github.String("queued")},
+		)
+	}
+
+	server := s.GetServer()
+	defer server.Close()
+
+	client, err := (&Config{Token: "token", ResponseCacheTTL: time.Minute}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Client.BaseURL = baseURL
+
+	budget := NewPageBudget(2)
+	ctx := WithPageBudget(context.Background(), budget)
+
+	runs, err := client.ListRepositoryWorkflowRuns(ctx, "test", "valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runs) != 3 {
+		t.Errorf("unexpected number of runs within the budget: want 3, got %d", len(runs))
+	}
+
+	if budget.Truncated() {
+		t.Error("unexpected truncation while the budget remains")
+	}
+
+	jobs, err := client.ListWorkflowJobs(ctx, "test", "valid", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(jobs) != 1 {
+		t.Errorf("unexpected number of jobs beyond the budget: want 1, got %d", len(jobs))
+	}
+
+	if !budget.Truncated() {
+		t.Error("expected truncation once the budget is exhausted")
+	}
+
+	// The truncated list isn't cached, so that it's listed in full without the budget.
+	jobs, err = client.ListWorkflowJobs(context.Background(), "test", "valid", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(jobs) != 2 {
+		t.Errorf("unexpected number of jobs without the budget: want 2, got %d", len(jobs))
+	}
+
+	s.AssertRequested(t, "GET", "/repos/test/valid/actions/runs?status=queued", 3)
+	s.AssertRequested(t, "GET", "/repos/test/valid/actions/runs/1/jobs", 3)
+}
+
+func TestNewPageBudget_Unlimited(t *testing.T) {
+	budget := NewPageBudget(0)
+
+	ctx := WithPageBudget(context.Background(), budget)
+
+	for i := 0; i < 10; i++ {
+		if !nextPage(ctx) {
+			t.Fatalf("unexpected end of pages at %d", i)
+		}
+	}
+
+	if budget.Truncated() {
+		t.Error("unexpected truncation without the budget")
+	}
+}
@@ -0,0 +1,137 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/fixture"
+)
+
+// recordedHeaders is the list of response headers kept in fixtures.
+// Everything else, including rate-limit and request-id headers, is dropped so that fixtures don't leak anything
+// about the recorded account and stay deterministic.
+var recordedHeaders = []string{"Content-Type", "Link"}
+
+// redactedFields is the list of JSON fields whose values are replaced with redactedValue in recorded response bodies.
+var redactedFields = map[string]bool{
+	"token":              true,
+	"access_token":       true,
+	"encoded_jit_config": true,
+}
+
+const redactedValue = "REDACTED"
+
+var linkURLPattern = regexp.MustCompile(`<https?://[^/>]+`)
+
+// RecordingTransport is a http.RoundTripper that records every GitHub API interaction
+// into a fixture file that can be replayed with fake.NewReplayServer.
+//
+// This is intended only for turning real-world behaviors into deterministic regression tests.
+// The recorded fixtures are sanitized so that they don't contain credentials, but
+// they still contain the names of your organizations, repositories, and runners.
+type RecordingTransport struct {
+	Transport http.RoundTripper
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewRecordingTransport creates a RecordingTransport that appends recorded interactions to the file at path.
+func NewRecordingTransport(transport http.RoundTripper, path string) (*RecordingTransport, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening fixture file for recording: %w", err)
+	}
+
+	return &RecordingTransport{Transport: transport, out: f}, nil
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := t.record(sanitize(req.Method, req.URL, resp, body)); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (t *RecordingTransport) record(i fixture.Interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fixture.Write(t.out, i)
+}
+
+func sanitize(method string, u *url.URL, resp *http.Response, body []byte) fixture.Interaction {
+	i := fixture.Interaction{
+		Method: method,
+		Path:   u.Path,
+		Query:  u.RawQuery,
+		Status: resp.StatusCode,
+	}
+
+	for _, h := range recordedHeaders {
+		v := resp.Header.Get(h)
+		if v == "" {
+			continue
+		}
+
+		if h == "Link" {
+			v = linkURLPattern.ReplaceAllString(v, "<"+fixture.LinkPlaceholder)
+		}
+
+		if i.Header == nil {
+			i.Header = map[string]string{}
+		}
+
+		i.Header[h] = v
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if redacted, err := json.Marshal(redact(v)); err == nil {
+			body = redacted
+		}
+	}
+
+	i.Body = string(body)
+
+	return i
+}
+
+func redact(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range typed {
+			if redactedFields[k] {
+				typed[k] = redactedValue
+			} else {
+				typed[k] = redact(elem)
+			}
+		}
+	case []interface{}:
+		for idx, elem := range typed {
+			typed[idx] = redact(elem)
+		}
+	}
+
+	return v
+}
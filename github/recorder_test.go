@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fixture"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	fixturePath := filepath.Join(t.TempDir(), "fixture.jsonl")
+
+	c := Config{
+		Token:          "token",
+		RecordFixtures: fixturePath,
+	}
+	recording, err := c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording.Client.BaseURL = baseURL
+
+	ctx := context.Background()
+
+	recordedRunners, err := recording.ListRunners(ctx, "", "", "test/valid")
+	if err != nil {
+		t.Fatalf("unexpected error while recording: %v", err)
+	}
+
+	if _, _, err := recording.Client.Actions.CreateRegistrationToken(ctx, "test", "valid"); err != nil {
+		t.Fatalf("unexpected error while recording: %v", err)
+	}
+
+	recorded, err := fixture.Load(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorded.Interactions) != 2 {
+		t.Fatalf("unexpected number of recorded interactions: want 2, got %d", len(recorded.Interactions))
+	}
+
+	for _, i := range recorded.Interactions {
+		if strings.Contains(i.Body, fake.RegistrationToken) {
+			t.Errorf("registration token must be redacted: %s", i.Body)
+		}
+	}
+
+	replayServer, replay := fake.NewReplayServer(recorded)
+	defer replayServer.Close()
+
+	replaying := newTestClient()
+	replayURL, err := url.Parse(replayServer.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaying.Client.BaseURL = replayURL
+
+	replayedRunners, err := replaying.ListRunners(ctx, "", "", "test/valid")
+	if err != nil {
+		t.Fatalf("unexpected error while replaying: %v", err)
+	}
+
+	if len(replayedRunners) != len(recordedRunners) {
+		t.Errorf("unexpected number of replayed runners: want %d, got %d", len(recordedRunners), len(replayedRunners))
+	}
+
+	token, _, err := replaying.Client.Actions.CreateRegistrationToken(ctx, "test", "valid")
+	if err != nil {
+		t.Fatalf("unexpected error while replaying: %v", err)
+	}
+
+	if token.GetToken() != "REDACTED" {
+		t.Errorf("unexpected replayed token: %s", token.GetToken())
+	}
+
+	if _, err := replaying.ListRunners(ctx, "", "", "test/unknown"); err == nil {
+		t.Errorf("expected an error for an unrecorded request")
+	}
+
+	if unmatched := replay.Unmatched(); len(unmatched) != 1 {
+		t.Errorf("unexpected unmatched requests: %v", unmatched)
+	}
+}
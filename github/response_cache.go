@@ -0,0 +1,102 @@
+package github
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCache caches the results of the GitHub API queries made by a client for a short TTL,
+// so that the HRAs and the other controllers querying the same workflow runs and jobs within the TTL
+// share a single set of API calls.
+//
+// This complements the HTTP cache of the client, which still makes a conditional request per query
+// to revalidate the cached response with its ETag once it's stale.
+// Concurrent queries for the same key wait for the first one instead of making their own.
+// Errors and the values wrapped in uncacheable aren't cached.
+type responseCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*responseCacheEntry
+}
+
+type responseCacheEntry struct {
+	done      chan struct{}
+	value     interface{}
+	err       error
+	expiresAt time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]*responseCacheEntry{},
+	}
+}
+
+// get returns the cached result for the key, or the result of fetch which is cached until the TTL elapses.
+// The returned value is shared by all the callers, so it must not be modified.
+func (c *responseCache) get(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	if c == nil || c.ttl <= 0 {
+		return fetch()
+	}
+
+	c.mu.Lock()
+
+	now := c.now()
+
+	for k, e := range c.entries {
+		if isExpired(e, now) {
+			delete(c.entries, k)
+		}
+	}
+
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+
+		<-e.done
+
+		return e.value, e.err
+	}
+
+	e := &responseCacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+
+	c.mu.Unlock()
+
+	e.value, e.err = fetch()
+
+	u, isUncacheable := e.value.(uncacheable)
+	if isUncacheable {
+		e.value = u.value
+	}
+
+	c.mu.Lock()
+	e.expiresAt = c.now().Add(c.ttl)
+	if e.err != nil || isUncacheable {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	close(e.done)
+
+	return e.value, e.err
+}
+
+// uncacheable wraps the value fetched for a key to be returned to the callers waiting for the fetch without being cached,
+// like a list cut short by the page budget of the context.
+type uncacheable struct {
+	value interface{}
+}
+
+// isExpired returns true when the fetch of the entry has completed and its result is older than the TTL.
+func isExpired(e *responseCacheEntry, now time.Time) bool {
+	select {
+	case <-e.done:
+		return !now.Before(e.expiresAt)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	c := newResponseCache(10 * time.Second)
+	c.now = func() time.Time { return now }
+
+	var fetches int
+
+	fetch := func(err error) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			fetches++
+			return fetches, err
+		}
+	}
+
+	get := func(key string, err error) interface{} {
+		t.Helper()
+
+		v, gotErr := c.get(key, fetch(err))
+		if !errors.Is(gotErr, err) {
+			t.Fatalf("unexpected error: want %v, got %v", err, gotErr)
+		}
+		return v
+	}
+
+	if v := get("a", nil); v != 1 {
+		t.Errorf("unexpected value: want 1, got %v", v)
+	}
+
+	// Cached within the TTL
+	now = now.Add(9 * time.Second)
+
+	if v := get("a", nil); v != 1 {
+		t.Errorf("unexpected value within the TTL: want 1, got %v", v)
+	}
+
+	// Keyed separately
+	if v := get("b", nil); v != 2 {
+		t.Errorf("unexpected value of another key: want 2, got %v", v)
+	}
+
+	// Expired after the TTL
+	now = now.Add(time.Second)
+
+	if v := get("a", nil); v != 3 {
+		t.Errorf("unexpected value after the TTL: want 3, got %v", v)
+	}
+
+	// Errors aren't cached
+	errFetch := errors.New("rate limited")
+
+	get("c", errFetch)
+
+	if v := get("c", nil); v != 5 {
+		t.Errorf("unexpected value after an error: want 5, got %v", v)
+	}
+
+	// Disabled without the TTL
+	disabled := newResponseCache(0)
+
+	for i := 0; i < 2; i++ {
+		disabled.get("a", fetch(nil))
+	}
+
+	if fetches != 7 {
+		t.Errorf("unexpected number of fetches: want 7, got %d", fetches)
+	}
+}
+
+func TestResponseCache_Concurrent(t *testing.T) {
+	c := newResponseCache(time.Minute)
+
+	var (
+		mu      sync.Mutex
+		fetches int
+		wg      sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, err := c.get("a", func() (interface{}, error) {
+				mu.Lock()
+				fetches++
+				mu.Unlock()
+
+				<-release
+
+				return "runs", nil
+			})
+
+			if err != nil || v != "runs" {
+				t.Errorf("unexpected result: %v, %v", v, err)
+			}
+		}()
+	}
+
+	// Give the goroutines a chance to wait for the first fetch
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("unexpected number of fetches: want 1, got %d", fetches)
+	}
+}
+
+func TestClient_ResponseCache(t *testing.T) {
+	requests := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests[req.URL.Path+"?"+req.URL.Query().Get("status")]++
+
+		if req.URL.Path == "/repos/test/valid/actions/runs" {
+			fmt.Fprint(w, `{"total_count": 1, "workflow_runs": [{"id": 1, "status": "queued"}]}`)
+		} else {
+			fmt.Fprint(w, `{"total_count": 1, "jobs": [{"id": 1, "status": "queued"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	c, err := (&Config{Token: "token", ResponseCacheTTL: time.Minute}).NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Client.BaseURL = baseURL
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		runs, err := c.ListRepositoryWorkflowRuns(ctx, "test", "valid")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(runs) != 2 {
+			t.Errorf("unexpected number of workflow runs: want 2, got %d", len(runs))
+		}
+
+		jobs, err := c.ListWorkflowJobs(ctx, "test", "valid", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(jobs) != 1 {
+			t.Errorf("unexpected number of workflow jobs: want 1, got %d", len(jobs))
+		}
+	}
+
+	want := map[string]int{
+		"/repos/test/valid/actions/runs?queued":      1,
+		"/repos/test/valid/actions/runs?in_progress": 1,
+		"/repos/test/valid/actions/runs/1/jobs?":     1,
+	}
+
+	for k, v := range want {
+		if requests[k] != v {
+			t.Errorf("unexpected number of requests to %s: want %d, got %d", k, v, requests[k])
+		}
+	}
+
+	if len(requests) != len(want) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
@@ -0,0 +1,204 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// RunnerGroupSettings is the settings of a runner group of an enterprise or an organization.
+type RunnerGroupSettings struct {
+	Name                     string `json:"name"`
+	Visibility               string `json:"visibility,omitempty"`
+	AllowsPublicRepositories bool   `json:"allows_public_repositories"`
+}
+
+// runnerGroupsPath returns the path of the runner groups of the enterprise, or the organization if the enterprise is empty.
+func runnerGroupsPath(enterprise, org string) (string, error) {
+	switch {
+	case enterprise != "" && org != "":
+		return "", errors.New("only one of enterprise and organization can be set for a runner group")
+	case enterprise != "":
+		return fmt.Sprintf("enterprises/%s/actions/runner-groups", enterprise), nil
+	case org != "":
+		return fmt.Sprintf("orgs/%s/actions/runner-groups", org), nil
+	}
+
+	return "", errors.New("either enterprise or organization must be set for a runner group")
+}
+
+// runnerGroupAccessPath returns the path of the organizations that can access the enterprise runner group,
+// or the repositories that can access the organization runner group, along with the key of their IDs in the requests to set them.
+func runnerGroupAccessPath(enterprise, org string, id int64) (string, string, error) {
+	p, err := runnerGroupsPath(enterprise, org)
+	if err != nil {
+		return "", "", err
+	}
+
+	if enterprise != "" {
+		return fmt.Sprintf("%s/%d/organizations", p, id), "selected_organization_ids", nil
+	}
+
+	return fmt.Sprintf("%s/%d/repositories", p, id), "selected_repository_ids", nil
+}
+
+// runnerGroupAccess is the list of the organizations or the repositories that can access a runner group.
+type runnerGroupAccess struct {
+	TotalCount    int `json:"total_count"`
+	Organizations []struct {
+		ID int64 `json:"id"`
+	} `json:"organizations,omitempty"`
+	Repositories []struct {
+		ID int64 `json:"id"`
+	} `json:"repositories,omitempty"`
+}
+
+// GetRunnerGroupByName returns the runner group of the name defined in the enterprise or the organization,
+// or nil if there's none. The runner groups inherited to the organization from its enterprise aren't returned.
+func (c *Client) GetRunnerGroupByName(ctx context.Context, enterprise, org, name string) (*github.RunnerGroup, error) {
+	p, err := runnerGroupsPath(enterprise, org)
+	if err != nil {
+		return nil, err
+	}
+
+	for page := 1; page != 0; {
+		req, err := c.Client.NewRequest("GET", fmt.Sprintf("%s?per_page=100&page=%d", p, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var list github.RunnerGroups
+		res, err := c.Client.Do(ctx, req, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runner groups: %w", err)
+		}
+
+		for _, g := range list.RunnerGroups {
+			if g.GetName() == name && !g.GetInherited() {
+				return g, nil
+			}
+		}
+
+		page = res.NextPage
+	}
+
+	return nil, nil
+}
+
+// CreateRunnerGroup creates the runner group in the enterprise or the organization.
+func (c *Client) CreateRunnerGroup(ctx context.Context, enterprise, org string, settings RunnerGroupSettings) (*github.RunnerGroup, error) {
+	p, err := runnerGroupsPath(enterprise, org)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.Client.NewRequest("POST", p, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var group github.RunnerGroup
+	if _, err := c.Client.Do(ctx, req, &group); err != nil {
+		return nil, fmt.Errorf("failed to create runner group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// UpdateRunnerGroup updates the settings of the runner group of the enterprise or the organization.
+func (c *Client) UpdateRunnerGroup(ctx context.Context, enterprise, org string, id int64, settings RunnerGroupSettings) error {
+	p, err := runnerGroupsPath(enterprise, org)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.Client.NewRequest("PATCH", fmt.Sprintf("%s/%d", p, id), settings)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("failed to update runner group: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRunnerGroup deletes the runner group of the enterprise or the organization.
+func (c *Client) DeleteRunnerGroup(ctx context.Context, enterprise, org string, id int64) error {
+	p, err := runnerGroupsPath(enterprise, org)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.Client.NewRequest("DELETE", fmt.Sprintf("%s/%d", p, id), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("failed to delete runner group: %w", err)
+	}
+
+	return nil
+}
+
+// ListRunnerGroupAccess returns the IDs of the organizations that can access the enterprise runner group,
+// or the repositories that can access the organization runner group, when its visibility is "selected".
+func (c *Client) ListRunnerGroupAccess(ctx context.Context, enterprise, org string, id int64) ([]int64, error) {
+	p, _, err := runnerGroupAccessPath(enterprise, org, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+
+	for page := 1; page != 0; {
+		req, err := c.Client.NewRequest("GET", fmt.Sprintf("%s?per_page=100&page=%d", p, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var list runnerGroupAccess
+		res, err := c.Client.Do(ctx, req, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runner group access: %w", err)
+		}
+
+		for _, v := range append(list.Organizations, list.Repositories...) {
+			ids = append(ids, v.ID)
+		}
+
+		page = res.NextPage
+	}
+
+	return ids, nil
+}
+
+// SetRunnerGroupAccess replaces the organizations that can access the enterprise runner group,
+// or the repositories that can access the organization runner group, with the ones of the IDs.
+func (c *Client) SetRunnerGroupAccess(ctx context.Context, enterprise, org string, id int64, ids []int64) error {
+	p, key, err := runnerGroupAccessPath(enterprise, org, id)
+	if err != nil {
+		return err
+	}
+
+	if ids == nil {
+		ids = []int64{}
+	}
+
+	body := map[string][]int64{key: ids}
+
+	req, err := c.Client.NewRequest("PUT", p, body)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.Client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("failed to set runner group access: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	"github.com/google/go-github/v39/github"
+)
+
+func newScenarioClient(t *testing.T, s *fake.Scenario) *Client {
+	t.Helper()
+
+	server := s.GetServer()
+	t.Cleanup(server.Close)
+
+	c := Config{Token: "token"}
+	client, err := c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Client.BaseURL = baseURL
+
+	return client
+}
+
+func TestScenario_RateLimit(t *testing.T) {
+	s := fake.NewScenario().
+		AddRunners(&github.Runner{Name: github.String("runner-1")}).
+		On("GET", "/repos/{owner}/{repo}/actions/runners", fake.RateLimited(time.Now().Add(-time.Second)), fake.Passthrough())
+
+	client := newScenarioClient(t, s)
+	ctx := context.Background()
+
+	_, err := client.ListRunners(ctx, "", "", "test/valid")
+
+	var rateLimitErr *github.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+
+	runners, err := client.ListRunners(ctx, "", "", "test/valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runners) != 1 {
+		t.Errorf("unexpected number of runners: want 1, got %d", len(runners))
+	}
+
+	s.AssertRequested(t, "GET", "/repos/test/valid/actions/runners", 2)
+}
+
+func TestScenario_Pagination(t *testing.T) {
+	s := fake.NewScenario().Paginate(2)
+	for _, name := range []string{"runner-1", "runner-2", "runner-3"} {
+		s.AddRunners(&github.Runner{Name: github.String(name)})
+	}
+
+	client := newScenarioClient(t, s)
+	ctx := context.Background()
+
+	runners, err := client.ListRunners(ctx, "", "test", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runners) != 3 {
+		t.Errorf("unexpected number of runners: want 3, got %d", len(runners))
+	}
+
+	s.AssertRequested(t, "GET", "/orgs/test/actions/runners", 2)
+	s.AssertRequested(t, "GET", "/orgs/test/actions/runners?page=2", 1)
+
+	if err := client.RemoveRunner(ctx, "", "test", "", runners[0].GetID()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(s.Runners()); got != 2 {
+		t.Errorf("unexpected number of runners after removal: want 2, got %d", got)
+	}
+}
+
+func TestScenario_WorkflowRunTransitions(t *testing.T) {
+	s := fake.NewScenario().
+		AddWorkflowRun("test", "valid",
+			&github.WorkflowRun{ID: github.Int64(1), Status: github.String("queued")},
+			&github.WorkflowJob{ID: github.Int64(10), Status: github.String("queued")},
+		)
+
+	client := newScenarioClient(t, s)
+	ctx := context.Background()
+
+	for _, status := range []string{"queued", "in_progress", "completed"} {
+		s.SetWorkflowRunStatus(1, status)
+
+		runs, err := client.ListRepositoryWorkflowRuns(ctx, "test", "valid")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := 1
+		if status == "completed" {
+			want = 0
+		}
+
+		if len(runs) != want {
+			t.Errorf("unexpected number of runs in %s: want %d, got %d", status, want, len(runs))
+		}
+
+		jobs, err := client.ListWorkflowJobs(ctx, "test", "valid", 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(jobs) != 1 || jobs[0].GetStatus() != status {
+			t.Errorf("unexpected jobs in %s: %+v", status, jobs)
+		}
+	}
+
+	s.AssertRequested(t, "GET", "/repos/test/valid/actions/runs?status=queued", 3)
+	s.AssertNotRequested(t, "DELETE", "/repos/{owner}/{repo}/actions/runners/{id}")
+}
+
+func TestScenario_ErrorInjection(t *testing.T) {
+	s := fake.NewScenario().
+		On("GET", "/repos/test/valid/actions/runners", fake.Response{CloseConnection: true}, fake.ErrorResponse(502, "Server Error").Delayed(10*time.Millisecond))
+
+	client := newScenarioClient(t, s)
+	ctx := context.Background()
+
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); err == nil {
+		t.Error("expected an error for the closed connection")
+	}
+
+	var errRes *github.ErrorResponse
+	if _, err := client.ListRunners(ctx, "", "", "test/valid"); !errors.As(err, &errRes) || errRes.Response.StatusCode != 502 {
+		t.Errorf("expected a 502 error response, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package plugin implements the calls to the executables and the HTTP services operated outside of the controller,
+// which are shared by the plugin mechanisms like the metric providers, the runner provisioners and the credentials providers.
+//
+// Each plugin package defines its own request and response, and uses this package only to deliver them.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeout returns d, or def when d isn't positive.
+func Timeout(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+// IsURL returns true when the target of a plugin definition is the URL of an HTTP service rather than a command.
+func IsURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// Exec runs the command within the timeout with stdin, which is nil for no input, and returns its stdout.
+// The error contains the stderr of the command.
+func Exec(ctx context.Context, command []string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Response is the response of an HTTP service.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Do sends the request to the URL within the timeout with the client, or http.DefaultClient when it's nil.
+// body is sent as JSON when it's not nil.
+// The response is returned regardless of its status code, so that the caller decides whether the body can be included in its error.
+func Do(ctx context.Context, client *http.Client, method, url string, body []byte, timeout time.Duration) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	return &Response{StatusCode: res.StatusCode, Body: resBody}, nil
+}
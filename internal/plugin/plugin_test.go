@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExec(t *testing.T) {
+	out, err := Exec(context.Background(), []string{"cat"}, []byte(`{"a":1}`), time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(out) != `{"a":1}` {
+		t.Errorf("unexpected stdout: %s", out)
+	}
+
+	if _, err := Exec(context.Background(), []string{"sh", "-c", "echo oops >&2; exit 1"}, nil, time.Second); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("the error must contain the stderr: %v", err)
+	}
+
+	if _, err := Exec(context.Background(), []string{"sleep", "1"}, nil, 10*time.Millisecond); err == nil {
+		t.Errorf("expected error for the command exceeding the timeout")
+	}
+}
+
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if r.Method == http.MethodPost && r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	defer server.Close()
+
+	res, err := Do(context.Background(), nil, http.MethodPost, server.URL, []byte(`{"a":1}`), time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.StatusCode != http.StatusTeapot || string(res.Body) != `POST {"a":1}` {
+		t.Errorf("unexpected response: %d %s", res.StatusCode, res.Body)
+	}
+
+	res, err = Do(context.Background(), nil, http.MethodGet, server.URL, nil, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(res.Body) != "GET " {
+		t.Errorf("unexpected response: %d %s", res.StatusCode, res.Body)
+	}
+}
+
+func TestIsURL(t *testing.T) {
+	for target, want := range map[string]bool{
+		"http://provider.default.svc/": true,
+		"https://provider.example.com": true,
+		"/usr/local/bin/provider --v":  false,
+	} {
+		if got := IsURL(target); got != want {
+			t.Errorf("IsURL(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -27,11 +29,20 @@ import (
 	"github.com/actions-runner-controller/actions-runner-controller/controllers"
 	"github.com/actions-runner-controller/actions-runner-controller/github"
 	"github.com/actions-runner-controller/actions-runner-controller/logging"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/credentialsprovider"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/labelmapping"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/metricprovider"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/podmutator"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/provisioner"
 	"github.com/kelseyhightower/envconfig"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -63,6 +74,19 @@ func (i *stringSlice) Set(value string) error {
 	return nil
 }
 
+// controllerDefaults are the controller-wide defaults that can also be set via the envvars named after their flags,
+// like SYNC_PERIOD for --sync-period, so that they can be tuned via the env of the controller deployment without rebuilding the controller.
+// The flags take precedence over the envvars.
+type controllerDefaults struct {
+	SyncPeriod                 time.Duration `split_words:"true"`
+	DefaultScaleDownDelay      time.Duration `split_words:"true"`
+	DisableJobLevelAutoscaling bool          `split_words:"true"`
+	DisableRunLevelAutoscaling bool          `split_words:"true"`
+	RepositoryFetchConcurrency int           `split_words:"true"`
+	RunnerImage                string        `split_words:"true"`
+	DockerImage                string        `split_words:"true"`
+}
+
 func main() {
 	var (
 		err      error
@@ -73,11 +97,32 @@ func main() {
 		leaderElectionId     string
 		syncPeriod           time.Duration
 
-		gitHubAPICacheDuration time.Duration
-		defaultScaleDownDelay  time.Duration
+		gitHubAPICacheDuration           time.Duration
+		defaultScaleDownDelay            time.Duration
+		scaleFromZeroPollInterval        time.Duration
+		gitHubAPIRateLimitThreshold      int
+		gitHubAPIRetries                 int
+		gitHubAPIRetryBaseDelay          time.Duration
+		gitHubAPICircuitBreakerThreshold int
+		gitHubAPICircuitBreakerCooldown  time.Duration
+		gitHubAPIMaxPages                int
+		workflowJobCacheTTL              time.Duration
+		repositoryFetchConcurrency       int
+		gitHubStatusPolling              bool
+		gitHubStatusURL                  string
+		gitHubStatusComponent            string
+		gitHubStatusInterval             time.Duration
+		disableRunLevelAutoscaling       bool
+		disableJobLevelAutoscaling       bool
+		drainMode                        bool
+		scaleDownGracePeriod             time.Duration
+		dryRun                           bool
+		federation                       bool
+		runnerUnregistrationTimeout      time.Duration
 
-		runnerImage            string
-		runnerImagePullSecrets stringSlice
+		runnerImage                 string
+		runnerImagePullSecrets      stringSlice
+		runnerImagePullSecretSource string
 
 		dockerImage          string
 		dockerRegistryMirror string
@@ -85,6 +130,87 @@ func main() {
 		logLevel             string
 
 		commonRunnerLabels commaSeparatedStringSlice
+
+		runnerDefaultNodeSelector commaSeparatedStringSlice
+		runnerDefaultTolerations  commaSeparatedStringSlice
+
+		runnerLabelAliases commaSeparatedStringSlice
+
+		runnerPodMutators commaSeparatedStringSlice
+
+		metricProviders       stringSlice
+		metricProviderTimeout time.Duration
+
+		canaryRepository string
+		canaryWorkflow   string
+		canaryRef        string
+		canaryInterval   time.Duration
+		canaryTimeout    time.Duration
+
+		runnerInventoryToken string
+		runnerFleetToken     string
+
+		runnerDeploymentPreviewToken string
+
+		adminAPIToken string
+
+		externalMetrics bool
+
+		runnerProvisioners       stringSlice
+		runnerProvisionerTimeout time.Duration
+
+		runnerVersionDriftDetection   bool
+		runnerVersionDriftInterval    time.Duration
+		runnerVersionRecycleThreshold int
+
+		runnerUtilizationSampling         bool
+		runnerUtilizationSamplingInterval time.Duration
+		runnerUtilizationReportWindow     time.Duration
+
+		runnerStatusSync         bool
+		runnerStatusSyncInterval time.Duration
+		runnerStatusMaxAge       time.Duration
+
+		runnerReadyRequiresOnline bool
+		runnerOnlineDeadline      time.Duration
+
+		runnerRegistrationGC                 bool
+		runnerRegistrationGCInterval         time.Duration
+		runnerRegistrationGCOfflineThreshold time.Duration
+		registrationTokenRefreshInterval     time.Duration
+
+		githubCredentialsProvider       string
+		githubCredentialsReloadInterval time.Duration
+
+		runnerRightsizing              bool
+		runnerRightsizingInterval      time.Duration
+		runnerRightsizingPrometheusURL string
+
+		runnerEphemeralStorageMonitoring       bool
+		runnerEphemeralStorageInterval         time.Duration
+		runnerEphemeralStorageRecycleThreshold int
+
+		interruptedJobRerunInterval  time.Duration
+		interruptedJobMaxRunAttempts int
+
+		runnerLabelMappingsFile string
+
+		reconcileErrorBudget controllers.ReconcileErrorBudget
+
+		shardCount    int
+		shardIndex    int
+		shardSelector string
+
+		prometheusMonitors                 bool
+		prometheusMonitorInterval          time.Duration
+		prometheusMonitorPortName          string
+		prometheusMonitorLabels            string
+		prometheusMonitorControllerService string
+		prometheusMonitorControllerScheme  string
+
+		scalingDecisionLogFile string
+
+		allowRunnerNetworkExposure bool
 	)
 
 	var c github.Config
@@ -94,13 +220,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	defaults := controllerDefaults{
+		SyncPeriod:                 1 * time.Minute,
+		DefaultScaleDownDelay:      controllers.DefaultScaleDownDelay,
+		RepositoryFetchConcurrency: controllers.DefaultRepositoryFetchConcurrency,
+		RunnerImage:                defaultRunnerImage,
+		DockerImage:                defaultDockerImage,
+	}
+	if err := envconfig.Process("", &defaults); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: processing environment variables: %v\n", err)
+		os.Exit(1)
+	}
+
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&leaderElectionId, "leader-election-id", "actions-runner-controller", "Controller id for leader election.")
-	flag.StringVar(&runnerImage, "runner-image", defaultRunnerImage, "The image name of self-hosted runner container.")
-	flag.StringVar(&dockerImage, "docker-image", defaultDockerImage, "The image name of docker sidecar container.")
+	flag.StringVar(&runnerImage, "runner-image", defaults.RunnerImage, "The image name of self-hosted runner container. Can also be set via the RUNNER_IMAGE envvar.")
+	flag.StringVar(&dockerImage, "docker-image", defaults.DockerImage, "The image name of docker sidecar container. Can also be set via the DOCKER_IMAGE envvar.")
 	flag.Var(&runnerImagePullSecrets, "runner-image-pull-secret", "The default image-pull secret name for self-hosted runner container.")
+	flag.StringVar(&runnerImagePullSecretSource, "runner-image-pull-secret-source", "", "The NAMESPACE/NAME of the central image-pull secret that is copied into the namespace of each runner on demand and attached to all the runner pods.")
 	flag.StringVar(&dockerRegistryMirror, "docker-registry-mirror", "", "The default Docker Registry Mirror used by runners.")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
@@ -111,17 +250,115 @@ func main() {
 	flag.StringVar(&c.BasicauthUsername, "github-basicauth-username", c.BasicauthUsername, "Username for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.BasicauthPassword, "github-basicauth-password", c.BasicauthPassword, "Password for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.RunnerGitHubURL, "runner-github-url", c.RunnerGitHubURL, "GitHub URL to be used by runners during registration")
+	flag.StringVar(&c.RecordFixtures, "github-record-fixtures", c.RecordFixtures, "For testing only. The path to the file to which every GitHub API interaction is recorded as a sanitized fixture that can be replayed by the fake GitHub server")
+	flag.StringVar(&c.Forge, "forge", c.Forge, "The forge that serves the API at github-url, either github or the experimental gitea for Gitea and Forgejo Actions. Defaults to github. Can also be set via the GITHUB_FORGE envvar.")
+	flag.DurationVar(&c.ResponseCacheTTL, "github-api-response-cache-ttl", c.ResponseCacheTTL, "The duration for which the workflow runs and jobs listed via the GitHub API are reused by HorizontalRunnerAutoscalers and the other controllers querying the same repository and workflow run, so that they share a single set of API calls. Set to 0 to disable it. Can also be set via the GITHUB_RESPONSE_CACHE_TTL envvar.")
 	flag.DurationVar(&gitHubAPICacheDuration, "github-api-cache-duration", 0, "DEPRECATED: The duration until the GitHub API cache expires. Setting this to e.g. 10m results in the controller tries its best not to make the same API call within 10m to reduce the chance of being rate-limited. Defaults to mostly the same value as sync-period. If you're tweaking this in order to make autoscaling more responsive, you'll probably want to tweak sync-period, too")
-	flag.DurationVar(&defaultScaleDownDelay, "default-scale-down-delay", controllers.DefaultScaleDownDelay, "The approximate delay for a scale down followed by a scale up, used to prevent flapping (down->up->down->... loop)")
-	flag.DurationVar(&syncPeriod, "sync-period", 1*time.Minute, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled.")
+	flag.DurationVar(&defaultScaleDownDelay, "default-scale-down-delay", defaults.DefaultScaleDownDelay, "The approximate delay for a scale down followed by a scale up, used to prevent flapping (down->up->down->... loop). Can also be set via the DEFAULT_SCALE_DOWN_DELAY envvar.")
+	flag.DurationVar(&scaleFromZeroPollInterval, "scale-from-zero-poll-interval", 0, "The interval at which HorizontalRunnerAutoscalers whose scale targets are scaled to zero poll for queued workflow jobs, when it's shorter than sync-period. Set to 0 to poll at sync-period.")
+	flag.IntVar(&gitHubAPIRateLimitThreshold, "github-api-rate-limit-threshold", controllers.DefaultGitHubAPIRateLimitThreshold, "The number of remaining GitHub API requests below which HorizontalRunnerAutoscalers stop polling the GitHub API until the rate limit window resets. Set to 0 to poll regardless of the rate limit.")
+	flag.IntVar(&gitHubAPIRetries, "github-api-retries", controllers.DefaultGitHubAPIRetries, "The number of times HorizontalRunnerAutoscalers retry the GitHub API calls made to compute the desired replicas on transient errors like 5xx responses. Set to 0 to disable retries.")
+	flag.DurationVar(&gitHubAPIRetryBaseDelay, "github-api-retry-base-delay", controllers.DefaultGitHubAPIRetryBaseDelay, "The delay before the first retry of the GitHub API calls failed with a transient error, doubled on each retry.")
+	flag.IntVar(&gitHubAPICircuitBreakerThreshold, "github-api-circuit-breaker-threshold", controllers.DefaultGitHubAPICircuitBreakerThreshold, "The number of consecutive reconciliations of a HorizontalRunnerAutoscaler failing with transient GitHub API errors after which it stops calling the GitHub API for github-api-circuit-breaker-cooldown, keeping the desired replicas as is. Set to 0 to disable the circuit breaker.")
+	flag.DurationVar(&gitHubAPICircuitBreakerCooldown, "github-api-circuit-breaker-cooldown", controllers.DefaultGitHubAPICircuitBreakerCooldown, "The duration for which a HorizontalRunnerAutoscaler stops calling the GitHub API once the circuit breaker opens.")
+	flag.IntVar(&gitHubAPIMaxPages, "github-api-max-pages-per-reconcile", 0, "The number of the additional pages of the workflow runs, jobs and runners that a HorizontalRunnerAutoscaler lists from the GitHub API per reconciliation, beyond the first page of each list. Also stops counting the workflow jobs once the demand exceeds maxReplicas. Set to 0 to list all the pages.")
+	flag.DurationVar(&workflowJobCacheTTL, "workflow-job-cache-ttl", controllers.DefaultWorkflowJobCacheTTL, "The max age of the jobs of queued and in-progress workflow runs cached by HorizontalRunnerAutoscalers along with the result of matching them against the runner labels. The jobs of a workflow run are refetched earlier when the status of the run changes. Set to 0 to fetch the jobs of every run on every reconciliation.")
+	flag.Var(&runnerLabelAliases, "runner-label-aliases", "The runner label renames in the OLD1=NEW1,OLD2=NEW2,... format. The old and the new labels are considered the same on matching the workflow jobs to the runners, so that a label can be renamed gradually across the workflows and the runner pools. Can be specified multiple times.")
+	flag.IntVar(&repositoryFetchConcurrency, "repository-fetch-concurrency", defaults.RepositoryFetchConcurrency, "The number of the repositories whose workflow runs are fetched in parallel by HorizontalRunnerAutoscalers to count the workflow jobs of a metric with repositoryNames. Set to 1 to fetch them serially. Can also be set via the REPOSITORY_FETCH_CONCURRENCY envvar.")
+	flag.IntVar(&c.MaxConcurrentRequests, "github-api-max-concurrent-requests", c.MaxConcurrentRequests, "The max number of the in-flight GitHub API requests per GitHub API credentials, across all the controllers, to avoid the secondary rate limits of GitHub on concurrent requests. Set to 0 for no limit. Can also be set via the GITHUB_MAX_CONCURRENT_REQUESTS envvar.")
+	flag.BoolVar(&gitHubStatusPolling, "github-status-polling", false, "Periodically poll the GitHub status page, and keep HorizontalRunnerAutoscalers from scaling down while GitHub Actions is degraded, marking them with the GitHubDegraded condition.")
+	flag.StringVar(&gitHubStatusURL, "github-status-url", controllers.DefaultGitHubStatusURL, "The URL of the components API of the status page polled for the GitHub status. Any other URL is polled as a health check, which reports GitHub degraded while it responds with a non-2xx status.")
+	flag.StringVar(&gitHubStatusComponent, "github-status-component", controllers.DefaultGitHubStatusComponent, "The name of the component in the status page whose incidents freeze scale down.")
+	flag.DurationVar(&gitHubStatusInterval, "github-status-polling-interval", controllers.DefaultGitHubStatusInterval, "The interval between polls of the GitHub status.")
+	flag.DurationVar(&scaleDownGracePeriod, "scale-down-grace-period", controllers.DefaultScaleDownGracePeriod, "The period after a runner registers or is seen busy during which it isn't chosen for scale down, so that a runner just assigned a job isn't deleted before the GitHub API reports it busy. Set to 0 to disable it.")
+	flag.DurationVar(&runnerUnregistrationTimeout, "runner-unregistration-timeout", 0, "The time a runner being removed is given to complete its job before its pod is deleted anyway, which fails the job. Defaults to 0, which waits for the job to complete however long it takes.")
+	flag.BoolVar(&drainMode, "drain-mode", false, "Stop creating runners, runner pods and instances and scaling up HorizontalRunnerAutoscalers, while still unregistering and deleting runners and updating statuses, e.g. to drain the runners before upgrading the controller or the cluster.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Compute and log the changes the controllers would make, like creating and deleting runner pods, scaling runner deployments and removing runners from GitHub, without making them. The changes of the Kubernetes objects are sent to the API server as dry-run requests, so that they're still validated. Useful to evaluate the controller against a copy of the production resources.")
+	flag.BoolVar(&federation, "enable-federation", false, "Experimental. Let HorizontalRunnerAutoscalers delegate the replicas demanded beyond their capacity to the RunnerDeployments in the other clusters listed in spec.overflow.federation.")
+	flag.BoolVar(&disableRunLevelAutoscaling, "disable-run-level-autoscaling", defaults.DisableRunLevelAutoscaling, "Make the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count workflow jobs only, without falling back to counting workflow runs whose jobs are unavailable, and reject HorizontalRunnerAutoscalers that scale up on the checkRun, pullRequest, or push webhook events. Can also be set via the DISABLE_RUN_LEVEL_AUTOSCALING envvar.")
+	flag.BoolVar(&disableJobLevelAutoscaling, "disable-job-level-autoscaling", defaults.DisableJobLevelAutoscaling, "Make the TotalNumberOfQueuedAndInProgressWorkflowRuns metric count each queued and in-progress workflow run as a single job without listing its jobs, which saves GitHub API calls at the cost of accuracy. It can't be used along with disable-run-level-autoscaling. Can also be set via the DISABLE_JOB_LEVEL_AUTOSCALING envvar.")
+	flag.DurationVar(&syncPeriod, "sync-period", defaults.SyncPeriod, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled. Can also be set via the SYNC_PERIOD envvar.")
 	flag.Var(&commonRunnerLabels, "common-runner-labels", "Runner labels in the K1=V1,K2=V2,... format that are inherited all the runners created by the controller. See https://github.com/actions-runner-controller/actions-runner-controller/issues/321 for more information")
 	flag.StringVar(&namespace, "watch-namespace", "", "The namespace to watch for custom resources. Set to empty for letting it watch for all namespaces.")
 	flag.StringVar(&logLevel, "log-level", logging.LogLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
+	flag.Var(&metricProviders, "metric-provider", "The external metric provider in the NAME=COMMAND or NAME=URL format, that can be referenced from HRA metrics of the External type. Can be specified multiple times.")
+	flag.DurationVar(&metricProviderTimeout, "metric-provider-timeout", metricprovider.DefaultTimeout, "The timeout of each call to an external metric provider.")
+	flag.StringVar(&canaryRepository, "canary-repository", "", "The repository in the OWNER/REPO format that contains the canary workflow. Setting this and --canary-workflow enables the canary job prober.")
+	flag.StringVar(&canaryWorkflow, "canary-workflow", "", "The file name of the canary workflow, like canary.yml. It must accept the `labels` input that is a JSON array of runner labels.")
+	flag.StringVar(&canaryRef, "canary-ref", "main", "The git ref on which the canary workflow is dispatched.")
+	flag.DurationVar(&canaryInterval, "canary-interval", controllers.DefaultCanaryInterval, "The interval between canary probes of all the runner pools.")
+	flag.DurationVar(&canaryTimeout, "canary-timeout", controllers.DefaultCanaryTimeout, "The duration after which a canary probe of a runner pool is considered failed.")
+	flag.StringVar(&runnerInventoryToken, "runner-inventory-token", os.Getenv("RUNNER_INVENTORY_TOKEN"), "The bearer token required to access the runner inventory served at /runners on the metrics endpoint. The inventory is disabled when empty. Can also be set via the RUNNER_INVENTORY_TOKEN envvar.")
+	flag.StringVar(&runnerFleetToken, "runner-fleet-token", os.Getenv("RUNNER_FLEET_TOKEN"), "The bearer token required to access the runner fleet summary served at /fleet on the metrics endpoint. The summary is disabled when empty. Can also be set via the RUNNER_FLEET_TOKEN envvar.")
+	flag.BoolVar(&externalMetrics, "external-metrics", false, "Serve the github_queued_jobs and github_busy_runners_percentage metrics of runnerdeployments via the External Metrics API on the webhook server, so that HorizontalPodAutoscalers can scale runnerdeployments. Requires the APIService for external.metrics.k8s.io/v1beta1 to point to the webhook service.")
+	flag.StringVar(&runnerDeploymentPreviewToken, "runner-deployment-preview-token", os.Getenv("RUNNER_DEPLOYMENT_PREVIEW_TOKEN"), "The bearer token required to request dry-runs of runnerdeployment changes served at /runnerdeployments/preview on the metrics endpoint. The endpoint is disabled when empty. Can also be set via the RUNNER_DEPLOYMENT_PREVIEW_TOKEN envvar.")
+	flag.StringVar(&adminAPIToken, "admin-api-token", os.Getenv("ADMIN_API_TOKEN"), "The bearer token required to access the admin API served at /admin/v1/ on the metrics endpoint, which lists runners by state, triggers HorizontalRunnerAutoscaler syncs, explains scaling decisions, and drains runner pools. The admin API is disabled when empty. Can also be set via the ADMIN_API_TOKEN envvar.")
+	flag.Var(&runnerProvisioners, "runner-provisioner", "The runner provisioner in the NAME=COMMAND or NAME=URL format, that can be referenced from the provisioner field of runners to back them by something other than pods. Can be specified multiple times.")
+	flag.DurationVar(&runnerProvisionerTimeout, "runner-provisioner-timeout", provisioner.DefaultTimeout, "The timeout of each call to a runner provisioner.")
+	flag.BoolVar(&runnerVersionDriftDetection, "runner-version-drift-detection", false, "Periodically compare the versions of runners read from their image tags against the latest release of actions/runner, and report the drift via the RunnerVersionUpToDate condition and metrics.")
+	flag.DurationVar(&runnerVersionDriftInterval, "runner-version-drift-interval", controllers.DefaultRunnerVersionDriftInterval, "The interval between runner version drift checks.")
+	flag.IntVar(&runnerVersionRecycleThreshold, "runner-version-recycle-threshold", 0, "The number of minor versions behind the latest release of actions/runner at which idle runners older than their runner pool's image are recycled one at a time. Set to 0 to disable recycling.")
+	flag.BoolVar(&runnerUtilizationSampling, "runner-utilization-sampling", false, "Periodically sample whether each runner is busy, and accumulate the registered time, the busy time and the number of jobs of the runners in the status of the runners and their RunnerDeployments.")
+	flag.DurationVar(&runnerUtilizationSamplingInterval, "runner-utilization-sampling-interval", controllers.DefaultRunnerUtilizationSamplingInterval, "The interval between runner utilization samples.")
+	flag.DurationVar(&runnerUtilizationReportWindow, "runner-utilization-report-window", 0, "When non-zero, the runner utilization sampling also reports the runner-hours, the busy hours, the average utilization and the peak concurrent runners of each RunnerDeployment over the rolling window of this length, rounded up to hours, in its status and the metrics. Requires --runner-utilization-sampling.")
+	flag.BoolVar(&runnerStatusSync, "runner-status-sync", false, "Periodically sync whether each runner is busy, idle or offline on GitHub into the status of the runner, shown by `kubectl get runners`. RunnerReplicaSets and HorizontalRunnerAutoscalers then find busy runners from the synced statuses instead of listing the runners via the GitHub API.")
+	flag.DurationVar(&runnerStatusSyncInterval, "runner-status-sync-interval", controllers.DefaultRunnerStatusSyncInterval, "The interval between runner status syncs. The synced statuses older than twice the interval are ignored.")
+	flag.BoolVar(&runnerReadyRequiresOnline, "runner-ready-requires-online", false, "Mark a runner ready, and count it in the available and ready replicas of its RunnerReplicaSet, only once GitHub reports the runner online rather than as soon as its pod becomes Ready. The pod of a runner that doesn't come online within runner-online-deadline is recreated.")
+	flag.DurationVar(&runnerOnlineDeadline, "runner-online-deadline", controllers.DefaultRunnerOnlineDeadline, "The duration within which a runner has to come online on GitHub since its pod became Ready before the pod is recreated, when runner-ready-requires-online is enabled.")
+	flag.BoolVar(&runnerRegistrationGC, "runner-registration-gc", false, "Periodically remove the offline runners registered to GitHub without live runners in the cluster, like the ones left by crashed nodes. Only the runners named after RunnerDeployments and RunnerSets are removed.")
+	flag.DurationVar(&runnerRegistrationGCInterval, "runner-registration-gc-interval", controllers.DefaultRunnerRegistrationGCInterval, "The interval between runner registration garbage collections.")
+	flag.DurationVar(&runnerRegistrationGCOfflineThreshold, "runner-registration-gc-offline-threshold", controllers.DefaultRunnerRegistrationGCOfflineThreshold, "The duration for which a runner registered to GitHub without a live runner needs to be seen offline before it's removed.")
+	flag.DurationVar(&registrationTokenRefreshInterval, "registration-token-refresh-interval", controllers.DefaultRegistrationTokenRefreshInterval, "The interval between refreshes of the cached runner registration tokens ahead of their expiry. Only the tokens of the enterprises, organizations and repositories runners were created for within the last hour are refreshed. Set to 0 to disable it.")
+	flag.StringVar(&githubCredentialsProvider, "github-credentials-provider", "", "The command or the URL that returns the GitHub API credentials as JSON, like a script reading them from AWS Secrets Manager or Vault. The credentials are read on startup instead of the github-token and github-app flags, and re-read every github-credentials-reload-interval to pick up the rotated credentials without restarting the controller.")
+	flag.DurationVar(&githubCredentialsReloadInterval, "github-credentials-reload-interval", controllers.DefaultGitHubCredentialsReloadInterval, "The interval at which the GitHub API credentials are re-read from the github-credentials-provider.")
+	flag.BoolVar(&runnerRightsizing, "runner-rightsizing", false, "Periodically sample the CPU and memory usage of the containers of runner pods, and recommend resource requests in the status of their RunnerDeployments. The recommendation is applied to the RunnerDeployments with spec.rightsizing.autoApply enabled.")
+	flag.DurationVar(&runnerRightsizingInterval, "runner-rightsizing-interval", controllers.DefaultRunnerRightsizingInterval, "The interval between runner resource usage samples.")
+	flag.StringVar(&runnerRightsizingPrometheusURL, "runner-rightsizing-prometheus-url", "", "The URL of the Prometheus server to query the resource usage of runner pods from, like http://prometheus.monitoring:9090. The usage is read from the metrics API served by metrics-server when empty.")
+	flag.BoolVar(&runnerEphemeralStorageMonitoring, "runner-ephemeral-storage-monitoring", false, "Periodically read the ephemeral storage used by runner pods with ephemeral storage limits from the kubelet, and recycle the idle runners approaching their limits.")
+	flag.DurationVar(&runnerEphemeralStorageInterval, "runner-ephemeral-storage-monitoring-interval", controllers.DefaultRunnerEphemeralStorageInterval, "The interval between runner ephemeral storage usage checks.")
+	flag.IntVar(&runnerEphemeralStorageRecycleThreshold, "runner-ephemeral-storage-recycle-threshold", controllers.DefaultRunnerEphemeralStorageRecycleThreshold, "The percentage of the ephemeral storage limit of a runner pod at or above which the runner is recycled once it's idle. Set to 0 to disable recycling.")
+	flag.DurationVar(&interruptedJobRerunInterval, "interrupted-job-rerun-interval", controllers.DefaultInterruptedJobRerunInterval, "The interval between checks for the failed workflow runs interrupted by the loss of runners of the runner pools with rerunInterruptedJobs enabled.")
+	flag.IntVar(&interruptedJobMaxRunAttempts, "interrupted-job-max-run-attempts", controllers.DefaultInterruptedJobMaxRunAttempts, "The max number of attempts of a workflow run up to which the failed jobs interrupted by the loss of runners are re-run.")
+	flag.StringVar(&runnerLabelMappingsFile, "runner-label-mappings", "", "The path to the YAML file that maps runner labels to the node selectors, tolerations and resources of runner pods, along with the GPU profiles, usually mounted from a ConfigMap. The mappings are applied to runner pods created after the controller starts.")
+	flag.Var(&runnerDefaultNodeSelector, "runner-default-node-selector", "The node selectors in the K1=V1,K2=V2,... format merged into every runner pod created by the controller. A key selected in the runner spec takes precedence. Can be specified multiple times.")
+	flag.Var(&runnerDefaultTolerations, "runner-default-tolerations", "The tolerations in the KEY[=VALUE][:EFFECT],... format merged into every runner pod created by the controller, along with the ones in the runner spec. A toleration without a value tolerates any value, and one without an effect tolerates all the effects. Can be specified multiple times.")
+	flag.Var(&runnerPodMutators, "runner-pod-mutators", fmt.Sprintf("The names of the runner pod mutators built into the controller, applied to every runner pod in the given order. The built-in mutators are %v.", podmutator.Registered()))
+	flag.IntVar(&reconcileErrorBudget.Threshold, "reconcile-error-threshold", controllers.DefaultReconcileErrorThreshold, "The number of consecutive reconcile failures of a Runner, RunnerReplicaSet, RunnerDeployment, RunnerSet, RunnerGroup or HorizontalRunnerAutoscaler after which it's considered degraded and retried only at reconcile-degraded-requeue-interval until a reconciliation succeeds. Set to 0 to retry with the exponential backoff forever.")
+	flag.DurationVar(&reconcileErrorBudget.DegradedRequeueInterval, "reconcile-degraded-requeue-interval", controllers.DefaultReconcileDegradedRequeueInterval, "The interval at which the objects whose reconciliations keep failing are retried.")
+	flag.IntVar(&shardCount, "shard-count", 1, "The number of the shards the HorizontalRunnerAutoscalers are spread across, each of which is run by its own controller replicas that elect a leader per shard. The HRAs are assigned to the shards by the consistent hash of their namespaces and names. Only the shard at index 0 runs the other controllers and periodic tasks.")
+	flag.IntVar(&shardIndex, "shard-index", 0, "The index of the shard the controller runs, from 0 to shard-count - 1.")
+	flag.StringVar(&shardSelector, "shard-selector", "", "The label selector of the HorizontalRunnerAutoscalers the shard reconciles, like shard=large, instead of the ones assigned to it by the consistent hash. Every HRA needs to be matched by exactly one of the shards.")
+	flag.BoolVar(&prometheusMonitors, "prometheus-monitors", false, "Periodically generate the ServiceMonitor of the controller and the PodMonitors of the runner pods exposing metrics for the Prometheus Operator, when its CRDs are installed.")
+	flag.DurationVar(&prometheusMonitorInterval, "prometheus-monitor-interval", controllers.DefaultPrometheusMonitorInterval, "The interval between the generations of the Prometheus monitors.")
+	flag.StringVar(&prometheusMonitorPortName, "prometheus-monitor-port-name", controllers.DefaultPrometheusMonitorPortName, "The name of the container port of runner pods to scrape metrics from. A PodMonitor is generated for each RunnerDeployment and RunnerSet whose pods have a container port of the name.")
+	flag.StringVar(&prometheusMonitorLabels, "prometheus-monitor-labels", "", "The labels in the K1=V1,K2=V2,... format added to the generated Prometheus monitors, e.g. to be selected by the Prometheus.")
+	flag.StringVar(&prometheusMonitorControllerService, "prometheus-monitor-controller-service", "", "The NAMESPACE/NAME of the metrics service of the controller to generate the ServiceMonitor for. It isn't generated when empty.")
+	flag.StringVar(&prometheusMonitorControllerScheme, "prometheus-monitor-controller-scheme", "http", "The scheme the metrics of the controller are scraped with, either http or https. Use https when the metrics are served via kube-rbac-proxy.")
+	flag.StringVar(&scalingDecisionLogFile, "scaling-decision-log", "", "The path to the file to which every change of the desired replicas of HorizontalRunnerAutoscalers is appended as a line of JSON, along with the reason and the observed queued and in-progress workflow jobs and busy runners that fed it. Set to - to write to the standard output, e.g. to be shipped along with the container logs. Disabled when empty.")
+	flag.BoolVar(&allowRunnerNetworkExposure, "allow-runner-network-exposure", false, "Allow runners to set hostNetwork, hostPorts and services, which expose the runner pods beyond the pod network, e.g. for the jobs whose services under test receive callbacks. The admission webhooks reject them otherwise.")
 	flag.Parse()
 
 	logger := logging.NewLogger(logLevel)
 
 	c.Log = &logger
+	c.DryRun = dryRun
+
+	var credentialsProvider credentialsprovider.Provider
+	if githubCredentialsProvider != "" {
+		credentialsProvider, err = credentialsprovider.Parse(githubCredentialsProvider, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: invalid --github-credentials-provider.", err)
+			os.Exit(1)
+		}
+
+		auth, err := credentialsProvider.Credentials(context.Background())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: reading GitHub credentials from the credentials provider failed.", err)
+			os.Exit(1)
+		}
+
+		c.SetAuth(*auth)
+	}
 
 	ghClient, err = c.NewClient()
 	if err != nil {
@@ -131,11 +368,27 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
+	shard, err := controllers.NewShard(shardCount, shardIndex, shardSelector)
+	if err != nil {
+		log.Error(err, "invalid --shard-count, --shard-index or --shard-selector")
+		os.Exit(1)
+	}
+
+	if disableJobLevelAutoscaling && disableRunLevelAutoscaling {
+		log.Error(fmt.Errorf("no workflow job would be counted"), "--disable-job-level-autoscaling can't be used along with --disable-run-level-autoscaling")
+		os.Exit(1)
+	}
+
+	if runnerUtilizationReportWindow > 0 && !runnerUtilizationSampling {
+		log.Error(fmt.Errorf("the report is made of the utilization samples"), "--runner-utilization-report-window requires --runner-utilization-sampling")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   leaderElectionId,
+		LeaderElectionID:   shard.LeaderElectionID(leaderElectionId),
 		Port:               9443,
 		SyncPeriod:         &syncPeriod,
 		Namespace:          namespace,
@@ -145,49 +398,189 @@ func main() {
 		os.Exit(1)
 	}
 
+	var centralImagePullSecret *types.NamespacedName
+	if runnerImagePullSecretSource != "" {
+		parts := strings.SplitN(runnerImagePullSecretSource, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Error(fmt.Errorf("%q isn't in the form of NAMESPACE/NAME", runnerImagePullSecretSource), "invalid --runner-image-pull-secret-source")
+			os.Exit(1)
+		}
+
+		centralImagePullSecret = &types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+
+	// The client of the controllers, which only logs the changes of the objects in the dry-run mode.
+	kubeClient := mgr.GetClient()
+	if dryRun {
+		kubeClient = controllers.NewDryRunClient(kubeClient, log.WithName("dryrun"))
+	}
+
+	// The controllers and tasks other than the HorizontalRunnerAutoscaler controller are run only by the primary shard.
+	primaryMgr := shard.PrimaryManager(mgr)
+
+	// The GitHub clients for the resources that reference their own GitHub API credentials with githubAPICredentialsFrom.
+	// They inherit the GitHub URLs of the controller-wide client.
+	ghClients := controllers.NewMultiGitHubClient(kubeClient, c)
+
+	provisioners := map[string]provisioner.Provisioner{}
+	for _, def := range runnerProvisioners {
+		name, p, err := provisioner.Parse(def, runnerProvisionerTimeout)
+		if err != nil {
+			log.Error(err, "unable to parse runner provisioner")
+			os.Exit(1)
+		}
+		if dryRun {
+			p = controllers.NewDryRunProvisioner(name, p, log.WithName("dryrun"))
+		}
+		provisioners[name] = p
+	}
+
+	var scalingDecisionLog io.Writer
+	switch scalingDecisionLogFile {
+	case "":
+	case "-":
+		scalingDecisionLog = os.Stdout
+	default:
+		f, err := os.OpenFile(scalingDecisionLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Error(err, "unable to open the scaling decision log")
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		scalingDecisionLog = f
+	}
+
+	var labelMappings *labelmapping.Config
+	if runnerLabelMappingsFile != "" {
+		labelMappings, err = labelmapping.Load(runnerLabelMappingsFile)
+		if err != nil {
+			log.Error(err, "unable to load runner label mappings")
+			os.Exit(1)
+		}
+	}
+
+	var runnerPodDefaults controllers.RunnerPodDefaults
+	for _, s := range runnerDefaultNodeSelector {
+		k, v, err := controllers.ParseRunnerNodeSelector(s)
+		if err != nil {
+			log.Error(err, "invalid runner-default-node-selector")
+			os.Exit(1)
+		}
+
+		if runnerPodDefaults.NodeSelector == nil {
+			runnerPodDefaults.NodeSelector = map[string]string{}
+		}
+
+		runnerPodDefaults.NodeSelector[k] = v
+	}
+	for _, s := range runnerDefaultTolerations {
+		t, err := controllers.ParseRunnerToleration(s)
+		if err != nil {
+			log.Error(err, "invalid runner-default-tolerations")
+			os.Exit(1)
+		}
+
+		runnerPodDefaults.Tolerations = append(runnerPodDefaults.Tolerations, t)
+	}
+
+	labelAliases, err := controllers.ParseRunnerLabelAliases(runnerLabelAliases)
+	if err != nil {
+		log.Error(err, "invalid runner-label-aliases")
+		os.Exit(1)
+	}
+
+	podMutators, err := podmutator.Build(runnerPodMutators)
+	if err != nil {
+		log.Error(err, "invalid runner-pod-mutators")
+		os.Exit(1)
+	}
+
 	runnerReconciler := &controllers.RunnerReconciler{
-		Client:               mgr.GetClient(),
+		Client:               kubeClient,
 		Log:                  log.WithName("runner"),
 		Scheme:               mgr.GetScheme(),
 		GitHubClient:         ghClient,
+		GitHubClients:        ghClients,
 		DockerImage:          dockerImage,
 		DockerRegistryMirror: dockerRegistryMirror,
 		// Defaults for self-hosted runner containers
 		RunnerImage:            runnerImage,
 		RunnerImagePullSecrets: runnerImagePullSecrets,
+		CentralImagePullSecret: centralImagePullSecret,
+		Provisioners:           provisioners,
+		LabelMappings:          labelMappings,
+		RunnerPodDefaults:      runnerPodDefaults,
+		PodMutators:            podMutators,
+		DrainMode:              drainMode,
+		ErrorBudget:            reconcileErrorBudget,
+
+		RunnerReadyRequiresOnline: runnerReadyRequiresOnline,
+		RunnerOnlineDeadline:      runnerOnlineDeadline,
 	}
 
-	if err = runnerReconciler.SetupWithManager(mgr); err != nil {
+	if err = runnerReconciler.SetupWithManager(primaryMgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "Runner")
 		os.Exit(1)
 	}
 
+	if runnerStatusSync {
+		if runnerStatusSyncInterval <= 0 {
+			runnerStatusSyncInterval = controllers.DefaultRunnerStatusSyncInterval
+		}
+
+		runnerStatusMaxAge = 2 * runnerStatusSyncInterval
+	}
+
 	runnerReplicaSetReconciler := &controllers.RunnerReplicaSetReconciler{
-		Client:       mgr.GetClient(),
-		Log:          log.WithName("runnerreplicaset"),
-		Scheme:       mgr.GetScheme(),
-		GitHubClient: ghClient,
+		Client:               kubeClient,
+		Log:                  log.WithName("runnerreplicaset"),
+		Scheme:               mgr.GetScheme(),
+		GitHubClient:         ghClient,
+		GitHubClients:        ghClients,
+		DrainMode:            drainMode,
+		ScaleDownGracePeriod: scaleDownGracePeriod,
+		RunnerStatusMaxAge:   runnerStatusMaxAge,
+		ErrorBudget:          reconcileErrorBudget,
+
+		RunnerReadyRequiresOnline: runnerReadyRequiresOnline,
 	}
 
-	if err = runnerReplicaSetReconciler.SetupWithManager(mgr); err != nil {
+	if err = runnerReplicaSetReconciler.SetupWithManager(primaryMgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "RunnerReplicaSet")
 		os.Exit(1)
 	}
 
 	runnerDeploymentReconciler := &controllers.RunnerDeploymentReconciler{
-		Client:             mgr.GetClient(),
+		Client:             kubeClient,
 		Log:                log.WithName("runnerdeployment"),
 		Scheme:             mgr.GetScheme(),
 		CommonRunnerLabels: commonRunnerLabels,
+		GitHubClient:       ghClient,
+		GitHubClients:      ghClients,
+		ErrorBudget:        reconcileErrorBudget,
 	}
 
-	if err = runnerDeploymentReconciler.SetupWithManager(mgr); err != nil {
+	if err = runnerDeploymentReconciler.SetupWithManager(primaryMgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "RunnerDeployment")
 		os.Exit(1)
 	}
 
+	runnerGroupReconciler := &controllers.RunnerGroupReconciler{
+		Client:        kubeClient,
+		Log:           log.WithName("runnergroup"),
+		GitHubClient:  ghClient,
+		GitHubClients: ghClients,
+		ErrorBudget:   reconcileErrorBudget,
+	}
+
+	if err = runnerGroupReconciler.SetupWithManager(primaryMgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "RunnerGroup")
+		os.Exit(1)
+	}
+
 	runnerSetReconciler := &controllers.RunnerSetReconciler{
-		Client:               mgr.GetClient(),
+		Client:               kubeClient,
 		Log:                  log.WithName("runnerset"),
 		Scheme:               mgr.GetScheme(),
 		CommonRunnerLabels:   commonRunnerLabels,
@@ -197,9 +590,18 @@ func main() {
 		// Defaults for self-hosted runner containers
 		RunnerImage:            runnerImage,
 		RunnerImagePullSecrets: runnerImagePullSecrets,
+		CentralImagePullSecret: centralImagePullSecret,
+		LabelMappings:          labelMappings,
+		RunnerPodDefaults:      runnerPodDefaults,
+		PodMutators:            podMutators,
+		DrainMode:              drainMode,
+		ScaleDownGracePeriod:   scaleDownGracePeriod,
+		GitHubClient:           ghClient,
+		GitHubClients:          ghClients,
+		ErrorBudget:            reconcileErrorBudget,
 	}
 
-	if err = runnerSetReconciler.SetupWithManager(mgr); err != nil {
+	if err = runnerSetReconciler.SetupWithManager(primaryMgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "RunnerSet")
 		os.Exit(1)
 	}
@@ -220,34 +622,116 @@ func main() {
 		"docker-image", dockerImage,
 		"common-runnner-labels", commonRunnerLabels,
 		"watch-namespace", namespace,
+		"drain-mode", drainMode,
+		"dry-run", dryRun,
+		"enable-federation", federation,
+		"shard-count", shardCount,
+		"shard-index", shardIndex,
 	)
 
+	providers := map[string]metricprovider.Provider{}
+	for _, def := range metricProviders {
+		name, p, err := metricprovider.Parse(def, metricProviderTimeout)
+		if err != nil {
+			log.Error(err, "unable to parse metric provider")
+			os.Exit(1)
+		}
+		providers[name] = p
+	}
+
+	var gitHubStatusPoller *controllers.GitHubStatusPoller
+
+	if gitHubStatusPolling {
+		gitHubStatusPoller = &controllers.GitHubStatusPoller{
+			Log:       log.WithName("githubstatus"),
+			URL:       gitHubStatusURL,
+			Component: gitHubStatusComponent,
+			Interval:  gitHubStatusInterval,
+		}
+
+		if err = mgr.Add(gitHubStatusPoller); err != nil {
+			log.Error(err, "unable to add GitHub status poller")
+			os.Exit(1)
+		}
+	}
+
+	newFederationClient := controllers.NewFederationClient(mgr.GetScheme())
+	if dryRun {
+		newClient := newFederationClient
+		newFederationClient = func(kubeconfig []byte) (client.Client, error) {
+			c, err := newClient(kubeconfig)
+			if err != nil {
+				return nil, err
+			}
+
+			return controllers.NewDryRunClient(c, log.WithName("dryrun").WithName("federation")), nil
+		}
+	}
+
 	horizontalRunnerAutoscaler := &controllers.HorizontalRunnerAutoscalerReconciler{
-		Client:                mgr.GetClient(),
-		Log:                   log.WithName("horizontalrunnerautoscaler"),
-		Scheme:                mgr.GetScheme(),
-		GitHubClient:          ghClient,
-		CacheDuration:         gitHubAPICacheDuration,
-		DefaultScaleDownDelay: defaultScaleDownDelay,
+		Client:                           kubeClient,
+		Log:                              log.WithName("horizontalrunnerautoscaler"),
+		Scheme:                           mgr.GetScheme(),
+		GitHubClient:                     ghClient,
+		GitHubClients:                    ghClients,
+		CacheDuration:                    gitHubAPICacheDuration,
+		DefaultScaleDownDelay:            defaultScaleDownDelay,
+		ScaleFromZeroPollInterval:        scaleFromZeroPollInterval,
+		GitHubAPIRateLimitThreshold:      gitHubAPIRateLimitThreshold,
+		GitHubAPIRetries:                 gitHubAPIRetries,
+		GitHubAPIRetryBaseDelay:          gitHubAPIRetryBaseDelay,
+		GitHubAPICircuitBreakerThreshold: gitHubAPICircuitBreakerThreshold,
+		GitHubAPICircuitBreakerCooldown:  gitHubAPICircuitBreakerCooldown,
+		GitHubAPIMaxPagesPerReconcile:    gitHubAPIMaxPages,
+		WorkflowJobCacheTTL:              workflowJobCacheTTL,
+		RunnerLabelAliases:               labelAliases,
+		RepositoryFetchConcurrency:       repositoryFetchConcurrency,
+		GitHubStatus:                     gitHubStatusPoller,
+		DisableRunLevelAutoscaling:       disableRunLevelAutoscaling,
+		DisableJobLevelAutoscaling:       disableJobLevelAutoscaling,
+		DrainMode:                        drainMode,
+		RunnerStatusMaxAge:               runnerStatusMaxAge,
+		Federation:                       federation,
+		NewFederationClient:              newFederationClient,
+		MetricProviders:                  providers,
+		ScaleAlgorithms:                  autoscaling.ScaleAlgorithms(),
+		ErrorBudget:                      reconcileErrorBudget,
+		Shard:                            shard,
+		ScalingDecisionLog:               scalingDecisionLog,
 	}
 
 	runnerPodReconciler := &controllers.RunnerPodReconciler{
-		Client:       mgr.GetClient(),
-		Log:          log.WithName("runnerpod"),
-		Scheme:       mgr.GetScheme(),
-		GitHubClient: ghClient,
+		Client:        kubeClient,
+		Log:           log.WithName("runnerpod"),
+		Scheme:        mgr.GetScheme(),
+		GitHubClient:  ghClient,
+		GitHubClients: ghClients,
+
+		UnregistrationTimeout: runnerUnregistrationTimeout,
 	}
 
-	if err = runnerPodReconciler.SetupWithManager(mgr); err != nil {
+	if err = runnerPodReconciler.SetupWithManager(primaryMgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "RunnerPod")
 		os.Exit(1)
 	}
 
+	runnerPodRetentionReconciler := &controllers.RunnerPodRetentionReconciler{
+		Client: kubeClient,
+		Log:    log.WithName("runnerpodretention"),
+	}
+
+	if err = runnerPodRetentionReconciler.SetupWithManager(primaryMgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "RunnerPodRetention")
+		os.Exit(1)
+	}
+
 	if err = horizontalRunnerAutoscaler.SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to create controller", "controller", "HorizontalRunnerAutoscaler")
 		os.Exit(1)
 	}
 
+	actionsv1alpha1.AllowRunnerNetworkExposure = allowRunnerNetworkExposure
+
 	if err = (&actionsv1alpha1.Runner{}).SetupWebhookWithManager(mgr); err != nil {
 		log.Error(err, "unable to create webhook", "webhook", "Runner")
 		os.Exit(1)
@@ -260,12 +744,308 @@ func main() {
 		log.Error(err, "unable to create webhook", "webhook", "RunnerReplicaSet")
 		os.Exit(1)
 	}
+	hraValidator := &controllers.HorizontalRunnerAutoscalerValidator{
+		Client:          kubeClient,
+		Log:             ctrl.Log.WithName("webhook").WithName("HorizontalRunnerAutoscaler"),
+		ScaleAlgorithms: horizontalRunnerAutoscaler.ScaleAlgorithms,
+	}
+	if err = hraValidator.SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to create webhook", "webhook", "HorizontalRunnerAutoscaler")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
+	if runnerInventoryToken != "" {
+		runnerInventory := &controllers.RunnerInventory{
+			Client:       kubeClient,
+			GitHubClient: ghClient,
+			Log:          log.WithName("runnerinventory"),
+			Token:        runnerInventoryToken,
+			Namespace:    namespace,
+		}
+
+		if err = mgr.AddMetricsExtraHandler("/runners", runnerInventory); err != nil {
+			log.Error(err, "unable to add runner inventory endpoint")
+			os.Exit(1)
+		}
+	}
+
+	if runnerFleetToken != "" {
+		runnerFleet := &controllers.RunnerFleet{
+			Client:        kubeClient,
+			GitHubClient:  ghClient,
+			GitHubClients: ghClients,
+			Log:           log.WithName("runnerfleet"),
+			Token:         runnerFleetToken,
+			Namespace:     namespace,
+		}
+
+		if err = mgr.AddMetricsExtraHandler("/fleet", runnerFleet); err != nil {
+			log.Error(err, "unable to add runner fleet endpoint")
+			os.Exit(1)
+		}
+	}
+
+	if runnerDeploymentPreviewToken != "" {
+		runnerDeploymentPreviewer := &controllers.RunnerDeploymentPreviewer{
+			Client:             kubeClient,
+			Log:                log.WithName("runnerdeploymentpreview"),
+			Token:              runnerDeploymentPreviewToken,
+			RunnerReconciler:   runnerReconciler,
+			CommonRunnerLabels: commonRunnerLabels,
+		}
+
+		if err = mgr.AddMetricsExtraHandler("/runnerdeployments/preview", runnerDeploymentPreviewer); err != nil {
+			log.Error(err, "unable to add runnerdeployment preview endpoint")
+			os.Exit(1)
+		}
+	}
+
+	if adminAPIToken != "" {
+		adminAPI := &controllers.AdminAPI{
+			Client:       kubeClient,
+			GitHubClient: ghClient,
+			Log:          log.WithName("adminapi"),
+			Token:        adminAPIToken,
+			Namespace:    namespace,
+		}
+
+		if err = mgr.AddMetricsExtraHandler("/admin/", adminAPI); err != nil {
+			log.Error(err, "unable to add admin API endpoint")
+			os.Exit(1)
+		}
+	}
+
+	if externalMetrics {
+		externalMetricsAdapter := &controllers.ExternalMetricsAdapter{
+			Client:     kubeClient,
+			Log:        log.WithName("externalmetrics"),
+			Autoscaler: horizontalRunnerAutoscaler,
+		}
+
+		if err = externalMetricsAdapter.SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to add external metrics adapter")
+			os.Exit(1)
+		}
+	}
+
+	if canaryRepository != "" && canaryWorkflow != "" {
+		canaryProber := &controllers.CanaryProber{
+			Client:             kubeClient,
+			Log:                log.WithName("canary"),
+			GitHubClient:       ghClient,
+			Repository:         canaryRepository,
+			Workflow:           canaryWorkflow,
+			Ref:                canaryRef,
+			Interval:           canaryInterval,
+			Timeout:            canaryTimeout,
+			Namespace:          namespace,
+			CommonRunnerLabels: commonRunnerLabels,
+		}
+
+		if err = primaryMgr.Add(canaryProber); err != nil {
+			log.Error(err, "unable to add canary prober")
+			os.Exit(1)
+		}
+	}
+
+	if runnerVersionDriftDetection {
+		runnerVersionDriftDetector := &controllers.RunnerVersionDriftDetector{
+			Client:           kubeClient,
+			Log:              log.WithName("runnerversion"),
+			GitHubClient:     ghClient,
+			Interval:         runnerVersionDriftInterval,
+			RecycleThreshold: runnerVersionRecycleThreshold,
+			RunnerImage:      runnerImage,
+			Namespace:        namespace,
+		}
+
+		if err = primaryMgr.Add(runnerVersionDriftDetector); err != nil {
+			log.Error(err, "unable to add runner version drift detector")
+			os.Exit(1)
+		}
+	}
+
+	if runnerUtilizationSampling {
+		runnerUtilizationSampler := &controllers.RunnerUtilizationSampler{
+			Client:        kubeClient,
+			Log:           log.WithName("runnerutilization"),
+			GitHubClient:  ghClient,
+			GitHubClients: ghClients,
+			Interval:      runnerUtilizationSamplingInterval,
+			ReportWindow:  runnerUtilizationReportWindow,
+			Namespace:     namespace,
+		}
+
+		if err = primaryMgr.Add(runnerUtilizationSampler); err != nil {
+			log.Error(err, "unable to add runner utilization sampler")
+			os.Exit(1)
+		}
+	}
+
+	if runnerStatusSync {
+		runnerStatusSyncer := &controllers.RunnerStatusSyncer{
+			Client:        kubeClient,
+			Log:           log.WithName("runnerstatus"),
+			GitHubClient:  ghClient,
+			GitHubClients: ghClients,
+			Interval:      runnerStatusSyncInterval,
+			Namespace:     namespace,
+		}
+
+		if err = primaryMgr.Add(runnerStatusSyncer); err != nil {
+			log.Error(err, "unable to add runner status syncer")
+			os.Exit(1)
+		}
+	}
+
+	if runnerRegistrationGC {
+		runnerRegistrationGC := &controllers.RunnerRegistrationGC{
+			Client:           kubeClient,
+			Log:              log.WithName("runnerregistrationgc"),
+			GitHubClient:     ghClient,
+			GitHubClients:    ghClients,
+			Interval:         runnerRegistrationGCInterval,
+			OfflineThreshold: runnerRegistrationGCOfflineThreshold,
+			Namespace:        namespace,
+		}
+
+		if err = primaryMgr.Add(runnerRegistrationGC); err != nil {
+			log.Error(err, "unable to add runner registration garbage collection")
+			os.Exit(1)
+		}
+	}
+
+	if registrationTokenRefreshInterval > 0 {
+		registrationTokenRefresher := &controllers.RegistrationTokenRefresher{
+			Log:           log.WithName("registrationtokenrefresher"),
+			GitHubClient:  ghClient,
+			GitHubClients: ghClients,
+			Interval:      registrationTokenRefreshInterval,
+		}
+
+		if err = primaryMgr.Add(registrationTokenRefresher); err != nil {
+			log.Error(err, "unable to add registration token refresher")
+			os.Exit(1)
+		}
+	}
+
+	if credentialsProvider != nil {
+		credentialsReloader := &controllers.GitHubCredentialsReloader{
+			Log:          log.WithName("githubcredentialsreloader"),
+			GitHubClient: ghClient,
+			Provider:     credentialsProvider,
+			Interval:     githubCredentialsReloadInterval,
+		}
+
+		if err = mgr.Add(credentialsReloader); err != nil {
+			log.Error(err, "unable to add github credentials reloader")
+			os.Exit(1)
+		}
+	}
+
+	if runnerRightsizing {
+		runnerRightsizer := &controllers.RunnerRightsizer{
+			Client:        kubeClient,
+			Log:           log.WithName("runnerrightsizing"),
+			PrometheusURL: runnerRightsizingPrometheusURL,
+			Interval:      runnerRightsizingInterval,
+			Namespace:     namespace,
+		}
+
+		if err = primaryMgr.Add(runnerRightsizer); err != nil {
+			log.Error(err, "unable to add runner rightsizer")
+			os.Exit(1)
+		}
+	}
+
+	if runnerEphemeralStorageMonitoring {
+		nodeStats, err := controllers.NewKubeletNodeStats(mgr.GetConfig())
+		if err != nil {
+			log.Error(err, "unable to create kubelet node stats client")
+			os.Exit(1)
+		}
+
+		runnerEphemeralStorageMonitor := &controllers.RunnerEphemeralStorageMonitor{
+			Client:           kubeClient,
+			Log:              log.WithName("runnerephemeralstorage"),
+			Recorder:         mgr.GetEventRecorderFor("runner-ephemeral-storage-monitor"),
+			GitHubClient:     ghClient,
+			GitHubClients:    ghClients,
+			NodeStats:        nodeStats,
+			Interval:         runnerEphemeralStorageInterval,
+			RecycleThreshold: runnerEphemeralStorageRecycleThreshold,
+			Namespace:        namespace,
+		}
+
+		if err = primaryMgr.Add(runnerEphemeralStorageMonitor); err != nil {
+			log.Error(err, "unable to add runner ephemeral storage monitor")
+			os.Exit(1)
+		}
+	}
+
+	if prometheusMonitors {
+		monitorLabels, err := labels.ConvertSelectorToLabelsMap(prometheusMonitorLabels)
+		if err != nil {
+			log.Error(err, "invalid --prometheus-monitor-labels")
+			os.Exit(1)
+		}
+
+		if prometheusMonitorControllerScheme != "http" && prometheusMonitorControllerScheme != "https" {
+			log.Error(fmt.Errorf("%q is neither http nor https", prometheusMonitorControllerScheme), "invalid --prometheus-monitor-controller-scheme")
+			os.Exit(1)
+		}
+
+		var controllerService *types.NamespacedName
+		if prometheusMonitorControllerService != "" {
+			parts := strings.SplitN(prometheusMonitorControllerService, "/", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				log.Error(fmt.Errorf("%q isn't in the form of NAMESPACE/NAME", prometheusMonitorControllerService), "invalid --prometheus-monitor-controller-service")
+				os.Exit(1)
+			}
+
+			controllerService = &types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		}
+
+		prometheusMonitorGenerator := &controllers.PrometheusMonitorGenerator{
+			Client:            kubeClient,
+			Log:               log.WithName("prometheusmonitors"),
+			RESTMapper:        mgr.GetRESTMapper(),
+			Interval:          prometheusMonitorInterval,
+			Namespace:         namespace,
+			PortName:          prometheusMonitorPortName,
+			Labels:            monitorLabels,
+			ControllerService: controllerService,
+			ControllerScheme:  prometheusMonitorControllerScheme,
+		}
+
+		if err = primaryMgr.Add(prometheusMonitorGenerator); err != nil {
+			log.Error(err, "unable to add prometheus monitor generator")
+			os.Exit(1)
+		}
+	}
+
+	interruptedJobRerunner := &controllers.InterruptedJobRerunner{
+		Client:         kubeClient,
+		Log:            log.WithName("interruptedjob"),
+		Recorder:       mgr.GetEventRecorderFor("interruptedjob-rerunner"),
+		GitHubClient:   ghClient,
+		GitHubClients:  ghClients,
+		Interval:       interruptedJobRerunInterval,
+		MaxRunAttempts: interruptedJobMaxRunAttempts,
+		Namespace:      namespace,
+	}
+
+	if err = primaryMgr.Add(interruptedJobRerunner); err != nil {
+		log.Error(err, "unable to add interrupted job rerunner")
+		os.Exit(1)
+	}
+
 	injector := &controllers.PodRunnerTokenInjector{
-		Client:       mgr.GetClient(),
-		GitHubClient: ghClient,
-		Log:          ctrl.Log.WithName("webhook").WithName("PodRunnerTokenInjector"),
+		Client:        kubeClient,
+		GitHubClient:  ghClient,
+		GitHubClients: ghClients,
+		Log:           ctrl.Log.WithName("webhook").WithName("PodRunnerTokenInjector"),
 	}
 	if err = injector.SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to create webhook server", "webhook", "PodRunnerTokenInjector")
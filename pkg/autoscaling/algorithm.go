@@ -0,0 +1,306 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-github/v39/github"
+)
+
+// ScaleAlgorithm suggests the desired replicas of the scale target of an HRA for one of its metrics.
+//
+// The HorizontalRunnerAutoscaler controller selects the algorithm by the type of the metric,
+// so a custom algorithm is used by the metrics whose type is the name the algorithm is registered with.
+// The suggestion is then combined with the ones of the other metrics by Suggest, and stabilized, limited and clamped by Compute.
+type ScaleAlgorithm interface {
+	// SuggestReplicas returns the suggested replicas, or nil when the algorithm has nothing to suggest,
+	// in which case the second metric or the min replicas are used.
+	SuggestReplicas(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error)
+}
+
+// ScaleAlgorithmFunc is an adapter to use an ordinary function as a ScaleAlgorithm.
+type ScaleAlgorithmFunc func(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error)
+
+// SuggestReplicas calls f(ctx, req).
+func (f ScaleAlgorithmFunc) SuggestReplicas(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+	return f(ctx, req)
+}
+
+// ScaleRequest is what a ScaleAlgorithm suggests the desired replicas from.
+type ScaleRequest struct {
+	HorizontalRunnerAutoscaler v1alpha1.HorizontalRunnerAutoscaler
+
+	// Metric is the metric of the HRA the replicas are suggested for.
+	Metric v1alpha1.MetricSpec
+
+	ScaleTarget ScaleTarget
+
+	// Observer observes the runners and the workflow jobs of the scale target.
+	// Each observation calls the GitHub API, so the algorithm should observe only what it needs.
+	Observer Observer
+
+	// Now is the time the replicas are suggested at, which the algorithms should use instead of time.Now,
+	// so that they can be tested on a fake clock. Zero means the current time.
+	Now time.Time
+}
+
+func (req ScaleRequest) now() time.Time {
+	if req.Now.IsZero() {
+		return time.Now()
+	}
+
+	return req.Now
+}
+
+// ScaleTarget is the RunnerDeployment or RunnerSet scaled by the HRA.
+type ScaleTarget struct {
+	Kind         string
+	Name         string
+	Enterprise   string
+	Organization string
+	Repository   string
+	Labels       []string
+
+	// Replicas is the current desired replicas of the scale target, which is nil when it's not set yet.
+	Replicas *int
+}
+
+// ScaleSuggestion is the desired replicas suggested by a ScaleAlgorithm.
+type ScaleSuggestion struct {
+	Replicas int
+
+	// Reason is recorded as the reason of the scaling decision when the suggestion is taken,
+	// which defaults to the type of the metric.
+	Reason string
+}
+
+// Observer observes the scale target for the ScaleAlgorithms.
+type Observer interface {
+	// Runners returns the numbers of the runners of the scale target.
+	Runners(ctx context.Context) (*RunnerCounts, error)
+
+	// WorkflowJobs returns the queued and in-progress workflow jobs that can run on the scale target,
+	// for the repositories and the workflows specified by the metric.
+	// It returns nil when there's nothing to count, like for organizational runners without any repositoryNames.
+	WorkflowJobs(ctx context.Context, metric v1alpha1.MetricSpec) (*WorkflowJobCounts, error)
+}
+
+// RunnerCounts is the numbers of the runners of a scale target.
+type RunnerCounts struct {
+	Runners    int
+	Registered int
+	Busy       int
+
+	// Completed is the number of the runners whose pods have completed but are not replaced yet,
+	// which are usually ephemeral runners that have just run a job.
+	Completed int
+}
+
+// WorkflowJobCounts is the queued and in-progress workflow jobs of a scale target.
+type WorkflowJobCounts struct {
+	// Queued and InProgress are the numbers of the jobs that can run on the scale target,
+	// including the workflow runs whose jobs are unavailable, which are counted as single jobs.
+	// The jobs throttled by their concurrency limits are excluded when the metric is concurrencyAware.
+	Queued     int
+	InProgress int
+
+	// Jobs is the queued and in-progress jobs listed from GitHub, before the concurrency limits are applied.
+	Jobs []WorkflowJob
+}
+
+// WorkflowJob is a workflow job along with the workflow run it belongs to.
+type WorkflowJob struct {
+	Owner      string
+	Repository string
+	Run        *github.WorkflowRun
+	Job        *github.WorkflowJob
+}
+
+var (
+	scaleAlgorithmsMu sync.RWMutex
+	scaleAlgorithms   = map[string]ScaleAlgorithm{}
+)
+
+func init() {
+	RegisterScaleAlgorithm(v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns, ScaleAlgorithmFunc(suggestByQueuedAndInProgressWorkflowRuns))
+	RegisterScaleAlgorithm(v1alpha1.AutoscalingMetricTypePercentageRunnersBusy, ScaleAlgorithmFunc(suggestByBusyRunners))
+	RegisterScaleAlgorithm(v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners, ScaleAlgorithmFunc(suggestByBusyRunners))
+	RegisterScaleAlgorithm(v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime, ScaleAlgorithmFunc(suggestByQueuedJobWaitTime))
+	RegisterScaleAlgorithm(v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner, ScaleAlgorithmFunc(suggestByQueuedJobsPerRunner))
+}
+
+// RegisterScaleAlgorithm makes the algorithm available to the metrics of the type.
+// It's meant to be called from the init function of the package implementing the algorithm,
+// which is linked to the controller by a blank import, like database/sql drivers.
+// It panics if an algorithm is already registered for the type.
+func RegisterScaleAlgorithm(metricType string, algorithm ScaleAlgorithm) {
+	scaleAlgorithmsMu.Lock()
+	defer scaleAlgorithmsMu.Unlock()
+
+	if algorithm == nil {
+		panic("autoscaling: the scale algorithm of " + metricType + " is nil")
+	}
+
+	if _, dup := scaleAlgorithms[metricType]; dup {
+		panic("autoscaling: a scale algorithm is already registered for " + metricType)
+	}
+
+	scaleAlgorithms[metricType] = algorithm
+}
+
+// ScaleAlgorithms returns the registered algorithms keyed by the metric types.
+func ScaleAlgorithms() map[string]ScaleAlgorithm {
+	scaleAlgorithmsMu.RLock()
+	defer scaleAlgorithmsMu.RUnlock()
+
+	algorithms := make(map[string]ScaleAlgorithm, len(scaleAlgorithms))
+	for t, a := range scaleAlgorithms {
+		algorithms[t] = a
+	}
+
+	return algorithms
+}
+
+// ScaleAlgorithmNames returns the sorted metric types the algorithms are registered for.
+func ScaleAlgorithmNames() []string {
+	var names []string
+	for t := range ScaleAlgorithms() {
+		names = append(names, t)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// suggestByQueuedAndInProgressWorkflowRuns is the algorithm of TotalNumberOfQueuedAndInProgressWorkflowRuns.
+func suggestByQueuedAndInProgressWorkflowRuns(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+	jobs, err := req.Observer.WorkflowJobs(ctx, req.Metric)
+	if err != nil || jobs == nil {
+		return nil, err
+	}
+
+	return &ScaleSuggestion{Replicas: TotalNumberOfQueuedAndInProgressWorkflowRuns(jobs.Queued, jobs.InProgress)}, nil
+}
+
+// suggestByBusyRunners is the algorithm of PercentageRunnersBusy and QueuedJobsPlusBusyRunners.
+// The scale target scaled to zero is woken up by the queued workflow jobs, as there's no runner that can become busy.
+func suggestByBusyRunners(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+	metric := req.Metric
+
+	if r := req.ScaleTarget.Replicas; r != nil && *r == 0 {
+		jobs, err := req.Observer.WorkflowJobs(ctx, metric)
+		if err != nil || jobs == nil {
+			return nil, err
+		}
+
+		replicas, err := QueuedJobsPlusBusyRunners(metric, 0, jobs.Queued, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScaleSuggestion{Replicas: replicas}, nil
+	}
+
+	desiredReplicasBefore := 1
+	if r := req.ScaleTarget.Replicas; r != nil {
+		desiredReplicasBefore = *r
+	}
+
+	var queued int
+
+	if metric.Type == v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners {
+		jobs, err := req.Observer.WorkflowJobs(ctx, metric)
+		if err != nil {
+			return nil, err
+		}
+
+		if jobs != nil {
+			queued = jobs.Queued
+		}
+	}
+
+	runners, err := req.Observer.Runners(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas int
+
+	switch metric.Type {
+	case v1alpha1.AutoscalingMetricTypePercentageRunnersBusy:
+		// The completed runners are counted as busy, as they have just run jobs and are going to be replaced
+		// to run the next ones. Otherwise a busy pool of ephemeral runners would look less busy than it is.
+		replicas, err = PercentageRunnersBusy(metric, desiredReplicasBefore, runners.Busy+runners.Completed)
+	case v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners:
+		replicas, err = QueuedJobsPlusBusyRunners(metric, desiredReplicasBefore, queued, runners.Busy)
+	default:
+		err = fmt.Errorf("unsupported metric type %q for the busy runners algorithm", metric.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScaleSuggestion{Replicas: replicas}, nil
+}
+
+// suggestByQueuedJobWaitTime is the algorithm of QueuedJobWaitTime.
+func suggestByQueuedJobWaitTime(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+	jobs, err := req.Observer.WorkflowJobs(ctx, req.Metric)
+	if err != nil || jobs == nil {
+		return nil, err
+	}
+
+	desiredReplicasBefore := 1
+	if r := req.ScaleTarget.Replicas; r != nil {
+		desiredReplicasBefore = *r
+	}
+
+	wait := OldestQueuedJobWaitTime(jobs.Jobs, req.now())
+
+	replicas, err := QueuedJobWaitTime(req.Metric, desiredReplicasBefore, wait, jobs.Queued, jobs.InProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScaleSuggestion{Replicas: replicas}, nil
+}
+
+// suggestByQueuedJobsPerRunner is the algorithm of QueuedJobsPerRunner.
+func suggestByQueuedJobsPerRunner(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+	jobs, err := req.Observer.WorkflowJobs(ctx, req.Metric)
+	if err != nil || jobs == nil {
+		return nil, err
+	}
+
+	replicas, err := QueuedJobsPerRunner(req.Metric, jobs.Queued, jobs.InProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScaleSuggestion{Replicas: replicas}, nil
+}
+
+// OldestQueuedJobWaitTime returns the time the oldest of the queued jobs has been waiting for a runner at now,
+// or zero when there's no queued job.
+// GitHub reports the time a queued job is queued at as its started_at.
+func OldestQueuedJobWaitTime(jobs []WorkflowJob, now time.Time) time.Duration {
+	var oldest time.Duration
+
+	for _, j := range jobs {
+		if j.Job.GetStatus() != "queued" || j.Job.StartedAt == nil {
+			continue
+		}
+
+		if wait := now.Sub(j.Job.StartedAt.Time); wait > oldest {
+			oldest = wait
+		}
+	}
+
+	return oldest
+}
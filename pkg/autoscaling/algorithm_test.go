@@ -0,0 +1,228 @@
+package autoscaling
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/google/go-github/v39/github"
+)
+
+type fakeObserver struct {
+	runners *RunnerCounts
+	jobs    *WorkflowJobCounts
+
+	observedRunners, observedJobs bool
+}
+
+func (o *fakeObserver) Runners(ctx context.Context) (*RunnerCounts, error) {
+	o.observedRunners = true
+	return o.runners, nil
+}
+
+func (o *fakeObserver) WorkflowJobs(ctx context.Context, metric v1alpha1.MetricSpec) (*WorkflowJobCounts, error) {
+	o.observedJobs = true
+	return o.jobs, nil
+}
+
+func TestScaleAlgorithms_BuiltIn(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	testcases := []struct {
+		description string
+		metric      v1alpha1.MetricSpec
+		replicas    *int
+		runners     *RunnerCounts
+		jobs        *WorkflowJobCounts
+
+		want            *int
+		wantRunnersSeen bool
+		wantJobsSeen    bool
+	}{
+		{
+			description:  "queued and in-progress workflow runs",
+			metric:       v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+			replicas:     intPtr(1),
+			jobs:         &WorkflowJobCounts{Queued: 3, InProgress: 2},
+			want:         intPtr(5),
+			wantJobsSeen: true,
+		},
+		{
+			description:  "nothing to count",
+			metric:       v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+			replicas:     intPtr(1),
+			wantJobsSeen: true,
+		},
+		{
+			description:     "percentage runners busy counts completed runners as busy",
+			metric:          v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+			replicas:        intPtr(10),
+			runners:         &RunnerCounts{Runners: 10, Registered: 8, Busy: 6, Completed: 2},
+			want:            intPtr(13),
+			wantRunnersSeen: true,
+		},
+		{
+			description:     "percentage runners busy without the current replicas",
+			metric:          v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+			runners:         &RunnerCounts{Runners: 1, Registered: 1, Busy: 1},
+			want:            intPtr(2),
+			wantRunnersSeen: true,
+		},
+		{
+			description:  "percentage runners busy from zero",
+			metric:       v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+			replicas:     intPtr(0),
+			jobs:         &WorkflowJobCounts{Queued: 3},
+			want:         intPtr(3),
+			wantJobsSeen: true,
+		},
+		{
+			description:     "queued jobs plus busy runners",
+			metric:          v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners},
+			replicas:        intPtr(4),
+			runners:         &RunnerCounts{Runners: 4, Registered: 4, Busy: 4},
+			jobs:            &WorkflowJobCounts{Queued: 3, InProgress: 4},
+			want:            intPtr(7),
+			wantRunnersSeen: true,
+			wantJobsSeen:    true,
+		},
+		{
+			description:     "queued jobs plus busy runners without anything to count",
+			metric:          v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners},
+			replicas:        intPtr(4),
+			runners:         &RunnerCounts{Runners: 4, Registered: 4, Busy: 2},
+			want:            intPtr(4),
+			wantRunnersSeen: true,
+			wantJobsSeen:    true,
+		},
+		{
+			description: "queued job wait time",
+			metric:      v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime},
+			replicas:    intPtr(4),
+			jobs: &WorkflowJobCounts{Queued: 2, InProgress: 4, Jobs: []WorkflowJob{
+				queuedJob(10 * time.Second),
+				queuedJob(5 * time.Minute),
+			}},
+			want:         intPtr(6),
+			wantJobsSeen: true,
+		},
+		{
+			description:  "queued job wait time without anything to count",
+			metric:       v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime},
+			replicas:     intPtr(4),
+			wantJobsSeen: true,
+		},
+		{
+			description:  "queued jobs per runner",
+			metric:       v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner, TargetQueuedJobsPerRunner: "2"},
+			replicas:     intPtr(4),
+			jobs:         &WorkflowJobCounts{Queued: 5, InProgress: 3},
+			want:         intPtr(6),
+			wantJobsSeen: true,
+		},
+	}
+
+	algorithms := ScaleAlgorithms()
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			o := &fakeObserver{runners: tc.runners, jobs: tc.jobs}
+
+			s, err := algorithms[tc.metric.Type].SuggestReplicas(context.Background(), ScaleRequest{
+				Metric:      tc.metric,
+				ScaleTarget: ScaleTarget{Replicas: tc.replicas},
+				Observer:    o,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got *int
+			if s != nil {
+				got = &s.Replicas
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected suggestion: want %v, got %v", deref(tc.want), deref(got))
+			}
+
+			if o.observedRunners != tc.wantRunnersSeen || o.observedJobs != tc.wantJobsSeen {
+				t.Errorf("unexpected observations: want runners=%v jobs=%v, got runners=%v jobs=%v", tc.wantRunnersSeen, tc.wantJobsSeen, o.observedRunners, o.observedJobs)
+			}
+		})
+	}
+}
+
+func queuedJob(wait time.Duration) WorkflowJob {
+	return WorkflowJob{Job: &github.WorkflowJob{
+		Status:    github.String("queued"),
+		StartedAt: &github.Timestamp{Time: time.Now().Add(-wait)},
+	}}
+}
+
+func TestOldestQueuedJobWaitTime(t *testing.T) {
+	now := time.Now()
+
+	jobs := []WorkflowJob{
+		{Job: &github.WorkflowJob{Status: github.String("in_progress"), StartedAt: &github.Timestamp{Time: now.Add(-time.Hour)}}},
+		{Job: &github.WorkflowJob{Status: github.String("queued"), StartedAt: &github.Timestamp{Time: now.Add(-2 * time.Minute)}}},
+		{Job: &github.WorkflowJob{Status: github.String("queued"), StartedAt: &github.Timestamp{Time: now.Add(-time.Minute)}}},
+		{Job: &github.WorkflowJob{Status: github.String("queued")}},
+		{},
+	}
+
+	if got := OldestQueuedJobWaitTime(jobs, now); got != 2*time.Minute {
+		t.Errorf("unexpected wait time: want %v, got %v", 2*time.Minute, got)
+	}
+
+	if got := OldestQueuedJobWaitTime(nil, now); got != 0 {
+		t.Errorf("unexpected wait time without jobs: want 0, got %v", got)
+	}
+}
+
+func TestRegisterScaleAlgorithm(t *testing.T) {
+	const metricType = "TestRegisterScaleAlgorithm"
+
+	a := ScaleAlgorithmFunc(func(ctx context.Context, req ScaleRequest) (*ScaleSuggestion, error) {
+		return &ScaleSuggestion{Replicas: 1}, nil
+	})
+
+	RegisterScaleAlgorithm(metricType, a)
+	defer func() {
+		scaleAlgorithmsMu.Lock()
+		delete(scaleAlgorithms, metricType)
+		scaleAlgorithmsMu.Unlock()
+	}()
+
+	if _, ok := ScaleAlgorithms()[metricType]; !ok {
+		t.Errorf("%s is not registered: got %v", metricType, ScaleAlgorithmNames())
+	}
+
+	for _, name := range []string{metricType, v1alpha1.AutoscalingMetricTypePercentageRunnersBusy} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic on registering %s twice", name)
+				}
+			}()
+
+			RegisterScaleAlgorithm(name, a)
+		}()
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected a panic on registering nil")
+			}
+		}()
+
+		RegisterScaleAlgorithm("Nil", nil)
+	}()
+}
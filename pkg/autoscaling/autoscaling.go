@@ -0,0 +1,213 @@
+// Package autoscaling computes the desired replicas of a HorizontalRunnerAutoscaler.
+//
+// It is the replica computation of the HorizontalRunnerAutoscaler controller, without the Kubernetes and GitHub API calls,
+// so that other tools like simulators and custom operators can reuse the exact logic of the controller.
+// The computation is done in two steps:
+//
+//   - Suggest evaluates the metrics of the HRA by the given SuggestFunc, and combines their suggestions according to
+//     the metricsCombinationPolicy, or falls back to the second metric. PercentageRunnersBusy, QueuedJobsPlusBusyRunners,
+//     QueuedJobWaitTime, QueuedJobsPerRunner and TotalNumberOfQueuedAndInProgressWorkflowRuns compute the suggestions of the metrics
+//     from the observed numbers, and the ScaleAlgorithms registered for the metric types compute them from what they observe through an Observer.
+//   - Compute adds the capacity reservations to the suggestion, stabilizes and limits scaling,
+//     clamps the replicas to the min and max replicas, and delays scale down after scale out.
+//
+// The state of the previous computations is read from the status of the HRA, so the caller is expected to update
+// status.desiredReplicas, status.lastSuccessfulScaleOutTime and status.scaleDownRecommendations from the Result
+// before the next computation, like the controller does.
+//
+// What the computation depends on is given explicitly, so it's deterministic and the exported functions are safe to be
+// called concurrently.
+package autoscaling
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// Reasons of the scaling decisions made by Compute other than the metric types.
+const (
+	ReasonMinReplicas            = "MinReplicas"
+	ReasonMaxReplicas            = "MaxReplicas"
+	ReasonCapacityReservations   = "CapacityReservations"
+	ReasonScaleDownDelay         = "ScaleDownDelay"
+	ReasonScaleDownStabilization = "ScaleDownStabilization"
+	ReasonScaleDownMaxStep       = "ScaleDownMaxStep"
+	ReasonScaleUpMaxStep         = "ScaleUpMaxStep"
+	ReasonMetricsSum             = "MetricsSum"
+	ReasonMetricsAverage         = "MetricsAverage"
+)
+
+// Input is everything the desired replicas are computed from.
+type Input struct {
+	// Now is the time of the computation, which determines the unexpired capacity reservations,
+	// the scale down stabilization window and the scale down delay.
+	Now time.Time
+
+	// HorizontalRunnerAutoscaler is the HRA to compute the desired replicas of.
+	// Its status carries the desired replicas, the last scale out time and the scale down recommendations
+	// of the previous computation.
+	HorizontalRunnerAutoscaler v1alpha1.HorizontalRunnerAutoscaler
+
+	// MinReplicas is the min replicas in effect, which can be overridden by a scheduled override.
+	MinReplicas int
+
+	// Suggested and Metric are the desired replicas suggested by the metrics and the metric that determined it, as returned by Suggest.
+	// MinReplicas is used when Suggested is nil.
+	Suggested *int
+	Metric    *v1alpha1.MetricSpec
+
+	// MetricReason is the reason of the suggestion given by the ScaleAlgorithm of the metric, which defaults to the metric type.
+	MetricReason string
+
+	// DefaultScaleDownDelay is the scale down delay after scale out used when neither the metric nor the HRA specifies one.
+	DefaultScaleDownDelay time.Duration
+}
+
+// Result is the desired replicas along with the numbers it's computed from.
+type Result struct {
+	DesiredReplicas int
+
+	// Reason is the reason of the scaling decision, which is either the type of the metric or one of the Reason constants.
+	Reason string
+
+	// Suggested is the replicas suggested by the metrics, or the min replicas when the metrics suggested nothing.
+	Suggested int
+
+	// Reserved is the sum of the replicas of the unexpired capacity reservations.
+	Reserved int
+
+	// Recommended is the sum of Suggested and Reserved, and Stabilized is the highest replicas recommended within the scale down stabilization window.
+	// They are equal when the HRA has no stabilization window.
+	Recommended int
+	Stabilized  int
+
+	// Recommendations is the recommendations to be kept in status.scaleDownRecommendations for the next computation,
+	// which is nil when the HRA has no stabilization window.
+	Recommendations []v1alpha1.ReplicaRecommendation
+
+	// ScaleDownDelayUntil is the time scale down is delayed until since the last scale out, which is nil unless scale down is delayed.
+	ScaleDownDelayUntil *time.Time
+}
+
+// Compute returns the desired replicas of the HRA for the replicas suggested by its metrics.
+func Compute(in Input) (*Result, error) {
+	now := in.Now
+	hra := in.HorizontalRunnerAutoscaler
+	minReplicas := in.MinReplicas
+
+	res := &Result{}
+
+	var reason string
+
+	if in.Suggested == nil {
+		res.Suggested = minReplicas
+		reason = ReasonMinReplicas
+	} else {
+		res.Suggested = *in.Suggested
+		reason = metricsScalingReason(hra, in.Metric, in.MetricReason)
+	}
+
+	res.Reserved, _ = ReservedReplicas(now, hra.Spec.CapacityReservations)
+
+	newDesiredReplicas := res.Suggested + res.Reserved
+
+	if res.Reserved > 0 && res.Suggested < res.Reserved {
+		reason = ReasonCapacityReservations
+	}
+
+	//
+	// Stabilize scaling-down over ScaleDownStabilizationWindowSeconds, and limit it to ScaleDownMaxStep.
+	// Scaling-up is limited to ScaleUpMaxStep per ScaleUpPeriodSeconds.
+	//
+
+	res.Recommended = newDesiredReplicas
+
+	res.Stabilized, res.Recommendations = stabilizeScaleDown(now, hra, newDesiredReplicas)
+	if res.Stabilized > newDesiredReplicas {
+		newDesiredReplicas = res.Stabilized
+		reason = ReasonScaleDownStabilization
+	}
+
+	if current := hra.Status.DesiredReplicas; current != nil {
+		limited, err := limitScaleDownStep(hra, *current, newDesiredReplicas)
+		if err != nil {
+			return nil, err
+		}
+
+		if limited > newDesiredReplicas {
+			newDesiredReplicas = limited
+			reason = ReasonScaleDownMaxStep
+		}
+
+		limited, err = limitScaleUpStep(now, hra, *current, newDesiredReplicas)
+		if err != nil {
+			return nil, err
+		}
+
+		if limited < newDesiredReplicas {
+			newDesiredReplicas = limited
+			reason = ReasonScaleUpMaxStep
+		}
+	}
+
+	if newDesiredReplicas < minReplicas {
+		newDesiredReplicas = minReplicas
+		reason = ReasonMinReplicas
+	} else if hra.Spec.MaxReplicas != nil && newDesiredReplicas > *hra.Spec.MaxReplicas {
+		newDesiredReplicas = *hra.Spec.MaxReplicas
+		reason = ReasonMaxReplicas
+	}
+
+	//
+	// Delay scaling-down for ScaleDownDelaySecondsAfterScaleUp or DefaultScaleDownDelay
+	//
+
+	if current, last := hra.Status.DesiredReplicas, hra.Status.LastSuccessfulScaleOutTime; current != nil && *current >= newDesiredReplicas && last != nil {
+		t := last.Add(scaleDownDelay(hra, in.Metric, in.DefaultScaleDownDelay))
+
+		// ScaleDownDelay is not passed
+		if t.After(now) {
+			res.ScaleDownDelayUntil = &t
+			newDesiredReplicas = *current
+			reason = ReasonScaleDownDelay
+		}
+	}
+
+	res.DesiredReplicas = newDesiredReplicas
+	res.Reason = reason
+
+	return res, nil
+}
+
+// scaleDownDelay returns the scale down delay after scale out, which is specified by the metric, the HRA, or the default in this order.
+func scaleDownDelay(hra v1alpha1.HorizontalRunnerAutoscaler, metric *v1alpha1.MetricSpec, def time.Duration) time.Duration {
+	if metric != nil && metric.ScaleDownDelaySecondsAfterScaleOut != nil {
+		return time.Duration(*metric.ScaleDownDelaySecondsAfterScaleOut) * time.Second
+	} else if hra.Spec.ScaleDownDelaySecondsAfterScaleUp != nil {
+		return time.Duration(*hra.Spec.ScaleDownDelaySecondsAfterScaleUp) * time.Second
+	}
+
+	return def
+}
+
+// metricsScalingReason returns the reason of the scaling decision made by the metric,
+// which is the reason given by its algorithm or the metric type, unless the suggestions of the metrics are summed or averaged.
+func metricsScalingReason(hra v1alpha1.HorizontalRunnerAutoscaler, metric *v1alpha1.MetricSpec, metricReason string) string {
+	switch hra.Spec.MetricsCombinationPolicy {
+	case v1alpha1.MetricsCombinationPolicySum:
+		return ReasonMetricsSum
+	case v1alpha1.MetricsCombinationPolicyAverage:
+		return ReasonMetricsAverage
+	}
+
+	if metricReason != "" {
+		return metricReason
+	}
+
+	if metric == nil {
+		return ""
+	}
+
+	return metric.Type
+}
@@ -0,0 +1,238 @@
+package autoscaling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCompute(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	minutesAgo := func(m int) *metav1.Time {
+		t := metav1.NewTime(now.Add(-time.Duration(m) * time.Minute))
+		return &t
+	}
+
+	step := intstr.FromInt(2)
+
+	total := v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns}
+
+	testcases := []struct {
+		description string
+		spec        v1alpha1.HorizontalRunnerAutoscalerSpec
+		status      v1alpha1.HorizontalRunnerAutoscalerStatus
+		minReplicas int
+		suggested   *int
+		metric      *v1alpha1.MetricSpec
+		reason      string
+
+		want            int
+		wantReason      string
+		wantDelayed     bool
+		wantRecommended int
+		wantStabilized  int
+		wantKept        int
+	}{
+		{
+			description: "no suggestion",
+			minReplicas: 2,
+			want:        2,
+			wantReason:  ReasonMinReplicas,
+		},
+		{
+			description: "suggested by the metric",
+			minReplicas: 1,
+			suggested:   intPtr(3),
+			metric:      &total,
+			want:        3,
+			wantReason:  total.Type,
+		},
+		{
+			description: "reason given by the scale algorithm",
+			minReplicas: 1,
+			suggested:   intPtr(3),
+			metric:      &total,
+			reason:      "QueueLatency",
+			want:        3,
+			wantReason:  "QueueLatency",
+		},
+		{
+			description: "summed metrics",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MetricsCombinationPolicy: v1alpha1.MetricsCombinationPolicySum},
+			minReplicas: 1,
+			suggested:   intPtr(3),
+			metric:      &total,
+			want:        3,
+			wantReason:  ReasonMetricsSum,
+		},
+		{
+			description: "below min replicas",
+			minReplicas: 4,
+			suggested:   intPtr(3),
+			metric:      &total,
+			want:        4,
+			wantReason:  ReasonMinReplicas,
+		},
+		{
+			description: "above max replicas",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{MaxReplicas: intPtr(5)},
+			minReplicas: 1,
+			suggested:   intPtr(8),
+			metric:      &total,
+			want:        5,
+			wantReason:  ReasonMaxReplicas,
+		},
+		{
+			description: "capacity reservations",
+			spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				CapacityReservations: []v1alpha1.CapacityReservation{
+					{ExpirationTime: metav1.NewTime(now.Add(time.Hour)), Replicas: 5},
+					{ExpirationTime: metav1.NewTime(now.Add(-time.Hour)), Replicas: 10},
+				},
+			},
+			minReplicas: 1,
+			suggested:   intPtr(2),
+			metric:      &total,
+			want:        7,
+			wantReason:  ReasonCapacityReservations,
+		},
+		{
+			description: "scale down stabilization",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownStabilizationWindowSeconds: intPtr(300)},
+			status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+				ScaleDownRecommendations: []v1alpha1.ReplicaRecommendation{{Time: *minutesAgo(2), Replicas: 6}},
+			},
+			minReplicas:     1,
+			suggested:       intPtr(2),
+			metric:          &total,
+			want:            6,
+			wantReason:      ReasonScaleDownStabilization,
+			wantRecommended: 2,
+			wantStabilized:  6,
+			wantKept:        2,
+		},
+		{
+			description: "scale down max step",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownMaxStep: &step},
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(10)},
+			minReplicas: 1,
+			suggested:   intPtr(2),
+			metric:      &total,
+			want:        8,
+			wantReason:  ReasonScaleDownMaxStep,
+		},
+		{
+			description: "scale up max step",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleUpMaxStep: &step},
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(1)},
+			minReplicas: 1,
+			suggested:   intPtr(10),
+			metric:      &total,
+			want:        3,
+			wantReason:  ReasonScaleUpMaxStep,
+		},
+		{
+			description: "scale down delayed by the default",
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(5), LastSuccessfulScaleOutTime: minutesAgo(5)},
+			minReplicas: 1,
+			suggested:   intPtr(2),
+			metric:      &total,
+			want:        5,
+			wantReason:  ReasonScaleDownDelay,
+			wantDelayed: true,
+		},
+		{
+			description: "scale down delay of the HRA passed",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownDelaySecondsAfterScaleUp: intPtr(60)},
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(5), LastSuccessfulScaleOutTime: minutesAgo(5)},
+			minReplicas: 1,
+			suggested:   intPtr(2),
+			metric:      &total,
+			want:        2,
+			wantReason:  total.Type,
+		},
+		{
+			description: "scale down delay of the metric takes precedence",
+			spec:        v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownDelaySecondsAfterScaleUp: intPtr(60)},
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(5), LastSuccessfulScaleOutTime: minutesAgo(5)},
+			minReplicas: 1,
+			suggested:   intPtr(2),
+			metric: &v1alpha1.MetricSpec{
+				Type:                               v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+				ScaleDownDelaySecondsAfterScaleOut: intPtr(600),
+			},
+			want:        5,
+			wantReason:  ReasonScaleDownDelay,
+			wantDelayed: true,
+		},
+		{
+			description: "scale up isn't delayed",
+			status:      v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: intPtr(5), LastSuccessfulScaleOutTime: minutesAgo(1)},
+			minReplicas: 1,
+			suggested:   intPtr(7),
+			metric:      &total,
+			want:        7,
+			wantReason:  total.Type,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			res, err := Compute(Input{
+				Now:                        now,
+				HorizontalRunnerAutoscaler: v1alpha1.HorizontalRunnerAutoscaler{Spec: tc.spec, Status: tc.status},
+				MinReplicas:                tc.minReplicas,
+				Suggested:                  tc.suggested,
+				Metric:                     tc.metric,
+				MetricReason:               tc.reason,
+				DefaultScaleDownDelay:      10 * time.Minute,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.DesiredReplicas != tc.want || res.Reason != tc.wantReason {
+				t.Errorf("unexpected desired replicas: want %d by %s, got %d by %s", tc.want, tc.wantReason, res.DesiredReplicas, res.Reason)
+			}
+
+			if delayed := res.ScaleDownDelayUntil != nil; delayed != tc.wantDelayed {
+				t.Errorf("unexpected scale down delay: want delayed=%v, got %v", tc.wantDelayed, res.ScaleDownDelayUntil)
+			}
+
+			if len(res.Recommendations) != tc.wantKept {
+				t.Errorf("unexpected recommendations: want %d, got %+v", tc.wantKept, res.Recommendations)
+			}
+
+			if tc.wantKept > 0 && (res.Recommended != tc.wantRecommended || res.Stabilized != tc.wantStabilized) {
+				t.Errorf("unexpected stabilization: want %d stabilized to %d, got %d stabilized to %d", tc.wantRecommended, tc.wantStabilized, res.Recommended, res.Stabilized)
+			}
+		})
+	}
+}
+
+func TestCompute_InvalidMaxStep(t *testing.T) {
+	step := intstr.FromString("abc")
+	current := 3
+
+	_, err := Compute(Input{
+		Now: time.Now(),
+		HorizontalRunnerAutoscaler: v1alpha1.HorizontalRunnerAutoscaler{
+			Spec:   v1alpha1.HorizontalRunnerAutoscalerSpec{ScaleDownMaxStep: &step},
+			Status: v1alpha1.HorizontalRunnerAutoscalerStatus{DesiredReplicas: &current},
+		},
+		MinReplicas: 1,
+	})
+	if err == nil {
+		t.Errorf("expected an error for the invalid scaleDownMaxStep")
+	}
+}
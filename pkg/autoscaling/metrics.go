@@ -0,0 +1,429 @@
+package autoscaling
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// The defaults of the thresholds and the factors of PercentageRunnersBusy and QueuedJobsPlusBusyRunners.
+const (
+	DefaultScaleUpThreshold   = 0.8
+	DefaultScaleDownThreshold = 0.3
+	DefaultScaleUpFactor      = 1.3
+	DefaultScaleDownFactor    = 0.7
+)
+
+// The defaults of the wait time thresholds of QueuedJobWaitTime.
+const (
+	DefaultScaleUpWaitTime   = time.Minute
+	DefaultScaleDownWaitTime = 10 * time.Second
+)
+
+// SuggestFunc returns the desired replicas suggested by the metric,
+// or nil when the metric has nothing to suggest, like an organizational TotalNumberOfQueuedAndInProgressWorkflowRuns metric
+// without any repository to count the workflow runs of.
+type SuggestFunc func(metric v1alpha1.MetricSpec) (*int, error)
+
+// Suggestion is the desired replicas suggested by one of the metrics of the HRA.
+type Suggestion struct {
+	Metric   *v1alpha1.MetricSpec
+	Replicas int
+}
+
+// Suggest returns the desired replicas suggested by the metrics of the HRA, along with the metric that determined it.
+// It returns nil replicas when the HRA has no metrics or the metrics suggested nothing, in which case the min replicas are desired.
+//
+// Without metricsCombinationPolicy, the first metric is used, and the second metric is used only when the first one suggests
+// zero or nothing. With metricsCombinationPolicy, all the metrics are evaluated and their suggestions are combined by Combine.
+// Suspended metrics are skipped as if they weren't there.
+func Suggest(spec v1alpha1.HorizontalRunnerAutoscalerSpec, suggest SuggestFunc) (*int, *v1alpha1.MetricSpec, error) {
+	metrics := ActiveMetrics(spec.Metrics)
+	numMetrics := len(metrics)
+	if numMetrics == 0 {
+		// We don't default to anything since ARC 0.23.0
+		// See https://github.com/actions-runner-controller/actions-runner-controller/issues/728
+		return nil, nil, nil
+	} else if spec.MetricsCombinationPolicy != "" {
+		return suggestByCombinedMetrics(spec, suggest)
+	} else if numMetrics > 2 {
+		return nil, nil, fmt.Errorf("too many autoscaling metrics configured: It must be 0 to 2 unless metricsCombinationPolicy is set, but got %d", numMetrics)
+	}
+
+	primaryMetric := &metrics[0]
+
+	suggested, err := suggest(*primaryMetric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if suggested != nil && *suggested > 0 {
+		return suggested, primaryMetric, nil
+	}
+
+	if numMetrics == 1 {
+		// This is never supposed to happen but anyway-
+		// Fall-back to `minReplicas + capacityReservedThroughWebhook`.
+		return nil, primaryMetric, nil
+	}
+
+	// At this point, we are sure that there are exactly 2 Metrics entries.
+
+	fallbackMetric := &metrics[1]
+
+	if primaryMetric.Type != v1alpha1.AutoscalingMetricTypePercentageRunnersBusy ||
+		fallbackMetric.Type != v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns {
+
+		return nil, nil, fmt.Errorf(
+			"invalid HRA Spec: Metrics[0] of %s cannot be combined with Metrics[1] of %s: The only allowed combination is 0=PercentageRunnersBusy and 1=TotalNumberOfQueuedAndInProgressWorkflowRuns",
+			primaryMetric.Type, fallbackMetric.Type,
+		)
+	}
+
+	suggested, err = suggest(*fallbackMetric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return suggested, fallbackMetric, nil
+}
+
+// suggestByCombinedMetrics evaluates all the metrics of the HRA and combines their suggestions
+// according to spec.metricsCombinationPolicy.
+// The metrics that suggest nothing, like TotalNumberOfQueuedAndInProgressWorkflowRuns without anything to count, are ignored.
+func suggestByCombinedMetrics(spec v1alpha1.HorizontalRunnerAutoscalerSpec, suggest SuggestFunc) (*int, *v1alpha1.MetricSpec, error) {
+	var suggestions []Suggestion
+
+	for i := range spec.Metrics {
+		m := &spec.Metrics[i]
+
+		if m.Suspended {
+			continue
+		}
+
+		v, err := suggest(*m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("spec.metrics[%d]: %w", i, err)
+		}
+
+		if v == nil {
+			continue
+		}
+
+		suggestions = append(suggestions, Suggestion{Metric: m, Replicas: *v})
+	}
+
+	return Combine(spec.MetricsCombinationPolicy, suggestions)
+}
+
+// ActiveMetrics returns the metrics that aren't suspended.
+func ActiveMetrics(metrics []v1alpha1.MetricSpec) []v1alpha1.MetricSpec {
+	var active []v1alpha1.MetricSpec
+
+	for _, m := range metrics {
+		if !m.Suspended {
+			active = append(active, m)
+		}
+	}
+
+	return active
+}
+
+// Combine combines the suggestions of the metrics by the policy.
+// The returned metric is the one whose suggestion is taken for Max and Min, or the one suggesting the most replicas
+// for Sum and Average, so that its scaleDownDelaySecondsAfterScaleOut applies.
+func Combine(policy string, suggestions []Suggestion) (*int, *v1alpha1.MetricSpec, error) {
+	if len(suggestions) == 0 {
+		return nil, nil, nil
+	}
+
+	var (
+		sum     int
+		largest = suggestions[0]
+		least   = suggestions[0]
+	)
+
+	for _, s := range suggestions {
+		sum += s.Replicas
+
+		if s.Replicas > largest.Replicas {
+			largest = s
+		}
+
+		if s.Replicas < least.Replicas {
+			least = s
+		}
+	}
+
+	var combined int
+
+	switch policy {
+	case v1alpha1.MetricsCombinationPolicyMax:
+		return &largest.Replicas, largest.Metric, nil
+	case v1alpha1.MetricsCombinationPolicyMin:
+		return &least.Replicas, least.Metric, nil
+	case v1alpha1.MetricsCombinationPolicySum:
+		combined = sum
+	case v1alpha1.MetricsCombinationPolicyAverage:
+		combined = int(math.Ceil(float64(sum) / float64(len(suggestions))))
+	default:
+		return nil, nil, fmt.Errorf("validating autoscaling metrics: unsupported metricsCombinationPolicy %q: It must be one of Max, Min, Sum, and Average", policy)
+	}
+
+	return &combined, largest.Metric, nil
+}
+
+// TotalNumberOfQueuedAndInProgressWorkflowRuns returns the desired replicas of the TotalNumberOfQueuedAndInProgressWorkflowRuns metric,
+// which is the number of the queued and in-progress workflow jobs that can run on the runners,
+// or the workflow runs whose jobs are unavailable.
+func TotalNumberOfQueuedAndInProgressWorkflowRuns(queued, inProgress int) int {
+	return queued + inProgress
+}
+
+// PercentageRunnersBusy returns the desired replicas of the PercentageRunnersBusy metric for the busy runners
+// among the current desired replicas.
+// The desired replicas can't be computed from zero, for which the controller uses QueuedJobsPlusBusyRunners instead.
+func PercentageRunnersBusy(metric v1alpha1.MetricSpec, desiredReplicasBefore, busy int) (int, error) {
+	scaleUpThreshold, err := parseMetricFloat(metric.ScaleUpThreshold, DefaultScaleUpThreshold, "scaleUpThreshold")
+	if err != nil {
+		return 0, err
+	}
+
+	scaleDownThreshold, err := parseMetricFloat(metric.ScaleDownThreshold, DefaultScaleDownThreshold, "scaleDownThreshold")
+	if err != nil {
+		return 0, err
+	}
+
+	scaleUpFactor, scaleDownFactor, err := parseScaleFactors(metric)
+	if err != nil {
+		return 0, err
+	}
+
+	scaleUpAdjustment, scaleDownAdjustment := metric.ScaleUpAdjustment, metric.ScaleDownAdjustment
+
+	fractionBusy := float64(busy) / float64(desiredReplicasBefore)
+
+	if fractionBusy >= scaleUpThreshold {
+		if scaleUpAdjustment > 0 {
+			return desiredReplicasBefore + scaleUpAdjustment, nil
+		}
+
+		return int(math.Ceil(float64(desiredReplicasBefore) * scaleUpFactor)), nil
+	} else if fractionBusy < scaleDownThreshold {
+		if scaleDownAdjustment > 0 {
+			return desiredReplicasBefore - scaleDownAdjustment, nil
+		}
+
+		return int(float64(desiredReplicasBefore) * scaleDownFactor), nil
+	}
+
+	return desiredReplicasBefore, nil
+}
+
+// QueuedJobsPlusBusyRunners returns the desired replicas of the QueuedJobsPlusBusyRunners metric,
+// which scales out proactively to the weighted sum of the queued workflow jobs and the busy runners,
+// while it scales in only when the percentage of busy runners falls below the scale down threshold, like PercentageRunnersBusy.
+// The replicas never go below the weighted demand on scale in.
+func QueuedJobsPlusBusyRunners(metric v1alpha1.MetricSpec, desiredReplicasBefore, queued, busy int) (int, error) {
+	queuedJobsWeight, err := parseMetricFloat(metric.QueuedJobsWeight, 1, "queuedJobsWeight")
+	if err != nil {
+		return 0, err
+	}
+
+	busyRunnersWeight, err := parseMetricFloat(metric.BusyRunnersWeight, 1, "busyRunnersWeight")
+	if err != nil {
+		return 0, err
+	}
+
+	if queuedJobsWeight < 0 || busyRunnersWeight < 0 {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].queuedJobsWeight and busyRunnersWeight cannot be lower than 0")
+	}
+
+	scaleDownThreshold, err := parseMetricFloat(metric.ScaleDownThreshold, DefaultScaleDownThreshold, "scaleDownThreshold")
+	if err != nil {
+		return 0, err
+	}
+
+	if metric.ScaleDownAdjustment < 0 {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownAdjustment cannot be lower than 0")
+	} else if metric.ScaleDownAdjustment > 0 && metric.ScaleDownFactor != "" {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[]: scaleDownAdjustment and scaleDownFactor cannot be specified together")
+	}
+
+	scaleDownFactor, err := parseMetricFloat(metric.ScaleDownFactor, DefaultScaleDownFactor, "scaleDownFactor")
+	if err != nil {
+		return 0, err
+	}
+
+	demand := int(math.Ceil(queuedJobsWeight*float64(queued) + busyRunnersWeight*float64(busy)))
+
+	if demand >= desiredReplicasBefore {
+		return demand, nil
+	}
+
+	if fractionBusy := float64(busy) / float64(desiredReplicasBefore); fractionBusy >= scaleDownThreshold {
+		return desiredReplicasBefore, nil
+	}
+
+	var desiredReplicas int
+	if metric.ScaleDownAdjustment > 0 {
+		desiredReplicas = desiredReplicasBefore - metric.ScaleDownAdjustment
+	} else {
+		desiredReplicas = int(float64(desiredReplicasBefore) * scaleDownFactor)
+	}
+
+	if desiredReplicas < demand {
+		desiredReplicas = demand
+	}
+
+	return desiredReplicas, nil
+}
+
+// QueuedJobWaitTime returns the desired replicas of the QueuedJobWaitTime metric for the time the oldest queued workflow job
+// has been waiting for a runner.
+// It scales out by the scale up factor or adjustment once the wait exceeds scaleUpWaitTime, up to the number of the queued
+// and in-progress jobs, as more runners than the jobs can't shorten the wait.
+// It scales in by the scale down factor or adjustment while the wait is at or below scaleDownWaitTime, down to the same number,
+// and keeps the replicas in between. The scale target scaled to zero is woken up to the number of the jobs right away,
+// as the jobs would otherwise wait for scaleUpWaitTime in addition to the startup time of the runners.
+func QueuedJobWaitTime(metric v1alpha1.MetricSpec, desiredReplicasBefore int, oldestWait time.Duration, queued, inProgress int) (int, error) {
+	scaleUpWaitTime := DefaultScaleUpWaitTime
+	if d := metric.ScaleUpWaitTime; d != nil {
+		scaleUpWaitTime = d.Duration
+	}
+
+	scaleDownWaitTime := DefaultScaleDownWaitTime
+	if d := metric.ScaleDownWaitTime; d != nil {
+		scaleDownWaitTime = d.Duration
+	}
+
+	if scaleDownWaitTime < 0 || scaleUpWaitTime < scaleDownWaitTime {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownWaitTime cannot be lower than 0 or greater than scaleUpWaitTime")
+	}
+
+	scaleUpFactor, scaleDownFactor, err := parseScaleFactors(metric)
+	if err != nil {
+		return 0, err
+	}
+
+	demand := queued + inProgress
+
+	if desiredReplicasBefore == 0 {
+		return demand, nil
+	}
+
+	if queued > 0 && oldestWait > scaleUpWaitTime {
+		desiredReplicas := int(math.Ceil(float64(desiredReplicasBefore) * scaleUpFactor))
+		if metric.ScaleUpAdjustment > 0 {
+			desiredReplicas = desiredReplicasBefore + metric.ScaleUpAdjustment
+		}
+
+		if desiredReplicas > demand {
+			desiredReplicas = demand
+		}
+
+		if desiredReplicas < desiredReplicasBefore {
+			desiredReplicas = desiredReplicasBefore
+		}
+
+		return desiredReplicas, nil
+	}
+
+	if queued > 0 && oldestWait > scaleDownWaitTime {
+		return desiredReplicasBefore, nil
+	}
+
+	desiredReplicas := int(float64(desiredReplicasBefore) * scaleDownFactor)
+	if metric.ScaleDownAdjustment > 0 {
+		desiredReplicas = desiredReplicasBefore - metric.ScaleDownAdjustment
+	}
+
+	if desiredReplicas < demand {
+		desiredReplicas = demand
+	}
+
+	if desiredReplicas > desiredReplicasBefore {
+		desiredReplicas = desiredReplicasBefore
+	}
+
+	return desiredReplicas, nil
+}
+
+// QueuedJobsPerRunner returns the desired replicas of the QueuedJobsPerRunner metric,
+// which is a runner for each of the in-progress workflow jobs plus a runner per targetQueuedJobsPerRunner queued jobs,
+// so that a pool of many short jobs can intentionally leave more than one queued job to each idle runner.
+func QueuedJobsPerRunner(metric v1alpha1.MetricSpec, queued, inProgress int) (int, error) {
+	target, err := parseMetricFloat(metric.TargetQueuedJobsPerRunner, 1, "targetQueuedJobsPerRunner")
+	if err != nil {
+		return 0, err
+	}
+
+	if target <= 0 {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].targetQueuedJobsPerRunner must be greater than 0")
+	}
+
+	return inProgress + int(math.Ceil(float64(queued)/target)), nil
+}
+
+// QueuedJobsPerRunnerRatio returns the number of the queued workflow jobs per runner of the desired replicas
+// that isn't running any of the in-progress jobs, which is what the QueuedJobsPerRunner metric targets.
+// It returns false when there's no queued job, or no runner left for them, e.g. as the max replicas limited the desired replicas.
+func QueuedJobsPerRunnerRatio(queued, inProgress, desiredReplicas int) (float64, bool) {
+	idle := desiredReplicas - inProgress
+	if queued <= 0 || idle <= 0 {
+		return 0, false
+	}
+
+	return float64(queued) / float64(idle), true
+}
+
+// parseScaleFactors returns the scale up and down factors of the metric,
+// validating that they aren't specified along with the adjustments.
+func parseScaleFactors(metric v1alpha1.MetricSpec) (float64, float64, error) {
+	var err error
+
+	scaleUpFactor := DefaultScaleUpFactor
+	if metric.ScaleUpAdjustment != 0 {
+		if metric.ScaleUpAdjustment < 0 {
+			return 0, 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpAdjustment cannot be lower than 0")
+		}
+
+		if metric.ScaleUpFactor != "" {
+			return 0, 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[]: scaleUpAdjustment and scaleUpFactor cannot be specified together")
+		}
+	} else if scaleUpFactor, err = parseMetricFloat(metric.ScaleUpFactor, DefaultScaleUpFactor, "scaleUpFactor"); err != nil {
+		return 0, 0, err
+	}
+
+	scaleDownFactor := DefaultScaleDownFactor
+	if metric.ScaleDownAdjustment != 0 {
+		if metric.ScaleDownAdjustment < 0 {
+			return 0, 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownAdjustment cannot be lower than 0")
+		}
+
+		if metric.ScaleDownFactor != "" {
+			return 0, 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[]: scaleDownAdjustment and scaleDownFactor cannot be specified together")
+		}
+	} else if scaleDownFactor, err = parseMetricFloat(metric.ScaleDownFactor, DefaultScaleDownFactor, "scaleDownFactor"); err != nil {
+		return 0, 0, err
+	}
+
+	return scaleUpFactor, scaleDownFactor, nil
+}
+
+func parseMetricFloat(s string, def float64, field string) (float64, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].%s cannot be parsed into a float64", field)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,318 @@
+package autoscaling
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPercentageRunnersBusy(t *testing.T) {
+	testcases := []struct {
+		metric        v1alpha1.MetricSpec
+		desiredBefore int
+		busy          int
+		want          int
+	}{
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, busy: 8, want: 13},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, busy: 2, want: 7},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, busy: 5, want: 10},
+		{metric: v1alpha1.MetricSpec{ScaleUpAdjustment: 2}, desiredBefore: 10, busy: 10, want: 12},
+		{metric: v1alpha1.MetricSpec{ScaleDownAdjustment: 3}, desiredBefore: 10, busy: 0, want: 7},
+		{metric: v1alpha1.MetricSpec{ScaleUpThreshold: "0.5", ScaleUpFactor: "2"}, desiredBefore: 4, busy: 2, want: 8},
+	}
+
+	for i, tc := range testcases {
+		got, err := PercentageRunnersBusy(tc.metric, tc.desiredBefore, tc.busy)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("[%d] unexpected desired replicas: want %d, got %d", i, tc.want, got)
+		}
+	}
+}
+
+func TestQueuedJobsPlusBusyRunners(t *testing.T) {
+	testcases := []struct {
+		metric        v1alpha1.MetricSpec
+		desiredBefore int
+		queued        int
+		busy          int
+		want          int
+	}{
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 3, queued: 3, busy: 2, want: 5},
+		{metric: v1alpha1.MetricSpec{QueuedJobsWeight: "0.5"}, desiredBefore: 3, queued: 3, busy: 2, want: 4},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, queued: 3, busy: 2, want: 7},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, queued: 0, busy: 5, want: 10},
+		{metric: v1alpha1.MetricSpec{ScaleDownAdjustment: 8}, desiredBefore: 10, queued: 3, busy: 2, want: 5},
+	}
+
+	for i, tc := range testcases {
+		got, err := QueuedJobsPlusBusyRunners(tc.metric, tc.desiredBefore, tc.queued, tc.busy)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("[%d] unexpected desired replicas: want %d, got %d", i, tc.want, got)
+		}
+	}
+}
+
+func TestQueuedJobWaitTime(t *testing.T) {
+	testcases := []struct {
+		metric        v1alpha1.MetricSpec
+		desiredBefore int
+		wait          time.Duration
+		queued        int
+		inProgress    int
+		want          int
+	}{
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 2 * time.Minute, queued: 5, inProgress: 10, want: 13},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 2 * time.Minute, queued: 1, inProgress: 10, want: 11},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 2 * time.Minute, queued: 1, inProgress: 5, want: 10},
+		{metric: v1alpha1.MetricSpec{ScaleUpAdjustment: 5}, desiredBefore: 10, wait: 2 * time.Minute, queued: 10, inProgress: 10, want: 15},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 30 * time.Second, queued: 5, inProgress: 10, want: 10},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 0, queued: 0, inProgress: 2, want: 7},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 10, wait: 5 * time.Second, queued: 1, inProgress: 8, want: 9},
+		{metric: v1alpha1.MetricSpec{ScaleDownAdjustment: 1}, desiredBefore: 10, wait: 0, queued: 0, inProgress: 0, want: 9},
+		{metric: v1alpha1.MetricSpec{}, desiredBefore: 0, wait: 5 * time.Second, queued: 2, inProgress: 0, want: 2},
+		{
+			metric:        v1alpha1.MetricSpec{ScaleUpWaitTime: &metav1.Duration{Duration: 10 * time.Second}, ScaleDownWaitTime: &metav1.Duration{Duration: 0}},
+			desiredBefore: 10, wait: 30 * time.Second, queued: 5, inProgress: 10, want: 13,
+		},
+	}
+
+	for i, tc := range testcases {
+		got, err := QueuedJobWaitTime(tc.metric, tc.desiredBefore, tc.wait, tc.queued, tc.inProgress)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("[%d] unexpected desired replicas: want %d, got %d", i, tc.want, got)
+		}
+	}
+
+	if _, err := QueuedJobWaitTime(v1alpha1.MetricSpec{ScaleDownWaitTime: &metav1.Duration{Duration: 2 * time.Minute}}, 10, 0, 0, 0); err == nil {
+		t.Errorf("expected an error for scaleDownWaitTime greater than scaleUpWaitTime")
+	}
+}
+
+func TestQueuedJobsPerRunner(t *testing.T) {
+	testcases := []struct {
+		metric     v1alpha1.MetricSpec
+		queued     int
+		inProgress int
+		want       int
+	}{
+		{metric: v1alpha1.MetricSpec{}, queued: 5, inProgress: 3, want: 8},
+		{metric: v1alpha1.MetricSpec{TargetQueuedJobsPerRunner: "2"}, queued: 10, inProgress: 3, want: 8},
+		{metric: v1alpha1.MetricSpec{TargetQueuedJobsPerRunner: "2"}, queued: 5, inProgress: 0, want: 3},
+		{metric: v1alpha1.MetricSpec{TargetQueuedJobsPerRunner: "0.5"}, queued: 3, inProgress: 1, want: 7},
+		{metric: v1alpha1.MetricSpec{TargetQueuedJobsPerRunner: "4"}, queued: 0, inProgress: 2, want: 2},
+	}
+
+	for i, tc := range testcases {
+		got, err := QueuedJobsPerRunner(tc.metric, tc.queued, tc.inProgress)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("[%d] unexpected desired replicas: want %d, got %d", i, tc.want, got)
+		}
+	}
+
+	for _, target := range []string{"0", "-1", "two"} {
+		if _, err := QueuedJobsPerRunner(v1alpha1.MetricSpec{TargetQueuedJobsPerRunner: target}, 1, 0); err == nil {
+			t.Errorf("expected an error for targetQueuedJobsPerRunner %q", target)
+		}
+	}
+}
+
+func TestQueuedJobsPerRunnerRatio(t *testing.T) {
+	testcases := []struct {
+		queued, inProgress, desired int
+
+		want   float64
+		wantOK bool
+	}{
+		{queued: 10, inProgress: 3, desired: 8, want: 2, wantOK: true},
+		{queued: 10, inProgress: 3, desired: 5, want: 5, wantOK: true},
+		{queued: 10, inProgress: 3, desired: 3},
+		{queued: 0, inProgress: 3, desired: 5},
+	}
+
+	for i, tc := range testcases {
+		got, ok := QueuedJobsPerRunnerRatio(tc.queued, tc.inProgress, tc.desired)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("[%d] unexpected ratio: want %v (%v), got %v (%v)", i, tc.want, tc.wantOK, got, ok)
+		}
+	}
+}
+
+func TestPercentageRunnersBusy_InvalidMetric(t *testing.T) {
+	for i, m := range []v1alpha1.MetricSpec{
+		{ScaleUpThreshold: "high"},
+		{ScaleUpAdjustment: -1},
+		{ScaleUpAdjustment: 2, ScaleUpFactor: "2"},
+		{ScaleDownAdjustment: 2, ScaleDownFactor: "0.5"},
+	} {
+		if _, err := PercentageRunnersBusy(m, 10, 5); err == nil {
+			t.Errorf("[%d] expected an error for %+v", i, m)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	percentage := v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy}
+	total := v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns}
+	queued := v1alpha1.MetricSpec{Type: v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners}
+
+	suspended := func(m v1alpha1.MetricSpec) v1alpha1.MetricSpec {
+		m.Suspended = true
+		return m
+	}
+
+	testcases := []struct {
+		description string
+		metrics     []v1alpha1.MetricSpec
+		policy      string
+		suggestions map[string]*int
+		want        *int
+		wantMetric  string
+		wantErr     bool
+	}{
+		{
+			description: "no metrics",
+		},
+		{
+			description: "primary metric",
+			metrics:     []v1alpha1.MetricSpec{percentage, total},
+			suggestions: map[string]*int{percentage.Type: intPtr(3), total.Type: intPtr(5)},
+			want:        intPtr(3),
+			wantMetric:  percentage.Type,
+		},
+		{
+			description: "fallback metric",
+			metrics:     []v1alpha1.MetricSpec{percentage, total},
+			suggestions: map[string]*int{percentage.Type: intPtr(0), total.Type: intPtr(5)},
+			want:        intPtr(5),
+			wantMetric:  total.Type,
+		},
+		{
+			description: "single metric suggesting nothing",
+			metrics:     []v1alpha1.MetricSpec{total},
+			wantMetric:  total.Type,
+		},
+		{
+			description: "invalid fallback metric",
+			metrics:     []v1alpha1.MetricSpec{total, percentage},
+			suggestions: map[string]*int{total.Type: intPtr(0)},
+			wantErr:     true,
+		},
+		{
+			description: "too many metrics",
+			metrics:     []v1alpha1.MetricSpec{percentage, total, queued},
+			wantErr:     true,
+		},
+		{
+			description: "combined metrics ignore the ones suggesting nothing",
+			metrics:     []v1alpha1.MetricSpec{percentage, total, queued},
+			policy:      v1alpha1.MetricsCombinationPolicySum,
+			suggestions: map[string]*int{percentage.Type: intPtr(3), queued.Type: intPtr(4)},
+			want:        intPtr(7),
+			wantMetric:  queued.Type,
+		},
+		{
+			description: "suspended primary metric is skipped",
+			metrics:     []v1alpha1.MetricSpec{suspended(percentage), total},
+			suggestions: map[string]*int{percentage.Type: intPtr(3), total.Type: intPtr(5)},
+			want:        intPtr(5),
+			wantMetric:  total.Type,
+		},
+		{
+			description: "suspended fallback metric is skipped",
+			metrics:     []v1alpha1.MetricSpec{percentage, suspended(total)},
+			suggestions: map[string]*int{percentage.Type: intPtr(0), total.Type: intPtr(5)},
+			wantMetric:  percentage.Type,
+		},
+		{
+			description: "suspended metrics don't count toward the limit",
+			metrics:     []v1alpha1.MetricSpec{suspended(queued), percentage, total},
+			suggestions: map[string]*int{percentage.Type: intPtr(3), queued.Type: intPtr(4)},
+			want:        intPtr(3),
+			wantMetric:  percentage.Type,
+		},
+		{
+			description: "all metrics suspended",
+			metrics:     []v1alpha1.MetricSpec{suspended(percentage), suspended(total)},
+			suggestions: map[string]*int{percentage.Type: intPtr(3), total.Type: intPtr(5)},
+		},
+		{
+			description: "combined metrics skip the suspended ones",
+			metrics:     []v1alpha1.MetricSpec{percentage, suspended(queued)},
+			policy:      v1alpha1.MetricsCombinationPolicyMax,
+			suggestions: map[string]*int{percentage.Type: intPtr(3), queued.Type: intPtr(4)},
+			want:        intPtr(3),
+			wantMetric:  percentage.Type,
+		},
+		{
+			description: "unsupported combination policy",
+			metrics:     []v1alpha1.MetricSpec{percentage, total},
+			policy:      "Median",
+			suggestions: map[string]*int{percentage.Type: intPtr(3), total.Type: intPtr(5)},
+			wantErr:     true,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			spec := v1alpha1.HorizontalRunnerAutoscalerSpec{Metrics: tc.metrics, MetricsCombinationPolicy: tc.policy}
+
+			got, metric, err := Suggest(spec, func(m v1alpha1.MetricSpec) (*int, error) {
+				return tc.suggestions[m.Type], nil
+			})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if fmt.Sprint(deref(got)) != fmt.Sprint(deref(tc.want)) {
+				t.Errorf("unexpected replicas: want %v, got %v", deref(tc.want), deref(got))
+			}
+
+			var gotMetric string
+			if metric != nil {
+				gotMetric = metric.Type
+			}
+
+			if gotMetric != tc.wantMetric {
+				t.Errorf("unexpected metric: want %q, got %q", tc.wantMetric, gotMetric)
+			}
+		})
+	}
+}
+
+func deref(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
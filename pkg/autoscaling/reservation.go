@@ -0,0 +1,32 @@
+package autoscaling
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// ReservedReplicas returns the sum of the replicas of the CapacityReservations unexpired at now,
+// along with the earliest expiration time among them, which is nil when there's none.
+func ReservedReplicas(now time.Time, reservations []v1alpha1.CapacityReservation) (int, *time.Time) {
+	var (
+		reserved       int
+		nextExpiration *time.Time
+	)
+
+	for _, reservation := range reservations {
+		t := reservation.ExpirationTime.Time
+
+		if !t.After(now) {
+			continue
+		}
+
+		reserved += reservation.Replicas
+
+		if nextExpiration == nil || t.Before(*nextExpiration) {
+			nextExpiration = &t
+		}
+	}
+
+	return reserved, nextExpiration
+}
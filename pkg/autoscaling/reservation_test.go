@@ -0,0 +1,61 @@
+package autoscaling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReservedReplicas(t *testing.T) {
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	reservation := func(replicas int, expiresIn time.Duration) v1alpha1.CapacityReservation {
+		return v1alpha1.CapacityReservation{
+			ExpirationTime: metav1.Time{Time: now.Add(expiresIn)},
+			Replicas:       replicas,
+		}
+	}
+
+	testcases := []struct {
+		description        string
+		reservations       []v1alpha1.CapacityReservation
+		wantReserved       int
+		wantNextExpiration time.Duration
+	}{
+		{
+			description: "no reservations",
+		},
+		{
+			description:  "expired reservations",
+			reservations: []v1alpha1.CapacityReservation{reservation(1, -time.Minute), reservation(2, 0)},
+		},
+		{
+			description:        "unexpired reservations",
+			reservations:       []v1alpha1.CapacityReservation{reservation(5, time.Hour), reservation(1, -time.Minute), reservation(2, 10*time.Minute)},
+			wantReserved:       7,
+			wantNextExpiration: 10 * time.Minute,
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			reserved, nextExpiration := ReservedReplicas(now, tc.reservations)
+
+			if reserved != tc.wantReserved {
+				t.Errorf("unexpected reserved replicas: want %d, got %d", tc.wantReserved, reserved)
+			}
+
+			if tc.wantNextExpiration == 0 {
+				if nextExpiration != nil {
+					t.Errorf("unexpected next expiration: want nil, got %v", *nextExpiration)
+				}
+			} else if nextExpiration == nil || !nextExpiration.Equal(now.Add(tc.wantNextExpiration)) {
+				t.Errorf("unexpected next expiration: want %v, got %v", now.Add(tc.wantNextExpiration), nextExpiration)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package autoscaling
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// stabilizeScaleDown returns the highest replicas recommended within the scale down stabilization window,
+// along with the recommendations to be kept in the status for the next reconciliation.
+//
+// A recommendation is dropped once a later one is equal or higher, as it can no longer be the highest in the window,
+// so the kept recommendations are ordered by time and strictly decreasing in replicas, and the first one is the highest.
+func stabilizeScaleDown(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, recommended int) (int, []v1alpha1.ReplicaRecommendation) {
+	window := hra.Spec.ScaleDownStabilizationWindowSeconds
+	if window == nil || *window <= 0 {
+		return recommended, nil
+	}
+
+	since := now.Add(-time.Duration(*window) * time.Second)
+
+	var recommendations []v1alpha1.ReplicaRecommendation
+
+	for _, r := range hra.Status.ScaleDownRecommendations {
+		if r.Time.Time.After(since) && r.Replicas > recommended {
+			recommendations = append(recommendations, r)
+		}
+	}
+
+	recommendations = append(recommendations, v1alpha1.ReplicaRecommendation{
+		Time:     metav1.Time{Time: now},
+		Replicas: recommended,
+	})
+
+	return recommendations[0].Replicas, recommendations
+}
+
+// limitScaleDownStep returns the desired replicas limited to ScaleDownMaxStep below the current desired replicas.
+func limitScaleDownStep(hra v1alpha1.HorizontalRunnerAutoscaler, current, desired int) (int, error) {
+	maxStep := hra.Spec.ScaleDownMaxStep
+	if maxStep == nil || desired >= current {
+		return desired, nil
+	}
+
+	step, err := getMaxScaleStep("scaleDownMaxStep", maxStep, current)
+	if err != nil {
+		return 0, err
+	}
+
+	if floor := current - step; desired < floor {
+		return floor, nil
+	}
+
+	return desired, nil
+}
+
+// getMaxScaleStep returns the number of replicas maxStep allows to add or remove at once, which is at least 1.
+// A percentage is of the current desired replicas and rounded up.
+func getMaxScaleStep(field string, maxStep *intstr.IntOrString, current int) (int, error) {
+	step, err := intstr.GetScaledValueFromIntOrPercent(maxStep, current, true)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, maxStep.String(), err)
+	}
+
+	if step < 1 {
+		step = 1
+	}
+
+	return step, nil
+}
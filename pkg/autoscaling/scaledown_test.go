@@ -0,0 +1,154 @@
+package autoscaling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestStabilizeScaleDown(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	recommendation := func(minutesAgo, replicas int) v1alpha1.ReplicaRecommendation {
+		return v1alpha1.ReplicaRecommendation{
+			Time:     metav1.NewTime(now.Add(-time.Duration(minutesAgo) * time.Minute)),
+			Replicas: replicas,
+		}
+	}
+
+	testcases := []struct {
+		description     string
+		window          *int
+		recommendations []v1alpha1.ReplicaRecommendation
+		recommended     int
+		want            int
+		wantKept        []int
+	}{
+		{
+			description:     "no window",
+			recommendations: []v1alpha1.ReplicaRecommendation{recommendation(1, 10)},
+			recommended:     2,
+			want:            2,
+		},
+		{
+			description: "first recommendation",
+			window:      intPtr(300),
+			recommended: 5,
+			want:        5,
+			wantKept:    []int{5},
+		},
+		{
+			description:     "highest recommendation within the window",
+			window:          intPtr(300),
+			recommendations: []v1alpha1.ReplicaRecommendation{recommendation(4, 10), recommendation(2, 6)},
+			recommended:     2,
+			want:            10,
+			wantKept:        []int{10, 6, 2},
+		},
+		{
+			description:     "recommendations out of the window are dropped",
+			window:          intPtr(300),
+			recommendations: []v1alpha1.ReplicaRecommendation{recommendation(6, 10), recommendation(2, 6)},
+			recommended:     2,
+			want:            6,
+			wantKept:        []int{6, 2},
+		},
+		{
+			description:     "scale up drops the lower recommendations",
+			window:          intPtr(300),
+			recommendations: []v1alpha1.ReplicaRecommendation{recommendation(4, 10), recommendation(2, 6)},
+			recommended:     12,
+			want:            12,
+			wantKept:        []int{12},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.description, func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleDownStabilizationWindowSeconds: tc.window,
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					ScaleDownRecommendations: tc.recommendations,
+				},
+			}
+
+			got, kept := stabilizeScaleDown(now, hra, tc.recommended)
+
+			if got != tc.want {
+				t.Errorf("incorrect replicas: want %d, got %d", tc.want, got)
+			}
+
+			var gotKept []int
+			for _, r := range kept {
+				gotKept = append(gotKept, r.Replicas)
+			}
+
+			if !reflect.DeepEqual(gotKept, tc.wantKept) {
+				t.Errorf("incorrect recommendations kept: want %v, got %v", tc.wantKept, gotKept)
+			}
+		})
+	}
+}
+
+func TestLimitScaleDownStep(t *testing.T) {
+	intOrStr := func(s string) *intstr.IntOrString {
+		v := intstr.Parse(s)
+		return &v
+	}
+
+	testcases := []struct {
+		maxStep *intstr.IntOrString
+		current int
+		desired int
+		want    int
+		wantErr bool
+	}{
+		{maxStep: nil, current: 10, desired: 1, want: 1},
+		{maxStep: intOrStr("2"), current: 10, desired: 1, want: 8},
+		{maxStep: intOrStr("2"), current: 10, desired: 9, want: 9},
+		{maxStep: intOrStr("2"), current: 5, desired: 8, want: 8},
+		{maxStep: intOrStr("25%"), current: 10, desired: 1, want: 7},
+		{maxStep: intOrStr("10%"), current: 3, desired: 1, want: 2},
+		{maxStep: intOrStr("0"), current: 3, desired: 1, want: 2},
+		{maxStep: intOrStr("abc"), current: 3, desired: 1, wantErr: true},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.maxStep.String(), func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleDownMaxStep: tc.maxStep,
+				},
+			}
+
+			got, err := limitScaleDownStep(hra, tc.current, tc.desired)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("incorrect replicas: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
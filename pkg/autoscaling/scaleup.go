@@ -0,0 +1,45 @@
+package autoscaling
+
+import (
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+)
+
+// DefaultScaleUpPeriod is the minimum interval between two scale ups limited by ScaleUpMaxStep
+// when the HRA doesn't specify scaleUpPeriodSeconds.
+const DefaultScaleUpPeriod = 30 * time.Second
+
+// ScaleUpPeriod returns the minimum interval between two scale ups limited by ScaleUpMaxStep.
+func ScaleUpPeriod(hra v1alpha1.HorizontalRunnerAutoscaler) time.Duration {
+	if p := hra.Spec.ScaleUpPeriodSeconds; p != nil && *p >= 0 {
+		return time.Duration(*p) * time.Second
+	}
+
+	return DefaultScaleUpPeriod
+}
+
+// limitScaleUpStep returns the desired replicas limited to ScaleUpMaxStep above the current desired replicas.
+// The current desired replicas are kept until ScaleUpPeriodSeconds passes since the last scale up,
+// so that the replicas increase by ScaleUpMaxStep per period at most, however often the HRA is reconciled.
+func limitScaleUpStep(now time.Time, hra v1alpha1.HorizontalRunnerAutoscaler, current, desired int) (int, error) {
+	maxStep := hra.Spec.ScaleUpMaxStep
+	if maxStep == nil || desired <= current {
+		return desired, nil
+	}
+
+	step, err := getMaxScaleStep("scaleUpMaxStep", maxStep, current)
+	if err != nil {
+		return 0, err
+	}
+
+	if last := hra.Status.LastSuccessfulScaleOutTime; last != nil && last.Add(ScaleUpPeriod(hra)).After(now) {
+		return current, nil
+	}
+
+	if ceiling := current + step; desired > ceiling {
+		return ceiling, nil
+	}
+
+	return desired, nil
+}
@@ -0,0 +1,82 @@
+package autoscaling
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestLimitScaleUpStep(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+
+	intOrStr := func(s string) *intstr.IntOrString {
+		v := intstr.Parse(s)
+		return &v
+	}
+
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	minutesAgo := func(m int) *metav1.Time {
+		t := metav1.NewTime(now.Add(-time.Duration(m) * time.Minute))
+		return &t
+	}
+
+	testcases := []struct {
+		maxStep      *intstr.IntOrString
+		period       *int
+		lastScaleOut *metav1.Time
+		current      int
+		desired      int
+		want         int
+		wantErr      bool
+	}{
+		{maxStep: nil, current: 1, desired: 300, want: 300},
+		{maxStep: intOrStr("5"), current: 1, desired: 300, want: 6},
+		{maxStep: intOrStr("5"), current: 1, desired: 3, want: 3},
+		{maxStep: intOrStr("5"), current: 10, desired: 2, want: 2},
+		{maxStep: intOrStr("50%"), current: 10, desired: 300, want: 15},
+		{maxStep: intOrStr("50%"), current: 0, desired: 300, want: 1},
+		{maxStep: intOrStr("5"), lastScaleOut: minutesAgo(0), current: 6, desired: 300, want: 6},
+		{maxStep: intOrStr("5"), lastScaleOut: minutesAgo(1), current: 6, desired: 300, want: 11},
+		{maxStep: intOrStr("5"), period: intPtr(120), lastScaleOut: minutesAgo(1), current: 6, desired: 300, want: 6},
+		{maxStep: intOrStr("5"), period: intPtr(0), lastScaleOut: minutesAgo(0), current: 6, desired: 300, want: 11},
+		{maxStep: intOrStr("abc"), current: 1, desired: 3, wantErr: true},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(fmt.Sprintf("%s %d->%d", tc.maxStep, tc.current, tc.desired), func(t *testing.T) {
+			hra := v1alpha1.HorizontalRunnerAutoscaler{
+				Spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+					ScaleUpMaxStep:       tc.maxStep,
+					ScaleUpPeriodSeconds: tc.period,
+				},
+				Status: v1alpha1.HorizontalRunnerAutoscalerStatus{
+					LastSuccessfulScaleOutTime: tc.lastScaleOut,
+				},
+			}
+
+			got, err := limitScaleUpStep(now, hra, tc.current, tc.desired)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("incorrect replicas: want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
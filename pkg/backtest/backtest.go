@@ -0,0 +1,495 @@
+// Package backtest evaluates how a HorizontalRunnerAutoscaler configuration would have scaled
+// against the historical workflow jobs obtained from GitHub.
+//
+// The simulation mirrors the pull-based autoscaling logic of the controller.
+// Each SyncPeriod, the desired replicas are computed from the simulated state of the runner pool
+// by the scale algorithms registered in the autoscaling package, and runners are added or removed accordingly.
+// Queued jobs are assigned to idle runners in the order they were created,
+// and occupy the runners for the same duration as they actually took.
+// Runners are assumed to be ephemeral, so that a runner becomes available again only after
+// RunnerStartupTime since it completed a job.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+	gogithub "github.com/google/go-github/v39/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultSyncPeriod        = time.Minute
+	DefaultRunnerStartupTime = time.Minute
+	DefaultScaleDownDelay    = 10 * time.Minute
+	DefaultDelayThreshold    = 2 * time.Minute
+	DefaultResolution        = 5 * time.Second
+)
+
+// Job is a historical workflow job.
+type Job struct {
+	ID         int64    `json:"id"`
+	Repository string   `json:"repository"`
+	Labels     []string `json:"labels"`
+
+	// CreatedAt is the time the job was queued.
+	CreatedAt   time.Time `json:"createdAt"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// Duration returns the time the job actually took on the runner.
+func (j Job) Duration() time.Duration {
+	if d := j.CompletedAt.Sub(j.StartedAt); d > 0 {
+		return d
+	}
+
+	return 0
+}
+
+// Config is the proposed autoscaling configuration and the parameters of the simulation.
+type Config struct {
+	HorizontalRunnerAutoscaler v1alpha1.HorizontalRunnerAutoscalerSpec
+
+	// RunnerLabels is the list of custom labels of the runner pool.
+	// Only jobs that can run on the runner pool are taken into account, the same as the controller does.
+	RunnerLabels []string
+
+	SyncPeriod        time.Duration
+	RunnerStartupTime time.Duration
+	// DefaultScaleDownDelay is used when the HRA doesn't specify scaleDownDelaySecondsAfterScaleOut.
+	DefaultScaleDownDelay time.Duration
+	// DelayThreshold is the queue wait above which a job is counted as delayed.
+	DelayThreshold time.Duration
+	// Resolution is the time step of the simulation.
+	Resolution time.Duration
+}
+
+// Sample is the state of the simulated runner pool at a sync period.
+type Sample struct {
+	Time            time.Time `json:"time"`
+	DesiredReplicas int       `json:"desiredReplicas"`
+	Runners         int       `json:"runners"`
+	Busy            int       `json:"busy"`
+	Queued          int       `json:"queued"`
+}
+
+// Report is the result of a backtest.
+type Report struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Jobs int `json:"jobs"`
+	// JobsDelayed is the number of jobs that waited longer than DelayThreshold in the queue.
+	JobsDelayed    int           `json:"jobsDelayed"`
+	DelayThreshold time.Duration `json:"delayThreshold"`
+	// JobsUnfinished is the number of jobs that didn't start or complete by the end of the period.
+	JobsUnfinished int `json:"jobsUnfinished"`
+
+	QueueWait       Percentiles `json:"queueWait"`
+	ActualQueueWait Percentiles `json:"actualQueueWait"`
+
+	RunnerMinutes     float64 `json:"runnerMinutes"`
+	BusyRunnerMinutes float64 `json:"busyRunnerMinutes"`
+	// IdleRunnerMinutes is the total minutes the runners were ready but had no job to run.
+	IdleRunnerMinutes float64 `json:"idleRunnerMinutes"`
+
+	MinReplicas int `json:"minReplicas"`
+	MaxReplicas int `json:"maxReplicas"`
+
+	Timeline []Sample `json:"timeline,omitempty"`
+}
+
+// Percentiles summarizes a distribution of durations.
+type Percentiles struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	Max time.Duration `json:"max"`
+}
+
+func newPercentiles(ds []time.Duration) Percentiles {
+	if len(ds) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := append([]time.Duration{}, ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	at := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+
+	return Percentiles{
+		P50: at(0.5),
+		P95: at(0.95),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+type simJob struct {
+	Job
+	started bool
+	start   time.Time
+}
+
+type simRunner struct {
+	readyAt   time.Time
+	job       *simJob
+	busyUntil time.Time
+}
+
+// Run simulates the runner pool between from and to.
+func Run(c Config, jobs []Job, from, to time.Time) (*Report, error) {
+	spec := c.HorizontalRunnerAutoscaler
+
+	if spec.MinReplicas == nil {
+		return nil, errors.New("minReplicas is required")
+	}
+
+	if spec.MaxReplicas == nil {
+		return nil, errors.New("maxReplicas is required")
+	}
+
+	syncPeriod := orDefault(c.SyncPeriod, DefaultSyncPeriod)
+	startup := orDefault(c.RunnerStartupTime, DefaultRunnerStartupTime)
+	delayThreshold := orDefault(c.DelayThreshold, DefaultDelayThreshold)
+	resolution := orDefault(c.Resolution, DefaultResolution)
+
+	scaleDownDelay := orDefault(c.DefaultScaleDownDelay, DefaultScaleDownDelay)
+
+	var pending []*simJob
+	for _, j := range jobs {
+		if !matchesLabels(j.Labels, c.RunnerLabels) {
+			continue
+		}
+
+		if j.CreatedAt.Before(from) || !j.CreatedAt.Before(to) {
+			continue
+		}
+
+		pending = append(pending, &simJob{Job: j})
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+
+	report := &Report{
+		From:           from,
+		To:             to,
+		Jobs:           len(pending),
+		DelayThreshold: delayThreshold,
+		MinReplicas:    math.MaxInt32,
+	}
+
+	var (
+		queue   []*simJob
+		runners []*simRunner
+		next    int
+
+		desired       = *spec.MinReplicas
+		nextSync      = from
+		waits, actual []time.Duration
+
+		runnerTime, busyTime, idleTime time.Duration
+	)
+
+	algorithms := autoscaling.ScaleAlgorithms()
+
+	// hra carries the state of the previous computations of the desired replicas in its status, like the controller does.
+	hra := v1alpha1.HorizontalRunnerAutoscaler{Spec: spec}
+
+	for i := 0; i < desired; i++ {
+		runners = append(runners, &simRunner{readyAt: from})
+	}
+
+	for t := from; t.Before(to); t = t.Add(resolution) {
+		for _, r := range runners {
+			if r.job != nil && !r.busyUntil.After(t) {
+				r.job = nil
+				r.readyAt = t.Add(startup)
+			}
+		}
+
+		for ; next < len(pending) && !pending[next].CreatedAt.After(t); next++ {
+			queue = append(queue, pending[next])
+		}
+
+		if !t.Before(nextSync) {
+			var busy int
+			for _, r := range runners {
+				if r.job != nil {
+					busy++
+				}
+			}
+
+			desiredBefore := desired
+
+			hra.Status.DesiredReplicas = &desiredBefore
+
+			observer := &simObserver{now: t, queue: queue, runners: runners}
+
+			suggested, metric, err := autoscaling.Suggest(spec, func(m v1alpha1.MetricSpec) (*int, error) {
+				return suggestReplicasByMetric(algorithms, hra, m, c.RunnerLabels, desiredBefore, observer, t)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := autoscaling.Compute(autoscaling.Input{
+				Now:                        t,
+				HorizontalRunnerAutoscaler: hra,
+				MinReplicas:                *spec.MinReplicas,
+				Suggested:                  suggested,
+				Metric:                     metric,
+				DefaultScaleDownDelay:      scaleDownDelay,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			desired = res.DesiredReplicas
+
+			hra.Status.ScaleDownRecommendations = res.Recommendations
+			if desired > desiredBefore {
+				hra.Status.LastSuccessfulScaleOutTime = &metav1.Time{Time: t}
+			}
+			runners = scale(runners, desired, t, startup)
+
+			report.Timeline = append(report.Timeline, Sample{
+				Time:            t,
+				DesiredReplicas: desired,
+				Runners:         len(runners),
+				Busy:            busy,
+				Queued:          len(queue),
+			})
+
+			if desired < report.MinReplicas {
+				report.MinReplicas = desired
+			}
+
+			if desired > report.MaxReplicas {
+				report.MaxReplicas = desired
+			}
+
+			nextSync = nextSync.Add(syncPeriod)
+		}
+
+		for _, r := range runners {
+			if len(queue) == 0 {
+				break
+			}
+
+			if r.job != nil || r.readyAt.After(t) {
+				continue
+			}
+
+			j := queue[0]
+			queue = queue[1:]
+
+			j.started = true
+			j.start = t
+
+			r.job = j
+			r.busyUntil = t.Add(j.Duration())
+
+			wait := t.Sub(j.CreatedAt)
+			waits = append(waits, wait)
+
+			if wait > delayThreshold {
+				report.JobsDelayed++
+			}
+		}
+
+		for _, r := range runners {
+			runnerTime += resolution
+
+			switch {
+			case r.job != nil:
+				busyTime += resolution
+			case !r.readyAt.After(t):
+				idleTime += resolution
+			}
+		}
+	}
+
+	for _, j := range pending {
+		if !j.started || j.start.Add(j.Duration()).After(to) {
+			report.JobsUnfinished++
+		}
+
+		if !j.started && to.Sub(j.CreatedAt) > delayThreshold {
+			report.JobsDelayed++
+		}
+
+		if !j.StartedAt.IsZero() {
+			actual = append(actual, j.StartedAt.Sub(j.CreatedAt))
+		}
+	}
+
+	if report.MinReplicas == math.MaxInt32 {
+		report.MinReplicas = 0
+	}
+
+	report.RunnerMinutes = runnerTime.Minutes()
+	report.BusyRunnerMinutes = busyTime.Minutes()
+	report.IdleRunnerMinutes = idleTime.Minutes()
+
+	report.QueueWait = newPercentiles(waits)
+	report.ActualQueueWait = newPercentiles(actual)
+
+	return report, nil
+}
+
+// scale adds runners that become ready after startup, or removes idle runners, so that there are desired runners.
+// Busy runners are never removed, like the controller waits for busy runners to complete their jobs.
+func scale(runners []*simRunner, desired int, now time.Time, startup time.Duration) []*simRunner {
+	for len(runners) < desired {
+		runners = append(runners, &simRunner{readyAt: now.Add(startup)})
+	}
+
+	excess := len(runners) - desired
+	if excess <= 0 {
+		return runners
+	}
+
+	var kept []*simRunner
+
+	// Remove runners that are not ready yet first, as the controller prefers deleting runners that are not registered yet.
+	for _, r := range runners {
+		if excess > 0 && r.job == nil && r.readyAt.After(now) {
+			excess--
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	runners = kept
+	kept = nil
+
+	for _, r := range runners {
+		if excess > 0 && r.job == nil {
+			excess--
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	return kept
+}
+
+// suggestReplicasByMetric mirrors HorizontalRunnerAutoscalerReconciler.suggestReplicasByMetric,
+// suggesting the replicas by the scale algorithm registered for the type of the metric,
+// with the runners and the workflow jobs observed from the simulation instead of GitHub.
+func suggestReplicasByMetric(algorithms map[string]autoscaling.ScaleAlgorithm, hra v1alpha1.HorizontalRunnerAutoscaler, m v1alpha1.MetricSpec, runnerLabels []string, desiredBefore int, observer autoscaling.Observer, now time.Time) (*int, error) {
+	algorithm, ok := algorithms[m.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported metric type for backtesting %q", m.Type)
+	}
+
+	s, err := algorithm.SuggestReplicas(context.Background(), autoscaling.ScaleRequest{
+		HorizontalRunnerAutoscaler: hra,
+		Metric:                     m,
+		ScaleTarget: autoscaling.ScaleTarget{
+			Kind:     "RunnerDeployment",
+			Labels:   runnerLabels,
+			Replicas: &desiredBefore,
+		},
+		Observer: observer,
+		Now:      now,
+	})
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	return &s.Replicas, nil
+}
+
+// simObserver is the autoscaling.Observer of the simulated runner pool at a sync period.
+// Every job in the simulation can run on the runner pool, so the metric doesn't filter the jobs.
+type simObserver struct {
+	now     time.Time
+	queue   []*simJob
+	runners []*simRunner
+}
+
+func (o *simObserver) Runners(ctx context.Context) (*autoscaling.RunnerCounts, error) {
+	c := &autoscaling.RunnerCounts{Runners: len(o.runners)}
+
+	for _, r := range o.runners {
+		if r.job != nil {
+			c.Busy++
+		}
+
+		if r.job != nil || !r.readyAt.After(o.now) {
+			c.Registered++
+		}
+	}
+
+	return c, nil
+}
+
+// WorkflowJobs returns the queued jobs, and the jobs running on the runners as the in-progress ones.
+// Like GitHub reports, a queued job has the time it was queued as its started_at.
+func (o *simObserver) WorkflowJobs(ctx context.Context, m v1alpha1.MetricSpec) (*autoscaling.WorkflowJobCounts, error) {
+	c := &autoscaling.WorkflowJobCounts{}
+
+	newJob := func(j *simJob, status string, startedAt time.Time) autoscaling.WorkflowJob {
+		return autoscaling.WorkflowJob{
+			Repository: j.Repository,
+			Job: &gogithub.WorkflowJob{
+				ID:        gogithub.Int64(j.ID),
+				Status:    gogithub.String(status),
+				Labels:    j.Labels,
+				StartedAt: &gogithub.Timestamp{Time: startedAt},
+			},
+		}
+	}
+
+	for _, j := range o.queue {
+		c.Queued++
+		c.Jobs = append(c.Jobs, newJob(j, "queued", j.CreatedAt))
+	}
+
+	for _, r := range o.runners {
+		if r.job != nil {
+			c.InProgress++
+			c.Jobs = append(c.Jobs, newJob(r.job, "in_progress", r.job.start))
+		}
+	}
+
+	return c, nil
+}
+
+// matchesLabels returns true if the job can run on the runner pool with the custom labels.
+// This mirrors how the controller filters jobs for TotalNumberOfQueuedAndInProgressWorkflowRuns.
+func matchesLabels(jobLabels, runnerLabels []string) bool {
+	labels := make(map[string]struct{}, len(jobLabels))
+	for _, l := range jobLabels {
+		labels[l] = struct{}{}
+	}
+
+	if _, ok := labels["self-hosted"]; !ok {
+		return false
+	}
+
+	for _, l := range runnerLabels {
+		if _, ok := labels[l]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
@@ -0,0 +1,167 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/autoscaling"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestRun(t *testing.T) {
+	from := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	job := func(id int64, createdAfter, duration time.Duration, labels ...string) Job {
+		created := from.Add(createdAfter)
+		return Job{
+			ID:          id,
+			Labels:      append([]string{"self-hosted"}, labels...),
+			CreatedAt:   created,
+			StartedAt:   created.Add(30 * time.Second),
+			CompletedAt: created.Add(30*time.Second + duration),
+		}
+	}
+
+	// A burst of 4 jobs that takes 10 minutes each, and a job for another runner pool.
+	jobs := []Job{
+		job(1, 10*time.Minute, 10*time.Minute, "linux"),
+		job(2, 10*time.Minute, 10*time.Minute, "linux"),
+		job(3, 10*time.Minute, 10*time.Minute, "linux"),
+		job(4, 10*time.Minute, 10*time.Minute, "linux"),
+		job(5, 10*time.Minute, 10*time.Minute, "gpu"),
+		{ID: 6, Labels: []string{"ubuntu-latest"}, CreatedAt: from.Add(time.Minute)},
+	}
+
+	testcases := []struct {
+		name        string
+		spec        v1alpha1.HorizontalRunnerAutoscalerSpec
+		wantDelayed int
+		wantMax     int
+		wantMin     int
+	}{
+		{
+			name: "static",
+			spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas: intPtr(1),
+				MaxReplicas: intPtr(1),
+			},
+			// The first job runs immediately, and the rest wait for 10, 21, and 32 minutes respectively
+			// as each runner takes 1 minute to restart.
+			wantDelayed: 3,
+			wantMin:     1,
+			wantMax:     1,
+		},
+		{
+			name: "TotalNumberOfQueuedAndInProgressWorkflowRuns",
+			spec: v1alpha1.HorizontalRunnerAutoscalerSpec{
+				MinReplicas: intPtr(0),
+				MaxReplicas: intPtr(10),
+				Metrics: []v1alpha1.MetricSpec{
+					{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+				},
+			},
+			// Runners are added on the next sync period and they take a minute to start.
+			wantDelayed: 0,
+			wantMin:     0,
+			wantMax:     4,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Run(Config{HorizontalRunnerAutoscaler: tc.spec, RunnerLabels: []string{"linux"}}, jobs, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Jobs != 4 {
+				t.Errorf("unexpected number of jobs: want 4, got %d", report.Jobs)
+			}
+
+			if report.JobsUnfinished != 0 {
+				t.Errorf("unexpected number of unfinished jobs: want 0, got %d", report.JobsUnfinished)
+			}
+
+			if report.JobsDelayed != tc.wantDelayed {
+				t.Errorf("unexpected number of delayed jobs: want %d, got %d", tc.wantDelayed, report.JobsDelayed)
+			}
+
+			if report.MinReplicas != tc.wantMin || report.MaxReplicas != tc.wantMax {
+				t.Errorf("unexpected replicas: want min=%d max=%d, got min=%d max=%d", tc.wantMin, tc.wantMax, report.MinReplicas, report.MaxReplicas)
+			}
+
+			if report.BusyRunnerMinutes != 40 {
+				t.Errorf("unexpected busy runner minutes: want 40, got %v", report.BusyRunnerMinutes)
+			}
+
+			if report.ActualQueueWait.Max != 30*time.Second {
+				t.Errorf("unexpected actual queue wait: %v", report.ActualQueueWait)
+			}
+		})
+	}
+}
+
+func TestSuggestReplicasByCombinedMetrics(t *testing.T) {
+	metrics := []v1alpha1.MetricSpec{
+		{Type: v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns},
+		{Type: v1alpha1.AutoscalingMetricTypePercentageRunnersBusy},
+	}
+
+	testcases := []struct {
+		policy string
+		want   int
+	}{
+		{policy: v1alpha1.MetricsCombinationPolicyMax, want: 13},
+		{policy: v1alpha1.MetricsCombinationPolicyMin, want: 11},
+		{policy: v1alpha1.MetricsCombinationPolicySum, want: 24},
+		{policy: v1alpha1.MetricsCombinationPolicyAverage, want: 12},
+	}
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// 10 runners, 8 of which are busy, and 3 queued jobs
+	observer := &simObserver{now: now}
+
+	for i := 0; i < 10; i++ {
+		r := &simRunner{readyAt: now.Add(-time.Minute)}
+		if i < 8 {
+			r.job = &simJob{Job: Job{ID: int64(i)}, started: true, start: now.Add(-time.Minute)}
+			r.busyUntil = now.Add(time.Minute)
+		}
+		observer.runners = append(observer.runners, r)
+	}
+
+	for i := 0; i < 3; i++ {
+		observer.queue = append(observer.queue, &simJob{Job: Job{ID: int64(10 + i), CreatedAt: now.Add(-time.Minute)}})
+	}
+
+	for i, tc := range testcases {
+		spec := v1alpha1.HorizontalRunnerAutoscalerSpec{Metrics: metrics, MetricsCombinationPolicy: tc.policy}
+		hra := v1alpha1.HorizontalRunnerAutoscaler{Spec: spec}
+
+		got, _, err := autoscaling.Suggest(spec, func(m v1alpha1.MetricSpec) (*int, error) {
+			return suggestReplicasByMetric(autoscaling.ScaleAlgorithms(), hra, m, nil, 10, observer, now)
+		})
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if got == nil || *got != tc.want {
+			t.Errorf("[%d] unexpected desired replicas: want %d, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestSuggestReplicasByMetric_UnregisteredMetricType(t *testing.T) {
+	m := v1alpha1.MetricSpec{Type: "Unregistered"}
+
+	_, err := suggestReplicasByMetric(autoscaling.ScaleAlgorithms(), v1alpha1.HorizontalRunnerAutoscaler{}, m, nil, 1, &simObserver{}, time.Now())
+	if err == nil {
+		t.Fatal("expected an error for the metric type without a registered scale algorithm")
+	}
+}
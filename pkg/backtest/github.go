@@ -0,0 +1,123 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v39/github"
+)
+
+// workflowJob is the subset of the workflow job API response.
+// We don't use gogithub.WorkflowJob because it lacks created_at, which is the time the job was queued.
+type workflowJob struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	Labels      []string   `json:"labels"`
+	CreatedAt   *time.Time `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type workflowJobs struct {
+	TotalCount int           `json:"total_count"`
+	Jobs       []workflowJob `json:"jobs"`
+}
+
+// FetchJobs fetches the completed workflow jobs of the repositories, whose workflow runs were created since the time.
+// Each repository must be in the OWNER/REPO format.
+//
+// Note that this makes one API call per 100 workflow runs and another per workflow run,
+// which can consume a large part of your API rate limit for busy repositories.
+func FetchJobs(ctx context.Context, c *github.Client, repositories []string, since time.Time) ([]Job, error) {
+	var jobs []Job
+
+	for _, repository := range repositories {
+		ownerAndRepo := strings.Split(repository, "/")
+		if len(ownerAndRepo) != 2 {
+			return nil, fmt.Errorf("invalid repository %q: it must be in the OWNER/REPO format", repository)
+		}
+
+		owner, repo := ownerAndRepo[0], ownerAndRepo[1]
+
+		opts := &gogithub.ListWorkflowRunsOptions{
+			Created:     ">=" + since.UTC().Format(time.RFC3339),
+			ListOptions: gogithub.ListOptions{PerPage: 100},
+		}
+
+		for {
+			runs, resp, err := c.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, opts)
+			if err != nil {
+				return nil, fmt.Errorf("listing workflow runs of %s: %w", repository, err)
+			}
+
+			for _, run := range runs.WorkflowRuns {
+				runJobs, err := fetchWorkflowJobs(ctx, c, owner, repo, run.GetID())
+				if err != nil {
+					return nil, fmt.Errorf("listing workflow jobs of %s run %d: %w", repository, run.GetID(), err)
+				}
+
+				for _, j := range runJobs {
+					if j.Status != "completed" || j.Conclusion == "skipped" || j.StartedAt == nil || j.CompletedAt == nil {
+						continue
+					}
+
+					createdAt := run.GetCreatedAt().Time
+					if j.CreatedAt != nil {
+						createdAt = *j.CreatedAt
+					}
+
+					jobs = append(jobs, Job{
+						ID:          j.ID,
+						Repository:  repository,
+						Labels:      j.Labels,
+						CreatedAt:   createdAt,
+						StartedAt:   *j.StartedAt,
+						CompletedAt: *j.CompletedAt,
+					})
+				}
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+
+			opts.Page = resp.NextPage
+		}
+	}
+
+	return jobs, nil
+}
+
+func fetchWorkflowJobs(ctx context.Context, c *github.Client, owner, repo string, runID int64) ([]workflowJob, error) {
+	var jobs []workflowJob
+
+	for page := 1; ; {
+		u := fmt.Sprintf("repos/%s/%s/actions/runs/%d/jobs?filter=all&per_page=100&page=%d", owner, repo, runID, page)
+
+		req, err := c.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var res workflowJobs
+
+		resp, err := c.Do(ctx, req, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, res.Jobs...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		page = resp.NextPage
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteText writes the human-readable summary of the report.
+func (r *Report) WriteText(w io.Writer) error {
+	var idleRatio float64
+	if r.RunnerMinutes > 0 {
+		idleRatio = r.IdleRunnerMinutes / r.RunnerMinutes * 100
+	}
+
+	_, err := fmt.Fprintf(w, `Period:              %s - %s
+Jobs:                %d
+Jobs delayed (>%s): %d
+Jobs unfinished:     %d
+Queue wait:          p50=%s p95=%s max=%s
+Actual queue wait:   p50=%s p95=%s max=%s
+Replicas:            min=%d max=%d
+Runner minutes:      %.0f (busy %.0f, idle %.0f = %.1f%%)
+`,
+		r.From.Format(time.RFC3339), r.To.Format(time.RFC3339),
+		r.Jobs,
+		r.DelayThreshold, r.JobsDelayed,
+		r.JobsUnfinished,
+		r.QueueWait.P50, r.QueueWait.P95, r.QueueWait.Max,
+		r.ActualQueueWait.P50, r.ActualQueueWait.P95, r.ActualQueueWait.Max,
+		r.MinReplicas, r.MaxReplicas,
+		r.RunnerMinutes, r.BusyRunnerMinutes, r.IdleRunnerMinutes, idleRatio,
+	)
+
+	return err
+}
+
+// WriteTimelineCSV writes the replicas over time as CSV.
+func (r *Report) WriteTimelineCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"time", "desiredReplicas", "runners", "busy", "queued"}); err != nil {
+		return err
+	}
+
+	for _, s := range r.Timeline {
+		record := []string{
+			s.Time.UTC().Format(time.RFC3339),
+			strconv.Itoa(s.DesiredReplicas),
+			strconv.Itoa(s.Runners),
+			strconv.Itoa(s.Busy),
+			strconv.Itoa(s.Queued),
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
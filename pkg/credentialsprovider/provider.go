@@ -0,0 +1,101 @@
+// Package credentialsprovider implements the plugin mechanism for reading the GitHub API credentials of the controller
+// from an external secret store, like AWS Secrets Manager or Vault, instead of the environment variables and the flags.
+//
+// A credentials provider is either an executable or an HTTP service operated outside of the controller.
+// The controller reads the credentials on startup and then periodically, so that the rotated credentials
+// are picked up without restarting the controller.
+//
+// Executable providers must write the JSON-encoded github.AuthConfig to stdout.
+// HTTP providers receive a GET request and must respond with the JSON-encoded github.AuthConfig and the status code 200.
+package credentialsprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/internal/plugin"
+)
+
+const DefaultTimeout = 30 * time.Second
+
+// Provider reads the GitHub API credentials from the external secret store.
+type Provider interface {
+	Credentials(ctx context.Context) (*github.AuthConfig, error)
+}
+
+// Exec is a Provider that runs an executable, like a script calling `aws secretsmanager get-secret-value` or `vault kv get`.
+type Exec struct {
+	Command []string
+	Timeout time.Duration
+}
+
+func (e *Exec) Credentials(ctx context.Context) (*github.AuthConfig, error) {
+	out, err := plugin.Exec(ctx, e.Command, nil, plugin.Timeout(e.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("running credentials provider %q: %w", strings.Join(e.Command, " "), err)
+	}
+
+	return decodeCredentials(out)
+}
+
+// HTTP is a Provider that sends a GET request to an HTTP service.
+type HTTP struct {
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+func (h *HTTP) Credentials(ctx context.Context) (*github.AuthConfig, error) {
+	res, err := plugin.Do(ctx, h.Client, http.MethodGet, h.URL, nil, plugin.Timeout(h.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("calling credentials provider %s: %w", h.URL, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		// The body isn't included as it may contain the credentials.
+		return nil, fmt.Errorf("credentials provider %s responded with status %d", h.URL, res.StatusCode)
+	}
+
+	return decodeCredentials(res.Body)
+}
+
+// decodeCredentials decodes the credentials, which must contain a token, basic auth credentials,
+// or the id, installation id and private key of a GitHub App.
+// The errors never contain the credentials.
+func decodeCredentials(data []byte) (*github.AuthConfig, error) {
+	var a github.AuthConfig
+
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("decoding credentials of %d bytes: %w", len(data), err)
+	}
+
+	switch {
+	case a.Token != "":
+	case a.BasicauthUsername != "" && a.BasicauthPassword != "":
+	case a.AppID != 0 && a.AppInstallationID != 0 && a.AppPrivateKey != "":
+	default:
+		return nil, fmt.Errorf("credentials must contain either token, basicauthUsername and basicauthPassword, or appID, appInstallationID and appPrivateKey")
+	}
+
+	return &a, nil
+}
+
+// Parse parses a provider definition in the COMMAND or URL format, like
+// `/usr/local/bin/github-credentials --secret-id arc/github` or `http://credentials.default.svc/github`.
+func Parse(def string, timeout time.Duration) (Provider, error) {
+	target := strings.TrimSpace(def)
+	if target == "" {
+		return nil, fmt.Errorf("invalid credentials provider %q: must be a COMMAND or a URL", def)
+	}
+
+	if plugin.IsURL(target) {
+		return &HTTP{URL: target, Timeout: timeout}, nil
+	}
+
+	return &Exec{Command: strings.Fields(target), Timeout: timeout}, nil
+}
@@ -0,0 +1,81 @@
+package credentialsprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse("http://localhost:8080/github", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*HTTP); !ok {
+		t.Errorf("unexpected provider type: %T", p)
+	}
+
+	p, err = Parse("/usr/local/bin/github-credentials --secret-id arc/github", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, ok := p.(*Exec); !ok || len(e.Command) != 3 {
+		t.Errorf("unexpected provider: %#v", p)
+	}
+
+	if _, err := Parse(" ", 0); err == nil {
+		t.Errorf("expected error for an empty definition")
+	}
+}
+
+func TestExec(t *testing.T) {
+	e := &Exec{Command: []string{"sh", "-c", `echo '{"appID": 1, "appInstallationID": 2, "appPrivateKey": "key", "extra": "ignored"}'`}}
+
+	a, err := e.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a.AppID != 1 || a.AppInstallationID != 2 || a.AppPrivateKey != "key" {
+		t.Errorf("unexpected credentials: %+v", *a)
+	}
+
+	e = &Exec{Command: []string{"sh", "-c", `echo '{"appID": 1, "appPrivateKey": "secret"}'`}}
+
+	if _, err := e.Credentials(context.Background()); err == nil {
+		t.Errorf("expected error for the incomplete credentials")
+	} else if strings.Contains(err.Error(), "secret") {
+		t.Errorf("the error contains the credentials: %v", err)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{"token": "ghp_test"}`))
+	}))
+	defer server.Close()
+
+	h := &HTTP{URL: server.URL + "/github"}
+
+	a, err := h.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a.Token != "ghp_test" {
+		t.Errorf("unexpected token: %s", a.Token)
+	}
+
+	h.URL = server.URL + "/missing"
+
+	if _, err := h.Credentials(context.Background()); err == nil {
+		t.Errorf("expected error for non-200 status")
+	}
+}
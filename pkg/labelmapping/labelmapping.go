@@ -0,0 +1,238 @@
+// Package labelmapping maps GitHub runner labels to Kubernetes scheduling constraints.
+//
+// The mapping is configured once for the whole controller, so that a runner pool advertising e.g. the `gpu` label
+// lands on GPU nodes without repeating the node selector, the tolerations and the resources in every runner spec.
+// The constraints are applied to the runner pods on creation. Anything set explicitly in the runner spec takes precedence.
+package labelmapping
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const runnerContainerName = "runner"
+
+// Config is the content of the label mapping file.
+type Config struct {
+	// Labels maps GitHub runner labels to the scheduling constraints of the runner pods.
+	// Labels are compared case-insensitively, like GitHub does on routing jobs to runners.
+	Labels map[string]Mapping `json:"labels"`
+
+	// Sizes maps the size labels, like `4-core` and `16-core`, to the resource tiers of the runner pods.
+	// A RunnerDeployment that advertises multiple sizes registers each runner with one of the size labels,
+	// so that the runner pod gets the tier of the size. Sizes are otherwise applied the same as Labels.
+	Sizes map[string]Mapping `json:"sizes,omitempty"`
+
+	// GPUProfiles is the scheduling constraints of the runner pods that are allocated GPUs, keyed by the profile names.
+	// A runner spec selects the profile by gpu.profile, or by the resource name of the GPUs, like `nvidia.com/gpu`.
+	GPUProfiles map[string]GPUProfile `json:"gpuProfiles,omitempty"`
+}
+
+// GPUProfile is the scheduling constraints applied to the pods of runners allocated the GPUs of the profile.
+type GPUProfile struct {
+	NodeSelector     map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations      []corev1.Toleration `json:"tolerations,omitempty"`
+	RuntimeClassName string              `json:"runtimeClassName,omitempty"`
+}
+
+// Mapping is the scheduling constraints applied to the pods of runners that have the label.
+type Mapping struct {
+	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
+	// Resources is applied to the runner container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Load reads the label mapping file at path.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading label mapping file: %w", err)
+	}
+
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing label mapping file %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Parse parses the YAML or JSON representation of Config.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]string{}
+
+	for _, labels := range []map[string]Mapping{c.Labels, c.Sizes} {
+		for l := range labels {
+			if strings.TrimSpace(l) == "" {
+				return nil, fmt.Errorf("labels and sizes must not contain an empty label")
+			}
+
+			key := strings.ToLower(l)
+			if other, ok := seen[key]; ok {
+				return nil, fmt.Errorf("labels %q and %q are the same label", other, l)
+			}
+
+			seen[key] = l
+		}
+	}
+
+	return &c, nil
+}
+
+// Match returns the mappings of the runner labels, in the order of the runner labels.
+func (c *Config) Match(runnerLabels []string) []Mapping {
+	if c == nil {
+		return nil
+	}
+
+	var res []Mapping
+
+	for _, rl := range runnerLabels {
+		if m, ok := lookup(c.Labels, rl); ok {
+			res = append(res, m)
+		} else if m, ok := lookup(c.Sizes, rl); ok {
+			res = append(res, m)
+		}
+	}
+
+	return res
+}
+
+// GPUProfile returns the GPU profile of the name.
+func (c *Config) GPUProfile(name string) (GPUProfile, bool) {
+	if c == nil {
+		return GPUProfile{}, false
+	}
+
+	p, ok := c.GPUProfiles[name]
+
+	return p, ok
+}
+
+// ApplyGPUProfile applies the GPU profile to the pod.
+// Node selectors that are already set in the pod are kept as is, the tolerations are added unless the pod already tolerates them,
+// and the runtime class is set unless the pod already has one.
+func (p GPUProfile) ApplyGPUProfile(pod *corev1.Pod) {
+	for k, v := range p.NodeSelector {
+		if _, ok := pod.Spec.NodeSelector[k]; ok {
+			continue
+		}
+
+		if pod.Spec.NodeSelector == nil {
+			pod.Spec.NodeSelector = map[string]string{}
+		}
+
+		pod.Spec.NodeSelector[k] = v
+	}
+
+	for _, t := range p.Tolerations {
+		if !hasToleration(pod.Spec.Tolerations, t) {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, t)
+		}
+	}
+
+	if p.RuntimeClassName != "" && pod.Spec.RuntimeClassName == nil {
+		name := p.RuntimeClassName
+		pod.Spec.RuntimeClassName = &name
+	}
+}
+
+func lookup(mappings map[string]Mapping, label string) (Mapping, bool) {
+	for l, m := range mappings {
+		if strings.EqualFold(l, label) {
+			return m, true
+		}
+	}
+
+	return Mapping{}, false
+}
+
+// Apply applies the mappings of the runner labels to the pod.
+// Node selectors, tolerations and resources that are already set in the pod are kept as is.
+// When two or more mappings set the same node selector key or resource, the one for the earlier runner label wins.
+// The node selector and the tolerations of the pod are copied before being modified,
+// as they're usually shared with the runner spec the pod is generated from.
+func (c *Config) Apply(runnerLabels []string, pod *corev1.Pod) {
+	var nodeSelectorCopied, tolerationsCopied bool
+
+	for _, m := range c.Match(runnerLabels) {
+		for k, v := range m.NodeSelector {
+			if _, ok := pod.Spec.NodeSelector[k]; ok {
+				continue
+			}
+
+			if !nodeSelectorCopied {
+				nodeSelector := make(map[string]string, len(pod.Spec.NodeSelector)+len(m.NodeSelector))
+				for k, v := range pod.Spec.NodeSelector {
+					nodeSelector[k] = v
+				}
+
+				pod.Spec.NodeSelector = nodeSelector
+				nodeSelectorCopied = true
+			}
+
+			pod.Spec.NodeSelector[k] = v
+		}
+
+		for _, t := range m.Tolerations {
+			if hasToleration(pod.Spec.Tolerations, t) {
+				continue
+			}
+
+			if !tolerationsCopied {
+				pod.Spec.Tolerations = append([]corev1.Toleration{}, pod.Spec.Tolerations...)
+				tolerationsCopied = true
+			}
+
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, t)
+		}
+
+		for i := range pod.Spec.Containers {
+			container := &pod.Spec.Containers[i]
+
+			if container.Name != runnerContainerName {
+				continue
+			}
+
+			container.Resources.Requests = mergeResources(container.Resources.Requests, m.Resources.Requests)
+			container.Resources.Limits = mergeResources(container.Resources.Limits, m.Resources.Limits)
+		}
+	}
+}
+
+func hasToleration(tolerations []corev1.Toleration, t corev1.Toleration) bool {
+	for _, existing := range tolerations {
+		if existing.MatchToleration(&t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mergeResources(dst, src corev1.ResourceList) corev1.ResourceList {
+	for name, q := range src {
+		if _, ok := dst[name]; ok {
+			continue
+		}
+
+		if dst == nil {
+			dst = corev1.ResourceList{}
+		}
+
+		dst[name] = q.DeepCopy()
+	}
+
+	return dst
+}
@@ -0,0 +1,181 @@
+package labelmapping
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const config = `labels:
+  gpu:
+    nodeSelector:
+      accelerator: nvidia
+      pool: gpu
+    tolerations:
+    - key: nvidia.com/gpu
+      operator: Exists
+      effect: NoSchedule
+    resources:
+      limits:
+        nvidia.com/gpu: "1"
+  HighMem:
+    nodeSelector:
+      pool: highmem
+    resources:
+      requests:
+        memory: 32Gi
+      limits:
+        memory: 64Gi
+sizes:
+  16-core:
+    resources:
+      requests:
+        cpu: "16"
+`
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: config,
+		},
+		{
+			name:    "unknown field",
+			data:    "labels:\n  gpu:\n    nodeSelectors: {}\n",
+			wantErr: `error unmarshaling JSON: while decoding JSON: json: unknown field "nodeSelectors"`,
+		},
+		{
+			name:    "duplicate label",
+			data:    "labels:\n  gpu: {}\n  GPU: {}\n",
+			wantErr: "are the same label",
+		},
+		{
+			name:    "empty label",
+			data:    "labels:\n  \"\": {}\n",
+			wantErr: "labels and sizes must not contain an empty label",
+		},
+		{
+			name:    "label and size",
+			data:    "labels:\n  large: {}\nsizes:\n  Large: {}\n",
+			wantErr: "are the same label",
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.data))
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("unexpected error: want %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, err := Parse([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPod := func() corev1.Pod {
+		return corev1.Pod{
+			Spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"pool": "custom"},
+				Containers: []corev1.Container{
+					{
+						Name: "runner",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+						},
+					},
+					{Name: "docker"},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name   string
+		labels []string
+		want   func(*corev1.Pod)
+	}{
+		{
+			name:   "no matching labels",
+			labels: []string{"linux"},
+			want:   func(*corev1.Pod) {},
+		},
+		{
+			name:   "gpu",
+			labels: []string{"linux", "gpu"},
+			want: func(p *corev1.Pod) {
+				p.Spec.NodeSelector["accelerator"] = "nvidia"
+				p.Spec.Tolerations = []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+				p.Spec.Containers[0].Resources.Limits["nvidia.com/gpu"] = resource.MustParse("1")
+			},
+		},
+		{
+			name:   "case-insensitive and explicit resources win",
+			labels: []string{"highmem"},
+			want: func(p *corev1.Pod) {
+				p.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Gi")}
+			},
+		},
+		{
+			name:   "size",
+			labels: []string{"linux", "16-core"},
+			want: func(p *corev1.Pod) {
+				p.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("16")}
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got := newPod()
+			c.Apply(tc.labels, &got)
+
+			want := newPod()
+			tc.want(&want)
+
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("unexpected pod: (-want +got)\n%s", d)
+			}
+		})
+	}
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	c, err := Parse([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod := corev1.Pod{}
+	c.Apply([]string{"highmem", "gpu"}, &pod)
+
+	if got := pod.Spec.NodeSelector["pool"]; got != "highmem" {
+		t.Errorf("unexpected pool node selector: want highmem, got %s", got)
+	}
+
+	var nilConfig *Config
+	nilConfig.Apply([]string{"gpu"}, &pod)
+}
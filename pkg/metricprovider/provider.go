@@ -0,0 +1,137 @@
+// Package metricprovider implements the plugin mechanism for the `External` autoscaling metric.
+//
+// An external metric provider is either an executable or an HTTP service operated outside of the controller.
+// For each HorizontalRunnerAutoscaler reconciliation the controller sends a Request to the provider
+// and receives a Response that contains the suggested number of replicas.
+//
+// Executable providers receive the JSON-encoded Request on stdin and must write the JSON-encoded Response to stdout.
+// HTTP providers receive the JSON-encoded Request as the body of a POST request and must respond
+// with the JSON-encoded Response and the status code 200.
+package metricprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/internal/plugin"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// Request is sent to the metric provider on each HorizontalRunnerAutoscaler reconciliation.
+type Request struct {
+	HorizontalRunnerAutoscaler ObjectRef         `json:"horizontalRunnerAutoscaler"`
+	ScaleTarget                ScaleTarget       `json:"scaleTarget"`
+	MinReplicas                int               `json:"minReplicas"`
+	MaxReplicas                int               `json:"maxReplicas"`
+	Params                     map[string]string `json:"params,omitempty"`
+}
+
+type ObjectRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type ScaleTarget struct {
+	Kind         string   `json:"kind"`
+	Name         string   `json:"name"`
+	Enterprise   string   `json:"enterprise,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Repository   string   `json:"repository,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	// Replicas is the current number of desired replicas of the scale target.
+	// Nil means that it is not yet set.
+	Replicas *int `json:"replicas,omitempty"`
+}
+
+// Response is returned by the metric provider.
+type Response struct {
+	// DesiredReplicas is the number of replicas suggested by the provider.
+	// The controller clamps it between minReplicas and maxReplicas as it does for built-in metrics.
+	DesiredReplicas int `json:"desiredReplicas"`
+}
+
+// Provider computes the suggested number of replicas for an HRA.
+type Provider interface {
+	SuggestReplicas(ctx context.Context, req Request) (*Response, error)
+}
+
+// Exec is a Provider that runs an executable for each request.
+type Exec struct {
+	Command []string
+	Timeout time.Duration
+}
+
+func (e *Exec) SuggestReplicas(ctx context.Context, req Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := plugin.Exec(ctx, e.Command, body, plugin.Timeout(e.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("running metric provider %q: %w", strings.Join(e.Command, " "), err)
+	}
+
+	return decodeResponse(out)
+}
+
+// HTTP is a Provider that sends a POST request to an HTTP service for each request.
+type HTTP struct {
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+func (h *HTTP) SuggestReplicas(ctx context.Context, req Request) (*Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := plugin.Do(ctx, h.Client, http.MethodPost, h.URL, body, plugin.Timeout(h.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("calling metric provider %s: %w", h.URL, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metric provider %s responded with status %d: %s", h.URL, res.StatusCode, string(res.Body))
+	}
+
+	return decodeResponse(res.Body)
+}
+
+func decodeResponse(data []byte) (*Response, error) {
+	var res Response
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("decoding metric provider response %q: %w", string(data), err)
+	}
+
+	if res.DesiredReplicas < 0 {
+		return nil, fmt.Errorf("metric provider suggested negative desired replicas of %d", res.DesiredReplicas)
+	}
+
+	return &res, nil
+}
+
+// Parse parses a provider definition in the NAME=COMMAND or NAME=URL format, like
+// `queue=/usr/local/bin/queue-metric --verbose` or `queue=http://queue-metric.default.svc/suggest`.
+func Parse(def string, timeout time.Duration) (string, Provider, error) {
+	kv := strings.SplitN(def, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || strings.TrimSpace(kv[1]) == "" {
+		return "", nil, fmt.Errorf("invalid metric provider %q: must be in the NAME=COMMAND or NAME=URL format", def)
+	}
+
+	name, target := kv[0], strings.TrimSpace(kv[1])
+
+	if plugin.IsURL(target) {
+		return name, &HTTP{URL: target, Timeout: timeout}, nil
+	}
+
+	return name, &Exec{Command: strings.Fields(target), Timeout: timeout}, nil
+}
@@ -0,0 +1,71 @@
+package metricprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	_, p, err := Parse("queue=http://localhost:8080/suggest", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*HTTP); !ok {
+		t.Errorf("unexpected provider type: %T", p)
+	}
+
+	name, p, err := Parse("queue=/usr/local/bin/queue-metric --verbose", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "queue" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	if e, ok := p.(*Exec); !ok || len(e.Command) != 2 {
+		t.Errorf("unexpected provider: %#v", p)
+	}
+
+	if _, _, err := Parse("queue", 0); err == nil {
+		t.Errorf("expected error for a definition without a command")
+	}
+}
+
+func TestExec(t *testing.T) {
+	e := &Exec{Command: []string{"sh", "-c", `cat > /dev/null; echo '{"desiredReplicas": 3}'`}}
+
+	res, err := e.SuggestReplicas(context.Background(), Request{MinReplicas: 1, MaxReplicas: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.DesiredReplicas != 3 {
+		t.Errorf("unexpected desired replicas: want 3, got %d", res.DesiredReplicas)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(Response{DesiredReplicas: req.MaxReplicas - 1})
+	}))
+	defer server.Close()
+
+	h := &HTTP{URL: server.URL}
+
+	res, err := h.SuggestReplicas(context.Background(), Request{MinReplicas: 1, MaxReplicas: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.DesiredReplicas != 4 {
+		t.Errorf("unexpected desired replicas: want 4, got %d", res.DesiredReplicas)
+	}
+}
@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"strings"
+)
+
+// unsupportedFlags is the list of upstream controller flags that have no counterpart in this fork.
+// Each of them is dropped from the migrated flags with an error.
+var unsupportedFlags = map[string]string{
+	"runner-status-update-hook": "runner status update hooks are not implemented by this controller",
+	"log-format":                "this controller always logs in the zap production format",
+}
+
+// deprecatedFlags is the list of flags that are still accepted but have no or reduced effect.
+var deprecatedFlags = map[string]string{
+	"github-api-cache-duration": "the GitHub API cache duration is derived from --sync-period. Consider removing it",
+}
+
+// controllerFlags is the set of the flags accepted by this controller, mapped to whether each of them is a boolean flag
+// that doesn't take a separate value argument. It must be kept in sync with the flags defined in main.go.
+var controllerFlags = map[string]bool{
+	"admin-api-token":                              false,
+	"allow-runner-network-exposure":                true,
+	"canary-interval":                              false,
+	"canary-ref":                                   false,
+	"canary-repository":                            false,
+	"canary-timeout":                               false,
+	"canary-workflow":                              false,
+	"common-runner-labels":                         false,
+	"default-scale-down-delay":                     false,
+	"disable-job-level-autoscaling":                true,
+	"disable-run-level-autoscaling":                true,
+	"docker-image":                                 false,
+	"docker-registry-mirror":                       false,
+	"drain-mode":                                   true,
+	"dry-run":                                      true,
+	"enable-federation":                            true,
+	"enable-leader-election":                       true,
+	"external-metrics":                             true,
+	"forge":                                        false,
+	"github-api-cache-duration":                    false,
+	"github-api-circuit-breaker-cooldown":          false,
+	"github-api-circuit-breaker-threshold":         false,
+	"github-api-max-concurrent-requests":           false,
+	"github-api-max-pages-per-reconcile":           false,
+	"github-api-rate-limit-threshold":              false,
+	"github-api-response-cache-ttl":                false,
+	"github-api-retries":                           false,
+	"github-api-retry-base-delay":                  false,
+	"github-app-id":                                false,
+	"github-app-installation-id":                   false,
+	"github-app-private-key":                       false,
+	"github-basicauth-password":                    false,
+	"github-basicauth-username":                    false,
+	"github-credentials-provider":                  false,
+	"github-credentials-reload-interval":           false,
+	"github-record-fixtures":                       false,
+	"github-status-component":                      false,
+	"github-status-polling":                        true,
+	"github-status-polling-interval":               false,
+	"github-status-url":                            false,
+	"github-token":                                 false,
+	"github-upload-url":                            false,
+	"github-url":                                   false,
+	"interrupted-job-max-run-attempts":             false,
+	"interrupted-job-rerun-interval":               false,
+	"leader-election-id":                           false,
+	"log-level":                                    false,
+	"metric-provider":                              false,
+	"metric-provider-timeout":                      false,
+	"metrics-addr":                                 false,
+	"prometheus-monitor-controller-scheme":         false,
+	"prometheus-monitor-controller-service":        false,
+	"prometheus-monitor-interval":                  false,
+	"prometheus-monitor-labels":                    false,
+	"prometheus-monitor-port-name":                 false,
+	"prometheus-monitors":                          true,
+	"reconcile-degraded-requeue-interval":          false,
+	"reconcile-error-threshold":                    false,
+	"registration-token-refresh-interval":          false,
+	"repository-fetch-concurrency":                 false,
+	"runner-default-node-selector":                 false,
+	"runner-default-tolerations":                   false,
+	"runner-deployment-preview-token":              false,
+	"runner-ephemeral-storage-monitoring":          true,
+	"runner-ephemeral-storage-monitoring-interval": false,
+	"runner-ephemeral-storage-recycle-threshold":   false,
+	"runner-github-url":                            false,
+	"runner-image":                                 false,
+	"runner-image-pull-secret":                     false,
+	"runner-image-pull-secret-source":              false,
+	"runner-inventory-token":                       false,
+	"runner-fleet-token":                           false,
+	"runner-label-aliases":                         false,
+	"runner-label-mappings":                        false,
+	"runner-online-deadline":                       false,
+	"runner-pod-mutators":                          false,
+	"runner-provisioner":                           false,
+	"runner-provisioner-timeout":                   false,
+	"runner-ready-requires-online":                 true,
+	"runner-registration-gc":                       true,
+	"runner-registration-gc-interval":              false,
+	"runner-registration-gc-offline-threshold":     false,
+	"runner-rightsizing":                           true,
+	"runner-rightsizing-interval":                  false,
+	"runner-rightsizing-prometheus-url":            false,
+	"runner-status-sync":                           true,
+	"runner-status-sync-interval":                  false,
+	"runner-unregistration-timeout":                false,
+	"runner-utilization-report-window":             false,
+	"runner-utilization-sampling":                  true,
+	"runner-utilization-sampling-interval":         false,
+	"runner-version-drift-detection":               true,
+	"runner-version-drift-interval":                false,
+	"runner-version-recycle-threshold":             false,
+	"scale-down-grace-period":                      false,
+	"scale-from-zero-poll-interval":                false,
+	"scaling-decision-log":                         false,
+	"shard-count":                                  false,
+	"shard-index":                                  false,
+	"shard-selector":                               false,
+	"sync-period":                                  false,
+	"watch-namespace":                              false,
+	"workflow-job-cache-ttl":                       false,
+}
+
+// Flags migrates the command-line arguments given to the upstream controller into
+// the arguments for this controller.
+// Positional arguments are passed through unchanged. Flags unknown to this controller are dropped with errors,
+// as the controller refuses to start with any of them.
+func Flags(args []string) ([]string, []Issue) {
+	var (
+		migrated []string
+		issues   []Issue
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			migrated = append(migrated, arg)
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		hasValue := false
+
+		if j := strings.Index(name, "="); j >= 0 {
+			name = name[:j]
+			hasValue = true
+		}
+
+		// Consume the flag value given as a separate argument, like `--sync-period 1m`.
+		current := []string{arg}
+		if !hasValue && !controllerFlags[name] && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			current = append(current, args[i])
+		}
+
+		id := "--" + name
+
+		if reason, ok := unsupportedFlags[name]; ok {
+			issues = append(issues, Issue{Severity: SeverityError, Object: id, Message: "dropped: " + reason})
+			continue
+		}
+
+		if _, ok := controllerFlags[name]; !ok {
+			issues = append(issues, Issue{Severity: SeverityError, Object: id, Message: "dropped: unknown to this controller"})
+			continue
+		}
+
+		if reason, ok := deprecatedFlags[name]; ok {
+			issues = append(issues, Issue{Severity: SeverityWarning, Object: id, Message: "deprecated: " + reason})
+		}
+
+		migrated = append(migrated, current...)
+	}
+
+	return migrated, issues
+}
@@ -0,0 +1,246 @@
+// Package migrate converts manifests and controller flags written for the upstream
+// actions-runner-controller into the ones accepted by this fork.
+//
+// The conversion is intentionally conservative. Any field or flag that cannot be
+// carried over as-is is reported as an Issue instead of being silently dropped,
+// so that the operator can review it before applying the output.
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue is a single incompatibility or note found while migrating a manifest or a flag.
+type Issue struct {
+	Severity string
+	// Object identifies the manifest or flag the issue is about, like `RunnerDeployment default/example`
+	// or `--sync-period`.
+	Object  string
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Object, i.Message)
+}
+
+// Result is the outcome of a migration.
+type Result struct {
+	// Documents is the list of migrated YAML documents, in the same order as the input.
+	Documents [][]byte
+	Issues    []Issue
+}
+
+// HasErrors returns true when at least one issue prevents the result from being applied as-is.
+func (r *Result) HasErrors() bool {
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// YAML returns all the migrated documents joined into a single multi-document YAML stream.
+func (r *Result) YAML() []byte {
+	var buf bytes.Buffer
+
+	for i, d := range r.Documents {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(d)
+	}
+
+	return buf.Bytes()
+}
+
+func (r *Result) addIssue(severity, object, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Severity: severity, Object: object, Message: fmt.Sprintf(format, args...)})
+}
+
+type typeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// Manifests reads a multi-document YAML stream of upstream custom resources and migrates each of them.
+// Documents that are not actions-runner-controller custom resources are passed through unchanged.
+func Manifests(r io.Reader) (*Result, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifests: %w", err)
+	}
+
+	res := &Result{}
+
+	for _, doc := range splitDocuments(data) {
+		out, err := res.migrateDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Documents = append(res.Documents, out)
+	}
+
+	return res, nil
+}
+
+func splitDocuments(data []byte) [][]byte {
+	var docs [][]byte
+
+	for _, d := range strings.Split("\n"+string(data), "\n---") {
+		// Drop anything following the separator on the same line, like `--- # comment`.
+		if i := strings.Index(d, "\n"); i >= 0 {
+			d = d[i+1:]
+		} else {
+			d = ""
+		}
+
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+
+		docs = append(docs, []byte(d))
+	}
+
+	return docs
+}
+
+func (res *Result) migrateDocument(doc []byte) ([]byte, error) {
+	var tm typeMeta
+
+	if err := yaml.Unmarshal(doc, &tm); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	id := fmt.Sprintf("%s %s/%s", tm.Kind, tm.Metadata.Namespace, tm.Metadata.Name)
+
+	if tm.APIVersion != v1alpha1.GroupVersion.String() {
+		if strings.HasPrefix(tm.APIVersion, v1alpha1.GroupVersion.Group+"/") {
+			res.addIssue(SeverityError, id, "unsupported apiVersion %q: only %q can be migrated", tm.APIVersion, v1alpha1.GroupVersion.String())
+		}
+
+		return doc, nil
+	}
+
+	var obj interface{}
+
+	switch tm.Kind {
+	case "Runner":
+		obj = &v1alpha1.Runner{}
+	case "RunnerDeployment":
+		obj = &v1alpha1.RunnerDeployment{}
+	case "RunnerReplicaSet":
+		obj = &v1alpha1.RunnerReplicaSet{}
+	case "RunnerSet":
+		obj = &v1alpha1.RunnerSet{}
+	case "HorizontalRunnerAutoscaler":
+		obj = &v1alpha1.HorizontalRunnerAutoscaler{}
+	default:
+		res.addIssue(SeverityError, id, "unsupported kind %q", tm.Kind)
+
+		return doc, nil
+	}
+
+	if err := yaml.UnmarshalStrict(doc, obj); err != nil {
+		res.addIssue(SeverityWarning, id, "dropping fields unknown to this controller: %v", err)
+
+		if err := yaml.Unmarshal(doc, obj); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", id, err)
+		}
+	}
+
+	for _, i := range validationIssues(obj) {
+		i.Object = id
+		res.Issues = append(res.Issues, i)
+	}
+
+	var orig map[string]interface{}
+
+	if err := yaml.Unmarshal(doc, &orig); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", id, err)
+	}
+
+	out, err := marshalWithoutStatus(obj, orig)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling %s: %w", id, err)
+	}
+
+	return out, nil
+}
+
+// marshalWithoutStatus marshals the object into YAML, omitting the status, null fields
+// like the empty creationTimestamp, empty objects like unset resource requirements,
+// and zero values like `image: ""` of the fields without omitempty that are missing in orig, the object before migration.
+// The status is not migrated because it is owned by the controller, not by the operator.
+func marshalWithoutStatus(obj interface{}, orig map[string]interface{}) ([]byte, error) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	delete(m, "status")
+
+	pruneUnset(m, orig)
+
+	return yaml.Marshal(m)
+}
+
+// pruneUnset deletes the nulls, the empty objects and the zero values that are not in orig from m.
+// The zero values set in orig, like `replicas: 0`, are kept as they're meant by the operator.
+func pruneUnset(m, orig map[string]interface{}) {
+	for k, v := range m {
+		o, set := orig[k]
+
+		switch typed := v.(type) {
+		case nil:
+			delete(m, k)
+		case map[string]interface{}:
+			om, _ := o.(map[string]interface{})
+			pruneUnset(typed, om)
+			if len(typed) == 0 {
+				delete(m, k)
+			}
+		case []interface{}:
+			ol, _ := o.([]interface{})
+			for i, item := range typed {
+				if im, ok := item.(map[string]interface{}); ok {
+					var oim map[string]interface{}
+					if i < len(ol) {
+						oim, _ = ol[i].(map[string]interface{})
+					}
+					pruneUnset(im, oim)
+				}
+			}
+		default:
+			if !set && reflect.ValueOf(v).IsZero() {
+				delete(m, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManifests(t *testing.T) {
+	in := `apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: example
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      repository: example/app
+      ephemeral: false
+      upstreamOnlyField: true
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: example
+  namespace: default
+spec:
+  scaleTargetRef:
+    name: example
+  metrics:
+  - type: TotalNumberOfQueuedAndInProgressWorkflowRuns
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: passthrough
+`
+
+	res, err := Manifests(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res.Documents) != 3 {
+		t.Fatalf("unexpected number of documents: want 3, got %d", len(res.Documents))
+	}
+
+	wantRD := `apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: example
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      ephemeral: false
+      repository: example/app
+`
+	if d := cmp.Diff(wantRD, string(res.Documents[0])); d != "" {
+		t.Errorf("unexpected runnerdeployment: (-want +got)\n%s", d)
+	}
+
+	if got := string(res.Documents[2]); !strings.Contains(got, "name: passthrough") {
+		t.Errorf("unexpected passthrough document: %s", got)
+	}
+
+	var got []string
+	for _, i := range res.Issues {
+		got = append(got, i.Severity+" "+i.Object)
+	}
+
+	want := []string{
+		"warning RunnerDeployment default/example",
+		"error HorizontalRunnerAutoscaler default/example",
+		"error HorizontalRunnerAutoscaler default/example",
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected issues: (-want +got)\n%s", d)
+	}
+
+	if !res.HasErrors() {
+		t.Errorf("expected errors to be reported")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	got, issues := Flags([]string{
+		"--sync-period", "1m",
+		"--enable-leader-election",
+		"--runner-status-update-hook",
+		"--log-format=json",
+		"--github-api-cache-duration=30s",
+		"--upstream-only-flag", "value",
+		"--dry-run",
+	})
+
+	want := []string{"--sync-period", "1m", "--enable-leader-election", "--github-api-cache-duration=30s", "--dry-run"}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected flags: (-want +got)\n%s", d)
+	}
+
+	var gotIssues []string
+	for _, i := range issues {
+		gotIssues = append(gotIssues, i.Severity+" "+i.Object)
+	}
+
+	wantIssues := []string{
+		"error --runner-status-update-hook",
+		"error --log-format",
+		"warning --github-api-cache-duration",
+		"error --upstream-only-flag",
+	}
+
+	if d := cmp.Diff(wantIssues, gotIssues); d != "" {
+		t.Errorf("unexpected issues: (-want +got)\n%s", d)
+	}
+}
+
+// TestControllerFlags ensures that the flags are validated against the actual flags of the controller,
+// by reading the flags defined in main.go.
+func TestControllerFlags(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "../../main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]bool{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "flag" {
+			return true
+		}
+
+		name, ok := call.Args[1].(*ast.BasicLit)
+		if !ok || name.Kind != token.STRING {
+			return true
+		}
+
+		unquoted, err := strconv.Unquote(name.Value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got[unquoted] = sel.Sel.Name == "BoolVar"
+
+		return true
+	})
+
+	if d := cmp.Diff(controllerFlags, got); d != "" {
+		t.Errorf("controllerFlags is out of sync with the flags defined in main.go: (-controllerFlags +main.go)\n%s", d)
+	}
+}
@@ -0,0 +1,16 @@
+package migrate
+
+import (
+	"github.com/actions-runner-controller/actions-runner-controller/pkg/validate"
+)
+
+// validationIssues validates the migrated custom resource with the same logic as `arc validate`.
+func validationIssues(obj interface{}) []Issue {
+	var issues []Issue
+
+	for _, i := range validate.Object(obj) {
+		issues = append(issues, Issue{Severity: i.Severity, Message: i.Message})
+	}
+
+	return issues
+}
@@ -0,0 +1,169 @@
+// Package podmutator is the in-process integration point for customizing the runner pods generated by the controller,
+// for the custom builds of the controller that need pod tweaks the runner specs can't express.
+//
+// A Mutator is registered under a name, typically from the init function of the package implementing it,
+// which is then linked into the controller binary with a blank import. Registered mutators are enabled by listing their names
+// in the --runner-pod-mutators flag, and applied to every runner pod in the order of the flag,
+// after the controller generated the pod from the runner spec and before the pod, or the statefulset of a RunnerSet, is created.
+//
+// Mutators must be deterministic, as the controller recreates the runner pods of a RunnerSet whenever the mutated pod changes.
+package podmutator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Target is the object the runner pod is generated for.
+type Target struct {
+	// Kind is either Runner or RunnerSet.
+	Kind      string
+	Namespace string
+	Name      string
+
+	RunnerConfig v1alpha1.RunnerConfig
+}
+
+// Mutator mutates the runner pod generated for the target.
+// An error prevents the pod from being created, and the target is reconciled again with the exponential backoff.
+type Mutator interface {
+	MutatePod(ctx context.Context, pod *corev1.Pod, target Target) error
+}
+
+// Func is a Mutator implemented by a function.
+type Func func(ctx context.Context, pod *corev1.Pod, target Target) error
+
+func (f Func) MutatePod(ctx context.Context, pod *corev1.Pod, target Target) error {
+	return f(ctx, pod, target)
+}
+
+// Factory creates the Mutator enabled via the --runner-pod-mutators flag, once on startup.
+type Factory func() (Mutator, error)
+
+var (
+	mu        sync.Mutex
+	factories = map[string]Factory{}
+)
+
+// Register registers the factory of the mutator under the name.
+// It panics when the name is empty, contains a comma, or is already registered, as that's a programming error.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name == "" || strings.Contains(name, ",") {
+		panic(fmt.Sprintf("podmutator: invalid mutator name %q", name))
+	}
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("podmutator: mutator %q is registered twice", name))
+	}
+
+	factories[name] = factory
+}
+
+// Registered returns the names of the registered mutators in alphabetical order.
+func Registered() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Chain applies the mutators in order.
+// The zero value and nil are the empty chain, which leaves the pods as is.
+type Chain struct {
+	names    []string
+	mutators []Mutator
+}
+
+// Build creates the chain of the registered mutators of the names, in the order of the names.
+func Build(names []string) (*Chain, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c := &Chain{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		factory, ok := factories[name]
+		if !ok {
+			var registered []string
+			for n := range factories {
+				registered = append(registered, n)
+			}
+
+			sort.Strings(registered)
+
+			return nil, fmt.Errorf("runner pod mutator %q isn't registered. The registered ones are %v", name, registered)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("runner pod mutator %q is enabled twice", name)
+		}
+
+		seen[name] = true
+
+		m, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("creating runner pod mutator %q: %w", name, err)
+		}
+
+		c.Add(name, m)
+	}
+
+	return c, nil
+}
+
+// Add appends the mutator to the chain.
+func (c *Chain) Add(name string, m Mutator) {
+	c.names = append(c.names, name)
+	c.mutators = append(c.mutators, m)
+}
+
+// Names returns the names of the mutators in the order they're applied.
+func (c *Chain) Names() []string {
+	if c == nil {
+		return nil
+	}
+
+	return append([]string{}, c.names...)
+}
+
+// Apply applies the mutators to the pod in order, each to the pod mutated by the previous ones.
+// The pod is left as is when any of the mutators fails, or changes the name or the namespace of the pod,
+// and the error names the mutator.
+func (c *Chain) Apply(ctx context.Context, pod *corev1.Pod, target Target) error {
+	if c == nil || len(c.mutators) == 0 {
+		return nil
+	}
+
+	mutated := pod.DeepCopy()
+
+	for i, m := range c.mutators {
+		if err := m.MutatePod(ctx, mutated, target); err != nil {
+			return fmt.Errorf("runner pod mutator %q: %w", c.names[i], err)
+		}
+
+		if mutated.Name != pod.Name || mutated.Namespace != pod.Namespace {
+			return fmt.Errorf("runner pod mutator %q: the name and the namespace of the pod must not be changed", c.names[i])
+		}
+	}
+
+	*pod = *mutated
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package podmutator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func appendAnnotation(value string) Func {
+	return func(ctx context.Context, pod *corev1.Pod, target Target) error {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+
+		pod.Annotations["order"] += value
+
+		return nil
+	}
+}
+
+func TestBuild(t *testing.T) {
+	Register("test-build-a", func() (Mutator, error) { return appendAnnotation("a"), nil })
+	Register("test-build-b", func() (Mutator, error) { return appendAnnotation("b"), nil })
+	Register("test-build-broken", func() (Mutator, error) { return nil, errors.New("missing config") })
+
+	c, err := Build([]string{"test-build-b", "test-build-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(c.Names(), ","); got != "test-build-b,test-build-a" {
+		t.Errorf("unexpected names: %s", got)
+	}
+
+	pod := &corev1.Pod{}
+	if err := c.Apply(context.Background(), pod, Target{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := pod.Annotations["order"]; got != "ba" {
+		t.Errorf("unexpected order of the mutators: %s", got)
+	}
+
+	for _, names := range [][]string{
+		{"test-build-unknown"},
+		{"test-build-a", "test-build-a"},
+		{"test-build-broken"},
+	} {
+		if _, err := Build(names); err == nil {
+			t.Errorf("%v: expected an error", names)
+		}
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	Register("test-register-duplicate", func() (Mutator, error) { return appendAnnotation("a"), nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic on the duplicate registration")
+		}
+	}()
+
+	Register("test-register-duplicate", func() (Mutator, error) { return appendAnnotation("a"), nil })
+}
+
+func TestChain_Apply(t *testing.T) {
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "runner", Namespace: "default"}}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var c *Chain
+
+		pod := newPod()
+		if err := c.Apply(context.Background(), pod, Target{}); err != nil || pod.Annotations != nil {
+			t.Errorf("unexpected result of the nil chain: %v, %v", err, pod.Annotations)
+		}
+	})
+
+	t.Run("target", func(t *testing.T) {
+		var got Target
+
+		c := &Chain{}
+		c.Add("target", Func(func(ctx context.Context, pod *corev1.Pod, target Target) error {
+			got = target
+			return nil
+		}))
+
+		want := Target{Kind: "RunnerSet", Namespace: "default", Name: "example"}
+
+		if err := c.Apply(context.Background(), newPod(), want); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got.Kind != want.Kind || got.Namespace != want.Namespace || got.Name != want.Name {
+			t.Errorf("unexpected target: %+v", got)
+		}
+	})
+
+	t.Run("error stops the chain and leaves the pod as is", func(t *testing.T) {
+		var called bool
+
+		c := &Chain{}
+		c.Add("first", appendAnnotation("a"))
+		c.Add("failing", Func(func(ctx context.Context, pod *corev1.Pod, target Target) error {
+			return errors.New("boom")
+		}))
+		c.Add("last", Func(func(ctx context.Context, pod *corev1.Pod, target Target) error {
+			called = true
+			return nil
+		}))
+
+		pod := newPod()
+
+		err := c.Apply(context.Background(), pod, Target{})
+		if err == nil || !strings.Contains(err.Error(), `"failing"`) || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if called {
+			t.Errorf("the mutator after the failing one was called")
+		}
+
+		if pod.Annotations != nil {
+			t.Errorf("the pod was mutated by the failed chain: %v", pod.Annotations)
+		}
+	})
+
+	t.Run("renaming the pod is rejected", func(t *testing.T) {
+		c := &Chain{}
+		c.Add("rename", Func(func(ctx context.Context, pod *corev1.Pod, target Target) error {
+			pod.Name = "other"
+			return nil
+		}))
+
+		pod := newPod()
+
+		if err := c.Apply(context.Background(), pod, Target{}); err == nil {
+			t.Errorf("expected an error")
+		}
+
+		if pod.Name != "runner" {
+			t.Errorf("the pod was renamed: %s", pod.Name)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+// Package provisioner implements the plugin mechanism for runners that are backed by something other than a pod,
+// like an EC2 Mac instance or a virtual machine.
+//
+// A provisioner is either an executable or an HTTP service operated outside of the controller.
+// The runner controller keeps handling the registration token, the busy check and the unregistration of the runner,
+// and sends a Request to the provisioner whenever it needs to create, inspect or destroy the machine that runs the runner agent.
+//
+// Executable provisioners receive the JSON-encoded Request on stdin and must write the JSON-encoded Instance to stdout.
+// HTTP provisioners receive the JSON-encoded Request as the body of a POST request and must respond
+// with the JSON-encoded Instance and the status code 200.
+//
+// Every action must be idempotent, as the controller may send the same request more than once.
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actions-runner-controller/actions-runner-controller/internal/plugin"
+)
+
+const DefaultTimeout = 60 * time.Second
+
+const (
+	// ActionProvision asks the provisioner to create a machine that runs the runner agent registered with Runner.Token.
+	ActionProvision = "provision"
+	// ActionStatus asks the provisioner for the current phase of the machine.
+	ActionStatus = "status"
+	// ActionDeprovision asks the provisioner to destroy the machine.
+	// The controller sends it only after the runner has been unregistered from GitHub, or is known to have stopped.
+	ActionDeprovision = "deprovision"
+)
+
+const (
+	PhasePending   = "Pending"
+	PhaseRunning   = "Running"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+	// PhaseDeleted must be returned once the machine has been completely destroyed.
+	// The controller keeps sending ActionDeprovision until it sees this phase.
+	PhaseDeleted = "Deleted"
+)
+
+// Request is sent to the provisioner.
+type Request struct {
+	Action string `json:"action"`
+	Runner Runner `json:"runner"`
+	// InstanceID is the ID returned by the provisioner on ActionProvision.
+	// It is empty for ActionProvision.
+	InstanceID string `json:"instanceID,omitempty"`
+}
+
+// Runner describes the runner to be run by the provisioned machine.
+type Runner struct {
+	Namespace    string   `json:"namespace"`
+	Name         string   `json:"name"`
+	Enterprise   string   `json:"enterprise,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Repository   string   `json:"repository,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	Group        string   `json:"group,omitempty"`
+	Ephemeral    bool     `json:"ephemeral"`
+	GitHubURL    string   `json:"githubURL"`
+	// Token is the registration token of the runner.
+	// It is set only for ActionProvision.
+	Token string `json:"token,omitempty"`
+}
+
+// Instance is returned by the provisioner.
+type Instance struct {
+	ID string `json:"id"`
+	// Phase is one of PhasePending, PhaseRunning, PhaseSucceeded, PhaseFailed and PhaseDeleted.
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+}
+
+// Provisioner creates, inspects and destroys machines that run runner agents.
+type Provisioner interface {
+	Provision(ctx context.Context, runner Runner) (*Instance, error)
+	Status(ctx context.Context, runner Runner, instanceID string) (*Instance, error)
+	Deprovision(ctx context.Context, runner Runner, instanceID string) (*Instance, error)
+}
+
+type caller interface {
+	call(ctx context.Context, req Request) ([]byte, error)
+}
+
+type provisioner struct {
+	caller
+}
+
+func (p provisioner) Provision(ctx context.Context, runner Runner) (*Instance, error) {
+	return p.do(ctx, Request{Action: ActionProvision, Runner: runner})
+}
+
+func (p provisioner) Status(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	runner.Token = ""
+
+	return p.do(ctx, Request{Action: ActionStatus, Runner: runner, InstanceID: instanceID})
+}
+
+func (p provisioner) Deprovision(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	runner.Token = ""
+
+	return p.do(ctx, Request{Action: ActionDeprovision, Runner: runner, InstanceID: instanceID})
+}
+
+func (p provisioner) do(ctx context.Context, req Request) (*Instance, error) {
+	data, err := p.call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeInstance(req.Action, data)
+}
+
+// Exec is a Provisioner that runs an executable for each request.
+type Exec struct {
+	Command []string
+	Timeout time.Duration
+}
+
+func (e *Exec) Provision(ctx context.Context, runner Runner) (*Instance, error) {
+	return provisioner{e}.Provision(ctx, runner)
+}
+
+func (e *Exec) Status(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	return provisioner{e}.Status(ctx, runner, instanceID)
+}
+
+func (e *Exec) Deprovision(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	return provisioner{e}.Deprovision(ctx, runner, instanceID)
+}
+
+func (e *Exec) call(ctx context.Context, req Request) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := plugin.Exec(ctx, e.Command, body, plugin.Timeout(e.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("running provisioner %q for %s: %w", strings.Join(e.Command, " "), req.Action, err)
+	}
+
+	return out, nil
+}
+
+// HTTP is a Provisioner that sends a POST request to an HTTP service for each request.
+type HTTP struct {
+	URL     string
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+func (h *HTTP) Provision(ctx context.Context, runner Runner) (*Instance, error) {
+	return provisioner{h}.Provision(ctx, runner)
+}
+
+func (h *HTTP) Status(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	return provisioner{h}.Status(ctx, runner, instanceID)
+}
+
+func (h *HTTP) Deprovision(ctx context.Context, runner Runner, instanceID string) (*Instance, error) {
+	return provisioner{h}.Deprovision(ctx, runner, instanceID)
+}
+
+func (h *HTTP) call(ctx context.Context, req Request) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := plugin.Do(ctx, h.Client, http.MethodPost, h.URL, body, plugin.Timeout(h.Timeout, DefaultTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("calling provisioner %s for %s: %w", h.URL, req.Action, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provisioner %s responded to %s with status %d: %s", h.URL, req.Action, res.StatusCode, string(res.Body))
+	}
+
+	return res.Body, nil
+}
+
+func decodeInstance(action string, data []byte) (*Instance, error) {
+	var instance Instance
+
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return nil, fmt.Errorf("decoding provisioner response to %s %q: %w", action, string(data), err)
+	}
+
+	switch instance.Phase {
+	case PhasePending, PhaseRunning, PhaseSucceeded, PhaseFailed, PhaseDeleted:
+	default:
+		return nil, fmt.Errorf("provisioner responded to %s with unknown phase %q", action, instance.Phase)
+	}
+
+	if action == ActionProvision && instance.ID == "" {
+		return nil, fmt.Errorf("provisioner responded to %s without instance id", action)
+	}
+
+	return &instance, nil
+}
+
+// Parse parses a provisioner definition in the NAME=COMMAND or NAME=URL format, like
+// `mac=/usr/local/bin/ec2-mac-provisioner --region us-west-2` or `mac=http://mac-provisioner.default.svc/`.
+func Parse(def string, timeout time.Duration) (string, Provisioner, error) {
+	kv := strings.SplitN(def, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || strings.TrimSpace(kv[1]) == "" {
+		return "", nil, fmt.Errorf("invalid runner provisioner %q: must be in the NAME=COMMAND or NAME=URL format", def)
+	}
+
+	name, target := kv[0], strings.TrimSpace(kv[1])
+
+	if plugin.IsURL(target) {
+		return name, &HTTP{URL: target, Timeout: timeout}, nil
+	}
+
+	return name, &Exec{Command: strings.Fields(target), Timeout: timeout}, nil
+}
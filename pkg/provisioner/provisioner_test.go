@@ -0,0 +1,103 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	_, p, err := Parse("mac=http://localhost:8080/", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*HTTP); !ok {
+		t.Errorf("unexpected provisioner type: %T", p)
+	}
+
+	name, p, err := Parse("mac=/usr/local/bin/ec2-mac-provisioner --region us-west-2", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "mac" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	if e, ok := p.(*Exec); !ok || len(e.Command) != 3 {
+		t.Errorf("unexpected provisioner: %#v", p)
+	}
+
+	if _, _, err := Parse("mac", 0); err == nil {
+		t.Errorf("expected error for a definition without a command")
+	}
+}
+
+func TestExec(t *testing.T) {
+	e := &Exec{Command: []string{"sh", "-c", `cat > /dev/null; echo '{"id": "i-123", "phase": "Pending"}'`}}
+
+	instance, err := e.Provision(context.Background(), Runner{Name: "example", Token: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if instance.ID != "i-123" || instance.Phase != PhasePending {
+		t.Errorf("unexpected instance: %+v", instance)
+	}
+
+	e = &Exec{Command: []string{"sh", "-c", `cat > /dev/null; echo '{"phase": "Unknown"}'`}}
+
+	if _, err := e.Status(context.Background(), Runner{Name: "example"}, "i-123"); err == nil {
+		t.Errorf("expected error for an unknown phase")
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	var requests []Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		requests = append(requests, req)
+
+		phase := PhaseRunning
+		if req.Action == ActionDeprovision {
+			phase = PhaseDeleted
+		}
+
+		json.NewEncoder(w).Encode(Instance{ID: "vm-1", Phase: phase})
+	}))
+	defer server.Close()
+
+	h := &HTTP{URL: server.URL}
+	runner := Runner{Name: "example", Token: "token"}
+
+	if _, err := h.Provision(context.Background(), runner); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	instance, err := h.Deprovision(context.Background(), runner, "vm-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if instance.Phase != PhaseDeleted {
+		t.Errorf("unexpected phase: %s", instance.Phase)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("unexpected number of requests: %d", len(requests))
+	}
+
+	if requests[0].Action != ActionProvision || requests[0].Runner.Token != "token" {
+		t.Errorf("unexpected provision request: %+v", requests[0])
+	}
+
+	if requests[1].Action != ActionDeprovision || requests[1].InstanceID != "vm-1" || requests[1].Runner.Token != "" {
+		t.Errorf("unexpected deprovision request: %+v", requests[1])
+	}
+}
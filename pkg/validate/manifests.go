@@ -0,0 +1,343 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Result is the outcome of a validation.
+type Result struct {
+	Files   int     `json:"files"`
+	Objects int     `json:"objects"`
+	Issues  []Issue `json:"issues"`
+}
+
+// HasErrors returns true when at least one issue would make the custom resources rejected or misbehave.
+func (r *Result) HasErrors() bool {
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarnings returns true when at least one issue is a warning.
+func (r *Result) HasWarnings() bool {
+	for _, i := range r.Issues {
+		if i.Severity == SeverityWarning {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Result) addIssue(severity string, o *object, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Severity: severity, File: o.file, Object: o.id(), Message: fmt.Sprintf(format, args...)})
+}
+
+type typeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+type object struct {
+	file      string
+	kind      string
+	namespace string
+	name      string
+
+	obj interface{}
+}
+
+func (o *object) id() string {
+	return fmt.Sprintf("%s %s/%s", o.kind, o.namespace, o.name)
+}
+
+type validator struct {
+	namespace string
+
+	res     *Result
+	objects []*object
+}
+
+// Path validates the custom resources in the YAML file at path, or in all the YAML files under the directory at path.
+// namespace is used for custom resources that don't have metadata.namespace, like `kubectl apply -n` does.
+// Documents that are not actions-runner-controller custom resources are ignored.
+func Path(path, namespace string) (*Result, error) {
+	v := &validator{namespace: namespace, res: &Result{}}
+
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if ext := filepath.Ext(p); p != path && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		return v.addFile(p, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v.check()
+
+	return v.res, nil
+}
+
+// Manifests validates the custom resources in the multi-document YAML stream.
+func Manifests(r io.Reader, namespace string) (*Result, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifests: %w", err)
+	}
+
+	v := &validator{namespace: namespace, res: &Result{}}
+
+	if err := v.addFile("", data); err != nil {
+		return nil, err
+	}
+
+	v.check()
+
+	return v.res, nil
+}
+
+func (v *validator) addFile(file string, data []byte) error {
+	v.res.Files++
+
+	r := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+
+	for {
+		doc, err := r.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		if err := v.addDocument(file, doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (v *validator) addDocument(file string, doc []byte) error {
+	var tm typeMeta
+
+	if err := yaml.Unmarshal(doc, &tm); err != nil {
+		return fmt.Errorf("parsing manifest in %s: %w", file, err)
+	}
+
+	if tm.Kind == "" || !strings.HasPrefix(tm.APIVersion, v1alpha1.GroupVersion.Group+"/") {
+		return nil
+	}
+
+	o := &object{file: file, kind: tm.Kind, namespace: tm.Metadata.Namespace, name: tm.Metadata.Name}
+	if o.namespace == "" {
+		o.namespace = v.namespace
+	}
+
+	v.res.Objects++
+
+	if tm.APIVersion != v1alpha1.GroupVersion.String() {
+		v.res.addIssue(SeverityError, o, "unsupported apiVersion %q: it must be %q", tm.APIVersion, v1alpha1.GroupVersion.String())
+		return nil
+	}
+
+	switch tm.Kind {
+	case "Runner":
+		o.obj = &v1alpha1.Runner{}
+	case "RunnerDeployment":
+		o.obj = &v1alpha1.RunnerDeployment{}
+	case "RunnerReplicaSet":
+		o.obj = &v1alpha1.RunnerReplicaSet{}
+	case "RunnerSet":
+		o.obj = &v1alpha1.RunnerSet{}
+	case "HorizontalRunnerAutoscaler":
+		o.obj = &v1alpha1.HorizontalRunnerAutoscaler{}
+	default:
+		v.res.addIssue(SeverityError, o, "unsupported kind %q", tm.Kind)
+		return nil
+	}
+
+	if o.name == "" {
+		v.res.addIssue(SeverityError, o, "metadata.name is required")
+	}
+
+	if err := yaml.UnmarshalStrict(doc, o.obj); err != nil {
+		v.res.addIssue(SeverityWarning, o, "unknown fields are pruned by the API server: %v", err)
+
+		if err := yaml.Unmarshal(doc, o.obj); err != nil {
+			v.res.addIssue(SeverityError, o, "invalid manifest: %v", err)
+			return nil
+		}
+	}
+
+	Default(o.obj)
+
+	for _, i := range Object(o.obj) {
+		i.File = o.file
+		i.Object = o.id()
+		v.res.Issues = append(v.res.Issues, i)
+	}
+
+	v.objects = append(v.objects, o)
+
+	return nil
+}
+
+// check runs the validations across the custom resources.
+func (v *validator) check() {
+	seen := map[string]*object{}
+
+	for _, o := range v.objects {
+		if first, ok := seen[o.id()]; ok {
+			v.res.addIssue(SeverityError, o, "defined more than once, first in %s", first.file)
+			continue
+		}
+
+		seen[o.id()] = o
+
+		switch obj := o.obj.(type) {
+		case *v1alpha1.RunnerDeployment:
+			v.checkSelector(o, "spec.selector", obj.Spec.Selector, obj.Spec.Template.Labels)
+		case *v1alpha1.RunnerReplicaSet:
+			v.checkSelector(o, "spec.selector", obj.Spec.Selector, obj.Spec.Template.Labels)
+		case *v1alpha1.RunnerSet:
+			v.checkSelector(o, "spec.selector", obj.Spec.Selector, obj.Spec.Template.Labels)
+		}
+	}
+
+	targeted := map[string]*object{}
+	// webhookScaled is the list of the scale targets of HRAs with scaleUpTriggers, keyed by the scope and the labels of the runners.
+	webhookScaled := map[string][]*object{}
+
+	for _, o := range v.objects {
+		hra, ok := o.obj.(*v1alpha1.HorizontalRunnerAutoscaler)
+		if !ok || hra.Spec.ScaleTargetRef.Name == "" {
+			continue
+		}
+
+		kind := hra.Spec.ScaleTargetRef.Kind
+		if kind == "" {
+			kind = "RunnerDeployment"
+		}
+
+		key := fmt.Sprintf("%s %s/%s", kind, o.namespace, hra.Spec.ScaleTargetRef.Name)
+
+		target, ok := seen[key]
+		if !ok {
+			v.res.addIssue(SeverityError, o, "spec.scaleTargetRef: %s is not found in the manifests", key)
+			continue
+		}
+
+		if other, ok := targeted[key]; ok {
+			v.res.addIssue(SeverityError, o, "spec.scaleTargetRef: %s is already scaled by %s", key, other.id())
+			continue
+		}
+
+		targeted[key] = o
+
+		if len(hra.Spec.ScaleUpTriggers) == 0 {
+			continue
+		}
+
+		var rc v1alpha1.RunnerConfig
+		switch t := target.obj.(type) {
+		case *v1alpha1.RunnerDeployment:
+			rc = t.Spec.Template.Spec.RunnerConfig
+		case *v1alpha1.RunnerSet:
+			rc = t.Spec.RunnerConfig
+		default:
+			continue
+		}
+
+		runnerLabels := append([]string{}, rc.Labels...)
+		sort.Strings(runnerLabels)
+
+		poolKey := fmt.Sprintf("%s/%s/%s %s %s", rc.Enterprise, rc.Organization, rc.Repository, rc.Group, strings.Join(runnerLabels, ","))
+
+		webhookScaled[poolKey] = append(webhookScaled[poolKey], target)
+	}
+
+	var poolKeys []string
+	for k := range webhookScaled {
+		poolKeys = append(poolKeys, k)
+	}
+	sort.Strings(poolKeys)
+
+	for _, k := range poolKeys {
+		targets := webhookScaled[k]
+		if len(targets) < 2 {
+			continue
+		}
+
+		var ids []string
+		for _, t := range targets {
+			ids = append(ids, t.id())
+		}
+
+		for _, t := range targets {
+			v.res.addIssue(SeverityWarning, t, "the scope and the labels of the runners are the same as %s. Only one of them is scaled on webhook events", strings.Join(without(ids, t.id()), ", "))
+		}
+	}
+}
+
+func (v *validator) checkSelector(o *object, field string, selector *metav1.LabelSelector, templateLabels map[string]string) {
+	if selector == nil {
+		return
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		v.res.addIssue(SeverityError, o, "%s: %v", field, err)
+		return
+	}
+
+	if !s.Matches(labels.Set(templateLabels)) {
+		v.res.addIssue(SeverityError, o, "%s doesn't match the labels of spec.template", field)
+	}
+}
+
+func without(items []string, item string) []string {
+	var res []string
+
+	for _, i := range items {
+		if i != item {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
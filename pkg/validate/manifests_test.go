@@ -0,0 +1,190 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManifests(t *testing.T) {
+	in := `apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: linux
+spec:
+  template:
+    spec:
+      organization: example
+      labels: [linux]
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: linux-2
+spec:
+  selector:
+    matchLabels:
+      app: linux-2
+  template:
+    metadata:
+      labels:
+        app: linux
+    spec:
+      organization: example
+      labels: [linux]
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: noscope
+spec:
+  template:
+    spec:
+      labels: [mac]
+      unknownField: true
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: linux
+spec:
+  scaleTargetRef:
+    name: linux
+  minReplicas: 1
+  maxReplicas: 3
+  scaleUpTriggers:
+  - githubEvent:
+      workflowJob: {}
+    duration: 30m
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: linux-2
+spec:
+  scaleTargetRef:
+    name: linux-2
+  minReplicas: 1
+  maxReplicas: 3
+  scaleUpTriggers:
+  - githubEvent:
+      workflowJob: {}
+    duration: 30m
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: missing
+spec:
+  scaleTargetRef:
+    kind: RunnerSet
+    name: missing
+  minReplicas: 3
+  maxReplicas: 1
+---
+apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: linux-again
+spec:
+  scaleTargetRef:
+    name: linux
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+	res, err := Manifests(strings.NewReader(in), "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Objects != 7 {
+		t.Errorf("unexpected number of objects: want 7, got %d", res.Objects)
+	}
+
+	var got []string
+	for _, i := range res.Issues {
+		got = append(got, i.String())
+	}
+
+	want := []string{
+		"warning: RunnerDeployment default/noscope: unknown fields are pruned by the API server: error unmarshaling JSON: while decoding JSON: json: unknown field \"unknownField\"",
+		"error: RunnerDeployment default/noscope: spec.template.spec.repository: Invalid value: \"\": Spec needs enterprise, organization or repository",
+		"error: HorizontalRunnerAutoscaler default/missing: spec.minReplicas 3 is greater than spec.maxReplicas 1",
+		"error: RunnerDeployment default/linux-2: spec.selector doesn't match the labels of spec.template",
+		"error: HorizontalRunnerAutoscaler default/missing: spec.scaleTargetRef: RunnerSet default/missing is not found in the manifests",
+		"error: HorizontalRunnerAutoscaler default/linux-again: spec.scaleTargetRef: RunnerDeployment default/linux is already scaled by HorizontalRunnerAutoscaler default/linux",
+		"warning: RunnerDeployment default/linux: the scope and the labels of the runners are the same as RunnerDeployment default/linux-2. Only one of them is scaled on webhook events",
+		"warning: RunnerDeployment default/linux-2: the scope and the labels of the runners are the same as RunnerDeployment default/linux. Only one of them is scaled on webhook events",
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unexpected issues: (-want +got)\n%s", d)
+	}
+
+	if !res.HasErrors() {
+		t.Error("expected errors")
+	}
+}
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"rd.yaml": `apiVersion: actions.summerwind.dev/v1alpha1
+kind: RunnerDeployment
+metadata:
+  name: example
+  namespace: runners
+spec:
+  template:
+    spec:
+      repository: example/app
+`,
+		"hra/hra.yml": `apiVersion: actions.summerwind.dev/v1alpha1
+kind: HorizontalRunnerAutoscaler
+metadata:
+  name: example
+  namespace: runners
+spec:
+  scaleTargetRef:
+    name: example
+  minReplicas: 1
+  maxReplicas: 3
+  metrics:
+  - type: PercentageRunnersBusy
+`,
+		"README.md": "not a manifest",
+	}
+
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := Path(dir, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Files != 2 || res.Objects != 2 {
+		t.Errorf("unexpected number of files and objects: files=%d objects=%d", res.Files, res.Objects)
+	}
+
+	if len(res.Issues) != 0 {
+		t.Errorf("unexpected issues: %v", res.Issues)
+	}
+}
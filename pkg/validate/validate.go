@@ -0,0 +1,183 @@
+// Package validate checks actions-runner-controller custom resources offline,
+// without a Kubernetes API server or the controller's admission webhooks.
+//
+// Each custom resource is defaulted and validated the same way as the admission webhooks do,
+// and the whole set of custom resources is checked for inconsistencies that the webhooks can't detect
+// as they see one object at a time, like a HorizontalRunnerAutoscaler whose scale target doesn't exist.
+package validate
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a single problem found in a custom resource.
+type Issue struct {
+	Severity string `json:"severity"`
+	// File is the path to the file that contains the custom resource, if known.
+	File string `json:"file,omitempty"`
+	// Object identifies the custom resource, like `RunnerDeployment default/example`.
+	Object  string `json:"object,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.File == "" {
+		return fmt.Sprintf("%s: %s: %s", i.Severity, i.Object, i.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s: %s", i.Severity, i.File, i.Object, i.Message)
+}
+
+// Default applies the defaulting of the mutating admission webhook of the custom resource, if any.
+func Default(obj interface{}) {
+	if d, ok := obj.(interface{ Default() }); ok {
+		d.Default()
+	}
+}
+
+// Object validates a single custom resource.
+// Runner, RunnerDeployment, and RunnerReplicaSet are validated by the same logic as their validating admission webhooks.
+// RunnerSet and HorizontalRunnerAutoscaler, which don't have validating admission webhooks,
+// are validated against the constraints the controller enforces on reconciliation.
+func Object(obj interface{}) []Issue {
+	switch o := obj.(type) {
+	case *v1alpha1.Runner:
+		return validationIssues(o.Validate())
+	case *v1alpha1.RunnerDeployment:
+		return validationIssues(o.Validate())
+	case *v1alpha1.RunnerReplicaSet:
+		return validationIssues(o.Validate())
+	case *v1alpha1.RunnerSet:
+		return validateRunnerSet(*o)
+	case *v1alpha1.HorizontalRunnerAutoscaler:
+		return validateHorizontalRunnerAutoscaler(*o)
+	}
+
+	return []Issue{{Severity: SeverityError, Message: fmt.Sprintf("unsupported type %T", obj)}}
+}
+
+func validationIssues(err error) []Issue {
+	if err == nil {
+		return nil
+	}
+
+	if statusErr, ok := err.(*apierrors.StatusError); ok && statusErr.ErrStatus.Details != nil {
+		var issues []Issue
+
+		for _, c := range statusErr.ErrStatus.Details.Causes {
+			issues = append(issues, Issue{Severity: SeverityError, Message: fmt.Sprintf("%s: %s", c.Field, c.Message)})
+		}
+
+		if len(issues) > 0 {
+			return issues
+		}
+	}
+
+	return []Issue{{Severity: SeverityError, Message: err.Error()}}
+}
+
+func validateRunnerSet(rs v1alpha1.RunnerSet) []Issue {
+	spec := v1alpha1.RunnerSpec{RunnerConfig: rs.Spec.RunnerConfig}
+
+	if err := spec.ValidateRepository(); err != nil {
+		return []Issue{{Severity: SeverityError, Message: fmt.Sprintf("spec.repository: %v", err)}}
+	}
+
+	return nil
+}
+
+func validateHorizontalRunnerAutoscaler(hra v1alpha1.HorizontalRunnerAutoscaler) []Issue {
+	var issues []Issue
+
+	add := func(severity, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if hra.Spec.ScaleTargetRef.Name == "" {
+		add(SeverityError, "spec.scaleTargetRef.name is required")
+	}
+
+	switch kind := hra.Spec.ScaleTargetRef.Kind; kind {
+	case "", "RunnerDeployment", "RunnerSet":
+	default:
+		add(SeverityError, "spec.scaleTargetRef.kind %q is not supported", kind)
+	}
+
+	if len(hra.Spec.Metrics) > 0 || len(hra.Spec.ScaleUpTriggers) > 0 {
+		if hra.Spec.MinReplicas == nil {
+			add(SeverityError, "spec.minReplicas is required when metrics or scaleUpTriggers are configured")
+		}
+
+		if hra.Spec.MaxReplicas == nil {
+			add(SeverityError, "spec.maxReplicas is required when metrics or scaleUpTriggers are configured")
+		}
+	}
+
+	if hra.Spec.MinReplicas != nil && hra.Spec.MaxReplicas != nil && *hra.Spec.MinReplicas > *hra.Spec.MaxReplicas {
+		add(SeverityError, "spec.minReplicas %d is greater than spec.maxReplicas %d", *hra.Spec.MinReplicas, *hra.Spec.MaxReplicas)
+	}
+
+	if d := hra.Spec.ScaleDownDelaySecondsAfterScaleUp; d != nil && *d < 0 {
+		add(SeverityError, "spec.scaleDownDelaySecondsAfterScaleOut must not be negative, but got %d", *d)
+	}
+
+	var activeMetrics int
+	for _, m := range hra.Spec.Metrics {
+		if !m.Suspended {
+			activeMetrics++
+		}
+	}
+
+	switch policy := hra.Spec.MetricsCombinationPolicy; policy {
+	case "":
+		if n := activeMetrics; n > 2 {
+			add(SeverityError, "spec.metrics must have 0 to 2 entries that aren't suspended unless spec.metricsCombinationPolicy is set, but got %d", n)
+		}
+	case v1alpha1.MetricsCombinationPolicyMax,
+		v1alpha1.MetricsCombinationPolicyMin,
+		v1alpha1.MetricsCombinationPolicySum,
+		v1alpha1.MetricsCombinationPolicyAverage:
+	default:
+		add(SeverityError, "spec.metricsCombinationPolicy %q is not supported", policy)
+	}
+
+	if n := len(hra.Spec.Metrics); n > 0 && activeMetrics == 0 {
+		add(SeverityWarning, "all the %d entries of spec.metrics are suspended, so minReplicas is desired until any of them is resumed", n)
+	}
+
+	for i, m := range hra.Spec.Metrics {
+		switch m.Type {
+		case v1alpha1.AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns,
+			v1alpha1.AutoscalingMetricTypePercentageRunnersBusy,
+			v1alpha1.AutoscalingMetricTypeQueuedJobsPlusBusyRunners,
+			v1alpha1.AutoscalingMetricTypeQueuedJobWaitTime:
+		case v1alpha1.AutoscalingMetricTypeQueuedJobsPerRunner:
+			if t := m.TargetQueuedJobsPerRunner; t != "" {
+				if v, err := strconv.ParseFloat(t, 64); err != nil || v <= 0 {
+					add(SeverityError, "spec.metrics[%d].targetQueuedJobsPerRunner must be a number greater than 0, but got %q", i, t)
+				}
+			}
+		case v1alpha1.AutoscalingMetricTypeExternal:
+			if m.External == nil || m.External.Provider == "" {
+				add(SeverityError, "spec.metrics[%d].external.provider is required for the External metric type", i)
+			}
+		default:
+			add(SeverityError, "spec.metrics[%d].type %q is not supported", i, m.Type)
+		}
+
+		if d := m.ScaleDownDelaySecondsAfterScaleOut; d != nil && *d < 0 {
+			add(SeverityError, "spec.metrics[%d].scaleDownDelaySecondsAfterScaleOut must not be negative, but got %d", i, *d)
+		}
+	}
+
+	return issues
+}
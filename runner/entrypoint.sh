@@ -46,8 +46,8 @@ else
   exit 1
 fi
 
-if [ -z "${RUNNER_TOKEN}" ]; then
-  log.error 'RUNNER_TOKEN must be set'
+if [ -z "${RUNNER_TOKEN}" ] && [ -z "${RUNNER_JIT_CONFIG:-}" ]; then
+  log.error 'Either RUNNER_TOKEN or RUNNER_JIT_CONFIG must be set'
   exit 1
 fi
 
@@ -75,6 +75,28 @@ fi
 cd ${RUNNER_HOME}
 # past that point, it's all relative pathes from /runner
 
+if [ -n "${RUNNER_CA_CERTIFICATES_DIR:-}" ]; then
+  log.debug "Adding the CA certificates in ${RUNNER_CA_CERTIFICATES_DIR} to the trust store"
+  # update-ca-certificates reads the local certificates from /usr/local/share/ca-certificates only
+  sudo mkdir -p /usr/local/share/ca-certificates/runner
+  if ! sudo cp -L "${RUNNER_CA_CERTIFICATES_DIR}"/*.crt /usr/local/share/ca-certificates/runner/ || ! sudo update-ca-certificates; then
+    # we don't register a runner whose jobs would fail on the TLS errors
+    log.error 'Failed to add the CA certificates to the trust store!'
+    exit 1
+  fi
+  # node doesn't read the system trust store
+  export NODE_EXTRA_CA_CERTS=/etc/ssl/certs/ca-certificates.crt
+fi
+
+if [ -n "${RUNNER_PRE_RUN_SCRIPT:-}" ]; then
+  log.debug "Running the pre-run script ${RUNNER_PRE_RUN_SCRIPT}"
+  if ! bash "${RUNNER_PRE_RUN_SCRIPT}"; then
+    # we don't register a runner that isn't set up as expected
+    log.error 'Pre-run script failed!'
+    exit 1
+  fi
+fi
+
 config_args=()
 if [ "${RUNNER_FEATURE_FLAG_EPHEMERAL:-}" == "true" -a "${RUNNER_EPHEMERAL}" == "true" ]; then
   config_args+=(--ephemeral)
@@ -85,34 +107,40 @@ if [ "${DISABLE_RUNNER_UPDATE:-}" == "true" ]; then
   log.debug 'Passing --disableupdate to config.sh to disable automatic runner updates.'
 fi
 
-retries_left=10
-while [[ ${retries_left} -gt 0 ]]; do
-  log.debug 'Configuring the runner.'
-  ./config.sh --unattended --replace \
-    --name "${RUNNER_NAME}" \
-    --url "${GITHUB_URL}${ATTACH}" \
-    --token "${RUNNER_TOKEN}" \
-    --runnergroup "${RUNNER_GROUPS}" \
-    --labels "${RUNNER_LABELS}" \
-    --work "${RUNNER_WORKDIR}" "${config_args[@]}"
-
-  if [ -f .runner ]; then
-    log.debug 'Runner successfully configured.'
-    break
+if [ -n "${RUNNER_JIT_CONFIG:-}" ]; then
+  # The runner is pre-registered by whoever generated the JIT config, so there's nothing to configure.
+  log.debug 'Skipping the runner configuration as RUNNER_JIT_CONFIG is set.'
+else
+  retries_left=10
+  while [[ ${retries_left} -gt 0 ]]; do
+    log.debug 'Configuring the runner.'
+    ./config.sh --unattended --replace \
+      --name "${RUNNER_NAME}" \
+      --url "${GITHUB_URL}${ATTACH}" \
+      --token "${RUNNER_TOKEN}" \
+      --runnergroup "${RUNNER_GROUPS}" \
+      --labels "${RUNNER_LABELS}" \
+      --work "${RUNNER_WORKDIR}" "${config_args[@]}"
+
+    if [ -f .runner ]; then
+      log.debug 'Runner successfully configured.'
+      break
+    fi
+
+    log.debug 'Configuration failed. Retrying'
+    retries_left=$((retries_left - 1))
+    sleep 1
+  done
+
+  if [ ! -f .runner ]; then
+    # we couldn't configure and register the runner; no point continuing
+    log.error 'Configuration failed!'
+    exit 2
   fi
 
-  log.debug 'Configuration failed. Retrying'
-  retries_left=$((retries_left - 1))
-  sleep 1
-done
-
-if [ ! -f .runner ]; then
-  # we couldn't configure and register the runner; no point continuing
-  log.error 'Configuration failed!'
-  exit 2
+  cat .runner
 fi
 
-cat .runner
 # Note: the `.runner` file's content should be something like the below:
 #
 # $ cat /runner/.runner
@@ -141,7 +169,9 @@ if [ -z "${UNITTEST:-}" ]; then
 fi
 
 args=()
-if [ "${RUNNER_FEATURE_FLAG_EPHEMERAL:-}" != "true" -a "${RUNNER_EPHEMERAL}" == "true" ]; then
+if [ -n "${RUNNER_JIT_CONFIG:-}" ]; then
+  args+=(--jitconfig "${RUNNER_JIT_CONFIG}")
+elif [ "${RUNNER_FEATURE_FLAG_EPHEMERAL:-}" != "true" -a "${RUNNER_EPHEMERAL}" == "true" ]; then
   args+=(--once)
   log.warning 'Passing --once is deprecated and will be removed as an option' \
     'from the image and actions-runner-controller at the release of 0.24.0.' \
@@ -149,8 +179,41 @@ if [ "${RUNNER_FEATURE_FLAG_EPHEMERAL:-}" != "true" -a "${RUNNER_EPHEMERAL}" ==
     'you are using github.com ignore this warning.'
 fi
 
+if [ -n "${RUNNER_SUSPENSION_FILE:-}" ]; then
+  # The runner is in the warm pool. It stays registered but offline so that no job is assigned to it,
+  # until the controller activates it by updating the pod annotation projected into the file.
+  log.debug "Waiting for the runner to be activated via ${RUNNER_SUSPENSION_FILE}"
+  while [ "$(cat "${RUNNER_SUSPENSION_FILE}" 2>/dev/null)" == "true" ]; do
+    sleep 1
+  done
+  log.debug 'Runner activated.'
+fi
+
+if [[ "${DOCKER_ENABLED}" == "true" ]] && [[ "${DOCKER_RESTART_POLICY:-}" == "FailFast" ]]; then
+  # Stop the runner once dockerd stops responding, so that the in-flight job fails right away
+  # instead of running against a crashed dockerd. The runner pod is then recreated by the controller.
+  log.debug 'Watching dockerd to stop the runner on its loss, as DOCKER_RESTART_POLICY is FailFast'
+  runner_pid=$$
+  (
+    failures=0
+    while sleep "${DOCKER_WATCH_INTERVAL_IN_SECONDS:-5}"; do
+      if docker info >/dev/null 2>&1; then
+        failures=0
+        continue
+      fi
+
+      failures=$((failures + 1))
+      if [[ ${failures} -ge 3 ]]; then
+        log.error 'Docker daemon is not responding. Stopping the runner.'
+        kill -TERM "${runner_pid}"
+        exit 0
+      fi
+    done
+  ) &
+fi
+
 # Unset entrypoint environment variables so they don't leak into the runner environment
-unset RUNNER_NAME RUNNER_REPO RUNNER_TOKEN STARTUP_DELAY_IN_SECONDS DISABLE_WAIT_FOR_DOCKER
+unset RUNNER_NAME RUNNER_REPO RUNNER_TOKEN RUNNER_JIT_CONFIG STARTUP_DELAY_IN_SECONDS DISABLE_WAIT_FOR_DOCKER RUNNER_SUSPENSION_FILE RUNNER_PRE_RUN_SCRIPT RUNNER_CA_CERTIFICATES_DIR
 
 # Docker ignores PAM and thus never loads the system environment variables that
 # are meant to be set in every environment of every user. We emulate the PAM
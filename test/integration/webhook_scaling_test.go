@@ -0,0 +1,348 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	actionsv1alpha1 "github.com/actions-runner-controller/actions-runner-controller/api/v1alpha1"
+	"github.com/actions-runner-controller/actions-runner-controller/controllers"
+	"github.com/actions-runner-controller/actions-runner-controller/github"
+	"github.com/actions-runner-controller/actions-runner-controller/github/fake"
+	gogithub "github.com/google/go-github/v39/github"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const webhookSecret = "integration-test-secret"
+
+type webhookScalingEnvironment struct {
+	Namespace *corev1.Namespace
+
+	webhookServer    *httptest.Server
+	fakeGithubServer *httptest.Server
+	deliveries       int
+}
+
+// setupWebhookScalingTest runs the horizontalrunnerautoscaler controller and the github webhook server
+// in a new namespace for each test.
+// Unlike the integration tests in the controllers package, the webhook server requires every payload to be signed
+// with webhookSecret, as it does in production.
+func setupWebhookScalingTest() *webhookScalingEnvironment {
+	var (
+		ctx    context.Context
+		cancel func()
+	)
+
+	ns := &corev1.Namespace{}
+	env := &webhookScalingEnvironment{Namespace: ns}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+
+		*ns = corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "webhook-scaling-"},
+		}
+
+		err := k8sClient.Create(ctx, ns)
+		Expect(err).NotTo(HaveOccurred(), "failed to create test namespace")
+
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Namespace:          ns.Name,
+			MetricsBindAddress: "0",
+		})
+		Expect(err).NotTo(HaveOccurred(), "failed to create manager")
+
+		env.fakeGithubServer = fake.NewServer()
+
+		autoscalerController := &controllers.HorizontalRunnerAutoscalerReconciler{
+			Client:        mgr.GetClient(),
+			Scheme:        scheme.Scheme,
+			Log:           logf.Log,
+			GitHubClient:  newGithubClient(env.fakeGithubServer),
+			Recorder:      mgr.GetEventRecorderFor("horizontalrunnerautoscaler-controller"),
+			CacheDuration: 1 * time.Second,
+			Name:          ns.Name + "horizontalrunnerautoscaler",
+		}
+		err = autoscalerController.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred(), "failed to setup autoscaler controller")
+
+		autoscalerWebhook := &controllers.HorizontalRunnerAutoscalerGitHubWebhook{
+			Client:         mgr.GetClient(),
+			Scheme:         scheme.Scheme,
+			Log:            logf.Log,
+			Recorder:       mgr.GetEventRecorderFor("horizontalrunnerautoscaler-controller"),
+			Name:           ns.Name + "horizontalrunnerautoscalergithubwebhook",
+			Namespace:      ns.Name,
+			SecretKeyBytes: []byte(webhookSecret),
+		}
+		err = autoscalerWebhook.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred(), "failed to setup autoscaler webhook")
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", autoscalerWebhook.Handle)
+
+		env.webhookServer = httptest.NewServer(mux)
+
+		go func() {
+			defer GinkgoRecover()
+
+			err := mgr.Start(ctx)
+			Expect(err).NotTo(HaveOccurred(), "failed to start manager")
+		}()
+	})
+
+	AfterEach(func() {
+		defer cancel()
+
+		env.fakeGithubServer.Close()
+		env.webhookServer.Close()
+
+		err := k8sClient.Delete(ctx, ns)
+		Expect(err).NotTo(HaveOccurred(), "failed to delete test namespace")
+	})
+
+	return env
+}
+
+var _ = Context("INTEGRATION: Webhook-driven scaling", func() {
+	env := setupWebhookScalingTest()
+	ns := env.Namespace
+
+	const name = "example-runnerdeploy"
+
+	BeforeEach(func() {
+		ctx := context.Background()
+
+		rd := &actionsv1alpha1.RunnerDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+			},
+			Spec: actionsv1alpha1.RunnerDeploymentSpec{
+				Replicas: intPtr(1),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"foo": "bar"},
+				},
+				Template: actionsv1alpha1.RunnerTemplate{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"foo": "bar"},
+					},
+					Spec: actionsv1alpha1.RunnerSpec{
+						RunnerConfig: actionsv1alpha1.RunnerConfig{
+							Organization: "test",
+							Image:        "bar",
+							Labels:       []string{"linux"},
+						},
+					},
+				},
+			},
+		}
+
+		err := k8sClient.Create(ctx, rd)
+		Expect(err).NotTo(HaveOccurred(), "failed to create test RunnerDeployment")
+
+		hra := &actionsv1alpha1.HorizontalRunnerAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+			},
+			Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+				ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{
+					Name: name,
+				},
+				MinReplicas:                       intPtr(1),
+				MaxReplicas:                       intPtr(3),
+				ScaleDownDelaySecondsAfterScaleUp: intPtr(1),
+				ScaleUpTriggers: []actionsv1alpha1.ScaleUpTrigger{
+					{
+						GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+							WorkflowJob: &actionsv1alpha1.WorkflowJobSpec{},
+						},
+						Amount:   1,
+						Duration: metav1.Duration{Duration: time.Minute},
+					},
+				},
+			},
+		}
+
+		err = k8sClient.Create(ctx, hra)
+		Expect(err).NotTo(HaveOccurred(), "failed to create test HorizontalRunnerAutoscaler")
+
+		env.ExpectRunnerDeploymentReplicasEventuallyEquals(name, 1, "replicas before any webhook event")
+	})
+
+	It("should scale the runner deployment up and down on signed workflow_job events", func() {
+		// Scale up by one per queued job
+		{
+			env.SendWorkflowJobEvent(1, "queued", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasEventuallyEquals(name, 2, "replicas after first queued job")
+
+			env.SendWorkflowJobEvent(2, "queued", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasEventuallyEquals(name, 3, "replicas after second queued job")
+		}
+
+		// Never scale beyond maxReplicas
+		{
+			env.SendWorkflowJobEvent(3, "queued", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasConsistentlyEquals(name, 3, "replicas after third queued job")
+		}
+
+		// Jobs that can't run on the runner deployment don't affect the replicas
+		{
+			env.SendWorkflowJobEvent(4, "queued", []string{"self-hosted", "gpu"})
+			env.SendWorkflowJobEvent(4, "completed", []string{"self-hosted", "gpu"})
+			env.ExpectRunnerDeploymentReplicasConsistentlyEquals(name, 3, "replicas after a job for another runner pool")
+		}
+
+		// Scale down by one per completed job.
+		// The first completion doesn't change the replicas, as the reservation for the third job was capped by maxReplicas.
+		{
+			env.SendWorkflowJobEvent(1, "completed", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasConsistentlyEquals(name, 3, "replicas after first completed job")
+
+			env.SendWorkflowJobEvent(2, "completed", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasEventuallyEquals(name, 2, "replicas after second completed job")
+
+			env.SendWorkflowJobEvent(3, "completed", []string{"self-hosted", "linux"})
+			env.ExpectRunnerDeploymentReplicasEventuallyEquals(name, 1, "replicas after all the jobs completed")
+		}
+	})
+
+	It("should not scale the runner deployment on workflow_job events with invalid signatures", func() {
+		resp, err := env.sendWebhook("workflow_job", workflowJobEvent(1, "queued", []string{"self-hosted", "linux"}), "wrong-secret")
+		Expect(err).NotTo(HaveOccurred(), "failed to send workflow_job event")
+		Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+		env.ExpectRunnerDeploymentReplicasConsistentlyEquals(name, 1, "replicas after a workflow_job event with an invalid signature")
+	})
+})
+
+func (env *webhookScalingEnvironment) SendWorkflowJobEvent(id int64, action string, labels []string) {
+	resp, err := env.sendWebhook("workflow_job", workflowJobEvent(id, action, labels), webhookSecret)
+
+	ExpectWithOffset(1, err).NotTo(HaveOccurred(), "failed to send workflow_job event")
+
+	ExpectWithOffset(1, resp.StatusCode).To(Equal(http.StatusOK))
+}
+
+func (env *webhookScalingEnvironment) ExpectRunnerDeploymentReplicasEventuallyEquals(name string, want int, optionalDescription ...interface{}) {
+	EventuallyWithOffset(1, env.runnerDeploymentReplicas(name), time.Second*10, time.Millisecond*500).Should(Equal(want), optionalDescription...)
+}
+
+// ExpectRunnerDeploymentReplicasConsistentlyEquals is used to assert that the replicas don't change over time,
+// which can't be done with Eventually as the HRA controller reconciles asynchronously.
+func (env *webhookScalingEnvironment) ExpectRunnerDeploymentReplicasConsistentlyEquals(name string, want int, optionalDescription ...interface{}) {
+	ConsistentlyWithOffset(1, env.runnerDeploymentReplicas(name), time.Second*3, time.Millisecond*500).Should(Equal(want), optionalDescription...)
+}
+
+func (env *webhookScalingEnvironment) runnerDeploymentReplicas(name string) func() int {
+	return func() int {
+		var rd actionsv1alpha1.RunnerDeployment
+
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: env.Namespace.Name, Name: name}, &rd); err != nil {
+			logf.Log.Error(err, "get runner deployment")
+			return -1
+		}
+
+		if rd.Spec.Replicas == nil {
+			return -1
+		}
+
+		return *rd.Spec.Replicas
+	}
+}
+
+func workflowJobEvent(id int64, action string, labels []string) *gogithub.WorkflowJobEvent {
+	return &gogithub.WorkflowJobEvent{
+		WorkflowJob: &gogithub.WorkflowJob{
+			ID:         gogithub.Int64(id),
+			RunID:      gogithub.Int64(id),
+			Status:     gogithub.String(action),
+			Conclusion: conclusion(action),
+			Labels:     labels,
+		},
+		Org: &gogithub.Organization{
+			Login: gogithub.String("test"),
+		},
+		Repo: &gogithub.Repository{
+			Name: gogithub.String("valid"),
+			Owner: &gogithub.User{
+				Login: gogithub.String("test"),
+				Type:  gogithub.String("Organization"),
+			},
+		},
+		Action: gogithub.String(action),
+	}
+}
+
+func conclusion(action string) *string {
+	if action != "completed" {
+		return nil
+	}
+
+	return gogithub.String("success")
+}
+
+// sendWebhook sends the event signed with the secret in the same way as GitHub does,
+// so that the webhook server validates it with the X-Hub-Signature-256 header.
+func (env *webhookScalingEnvironment) sendWebhook(eventType string, event interface{}, secret string) (*http.Response, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	env.deliveries++
+
+	req, err := http.NewRequest(http.MethodPost, env.webhookServer.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", fmt.Sprintf("delivery-%d", env.deliveries))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Content-Type", "application/json")
+
+	return http.DefaultClient.Do(req)
+}
+
+func newGithubClient(server *httptest.Server) *github.Client {
+	c := github.Config{
+		Token: "token",
+	}
+	client, err := c.NewClient()
+	if err != nil {
+		panic(err)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		panic(err)
+	}
+	client.Client.BaseURL = baseURL
+
+	return client
+}
+
+func intPtr(v int) *int {
+	return &v
+}